@@ -3,9 +3,11 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
 	"time"
@@ -17,16 +19,28 @@ import (
 	"github.com/popeskul/payment-gateway/internal/api"
 	"github.com/popeskul/payment-gateway/internal/auth"
 	"github.com/popeskul/payment-gateway/internal/config"
+	"github.com/popeskul/payment-gateway/internal/core/domain/ratelimit"
+	"github.com/popeskul/payment-gateway/internal/core/domain/user"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
 	"github.com/popeskul/payment-gateway/internal/core/services"
 	"github.com/popeskul/payment-gateway/internal/hasher"
 	"github.com/popeskul/payment-gateway/internal/infrastructure/acquiringbank"
+	"github.com/popeskul/payment-gateway/internal/infrastructure/database/memory"
 	"github.com/popeskul/payment-gateway/internal/infrastructure/database/postgres"
+	"github.com/popeskul/payment-gateway/internal/infrastructure/filestore"
+	"github.com/popeskul/payment-gateway/internal/infrastructure/fxrates"
+	"github.com/popeskul/payment-gateway/internal/infrastructure/mailer"
 	"github.com/popeskul/payment-gateway/internal/infrastructure/metrics"
+	"github.com/popeskul/payment-gateway/internal/infrastructure/settlement"
 	"github.com/popeskul/payment-gateway/internal/infrastructure/uuid"
 	"github.com/popeskul/payment-gateway/internal/logger"
+	"github.com/popeskul/payment-gateway/internal/worker"
 )
 
 func main() {
+	storage := flag.String("storage", "postgres", `storage backend, "postgres" or "memory"`)
+	flag.Parse()
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
@@ -37,57 +51,215 @@ func main() {
 		log.Fatalf("Failed to create logger: %v", err)
 	}
 
-	db, err := postgres.NewDatabase(&cfg.Database)
-	if err != nil {
-		logger.Error("Failed to connect to database", "error", err)
-		os.Exit(1)
-	}
-	defer db.Close()
+	uuidGenerator := uuid.NewUUIDGenerator()
 
-	migrationsPath := "file://./migrations"
-	dbURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.Database.User, cfg.Database.Password, cfg.Database.Host,
-		cfg.Database.Port, cfg.Database.DBName)
+	var repos ports.Repositories
+	var tokenStore ports.TokenStore
+	var locker ports.Locker
+	var rateLimitStore ports.RateLimitStore
+	switch *storage {
+	case "postgres":
+		db, err := postgres.NewDatabase(&cfg.Database)
+		if err != nil {
+			logger.Error("Failed to connect to database", "error", err)
+			os.Exit(1)
+		}
+		defer db.Close()
 
-	m, err := migrate.New(migrationsPath, dbURL)
-	if err != nil {
-		logger.Error("Error creating migrate instance", "error", err)
-		os.Exit(1)
-	}
+		migrationsPath := "file://./migrations"
+		dbURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+			cfg.Database.User, cfg.Database.Password, cfg.Database.Host,
+			cfg.Database.Port, cfg.Database.DBName)
 
-	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		logger.Error("Error applying migrations", "error", err)
+		m, err := migrate.New(migrationsPath, dbURL)
+		if err != nil {
+			logger.Error("Error creating migrate instance", "error", err)
+			os.Exit(1)
+		}
+
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			logger.Error("Error applying migrations", "error", err)
+			os.Exit(1)
+		}
+
+		logger.Info("Migrations applied successfully")
+
+		repos = postgres.NewRepositories(db, uuidGenerator)
+		tokenStore = postgres.NewPostgresTokenStore(db.Pool)
+		locker = postgres.NewAdvisoryLocker(db, logger)
+
+		switch cfg.RateLimits.Store {
+		case "postgres":
+			rateLimitStore = postgres.NewRateLimitStore(db)
+		case "memory":
+			rateLimitStore = memory.NewRateLimitStore()
+		default:
+			logger.Error("Unknown rate limit store", "store", cfg.RateLimits.Store)
+			os.Exit(1)
+		}
+	case "memory":
+		// Nothing survives a restart, and replicas do not share data or locks.
+		store := memory.NewStore()
+		repos = memory.NewRepositories(store, uuidGenerator)
+		tokenStore = memory.NewTokenStore(store, uuidGenerator)
+		locker = memory.NewLocker()
+		rateLimitStore = memory.NewRateLimitStore()
+		logger.Info("Using in-memory storage")
+	default:
+		logger.Error("Unknown storage backend", "storage", *storage)
 		os.Exit(1)
 	}
 
-	logger.Info("Migrations applied successfully")
+	merchantRepo := repos.Merchants()
+	paymentRepo := repos.Payments()
+	refundRepo := repos.Refunds()
+	userRepo := repos.Users()
+	reconciliationRepo := repos.Reconciliations()
+	subscriptionRepo := repos.Subscriptions()
+	customerRepo := repos.Customers()
+	memberRepo := repos.Members()
+	apiKeyRepo := repos.APIKeys()
+	onboardingRepo := repos.Onboarding()
+	auditRepo := repos.AuditLog()
+	oauthClientRepo := repos.OAuthClients()
 
-	uuidGenerator := uuid.NewUUIDGenerator()
+	// There is no live acquirer integration yet, so live traffic also goes to a
+	// simulator; test traffic always does.
+	acquiringBank := acquiringbank.NewModeRouter(
+		acquiringbank.NewAcquiringBankSimulator(logger, 200*time.Millisecond, 0.05),
+		acquiringbank.NewAcquiringBankSimulator(logger, 200*time.Millisecond, 0.05),
+	)
 
-	merchantRepo := postgres.NewMerchantRepository(db, uuidGenerator)
-	paymentRepo := postgres.NewPaymentRepository(db, uuidGenerator)
-	refundRepo := postgres.NewRefundRepository(db, uuidGenerator)
-	userRepo := postgres.NewUserRepository(db, uuidGenerator)
+	fxRateProvider := fxrates.NewCachedRateProvider(fxrates.NewStaticRateProvider(cfg.FX.RatesFile), cfg.FX.CacheBucket)
+
+	settlementSource := settlement.NewCSVFileSource(cfg.Reconciliation.SettlementDir, settlement.CSVFormat{
+		Delimiter:         cfg.Reconciliation.CSV.Delimiter,
+		TimeLayout:        cfg.Reconciliation.CSV.TimeLayout,
+		ReferenceColumn:   cfg.Reconciliation.CSV.ReferenceColumn,
+		TypeColumn:        cfg.Reconciliation.CSV.TypeColumn,
+		AmountColumn:      cfg.Reconciliation.CSV.AmountColumn,
+		CurrencyColumn:    cfg.Reconciliation.CSV.CurrencyColumn,
+		ProcessedAtColumn: cfg.Reconciliation.CSV.ProcessedAtColumn,
+		PaymentType:       cfg.Reconciliation.CSV.PaymentType,
+		RefundType:        cfg.Reconciliation.CSV.RefundType,
+	})
+
+	documentStore := filestore.NewLocalFileStore(cfg.Documents.Dir)
+
+	passwordHasher := hasher.NewArgon2idPasswordHasher(hasher.Argon2Params{
+		Memory:      cfg.Auth.PasswordHashing.Memory,
+		Iterations:  cfg.Auth.PasswordHashing.Iterations,
+		Parallelism: cfg.Auth.PasswordHashing.Parallelism,
+	})
+
+	var breachedPasswords user.BreachedPasswords
+	if cfg.Auth.PasswordPolicy.BreachedPasswordsFile != "" {
+		breachedPasswords, err = hasher.LoadBreachedPasswords(cfg.Auth.PasswordPolicy.BreachedPasswordsFile)
+		if err != nil {
+			logger.Error("Failed to load breached password list", "error", err)
+			os.Exit(1)
+		}
+	}
 
-	tokenStore := postgres.NewPostgresTokenStore(db.Pool)
+	var mail ports.Mailer
+	switch cfg.Mail.Driver {
+	case "smtp":
+		mail = mailer.NewSMTPMailer(cfg.Mail.SMTP.Host, cfg.Mail.SMTP.Port, cfg.Mail.SMTP.Username, cfg.Mail.SMTP.Password, cfg.Mail.From)
+	case "file":
+		mail = mailer.NewFileMailer(cfg.Mail.From, cfg.Mail.Dir)
+	default:
+		logger.Error("Unknown mail driver", "driver", cfg.Mail.Driver)
+		os.Exit(1)
+	}
 
-	acquiringBank := acquiringbank.NewAcquiringBankSimulator(logger, 200*time.Millisecond, 0.05)
+	merchantService := services.NewMerchantService(merchantRepo, memberRepo, apiKeyRepo, subscriptionRepo, oauthClientRepo, logger, cfg.APIKeys.SigningPepper)
+	paymentService := services.NewPaymentService(paymentRepo, merchantRepo, customerRepo, acquiringBank, fxRateProvider, logger)
+	refundService := services.NewRefundService(refundRepo, paymentRepo, merchantRepo, acquiringBank, logger)
+	userService := services.NewUserService(userRepo, logger, passwordHasher, mail, services.UserServiceConfig{
+		AppURL:          cfg.Mail.AppURL,
+		VerificationTTL: cfg.Auth.EmailVerificationTTL,
+		ResetTTL:        cfg.Auth.PasswordResetTTL,
+		TOTPIssuer:      cfg.Auth.TOTPIssuer,
+		MFATTL:          cfg.Auth.MFATokenTTL,
+		Lockout: user.LockoutPolicy{
+			Window:           cfg.Auth.Lockout.Window,
+			DelayAfter:       cfg.Auth.Lockout.DelayAfter,
+			BaseDelay:        cfg.Auth.Lockout.BaseDelay,
+			MaxDelay:         cfg.Auth.Lockout.MaxDelay,
+			AccountThreshold: cfg.Auth.Lockout.AccountThreshold,
+			IPThreshold:      cfg.Auth.Lockout.IPThreshold,
+			LockoutDuration:  cfg.Auth.Lockout.Duration,
+		},
+		Passwords: user.PasswordPolicy{
+			MinLength:           cfg.Auth.PasswordPolicy.MinLength,
+			MaxLength:           cfg.Auth.PasswordPolicy.MaxLength,
+			MinCharacterClasses: cfg.Auth.PasswordPolicy.MinCharacterClasses,
+			Breached:            breachedPasswords,
+		},
+	})
+	reconciliationService := services.NewReconciliationService(reconciliationRepo, paymentRepo, refundRepo, settlementSource, locker, logger)
+	memberService := services.NewMemberService(memberRepo, userRepo, merchantRepo, logger)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, logger, cfg.APIKeys.RotationGracePeriod, cfg.APIKeys.SignatureMaxClockSkew, cfg.APIKeys.SigningPepper)
+	onboardingService := services.NewOnboardingService(merchantRepo, onboardingRepo, documentStore, logger, cfg.Documents.MaxSize)
+	exportService := services.NewMerchantExportService(merchantRepo, memberRepo, apiKeyRepo, onboardingRepo, customerRepo, paymentRepo, refundRepo, subscriptionRepo, logger)
+	auditService := services.NewAuditService(auditRepo, logger)
+	oauthService := services.NewOAuthService(oauthClientRepo, logger)
+	customerService := services.NewCustomerService(customerRepo, merchantRepo, paymentRepo, logger)
+	subscriptionService := services.NewSubscriptionService(subscriptionRepo, paymentService, locker, logger, cfg.Subscriptions.BatchSize, cfg.Subscriptions.RetryIntervals)
+	paymentSweeper := services.NewPaymentSweeper(paymentRepo, acquiringBank, locker, logger, cfg.Sweeper.PendingTTL, cfg.Sweeper.BatchSize)
 
-	passwordHasher := hasher.NewBcryptPasswordHasher()
+	var signingKeys *auth.KeyRing
+	if len(cfg.Auth.SigningKeys) > 0 {
+		keys := make([]*auth.SigningKey, 0, len(cfg.Auth.SigningKeys))
+		for _, keyCfg := range cfg.Auth.SigningKeys {
+			key, err := auth.LoadSigningKey(keyCfg.ID, keyCfg.Algorithm, keyCfg.PrivateKeyFile, keyCfg.ActivatesAt, keyCfg.RetiresAt)
+			if err != nil {
+				logger.Error("Failed to load signing key", "error", err)
+				os.Exit(1)
+			}
+			keys = append(keys, key)
+		}
 
-	merchantService := services.NewMerchantService(merchantRepo, logger)
-	paymentService := services.NewPaymentService(paymentRepo, acquiringBank, logger)
-	refundService := services.NewRefundService(refundRepo, paymentRepo, logger)
-	userService := services.NewUserService(userRepo, logger, passwordHasher)
+		signingKeys, err = auth.NewKeyRing(keys...)
+		if err != nil {
+			logger.Error("Invalid signing keys", "error", err)
+			os.Exit(1)
+		}
+		if _, err := signingKeys.SigningKey(time.Now()); err != nil {
+			logger.Error("Invalid signing keys", "error", err)
+			os.Exit(1)
+		}
+	}
 
-	jwtManager := auth.NewJWTManager(&cfg.Auth, tokenStore)
+	jwtManager := auth.NewJWTManager(&cfg.Auth, tokenStore, signingKeys)
 
 	metrics.InitMetrics()
 
+	var trustedProxies []netip.Prefix
+	for _, cidr := range cfg.Server.TrustedProxies {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			logger.Error("Invalid trusted proxy range", "error", err, "range", cidr)
+			os.Exit(1)
+		}
+		trustedProxies = append(trustedProxies, prefix)
+	}
+
+	rateLimits := api.RateLimits{
+		Auth:      rateLimitPolicy(cfg.RateLimits.Auth),
+		Dashboard: rateLimitPolicy(cfg.RateLimits.Dashboard),
+		API:       rateLimitPolicy(cfg.RateLimits.API),
+	}
+	if cfg.RateLimits.Enabled {
+		rateLimits.Store = rateLimitStore
+	}
+
 	router := api.NewRouter(
-		services.NewServices(merchantService, paymentService, refundService, userService),
+		services.NewServices(merchantService, paymentService, refundService, userService, reconciliationService, subscriptionService, customerService, memberService, apiKeyService, onboardingService, exportService, auditService, oauthService),
 		logger,
 		jwtManager,
+		trustedProxies,
+		rateLimits,
 	)
 
 	srv := &http.Server{
@@ -95,6 +267,46 @@ func main() {
 		Handler: router,
 	}
 
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	go worker.RunPeriodically(workerCtx, cfg.Auth.SessionSyncInterval, logger, "revoked_sessions_sync", func(ctx context.Context) error {
+		return jwtManager.SyncRevokedSessions()
+	})
+
+	go worker.RunPeriodically(workerCtx, cfg.APIKeys.SignatureMaxClockSkew, logger, "api_key_nonce_prune", func(ctx context.Context) error {
+		_, err := apiKeyService.PruneNonces(ctx)
+		return err
+	})
+
+	if cfg.RateLimits.Enabled {
+		go worker.RunPeriodically(workerCtx, cfg.RateLimits.PruneInterval, logger, "rate_limit_prune", func(ctx context.Context) error {
+			_, err := rateLimitStore.Prune(ctx, time.Now().Add(-cfg.RateLimits.PruneAfter))
+			return err
+		})
+	}
+
+	if cfg.Reconciliation.Enabled {
+		go worker.RunPeriodically(workerCtx, cfg.Reconciliation.Interval, logger, "reconciliation", func(ctx context.Context) error {
+			_, err := reconciliationService.RunReconciliation(ctx)
+			return err
+		})
+	}
+
+	if cfg.Sweeper.Enabled {
+		go worker.RunPeriodically(workerCtx, cfg.Sweeper.Interval, logger, "payment_sweeper", func(ctx context.Context) error {
+			_, err := paymentSweeper.Sweep(ctx)
+			return err
+		})
+	}
+
+	if cfg.Subscriptions.Enabled {
+		go worker.RunPeriodically(workerCtx, cfg.Subscriptions.Interval, logger, "subscription_billing", func(ctx context.Context) error {
+			_, err := subscriptionService.RunBilling(ctx)
+			return err
+		})
+	}
+
 	go func() {
 		logger.Info("Starting server", "port", cfg.Server.Port)
 		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -107,6 +319,7 @@ func main() {
 	signal.Notify(quit, os.Interrupt)
 	<-quit
 	logger.Info("Shutting down server...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -116,3 +329,13 @@ func main() {
 
 	logger.Info("Server exiting")
 }
+
+func rateLimitPolicy(c config.RateLimitPolicyConfig) ratelimit.Policy {
+	return ratelimit.Policy{
+		IP:          ratelimit.Limit{Requests: c.IP.Requests, Period: c.IP.Period},
+		User:        ratelimit.Limit{Requests: c.User.Requests, Period: c.User.Period},
+		APIKey:      ratelimit.Limit{Requests: c.APIKey.Requests, Period: c.APIKey.Period},
+		OAuthClient: ratelimit.Limit{Requests: c.OAuthClient.Requests, Period: c.OAuthClient.Period},
+		Merchant:    ratelimit.Limit{Requests: c.Merchant.Requests, Period: c.Merchant.Period},
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+)
+
+// CreateAPIKey issues a new key. The response is the only time the secret is
+// returned.
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionAPIKeysManage) || !h.requireVerifiedEmail(w, r) {
+		return
+	}
+
+	var req apikey.CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode api key request", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+	key, err := h.services.APIKeys().CreateKey(r.Context(), id, userID, &req)
+	if err != nil {
+		h.logger.Error("Failed to create api key", "error", err, "merchant_id", id)
+		http.Error(w, "Failed to create API key: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditCreated(r, id, "api_key", key.ID, key.APIKey)
+	respondJSON(w, http.StatusCreated, key)
+}
+
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionAPIKeysManage) {
+		return
+	}
+
+	keys, err := h.services.APIKeys().ListKeys(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to list api keys", "error", err, "merchant_id", id)
+		http.Error(w, "Failed to list API keys", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, keys)
+}
+
+func (h *Handler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	keyID := chi.URLParam(r, "keyID")
+	if !h.authorize(w, r, id, member.PermissionAPIKeysManage) || !h.requireVerifiedEmail(w, r) {
+		return
+	}
+
+	var req apikey.RotateRequest
+	// The body is optional; without it the configured grace period applies.
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.logger.Error("Failed to decode rotate request", "error", err)
+			http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	userID := r.Context().Value("userID").(string)
+	key, err := h.services.APIKeys().RotateKey(r.Context(), id, keyID, userID, &req)
+	if err != nil {
+		h.logger.Error("Failed to rotate api key", "error", err, "id", keyID)
+		http.Error(w, "Failed to rotate API key: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditSetTarget(r, "api_key", keyID)
+	respondJSON(w, http.StatusCreated, key)
+}
+
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	keyID := chi.URLParam(r, "keyID")
+	if !h.authorize(w, r, id, member.PermissionAPIKeysManage) {
+		return
+	}
+
+	if err := h.services.APIKeys().RevokeKey(r.Context(), id, keyID); err != nil {
+		h.logger.Error("Failed to revoke api key", "error", err, "id", keyID)
+		http.Error(w, "Failed to revoke API key: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditSetTarget(r, "api_key", keyID)
+	respondJSON(w, http.StatusOK, map[string]string{"message": "API key revoked successfully"})
+}
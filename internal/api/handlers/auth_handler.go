@@ -2,9 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 
+	"github.com/popeskul/payment-gateway/internal/auth"
 	"github.com/popeskul/payment-gateway/internal/core/domain/user"
+	"github.com/popeskul/payment-gateway/internal/domain"
 	"github.com/popeskul/payment-gateway/internal/infrastructure/metrics"
 )
 
@@ -18,11 +24,15 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	newUser, err := h.services.Users().Register(r.Context(), &registerRequest)
 	if err != nil {
 		h.logger.Error("Failed to register user", "error", err)
+		if errors.Is(err, user.ErrWeakPassword) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		h.respondError(w, http.StatusInternalServerError, "Failed to register user")
 		return
 	}
 
-	accessToken, refreshToken, err := h.JWTManager.GenerateTokenPair(newUser.ID)
+	accessToken, refreshToken, err := h.JWTManager.GenerateTokenPair(newUser.ID, sessionClient(r))
 	if err != nil {
 		h.logger.Error("Failed to generate token pair", "error", err)
 		h.respondError(w, http.StatusInternalServerError, "Failed to generate tokens")
@@ -42,7 +52,10 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.services.Users().Login(r.Context(), &loginRequest)
+	user, err := h.services.Users().Login(r.Context(), &loginRequest, remoteIP(r))
+	if h.loginThrottled(w, err) {
+		return
+	}
 	if err != nil {
 		h.logger.Error("Failed to login", "error", err)
 		h.respondError(w, http.StatusUnauthorized, "Invalid credentials")
@@ -50,7 +63,68 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, refreshToken, err := h.JWTManager.GenerateTokenPair(user.ID)
+	// Users with two-factor authentication get the tokens from LoginTwoFactor.
+	if user.TwoFactorEnabled() {
+		mfaToken, err := h.services.Users().BeginTwoFactorLogin(r.Context(), user.ID)
+		if err != nil {
+			h.logger.Error("Failed to begin two-factor login", "error", err)
+			h.respondError(w, http.StatusInternalServerError, "Failed to login")
+			return
+		}
+
+		h.respondJSON(w, http.StatusOK, map[string]interface{}{
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+		})
+		return
+	}
+
+	h.issueLoginTokens(w, r, user.ID)
+}
+
+// LoginTwoFactor completes a login with the MFA token from Login and a TOTP
+// or recovery code.
+func (h *Handler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var loginRequest user.TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&loginRequest); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	user, err := h.services.Users().CompleteTwoFactorLogin(r.Context(), &loginRequest, remoteIP(r))
+	if h.loginThrottled(w, err) {
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to complete two-factor login", "error", err)
+		h.respondError(w, http.StatusUnauthorized, "Invalid MFA token or code")
+		metrics.AuthenticationAttempts.WithLabelValues("failed").Inc()
+		return
+	}
+
+	h.issueLoginTokens(w, r, user.ID)
+}
+
+// loginThrottled responds with 429 and reports true if err is a
+// *user.LoginThrottledError.
+func (h *Handler) loginThrottled(w http.ResponseWriter, err error) bool {
+	var throttled *user.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+
+	h.logger.Warn("Login throttled", "error", err)
+	if throttled.LockedNow {
+		metrics.LoginLockouts.WithLabelValues(string(throttled.Scope)).Inc()
+	}
+	metrics.AuthenticationAttempts.WithLabelValues("throttled").Inc()
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	h.respondError(w, http.StatusTooManyRequests, "Too many failed login attempts, try again later")
+	return true
+}
+
+func (h *Handler) issueLoginTokens(w http.ResponseWriter, r *http.Request, userID string) {
+	accessToken, refreshToken, err := h.JWTManager.GenerateTokenPair(userID, sessionClient(r))
 	if err != nil {
 		h.logger.Error("Failed to generate token pair", "error", err)
 		h.respondError(w, http.StatusInternalServerError, "Failed to generate tokens")
@@ -75,14 +149,17 @@ func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	claims, err := h.JWTManager.ValidateAccessToken(refreshRequest.RefreshToken)
-	if err != nil {
-		h.respondError(w, http.StatusUnauthorized, "Invalid refresh token")
-		return
-	}
-
-	newAccessToken, newRefreshToken, err := h.JWTManager.RefreshTokens(claims.UserID, refreshRequest.RefreshToken)
+	newAccessToken, newRefreshToken, err := h.JWTManager.RefreshTokens(refreshRequest.RefreshToken, sessionClient(r))
 	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRefreshTokenReused):
+			h.logger.Warn("Refresh token reuse detected, token family revoked", "ip", remoteIP(r))
+			h.respondError(w, http.StatusUnauthorized, "Invalid refresh token")
+			return
+		case errors.Is(err, auth.ErrInvalidToken), errors.Is(err, auth.ErrExpiredToken):
+			h.respondError(w, http.StatusUnauthorized, "Invalid refresh token")
+			return
+		}
 		h.logger.Error("Failed to refresh tokens", "error", err)
 		h.respondError(w, http.StatusInternalServerError, "Failed to refresh tokens")
 		return
@@ -94,6 +171,13 @@ func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// JWKS publishes the public keys access tokens are signed with, so that other
+// services can verify them.
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	h.respondJSON(w, http.StatusOK, h.JWTManager.JWKS())
+}
+
 func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("userID").(string)
 	refreshToken := r.Header.Get("X-Refresh-Token")
@@ -150,13 +234,184 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 
 	if err := h.services.Users().ChangePassword(r.Context(), userID, &changePasswordRequest); err != nil {
 		h.logger.Error("Failed to change password", "error", err)
+		if errors.Is(err, user.ErrWeakPassword) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		h.respondError(w, http.StatusInternalServerError, "Failed to change password")
 		return
 	}
 
+	// Only the session that knew the old password stays logged in.
+	if _, err := h.JWTManager.RevokeOtherSessions(userID, currentSessionID(r)); err != nil {
+		h.logger.Error("Failed to revoke sessions", "error", err, "user_id", userID)
+		h.respondError(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
 	h.respondJSON(w, http.StatusOK, map[string]string{"message": "Password successfully changed"})
 }
 
+// ResendVerificationEmail mails the authenticated user a new verification link.
+func (h *Handler) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	if err := h.services.Users().SendVerificationEmail(r.Context(), userID); err != nil {
+		h.logger.Error("Failed to send verification email", "error", err)
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]string{"message": "Verification email sent"})
+}
+
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var verifyRequest user.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&verifyRequest); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := h.services.Users().VerifyEmail(r.Context(), &verifyRequest); err != nil {
+		h.logger.Error("Failed to verify email", "error", err)
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]string{"message": "Email address verified"})
+}
+
+// ForgotPassword always answers the same way, whether or not the address
+// belongs to a user.
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var forgotRequest user.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&forgotRequest); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := h.services.Users().RequestPasswordReset(r.Context(), &forgotRequest); err != nil {
+		h.logger.Error("Failed to request password reset", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "Failed to request password reset")
+		return
+	}
+
+	h.respondJSON(w, http.StatusAccepted, map[string]string{"message": "If the address belongs to an account, a reset link has been sent"})
+}
+
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var resetRequest user.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&resetRequest); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	userID, err := h.services.Users().ResetPassword(r.Context(), &resetRequest)
+	if err != nil {
+		h.logger.Error("Failed to reset password", "error", err)
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Whoever knew the old password may still be logged in.
+	if _, err := h.JWTManager.RevokeAllSessions(userID); err != nil {
+		h.logger.Error("Failed to revoke sessions", "error", err, "user_id", userID)
+		h.respondError(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]string{"message": "Password successfully reset"})
+}
+
+// EnrollTwoFactor returns a new TOTP secret and its provisioning URI.
+// Two-factor authentication is on once ConfirmTwoFactor accepts a code.
+func (h *Handler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	enrollment, err := h.services.Users().EnrollTwoFactor(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to enroll two-factor authentication", "error", err)
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, enrollment)
+}
+
+func (h *Handler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	var codeRequest user.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&codeRequest); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	codes, err := h.services.Users().ConfirmTwoFactor(r.Context(), userID, &codeRequest)
+	if err != nil {
+		h.logger.Error("Failed to confirm two-factor authentication", "error", err)
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string][]string{"recovery_codes": codes})
+}
+
+func (h *Handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	var disableRequest user.DisableTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&disableRequest); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := h.services.Users().DisableTwoFactor(r.Context(), userID, &disableRequest); err != nil {
+		h.logger.Error("Failed to disable two-factor authentication", "error", err)
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	var codeRequest user.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&codeRequest); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	codes, err := h.services.Users().RegenerateRecoveryCodes(r.Context(), userID, &codeRequest)
+	if err != nil {
+		h.logger.Error("Failed to regenerate recovery codes", "error", err)
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string][]string{"recovery_codes": codes})
+}
+
+// requireVerifiedEmail guards sensitive operations: creating or deleting a
+// merchant, inviting members and issuing API keys.
+func (h *Handler) requireVerifiedEmail(w http.ResponseWriter, r *http.Request) bool {
+	userID, _ := r.Context().Value("userID").(string)
+
+	u, err := h.services.Users().GetUserByID(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get user", "error", err, "id", userID)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+	if !u.EmailVerified() {
+		http.Error(w, "Email address is not verified", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
 func (h *Handler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -166,3 +421,17 @@ func (h *Handler) respondJSON(w http.ResponseWriter, status int, data interface{
 func (h *Handler) respondError(w http.ResponseWriter, status int, message string) {
 	h.respondJSON(w, status, map[string]string{"error": message})
 }
+
+func sessionClient(r *http.Request) domain.SessionClient {
+	return domain.SessionClient{UserAgent: r.UserAgent(), IP: remoteIP(r)}
+}
+
+// remoteIP strips the port from RemoteAddr, which the RealIP middleware has
+// already replaced with the forwarded address when there is one.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
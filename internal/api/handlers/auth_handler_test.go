@@ -4,16 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
+	"github.com/popeskul/payment-gateway/internal/auth"
 	"github.com/popeskul/payment-gateway/internal/core/domain/user"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/domain"
 )
 
 func TestHandler_GetProfile(t *testing.T) {
@@ -91,3 +95,265 @@ func TestHandler_GetProfile(t *testing.T) {
 		assert.Equal(t, "Failed to get user profile", responseBody["error"])
 	})
 }
+
+func TestHandler_Register(t *testing.T) {
+	tests := []struct {
+		name           string
+		registerErr    error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:           "Password rejected by policy",
+			registerErr:    fmt.Errorf("%w: it must be at least 12 characters long", user.ErrWeakPassword),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"error": "password does not meet the password policy: it must be at least 12 characters long"},
+		},
+		{
+			name:           "Other failure",
+			registerErr:    errors.New("failed to create user"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   map[string]interface{}{"error": "Failed to register user"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockServices := ports.NewMockServices(ctrl)
+			mockUserService := ports.NewMockUserService(ctrl)
+			mockLogger := ports.NewMockLogger(ctrl)
+			mockServices.EXPECT().Users().Return(mockUserService)
+			mockUserService.EXPECT().Register(gomock.Any(), gomock.Any()).Return(nil, tt.registerErr)
+			mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+			h := NewHandler(mockServices, mockLogger, ports.NewMockJWTManager(ctrl))
+
+			req, _ := http.NewRequest("POST", "/register", strings.NewReader(`{"email":"test@example.com","password":"short"}`))
+			rr := httptest.NewRecorder()
+
+			h.Register(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			var responseBody map[string]interface{}
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &responseBody))
+			assert.Equal(t, tt.expectedBody, responseBody)
+		})
+	}
+}
+
+func TestHandler_ResetPassword(t *testing.T) {
+	tests := []struct {
+		name           string
+		resetErr       error
+		revokeErr      error
+		expectedStatus int
+	}{
+		{name: "Revokes every session", expectedStatus: http.StatusOK},
+		{name: "Invalid token", resetErr: errors.New("invalid or expired token"), expectedStatus: http.StatusBadRequest},
+		{name: "Sessions not revoked", revokeErr: errors.New("database error"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockServices := ports.NewMockServices(ctrl)
+			mockUserService := ports.NewMockUserService(ctrl)
+			mockJWTManager := ports.NewMockJWTManager(ctrl)
+			mockLogger := ports.NewMockLogger(ctrl)
+			mockServices.EXPECT().Users().Return(mockUserService)
+			mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+			mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+			if tt.resetErr != nil {
+				mockUserService.EXPECT().ResetPassword(gomock.Any(), gomock.Any()).Return("", tt.resetErr)
+				mockJWTManager.EXPECT().RevokeAllSessions(gomock.Any()).Times(0)
+			} else {
+				mockUserService.EXPECT().ResetPassword(gomock.Any(), gomock.Any()).Return("user123", nil)
+				mockJWTManager.EXPECT().RevokeAllSessions("user123").Return(2, tt.revokeErr)
+			}
+
+			h := NewHandler(mockServices, mockLogger, mockJWTManager)
+
+			req, _ := http.NewRequest("POST", "/reset-password", strings.NewReader(`{"token":"abc","new_password":"Correct-Horse-9"}`))
+			rr := httptest.NewRecorder()
+
+			h.ResetPassword(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+}
+
+func TestHandler_Login(t *testing.T) {
+	enabledAt := time.Now()
+
+	tests := []struct {
+		name           string
+		setupMocks     func(*ports.MockServices, *ports.MockUserService, *ports.MockJWTManager)
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name: "Password only",
+			setupMocks: func(ms *ports.MockServices, mus *ports.MockUserService, mj *ports.MockJWTManager) {
+				ms.EXPECT().Users().Return(mus)
+				mus.EXPECT().Login(gomock.Any(), gomock.Any(), gomock.Any()).Return(&user.User{ID: "user123"}, nil)
+				mj.EXPECT().GenerateTokenPair("user123", gomock.Any()).Return("access", "refresh", nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"access_token": "access", "refresh_token": "refresh"},
+		},
+		{
+			name: "Two-factor authentication enabled",
+			setupMocks: func(ms *ports.MockServices, mus *ports.MockUserService, mj *ports.MockJWTManager) {
+				ms.EXPECT().Users().Return(mus).Times(2)
+				mus.EXPECT().Login(gomock.Any(), gomock.Any(), gomock.Any()).Return(&user.User{ID: "user123", TwoFactorEnabledAt: &enabledAt}, nil)
+				mus.EXPECT().BeginTwoFactorLogin(gomock.Any(), "user123").Return("mfa", nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"mfa_required": true, "mfa_token": "mfa"},
+		},
+		{
+			name: "Throttled",
+			setupMocks: func(ms *ports.MockServices, mus *ports.MockUserService, mj *ports.MockJWTManager) {
+				ms.EXPECT().Users().Return(mus)
+				mus.EXPECT().Login(gomock.Any(), gomock.Any(), "192.0.2.1").Return(nil, &user.LoginThrottledError{
+					Scope: user.ThrottleAccount, RetryAfter: 1500 * time.Millisecond,
+				})
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   map[string]interface{}{"error": "Too many failed login attempts, try again later"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockServices := ports.NewMockServices(ctrl)
+			mockUserService := ports.NewMockUserService(ctrl)
+			mockLogger := ports.NewMockLogger(ctrl)
+			mockJWTManager := ports.NewMockJWTManager(ctrl)
+
+			tt.setupMocks(mockServices, mockUserService, mockJWTManager)
+
+			mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+			h := NewHandler(mockServices, mockLogger, mockJWTManager)
+
+			req, _ := http.NewRequest("POST", "/login", strings.NewReader(`{"email":"test@example.com","password":"password123"}`))
+			req.RemoteAddr = "192.0.2.1:4321"
+			rr := httptest.NewRecorder()
+
+			h.Login(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusTooManyRequests {
+				assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+			}
+
+			var responseBody map[string]interface{}
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &responseBody))
+			assert.Equal(t, tt.expectedBody, responseBody)
+		})
+	}
+}
+
+func TestHandler_RefreshToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupMocks     func(*ports.MockJWTManager, *ports.MockLogger)
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name: "Rotated",
+			setupMocks: func(mj *ports.MockJWTManager, ml *ports.MockLogger) {
+				mj.EXPECT().RefreshTokens("refresh", gomock.Any()).Return("new-access", "new-refresh", nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"access_token": "new-access", "refresh_token": "new-refresh"},
+		},
+		{
+			name: "Invalid token",
+			setupMocks: func(mj *ports.MockJWTManager, ml *ports.MockLogger) {
+				mj.EXPECT().RefreshTokens("refresh", gomock.Any()).Return("", "", auth.ErrInvalidToken)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   map[string]interface{}{"error": "Invalid refresh token"},
+		},
+		{
+			name: "Reused token",
+			setupMocks: func(mj *ports.MockJWTManager, ml *ports.MockLogger) {
+				mj.EXPECT().RefreshTokens("refresh", gomock.Any()).Return("", "", domain.ErrRefreshTokenReused)
+				ml.EXPECT().Warn("Refresh token reuse detected, token family revoked", "ip", "192.0.2.1")
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   map[string]interface{}{"error": "Invalid refresh token"},
+		},
+		{
+			name: "Store failure",
+			setupMocks: func(mj *ports.MockJWTManager, ml *ports.MockLogger) {
+				mj.EXPECT().RefreshTokens("refresh", gomock.Any()).Return("", "", errors.New("database error"))
+				ml.EXPECT().Error("Failed to refresh tokens", "error", gomock.Any())
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   map[string]interface{}{"error": "Failed to refresh tokens"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockServices := ports.NewMockServices(ctrl)
+			mockLogger := ports.NewMockLogger(ctrl)
+			mockJWTManager := ports.NewMockJWTManager(ctrl)
+
+			tt.setupMocks(mockJWTManager, mockLogger)
+
+			h := NewHandler(mockServices, mockLogger, mockJWTManager)
+
+			req, _ := http.NewRequest("POST", "/refresh", strings.NewReader(`{"refresh_token":"refresh"}`))
+			req.RemoteAddr = "192.0.2.1:4321"
+			rr := httptest.NewRecorder()
+
+			h.RefreshToken(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			var responseBody map[string]interface{}
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &responseBody))
+			assert.Equal(t, tt.expectedBody, responseBody)
+		})
+	}
+}
+
+func TestHandler_JWKS(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockJWTManager := ports.NewMockJWTManager(ctrl)
+	mockJWTManager.EXPECT().JWKS().Return(domain.JSONWebKeySet{Keys: []domain.JSONWebKey{
+		{KeyType: "OKP", KeyID: "ed-1", Algorithm: "EdDSA", Use: "sig", Curve: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+	}})
+
+	h := NewHandler(ports.NewMockServices(ctrl), ports.NewMockLogger(ctrl), mockJWTManager)
+
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	rr := httptest.NewRecorder()
+
+	h.JWKS(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "public, max-age=300", rr.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"keys":[{"kty":"OKP","kid":"ed-1","alg":"EdDSA","use":"sig","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`, rr.Body.String())
+}
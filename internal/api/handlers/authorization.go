@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
+)
+
+// requestMode returns the mode the auth middleware resolved for the request.
+// Records of the other mode are treated as if they did not exist.
+func requestMode(r *http.Request) mode.Mode {
+	if m, ok := r.Context().Value("mode").(mode.Mode); ok {
+		return m
+	}
+	return mode.Live
+}
+
+// authorize checks that the authenticated user's role on the merchant, the
+// API key's scopes, or the OAuth client's token scopes and merchant grant,
+// grant the permission. It writes the error response and
+// returns false otherwise. The merchant is named in the audit entry of a
+// mutating request.
+func (h *Handler) authorize(w http.ResponseWriter, r *http.Request, merchantID string, permission member.Permission) bool {
+	auditTarget(r, merchantID, "merchant", merchantID)
+
+	if key, ok := r.Context().Value("apiKey").(*apikey.APIKey); ok {
+		if key.MerchantID != merchantID || !key.Allows(permission) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return false
+		}
+		return true
+	}
+
+	var err error
+	if client, ok := r.Context().Value("oauthClient").(*oauth.Principal); ok {
+		// The token's scopes and the merchant's grant both have to allow it.
+		if !client.Allows(permission) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return false
+		}
+		err = h.services.OAuth().Authorize(r.Context(), client.ClientID, merchantID, permission)
+	} else {
+		userID, _ := r.Context().Value("userID").(string)
+		err = h.services.Members().Authorize(r.Context(), userID, merchantID, permission)
+	}
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, member.ErrForbidden) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+
+	if errors.Is(err, member.ErrTwoFactorRequired) {
+		http.Error(w, "Two-factor authentication is required for your role on this merchant", http.StatusForbidden)
+		return false
+	}
+
+	h.logger.Error("Failed to authorize request", "error", err, "merchant_id", merchantID)
+	http.Error(w, "Failed to authorize request", http.StatusInternalServerError)
+	return false
+}
+
+func (h *Handler) authorizePayment(w http.ResponseWriter, r *http.Request, paymentID string, permission member.Permission) bool {
+	p, err := h.services.Payments().GetPayment(r.Context(), paymentID)
+	if err != nil || p.Mode != requestMode(r) {
+		h.logger.Error("Failed to get payment", "error", err, "id", paymentID)
+		http.Error(w, "Payment not found", http.StatusNotFound)
+		return false
+	}
+	auditTarget(r, p.MerchantID, "payment", paymentID)
+
+	return h.authorize(w, r, p.MerchantID, permission)
+}
+
+func (h *Handler) authorizeRefund(w http.ResponseWriter, r *http.Request, refundID string, permission member.Permission) bool {
+	ref, err := h.services.Refunds().GetRefund(r.Context(), refundID)
+	if err != nil || ref.Mode != requestMode(r) {
+		h.logger.Error("Failed to get refund", "error", err, "id", refundID)
+		http.Error(w, "Refund not found", http.StatusNotFound)
+		return false
+	}
+	auditTarget(r, "", "refund", refundID)
+
+	return h.authorizePayment(w, r, ref.PaymentID, permission)
+}
+
+func (h *Handler) authorizeCustomer(w http.ResponseWriter, r *http.Request, customerID string, permission member.Permission) bool {
+	c, err := h.services.Customers().GetCustomer(r.Context(), customerID)
+	if err != nil || c.Mode != requestMode(r) {
+		h.logger.Error("Failed to get customer", "error", err, "id", customerID)
+		http.Error(w, "Customer not found", http.StatusNotFound)
+		return false
+	}
+	auditTarget(r, c.MerchantID, "customer", customerID)
+
+	return h.authorize(w, r, c.MerchantID, permission)
+}
+
+func (h *Handler) authorizePlan(w http.ResponseWriter, r *http.Request, planID string, permission member.Permission) bool {
+	p, err := h.services.Subscriptions().GetPlan(r.Context(), planID)
+	if err != nil || p.Mode != requestMode(r) {
+		h.logger.Error("Failed to get plan", "error", err, "id", planID)
+		http.Error(w, "Plan not found", http.StatusNotFound)
+		return false
+	}
+	auditTarget(r, p.MerchantID, "plan", planID)
+
+	return h.authorize(w, r, p.MerchantID, permission)
+}
+
+func (h *Handler) authorizeSubscription(w http.ResponseWriter, r *http.Request, subscriptionID string, permission member.Permission) bool {
+	s, err := h.services.Subscriptions().GetSubscription(r.Context(), subscriptionID)
+	if err != nil || s.Mode != requestMode(r) {
+		h.logger.Error("Failed to get subscription", "error", err, "id", subscriptionID)
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return false
+	}
+	auditTarget(r, s.MerchantID, "subscription", subscriptionID)
+
+	return h.authorize(w, r, s.MerchantID, permission)
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+)
+
+func (h *Handler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
+	var c customer.Customer
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		h.logger.Error("Failed to decode customer", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorize(w, r, c.MerchantID, member.PermissionCustomersWrite) {
+		return
+	}
+
+	c.Mode = requestMode(r)
+	if err := h.services.Customers().CreateCustomer(r.Context(), &c); err != nil {
+		h.logger.Error("Failed to create customer", "error", err)
+		http.Error(w, "Failed to create customer: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditCreated(r, c.MerchantID, "customer", c.ID, c)
+	respondJSON(w, http.StatusCreated, c)
+}
+
+func (h *Handler) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	c, err := h.services.Customers().GetCustomer(r.Context(), id)
+	if err != nil || c.Mode != requestMode(r) {
+		h.logger.Error("Failed to get customer", "error", err, "id", id)
+		http.Error(w, "Customer not found", http.StatusNotFound)
+		return
+	}
+
+	if !h.authorize(w, r, c.MerchantID, member.PermissionRead) {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
+
+func (h *Handler) UpdateCustomer(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var c customer.Customer
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		h.logger.Error("Failed to decode customer", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorizeCustomer(w, r, id, member.PermissionCustomersWrite) {
+		return
+	}
+
+	before, err := h.services.Customers().GetCustomer(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get customer", "error", err, "id", id)
+		http.Error(w, "Customer not found", http.StatusNotFound)
+		return
+	}
+
+	c.ID = id
+	if err := h.services.Customers().UpdateCustomer(r.Context(), &c); err != nil {
+		h.logger.Error("Failed to update customer", "error", err, "id", id)
+		http.Error(w, "Failed to update customer: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Read the customer back, since the service fills in what the request left out.
+	after, err := h.services.Customers().GetCustomer(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get customer", "error", err, "id", id)
+		http.Error(w, "Failed to get customer", http.StatusInternalServerError)
+		return
+	}
+
+	auditChanges(r, before, after)
+	respondJSON(w, http.StatusOK, after)
+}
+
+func (h *Handler) DeleteCustomer(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorizeCustomer(w, r, id, member.PermissionCustomersWrite) {
+		return
+	}
+
+	if err := h.services.Customers().DeleteCustomer(r.Context(), id); err != nil {
+		h.logger.Error("Failed to delete customer", "error", err, "id", id)
+		http.Error(w, "Failed to delete customer", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Customer deleted successfully"})
+}
+
+// ListCustomers lists a merchant's customers. The optional q parameter
+// searches by email or name.
+func (h *Handler) ListCustomers(w http.ResponseWriter, r *http.Request) {
+	merchantID := r.URL.Query().Get("merchant_id")
+	if merchantID == "" {
+		h.logger.Error("Merchant ID is required")
+		http.Error(w, "Merchant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorize(w, r, merchantID, member.PermissionRead) {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit == 0 {
+		limit = 10
+	}
+
+	customers, err := h.services.Customers().ListCustomers(r.Context(), merchantID, requestMode(r), r.URL.Query().Get("q"), limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list customers", "error", err)
+		http.Error(w, "Failed to list customers", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, customers)
+}
+
+func (h *Handler) ListCustomerPayments(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorizeCustomer(w, r, id, member.PermissionRead) {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit == 0 {
+		limit = 10
+	}
+
+	payments, err := h.services.Customers().ListPayments(r.Context(), id, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list customer payments", "error", err, "id", id)
+		http.Error(w, "Failed to list customer payments", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, payments)
+}
+
+func (h *Handler) AddPaymentMethod(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorizeCustomer(w, r, id, member.PermissionCustomersWrite) {
+		return
+	}
+
+	var pm customer.PaymentMethod
+	if err := json.NewDecoder(r.Body).Decode(&pm); err != nil {
+		h.logger.Error("Failed to decode payment method", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.services.Customers().AddPaymentMethod(r.Context(), id, &pm); err != nil {
+		h.logger.Error("Failed to add payment method", "error", err, "customer_id", id)
+		http.Error(w, "Failed to add payment method: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditCreated(r, "", "payment_method", pm.ID, pm)
+	respondJSON(w, http.StatusCreated, pm)
+}
+
+func (h *Handler) ListPaymentMethods(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorizeCustomer(w, r, id, member.PermissionRead) {
+		return
+	}
+
+	methods, err := h.services.Customers().ListPaymentMethods(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to list payment methods", "error", err, "customer_id", id)
+		http.Error(w, "Failed to list payment methods", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, methods)
+}
+
+func (h *Handler) SetDefaultPaymentMethod(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	methodID := chi.URLParam(r, "methodID")
+	if !h.authorizeCustomer(w, r, id, member.PermissionCustomersWrite) {
+		return
+	}
+
+	pm, err := h.services.Customers().SetDefaultPaymentMethod(r.Context(), id, methodID)
+	if err != nil {
+		h.logger.Error("Failed to set default payment method", "error", err, "customer_id", id)
+		http.Error(w, "Failed to set default payment method: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditSetTarget(r, "payment_method", methodID)
+	respondJSON(w, http.StatusOK, pm)
+}
+
+func (h *Handler) RemovePaymentMethod(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	methodID := chi.URLParam(r, "methodID")
+	if !h.authorizeCustomer(w, r, id, member.PermissionCustomersWrite) {
+		return
+	}
+
+	if err := h.services.Customers().RemovePaymentMethod(r.Context(), id, methodID); err != nil {
+		h.logger.Error("Failed to remove payment method", "error", err, "customer_id", id)
+		http.Error(w, "Failed to remove payment method: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditSetTarget(r, "payment_method", methodID)
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Payment method removed successfully"})
+}
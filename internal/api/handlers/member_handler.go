@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+)
+
+func (h *Handler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionRead) {
+		return
+	}
+
+	members, err := h.services.Members().ListMembers(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to list members", "error", err, "merchant_id", id)
+		http.Error(w, "Failed to list members", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, members)
+}
+
+// InviteMember grants an already registered user a role on the merchant.
+func (h *Handler) InviteMember(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionMembersManage) || !h.requireVerifiedEmail(w, r) {
+		return
+	}
+
+	var req member.InviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode invite request", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+	m, err := h.services.Members().InviteMember(r.Context(), id, userID, &req)
+	if err != nil {
+		h.logger.Error("Failed to invite member", "error", err, "merchant_id", id)
+		if errors.Is(err, member.ErrForbidden) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		http.Error(w, "Failed to invite member: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditCreated(r, "", "member", m.UserID, m)
+	respondJSON(w, http.StatusCreated, m)
+}
+
+func (h *Handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionMembersManage) {
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+	memberID := chi.URLParam(r, "userID")
+	if err := h.services.Members().RemoveMember(r.Context(), id, userID, memberID); err != nil {
+		h.logger.Error("Failed to remove member", "error", err, "merchant_id", id, "user_id", memberID)
+		if errors.Is(err, member.ErrForbidden) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		http.Error(w, "Failed to remove member: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditSetTarget(r, "member", memberID)
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Member removed successfully"})
+}
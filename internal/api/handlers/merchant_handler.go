@@ -6,10 +6,15 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
 	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
 )
 
 func (h *Handler) CreateMerchant(w http.ResponseWriter, r *http.Request) {
+	if !h.requireVerifiedEmail(w, r) {
+		return
+	}
+
 	var m merchant.Merchant
 	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
 		h.logger.Error("Failed to decode merchant", "error", err)
@@ -17,17 +22,25 @@ func (h *Handler) CreateMerchant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.services.Merchants().CreateMerchant(r.Context(), &m); err != nil {
+	// The creating user becomes the merchant's first owner.
+	userID := r.Context().Value("userID").(string)
+	keys, err := h.services.Merchants().CreateMerchant(r.Context(), &m, userID)
+	if err != nil {
 		h.logger.Error("Failed to create merchant", "error", err)
 		http.Error(w, "Failed to create merchant", http.StatusInternalServerError)
 		return
 	}
 
-	respondJSON(w, http.StatusCreated, m)
+	auditCreated(r, m.ID, "merchant", m.ID, m)
+	respondJSON(w, http.StatusCreated, merchant.Created{Merchant: &m, APIKeys: keys})
 }
 
 func (h *Handler) GetMerchant(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionRead) {
+		return
+	}
+
 	m, err := h.services.Merchants().GetMerchant(r.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to get merchant", "error", err, "id", id)
@@ -40,6 +53,10 @@ func (h *Handler) GetMerchant(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) UpdateMerchant(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionMerchantUpdate) {
+		return
+	}
+
 	var m merchant.Merchant
 	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
 		h.logger.Error("Failed to decode merchant", "error", err)
@@ -47,6 +64,13 @@ func (h *Handler) UpdateMerchant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	before, err := h.services.Merchants().GetMerchant(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get merchant", "error", err, "id", id)
+		http.Error(w, "Merchant not found", http.StatusNotFound)
+		return
+	}
+
 	m.ID = id
 	if err := h.services.Merchants().UpdateMerchant(r.Context(), &m); err != nil {
 		h.logger.Error("Failed to update merchant", "error", err, "id", id)
@@ -54,28 +78,69 @@ func (h *Handler) UpdateMerchant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, m)
+	// Read the merchant back, since the service fills in what the request left out.
+	after, err := h.services.Merchants().GetMerchant(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get merchant", "error", err, "id", id)
+		http.Error(w, "Failed to get merchant", http.StatusInternalServerError)
+		return
+	}
+
+	auditChanges(r, before, after)
+	respondJSON(w, http.StatusOK, after)
 }
 
 func (h *Handler) DeleteMerchant(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	if err := h.services.Merchants().DeleteMerchant(r.Context(), id); err != nil {
+	if !h.authorize(w, r, id, member.PermissionMerchantDelete) || !h.requireVerifiedEmail(w, r) {
+		return
+	}
+
+	var req merchant.DeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode delete request", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.services.Merchants().DeleteMerchant(r.Context(), id, &req); err != nil {
 		h.logger.Error("Failed to delete merchant", "error", err, "id", id)
-		http.Error(w, "Failed to delete merchant", http.StatusInternalServerError)
+		http.Error(w, "Failed to delete merchant: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Merchant deleted successfully"})
 }
 
+// ExportMerchant returns all data held for the merchant as a JSON download.
+// Only owners can export, including after the merchant was deleted.
+func (h *Handler) ExportMerchant(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionMerchantDelete) {
+		return
+	}
+
+	export, err := h.services.Exports().ExportMerchant(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to export merchant", "error", err, "id", id)
+		http.Error(w, "Failed to export merchant", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename=\"merchant-"+id+"-export.json\"")
+	respondJSON(w, http.StatusOK, export)
+}
+
+// ListMerchants lists the merchants the authenticated user is a member of.
 func (h *Handler) ListMerchants(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
 	if limit == 0 {
 		limit = 10
 	}
 
-	merchants, err := h.services.Merchants().ListMerchants(r.Context(), limit, offset)
+	merchants, err := h.services.Merchants().ListMerchants(r.Context(), userID, limit, offset)
 	if err != nil {
 		h.logger.Error("Failed to list merchants", "error", err)
 		http.Error(w, "Failed to list merchants", http.StatusInternalServerError)
@@ -8,21 +8,29 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/audit"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
 	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/user"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
 )
 
 func TestHandler_CreateMerchant(t *testing.T) {
+	verifiedAt := time.Now()
+
 	tests := []struct {
 		name           string
 		input          merchant.Merchant
-		setupMocks     func(*ports.MockServices, *ports.MockMerchantService, *ports.MockLogger)
+		setupMocks     func(*ports.MockServices, *ports.MockMerchantService, *ports.MockUserService, *ports.MockLogger)
 		expectedStatus int
 		expectedBody   interface{}
 	}{
@@ -31,13 +39,24 @@ func TestHandler_CreateMerchant(t *testing.T) {
 			input: merchant.Merchant{
 				Name: "Test Merchant",
 			},
-			setupMocks: func(ms *ports.MockServices, mms *ports.MockMerchantService, ml *ports.MockLogger) {
+			setupMocks: func(ms *ports.MockServices, mms *ports.MockMerchantService, mus *ports.MockUserService, ml *ports.MockLogger) {
+				ms.EXPECT().Users().Return(mus)
+				mus.EXPECT().GetUserByID(gomock.Any(), "user1").Return(&user.User{ID: "user1", EmailVerifiedAt: &verifiedAt}, nil)
 				ms.EXPECT().Merchants().Return(mms)
-				mms.EXPECT().CreateMerchant(gomock.Any(), gomock.Any()).Return(nil)
+				mms.EXPECT().CreateMerchant(gomock.Any(), gomock.Any(), "user1").Return([]*apikey.CreatedKey{
+					{APIKey: &apikey.APIKey{ID: "key1", Mode: mode.Test}, Secret: "sk_test_secret"},
+					{APIKey: &apikey.APIKey{ID: "key2", Mode: mode.Live}, Secret: "sk_live_secret"},
+				}, nil)
 			},
 			expectedStatus: http.StatusCreated,
-			expectedBody: merchant.Merchant{
-				Name: "Test Merchant",
+			expectedBody: merchant.Created{
+				Merchant: &merchant.Merchant{
+					Name: "Test Merchant",
+				},
+				APIKeys: []*apikey.CreatedKey{
+					{APIKey: &apikey.APIKey{ID: "key1", Mode: mode.Test}, Secret: "sk_test_secret"},
+					{APIKey: &apikey.APIKey{ID: "key2", Mode: mode.Live}, Secret: "sk_live_secret"},
+				},
 			},
 		},
 		{
@@ -45,14 +64,28 @@ func TestHandler_CreateMerchant(t *testing.T) {
 			input: merchant.Merchant{
 				Name: "Test Merchant",
 			},
-			setupMocks: func(ms *ports.MockServices, mms *ports.MockMerchantService, ml *ports.MockLogger) {
+			setupMocks: func(ms *ports.MockServices, mms *ports.MockMerchantService, mus *ports.MockUserService, ml *ports.MockLogger) {
+				ms.EXPECT().Users().Return(mus)
+				mus.EXPECT().GetUserByID(gomock.Any(), "user1").Return(&user.User{ID: "user1", EmailVerifiedAt: &verifiedAt}, nil)
 				ms.EXPECT().Merchants().Return(mms)
-				mms.EXPECT().CreateMerchant(gomock.Any(), gomock.Any()).Return(errors.New("service error"))
+				mms.EXPECT().CreateMerchant(gomock.Any(), gomock.Any(), "user1").Return(nil, errors.New("service error"))
 				ml.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   "Failed to create merchant\n",
 		},
+		{
+			name: "Email Not Verified",
+			input: merchant.Merchant{
+				Name: "Test Merchant",
+			},
+			setupMocks: func(ms *ports.MockServices, mms *ports.MockMerchantService, mus *ports.MockUserService, ml *ports.MockLogger) {
+				ms.EXPECT().Users().Return(mus)
+				mus.EXPECT().GetUserByID(gomock.Any(), "user1").Return(&user.User{ID: "user1"}, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "Email address is not verified\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -62,9 +95,10 @@ func TestHandler_CreateMerchant(t *testing.T) {
 
 			mockServices := ports.NewMockServices(ctrl)
 			mockMerchantService := ports.NewMockMerchantService(ctrl)
+			mockUserService := ports.NewMockUserService(ctrl)
 			mockLogger := ports.NewMockLogger(ctrl)
 
-			tt.setupMocks(mockServices, mockMerchantService, mockLogger)
+			tt.setupMocks(mockServices, mockMerchantService, mockUserService, mockLogger)
 
 			h := NewHandler(mockServices, mockLogger, nil)
 
@@ -73,6 +107,7 @@ func TestHandler_CreateMerchant(t *testing.T) {
 
 			req, err := http.NewRequest("POST", "/merchants", bytes.NewBuffer(body))
 			require.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), "userID", "user1"))
 
 			rr := httptest.NewRecorder()
 
@@ -80,7 +115,7 @@ func TestHandler_CreateMerchant(t *testing.T) {
 
 			assert.Equal(t, tt.expectedStatus, rr.Code)
 			if tt.expectedStatus == http.StatusCreated {
-				var response merchant.Merchant
+				var response merchant.Created
 				err = json.Unmarshal(rr.Body.Bytes(), &response)
 				require.NoError(t, err)
 				assert.Equal(t, tt.expectedBody, response)
@@ -95,14 +130,16 @@ func TestHandler_GetMerchant(t *testing.T) {
 	tests := []struct {
 		name           string
 		merchantID     string
-		setupMocks     func(*ports.MockServices, *ports.MockMerchantService, *ports.MockLogger)
+		setupMocks     func(*ports.MockServices, *ports.MockMerchantService, *ports.MockMemberService, *ports.MockLogger)
 		expectedStatus int
 		expectedBody   interface{}
 	}{
 		{
 			name:       "Success",
 			merchantID: "123",
-			setupMocks: func(ms *ports.MockServices, mms *ports.MockMerchantService, ml *ports.MockLogger) {
+			setupMocks: func(ms *ports.MockServices, mms *ports.MockMerchantService, mmem *ports.MockMemberService, ml *ports.MockLogger) {
+				ms.EXPECT().Members().Return(mmem)
+				mmem.EXPECT().Authorize(gomock.Any(), "user1", "123", member.PermissionRead).Return(nil)
 				ms.EXPECT().Merchants().Return(mms)
 				mms.EXPECT().GetMerchant(gomock.Any(), "123").Return(&merchant.Merchant{ID: "123", Name: "Test Merchant"}, nil)
 			},
@@ -112,10 +149,22 @@ func TestHandler_GetMerchant(t *testing.T) {
 				Name: "Test Merchant",
 			},
 		},
+		{
+			name:       "Forbidden",
+			merchantID: "789",
+			setupMocks: func(ms *ports.MockServices, mms *ports.MockMerchantService, mmem *ports.MockMemberService, ml *ports.MockLogger) {
+				ms.EXPECT().Members().Return(mmem)
+				mmem.EXPECT().Authorize(gomock.Any(), "user1", "789", member.PermissionRead).Return(member.ErrForbidden)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "Forbidden\n",
+		},
 		{
 			name:       "Not Found",
 			merchantID: "456",
-			setupMocks: func(ms *ports.MockServices, mms *ports.MockMerchantService, ml *ports.MockLogger) {
+			setupMocks: func(ms *ports.MockServices, mms *ports.MockMerchantService, mmem *ports.MockMemberService, ml *ports.MockLogger) {
+				ms.EXPECT().Members().Return(mmem)
+				mmem.EXPECT().Authorize(gomock.Any(), "user1", "456", member.PermissionRead).Return(nil)
 				ms.EXPECT().Merchants().Return(mms)
 				mms.EXPECT().GetMerchant(gomock.Any(), "456").Return(nil, errors.New("not found"))
 				ml.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
@@ -132,9 +181,10 @@ func TestHandler_GetMerchant(t *testing.T) {
 
 			mockServices := ports.NewMockServices(ctrl)
 			mockMerchantService := ports.NewMockMerchantService(ctrl)
+			mockMemberService := ports.NewMockMemberService(ctrl)
 			mockLogger := ports.NewMockLogger(ctrl)
 
-			tt.setupMocks(mockServices, mockMerchantService, mockLogger)
+			tt.setupMocks(mockServices, mockMerchantService, mockMemberService, mockLogger)
 
 			h := NewHandler(mockServices, mockLogger, nil)
 
@@ -143,7 +193,8 @@ func TestHandler_GetMerchant(t *testing.T) {
 
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", tt.merchantID)
-			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(context.WithValue(ctx, "userID", "user1"))
 
 			rr := httptest.NewRecorder()
 
@@ -161,3 +212,49 @@ func TestHandler_GetMerchant(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_UpdateMerchant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockServices := ports.NewMockServices(ctrl)
+	mockMerchantService := ports.NewMockMerchantService(ctrl)
+	mockMemberService := ports.NewMockMemberService(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	before := &merchant.Merchant{ID: "123", Name: "Old Name", SettlementCurrency: "EUR"}
+	// The service keeps the settlement currency the request left out.
+	after := &merchant.Merchant{ID: "123", Name: "New Name", SettlementCurrency: "EUR"}
+
+	mockServices.EXPECT().Members().Return(mockMemberService)
+	mockMemberService.EXPECT().Authorize(gomock.Any(), "user1", "123", member.PermissionMerchantUpdate).Return(nil)
+	mockServices.EXPECT().Merchants().Return(mockMerchantService).Times(3)
+	gomock.InOrder(
+		mockMerchantService.EXPECT().GetMerchant(gomock.Any(), "123").Return(before, nil),
+		mockMerchantService.EXPECT().UpdateMerchant(gomock.Any(), gomock.Any()).Return(nil),
+		mockMerchantService.EXPECT().GetMerchant(gomock.Any(), "123").Return(after, nil),
+	)
+
+	h := NewHandler(mockServices, mockLogger, nil)
+
+	req, err := http.NewRequest("PUT", "/merchants/123", bytes.NewBufferString(`{"name":"New Name"}`))
+	require.NoError(t, err)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "123")
+	entry := &audit.Entry{}
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, "audit", entry)
+	req = req.WithContext(context.WithValue(ctx, "userID", "user1"))
+
+	rr := httptest.NewRecorder()
+
+	h.UpdateMerchant(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response merchant.Merchant
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, *after, response)
+	assert.Equal(t, before, entry.Before)
+	assert.Equal(t, after, entry.After, "the audit entry records the stored merchant, not the request body")
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
+)
+
+// oauthError writes an error response of the token endpoint (RFC 6749
+// section 5.2).
+func oauthError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	respondJSON(w, status, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+// authenticateOAuthClient authenticates the client of a token or
+// introspection request by HTTP Basic or the client_id and client_secret form
+// fields. It writes the error response and returns nil on failure.
+func (h *Handler) authenticateOAuthClient(w http.ResponseWriter, r *http.Request) *oauth.Client {
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		// Basic credentials are form-encoded first (RFC 6749 section 2.3.1).
+		var errID, errSecret error
+		clientID, errID = url.QueryUnescape(clientID)
+		secret, errSecret = url.QueryUnescape(secret)
+		if errID != nil || errSecret != nil {
+			oauthError(w, http.StatusBadRequest, "invalid_request", "Malformed client credentials")
+			return nil
+		}
+	} else {
+		clientID = r.PostForm.Get("client_id")
+		secret = r.PostForm.Get("client_secret")
+	}
+
+	if clientID == "" || secret == "" {
+		oauthError(w, http.StatusBadRequest, "invalid_request", "Client credentials are required")
+		return nil
+	}
+
+	client, err := h.services.OAuth().AuthenticateClient(r.Context(), clientID, secret)
+	if err != nil {
+		h.logger.Warn("OAuth client authentication failed", "client_id", clientID, "ip", remoteIP(r))
+		if basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		oauthError(w, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+		return nil
+	}
+
+	return client
+}
+
+// OAuthToken issues access tokens for the client credentials grant. The
+// token carries the requested scopes, or every scope of the client if none
+// are requested; what it may do on a merchant is further limited by the
+// merchant's grant.
+func (h *Handler) OAuthToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		oauthError(w, http.StatusBadRequest, "invalid_request", "Malformed form body")
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "client_credentials" {
+		oauthError(w, http.StatusBadRequest, "unsupported_grant_type", "Only client_credentials is supported")
+		return
+	}
+
+	client := h.authenticateOAuthClient(w, r)
+	if client == nil {
+		return
+	}
+
+	requested, err := oauth.ParseScopes(r.PostForm.Get("scope"))
+	if err == nil {
+		requested, err = client.TokenScopes(requested)
+	}
+	if err != nil {
+		oauthError(w, http.StatusBadRequest, "invalid_scope", err.Error())
+		return
+	}
+
+	scope := oauth.FormatScopes(requested)
+	accessToken, expiresIn, err := h.JWTManager.GenerateClientToken(client.ID, scope)
+	if err != nil {
+		h.logger.Error("Failed to generate client token", "error", err, "client_id", client.ID)
+		oauthError(w, http.StatusInternalServerError, "server_error", "Failed to generate token")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int64(expiresIn.Seconds()),
+		"scope":        scope,
+	})
+}
+
+// OAuthIntrospect tells a client whether one of its own tokens is active
+// (RFC 7662). Tokens of other clients and users are reported inactive.
+func (h *Handler) OAuthIntrospect(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		oauthError(w, http.StatusBadRequest, "invalid_request", "Malformed form body")
+		return
+	}
+
+	client := h.authenticateOAuthClient(w, r)
+	if client == nil {
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		oauthError(w, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	claims, err := h.JWTManager.ValidateAccessToken(token)
+	if err != nil || claims.ClientID != client.ID {
+		respondJSON(w, http.StatusOK, oauth.Introspection{Active: false})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, oauth.Introspection{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		TokenType: "Bearer",
+		ExpiresAt: claims.RegisteredClaims.ExpiresAt.Unix(),
+		IssuedAt:  claims.IssuedAt.Unix(),
+	})
+}
+
+// RegisterOAuthClient registers a client owned by the user. The response is
+// the only time the secret is returned.
+func (h *Handler) RegisterOAuthClient(w http.ResponseWriter, r *http.Request) {
+	if !h.requireVerifiedEmail(w, r) {
+		return
+	}
+
+	var req oauth.RegisterClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+	client, err := h.services.OAuth().RegisterClient(r.Context(), userID, &req)
+	if err != nil {
+		h.logger.Error("Failed to register oauth client", "error", err, "user_id", userID)
+		h.respondError(w, http.StatusBadRequest, "Failed to register client: "+err.Error())
+		return
+	}
+
+	auditCreated(r, "", "oauth_client", client.ID, client.Client)
+	h.respondJSON(w, http.StatusCreated, client)
+}
+
+func (h *Handler) ListOAuthClients(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	clients, err := h.services.OAuth().ListClients(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to list oauth clients", "error", err, "user_id", userID)
+		h.respondError(w, http.StatusInternalServerError, "Failed to list clients")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, clients)
+}
+
+// RevokeOAuthClient stops the client from getting tokens. Tokens it already
+// holds are refused on their next use, as the client is checked per request.
+func (h *Handler) RevokeOAuthClient(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	id := chi.URLParam(r, "id")
+	auditSetTarget(r, "oauth_client", id)
+
+	if err := h.services.OAuth().RevokeClient(r.Context(), userID, id); err != nil {
+		if errors.Is(err, oauth.ErrClientNotFound) {
+			h.respondError(w, http.StatusNotFound, "Client not found")
+			return
+		}
+		h.logger.Error("Failed to revoke oauth client", "error", err, "client_id", id)
+		h.respondError(w, http.StatusInternalServerError, "Failed to revoke client")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListOAuthClientGrants lists the merchants that granted the client access.
+func (h *Handler) ListOAuthClientGrants(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	id := chi.URLParam(r, "id")
+
+	grants, err := h.services.OAuth().ListClientGrants(r.Context(), userID, id)
+	if err != nil {
+		if errors.Is(err, oauth.ErrClientNotFound) {
+			h.respondError(w, http.StatusNotFound, "Client not found")
+			return
+		}
+		h.logger.Error("Failed to list oauth client grants", "error", err, "client_id", id)
+		h.respondError(w, http.StatusInternalServerError, "Failed to list grants")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, grants)
+}
+
+// GrantOAuthClient lets a client act on the merchant within the given scopes.
+// Granting a client again replaces its scopes.
+func (h *Handler) GrantOAuthClient(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionMembersManage) || !h.requireVerifiedEmail(w, r) {
+		return
+	}
+
+	var req oauth.GrantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode oauth grant request", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+	grant, err := h.services.OAuth().GrantClient(r.Context(), id, userID, &req)
+	if err != nil {
+		h.logger.Error("Failed to grant oauth client", "error", err, "merchant_id", id)
+		if errors.Is(err, oauth.ErrClientNotFound) {
+			http.Error(w, "Client not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to grant access: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditCreated(r, "", "oauth_grant", grant.ClientID, grant)
+	respondJSON(w, http.StatusCreated, grant)
+}
+
+func (h *Handler) ListOAuthGrants(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionMembersManage) {
+		return
+	}
+
+	grants, err := h.services.OAuth().ListGrants(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to list oauth grants", "error", err, "merchant_id", id)
+		http.Error(w, "Failed to list grants", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, grants)
+}
+
+func (h *Handler) RevokeOAuthGrant(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	clientID := chi.URLParam(r, "clientID")
+	if !h.authorize(w, r, id, member.PermissionMembersManage) {
+		return
+	}
+
+	if err := h.services.OAuth().RevokeGrant(r.Context(), id, clientID); err != nil {
+		h.logger.Error("Failed to revoke oauth grant", "error", err, "merchant_id", id, "client_id", clientID)
+		if errors.Is(err, oauth.ErrGrantNotFound) {
+			http.Error(w, "Grant not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to revoke grant", http.StatusInternalServerError)
+		return
+	}
+
+	auditSetTarget(r, "oauth_grant", clientID)
+	w.WriteHeader(http.StatusNoContent)
+}
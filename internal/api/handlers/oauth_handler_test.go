@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/domain"
+)
+
+func oauthFormRequest(target string, form url.Values) *http.Request {
+	req := httptest.NewRequest("POST", target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestHandler_OAuthToken(t *testing.T) {
+	client := &oauth.Client{ID: "client1", Scopes: []oauth.Scope{oauth.ScopeRead, oauth.ScopePaymentsWrite}}
+
+	tests := []struct {
+		name           string
+		form           url.Values
+		basicAuth      bool
+		setupMocks     func(services *ports.MockServices, oauthService *ports.MockOAuthService, jwtManager *ports.MockJWTManager, logger *ports.MockLogger)
+		expectedStatus int
+		expectedError  string
+		expectedScope  string
+	}{
+		{
+			name: "Credentials in the body, all scopes",
+			form: url.Values{"grant_type": {"client_credentials"}, "client_id": {"client1"}, "client_secret": {"cs_secret"}},
+			setupMocks: func(services *ports.MockServices, oauthService *ports.MockOAuthService, jwtManager *ports.MockJWTManager, logger *ports.MockLogger) {
+				services.EXPECT().OAuth().Return(oauthService)
+				oauthService.EXPECT().AuthenticateClient(gomock.Any(), "client1", "cs_secret").Return(client, nil)
+				jwtManager.EXPECT().GenerateClientToken("client1", "read payments:write").Return("token", time.Hour, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedScope:  "read payments:write",
+		},
+		{
+			name:      "Basic auth, narrowed scope",
+			form:      url.Values{"grant_type": {"client_credentials"}, "scope": {"read"}},
+			basicAuth: true,
+			setupMocks: func(services *ports.MockServices, oauthService *ports.MockOAuthService, jwtManager *ports.MockJWTManager, logger *ports.MockLogger) {
+				services.EXPECT().OAuth().Return(oauthService)
+				oauthService.EXPECT().AuthenticateClient(gomock.Any(), "client1", "cs_secret").Return(client, nil)
+				jwtManager.EXPECT().GenerateClientToken("client1", "read").Return("token", time.Hour, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedScope:  "read",
+		},
+		{
+			name:      "Scope not registered for the client",
+			form:      url.Values{"grant_type": {"client_credentials"}, "scope": {"refunds:write"}},
+			basicAuth: true,
+			setupMocks: func(services *ports.MockServices, oauthService *ports.MockOAuthService, jwtManager *ports.MockJWTManager, logger *ports.MockLogger) {
+				services.EXPECT().OAuth().Return(oauthService)
+				oauthService.EXPECT().AuthenticateClient(gomock.Any(), "client1", "cs_secret").Return(client, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_scope",
+		},
+		{
+			name:      "Wrong secret",
+			form:      url.Values{"grant_type": {"client_credentials"}},
+			basicAuth: true,
+			setupMocks: func(services *ports.MockServices, oauthService *ports.MockOAuthService, jwtManager *ports.MockJWTManager, logger *ports.MockLogger) {
+				services.EXPECT().OAuth().Return(oauthService)
+				oauthService.EXPECT().AuthenticateClient(gomock.Any(), "client1", "cs_secret").Return(nil, oauth.ErrInvalidClient)
+				logger.EXPECT().Warn(gomock.Any(), gomock.Any())
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "invalid_client",
+		},
+		{
+			name:           "Other grant type",
+			form:           url.Values{"grant_type": {"password"}},
+			setupMocks:     func(*ports.MockServices, *ports.MockOAuthService, *ports.MockJWTManager, *ports.MockLogger) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "unsupported_grant_type",
+		},
+		{
+			name:           "No credentials",
+			form:           url.Values{"grant_type": {"client_credentials"}},
+			setupMocks:     func(*ports.MockServices, *ports.MockOAuthService, *ports.MockJWTManager, *ports.MockLogger) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockServices := ports.NewMockServices(ctrl)
+			mockOAuthService := ports.NewMockOAuthService(ctrl)
+			mockJWTManager := ports.NewMockJWTManager(ctrl)
+			mockLogger := ports.NewMockLogger(ctrl)
+			tt.setupMocks(mockServices, mockOAuthService, mockJWTManager, mockLogger)
+
+			h := NewHandler(mockServices, mockLogger, mockJWTManager)
+
+			req := oauthFormRequest("/oauth/token", tt.form)
+			if tt.basicAuth {
+				req.SetBasicAuth("client1", "cs_secret")
+			}
+			rr := httptest.NewRecorder()
+			h.OAuthToken(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			if tt.expectedError != "" {
+				assert.Equal(t, tt.expectedError, body["error"])
+				if tt.expectedStatus == http.StatusUnauthorized {
+					assert.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+				}
+				return
+			}
+			assert.Equal(t, "Bearer", body["token_type"])
+			assert.Equal(t, float64(3600), body["expires_in"])
+			assert.Equal(t, tt.expectedScope, body["scope"])
+		})
+	}
+}
+
+func TestHandler_OAuthIntrospect(t *testing.T) {
+	now := time.Now()
+	clientClaims := func(clientID string) *domain.Claims {
+		return &domain.Claims{
+			ClientID: clientID,
+			Scope:    "read",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+				IssuedAt:  jwt.NewNumericDate(now),
+			},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		claims         *domain.Claims
+		validateErr    error
+		expectedActive bool
+	}{
+		{name: "Own token", claims: clientClaims("client1"), expectedActive: true},
+		{name: "Token of another client", claims: clientClaims("client2")},
+		{name: "User token", claims: &domain.Claims{UserID: "user1"}},
+		{name: "Expired token", validateErr: jwt.ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockServices := ports.NewMockServices(ctrl)
+			mockOAuthService := ports.NewMockOAuthService(ctrl)
+			mockJWTManager := ports.NewMockJWTManager(ctrl)
+			mockServices.EXPECT().OAuth().Return(mockOAuthService)
+			mockOAuthService.EXPECT().AuthenticateClient(gomock.Any(), "client1", "cs_secret").Return(&oauth.Client{ID: "client1"}, nil)
+			mockJWTManager.EXPECT().ValidateAccessToken("token").Return(tt.claims, tt.validateErr)
+
+			h := NewHandler(mockServices, ports.NewMockLogger(ctrl), mockJWTManager)
+
+			req := oauthFormRequest("/oauth/introspect", url.Values{"token": {"token"}})
+			req.SetBasicAuth("client1", "cs_secret")
+			rr := httptest.NewRecorder()
+			h.OAuthIntrospect(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var introspection oauth.Introspection
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &introspection))
+			assert.Equal(t, tt.expectedActive, introspection.Active)
+			if tt.expectedActive {
+				assert.Equal(t, "read", introspection.Scope)
+				assert.Equal(t, now.Add(time.Hour).Unix(), introspection.ExpiresAt)
+			} else {
+				assert.Empty(t, introspection.ClientID)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+)
+
+func (h *Handler) UpdateBusinessDetails(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionMerchantUpdate) {
+		return
+	}
+
+	var details merchant.BusinessDetails
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		h.logger.Error("Failed to decode business details", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.services.Merchants().GetMerchant(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get merchant", "error", err, "id", id)
+		http.Error(w, "Merchant not found", http.StatusNotFound)
+		return
+	}
+
+	m, err := h.services.Onboarding().UpdateBusinessDetails(r.Context(), id, &details)
+	if err != nil {
+		h.logger.Error("Failed to update business details", "error", err, "merchant_id", id)
+		http.Error(w, "Failed to update business details: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditChanges(r, before.BusinessDetails, m.BusinessDetails)
+	respondJSON(w, http.StatusOK, m)
+}
+
+func (h *Handler) AddBeneficialOwner(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionMerchantUpdate) {
+		return
+	}
+
+	var owner merchant.BeneficialOwner
+	if err := json.NewDecoder(r.Body).Decode(&owner); err != nil {
+		h.logger.Error("Failed to decode beneficial owner", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.services.Onboarding().AddOwner(r.Context(), id, &owner); err != nil {
+		h.logger.Error("Failed to add beneficial owner", "error", err, "merchant_id", id)
+		http.Error(w, "Failed to add beneficial owner: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditCreated(r, "", "beneficial_owner", owner.ID, owner)
+	respondJSON(w, http.StatusCreated, owner)
+}
+
+func (h *Handler) ListBeneficialOwners(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionRead) {
+		return
+	}
+
+	owners, err := h.services.Onboarding().ListOwners(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to list beneficial owners", "error", err, "merchant_id", id)
+		http.Error(w, "Failed to list beneficial owners", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, owners)
+}
+
+func (h *Handler) RemoveBeneficialOwner(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionMerchantUpdate) {
+		return
+	}
+
+	ownerID := chi.URLParam(r, "ownerID")
+	if err := h.services.Onboarding().RemoveOwner(r.Context(), id, ownerID); err != nil {
+		h.logger.Error("Failed to remove beneficial owner", "error", err, "merchant_id", id, "owner_id", ownerID)
+		http.Error(w, "Failed to remove beneficial owner: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditSetTarget(r, "beneficial_owner", ownerID)
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Beneficial owner removed successfully"})
+}
+
+// UploadDocument accepts a multipart form with the file in "file" and the
+// document type in "type".
+func (h *Handler) UploadDocument(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionMerchantUpdate) {
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.logger.Error("Failed to read uploaded document", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	doc := &merchant.Document{
+		MerchantID:  id,
+		Type:        merchant.DocumentType(r.FormValue("type")),
+		FileName:    header.Filename,
+		ContentType: header.Header.Get("Content-Type"),
+		Size:        header.Size,
+		UploadedBy:  r.Context().Value("userID").(string),
+	}
+
+	if err := h.services.Onboarding().UploadDocument(r.Context(), doc, file); err != nil {
+		h.logger.Error("Failed to upload document", "error", err, "merchant_id", id)
+		http.Error(w, "Failed to upload document: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditCreated(r, "", "document", doc.ID, doc)
+	respondJSON(w, http.StatusCreated, doc)
+}
+
+func (h *Handler) ListDocuments(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionRead) {
+		return
+	}
+
+	documents, err := h.services.Onboarding().ListDocuments(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to list documents", "error", err, "merchant_id", id)
+		http.Error(w, "Failed to list documents", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, documents)
+}
+
+func (h *Handler) SubmitApplication(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionMerchantUpdate) {
+		return
+	}
+
+	m, err := h.services.Onboarding().Submit(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to submit application", "error", err, "merchant_id", id)
+		http.Error(w, "Failed to submit application: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, m)
+}
+
+// requireStaff lets only platform staff through to the review, reconciliation
+// and cross-merchant audit endpoints.
+func (h *Handler) requireStaff(w http.ResponseWriter, r *http.Request) bool {
+	userID, _ := r.Context().Value("userID").(string)
+
+	u, err := h.services.Users().GetUserByID(r.Context(), userID)
+	if err != nil || !u.Staff {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (h *Handler) ListMerchantsForReview(w http.ResponseWriter, r *http.Request) {
+	if !h.requireStaff(w, r) {
+		return
+	}
+
+	status := merchant.Status(r.URL.Query().Get("status"))
+	if status == "" {
+		status = merchant.StatusSubmitted
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit == 0 {
+		limit = 10
+	}
+
+	merchants, err := h.services.Onboarding().ListByStatus(r.Context(), status, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list merchants for review", "error", err, "status", status)
+		http.Error(w, "Failed to list merchants", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, merchants)
+}
+
+func (h *Handler) StartMerchantReview(w http.ResponseWriter, r *http.Request) {
+	if !h.requireStaff(w, r) {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	userID := r.Context().Value("userID").(string)
+	m, err := h.services.Onboarding().StartReview(r.Context(), id, userID)
+	if err != nil {
+		h.logger.Error("Failed to start review", "error", err, "merchant_id", id)
+		http.Error(w, "Failed to start review: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditTarget(r, id, "merchant", id)
+	respondJSON(w, http.StatusOK, m)
+}
+
+func (h *Handler) DecideMerchantReview(w http.ResponseWriter, r *http.Request) {
+	if !h.requireStaff(w, r) {
+		return
+	}
+
+	var decision merchant.ReviewDecision
+	if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+		h.logger.Error("Failed to decode review decision", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	userID := r.Context().Value("userID").(string)
+	m, err := h.services.Onboarding().Decide(r.Context(), id, userID, &decision)
+	if err != nil {
+		h.logger.Error("Failed to record review decision", "error", err, "merchant_id", id)
+		http.Error(w, "Failed to record review decision: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditTarget(r, id, "merchant", id)
+	respondJSON(w, http.StatusOK, m)
+}
+
+func (h *Handler) DownloadDocument(w http.ResponseWriter, r *http.Request) {
+	if !h.requireStaff(w, r) {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	documentID := chi.URLParam(r, "documentID")
+	doc, content, err := h.services.Onboarding().OpenDocument(r.Context(), id, documentID)
+	if err != nil {
+		h.logger.Error("Failed to open document", "error", err, "merchant_id", id, "document_id", documentID)
+		http.Error(w, "Document not found", http.StatusNotFound)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", doc.ContentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(doc.FileName))
+	w.Header().Set("Content-Length", strconv.FormatInt(doc.Size, 10))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		h.logger.Error("Failed to send document", "error", err, "document_id", documentID)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
 	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
 )
 
@@ -18,31 +19,41 @@ func (h *Handler) CreatePayment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	merchantID := r.Context().Value("merchantID").(string)
-	p.MerchantID = merchantID
+	if !h.authorize(w, r, p.MerchantID, member.PermissionPaymentsWrite) {
+		return
+	}
 
+	p.Mode = requestMode(r)
 	if err := h.services.Payments().CreatePayment(r.Context(), &p); err != nil {
 		h.logger.Error("Failed to create payment", "error", err)
-		http.Error(w, "Failed to create payment", http.StatusInternalServerError)
 		metrics.PaymentTotal.WithLabelValues("failed").Inc()
+		if respondLimitError(w, err) {
+			return
+		}
+		http.Error(w, "Failed to create payment", http.StatusInternalServerError)
 		return
 	}
 
 	metrics.PaymentTotal.WithLabelValues("success").Inc()
 	metrics.PaymentAmount.WithLabelValues(p.Currency).Observe(p.Amount)
 
+	auditCreated(r, p.MerchantID, "payment", p.ID, p)
 	respondJSON(w, http.StatusCreated, p)
 }
 
 func (h *Handler) GetPayment(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	p, err := h.services.Payments().GetPayment(r.Context(), id)
-	if err != nil {
+	if err != nil || p.Mode != requestMode(r) {
 		h.logger.Error("Failed to get payment", "error", err, "id", id)
 		http.Error(w, "Payment not found", http.StatusNotFound)
 		return
 	}
 
+	if !h.authorize(w, r, p.MerchantID, member.PermissionRead) {
+		return
+	}
+
 	respondJSON(w, http.StatusOK, p)
 }
 
@@ -55,6 +66,17 @@ func (h *Handler) UpdatePayment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.authorizePayment(w, r, id, member.PermissionPaymentsWrite) {
+		return
+	}
+
+	before, err := h.services.Payments().GetPayment(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get payment", "error", err, "id", id)
+		http.Error(w, "Payment not found", http.StatusNotFound)
+		return
+	}
+
 	p.ID = id
 	if err := h.services.Payments().UpdatePayment(r.Context(), &p); err != nil {
 		h.logger.Error("Failed to update payment", "error", err, "id", id)
@@ -62,18 +84,37 @@ func (h *Handler) UpdatePayment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, p)
+	// Read the payment back, since the service fills in what the request left out.
+	after, err := h.services.Payments().GetPayment(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get payment", "error", err, "id", id)
+		http.Error(w, "Failed to get payment", http.StatusInternalServerError)
+		return
+	}
+
+	auditChanges(r, before, after)
+	respondJSON(w, http.StatusOK, after)
 }
 
 func (h *Handler) ListPayments(w http.ResponseWriter, r *http.Request) {
-	merchantID := r.Context().Value("merchantID").(string)
+	merchantID := r.URL.Query().Get("merchant_id")
+	if merchantID == "" {
+		h.logger.Error("Merchant ID is required")
+		http.Error(w, "Merchant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorize(w, r, merchantID, member.PermissionRead) {
+		return
+	}
+
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
 	if limit == 0 {
 		limit = 10
 	}
 
-	payments, err := h.services.Payments().ListPayments(r.Context(), merchantID, limit, offset)
+	payments, err := h.services.Payments().ListPayments(r.Context(), merchantID, requestMode(r), limit, offset)
 	if err != nil {
 		h.logger.Error("Failed to list payments", "error", err)
 		http.Error(w, "Failed to list payments", http.StatusInternalServerError)
@@ -85,6 +126,10 @@ func (h *Handler) ListPayments(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) ProcessPayment(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
+	if !h.authorizePayment(w, r, id, member.PermissionPaymentsWrite) {
+		return
+	}
+
 	if err := h.services.Payments().ProcessPayment(r.Context(), id); err != nil {
 		h.logger.Error("Failed to process payment", "error", err, "id", id)
 		http.Error(w, "Failed to process payment", http.StatusInternalServerError)
@@ -96,3 +141,39 @@ func (h *Handler) ProcessPayment(w http.ResponseWriter, r *http.Request) {
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Payment processed successfully"})
 }
+
+// CapturePayment collects an authorized payment of a merchant that has
+// auto-capture turned off.
+func (h *Handler) CapturePayment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorizePayment(w, r, id, member.PermissionPaymentsWrite) {
+		return
+	}
+
+	if err := h.services.Payments().CapturePayment(r.Context(), id); err != nil {
+		h.logger.Error("Failed to capture payment", "error", err, "id", id)
+		http.Error(w, "Failed to capture payment: "+err.Error(), http.StatusBadRequest)
+		metrics.PaymentTotal.WithLabelValues("failed").Inc()
+		return
+	}
+
+	metrics.PaymentTotal.WithLabelValues("captured").Inc()
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Payment captured successfully"})
+}
+
+func (h *Handler) ListPaymentTransitions(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorizePayment(w, r, id, member.PermissionRead) {
+		return
+	}
+
+	transitions, err := h.services.Payments().ListTransitions(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to list payment transitions", "error", err, "id", id)
+		http.Error(w, "Failed to list payment transitions", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, transitions)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
+)
+
+func (h *Handler) RunReconciliation(w http.ResponseWriter, r *http.Request) {
+	if !h.requireStaff(w, r) {
+		return
+	}
+
+	reports, err := h.services.Reconciliation().RunReconciliation(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to run reconciliation", "error", err)
+		http.Error(w, "Failed to run reconciliation", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, reports)
+}
+
+func (h *Handler) ListReconciliationReports(w http.ResponseWriter, r *http.Request) {
+	if !h.requireStaff(w, r) {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit == 0 {
+		limit = 10
+	}
+
+	reports, err := h.services.Reconciliation().ListReports(r.Context(), limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list reconciliation reports", "error", err)
+		http.Error(w, "Failed to list reconciliation reports", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, reports)
+}
+
+func (h *Handler) GetReconciliationReport(w http.ResponseWriter, r *http.Request) {
+	if !h.requireStaff(w, r) {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	report, err := h.services.Reconciliation().GetReport(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get reconciliation report", "error", err, "id", id)
+		http.Error(w, "Reconciliation report not found", http.StatusNotFound)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+func (h *Handler) ListDiscrepancies(w http.ResponseWriter, r *http.Request) {
+	if !h.requireStaff(w, r) {
+		return
+	}
+
+	reportID := chi.URLParam(r, "id")
+	status := reconciliation.DiscrepancyStatus(r.URL.Query().Get("status"))
+	if status != "" && status != reconciliation.DiscrepancyStatusOpen && status != reconciliation.DiscrepancyStatusResolved {
+		http.Error(w, "Invalid status filter", http.StatusBadRequest)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit == 0 {
+		limit = 50
+	}
+
+	discrepancies, err := h.services.Reconciliation().ListDiscrepancies(r.Context(), reportID, status, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list discrepancies", "error", err, "report_id", reportID)
+		http.Error(w, "Failed to list discrepancies", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, discrepancies)
+}
+
+func (h *Handler) ResolveDiscrepancy(w http.ResponseWriter, r *http.Request) {
+	if !h.requireStaff(w, r) {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	userID := r.Context().Value("userID").(string)
+
+	var req reconciliation.ResolveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode resolve request", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.services.Reconciliation().ResolveDiscrepancy(r.Context(), id, userID, &req)
+	if err != nil {
+		h.logger.Error("Failed to resolve discrepancy", "error", err, "id", id)
+		http.Error(w, "Failed to resolve discrepancy: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, d)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
+	"github.com/popeskul/payment-gateway/internal/core/domain/user"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+func TestHandler_Reconciliation_RequiresStaff(t *testing.T) {
+	handlers := map[string]struct {
+		method, target string
+		handle         func(h *Handler) http.HandlerFunc
+	}{
+		"RunReconciliation":         {"POST", "/reconciliation/run", func(h *Handler) http.HandlerFunc { return h.RunReconciliation }},
+		"ListReconciliationReports": {"GET", "/reconciliation/reports", func(h *Handler) http.HandlerFunc { return h.ListReconciliationReports }},
+		"GetReconciliationReport":   {"GET", "/reconciliation/reports/r1", func(h *Handler) http.HandlerFunc { return h.GetReconciliationReport }},
+		"ListDiscrepancies":         {"GET", "/reconciliation/reports/r1/discrepancies", func(h *Handler) http.HandlerFunc { return h.ListDiscrepancies }},
+		"ResolveDiscrepancy":        {"POST", "/reconciliation/discrepancies/d1/resolve", func(h *Handler) http.HandlerFunc { return h.ResolveDiscrepancy }},
+	}
+
+	for name, tt := range handlers {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockServices := ports.NewMockServices(ctrl)
+			mockUserService := ports.NewMockUserService(ctrl)
+			mockServices.EXPECT().Users().Return(mockUserService)
+			mockUserService.EXPECT().GetUserByID(gomock.Any(), "user1").Return(&user.User{ID: "user1"}, nil)
+			// The reconciliation service must not be reached.
+			mockServices.EXPECT().Reconciliation().Times(0)
+
+			h := NewHandler(mockServices, ports.NewMockLogger(ctrl), nil)
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(`{"note":"ok"}`))
+			req = req.WithContext(context.WithValue(req.Context(), "userID", "user1"))
+			rr := httptest.NewRecorder()
+
+			tt.handle(h)(rr, req)
+
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+	}
+}
+
+func TestHandler_ListReconciliationReports_Staff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockServices := ports.NewMockServices(ctrl)
+	mockUserService := ports.NewMockUserService(ctrl)
+	mockReconciliationService := ports.NewMockReconciliationService(ctrl)
+	mockServices.EXPECT().Users().Return(mockUserService)
+	mockUserService.EXPECT().GetUserByID(gomock.Any(), "staff1").Return(&user.User{ID: "staff1", Staff: true}, nil)
+	mockServices.EXPECT().Reconciliation().Return(mockReconciliationService)
+	mockReconciliationService.EXPECT().ListReports(gomock.Any(), 10, 0).Return([]*reconciliation.Report{{ID: "r1"}}, nil)
+
+	h := NewHandler(mockServices, ports.NewMockLogger(ctrl), nil)
+
+	req := httptest.NewRequest("GET", "/reconciliation/reports", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "userID", "staff1"))
+	rr := httptest.NewRecorder()
+
+	h.ListReconciliationReports(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
 	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
 	"github.com/popeskul/payment-gateway/internal/infrastructure/metrics"
 )
@@ -18,28 +19,40 @@ func (h *Handler) CreateRefund(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.authorizePayment(w, r, ref.PaymentID, member.PermissionRefundsWrite) {
+		return
+	}
+
 	if err := h.services.Refunds().CreateRefund(r.Context(), &ref); err != nil {
 		h.logger.Error("Failed to create refund", "error", err)
-		http.Error(w, "Failed to create refund", http.StatusInternalServerError)
 		metrics.RefundTotal.WithLabelValues("failed").Inc()
+		if respondLimitError(w, err) {
+			return
+		}
+		http.Error(w, "Failed to create refund", http.StatusInternalServerError)
 		return
 	}
 
 	metrics.RefundTotal.WithLabelValues("success").Inc()
 	metrics.RefundAmount.WithLabelValues(ref.Currency).Observe(ref.Amount)
 
+	auditCreated(r, "", "refund", ref.ID, ref)
 	respondJSON(w, http.StatusCreated, ref)
 }
 
 func (h *Handler) GetRefund(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	ref, err := h.services.Refunds().GetRefund(r.Context(), id)
-	if err != nil {
+	if err != nil || ref.Mode != requestMode(r) {
 		h.logger.Error("Failed to get refund", "error", err, "id", id)
 		http.Error(w, "Refund not found", http.StatusNotFound)
 		return
 	}
 
+	if !h.authorizePayment(w, r, ref.PaymentID, member.PermissionRead) {
+		return
+	}
+
 	respondJSON(w, http.StatusOK, ref)
 }
 
@@ -52,6 +65,10 @@ func (h *Handler) UpdateRefund(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.authorizeRefund(w, r, id, member.PermissionRefundsWrite) {
+		return
+	}
+
 	ref.ID = id
 	if err := h.services.Refunds().UpdateRefund(r.Context(), &ref); err != nil {
 		h.logger.Error("Failed to update refund", "error", err, "id", id)
@@ -70,6 +87,10 @@ func (h *Handler) ListRefunds(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.authorizePayment(w, r, paymentID, member.PermissionRead) {
+		return
+	}
+
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
 	if limit == 0 {
@@ -88,6 +109,10 @@ func (h *Handler) ListRefunds(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) ProcessRefund(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
+	if !h.authorizeRefund(w, r, id, member.PermissionRefundsWrite) {
+		return
+	}
+
 	if err := h.services.Refunds().ProcessRefund(r.Context(), id); err != nil {
 		h.logger.Error("Failed to process refund", "error", err, "id", id)
 		http.Error(w, "Failed to process refund", http.StatusInternalServerError)
@@ -99,3 +124,21 @@ func (h *Handler) ProcessRefund(w http.ResponseWriter, r *http.Request) {
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Refund processed successfully"})
 }
+
+func (h *Handler) CancelRefund(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorizeRefund(w, r, id, member.PermissionRefundsWrite) {
+		return
+	}
+
+	ref, err := h.services.Refunds().CancelRefund(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to cancel refund", "error", err, "id", id)
+		http.Error(w, "Failed to cancel refund: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	metrics.RefundTotal.WithLabelValues("canceled").Inc()
+
+	respondJSON(w, http.StatusOK, ref)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/popeskul/payment-gateway/internal/domain"
+)
+
+// currentSessionID is the session of the request's access token. Tokens
+// issued before sessions existed have none.
+func currentSessionID(r *http.Request) string {
+	sessionID, _ := r.Context().Value("sessionID").(string)
+	return sessionID
+}
+
+// ListSessions returns where the user is logged in, marking the session of
+// the request.
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	sessions, err := h.JWTManager.ListSessions(userID)
+	if err != nil {
+		h.logger.Error("Failed to list sessions", "error", err, "user_id", userID)
+		h.respondError(w, http.StatusInternalServerError, "Failed to list sessions")
+		return
+	}
+
+	current := currentSessionID(r)
+	for _, session := range sessions {
+		session.Current = session.ID == current
+	}
+
+	h.respondJSON(w, http.StatusOK, sessions)
+}
+
+// RevokeSession signs the user out of one session. Its refresh token stops
+// working and its access tokens are rejected.
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	id := chi.URLParam(r, "id")
+	auditSetTarget(r, "session", id)
+
+	if err := h.JWTManager.RevokeSession(userID, id); err != nil {
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			h.respondError(w, http.StatusNotFound, "Session not found")
+			return
+		}
+		h.logger.Error("Failed to revoke session", "error", err, "session_id", id)
+		h.respondError(w, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeOtherSessions signs the user out everywhere but the session of the
+// request.
+func (h *Handler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	revoked, err := h.JWTManager.RevokeOtherSessions(userID, currentSessionID(r))
+	if err != nil {
+		h.logger.Error("Failed to revoke sessions", "error", err, "user_id", userID)
+		h.respondError(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]int{"revoked": revoked})
+}
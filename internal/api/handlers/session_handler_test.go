@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/domain"
+)
+
+func sessionRequest(method, target, sessionID string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	ctx := context.WithValue(req.Context(), "userID", "user123")
+	ctx = context.WithValue(ctx, "sessionID", sessionID)
+	return req.WithContext(ctx)
+}
+
+func TestHandler_ListSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockJWTManager := ports.NewMockJWTManager(ctrl)
+	mockJWTManager.EXPECT().ListSessions("user123").Return([]*domain.Session{
+		{ID: "laptop", UserID: "user123", Device: "Firefox on Linux"},
+		{ID: "phone", UserID: "user123", Device: "Safari on iPhone"},
+	}, nil)
+
+	h := NewHandler(ports.NewMockServices(ctrl), ports.NewMockLogger(ctrl), mockJWTManager)
+
+	rr := httptest.NewRecorder()
+	h.ListSessions(rr, sessionRequest("GET", "/sessions", "phone"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var sessions []domain.Session
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &sessions))
+	require.Len(t, sessions, 2)
+	assert.False(t, sessions[0].Current)
+	assert.True(t, sessions[1].Current)
+}
+
+func TestHandler_RevokeSession(t *testing.T) {
+	tests := []struct {
+		name           string
+		revokeErr      error
+		expectedStatus int
+	}{
+		{name: "Revoked", expectedStatus: http.StatusNoContent},
+		{name: "Not the user's session", revokeErr: domain.ErrSessionNotFound, expectedStatus: http.StatusNotFound},
+		{name: "Store failure", revokeErr: errors.New("database error"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := ports.NewMockLogger(ctrl)
+			mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+			mockJWTManager := ports.NewMockJWTManager(ctrl)
+			mockJWTManager.EXPECT().RevokeSession("user123", "laptop").Return(tt.revokeErr)
+
+			h := NewHandler(ports.NewMockServices(ctrl), mockLogger, mockJWTManager)
+
+			req := sessionRequest("DELETE", "/sessions/laptop", "phone")
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "laptop")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+			h.RevokeSession(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+}
+
+func TestHandler_RevokeOtherSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockJWTManager := ports.NewMockJWTManager(ctrl)
+	mockJWTManager.EXPECT().RevokeOtherSessions("user123", "phone").Return(2, nil)
+
+	h := NewHandler(ports.NewMockServices(ctrl), ports.NewMockLogger(ctrl), mockJWTManager)
+
+	rr := httptest.NewRecorder()
+	h.RevokeOtherSessions(rr, sessionRequest("POST", "/sessions/revoke-others", "phone"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"revoked":2}`, rr.Body.String())
+}
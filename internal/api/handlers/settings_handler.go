@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+)
+
+// GetMerchantSettings returns the settings in effect, which are the defaults
+// until the merchant saves its own.
+func (h *Handler) GetMerchantSettings(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionRead) {
+		return
+	}
+
+	m, err := h.services.Merchants().GetMerchant(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get merchant", "error", err, "id", id)
+		http.Error(w, "Merchant not found", http.StatusNotFound)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, m.ProcessingSettings())
+}
+
+// UpdateMerchantSettings replaces the settings. Fields left out of the request
+// fall back to their defaults.
+func (h *Handler) UpdateMerchantSettings(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorize(w, r, id, member.PermissionMerchantUpdate) {
+		return
+	}
+
+	settings := merchant.DefaultSettings()
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		h.logger.Error("Failed to decode merchant settings", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.services.Merchants().GetMerchant(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get merchant", "error", err, "id", id)
+		http.Error(w, "Merchant not found", http.StatusNotFound)
+		return
+	}
+
+	m, err := h.services.Merchants().UpdateSettings(r.Context(), id, &settings)
+	if err != nil {
+		h.logger.Error("Failed to update merchant settings", "error", err, "id", id)
+		http.Error(w, "Failed to update merchant settings: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditChanges(r, before.ProcessingSettings(), m.ProcessingSettings())
+	respondJSON(w, http.StatusOK, m.ProcessingSettings())
+}
+
+// respondLimitError writes a 422 with the limit's error code when err was
+// caused by the merchant's settings, and reports whether it did.
+func respondLimitError(w http.ResponseWriter, err error) bool {
+	var limitErr *merchant.LimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+
+	respondJSON(w, http.StatusUnprocessableEntity, limitErr)
+	return true
+}
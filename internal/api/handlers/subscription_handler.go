@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/domain/subscription"
+)
+
+func (h *Handler) CreatePlan(w http.ResponseWriter, r *http.Request) {
+	var p subscription.Plan
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		h.logger.Error("Failed to decode plan", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorize(w, r, p.MerchantID, member.PermissionBillingWrite) {
+		return
+	}
+
+	p.Mode = requestMode(r)
+	if err := h.services.Subscriptions().CreatePlan(r.Context(), &p); err != nil {
+		h.logger.Error("Failed to create plan", "error", err)
+		http.Error(w, "Failed to create plan: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditCreated(r, p.MerchantID, "plan", p.ID, p)
+	respondJSON(w, http.StatusCreated, p)
+}
+
+func (h *Handler) GetPlan(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	p, err := h.services.Subscriptions().GetPlan(r.Context(), id)
+	if err != nil || p.Mode != requestMode(r) {
+		h.logger.Error("Failed to get plan", "error", err, "id", id)
+		http.Error(w, "Plan not found", http.StatusNotFound)
+		return
+	}
+
+	if !h.authorize(w, r, p.MerchantID, member.PermissionRead) {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, p)
+}
+
+func (h *Handler) ListPlans(w http.ResponseWriter, r *http.Request) {
+	merchantID := r.URL.Query().Get("merchant_id")
+	if merchantID == "" {
+		h.logger.Error("Merchant ID is required")
+		http.Error(w, "Merchant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorize(w, r, merchantID, member.PermissionRead) {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit == 0 {
+		limit = 10
+	}
+
+	plans, err := h.services.Subscriptions().ListPlans(r.Context(), merchantID, requestMode(r), limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list plans", "error", err)
+		http.Error(w, "Failed to list plans", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, plans)
+}
+
+func (h *Handler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req subscription.CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode subscription", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorize(w, r, req.MerchantID, member.PermissionBillingWrite) {
+		return
+	}
+
+	s := subscription.Subscription{
+		MerchantID:    req.MerchantID,
+		Mode:          requestMode(r),
+		PlanID:        req.PlanID,
+		PaymentMethod: req.PaymentMethod,
+		PaymentToken:  req.PaymentToken,
+	}
+	if err := h.services.Subscriptions().CreateSubscription(r.Context(), &s); err != nil {
+		h.logger.Error("Failed to create subscription", "error", err)
+		http.Error(w, "Failed to create subscription: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auditCreated(r, s.MerchantID, "subscription", s.ID, s)
+	respondJSON(w, http.StatusCreated, s)
+}
+
+func (h *Handler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	s, err := h.services.Subscriptions().GetSubscription(r.Context(), id)
+	if err != nil || s.Mode != requestMode(r) {
+		h.logger.Error("Failed to get subscription", "error", err, "id", id)
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	}
+
+	if !h.authorize(w, r, s.MerchantID, member.PermissionRead) {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, s)
+}
+
+func (h *Handler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	merchantID := r.URL.Query().Get("merchant_id")
+	if merchantID == "" {
+		h.logger.Error("Merchant ID is required")
+		http.Error(w, "Merchant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorize(w, r, merchantID, member.PermissionRead) {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit == 0 {
+		limit = 10
+	}
+
+	subscriptions, err := h.services.Subscriptions().ListSubscriptions(r.Context(), merchantID, requestMode(r), limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list subscriptions", "error", err)
+		http.Error(w, "Failed to list subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, subscriptions)
+}
+
+func (h *Handler) ChangeSubscriptionPlan(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var req subscription.ChangePlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode change plan request", "error", err)
+		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorizeSubscription(w, r, id, member.PermissionBillingWrite) {
+		return
+	}
+
+	s, err := h.services.Subscriptions().ChangePlan(r.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("Failed to change subscription plan", "error", err, "id", id)
+		http.Error(w, "Failed to change plan: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, s)
+}
+
+func (h *Handler) CancelSubscription(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var req subscription.CancelRequest
+	// The body is optional; an empty one cancels immediately.
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.logger.Error("Failed to decode cancel request", "error", err)
+			http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if !h.authorizeSubscription(w, r, id, member.PermissionBillingWrite) {
+		return
+	}
+
+	s, err := h.services.Subscriptions().CancelSubscription(r.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("Failed to cancel subscription", "error", err, "id", id)
+		http.Error(w, "Failed to cancel subscription: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, s)
+}
+
+func (h *Handler) ListSubscriptionEvents(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !h.authorizeSubscription(w, r, id, member.PermissionRead) {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit == 0 {
+		limit = 10
+	}
+
+	events, err := h.services.Subscriptions().ListEvents(r.Context(), id, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list subscription events", "error", err, "id", id)
+		http.Error(w, "Failed to list subscription events", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, events)
+}
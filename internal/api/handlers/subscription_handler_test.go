@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/audit"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/subscription"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+func TestHandler_CreateSubscription(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockServices := ports.NewMockServices(ctrl)
+	mockSubscriptionService := ports.NewMockSubscriptionService(ctrl)
+	mockMemberService := ports.NewMockMemberService(ctrl)
+
+	mockServices.EXPECT().Members().Return(mockMemberService)
+	mockMemberService.EXPECT().Authorize(gomock.Any(), "user1", "merchant1", member.PermissionBillingWrite).Return(nil)
+	mockServices.EXPECT().Subscriptions().Return(mockSubscriptionService)
+	mockSubscriptionService.EXPECT().CreateSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, s *subscription.Subscription) error {
+		assert.Equal(t, "merchant1", s.MerchantID)
+		assert.Equal(t, mode.Live, s.Mode)
+		assert.Equal(t, "plan1", s.PlanID)
+		assert.Equal(t, "credit_card", s.PaymentMethod)
+		assert.Equal(t, "tok_visa", s.PaymentToken)
+		s.ID = "sub1"
+		s.Status = subscription.StatusActive
+		return nil
+	})
+
+	h := NewHandler(mockServices, ports.NewMockLogger(ctrl), nil)
+
+	body := `{"merchant_id":"merchant1","plan_id":"plan1","payment_method":"credit_card","payment_token":"tok_visa"}`
+	req, err := http.NewRequest("POST", "/subscriptions", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	ctx := context.WithValue(req.Context(), "audit", &audit.Entry{})
+	req = req.WithContext(context.WithValue(ctx, "userID", "user1"))
+
+	rr := httptest.NewRecorder()
+
+	h.CreateSubscription(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"id":"sub1"`)
+	assert.NotContains(t, rr.Body.String(), "tok_visa", "the payment token is never returned")
+}
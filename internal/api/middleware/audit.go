@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/audit"
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+// Audit records every successful POST, PUT, PATCH and DELETE in the audit log.
+// It must run after authentication. The entry is put in the request context
+// under "audit" so handlers can name the merchant and target and attach the
+// target's state before and after the change.
+//
+// The action is the method and route pattern, e.g. "PUT /api/v1/merchants/{id}".
+// A failure to record is logged; the response has been sent by then.
+func Audit(audits ports.AuditService, logger ports.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			entry := &audit.Entry{
+				IP:        clientIP(r),
+				RequestID: chiMiddleware.GetReqID(r.Context()),
+			}
+			if key, ok := r.Context().Value("apiKey").(*apikey.APIKey); ok {
+				entry.ActorType = audit.ActorAPIKey
+				entry.ActorID = key.ID
+			} else if client, ok := r.Context().Value("oauthClient").(*oauth.Principal); ok {
+				entry.ActorType = audit.ActorOAuthClient
+				entry.ActorID = client.ClientID
+			} else {
+				entry.ActorType = audit.ActorUser
+				entry.ActorID, _ = r.Context().Value("userID").(string)
+			}
+
+			ww := NewStatusResponseWriter(w)
+			next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), "audit", entry)))
+
+			if ww.status >= http.StatusBadRequest {
+				return
+			}
+
+			entry.Action = r.Method + " " + r.URL.Path
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				entry.Action = r.Method + " " + rctx.RoutePattern()
+			}
+
+			if err := audits.Record(r.Context(), entry); err != nil {
+				logger.Error("Failed to record audit entry", "error", err, "action", entry.Action, "request_id", entry.RequestID)
+			}
+		})
+	}
+}
+
+// clientIP strips the port from RemoteAddr, which the RealIP middleware has
+// already replaced with the forwarded address when there is one.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -1,36 +1,225 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/domain"
+	"github.com/popeskul/payment-gateway/pkg/signing"
 )
 
+// Auth authenticates users by bearer token. Tokens of OAuth clients are
+// refused; they are only accepted by APIKeyOrAuth.
 func Auth(jwtManager ports.JWTManager) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
-				http.Error(w, "Authorization header is required", http.StatusUnauthorized)
+			claims, ok := bearerClaims(w, r, jwtManager)
+			if !ok {
 				return
 			}
 
-			bearerToken := strings.Split(authHeader, " ")
-			if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
-				http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+			if claims.ClientID != "" {
+				http.Error(w, "Client credentials cannot be used here", http.StatusForbidden)
 				return
 			}
 
-			claims, err := jwtManager.ValidateAccessToken(bearerToken[1])
+			next.ServeHTTP(w, r.WithContext(userContext(r.Context(), claims)))
+		})
+	}
+}
+
+// bearerClaims validates the request's bearer token. It writes the error
+// response and returns false if there is no valid one.
+func bearerClaims(w http.ResponseWriter, r *http.Request, jwtManager ports.JWTManager) (*domain.Claims, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		http.Error(w, "Authorization header is required", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	bearerToken := strings.Split(authHeader, " ")
+	if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
+		http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	claims, err := jwtManager.ValidateAccessToken(bearerToken[1])
+	if err != nil {
+		http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	// A signed-out session's access tokens stay valid until they expire, so
+	// they are checked against the revoked sessions.
+	if claims.SessionID != "" && jwtManager.IsSessionRevoked(claims.SessionID) {
+		http.Error(w, "Session has been revoked", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return claims, true
+}
+
+func userContext(ctx context.Context, claims *domain.Claims) context.Context {
+	ctx = context.WithValue(ctx, "userID", claims.UserID)
+	return context.WithValue(ctx, "sessionID", claims.SessionID)
+}
+
+// APIKeyOrAuth authenticates server-to-server calls by the X-API-Key header,
+// or by a request signature made with a key (see package signing), and falls
+// back to bearer tokens otherwise. The key is stored in the request context
+// under "apiKey" for per-merchant scope checks. Bearer tokens of OAuth
+// clients are stored as an *oauth.Principal under "oauthClient".
+//
+// The request's mode is stored under "mode". It is the key's mode for API
+// keys; bearer token users and clients pick it with the X-Mode header,
+// defaulting to live.
+func APIKeyOrAuth(jwtManager ports.JWTManager, apiKeys ports.APIKeyService) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(signing.HeaderSignature) != "" {
+				key, ok := signedAPIKey(w, r, apiKeys)
+				if !ok {
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), "apiKey", key)
+				ctx = context.WithValue(ctx, "mode", key.Mode.OrLive())
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			secret := r.Header.Get("X-API-Key")
+			if secret == "" {
+				m := mode.Mode(r.Header.Get("X-Mode")).OrLive()
+				if !m.Valid() {
+					http.Error(w, "X-Mode must be live or test", http.StatusBadRequest)
+					return
+				}
+
+				claims, ok := bearerClaims(w, r, jwtManager)
+				if !ok {
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), "mode", m)
+				if claims.ClientID != "" {
+					ctx = context.WithValue(ctx, "oauthClient", &oauth.Principal{
+						ClientID: claims.ClientID,
+						Scopes:   scopesOf(claims.Scope),
+					})
+				} else {
+					ctx = userContext(ctx, claims)
+				}
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			key, err := apiKeys.Authenticate(r.Context(), secret)
+			if errors.Is(err, apikey.ErrSignatureRequired) {
+				http.Error(w, "This API key only accepts signed requests", http.StatusUnauthorized)
+				return
+			}
 			if err != nil {
-				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				http.Error(w, "Invalid, expired or revoked API key", http.StatusUnauthorized)
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), "userID", claims.UserID)
+			ctx := context.WithValue(r.Context(), "apiKey", key)
+			ctx = context.WithValue(ctx, "mode", key.Mode.OrLive())
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// maxSignedBodyBytes caps the body signedAPIKey reads to hash, since it is
+// read before the caller is authenticated.
+const maxSignedBodyBytes = 1 << 20
+
+// signedAPIKey checks the request's signature. The body is read to hash it
+// and replaced for the handler. It writes the error response and returns
+// false if the signature is not valid.
+func signedAPIKey(w http.ResponseWriter, r *http.Request, apiKeys ports.APIKeyService) (*apikey.APIKey, bool) {
+	timestamp, err := strconv.ParseInt(r.Header.Get(signing.HeaderTimestamp), 10, 64)
+	if err != nil {
+		http.Error(w, signing.HeaderTimestamp+" must be a Unix time in seconds", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSignedBodyBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, "Request body is too large", http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return nil, false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	key, err := apiKeys.AuthenticateSigned(r.Context(), &apikey.SignedRequest{
+		KeyID:      r.Header.Get(signing.HeaderKeyID),
+		Method:     r.Method,
+		RequestURI: r.URL.RequestURI(),
+		Timestamp:  timestamp,
+		Nonce:      r.Header.Get(signing.HeaderNonce),
+		BodyHash:   signing.BodyHash(body),
+		Signature:  r.Header.Get(signing.HeaderSignature),
+	})
+	if err != nil {
+		http.Error(w, "Invalid, expired or replayed request signature", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return key, true
+}
+
+// RequireScope checks the scopes of OAuth client tokens: reads need the read
+// scope and anything else the given write scope. Users and API keys pass
+// through; their permissions are checked per merchant by the handlers, which
+// also check the client's grant on the merchant.
+func RequireScope(write oauth.Scope) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client, ok := r.Context().Value("oauthClient").(*oauth.Principal)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			required := write
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				required = oauth.ScopeRead
+			}
+
+			if !client.HasScope(required) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+string(required)+`"`)
+				http.Error(w, "Token lacks the "+string(required)+" scope", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// scopesOf parses the scope claim. Unknown scopes are dropped rather than
+// failing the request; they grant nothing.
+func scopesOf(scope string) []oauth.Scope {
+	var scopes []oauth.Scope
+	for _, field := range strings.Fields(scope) {
+		if s := oauth.Scope(field); s.Valid() {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/pkg/signing"
+)
+
+func TestAPIKeyOrAuth_SignedBodyTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// No calls expected: the body is refused before the signature is checked.
+	apiKeys := ports.NewMockAPIKeyService(ctrl)
+
+	req := httptest.NewRequest("POST", "/payments", bytes.NewReader(make([]byte, maxSignedBodyBytes+1)))
+	req.Header.Set(signing.HeaderKeyID, "key1")
+	req.Header.Set(signing.HeaderTimestamp, strconv.FormatInt(time.Now().Unix(), 10))
+	req.Header.Set(signing.HeaderNonce, "nonce1")
+	req.Header.Set(signing.HeaderSignature, "signature")
+
+	var called bool
+	rr := httptest.NewRecorder()
+	APIKeyOrAuth(ports.NewMockJWTManager(ctrl), apiKeys)(okHandler(&called)).ServeHTTP(rr, req)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+}
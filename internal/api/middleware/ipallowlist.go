@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/audit"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+// APIKeyIPAllowlist refuses API key requests from addresses outside the
+// merchant's allowlist for the key's mode, and records each refusal in the
+// audit log. Users and OAuth clients pass through. It must run after
+// APIKeyOrAuth, and after RealIP so the client's address is known.
+//
+// A signed request's nonce has been used up by APIKeyOrAuth by the time it is
+// refused here. That is on purpose: only requests with a valid signature reach
+// the allowlist and the audit log, and a refused request cannot be replayed
+// later from an allowed address.
+func APIKeyIPAllowlist(merchants ports.MerchantService, audits ports.AuditService, logger ports.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := r.Context().Value("apiKey").(*apikey.APIKey)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			m, err := merchants.GetMerchant(r.Context(), key.MerchantID)
+			if err != nil {
+				logger.Error("Failed to get merchant for ip allowlist", "error", err, "merchant_id", key.MerchantID)
+				http.Error(w, "Failed to check IP allowlist", http.StatusInternalServerError)
+				return
+			}
+
+			ip := clientIP(r)
+			if m.ProcessingSettings().APIKeyIPAllowlist.Allows(key.Mode, ip) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			logger.Warn("API key used from an address outside the allowlist", "id", key.ID, "merchant_id", key.MerchantID, "ip", ip)
+			entry := &audit.Entry{
+				MerchantID: key.MerchantID,
+				ActorType:  audit.ActorAPIKey,
+				ActorID:    key.ID,
+				IP:         ip,
+				RequestID:  chiMiddleware.GetReqID(r.Context()),
+				Action:     audit.ActionAPIKeyIPDenied,
+				TargetType: "api_key",
+				TargetID:   key.ID,
+			}
+			if err := audits.Record(r.Context(), entry); err != nil {
+				logger.Error("Failed to record audit entry", "error", err, "action", entry.Action, "request_id", entry.RequestID)
+			}
+
+			http.Error(w, "API key is not allowed from this IP address", http.StatusForbidden)
+		})
+	}
+}
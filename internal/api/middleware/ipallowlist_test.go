@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/audit"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+func TestAPIKeyIPAllowlist(t *testing.T) {
+	m := &merchant.Merchant{
+		ID: "merchant1",
+		Settings: &merchant.Settings{
+			APIKeyIPAllowlist: merchant.IPAllowlist{
+				Live: []string{"203.0.113.0/24"},
+				Test: []string{"198.51.100.0/24", "2001:db8::/48"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		mode    mode.Mode
+		ip      string
+		allowed bool
+	}{
+		{name: "Live key inside the live ranges", mode: mode.Live, ip: "203.0.113.7", allowed: true},
+		{name: "Live key inside the test ranges only", mode: mode.Live, ip: "198.51.100.7", allowed: false},
+		{name: "Test key inside the test ranges", mode: mode.Test, ip: "198.51.100.7", allowed: true},
+		{name: "Test key inside an IPv6 test range", mode: mode.Test, ip: "2001:db8::1", allowed: true},
+		{name: "Test key inside the live ranges only", mode: mode.Test, ip: "203.0.113.7", allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			merchants := ports.NewMockMerchantService(ctrl)
+			audits := ports.NewMockAuditService(ctrl)
+			logger := ports.NewMockLogger(ctrl)
+
+			merchants.EXPECT().GetMerchant(gomock.Any(), "merchant1").Return(m, nil)
+			if !tt.allowed {
+				logger.EXPECT().Warn("API key used from an address outside the allowlist", "id", "key1", "merchant_id", "merchant1", "ip", tt.ip)
+				audits.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e *audit.Entry) error {
+					assert.Equal(t, "merchant1", e.MerchantID)
+					assert.Equal(t, audit.ActorAPIKey, e.ActorType)
+					assert.Equal(t, "key1", e.ActorID)
+					assert.Equal(t, tt.ip, e.IP)
+					assert.Equal(t, audit.ActionAPIKeyIPDenied, e.Action)
+					assert.Equal(t, "api_key", e.TargetType)
+					assert.Equal(t, "key1", e.TargetID)
+					return nil
+				})
+			}
+
+			req := httptest.NewRequest("GET", "/payments", nil)
+			req.RemoteAddr = tt.ip
+			key := &apikey.APIKey{ID: "key1", MerchantID: "merchant1", Mode: tt.mode}
+			req = req.WithContext(context.WithValue(req.Context(), "apiKey", key))
+
+			var called bool
+			rr := httptest.NewRecorder()
+			APIKeyIPAllowlist(merchants, audits, logger)(okHandler(&called)).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.allowed, called)
+			if tt.allowed {
+				assert.Equal(t, http.StatusOK, rr.Code)
+			} else {
+				assert.Equal(t, http.StatusForbidden, rr.Code)
+			}
+		})
+	}
+}
+
+func TestAPIKeyIPAllowlist_WithoutAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Users and OAuth clients are not checked, so no calls are expected.
+	merchants := ports.NewMockMerchantService(ctrl)
+	audits := ports.NewMockAuditService(ctrl)
+
+	req := httptest.NewRequest("GET", "/payments", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+
+	var called bool
+	rr := httptest.NewRecorder()
+	APIKeyIPAllowlist(merchants, audits, ports.NewMockLogger(ctrl))(okHandler(&called)).ServeHTTP(rr, req)
+
+	assert.True(t, called)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
+	"github.com/popeskul/payment-gateway/internal/core/domain/ratelimit"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/infrastructure/metrics"
+)
+
+// RateLimiter limits requests with token buckets kept in a RateLimitStore.
+// Buckets are per route group, so the same caller has separate limits on, for
+// example, the auth endpoints and the merchant API.
+//
+// Every limited response carries the RateLimit-Limit, RateLimit-Remaining,
+// RateLimit-Reset and RateLimit-Policy headers of the bucket closest to
+// running out. Refused requests get 429 with Retry-After. If the store fails,
+// the request is let through and the error logged.
+type RateLimiter struct {
+	store  ports.RateLimitStore
+	logger ports.Logger
+}
+
+func NewRateLimiter(store ports.RateLimitStore, logger ports.Logger) *RateLimiter {
+	return &RateLimiter{
+		store:  store,
+		logger: logger,
+	}
+}
+
+type rateLimitKey struct {
+	kind  string
+	id    string
+	limit ratelimit.Limit
+}
+
+// ByIP limits requests by client IP address. It does not need
+// authentication, so it can guard the public routes.
+func (l *RateLimiter) ByIP(group string, limit ratelimit.Limit) func(next http.Handler) http.Handler {
+	return l.limit(group, func(r *http.Request) []rateLimitKey {
+		return []rateLimitKey{{"ip", clientIP(r), limit}}
+	})
+}
+
+// ByCaller limits requests by who made them: the API key and its merchant,
+// the OAuth client or the user. It must run after authentication; the
+// policy's IP limit is left to ByIP, which runs before it, so that requests
+// failing authentication are limited too.
+func (l *RateLimiter) ByCaller(group string, policy ratelimit.Policy) func(next http.Handler) http.Handler {
+	return l.limit(group, func(r *http.Request) []rateLimitKey {
+		var keys []rateLimitKey
+		if key, ok := r.Context().Value("apiKey").(*apikey.APIKey); ok {
+			keys = append(keys,
+				rateLimitKey{"api_key", key.ID, policy.APIKey},
+				rateLimitKey{"merchant", key.MerchantID, policy.Merchant},
+			)
+		} else if client, ok := r.Context().Value("oauthClient").(*oauth.Principal); ok {
+			keys = append(keys, rateLimitKey{"oauth_client", client.ClientID, policy.OAuthClient})
+		} else if userID, ok := r.Context().Value("userID").(string); ok {
+			keys = append(keys, rateLimitKey{"user", userID, policy.User})
+		}
+		return keys
+	})
+}
+
+func (l *RateLimiter) limit(group string, keysOf func(r *http.Request) []rateLimitKey) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var keys []rateLimitKey
+			var buckets []ratelimit.Key
+			for _, key := range keysOf(r) {
+				if !key.limit.Enabled() || key.id == "" {
+					continue
+				}
+				keys = append(keys, key)
+				buckets = append(buckets, ratelimit.Key{Name: group + ":" + key.kind + ":" + key.id, Limit: key.limit})
+			}
+			if len(buckets) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			results, err := l.store.Take(r.Context(), buckets, time.Now())
+			if err != nil {
+				l.logger.Error("Failed to check rate limit", "error", err, "group", group)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			for i, result := range results {
+				if !result.Allowed {
+					metrics.RateLimitedRequests.WithLabelValues(group, keys[i].kind).Inc()
+					setRateLimitHeaders(w, result)
+					w.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(result.RetryAfter, 1), 10))
+					http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+					return
+				}
+			}
+
+			for _, result := range results {
+				if remaining, err := strconv.Atoi(w.Header().Get("RateLimit-Remaining")); err != nil || result.Remaining < remaining {
+					setRateLimitHeaders(w, result)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, result ratelimit.Result) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit.Requests))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.FormatInt(ceilSeconds(result.Reset, 0), 10))
+	w.Header().Set("RateLimit-Policy", result.Limit.Policy())
+}
+
+// ceilSeconds rounds d up to whole seconds, and to no less than min.
+func ceilSeconds(d time.Duration, min int64) int64 {
+	seconds := int64((d + time.Second - 1) / time.Second)
+	if seconds < min {
+		return min
+	}
+	return seconds
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/ratelimit"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+var testPolicy = ratelimit.Policy{
+	IP:       ratelimit.Limit{Requests: 100, Period: time.Minute},
+	APIKey:   ratelimit.Limit{Requests: 10, Period: time.Minute},
+	Merchant: ratelimit.Limit{Requests: 50, Period: time.Minute},
+}
+
+func apiKeyRequest() *http.Request {
+	req := httptest.NewRequest("GET", "/payments", nil)
+	req.RemoteAddr = "203.0.113.7:4321"
+	key := &apikey.APIKey{ID: "key1", MerchantID: "merchant1"}
+	return req.WithContext(context.WithValue(req.Context(), "apiKey", key))
+}
+
+func okHandler(called *bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*called = true
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestRateLimiter_HeadersOfTightestBucket(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := ports.NewMockRateLimitStore(ctrl)
+	store.EXPECT().Take(gomock.Any(), []ratelimit.Key{{Name: "api:ip:203.0.113.7", Limit: testPolicy.IP}}, gomock.Any()).
+		Return([]ratelimit.Result{{Limit: testPolicy.IP, Allowed: true, Remaining: 90, Reset: 6 * time.Second}}, nil)
+	store.EXPECT().Take(gomock.Any(), []ratelimit.Key{
+		{Name: "api:api_key:key1", Limit: testPolicy.APIKey},
+		{Name: "api:merchant:merchant1", Limit: testPolicy.Merchant},
+	}, gomock.Any()).Return([]ratelimit.Result{
+		{Limit: testPolicy.APIKey, Allowed: true, Remaining: 2, Reset: 1500 * time.Millisecond},
+		{Limit: testPolicy.Merchant, Allowed: true, Remaining: 30, Reset: 24 * time.Second},
+	}, nil)
+
+	limiter := NewRateLimiter(store, ports.NewMockLogger(ctrl))
+	var called bool
+	rr := httptest.NewRecorder()
+	limiter.ByIP("api", testPolicy.IP)(limiter.ByCaller("api", testPolicy)(okHandler(&called))).ServeHTTP(rr, apiKeyRequest())
+
+	assert.True(t, called)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "10", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "2", rr.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", rr.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "10;w=60", rr.Header().Get("RateLimit-Policy"))
+	assert.Empty(t, rr.Header().Get("Retry-After"))
+}
+
+func TestRateLimiter_Refused(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The merchant's bucket has tokens left, but the key's is empty.
+	store := ports.NewMockRateLimitStore(ctrl)
+	store.EXPECT().Take(gomock.Any(), []ratelimit.Key{
+		{Name: "api:api_key:key1", Limit: testPolicy.APIKey},
+		{Name: "api:merchant:merchant1", Limit: testPolicy.Merchant},
+	}, gomock.Any()).Return([]ratelimit.Result{
+		{Limit: testPolicy.APIKey, Remaining: 0, Reset: time.Minute, RetryAfter: 5200 * time.Millisecond},
+		{Limit: testPolicy.Merchant, Allowed: true, Remaining: 30, Reset: 24 * time.Second},
+	}, nil)
+
+	var called bool
+	rr := httptest.NewRecorder()
+	NewRateLimiter(store, ports.NewMockLogger(ctrl)).ByCaller("api", testPolicy)(okHandler(&called)).ServeHTTP(rr, apiKeyRequest())
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "6", rr.Header().Get("Retry-After"))
+	assert.Equal(t, "10", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", rr.Header().Get("RateLimit-Reset"))
+}
+
+func TestRateLimiter_StoreFailureLetsRequestThrough(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := ports.NewMockRateLimitStore(ctrl)
+	store.EXPECT().Take(gomock.Any(), []ratelimit.Key{{Name: "auth:ip:203.0.113.7", Limit: testPolicy.IP}}, gomock.Any()).
+		Return(nil, errors.New("database error"))
+	logger := ports.NewMockLogger(ctrl)
+	logger.EXPECT().Error("Failed to check rate limit", "error", gomock.Any(), "group", "auth")
+
+	req := httptest.NewRequest("POST", "/login", nil)
+	req.RemoteAddr = "203.0.113.7:4321"
+
+	var called bool
+	rr := httptest.NewRecorder()
+	NewRateLimiter(store, logger).ByIP("auth", testPolicy.IP)(okHandler(&called)).ServeHTTP(rr, req)
+
+	assert.True(t, called)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimiter_ByIPRunsWithoutAuthentication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := ports.NewMockRateLimitStore(ctrl)
+	store.EXPECT().Take(gomock.Any(), []ratelimit.Key{{Name: "api:ip:203.0.113.7", Limit: testPolicy.IP}}, gomock.Any()).
+		Return([]ratelimit.Result{{Limit: testPolicy.IP, Remaining: 0, Reset: time.Minute, RetryAfter: time.Second}}, nil)
+
+	// A request with a bad key never gets as far as authentication.
+	req := httptest.NewRequest("GET", "/payments", nil)
+	req.RemoteAddr = "203.0.113.7:4321"
+	req.Header.Set("X-API-Key", "sk_live_guess")
+
+	var called bool
+	rr := httptest.NewRecorder()
+	NewRateLimiter(store, ports.NewMockLogger(ctrl)).ByIP("api", testPolicy.IP)(okHandler(&called)).ServeHTTP(rr, req)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+}
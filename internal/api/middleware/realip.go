@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIP replaces RemoteAddr with the client's address from X-Forwarded-For,
+// or X-Real-IP, but only when the request comes from one of the trusted
+// proxies. Anyone else could put any address in those headers, so they are
+// ignored and the connection's address is kept.
+//
+// X-Forwarded-For is read from the right: each proxy appends the address it
+// received the request from, so the client is the last address that is not a
+// trusted proxy. Entries to the left of it were supplied by the client.
+func RealIP(trustedProxies []netip.Prefix) func(next http.Handler) http.Handler {
+	trusted := func(addr netip.Addr) bool {
+		for _, p := range trustedProxies {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remote, ok := parseAddr(clientIP(r))
+			if ok && trusted(remote) {
+				if ip, ok := forwardedFor(r, trusted); ok {
+					r.RemoteAddr = ip.String()
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forwardedFor(r *http.Request, trusted func(netip.Addr) bool) (netip.Addr, bool) {
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	var client netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseAddr(strings.TrimSpace(hops[i]))
+		if !ok {
+			// Nothing left of a malformed entry can be trusted.
+			break
+		}
+		client = addr
+		if !trusted(addr) {
+			break
+		}
+	}
+	if client.IsValid() {
+		return client, true
+	}
+
+	return parseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+}
+
+// parseAddr accepts an IP address with or without a port.
+func parseAddr(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
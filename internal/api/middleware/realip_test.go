@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealIP(t *testing.T) {
+	trustedProxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		realIP       string
+		expectedAddr string
+	}{
+		{
+			name:         "Untrusted peer spoofing X-Forwarded-For",
+			remoteAddr:   "198.51.100.9:1234",
+			forwardedFor: []string{"203.0.113.7"},
+			expectedAddr: "198.51.100.9:1234",
+		},
+		{
+			name:         "Untrusted peer spoofing X-Real-IP",
+			remoteAddr:   "198.51.100.9:1234",
+			realIP:       "203.0.113.7",
+			expectedAddr: "198.51.100.9:1234",
+		},
+		{
+			name:         "Trusted proxy",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"203.0.113.7"},
+			expectedAddr: "203.0.113.7",
+		},
+		{
+			name:         "Rightmost untrusted hop wins",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"192.0.2.1, 203.0.113.7, 10.0.0.2"},
+			expectedAddr: "203.0.113.7",
+		},
+		{
+			name:         "Hops over several headers",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"192.0.2.1, 203.0.113.7", "10.0.0.2"},
+			expectedAddr: "203.0.113.7",
+		},
+		{
+			name:         "Nothing left of a malformed hop is used",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"203.0.113.7, not-an-ip, 10.0.0.2"},
+			expectedAddr: "10.0.0.2",
+		},
+		{
+			name:         "Malformed X-Forwarded-For falls back to X-Real-IP",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"not-an-ip"},
+			realIP:       "203.0.113.8",
+			expectedAddr: "203.0.113.8",
+		},
+		{
+			name:         "X-Real-IP from a trusted proxy",
+			remoteAddr:   "10.0.0.1:1234",
+			realIP:       "203.0.113.8",
+			expectedAddr: "203.0.113.8",
+		},
+		{
+			name:         "Trusted proxy without headers",
+			remoteAddr:   "10.0.0.1:1234",
+			expectedAddr: "10.0.0.1:1234",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, header := range tt.forwardedFor {
+				req.Header.Add("X-Forwarded-For", header)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			var remoteAddr string
+			handler := RealIP(trustedProxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				remoteAddr = r.RemoteAddr
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.expectedAddr, remoteAddr)
+		})
+	}
+}
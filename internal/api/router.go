@@ -3,23 +3,51 @@ package api
 import (
 	"github.com/popeskul/payment-gateway/internal/infrastructure/metrics"
 	"net/http"
+	"net/netip"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/popeskul/payment-gateway/internal/api/handlers"
 	customMiddleware "github.com/popeskul/payment-gateway/internal/api/middleware"
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
+	"github.com/popeskul/payment-gateway/internal/core/domain/ratelimit"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
 )
 
 type Router struct {
-	router  *chi.Mux
-	handler *handlers.Handler
+	router         *chi.Mux
+	handler        *handlers.Handler
+	services       ports.Services
+	logger         ports.Logger
+	trustedProxies []netip.Prefix
+	rateLimits     RateLimits
+	limiter        *customMiddleware.RateLimiter
 }
 
-func NewRouter(services ports.Services, logger ports.Logger, jwtManager ports.JWTManager) *Router {
+// RateLimits sets the limits of each route group. Auth covers the public
+// authentication routes, where only the IP limit applies; Dashboard the
+// routes for signed-in users; and API the merchant resource routes. Rate
+// limiting is off when Store is nil.
+type RateLimits struct {
+	Store     ports.RateLimitStore
+	Auth      ratelimit.Policy
+	Dashboard ratelimit.Policy
+	API       ratelimit.Policy
+}
+
+// NewRouter builds the API. Forwarded client addresses are only believed from
+// the trusted proxies.
+func NewRouter(services ports.Services, logger ports.Logger, jwtManager ports.JWTManager, trustedProxies []netip.Prefix, rateLimits RateLimits) *Router {
 	r := &Router{
-		router:  chi.NewRouter(),
-		handler: handlers.NewHandler(services, logger, jwtManager),
+		router:         chi.NewRouter(),
+		handler:        handlers.NewHandler(services, logger, jwtManager),
+		services:       services,
+		logger:         logger,
+		trustedProxies: trustedProxies,
+		rateLimits:     rateLimits,
+	}
+	if rateLimits.Store != nil {
+		r.limiter = customMiddleware.NewRateLimiter(rateLimits.Store, logger)
 	}
 
 	r.setupRoutes()
@@ -28,52 +56,189 @@ func NewRouter(services ports.Services, logger ports.Logger, jwtManager ports.JW
 
 func (r *Router) setupRoutes() {
 	r.router.Use(middleware.RequestID)
-	r.router.Use(middleware.RealIP)
+	r.router.Use(customMiddleware.RealIP(r.trustedProxies))
 	r.router.Use(middleware.Logger)
 	r.router.Use(middleware.Recoverer)
 	r.router.Use(customMiddleware.Cors)
 	r.router.Use(customMiddleware.MetricsMiddleware)
 
 	r.router.Handle("/metrics", metrics.MetricsHandler())
+	r.router.Get("/.well-known/jwks.json", r.handler.JWKS)
 
 	r.router.Route("/api/v1", func(router chi.Router) {
-		// Public authentication routes
-		router.Post("/register", r.handler.Register)
-		router.Post("/login", r.handler.Login)
-		router.Post("/refresh", r.handler.RefreshToken)
+		router.Group(func(router chi.Router) {
+			r.useRateLimitByIP(router, "auth", r.rateLimits.Auth.IP)
+
+			// Public authentication routes
+			router.Post("/register", r.handler.Register)
+			router.Post("/login", r.handler.Login)
+			router.Post("/login/2fa", r.handler.LoginTwoFactor)
+			router.Post("/refresh", r.handler.RefreshToken)
+			router.Post("/verify-email", r.handler.VerifyEmail)
+			router.Post("/forgot-password", r.handler.ForgotPassword)
+			router.Post("/reset-password", r.handler.ResetPassword)
+
+			// OAuth client credentials, authenticated by client secret
+			router.Post("/oauth/token", r.handler.OAuthToken)
+			router.Post("/oauth/introspect", r.handler.OAuthIntrospect)
+		})
 
-		// Protected routes
+		// Protected routes, bearer token only
 		router.Group(func(router chi.Router) {
+			r.useRateLimitByIP(router, "dashboard", r.rateLimits.Dashboard.IP)
 			router.Use(customMiddleware.Auth(r.handler.JWTManager))
+			r.useRateLimitByCaller(router, "dashboard", r.rateLimits.Dashboard)
+			router.Use(customMiddleware.Audit(r.services.Audit(), r.logger))
 
 			// User routes
 			router.Post("/logout", r.handler.Logout)
 			router.Get("/profile", r.handler.GetProfile)
 			router.Put("/profile", r.handler.UpdateProfile)
 			router.Post("/change-password", r.handler.ChangePassword)
+			router.Post("/verify-email/resend", r.handler.ResendVerificationEmail)
+			router.Post("/2fa/enroll", r.handler.EnrollTwoFactor)
+			router.Post("/2fa/confirm", r.handler.ConfirmTwoFactor)
+			router.Post("/2fa/disable", r.handler.DisableTwoFactor)
+			router.Post("/2fa/recovery-codes", r.handler.RegenerateRecoveryCodes)
+			router.Get("/sessions", r.handler.ListSessions)
+			router.Post("/sessions/revoke-others", r.handler.RevokeOtherSessions)
+			router.Delete("/sessions/{id}", r.handler.RevokeSession)
+			router.Post("/oauth/clients", r.handler.RegisterOAuthClient)
+			router.Get("/oauth/clients", r.handler.ListOAuthClients)
+			router.Delete("/oauth/clients/{id}", r.handler.RevokeOAuthClient)
+			router.Get("/oauth/clients/{id}/grants", r.handler.ListOAuthClientGrants)
 
 			// Merchant routes
 			router.Post("/merchants", r.handler.CreateMerchant)
 			router.Get("/merchants/{id}", r.handler.GetMerchant)
 			router.Put("/merchants/{id}", r.handler.UpdateMerchant)
 			router.Delete("/merchants/{id}", r.handler.DeleteMerchant)
+			router.Get("/merchants/{id}/export", r.handler.ExportMerchant)
+			router.Get("/merchants/{id}/settings", r.handler.GetMerchantSettings)
+			router.Put("/merchants/{id}/settings", r.handler.UpdateMerchantSettings)
 			router.Get("/merchants", r.handler.ListMerchants)
+			router.Get("/merchants/{id}/members", r.handler.ListMembers)
+			router.Post("/merchants/{id}/members", r.handler.InviteMember)
+			router.Delete("/merchants/{id}/members/{userID}", r.handler.RemoveMember)
+
+			// Merchant onboarding
+			router.Put("/merchants/{id}/business-details", r.handler.UpdateBusinessDetails)
+			router.Post("/merchants/{id}/owners", r.handler.AddBeneficialOwner)
+			router.Get("/merchants/{id}/owners", r.handler.ListBeneficialOwners)
+			router.Delete("/merchants/{id}/owners/{ownerID}", r.handler.RemoveBeneficialOwner)
+			router.Post("/merchants/{id}/documents", r.handler.UploadDocument)
+			router.Get("/merchants/{id}/documents", r.handler.ListDocuments)
+			router.Post("/merchants/{id}/submit", r.handler.SubmitApplication)
+
+			// Merchant review, staff only
+			router.Get("/review/merchants", r.handler.ListMerchantsForReview)
+			router.Post("/review/merchants/{id}/start", r.handler.StartMerchantReview)
+			router.Post("/review/merchants/{id}/decision", r.handler.DecideMerchantReview)
+			router.Get("/review/merchants/{id}/documents/{documentID}", r.handler.DownloadDocument)
+
+			// API key management
+			router.Post("/merchants/{id}/api-keys", r.handler.CreateAPIKey)
+			router.Get("/merchants/{id}/api-keys", r.handler.ListAPIKeys)
+			router.Post("/merchants/{id}/api-keys/{keyID}/rotate", r.handler.RotateAPIKey)
+			router.Delete("/merchants/{id}/api-keys/{keyID}", r.handler.RevokeAPIKey)
+
+			// OAuth client grants
+			router.Post("/merchants/{id}/oauth-grants", r.handler.GrantOAuthClient)
+			router.Get("/merchants/{id}/oauth-grants", r.handler.ListOAuthGrants)
+			router.Delete("/merchants/{id}/oauth-grants/{clientID}", r.handler.RevokeOAuthGrant)
+
+			// Audit log
+			router.Get("/merchants/{id}/audit-log", r.handler.ListMerchantAuditLog)
+			router.Get("/audit-log", r.handler.ListAuditLog)
+			router.Get("/audit-log/verify", r.handler.VerifyAuditLog)
+
+			// Reconciliation routes
+			router.Post("/reconciliation/run", r.handler.RunReconciliation)
+			router.Get("/reconciliation/reports", r.handler.ListReconciliationReports)
+			router.Get("/reconciliation/reports/{id}", r.handler.GetReconciliationReport)
+			router.Get("/reconciliation/reports/{id}/discrepancies", r.handler.ListDiscrepancies)
+			router.Post("/reconciliation/discrepancies/{id}/resolve", r.handler.ResolveDiscrepancy)
+		})
+
+		// Merchant resource routes, bearer token, scoped API key or OAuth client
+		router.Group(func(router chi.Router) {
+			// Limited by IP before authenticating, so guessing keys, tokens
+			// or signatures is limited as well.
+			r.useRateLimitByIP(router, "api", r.rateLimits.API.IP)
+			router.Use(customMiddleware.APIKeyOrAuth(r.handler.JWTManager, r.services.APIKeys()))
+			router.Use(customMiddleware.APIKeyIPAllowlist(r.services.Merchants(), r.services.Audit(), r.logger))
+			r.useRateLimitByCaller(router, "api", r.rateLimits.API)
+			router.Use(customMiddleware.Audit(r.services.Audit(), r.logger))
 
 			// Payment routes
-			router.Post("/payments", r.handler.CreatePayment)
-			router.Get("/payments/{id}", r.handler.GetPayment)
-			router.Get("/payments", r.handler.ListPayments)
-			router.Post("/payments/{id}/process", r.handler.ProcessPayment)
+			router.Group(func(router chi.Router) {
+				router.Use(customMiddleware.RequireScope(oauth.ScopePaymentsWrite))
+
+				router.Post("/payments", r.handler.CreatePayment)
+				router.Get("/payments/{id}", r.handler.GetPayment)
+				router.Get("/payments", r.handler.ListPayments)
+				router.Post("/payments/{id}/process", r.handler.ProcessPayment)
+				router.Post("/payments/{id}/capture", r.handler.CapturePayment)
+				router.Get("/payments/{id}/transitions", r.handler.ListPaymentTransitions)
+			})
+
+			// Customer routes
+			router.Group(func(router chi.Router) {
+				router.Use(customMiddleware.RequireScope(oauth.ScopeCustomersWrite))
+
+				router.Post("/customers", r.handler.CreateCustomer)
+				router.Get("/customers", r.handler.ListCustomers)
+				router.Get("/customers/{id}", r.handler.GetCustomer)
+				router.Put("/customers/{id}", r.handler.UpdateCustomer)
+				router.Delete("/customers/{id}", r.handler.DeleteCustomer)
+				router.Get("/customers/{id}/payments", r.handler.ListCustomerPayments)
+				router.Post("/customers/{id}/payment-methods", r.handler.AddPaymentMethod)
+				router.Get("/customers/{id}/payment-methods", r.handler.ListPaymentMethods)
+				router.Post("/customers/{id}/payment-methods/{methodID}/default", r.handler.SetDefaultPaymentMethod)
+				router.Delete("/customers/{id}/payment-methods/{methodID}", r.handler.RemovePaymentMethod)
+			})
 
 			// Refund routes
-			router.Post("/refunds", r.handler.CreateRefund)
-			router.Get("/refunds/{id}", r.handler.GetRefund)
-			router.Get("/refunds", r.handler.ListRefunds)
-			router.Post("/refunds/{id}/process", r.handler.ProcessRefund)
+			router.Group(func(router chi.Router) {
+				router.Use(customMiddleware.RequireScope(oauth.ScopeRefundsWrite))
+
+				router.Post("/refunds", r.handler.CreateRefund)
+				router.Get("/refunds/{id}", r.handler.GetRefund)
+				router.Get("/refunds", r.handler.ListRefunds)
+				router.Post("/refunds/{id}/process", r.handler.ProcessRefund)
+				router.Post("/refunds/{id}/cancel", r.handler.CancelRefund)
+			})
+
+			// Subscription routes
+			router.Group(func(router chi.Router) {
+				router.Use(customMiddleware.RequireScope(oauth.ScopeBillingWrite))
+
+				router.Post("/plans", r.handler.CreatePlan)
+				router.Get("/plans", r.handler.ListPlans)
+				router.Get("/plans/{id}", r.handler.GetPlan)
+				router.Post("/subscriptions", r.handler.CreateSubscription)
+				router.Get("/subscriptions", r.handler.ListSubscriptions)
+				router.Get("/subscriptions/{id}", r.handler.GetSubscription)
+				router.Post("/subscriptions/{id}/change-plan", r.handler.ChangeSubscriptionPlan)
+				router.Post("/subscriptions/{id}/cancel", r.handler.CancelSubscription)
+				router.Get("/subscriptions/{id}/events", r.handler.ListSubscriptionEvents)
+			})
 		})
 	})
 }
 
+func (r *Router) useRateLimitByIP(router chi.Router, group string, limit ratelimit.Limit) {
+	if r.limiter != nil {
+		router.Use(r.limiter.ByIP(group, limit))
+	}
+}
+
+func (r *Router) useRateLimitByCaller(router chi.Router, group string, policy ratelimit.Policy) {
+	if r.limiter != nil {
+		router.Use(r.limiter.ByCaller(group, policy))
+	}
+}
+
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	r.router.ServeHTTP(w, req)
 }
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// SessionDenylist remembers revoked sessions whose access tokens may still be
+// unexpired. Entries are kept for one access token TTL after the revocation;
+// after that every token of the session has expired anyway.
+type SessionDenylist struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	revoked map[string]time.Time
+}
+
+func NewSessionDenylist(ttl time.Duration) *SessionDenylist {
+	return &SessionDenylist{
+		ttl:     ttl,
+		revoked: make(map[string]time.Time),
+	}
+}
+
+// Add denies the session from revokedAt on.
+func (d *SessionDenylist) Add(sessionID string, revokedAt time.Time) {
+	until := revokedAt.Add(d.ttl)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if until.After(d.revoked[sessionID]) {
+		d.revoked[sessionID] = until
+	}
+}
+
+func (d *SessionDenylist) Contains(sessionID string, now time.Time) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	until, ok := d.revoked[sessionID]
+	return ok && now.Before(until)
+}
+
+// Prune drops the entries that have run out.
+func (d *SessionDenylist) Prune(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for sessionID, until := range d.revoked {
+		if !now.Before(until) {
+			delete(d.revoked, sessionID)
+		}
+	}
+}
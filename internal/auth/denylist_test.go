@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionDenylist(t *testing.T) {
+	now := time.Now()
+	d := NewSessionDenylist(15 * time.Minute)
+
+	d.Add("session1", now)
+	assert.True(t, d.Contains("session1", now.Add(14*time.Minute)))
+	assert.False(t, d.Contains("session1", now.Add(15*time.Minute)))
+	assert.False(t, d.Contains("session2", now))
+
+	// A later revocation extends the entry, an earlier one does not cut it short.
+	d.Add("session1", now.Add(10*time.Minute))
+	d.Add("session1", now.Add(-10*time.Minute))
+	assert.True(t, d.Contains("session1", now.Add(20*time.Minute)))
+
+	d.Prune(now.Add(time.Hour))
+	assert.Empty(t, d.revoked)
+}
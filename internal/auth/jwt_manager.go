@@ -1,12 +1,15 @@
 package auth
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
-	"github.com/popeskul/payment-gateway/internal/domain"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/domain"
 )
 
 var (
@@ -14,95 +17,301 @@ var (
 	ErrExpiredToken = errors.New("expired token")
 )
 
+// JWTManager issues access tokens signed by the key ring, or with the HS256
+// access token secret when keys is nil. Refresh tokens are only ever read by
+// this service and stay HS256.
 type JWTManager struct {
 	config     ports.AuthConfig
 	tokenStore ports.TokenStore
+	keys       *KeyRing
+	revoked    *SessionDenylist
 }
 
-func NewJWTManager(config ports.AuthConfig, tokenStore ports.TokenStore) *JWTManager {
+func NewJWTManager(config ports.AuthConfig, tokenStore ports.TokenStore, keys *KeyRing) *JWTManager {
 	return &JWTManager{
 		config:     config,
 		tokenStore: tokenStore,
+		keys:       keys,
+		revoked:    NewSessionDenylist(config.GetAccessTokenTTL()),
 	}
 }
 
-func (m *JWTManager) GenerateTokenPair(userID string) (accessToken, refreshToken string, err error) {
-	accessToken, err = m.generateAccessToken(userID)
-	if err != nil {
+func (m *JWTManager) GenerateTokenPair(userID string, client domain.SessionClient) (accessToken, refreshToken string, err error) {
+	now := time.Now()
+	session := &domain.Session{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		Device:     domain.DeviceFromUserAgent(client.UserAgent),
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(m.config.GetRefreshTokenTTL()),
+	}
+	if err := m.tokenStore.CreateSession(session); err != nil {
 		return "", "", err
 	}
 
-	refreshToken, err = m.generateRefreshToken(userID)
+	return m.issueTokenPair(userID, session.ID, now)
+}
+
+func (m *JWTManager) ValidateAccessToken(tokenString string) (*domain.Claims, error) {
+	if m.keys == nil {
+		return m.validate(tokenString, hmacKey(m.config.GetAccessTokenSecret()))
+	}
+
+	return m.validate(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := m.keys.VerificationKey(kid, time.Now())
+		if !ok || token.Method.Alg() != key.Algorithm {
+			return nil, ErrInvalidToken
+		}
+		return key.private.Public(), nil
+	})
+}
+
+func (m *JWTManager) GenerateClientToken(clientID, scope string) (accessToken string, expiresIn time.Duration, err error) {
+	now := time.Now()
+	ttl := m.config.GetClientTokenTTL()
+
+	accessToken, err = m.signAccessToken(&domain.Claims{
+		ClientID: clientID,
+		Scope:    scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   clientID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}, now)
 	if err != nil {
-		return "", "", err
+		return "", 0, err
 	}
+	return accessToken, ttl, nil
+}
 
-	err = m.tokenStore.StoreRefreshToken(userID, refreshToken)
+func (m *JWTManager) JWKS() domain.JSONWebKeySet {
+	if m.keys == nil {
+		return domain.JSONWebKeySet{Keys: []domain.JSONWebKey{}}
+	}
+	return m.keys.JWKS(time.Now())
+}
+
+func (m *JWTManager) RefreshTokens(refreshToken string, client domain.SessionClient) (newAccessToken, newRefreshToken string, err error) {
+	claims, err := m.validate(refreshToken, hmacKey(m.config.GetRefreshTokenSecret()))
 	if err != nil {
 		return "", "", err
 	}
 
-	return accessToken, refreshToken, nil
-}
+	hash := hashRefreshToken(refreshToken)
+	stored, err := m.tokenStore.GetRefreshToken(hash)
+	if err != nil || stored.UserID != claims.UserID {
+		return "", "", ErrInvalidToken
+	}
 
-func (m *JWTManager) ValidateAccessToken(tokenString string) (*domain.Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &domain.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(m.config.GetAccessTokenSecret()), nil
-	})
+	if stored.RevokedAt != nil {
+		return "", "", ErrInvalidToken
+	}
 
-	if err != nil {
-		if errors.Is(err, jwt.ErrTokenExpired) {
-			return nil, ErrExpiredToken
+	if stored.RotatedAt != nil {
+		return "", "", m.revokeReusedFamily(stored.FamilyID)
+	}
+
+	now := time.Now()
+	if !now.Before(stored.ExpiresAt) {
+		return "", "", ErrExpiredToken
+	}
+
+	if err := m.tokenStore.RotateRefreshToken(hash); err != nil {
+		if errors.Is(err, domain.ErrRefreshTokenReused) {
+			// Another request rotated the token first.
+			return "", "", m.revokeReusedFamily(stored.FamilyID)
 		}
-		return nil, ErrInvalidToken
+		return "", "", err
 	}
 
-	if claims, ok := token.Claims.(*domain.Claims); ok && token.Valid {
-		return claims, nil
+	if err := m.tokenStore.TouchSession(stored.FamilyID, client.IP, now, now.Add(m.config.GetRefreshTokenTTL())); err != nil {
+		return "", "", err
 	}
 
-	return nil, ErrInvalidToken
+	return m.issueTokenPair(stored.UserID, stored.FamilyID, now)
 }
 
-func (m *JWTManager) RefreshTokens(userID string, refreshToken string) (newAccessToken, newRefreshToken string, err error) {
-	if !m.tokenStore.IsRefreshTokenValid(userID, refreshToken) {
-		return "", "", ErrInvalidToken
+func (m *JWTManager) InvalidateRefreshToken(userID string, refreshToken string) error {
+	stored, err := m.tokenStore.GetRefreshToken(hashRefreshToken(refreshToken))
+	if err != nil || stored.UserID != userID {
+		// Nothing to log out of.
+		return nil
 	}
 
-	err = m.tokenStore.DeleteRefreshToken(userID, refreshToken)
+	return m.revokeSession(stored.FamilyID)
+}
+
+func (m *JWTManager) ListSessions(userID string) ([]*domain.Session, error) {
+	return m.tokenStore.ListSessions(userID)
+}
+
+func (m *JWTManager) RevokeSession(userID, sessionID string) error {
+	session, err := m.tokenStore.GetSession(sessionID)
 	if err != nil {
-		return "", "", err
+		return err
+	}
+	if session.UserID != userID {
+		return domain.ErrSessionNotFound
 	}
 
-	return m.GenerateTokenPair(userID)
+	return m.revokeSession(sessionID)
 }
 
-func (m *JWTManager) InvalidateRefreshToken(userID string, refreshToken string) error {
-	return m.tokenStore.DeleteRefreshToken(userID, refreshToken)
+func (m *JWTManager) RevokeOtherSessions(userID, currentSessionID string) (int, error) {
+	revoked, err := m.tokenStore.RevokeOtherSessions(userID, currentSessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for _, sessionID := range revoked {
+		m.revoked.Add(sessionID, now)
+	}
+	return len(revoked), nil
 }
 
-func (m *JWTManager) generateAccessToken(userID string) (string, error) {
-	claims := &domain.Claims{
-		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(m.config.GetAccessTokenTTL()) * time.Minute)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+func (m *JWTManager) RevokeAllSessions(userID string) (int, error) {
+	return m.RevokeOtherSessions(userID, "")
+}
+
+func (m *JWTManager) IsSessionRevoked(sessionID string) bool {
+	return m.revoked.Contains(sessionID, time.Now())
+}
+
+func (m *JWTManager) SyncRevokedSessions() error {
+	now := time.Now()
+	revoked, err := m.tokenStore.ListRevokedSessions(now.Add(-m.config.GetAccessTokenTTL()))
+	if err != nil {
+		return err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(m.config.GetAccessTokenSecret()))
+	m.revoked.Prune(now)
+	for _, sessionID := range revoked {
+		m.revoked.Add(sessionID, now)
+	}
+	return nil
 }
 
-func (m *JWTManager) generateRefreshToken(userID string) (string, error) {
-	claims := &domain.Claims{
-		UserID: userID,
+// revokeSession revokes the session with its refresh tokens and denies its
+// access tokens here at once; other instances follow on their next
+// SyncRevokedSessions.
+func (m *JWTManager) revokeSession(sessionID string) error {
+	if err := m.tokenStore.RevokeTokenFamily(sessionID); err != nil {
+		return err
+	}
+	m.revoked.Add(sessionID, time.Now())
+	return nil
+}
+
+// revokeReusedFamily is called when a rotated token comes back. Either the
+// client or an attacker holds a copy, and there is no telling which, so every
+// token of the family stops working.
+func (m *JWTManager) revokeReusedFamily(familyID string) error {
+	if err := m.revokeSession(familyID); err != nil {
+		return err
+	}
+	return domain.ErrRefreshTokenReused
+}
+
+// issueTokenPair issues tokens of the session, whose ID doubles as the refresh
+// token family.
+func (m *JWTManager) issueTokenPair(userID, familyID string, now time.Time) (accessToken, refreshToken string, err error) {
+	accessToken, err = m.signAccessToken(&domain.Claims{
+		UserID:    userID,
+		SessionID: familyID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(m.config.GetRefreshTokenTTL()) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.config.GetAccessTokenTTL())),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
+	}, now)
+	if err != nil {
+		return "", "", err
 	}
 
+	expiresAt := now.Add(m.config.GetRefreshTokenTTL())
+	refreshToken, err = m.sign(&domain.Claims{
+		UserID:   userID,
+		FamilyID: familyID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			// The ID makes every refresh token unique, even two issued in the
+			// same second.
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}, m.config.GetRefreshTokenSecret())
+	if err != nil {
+		return "", "", err
+	}
+
+	err = m.tokenStore.StoreRefreshToken(&domain.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashRefreshToken(refreshToken),
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+func (m *JWTManager) signAccessToken(claims *domain.Claims, now time.Time) (string, error) {
+	if m.keys == nil {
+		return m.sign(claims, m.config.GetAccessTokenSecret())
+	}
+
+	key, err := m.keys.SigningKey(now)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
+}
+
+func (m *JWTManager) sign(claims *domain.Claims, secret string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(m.config.GetRefreshTokenSecret()))
+	return token.SignedString([]byte(secret))
+}
+
+func (m *JWTManager) validate(tokenString string, keyFunc jwt.Keyfunc) (*domain.Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &domain.Claims{}, keyFunc)
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	if claims, ok := token.Claims.(*domain.Claims); ok && token.Valid {
+		return claims, nil
+	}
+
+	return nil, ErrInvalidToken
+}
+
+// hmacKey accepts HS256 tokens signed with secret only.
+func hmacKey(secret string) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidToken
+		}
+		return []byte(secret), nil
+	}
+}
+
+// hashRefreshToken returns the hex SHA-256 refresh tokens are stored under.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/domain"
+)
+
+func newTestJWTManager(t *testing.T) (*JWTManager, *ports.MockTokenStore) {
+	ctrl := gomock.NewController(t)
+
+	config := ports.NewMockAuthConfig(ctrl)
+	config.EXPECT().GetAccessTokenSecret().Return("access-secret").AnyTimes()
+	config.EXPECT().GetRefreshTokenSecret().Return("refresh-secret").AnyTimes()
+	config.EXPECT().GetAccessTokenTTL().Return(15 * time.Minute).AnyTimes()
+	config.EXPECT().GetRefreshTokenTTL().Return(24 * time.Hour).AnyTimes()
+	config.EXPECT().GetClientTokenTTL().Return(time.Hour).AnyTimes()
+
+	store := ports.NewMockTokenStore(ctrl)
+	return NewJWTManager(config, store, nil), store
+}
+
+func TestJWTManager_GenerateTokenPair(t *testing.T) {
+	m, store := newTestJWTManager(t)
+
+	var session *domain.Session
+	store.EXPECT().CreateSession(gomock.Any()).DoAndReturn(func(s *domain.Session) error {
+		session = s
+		return nil
+	})
+	var stored *domain.RefreshToken
+	store.EXPECT().StoreRefreshToken(gomock.Any()).DoAndReturn(func(token *domain.RefreshToken) error {
+		stored = token
+		return nil
+	})
+
+	access, refresh, err := m.GenerateTokenPair("user123", domain.SessionClient{
+		UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:131.0) Gecko/20100101 Firefox/131.0",
+		IP:        "192.0.2.1",
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, session)
+	assert.Equal(t, "user123", session.UserID)
+	assert.Equal(t, "Firefox on Linux", session.Device)
+	assert.Equal(t, "192.0.2.1", session.IP)
+
+	claims, err := m.ValidateAccessToken(access)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", claims.UserID)
+	assert.Equal(t, session.ID, claims.SessionID)
+
+	_, err = m.ValidateAccessToken(refresh)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	require.NotNil(t, stored)
+	assert.Equal(t, "user123", stored.UserID)
+	assert.Equal(t, session.ID, stored.FamilyID)
+	assert.Equal(t, hashRefreshToken(refresh), stored.TokenHash)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), stored.ExpiresAt, time.Minute)
+}
+
+func TestJWTManager_GenerateClientToken(t *testing.T) {
+	m, _ := newTestJWTManager(t)
+
+	access, expiresIn, err := m.GenerateClientToken("client1", "read payments:write")
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, expiresIn)
+
+	claims, err := m.ValidateAccessToken(access)
+	require.NoError(t, err)
+	assert.Equal(t, "client1", claims.ClientID)
+	assert.Equal(t, "client1", claims.Subject)
+	assert.Equal(t, "read payments:write", claims.Scope)
+	assert.Empty(t, claims.UserID, "client tokens act for no user")
+	assert.Empty(t, claims.SessionID)
+}
+
+func TestJWTManager_RefreshTokens(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		stored      func(hash string) *domain.RefreshToken
+		setupMocks  func(store *ports.MockTokenStore, hash string)
+		expectedErr error
+	}{
+		{
+			name: "Rotates within the family",
+			stored: func(hash string) *domain.RefreshToken {
+				return &domain.RefreshToken{UserID: "user123", FamilyID: "family", TokenHash: hash, ExpiresAt: now.Add(time.Hour)}
+			},
+			setupMocks: func(store *ports.MockTokenStore, hash string) {
+				store.EXPECT().RotateRefreshToken(hash).Return(nil)
+				store.EXPECT().TouchSession("family", "192.0.2.7", gomock.Any(), gomock.Any()).Return(nil)
+				store.EXPECT().StoreRefreshToken(gomock.Any()).DoAndReturn(func(token *domain.RefreshToken) error {
+					assert.Equal(t, "family", token.FamilyID)
+					assert.NotEqual(t, hash, token.TokenHash)
+					return nil
+				})
+			},
+		},
+		{
+			name: "Reused token revokes the family",
+			stored: func(hash string) *domain.RefreshToken {
+				return &domain.RefreshToken{UserID: "user123", FamilyID: "family", TokenHash: hash, ExpiresAt: now.Add(time.Hour), RotatedAt: &now}
+			},
+			setupMocks: func(store *ports.MockTokenStore, hash string) {
+				store.EXPECT().RevokeTokenFamily("family").Return(nil)
+			},
+			expectedErr: domain.ErrRefreshTokenReused,
+		},
+		{
+			name: "Concurrent rotation revokes the family",
+			stored: func(hash string) *domain.RefreshToken {
+				return &domain.RefreshToken{UserID: "user123", FamilyID: "family", TokenHash: hash, ExpiresAt: now.Add(time.Hour)}
+			},
+			setupMocks: func(store *ports.MockTokenStore, hash string) {
+				store.EXPECT().RotateRefreshToken(hash).Return(domain.ErrRefreshTokenReused)
+				store.EXPECT().RevokeTokenFamily("family").Return(nil)
+			},
+			expectedErr: domain.ErrRefreshTokenReused,
+		},
+		{
+			name: "Revoked family",
+			stored: func(hash string) *domain.RefreshToken {
+				return &domain.RefreshToken{UserID: "user123", FamilyID: "family", TokenHash: hash, ExpiresAt: now.Add(time.Hour), RevokedAt: &now}
+			},
+			setupMocks:  func(store *ports.MockTokenStore, hash string) {},
+			expectedErr: ErrInvalidToken,
+		},
+		{
+			name: "Expired in store",
+			stored: func(hash string) *domain.RefreshToken {
+				return &domain.RefreshToken{UserID: "user123", FamilyID: "family", TokenHash: hash, ExpiresAt: now.Add(-time.Minute)}
+			},
+			setupMocks:  func(store *ports.MockTokenStore, hash string) {},
+			expectedErr: ErrExpiredToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, store := newTestJWTManager(t)
+
+			store.EXPECT().CreateSession(gomock.Any()).Return(nil)
+			store.EXPECT().StoreRefreshToken(gomock.Any()).Return(nil)
+			_, refresh, err := m.GenerateTokenPair("user123", domain.SessionClient{})
+			require.NoError(t, err)
+
+			hash := hashRefreshToken(refresh)
+			store.EXPECT().GetRefreshToken(hash).Return(tt.stored(hash), nil)
+			tt.setupMocks(store, hash)
+
+			access, newRefresh, err := m.RefreshTokens(refresh, domain.SessionClient{IP: "192.0.2.7"})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				if errors.Is(tt.expectedErr, domain.ErrRefreshTokenReused) {
+					assert.True(t, m.IsSessionRevoked("family"))
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotEmpty(t, access)
+			assert.NotEqual(t, refresh, newRefresh)
+		})
+	}
+}
+
+func TestJWTManager_RefreshTokens_RejectsAccessToken(t *testing.T) {
+	m, store := newTestJWTManager(t)
+
+	store.EXPECT().CreateSession(gomock.Any()).Return(nil)
+	store.EXPECT().StoreRefreshToken(gomock.Any()).Return(nil)
+	access, _, err := m.GenerateTokenPair("user123", domain.SessionClient{})
+	require.NoError(t, err)
+
+	_, _, err = m.RefreshTokens(access, domain.SessionClient{})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestJWTManager_RevokeSession(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupMocks  func(store *ports.MockTokenStore)
+		expectedErr error
+		revoked     bool
+	}{
+		{
+			name: "Own session",
+			setupMocks: func(store *ports.MockTokenStore) {
+				store.EXPECT().GetSession("session1").Return(&domain.Session{ID: "session1", UserID: "user123"}, nil)
+				store.EXPECT().RevokeTokenFamily("session1").Return(nil)
+			},
+			revoked: true,
+		},
+		{
+			name: "Another user's session",
+			setupMocks: func(store *ports.MockTokenStore) {
+				store.EXPECT().GetSession("session1").Return(&domain.Session{ID: "session1", UserID: "user456"}, nil)
+			},
+			expectedErr: domain.ErrSessionNotFound,
+		},
+		{
+			name: "Unknown session",
+			setupMocks: func(store *ports.MockTokenStore) {
+				store.EXPECT().GetSession("session1").Return(nil, domain.ErrSessionNotFound)
+			},
+			expectedErr: domain.ErrSessionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, store := newTestJWTManager(t)
+			tt.setupMocks(store)
+
+			err := m.RevokeSession("user123", "session1")
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, tt.revoked, m.IsSessionRevoked("session1"))
+		})
+	}
+}
+
+func TestJWTManager_RevokeOtherSessions(t *testing.T) {
+	m, store := newTestJWTManager(t)
+	store.EXPECT().RevokeOtherSessions("user123", "current").Return([]string{"laptop", "phone"}, nil)
+
+	revoked, err := m.RevokeOtherSessions("user123", "current")
+	require.NoError(t, err)
+	assert.Equal(t, 2, revoked)
+	assert.True(t, m.IsSessionRevoked("laptop"))
+	assert.True(t, m.IsSessionRevoked("phone"))
+	assert.False(t, m.IsSessionRevoked("current"))
+}
+
+func TestJWTManager_InvalidateRefreshToken(t *testing.T) {
+	m, store := newTestJWTManager(t)
+	store.EXPECT().GetRefreshToken(hashRefreshToken("refresh")).Return(&domain.RefreshToken{UserID: "user123", FamilyID: "session1"}, nil).Times(2)
+	store.EXPECT().RevokeTokenFamily("session1").Return(nil)
+
+	require.NoError(t, m.InvalidateRefreshToken("user456", "refresh"))
+	assert.False(t, m.IsSessionRevoked("session1"))
+
+	require.NoError(t, m.InvalidateRefreshToken("user123", "refresh"))
+	assert.True(t, m.IsSessionRevoked("session1"))
+}
+
+func TestJWTManager_SyncRevokedSessions(t *testing.T) {
+	m, store := newTestJWTManager(t)
+	store.EXPECT().ListRevokedSessions(gomock.Any()).DoAndReturn(func(since time.Time) ([]string, error) {
+		assert.WithinDuration(t, time.Now().Add(-15*time.Minute), since, time.Minute)
+		return []string{"elsewhere"}, nil
+	})
+
+	require.NoError(t, m.SyncRevokedSessions())
+	assert.True(t, m.IsSessionRevoked("elsewhere"))
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/popeskul/payment-gateway/internal/domain"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var ErrNoSigningKey = errors.New("no active signing key")
+
+// SigningKey is an asymmetric key that signs access tokens. It signs from
+// ActivatesAt and verifies until RetiresAt; a zero time means no bound.
+type SigningKey struct {
+	ID          string
+	Algorithm   string
+	ActivatesAt time.Time
+	RetiresAt   time.Time
+	private     crypto.Signer
+}
+
+// LoadSigningKey reads a PEM encoded PKCS#8 private key, or a PKCS#1 one for
+// RS256, from path.
+func LoadSigningKey(id, algorithm, path string, activatesAt, retiresAt time.Time) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key %q: %w", id, err)
+	}
+	return ParseSigningKey(id, algorithm, data, activatesAt, retiresAt)
+}
+
+func ParseSigningKey(id, algorithm string, pemData []byte, activatesAt, retiresAt time.Time) (*SigningKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %q is not PEM encoded", id)
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("signing key %q has unsupported PEM type %q", id, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %q: %w", id, err)
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("signing key %q is not a private key", id)
+	}
+
+	return &SigningKey{
+		ID:          id,
+		Algorithm:   algorithm,
+		ActivatesAt: activatesAt,
+		RetiresAt:   retiresAt,
+		private:     signer,
+	}, nil
+}
+
+func (k *SigningKey) validate() error {
+	if k.ID == "" {
+		return errors.New("signing key has no kid")
+	}
+	if !k.RetiresAt.IsZero() && !k.RetiresAt.After(k.ActivatesAt) {
+		return fmt.Errorf("signing key %q retires before it activates", k.ID)
+	}
+
+	switch k.Algorithm {
+	case AlgorithmRS256:
+		key, ok := k.private.(*rsa.PrivateKey)
+		if !ok {
+			return fmt.Errorf("signing key %q is not an RSA key", k.ID)
+		}
+		if key.N.BitLen() < 2048 {
+			return fmt.Errorf("signing key %q is shorter than 2048 bits", k.ID)
+		}
+	case AlgorithmEdDSA:
+		if _, ok := k.private.(ed25519.PrivateKey); !ok {
+			return fmt.Errorf("signing key %q is not an Ed25519 key", k.ID)
+		}
+	default:
+		return fmt.Errorf("signing key %q has unsupported algorithm %q", k.ID, k.Algorithm)
+	}
+	return nil
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+	if k.Algorithm == AlgorithmEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+func (k *SigningKey) active(now time.Time) bool {
+	return !now.Before(k.ActivatesAt) && !k.retired(now)
+}
+
+func (k *SigningKey) retired(now time.Time) bool {
+	return !k.RetiresAt.IsZero() && !now.Before(k.RetiresAt)
+}
+
+func (k *SigningKey) jwk() domain.JSONWebKey {
+	key := domain.JSONWebKey{
+		KeyID:     k.ID,
+		Algorithm: k.Algorithm,
+		Use:       "sig",
+	}
+
+	switch public := k.private.Public().(type) {
+	case *rsa.PublicKey:
+		key.KeyType = "RSA"
+		key.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		key.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		key.KeyType = "OKP"
+		key.Curve = "Ed25519"
+		key.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+	return key
+}
+
+// KeyRing holds the access token signing keys. Keys are rotated on a
+// schedule: the next key is added with a later ActivatesAt, and is published
+// from the start so that verifiers have it before the first token it signs,
+// while the previous key gets a RetiresAt no earlier than the new key's
+// ActivatesAt plus the access token TTL.
+type KeyRing struct {
+	keys []*SigningKey
+}
+
+func NewKeyRing(keys ...*SigningKey) (*KeyRing, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("key ring needs at least one signing key")
+	}
+
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if err := key.validate(); err != nil {
+			return nil, err
+		}
+		if seen[key.ID] {
+			return nil, fmt.Errorf("duplicate signing key %q", key.ID)
+		}
+		seen[key.ID] = true
+	}
+
+	return &KeyRing{keys: keys}, nil
+}
+
+// SigningKey returns the most recently activated key that is not retired.
+func (r *KeyRing) SigningKey(now time.Time) (*SigningKey, error) {
+	var current *SigningKey
+	for _, key := range r.keys {
+		if key.active(now) && (current == nil || !key.ActivatesAt.Before(current.ActivatesAt)) {
+			current = key
+		}
+	}
+	if current == nil {
+		return nil, ErrNoSigningKey
+	}
+	return current, nil
+}
+
+// VerificationKey returns the key with the given kid unless it is retired.
+func (r *KeyRing) VerificationKey(kid string, now time.Time) (*SigningKey, bool) {
+	for _, key := range r.keys {
+		if key.ID == kid {
+			return key, !key.retired(now)
+		}
+	}
+	return nil, false
+}
+
+// JWKS returns the public keys of every key that is not retired, including
+// those yet to activate.
+func (r *KeyRing) JWKS(now time.Time) domain.JSONWebKeySet {
+	set := domain.JSONWebKeySet{Keys: []domain.JSONWebKey{}}
+	for _, key := range r.keys {
+		if !key.retired(now) {
+			set.Keys = append(set.Keys, key.jwk())
+		}
+	}
+	return set
+}
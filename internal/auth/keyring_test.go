@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/domain"
+)
+
+func newRSAKey(t *testing.T, id string, activatesAt, retiresAt time.Time) *SigningKey {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return &SigningKey{ID: id, Algorithm: AlgorithmRS256, ActivatesAt: activatesAt, RetiresAt: retiresAt, private: private}
+}
+
+func newEd25519Key(t *testing.T, id string, activatesAt, retiresAt time.Time) *SigningKey {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return &SigningKey{ID: id, Algorithm: AlgorithmEdDSA, ActivatesAt: activatesAt, RetiresAt: retiresAt, private: private}
+}
+
+func TestParseSigningKey(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	key, err := ParseSigningKey("ed-1", AlgorithmEdDSA, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, private, key.private)
+
+	_, err = ParseSigningKey("ed-1", AlgorithmEdDSA, []byte("not a key"), time.Time{}, time.Time{})
+	assert.Error(t, err)
+}
+
+func TestNewKeyRing(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		keys    func(t *testing.T) []*SigningKey
+		wantErr bool
+	}{
+		{
+			name: "Valid keys",
+			keys: func(t *testing.T) []*SigningKey {
+				return []*SigningKey{newRSAKey(t, "rsa-1", time.Time{}, time.Time{}), newEd25519Key(t, "ed-1", now, now.Add(time.Hour))}
+			},
+		},
+		{
+			name:    "No keys",
+			keys:    func(t *testing.T) []*SigningKey { return nil },
+			wantErr: true,
+		},
+		{
+			name: "Duplicate kid",
+			keys: func(t *testing.T) []*SigningKey {
+				return []*SigningKey{newEd25519Key(t, "key", time.Time{}, time.Time{}), newEd25519Key(t, "key", time.Time{}, time.Time{})}
+			},
+			wantErr: true,
+		},
+		{
+			name: "Algorithm does not match the key",
+			keys: func(t *testing.T) []*SigningKey {
+				key := newEd25519Key(t, "key", time.Time{}, time.Time{})
+				key.Algorithm = AlgorithmRS256
+				return []*SigningKey{key}
+			},
+			wantErr: true,
+		},
+		{
+			name: "Retires before it activates",
+			keys: func(t *testing.T) []*SigningKey {
+				return []*SigningKey{newEd25519Key(t, "key", now, now.Add(-time.Hour))}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyRing(tt.keys(t)...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestKeyRing_Rotation(t *testing.T) {
+	now := time.Now()
+	old := newRSAKey(t, "old", now.Add(-48*time.Hour), now.Add(time.Hour))
+	next := newEd25519Key(t, "next", now.Add(30*time.Minute), time.Time{})
+	retired := newEd25519Key(t, "retired", now.Add(-96*time.Hour), now.Add(-48*time.Hour))
+
+	ring, err := NewKeyRing(old, next, retired)
+	require.NoError(t, err)
+
+	key, err := ring.SigningKey(now)
+	require.NoError(t, err)
+	assert.Equal(t, "old", key.ID)
+
+	key, err = ring.SigningKey(now.Add(45 * time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "next", key.ID)
+
+	_, ok := ring.VerificationKey("old", now.Add(45*time.Minute))
+	assert.True(t, ok)
+	_, ok = ring.VerificationKey("old", now.Add(2*time.Hour))
+	assert.False(t, ok)
+	_, ok = ring.VerificationKey("retired", now)
+	assert.False(t, ok)
+
+	var kids []string
+	for _, jwk := range ring.JWKS(now).Keys {
+		kids = append(kids, jwk.KeyID)
+	}
+	assert.Equal(t, []string{"old", "next"}, kids)
+
+	_, err = ring.SigningKey(now.Add(-120 * time.Hour))
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+}
+
+func TestKeyRing_JWKS(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa-1", time.Time{}, time.Time{})
+	edKey := newEd25519Key(t, "ed-1", time.Time{}, time.Time{})
+
+	ring, err := NewKeyRing(rsaKey, edKey)
+	require.NoError(t, err)
+
+	keys := ring.JWKS(time.Now()).Keys
+	require.Len(t, keys, 2)
+
+	assert.Equal(t, "RSA", keys[0].KeyType)
+	assert.Equal(t, "RS256", keys[0].Algorithm)
+	assert.Equal(t, "sig", keys[0].Use)
+	assert.Equal(t, "AQAB", keys[0].E)
+	assert.NotEmpty(t, keys[0].N)
+
+	assert.Equal(t, domain.JSONWebKey{
+		KeyType:   "OKP",
+		KeyID:     "ed-1",
+		Algorithm: "EdDSA",
+		Use:       "sig",
+		Curve:     "Ed25519",
+		X:         keys[1].X,
+	}, keys[1])
+	assert.Len(t, keys[1].X, 43)
+}
+
+func TestJWTManager_AsymmetricAccessTokens(t *testing.T) {
+	now := time.Now()
+	rsaKey := newRSAKey(t, "rsa-1", now.Add(-time.Hour), time.Time{})
+	edKey := newEd25519Key(t, "ed-1", now.Add(-time.Minute), time.Time{})
+
+	ring, err := NewKeyRing(rsaKey, edKey)
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	config := ports.NewMockAuthConfig(ctrl)
+	config.EXPECT().GetAccessTokenSecret().Return("access-secret").AnyTimes()
+	config.EXPECT().GetRefreshTokenSecret().Return("refresh-secret").AnyTimes()
+	config.EXPECT().GetAccessTokenTTL().Return(15 * time.Minute).AnyTimes()
+	config.EXPECT().GetRefreshTokenTTL().Return(24 * time.Hour).AnyTimes()
+	store := ports.NewMockTokenStore(ctrl)
+	store.EXPECT().CreateSession(gomock.Any()).Return(nil)
+	store.EXPECT().StoreRefreshToken(gomock.Any()).Return(nil)
+
+	m := NewJWTManager(config, store, ring)
+
+	access, _, err := m.GenerateTokenPair("user123", domain.SessionClient{})
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(access, &domain.Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", parsed.Header["alg"])
+	assert.Equal(t, "ed-1", parsed.Header["kid"])
+
+	claims, err := m.ValidateAccessToken(access)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", claims.UserID)
+
+	t.Run("Accepts tokens of a previous key", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, &domain.Claims{UserID: "user123"})
+		token.Header["kid"] = "rsa-1"
+		signed, err := token.SignedString(rsaKey.private)
+		require.NoError(t, err)
+
+		_, err = m.ValidateAccessToken(signed)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Rejects unknown kid", func(t *testing.T) {
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, &domain.Claims{UserID: "user123"})
+		token.Header["kid"] = "unknown"
+		signed, err := token.SignedString(private)
+		require.NoError(t, err)
+
+		_, err = m.ValidateAccessToken(signed)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Rejects algorithm other than the key's", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, &domain.Claims{UserID: "user123"})
+		token.Header["kid"] = "rsa-1"
+		signed, err := token.SignedString([]byte("access-secret"))
+		require.NoError(t, err)
+
+		_, err = m.ValidateAccessToken(signed)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Rejects HS256 tokens once keys are configured", func(t *testing.T) {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &domain.Claims{UserID: "user123"}).SignedString([]byte("access-secret"))
+		require.NoError(t, err)
+
+		_, err = m.ValidateAccessToken(signed)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
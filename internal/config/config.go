@@ -5,23 +5,36 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server        ServerConfig
-	Database      DatabaseConfig
-	Auth          AuthConfig
-	AcquiringBank AcquiringBankConfig
-	Logging       LoggingConfig
-	Metrics       MetricsConfig
+	Server         ServerConfig
+	Database       DatabaseConfig
+	Auth           AuthConfig
+	AcquiringBank  AcquiringBankConfig
+	FX             FXConfig
+	Reconciliation ReconciliationConfig
+	Sweeper        SweeperConfig
+	Subscriptions  SubscriptionsConfig
+	APIKeys        APIKeysConfig    `mapstructure:"api_keys"`
+	RateLimits     RateLimitsConfig `mapstructure:"rate_limits"`
+	Documents      DocumentsConfig
+	Mail           MailConfig
+	Logging        LoggingConfig
+	Metrics        MetricsConfig
 }
 
+// ServerConfig.TrustedProxies are the CIDR ranges of the load balancers and
+// proxies in front of the API. Only requests from them may set the client's
+// address with X-Forwarded-For or X-Real-IP.
 type ServerConfig struct {
-	Port         string
-	ReadTimeout  time.Duration
-	WriteTimeout time.Duration
-	IdleTimeout  time.Duration
+	Port           string
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	IdleTimeout    time.Duration
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 type DatabaseConfig struct {
@@ -40,8 +53,75 @@ type DatabaseConfig struct {
 type AuthConfig struct {
 	AccessTokenSecret  string
 	RefreshTokenSecret string
-	AccessTokenTTL     time.Duration
-	RefreshTokenTTL    time.Duration
+	AccessTokenTTL     time.Duration `mapstructure:"access_token_ttl"`
+	RefreshTokenTTL    time.Duration `mapstructure:"refresh_token_ttl"`
+	// ClientTokenTTL is the lifetime of OAuth client credentials tokens.
+	ClientTokenTTL time.Duration `mapstructure:"client_token_ttl"`
+	// EmailVerificationTTL and PasswordResetTTL are how long mailed links work.
+	EmailVerificationTTL time.Duration `mapstructure:"email_verification_ttl"`
+	PasswordResetTTL     time.Duration `mapstructure:"password_reset_ttl"`
+	// TOTPIssuer is the account name shown in authenticator apps.
+	TOTPIssuer string `mapstructure:"totp_issuer"`
+	// MFATokenTTL is how long a user has to enter their second factor after
+	// the password step of a login.
+	MFATokenTTL time.Duration `mapstructure:"mfa_token_ttl"`
+	Lockout     LockoutConfig
+	// PasswordHashing and PasswordPolicy apply to new passwords; stored
+	// hashes made with other parameters are replaced on the next login.
+	PasswordHashing PasswordHashingConfig `mapstructure:"password_hashing"`
+	PasswordPolicy  PasswordPolicyConfig  `mapstructure:"password_policy"`
+	// SessionSyncInterval is how often sessions revoked through another
+	// instance are picked up, and so how long their access tokens may still
+	// be accepted here.
+	SessionSyncInterval time.Duration `mapstructure:"session_sync_interval"`
+	// SigningKeys sign access tokens with RS256 or EdDSA. Without any, access
+	// tokens are signed with AccessTokenSecret.
+	SigningKeys []SigningKeyConfig `mapstructure:"signing_keys"`
+}
+
+// SigningKeyConfig is one access token signing key, a PEM private key file
+// named by its kid. A key signs from ActivatesAt and is accepted until
+// RetiresAt; leave either empty for no bound. To rotate, add the next key with
+// a later ActivatesAt and retire the current one no earlier than that plus
+// AccessTokenTTL.
+type SigningKeyConfig struct {
+	ID             string `mapstructure:"kid"`
+	Algorithm      string
+	PrivateKeyFile string    `mapstructure:"private_key_file"`
+	ActivatesAt    time.Time `mapstructure:"activates_at"`
+	RetiresAt      time.Time `mapstructure:"retires_at"`
+}
+
+// LockoutConfig throttles failed logins. After DelayAfter failures of an
+// account, each further attempt has to wait BaseDelay, doubling up to
+// MaxDelay. At AccountThreshold failures of an account, or IPThreshold from
+// one IP address, logins are locked for Duration. Failures older than Window
+// are forgotten.
+type LockoutConfig struct {
+	Window           time.Duration
+	DelayAfter       int           `mapstructure:"delay_after"`
+	BaseDelay        time.Duration `mapstructure:"base_delay"`
+	MaxDelay         time.Duration `mapstructure:"max_delay"`
+	AccountThreshold int           `mapstructure:"account_threshold"`
+	IPThreshold      int           `mapstructure:"ip_threshold"`
+	Duration         time.Duration
+}
+
+// PasswordHashingConfig holds the Argon2id parameters. Memory is in KiB.
+type PasswordHashingConfig struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// PasswordPolicyConfig limits the passwords users may choose. Passwords must
+// have MinCharacterClasses of lowercase, uppercase, digits and symbols, and
+// must not be in the BreachedPasswordsFile, a list with one password per line.
+type PasswordPolicyConfig struct {
+	MinLength             int    `mapstructure:"min_length"`
+	MaxLength             int    `mapstructure:"max_length"`
+	MinCharacterClasses   int    `mapstructure:"min_character_classes"`
+	BreachedPasswordsFile string `mapstructure:"breached_passwords_file"`
 }
 
 type AcquiringBankConfig struct {
@@ -49,6 +129,120 @@ type AcquiringBankConfig struct {
 	FailureRate     float64
 }
 
+type FXConfig struct {
+	RatesFile   string        `mapstructure:"rates_file"`
+	CacheBucket time.Duration `mapstructure:"cache_bucket"`
+}
+
+type ReconciliationConfig struct {
+	Enabled       bool
+	SettlementDir string `mapstructure:"settlement_dir"`
+	Interval      time.Duration
+	CSV           SettlementCSVConfig
+}
+
+// SettlementCSVConfig maps the acquirer's CSV header names onto the fields we
+// reconcile on, so that a new acquirer format only needs a config change.
+type SettlementCSVConfig struct {
+	Delimiter         string
+	TimeLayout        string `mapstructure:"time_layout"`
+	ReferenceColumn   string `mapstructure:"reference_column"`
+	TypeColumn        string `mapstructure:"type_column"`
+	AmountColumn      string `mapstructure:"amount_column"`
+	CurrencyColumn    string `mapstructure:"currency_column"`
+	ProcessedAtColumn string `mapstructure:"processed_at_column"`
+	PaymentType       string `mapstructure:"payment_type"`
+	RefundType        string `mapstructure:"refund_type"`
+}
+
+type SweeperConfig struct {
+	Enabled    bool
+	Interval   time.Duration
+	PendingTTL time.Duration `mapstructure:"pending_ttl"`
+	BatchSize  int           `mapstructure:"batch_size"`
+}
+
+// SubscriptionsConfig drives the recurring billing job. RetryIntervals is the
+// dunning schedule: the delay before each retry of a failed renewal, after
+// which the subscription is marked unpaid.
+type SubscriptionsConfig struct {
+	Enabled        bool
+	Interval       time.Duration
+	BatchSize      int             `mapstructure:"batch_size"`
+	RetryIntervals []time.Duration `mapstructure:"retry_intervals"`
+}
+
+// APIKeysConfig sets how long a rotated API key keeps working when the
+// rotation request does not specify a grace period, and how far the timestamp
+// of a signed request may be from the server's clock.
+type APIKeysConfig struct {
+	RotationGracePeriod   time.Duration `mapstructure:"rotation_grace_period"`
+	SignatureMaxClockSkew time.Duration `mapstructure:"signature_max_clock_skew"`
+	// SigningPepper derives the keys' signing secrets. Changing it invalidates
+	// every signing secret; without it signed requests are refused.
+	SigningPepper string
+}
+
+// RateLimitsConfig sets the token bucket limits of each route group. Store
+// "postgres" shares the buckets between replicas and "memory" keeps them per
+// replica; with --storage=memory they are always kept in memory. Buckets idle
+// for PruneAfter, which should be longer than any limit's period, are deleted
+// every PruneInterval.
+type RateLimitsConfig struct {
+	Enabled       bool
+	Store         string
+	PruneInterval time.Duration `mapstructure:"prune_interval"`
+	PruneAfter    time.Duration `mapstructure:"prune_after"`
+	// Auth applies to the public authentication routes, where callers are
+	// only known by IP address.
+	Auth      RateLimitPolicyConfig
+	Dashboard RateLimitPolicyConfig
+	API       RateLimitPolicyConfig
+}
+
+// RateLimitPolicyConfig holds a route group's limits for each kind of caller.
+// Requests made with an API key count towards both the key and its merchant.
+// A limit with zero requests is not enforced.
+type RateLimitPolicyConfig struct {
+	IP          RateLimitConfig
+	User        RateLimitConfig
+	APIKey      RateLimitConfig `mapstructure:"api_key"`
+	OAuthClient RateLimitConfig `mapstructure:"oauth_client"`
+	Merchant    RateLimitConfig
+}
+
+// RateLimitConfig allows Requests requests per Period, in bursts of up to
+// Requests.
+type RateLimitConfig struct {
+	Requests int
+	Period   time.Duration
+}
+
+// DocumentsConfig sets where uploaded KYC documents are stored and the largest
+// upload accepted, in bytes.
+type DocumentsConfig struct {
+	Dir     string
+	MaxSize int64 `mapstructure:"max_size"`
+}
+
+// MailConfig selects how transactional email is sent. Driver "file" writes
+// messages to Dir, or to stdout when Dir is empty, and "smtp" delivers them.
+// AppURL is the front end that mailed links point to.
+type MailConfig struct {
+	Driver string
+	From   string
+	Dir    string
+	AppURL string `mapstructure:"app_url"`
+	SMTP   SMTPConfig
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
 type LoggingConfig struct {
 	Level  string
 	Format string
@@ -103,12 +297,16 @@ func (a AuthConfig) GetRefreshTokenSecret() string {
 	return a.RefreshTokenSecret
 }
 
-func (a AuthConfig) GetAccessTokenTTL() int {
-	return int(a.AccessTokenTTL)
+func (a AuthConfig) GetAccessTokenTTL() time.Duration {
+	return a.AccessTokenTTL
 }
 
-func (a AuthConfig) GetRefreshTokenTTL() int {
-	return int(a.RefreshTokenTTL)
+func (a AuthConfig) GetRefreshTokenTTL() time.Duration {
+	return a.RefreshTokenTTL
+}
+
+func (a AuthConfig) GetClientTokenTTL() time.Duration {
+	return a.ClientTokenTTL
 }
 
 func LoadConfig() (*Config, error) {
@@ -123,13 +321,19 @@ func LoadConfig() (*Config, error) {
 	}
 
 	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
+	if err := viper.Unmarshal(&config, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		mapstructure.StringToTimeHookFunc(time.RFC3339),
+	))); err != nil {
 		return nil, err
 	}
 
 	config.Auth.AccessTokenSecret = viper.GetString("ACCESS_TOKEN_SECRET")
 	config.Auth.RefreshTokenSecret = viper.GetString("REFRESH_TOKEN_SECRET")
+	config.APIKeys.SigningPepper = viper.GetString("API_KEY_SIGNING_PEPPER")
 	config.Database.Password = viper.GetString("DB_PASSWORD")
+	config.Mail.SMTP.Password = viper.GetString("SMTP_PASSWORD")
 
 	if metricsEnabled := viper.GetString("METRICS_ENABLED"); metricsEnabled != "" {
 		enabled, err := strconv.ParseBool(metricsEnabled)
@@ -154,6 +358,12 @@ func LoadConfig() (*Config, error) {
 	if dbSSLMode := viper.GetString("DB_SSLMODE"); dbSSLMode != "" {
 		config.Database.SSLMode = dbSSLMode
 	}
+	if fxRatesFile := viper.GetString("FX_RATES_FILE"); fxRatesFile != "" {
+		config.FX.RatesFile = fxRatesFile
+	}
+	if settlementDir := viper.GetString("SETTLEMENT_DIR"); settlementDir != "" {
+		config.Reconciliation.SettlementDir = settlementDir
+	}
 	if logLevel := viper.GetString("LOG_LEVEL"); logLevel != "" {
 		config.Logging.Level = logLevel
 	}
@@ -203,6 +413,153 @@ func setDefaults(config *Config) {
 	if config.AcquiringBank.FailureRate == 0 {
 		config.AcquiringBank.FailureRate = 0.05
 	}
+	if config.FX.RatesFile == "" {
+		config.FX.RatesFile = "./configs/fx_rates.json"
+	}
+	if config.FX.CacheBucket == 0 {
+		config.FX.CacheBucket = 1 * time.Hour
+	}
+	if config.Reconciliation.SettlementDir == "" {
+		config.Reconciliation.SettlementDir = "./settlements"
+	}
+	if config.Reconciliation.Interval == 0 {
+		config.Reconciliation.Interval = 1 * time.Hour
+	}
+	if config.Reconciliation.CSV.Delimiter == "" {
+		config.Reconciliation.CSV.Delimiter = ","
+	}
+	if config.Reconciliation.CSV.TimeLayout == "" {
+		config.Reconciliation.CSV.TimeLayout = time.RFC3339
+	}
+	if config.Reconciliation.CSV.ReferenceColumn == "" {
+		config.Reconciliation.CSV.ReferenceColumn = "reference"
+	}
+	if config.Reconciliation.CSV.TypeColumn == "" {
+		config.Reconciliation.CSV.TypeColumn = "type"
+	}
+	if config.Reconciliation.CSV.AmountColumn == "" {
+		config.Reconciliation.CSV.AmountColumn = "amount"
+	}
+	if config.Reconciliation.CSV.CurrencyColumn == "" {
+		config.Reconciliation.CSV.CurrencyColumn = "currency"
+	}
+	if config.Reconciliation.CSV.ProcessedAtColumn == "" {
+		config.Reconciliation.CSV.ProcessedAtColumn = "processed_at"
+	}
+	if config.Reconciliation.CSV.PaymentType == "" {
+		config.Reconciliation.CSV.PaymentType = "payment"
+	}
+	if config.Reconciliation.CSV.RefundType == "" {
+		config.Reconciliation.CSV.RefundType = "refund"
+	}
+	if config.Sweeper.Interval == 0 {
+		config.Sweeper.Interval = 1 * time.Minute
+	}
+	if config.Sweeper.PendingTTL == 0 {
+		config.Sweeper.PendingTTL = 30 * time.Minute
+	}
+	if config.Sweeper.BatchSize == 0 {
+		config.Sweeper.BatchSize = 100
+	}
+	if config.Subscriptions.Interval == 0 {
+		config.Subscriptions.Interval = 5 * time.Minute
+	}
+	if config.Subscriptions.BatchSize == 0 {
+		config.Subscriptions.BatchSize = 100
+	}
+	if len(config.Subscriptions.RetryIntervals) == 0 {
+		config.Subscriptions.RetryIntervals = []time.Duration{24 * time.Hour, 72 * time.Hour, 7 * 24 * time.Hour}
+	}
+	if config.APIKeys.RotationGracePeriod == 0 {
+		config.APIKeys.RotationGracePeriod = 24 * time.Hour
+	}
+	if config.APIKeys.SignatureMaxClockSkew == 0 {
+		config.APIKeys.SignatureMaxClockSkew = 5 * time.Minute
+	}
+	if config.RateLimits.Store == "" {
+		config.RateLimits.Store = "postgres"
+	}
+	if config.RateLimits.PruneInterval == 0 {
+		config.RateLimits.PruneInterval = 10 * time.Minute
+	}
+	if config.RateLimits.PruneAfter == 0 {
+		config.RateLimits.PruneAfter = 1 * time.Hour
+	}
+	if config.Documents.Dir == "" {
+		config.Documents.Dir = "./documents"
+	}
+	if config.Documents.MaxSize == 0 {
+		config.Documents.MaxSize = 10 << 20
+	}
+	if config.Auth.EmailVerificationTTL == 0 {
+		config.Auth.EmailVerificationTTL = 48 * time.Hour
+	}
+	if config.Auth.PasswordResetTTL == 0 {
+		config.Auth.PasswordResetTTL = 1 * time.Hour
+	}
+	if config.Auth.TOTPIssuer == "" {
+		config.Auth.TOTPIssuer = "Payment Gateway"
+	}
+	if config.Auth.MFATokenTTL == 0 {
+		config.Auth.MFATokenTTL = 5 * time.Minute
+	}
+	if config.Auth.ClientTokenTTL == 0 {
+		config.Auth.ClientTokenTTL = time.Hour
+	}
+	if config.Auth.SessionSyncInterval == 0 {
+		config.Auth.SessionSyncInterval = 10 * time.Second
+	}
+	if config.Auth.Lockout.Window == 0 {
+		config.Auth.Lockout.Window = 15 * time.Minute
+	}
+	if config.Auth.Lockout.DelayAfter == 0 {
+		config.Auth.Lockout.DelayAfter = 3
+	}
+	if config.Auth.Lockout.BaseDelay == 0 {
+		config.Auth.Lockout.BaseDelay = 1 * time.Second
+	}
+	if config.Auth.Lockout.MaxDelay == 0 {
+		config.Auth.Lockout.MaxDelay = 30 * time.Second
+	}
+	if config.Auth.Lockout.AccountThreshold == 0 {
+		config.Auth.Lockout.AccountThreshold = 10
+	}
+	if config.Auth.Lockout.IPThreshold == 0 {
+		config.Auth.Lockout.IPThreshold = 50
+	}
+	if config.Auth.Lockout.Duration == 0 {
+		config.Auth.Lockout.Duration = 15 * time.Minute
+	}
+	if config.Auth.PasswordHashing.Memory == 0 {
+		config.Auth.PasswordHashing.Memory = 64 * 1024
+	}
+	if config.Auth.PasswordHashing.Iterations == 0 {
+		config.Auth.PasswordHashing.Iterations = 3
+	}
+	if config.Auth.PasswordHashing.Parallelism == 0 {
+		config.Auth.PasswordHashing.Parallelism = 2
+	}
+	if config.Auth.PasswordPolicy.MinLength == 0 {
+		config.Auth.PasswordPolicy.MinLength = 12
+	}
+	if config.Auth.PasswordPolicy.MaxLength == 0 {
+		config.Auth.PasswordPolicy.MaxLength = 128
+	}
+	if config.Auth.PasswordPolicy.MinCharacterClasses == 0 {
+		config.Auth.PasswordPolicy.MinCharacterClasses = 2
+	}
+	if config.Mail.Driver == "" {
+		config.Mail.Driver = "file"
+	}
+	if config.Mail.From == "" {
+		config.Mail.From = "Payment Gateway <no-reply@localhost>"
+	}
+	if config.Mail.AppURL == "" {
+		config.Mail.AppURL = "http://localhost:8080"
+	}
+	if config.Mail.SMTP.Port == 0 {
+		config.Mail.SMTP.Port = 587
+	}
 	if config.Logging.Level == "" {
 		config.Logging.Level = "info"
 	}
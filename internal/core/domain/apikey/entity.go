@@ -0,0 +1,110 @@
+package apikey
+
+import (
+	"errors"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+)
+
+// Scope limits what a key may do. Every key can read the merchant's data;
+// write scopes are granted explicitly.
+type Scope string
+
+const (
+	ScopePaymentsWrite Scope = "payments:write"
+	ScopeRefundsWrite  Scope = "refunds:write"
+	ScopeReadOnly      Scope = "read"
+)
+
+func (s Scope) Valid() bool {
+	switch s {
+	case ScopePaymentsWrite, ScopeRefundsWrite, ScopeReadOnly:
+		return true
+	}
+	return false
+}
+
+// ErrSignatureRequired is returned when a key that only accepts signed
+// requests is sent as a plain secret.
+var ErrSignatureRequired = errors.New("api key requires signed requests")
+
+// APIKey is a merchant credential for server-to-server calls. Only a hash of
+// the secret is stored; Prefix is kept so keys can be told apart in listings.
+// Keys with RequireSignature cannot be sent as they are, only used to sign
+// requests with their signing secret, so an intercepted request does not give
+// the key away.
+type APIKey struct {
+	ID               string     `json:"id"`
+	MerchantID       string     `json:"merchant_id"`
+	Mode             mode.Mode  `json:"mode"`
+	Name             string     `json:"name"`
+	Prefix           string     `json:"prefix"`
+	Hash             string     `json:"-"`
+	Scopes           []Scope    `json:"scopes"`
+	RequireSignature bool       `json:"require_signature"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt       *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	RotatedTo        string     `json:"rotated_to,omitempty"`
+	CreatedBy        string     `json:"created_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// Active reports whether the key can still authenticate at the given time.
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// Allows reports whether the key's scopes grant the member permission.
+func (k *APIKey) Allows(p member.Permission) bool {
+	if p == member.PermissionRead {
+		return true
+	}
+	for _, s := range k.Scopes {
+		if string(s) == string(p) {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateRequest struct {
+	Name             string     `json:"name"`
+	Mode             mode.Mode  `json:"mode,omitempty"`
+	Scopes           []Scope    `json:"scopes"`
+	RequireSignature bool       `json:"require_signature,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+}
+
+// RotateRequest replaces a key. GracePeriod, a Go duration such as "24h", is
+// how long the old key keeps working; it defaults to the configured value.
+type RotateRequest struct {
+	GracePeriod string `json:"grace_period,omitempty"`
+}
+
+// CreatedKey is returned once, when a key is created or rotated. Neither the
+// secret nor the signing secret can be retrieved afterwards. SigningSecret is
+// empty when the server is not configured to accept signed requests.
+type CreatedKey struct {
+	*APIKey
+	Secret        string `json:"secret"`
+	SigningSecret string `json:"signing_secret,omitempty"`
+}
+
+// SignedRequest is what a request signed with a key presents; see package
+// signing for the scheme. BodyHash is computed by the server from the body
+// it received.
+type SignedRequest struct {
+	KeyID      string
+	Method     string
+	RequestURI string
+	Timestamp  int64
+	Nonce      string
+	BodyHash   string
+	Signature  string
+}
@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Redacted replaces the value of sensitive fields in recorded changes.
+const Redacted = "[REDACTED]"
+
+// sensitiveFields are JSON field names whose values never reach the log, at
+// any depth. A change to them is still recorded, with both sides redacted.
+// Personal data is included because the log is kept after a customer or
+// owner is erased.
+var sensitiveFields = map[string]bool{
+	"password":      true,
+	"old_password":  true,
+	"new_password":  true,
+	"password_hash": true,
+	"secret":        true,
+	"token":         true,
+	"payment_token": true,
+	"key_hash":      true,
+	"tax_id":        true,
+	"date_of_birth": true,
+
+	"email":            true,
+	"name":             true,
+	"full_name":        true,
+	"phone":            true,
+	"address":          true,
+	"billing_address":  true,
+	"shipping_address": true,
+}
+
+// Diff compares the JSON form of two values field by field and returns the
+// top-level fields that differ, with sensitive values redacted. Either value
+// may be nil. It returns nil when nothing changed.
+func Diff(before, after interface{}) (map[string]Change, error) {
+	b, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes map[string]Change
+	record := func(field string, was, now interface{}) {
+		if reflect.DeepEqual(was, now) {
+			return
+		}
+		if changes == nil {
+			changes = make(map[string]Change)
+		}
+		if sensitiveFields[field] {
+			changes[field] = Change{Before: redactedOrNil(was), After: redactedOrNil(now)}
+			return
+		}
+		changes[field] = Change{Before: redact(was), After: redact(now)}
+	}
+
+	for field, was := range b {
+		record(field, was, a[field])
+	}
+	for field, now := range a {
+		if _, ok := b[field]; !ok {
+			record(field, nil, now)
+		}
+	}
+	return changes, nil
+}
+
+func toFields(v interface{}) (map[string]interface{}, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func redactedOrNil(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return Redacted
+}
+
+// redact masks sensitive fields inside nested objects and arrays.
+func redact(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			if sensitiveFields[k] {
+				out[k] = redactedOrNil(val)
+			} else {
+				out[k] = redact(val)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			out[i] = redact(val)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type ActorType string
+
+const (
+	ActorUser   ActorType = "user"
+	ActorAPIKey ActorType = "api_key"
+	// ActorOAuthClient is a client of the client credentials grant.
+	ActorOAuthClient ActorType = "oauth_client"
+)
+
+// ActionAPIKeyIPDenied records a request refused because the API key's
+// merchant does not allow the client's IP address. The target is the key.
+const ActionAPIKeyIPDenied = "api_key.ip_denied"
+
+// Entry records one successful mutating API request, or a denied one of the
+// kinds with an Action constant above. Entries form a single
+// hash chain: each one's Hash covers its own fields and the previous entry's
+// hash, so editing or removing an entry breaks every hash after it.
+type Entry struct {
+	ID         string            `json:"id"`
+	Sequence   int64             `json:"sequence"`
+	MerchantID string            `json:"merchant_id,omitempty"`
+	ActorType  ActorType         `json:"actor_type"`
+	ActorID    string            `json:"actor_id"`
+	IP         string            `json:"ip"`
+	RequestID  string            `json:"request_id,omitempty"`
+	Action     string            `json:"action"`
+	TargetType string            `json:"target_type,omitempty"`
+	TargetID   string            `json:"target_id,omitempty"`
+	Changes    map[string]Change `json:"changes,omitempty"`
+	PrevHash   string            `json:"prev_hash"`
+	Hash       string            `json:"hash"`
+	CreatedAt  time.Time         `json:"created_at"`
+
+	// Before and After are the target's state around the action, set by the
+	// handler. They are turned into Changes when the entry is recorded.
+	Before interface{} `json:"-"`
+	After  interface{} `json:"-"`
+}
+
+// Change is the old and new value of a top-level field. Either side is nil
+// when the field did not exist, e.g. for a created record.
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// ComputeHash returns the hex SHA-256 of the entry's content and PrevHash.
+// CreatedAt is hashed in UTC at microsecond precision, which is what the
+// database keeps.
+func (e *Entry) ComputeHash() string {
+	content := struct {
+		ID         string            `json:"id"`
+		Sequence   int64             `json:"sequence"`
+		MerchantID string            `json:"merchant_id"`
+		ActorType  ActorType         `json:"actor_type"`
+		ActorID    string            `json:"actor_id"`
+		IP         string            `json:"ip"`
+		RequestID  string            `json:"request_id"`
+		Action     string            `json:"action"`
+		TargetType string            `json:"target_type"`
+		TargetID   string            `json:"target_id"`
+		Changes    map[string]Change `json:"changes"`
+		PrevHash   string            `json:"prev_hash"`
+		CreatedAt  string            `json:"created_at"`
+	}{
+		e.ID, e.Sequence, e.MerchantID, e.ActorType, e.ActorID, e.IP, e.RequestID, e.Action, e.TargetType, e.TargetID,
+		e.Changes, e.PrevHash, e.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+	}
+
+	// Maps marshal with sorted keys, so the encoding is stable.
+	data, _ := json.Marshal(content)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyLink checks that e is intact and directly follows prev, which is nil
+// for the first entry of the log.
+func VerifyLink(prev, e *Entry) error {
+	if prev == nil {
+		if e.Sequence != 1 || e.PrevHash != "" {
+			return fmt.Errorf("entry %d does not start the chain", e.Sequence)
+		}
+	} else {
+		if e.Sequence != prev.Sequence+1 {
+			return fmt.Errorf("entry %d is followed by entry %d", prev.Sequence, e.Sequence)
+		}
+		if e.PrevHash != prev.Hash {
+			return fmt.Errorf("entry %d does not link to entry %d", e.Sequence, prev.Sequence)
+		}
+	}
+
+	if e.Hash != e.ComputeHash() {
+		return fmt.Errorf("entry %d was modified", e.Sequence)
+	}
+	return nil
+}
+
+// Filter narrows a log query. Empty fields match everything.
+type Filter struct {
+	MerchantID string
+	ActorID    string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// Verification is the result of walking the whole chain.
+type Verification struct {
+	Valid   bool  `json:"valid"`
+	Checked int64 `json:"checked"`
+	// BrokenAt is the sequence of the first entry that failed verification.
+	BrokenAt int64  `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
@@ -0,0 +1,44 @@
+package currency
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+type Rate struct {
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Value float64   `json:"value"`
+	AsOf  time.Time `json:"as_of"`
+}
+
+// Convert applies the rate to an amount expressed in the From currency and
+// rounds the result to the minor units of the To currency.
+func (r *Rate) Convert(amount float64) float64 {
+	return Round(amount*r.Value, r.To)
+}
+
+// minorUnits lists the ISO 4217 currencies whose minor unit is not a
+// hundredth of the major unit.
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// MinorUnits returns the number of decimals of a currency's minor unit, e.g.
+// 2 for USD, 0 for JPY and 3 for KWD.
+func MinorUnits(code string) int {
+	if units, ok := minorUnits[strings.ToUpper(code)]; ok {
+		return units
+	}
+	return 2
+}
+
+// Round rounds an amount to the minor units of its currency.
+func Round(amount float64, code string) float64 {
+	scale := math.Pow10(MinorUnits(code))
+	return math.Round(amount*scale) / scale
+}
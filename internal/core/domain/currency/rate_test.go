@@ -0,0 +1,54 @@
+package currency_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/currency"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   float64
+		code     string
+		expected float64
+	}{
+		{name: "Two decimals", amount: 10.005, code: "USD", expected: 10.01},
+		{name: "Lower case code", amount: 10.004, code: "eur", expected: 10.0},
+		{name: "Unknown currency uses two decimals", amount: 1.239, code: "XYZ", expected: 1.24},
+		{name: "JPY has no minor unit", amount: 1234.5, code: "JPY", expected: 1235},
+		{name: "KRW has no minor unit", amount: 999.4, code: "KRW", expected: 999},
+		{name: "KWD has three decimals", amount: 12.3456, code: "KWD", expected: 12.346},
+		{name: "BHD has three decimals", amount: 0.1234, code: "BHD", expected: 0.123},
+		{name: "Negative amounts", amount: -5.5, code: "JPY", expected: -6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, currency.Round(tt.amount, tt.code))
+		})
+	}
+}
+
+func TestRate_Convert(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     currency.Rate
+		amount   float64
+		expected float64
+	}{
+		{name: "To USD", rate: currency.Rate{From: "EUR", To: "USD", Value: 1.0871}, amount: 100, expected: 108.71},
+		{name: "To JPY", rate: currency.Rate{From: "USD", To: "JPY", Value: 151.237}, amount: 10.5, expected: 1588},
+		{name: "From JPY", rate: currency.Rate{From: "JPY", To: "USD", Value: 0.006612}, amount: 1588, expected: 10.5},
+		{name: "To KWD", rate: currency.Rate{From: "USD", To: "KWD", Value: 0.30745}, amount: 100, expected: 30.745},
+		{name: "To BHD", rate: currency.Rate{From: "USD", To: "BHD", Value: 0.376}, amount: 12.34, expected: 4.64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.rate.Convert(tt.amount))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package customer
+
+import (
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+)
+
+type Address struct {
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	State      string `json:"state,omitempty"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
+
+type Customer struct {
+	ID              string            `json:"id"`
+	MerchantID      string            `json:"merchant_id"`
+	Mode            mode.Mode         `json:"mode"`
+	Email           string            `json:"email"`
+	Name            string            `json:"name"`
+	Phone           string            `json:"phone,omitempty"`
+	BillingAddress  *Address          `json:"billing_address,omitempty"`
+	ShippingAddress *Address          `json:"shipping_address,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// PaymentMethod is a tokenized instrument saved on a customer. Only the
+// acquirer token and display details are stored, never the card number.
+type PaymentMethod struct {
+	ID         string    `json:"id"`
+	CustomerID string    `json:"customer_id"`
+	Type       string    `json:"type"`
+	Token      string    `json:"token"`
+	Brand      string    `json:"brand,omitempty"`
+	Last4      string    `json:"last4,omitempty"`
+	ExpMonth   int       `json:"exp_month,omitempty"`
+	ExpYear    int       `json:"exp_year,omitempty"`
+	IsDefault  bool      `json:"is_default"`
+	CreatedAt  time.Time `json:"created_at"`
+}
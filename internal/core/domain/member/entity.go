@@ -0,0 +1,90 @@
+package member
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrForbidden is returned when a user has no role on a merchant, or their
+// role does not grant the requested permission.
+var ErrForbidden = errors.New("forbidden")
+
+// ErrTwoFactorRequired is returned when the merchant requires two-factor
+// authentication for the user's role and the user has not turned it on.
+var ErrTwoFactorRequired = errors.New("two-factor authentication is required for this role")
+
+type Role string
+
+const (
+	RoleOwner     Role = "owner"
+	RoleAdmin     Role = "admin"
+	RoleDeveloper Role = "developer"
+	RoleSupport   Role = "support"
+	RoleReadOnly  Role = "read_only"
+)
+
+type Permission string
+
+const (
+	PermissionRead           Permission = "read"
+	PermissionMerchantUpdate Permission = "merchant:update"
+	PermissionMerchantDelete Permission = "merchant:delete"
+	PermissionMembersManage  Permission = "members:manage"
+	PermissionPaymentsWrite  Permission = "payments:write"
+	PermissionRefundsWrite   Permission = "refunds:write"
+	PermissionCustomersWrite Permission = "customers:write"
+	PermissionBillingWrite   Permission = "billing:write"
+	PermissionAPIKeysManage  Permission = "api_keys:manage"
+	PermissionAuditRead      Permission = "audit:read"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleOwner: {
+		PermissionRead, PermissionMerchantUpdate, PermissionMerchantDelete, PermissionMembersManage, PermissionAPIKeysManage,
+		PermissionPaymentsWrite, PermissionRefundsWrite, PermissionCustomersWrite, PermissionBillingWrite, PermissionAuditRead,
+	},
+	RoleAdmin: {
+		PermissionRead, PermissionMerchantUpdate, PermissionMembersManage, PermissionAPIKeysManage,
+		PermissionPaymentsWrite, PermissionRefundsWrite, PermissionCustomersWrite, PermissionBillingWrite, PermissionAuditRead,
+	},
+	RoleDeveloper: {
+		PermissionRead, PermissionAPIKeysManage, PermissionPaymentsWrite, PermissionRefundsWrite, PermissionCustomersWrite,
+		PermissionBillingWrite,
+	},
+	RoleSupport: {
+		PermissionRead, PermissionRefundsWrite, PermissionCustomersWrite,
+	},
+	RoleReadOnly: {
+		PermissionRead,
+	},
+}
+
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+func (r Role) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Member links a user to a merchant with a role.
+type Member struct {
+	MerchantID string    `json:"merchant_id"`
+	UserID     string    `json:"user_id"`
+	Email      string    `json:"email,omitempty"`
+	Role       Role      `json:"role"`
+	InvitedBy  string    `json:"invited_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type InviteRequest struct {
+	Email string `json:"email"`
+	Role  Role   `json:"role"`
+}
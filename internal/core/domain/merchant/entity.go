@@ -1,12 +1,52 @@
 package merchant
 
-import "time"
+import (
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+)
 
 type Merchant struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	ApiKey    string    `json:"api_key"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	Email              string `json:"email"`
+	SettlementCurrency string `json:"settlement_currency"`
+	// Status is the onboarding state; only active merchants can take live
+	// payments.
+	Status          Status           `json:"status"`
+	StatusReason    string           `json:"status_reason,omitempty"`
+	BusinessDetails *BusinessDetails `json:"business_details,omitempty"`
+	SubmittedAt     *time.Time       `json:"submitted_at,omitempty"`
+	ReviewedAt      *time.Time       `json:"reviewed_at,omitempty"`
+	ReviewedBy      string           `json:"reviewed_by,omitempty"`
+	// Settings is nil until the merchant saves its own; see ProcessingSettings.
+	Settings *Settings `json:"settings,omitempty"`
+	// DeletedAt is set when the merchant is offboarded. The row is kept so that
+	// its payments and refunds stay intact.
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+	DeletionReason string     `json:"deletion_reason,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// AcceptsPayments reports whether new payments can be taken for the merchant
+// in the given mode. Test mode is open from signup so integrations can be
+// built while onboarding is still under review.
+func (m *Merchant) AcceptsPayments(in mode.Mode) bool {
+	if m.DeletedAt != nil {
+		return false
+	}
+	return in == mode.Test || m.Status == StatusActive
+}
+
+// Created is returned once, when a merchant is created, with the test and
+// live keys it starts with. Their secrets cannot be retrieved afterwards.
+type Created struct {
+	*Merchant
+	APIKeys []*apikey.CreatedKey `json:"api_keys"`
+}
+
+type DeleteRequest struct {
+	Reason string `json:"reason"`
 }
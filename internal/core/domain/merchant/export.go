@@ -0,0 +1,33 @@
+package merchant
+
+import (
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	"github.com/popeskul/payment-gateway/internal/core/domain/subscription"
+)
+
+// Export is everything the gateway holds for a merchant, handed over when the
+// merchant is offboarded. Secrets such as API key hashes are never included.
+type Export struct {
+	GeneratedAt      time.Time                    `json:"generated_at"`
+	Merchant         *Merchant                    `json:"merchant"`
+	Members          []*member.Member             `json:"members"`
+	APIKeys          []*apikey.APIKey             `json:"api_keys"`
+	BeneficialOwners []*BeneficialOwner           `json:"beneficial_owners"`
+	Documents        []*Document                  `json:"documents"`
+	Customers        []*ExportedCustomer          `json:"customers"`
+	Payments         []*payment.Payment           `json:"payments"`
+	Refunds          []*refund.Refund             `json:"refunds"`
+	Plans            []*subscription.Plan         `json:"plans"`
+	Subscriptions    []*subscription.Subscription `json:"subscriptions"`
+}
+
+type ExportedCustomer struct {
+	*customer.Customer
+	PaymentMethods []*customer.PaymentMethod `json:"payment_methods"`
+}
@@ -0,0 +1,106 @@
+package merchant
+
+import "time"
+
+type Status string
+
+const (
+	StatusDraft     Status = "draft"
+	StatusSubmitted Status = "submitted"
+	StatusInReview  Status = "in_review"
+	StatusActive    Status = "active"
+	StatusRejected  Status = "rejected"
+	StatusSuspended Status = "suspended"
+)
+
+// transitions lists the statuses each status may move to. Rejected merchants
+// can fix their application and submit again.
+var transitions = map[Status][]Status{
+	StatusDraft:     {StatusSubmitted},
+	StatusSubmitted: {StatusInReview},
+	StatusInReview:  {StatusActive, StatusRejected},
+	StatusRejected:  {StatusSubmitted},
+	StatusActive:    {StatusSuspended},
+	StatusSuspended: {StatusActive},
+}
+
+func (s Status) CanTransitionTo(to Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Editable reports whether the application can still be changed by the
+// merchant.
+func (s Status) Editable() bool {
+	return s == StatusDraft || s == StatusRejected
+}
+
+type BusinessDetails struct {
+	LegalName          string `json:"legal_name"`
+	RegistrationNumber string `json:"registration_number"`
+	TaxID              string `json:"tax_id,omitempty"`
+	BusinessType       string `json:"business_type"`
+	Country            string `json:"country"`
+	Address            string `json:"address"`
+	Website            string `json:"website,omitempty"`
+}
+
+// Complete reports whether the details required for review are present.
+func (d *BusinessDetails) Complete() bool {
+	return d != nil && d.LegalName != "" && d.RegistrationNumber != "" && d.BusinessType != "" &&
+		d.Country != "" && d.Address != ""
+}
+
+// BeneficialOwner is a person who owns or controls part of the business.
+type BeneficialOwner struct {
+	ID               string    `json:"id"`
+	MerchantID       string    `json:"merchant_id"`
+	FullName         string    `json:"full_name"`
+	DateOfBirth      string    `json:"date_of_birth"`
+	Nationality      string    `json:"nationality"`
+	OwnershipPercent float64   `json:"ownership_percent"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type DocumentType string
+
+const (
+	DocumentIncorporation DocumentType = "certificate_of_incorporation"
+	DocumentProofAddress  DocumentType = "proof_of_address"
+	DocumentIdentity      DocumentType = "identity_document"
+	DocumentBankStatement DocumentType = "bank_statement"
+	DocumentOther         DocumentType = "other"
+)
+
+func (t DocumentType) Valid() bool {
+	switch t {
+	case DocumentIncorporation, DocumentProofAddress, DocumentIdentity, DocumentBankStatement, DocumentOther:
+		return true
+	}
+	return false
+}
+
+// Document is an uploaded KYC file. The content lives in the file store under
+// StorageKey.
+type Document struct {
+	ID          string       `json:"id"`
+	MerchantID  string       `json:"merchant_id"`
+	Type        DocumentType `json:"type"`
+	FileName    string       `json:"file_name"`
+	ContentType string       `json:"content_type"`
+	Size        int64        `json:"size"`
+	StorageKey  string       `json:"-"`
+	UploadedBy  string       `json:"uploaded_by,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// ReviewDecision moves a merchant out of review, or suspends and reinstates
+// an active one. A reason is required for rejections and suspensions.
+type ReviewDecision struct {
+	Status Status `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,147 @@
+package merchant
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+)
+
+// Settings control how a merchant's payments and refunds are processed.
+// Amount limits and volume caps are in the merchant's settlement currency;
+// zero or empty values mean no restriction.
+type Settings struct {
+	AllowedCurrencies     []string `json:"allowed_currencies,omitempty"`
+	AllowedPaymentMethods []string `json:"allowed_payment_methods,omitempty"`
+	MinAmount             float64  `json:"min_amount,omitempty"`
+	MaxAmount             float64  `json:"max_amount,omitempty"`
+	DailyVolumeLimit      float64  `json:"daily_volume_limit,omitempty"`
+	MonthlyVolumeLimit    float64  `json:"monthly_volume_limit,omitempty"`
+	RefundWindowDays      int      `json:"refund_window_days,omitempty"`
+	// AutoCapture captures payments as soon as they are processed. When off,
+	// processing only authorizes and the payment has to be captured separately.
+	AutoCapture         bool   `json:"auto_capture"`
+	StatementDescriptor string `json:"statement_descriptor,omitempty"`
+	// TwoFactorRoles are the member roles that must have two-factor
+	// authentication turned on to act on the merchant.
+	TwoFactorRoles []member.Role `json:"two_factor_roles,omitempty"`
+	// APIKeyIPAllowlist limits where the merchant's API keys can be used from.
+	APIKeyIPAllowlist IPAllowlist `json:"api_key_ip_allowlist"`
+}
+
+// IPAllowlist holds CIDR ranges, such as "203.0.113.0/24" or
+// "2001:db8::/48", for the API keys of each mode. Keys of a mode without
+// ranges can be used from any address.
+type IPAllowlist struct {
+	Live []string `json:"live,omitempty"`
+	Test []string `json:"test,omitempty"`
+}
+
+func (a IPAllowlist) ranges(m mode.Mode) []string {
+	if m.OrLive() == mode.Test {
+		return a.Test
+	}
+	return a.Live
+}
+
+// Validate reports the first range that is not valid CIDR notation.
+func (a IPAllowlist) Validate() error {
+	for _, ranges := range [][]string{a.Live, a.Test} {
+		for _, r := range ranges {
+			if _, err := netip.ParsePrefix(r); err != nil {
+				return fmt.Errorf("invalid CIDR range %q", r)
+			}
+		}
+	}
+	return nil
+}
+
+// Allows reports whether keys of the mode may be used from the IP address.
+func (a IPAllowlist) Allows(m mode.Mode, ip string) bool {
+	ranges := a.ranges(m)
+	if len(ranges) == 0 {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, r := range ranges {
+		prefix, err := netip.ParsePrefix(r)
+		if err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultSettings apply to merchants that have not configured anything.
+func DefaultSettings() Settings {
+	return Settings{AutoCapture: true}
+}
+
+// ProcessingSettings returns the merchant's settings, or the defaults when
+// none were saved.
+func (m *Merchant) ProcessingSettings() Settings {
+	if m.Settings == nil {
+		return DefaultSettings()
+	}
+	return *m.Settings
+}
+
+func (s Settings) AllowsCurrency(currency string) bool {
+	return len(s.AllowedCurrencies) == 0 || contains(s.AllowedCurrencies, currency)
+}
+
+func (s Settings) AllowsPaymentMethod(method string) bool {
+	return len(s.AllowedPaymentMethods) == 0 || contains(s.AllowedPaymentMethods, method)
+}
+
+func (s Settings) RequiresTwoFactor(role member.Role) bool {
+	for _, r := range s.TwoFactorRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+type LimitCode string
+
+const (
+	LimitCurrencyNotAllowed      LimitCode = "currency_not_allowed"
+	LimitPaymentMethodNotAllowed LimitCode = "payment_method_not_allowed"
+	LimitAmountTooSmall          LimitCode = "amount_below_minimum"
+	LimitAmountTooLarge          LimitCode = "amount_above_maximum"
+	LimitDailyVolumeExceeded     LimitCode = "daily_volume_exceeded"
+	LimitMonthlyVolumeExceeded   LimitCode = "monthly_volume_exceeded"
+	LimitRefundWindowExpired     LimitCode = "refund_window_expired"
+)
+
+// LimitError is returned when a payment or refund is rejected by the
+// merchant's settings. Code is stable and meant for API clients.
+type LimitError struct {
+	Code    LimitCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+func NewLimitError(code LimitCode, format string, args ...interface{}) *LimitError {
+	return &LimitError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *LimitError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,24 @@
+package mode
+
+// Mode separates sandbox traffic from real traffic. Every payment, refund,
+// customer, plan, subscription and API key belongs to exactly one mode, and
+// records of one mode are never visible to requests made in the other.
+type Mode string
+
+const (
+	Live Mode = "live"
+	// Test requests are processed by the simulator acquirer and never move money.
+	Test Mode = "test"
+)
+
+func (m Mode) Valid() bool {
+	return m == Live || m == Test
+}
+
+// OrLive returns the mode, defaulting to live when it is not set.
+func (m Mode) OrLive() Mode {
+	if m == "" {
+		return Live
+	}
+	return m
+}
@@ -0,0 +1,161 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+)
+
+var (
+	ErrClientNotFound = errors.New("oauth client not found")
+	ErrGrantNotFound  = errors.New("oauth grant not found")
+	// ErrInvalidClient is returned for unknown or revoked clients and wrong
+	// secrets alike.
+	ErrInvalidClient = errors.New("invalid client credentials")
+	ErrInvalidScope  = errors.New("invalid scope")
+)
+
+// Scope limits what a client token may do. Scopes are named after the member
+// permissions they grant.
+type Scope string
+
+const (
+	ScopeRead           Scope = "read"
+	ScopePaymentsWrite  Scope = "payments:write"
+	ScopeRefundsWrite   Scope = "refunds:write"
+	ScopeCustomersWrite Scope = "customers:write"
+	ScopeBillingWrite   Scope = "billing:write"
+)
+
+func (s Scope) Valid() bool {
+	switch s {
+	case ScopeRead, ScopePaymentsWrite, ScopeRefundsWrite, ScopeCustomersWrite, ScopeBillingWrite:
+		return true
+	}
+	return false
+}
+
+// ParseScopes splits a space-delimited scope parameter (RFC 6749 section 3.3).
+func ParseScopes(s string) ([]Scope, error) {
+	var scopes []Scope
+	for _, field := range strings.Fields(s) {
+		scope := Scope(field)
+		if !scope.Valid() {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, field)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// FormatScopes joins scopes into a scope parameter.
+func FormatScopes(scopes []Scope) string {
+	fields := make([]string, len(scopes))
+	for i, s := range scopes {
+		fields[i] = string(s)
+	}
+	return strings.Join(fields, " ")
+}
+
+func hasScope(scopes []Scope, scope Scope) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func allows(scopes []Scope, p member.Permission) bool {
+	return hasScope(scopes, Scope(p))
+}
+
+// Client is a machine credential registered by a partner. It acts on the
+// merchants that granted it access, within the scopes of both its own
+// registration and each merchant's grant. Only a hash of the secret is kept.
+type Client struct {
+	ID         string     `json:"client_id"`
+	OwnerID    string     `json:"owner_id"`
+	Name       string     `json:"name"`
+	SecretHash string     `json:"-"`
+	Scopes     []Scope    `json:"scopes"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (c *Client) Active() bool {
+	return c.RevokedAt == nil
+}
+
+// TokenScopes resolves the scope parameter of a token request. An empty
+// request gets every scope of the client; otherwise each requested scope has
+// to be registered for it.
+func (c *Client) TokenScopes(requested []Scope) ([]Scope, error) {
+	if len(requested) == 0 {
+		return c.Scopes, nil
+	}
+	for _, s := range requested {
+		if !hasScope(c.Scopes, s) {
+			return nil, fmt.Errorf("%w: %q is not registered for the client", ErrInvalidScope, s)
+		}
+	}
+	return requested, nil
+}
+
+// Grant is a merchant's consent for a client to act on its behalf.
+type Grant struct {
+	ClientID   string    `json:"client_id"`
+	MerchantID string    `json:"merchant_id"`
+	Scopes     []Scope   `json:"scopes"`
+	GrantedBy  string    `json:"granted_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (g *Grant) Allows(p member.Permission) bool {
+	return allows(g.Scopes, p)
+}
+
+// Principal is the client behind an access token, with the token's scopes.
+type Principal struct {
+	ClientID string
+	Scopes   []Scope
+}
+
+func (p *Principal) HasScope(scope Scope) bool {
+	return hasScope(p.Scopes, scope)
+}
+
+func (p *Principal) Allows(permission member.Permission) bool {
+	return allows(p.Scopes, permission)
+}
+
+type RegisterClientRequest struct {
+	Name   string  `json:"name"`
+	Scopes []Scope `json:"scopes"`
+}
+
+// RegisteredClient is returned once, at registration. The secret cannot be
+// retrieved afterwards.
+type RegisteredClient struct {
+	*Client
+	Secret string `json:"client_secret"`
+}
+
+type GrantRequest struct {
+	ClientID string  `json:"client_id"`
+	Scopes   []Scope `json:"scopes"`
+}
+
+// Introspection is the response of the token introspection endpoint
+// (RFC 7662). Inactive tokens only carry Active.
+type Introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+}
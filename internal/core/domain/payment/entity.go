@@ -1,6 +1,13 @@
 package payment
 
-import "time"
+import (
+	"errors"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+)
+
+var ErrNotFound = errors.New("payment not found")
 
 type PaymentStatus string
 
@@ -8,16 +15,47 @@ const (
 	PaymentStatusPending   PaymentStatus = "pending"
 	PaymentStatusCompleted PaymentStatus = "completed"
 	PaymentStatusFailed    PaymentStatus = "failed"
+	// PaymentStatusAuthorized means funds are held at the acquirer but not yet captured.
+	PaymentStatusAuthorized PaymentStatus = "authorized"
+	// PaymentStatusExpired is set by the sweeper on payments left pending past their TTL.
+	PaymentStatusExpired PaymentStatus = "expired"
+	// PaymentStatusVoided is set when an authorization is released without capture.
+	PaymentStatusVoided PaymentStatus = "voided"
+	// PaymentStatusProcessing claims a pending or authorized payment while it
+	// is charged, authorized or captured at the acquirer.
+	PaymentStatusProcessing PaymentStatus = "processing"
+	// PaymentStatusVoiding claims an expired authorization while the acquirer
+	// releases it.
+	PaymentStatusVoiding PaymentStatus = "voiding"
 )
 
 type Payment struct {
-	ID            string        `json:"id"`
-	MerchantID    string        `json:"merchant_id"`
-	Amount        float64       `json:"amount"`
-	Currency      string        `json:"currency"`
-	Status        PaymentStatus `json:"status"`
-	PaymentMethod string        `json:"payment_method"`
-	Description   string        `json:"description"`
-	CreatedAt     time.Time     `json:"created_at"`
-	UpdatedAt     time.Time     `json:"updated_at"`
+	ID                 string        `json:"id"`
+	MerchantID         string        `json:"merchant_id"`
+	Mode               mode.Mode     `json:"mode"`
+	Amount             float64       `json:"amount"`
+	Currency           string        `json:"currency"`
+	SettlementAmount   float64       `json:"settlement_amount"`
+	SettlementCurrency string        `json:"settlement_currency"`
+	FXRate             float64       `json:"fx_rate"`
+	FXRateTimestamp    time.Time     `json:"fx_rate_timestamp"`
+	Status             PaymentStatus `json:"status"`
+	PaymentMethod      string        `json:"payment_method"`
+	// PaymentToken references stored card details, used for merchant-initiated charges.
+	PaymentToken   string `json:"payment_token,omitempty"`
+	SubscriptionID string `json:"subscription_id,omitempty"`
+	// CustomerID and PaymentMethodID link the payment to a saved customer; when
+	// only the customer is given, their default payment method is charged.
+	CustomerID      string `json:"customer_id,omitempty"`
+	PaymentMethodID string `json:"payment_method_id,omitempty"`
+	Description     string `json:"description"`
+	// StatementDescriptor is what the cardholder sees on their statement,
+	// copied from the merchant's settings when the payment is created.
+	StatementDescriptor string     `json:"statement_descriptor,omitempty"`
+	AcquirerReference   string     `json:"acquirer_reference,omitempty"`
+	ProcessedAt         *time.Time `json:"processed_at,omitempty"`
+	// AuthorizationExpiresAt is when an uncaptured authorization gets voided.
+	AuthorizationExpiresAt *time.Time `json:"authorization_expires_at,omitempty"`
+	CreatedAt              time.Time  `json:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at"`
 }
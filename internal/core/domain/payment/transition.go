@@ -0,0 +1,20 @@
+package payment
+
+import "time"
+
+// StatusTransition is an append-only record of a payment changing status.
+type StatusTransition struct {
+	ID         string        `json:"id"`
+	PaymentID  string        `json:"payment_id"`
+	FromStatus PaymentStatus `json:"from_status"`
+	ToStatus   PaymentStatus `json:"to_status"`
+	Reason     string        `json:"reason"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// SweepResult summarises a single run of the stale payment sweeper.
+type SweepResult struct {
+	Expired int  `json:"expired"`
+	Voided  int  `json:"voided"`
+	Skipped bool `json:"skipped"`
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+)
+
+// Limit allows Requests requests per Period as a token bucket: the bucket
+// holds up to Requests tokens, each request takes one, and they are refilled
+// evenly over Period. A zero limit is not enforced.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// Policy is what RateLimit-Policy advertises, e.g. "100;w=60".
+func (l Limit) Policy() string {
+	return fmt.Sprintf("%d;w=%d", l.Requests, int64(l.Period/time.Second))
+}
+
+// Interval is the time it takes to refill one token.
+func (l Limit) Interval() time.Duration {
+	return l.Period / time.Duration(l.Requests)
+}
+
+// Bucket is the state of one key's bucket as of UpdatedAt.
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// NewBucket returns the full bucket a key starts with.
+func (l Limit) NewBucket(now time.Time) Bucket {
+	return Bucket{Tokens: float64(l.Requests), UpdatedAt: now}
+}
+
+// Key names a bucket and the limit it is kept under.
+type Key struct {
+	Name  string
+	Limit Limit
+}
+
+// Result is the state of one bucket after a take.
+type Result struct {
+	Limit Limit
+	// Allowed reports whether the bucket had a token. A token is only taken
+	// if every bucket of the take had one.
+	Allowed   bool
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next token, if the request was refused.
+	RetryAfter time.Duration
+}
+
+// TakeAll refills the buckets, buckets[i] under limits[i], for the time
+// since they were last updated and takes a token from each if every one of
+// them has a token. Otherwise none is taken, so a request refused by one limit
+// does not use up the others. The buckets are refilled either way.
+func TakeAll(limits []Limit, buckets []*Bucket, now time.Time) []Result {
+	allowed := true
+	for i, l := range limits {
+		l.refill(buckets[i], now)
+		if buckets[i].Tokens < 1 {
+			allowed = false
+		}
+	}
+
+	results := make([]Result, len(limits))
+	for i, l := range limits {
+		b := buckets[i]
+		result := Result{Limit: l, Allowed: b.Tokens >= 1}
+		if allowed {
+			b.Tokens--
+		}
+		if !result.Allowed {
+			result.RetryAfter = time.Duration((1 - b.Tokens) * float64(l.Interval()))
+		}
+
+		result.Remaining = int(b.Tokens)
+		result.Reset = time.Duration((float64(l.Requests) - b.Tokens) * float64(l.Interval()))
+		results[i] = result
+	}
+	return results
+}
+
+func (l Limit) refill(b *Bucket, now time.Time) {
+	if elapsed := now.Sub(b.UpdatedAt); elapsed > 0 {
+		b.Tokens += float64(elapsed) / float64(l.Interval())
+		b.UpdatedAt = now
+	}
+	if capacity := float64(l.Requests); b.Tokens > capacity {
+		b.Tokens = capacity
+	}
+}
+
+// Policy holds the limits of a route group for each kind of key. Requests
+// made with an API key also count towards the key's merchant.
+type Policy struct {
+	IP          Limit
+	User        Limit
+	APIKey      Limit
+	OAuthClient Limit
+	Merchant    Limit
+}
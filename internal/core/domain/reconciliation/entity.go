@@ -0,0 +1,88 @@
+package reconciliation
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrReportNotFound = errors.New("reconciliation report not found")
+	// ErrInProgress means another run holds the settlement file.
+	ErrInProgress = errors.New("settlement file is already being reconciled")
+)
+
+type RecordType string
+
+const (
+	RecordTypePayment RecordType = "payment"
+	RecordTypeRefund  RecordType = "refund"
+)
+
+type ReportStatus string
+
+const (
+	ReportStatusProcessing ReportStatus = "processing"
+	ReportStatusCompleted  ReportStatus = "completed"
+	ReportStatusFailed     ReportStatus = "failed"
+)
+
+type DiscrepancyType string
+
+const (
+	// DiscrepancyMissingInternally means the acquirer settled a transaction we have no record of.
+	DiscrepancyMissingInternally DiscrepancyType = "missing_internally"
+	// DiscrepancyMissingAtAcquirer means we processed a transaction the acquirer did not settle.
+	DiscrepancyMissingAtAcquirer DiscrepancyType = "missing_at_acquirer"
+	DiscrepancyAmountMismatch    DiscrepancyType = "amount_mismatch"
+)
+
+type DiscrepancyStatus string
+
+const (
+	DiscrepancyStatusOpen     DiscrepancyStatus = "open"
+	DiscrepancyStatusResolved DiscrepancyStatus = "resolved"
+)
+
+// SettlementLine is a single transaction as reported by the acquirer.
+type SettlementLine struct {
+	AcquirerReference string     `json:"acquirer_reference"`
+	RecordType        RecordType `json:"record_type"`
+	Amount            float64    `json:"amount"`
+	Currency          string     `json:"currency"`
+	ProcessedAt       time.Time  `json:"processed_at"`
+}
+
+type Report struct {
+	ID               string       `json:"id"`
+	FileName         string       `json:"file_name"`
+	Status           ReportStatus `json:"status"`
+	PeriodStart      *time.Time   `json:"period_start,omitempty"`
+	PeriodEnd        *time.Time   `json:"period_end,omitempty"`
+	TotalLines       int          `json:"total_lines"`
+	MatchedCount     int          `json:"matched_count"`
+	DiscrepancyCount int          `json:"discrepancy_count"`
+	Error            string       `json:"error,omitempty"`
+	CreatedAt        time.Time    `json:"created_at"`
+	CompletedAt      *time.Time   `json:"completed_at,omitempty"`
+}
+
+type Discrepancy struct {
+	ID                string            `json:"id"`
+	ReportID          string            `json:"report_id"`
+	Type              DiscrepancyType   `json:"type"`
+	RecordType        RecordType        `json:"record_type"`
+	RecordID          string            `json:"record_id,omitempty"`
+	AcquirerReference string            `json:"acquirer_reference"`
+	ExpectedAmount    *float64          `json:"expected_amount,omitempty"`
+	ActualAmount      *float64          `json:"actual_amount,omitempty"`
+	Currency          string            `json:"currency"`
+	Status            DiscrepancyStatus `json:"status"`
+	Resolution        string            `json:"resolution,omitempty"`
+	ResolvedBy        string            `json:"resolved_by,omitempty"`
+	ResolvedAt        *time.Time        `json:"resolved_at,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+}
+
+type ResolveRequest struct {
+	Resolution string `json:"resolution"`
+}
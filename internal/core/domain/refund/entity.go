@@ -1,22 +1,39 @@
 package refund
 
-import "time"
+import (
+	"errors"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+)
+
+var ErrNotFound = errors.New("refund not found")
 
 type RefundStatus string
 
 const (
-	RefundStatusPending   RefundStatus = "pending"
-	RefundStatusCompleted RefundStatus = "completed"
-	RefundStatusFailed    RefundStatus = "failed"
+	RefundStatusPending RefundStatus = "pending"
+	// RefundStatusProcessing marks a refund claimed for the acquirer; it can
+	// no longer be canceled.
+	RefundStatusProcessing RefundStatus = "processing"
+	RefundStatusCompleted  RefundStatus = "completed"
+	RefundStatusFailed     RefundStatus = "failed"
+	RefundStatusCanceled   RefundStatus = "canceled"
 )
 
 type Refund struct {
-	ID        string       `json:"id"`
-	PaymentID string       `json:"payment_id"`
-	Amount    float64      `json:"amount"`
-	Currency  string       `json:"currency"`
-	Reason    string       `json:"reason"`
-	Status    RefundStatus `json:"status"`
-	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
+	ID                 string       `json:"id"`
+	PaymentID          string       `json:"payment_id"`
+	Mode               mode.Mode    `json:"mode"`
+	CustomerID         string       `json:"customer_id,omitempty"`
+	Amount             float64      `json:"amount"`
+	Currency           string       `json:"currency"`
+	SettlementAmount   float64      `json:"settlement_amount"`
+	SettlementCurrency string       `json:"settlement_currency"`
+	Reason             string       `json:"reason"`
+	Status             RefundStatus `json:"status"`
+	AcquirerReference  string       `json:"acquirer_reference,omitempty"`
+	ProcessedAt        *time.Time   `json:"processed_at,omitempty"`
+	CreatedAt          time.Time    `json:"created_at"`
+	UpdatedAt          time.Time    `json:"updated_at"`
 }
@@ -0,0 +1,118 @@
+package subscription
+
+import (
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+)
+
+type Interval string
+
+const (
+	IntervalDay   Interval = "day"
+	IntervalWeek  Interval = "week"
+	IntervalMonth Interval = "month"
+	IntervalYear  Interval = "year"
+)
+
+type Status string
+
+const (
+	StatusTrialing Status = "trialing"
+	StatusActive   Status = "active"
+	// StatusPastDue means the last renewal failed and dunning retries are scheduled.
+	StatusPastDue Status = "past_due"
+	// StatusUnpaid means every dunning retry failed; billing has stopped.
+	StatusUnpaid   Status = "unpaid"
+	StatusCanceled Status = "canceled"
+)
+
+type EventType string
+
+const (
+	EventCreated       EventType = "subscription.created"
+	EventTrialEnded    EventType = "subscription.trial_ended"
+	EventRenewed       EventType = "subscription.renewed"
+	EventPaymentFailed EventType = "subscription.payment_failed"
+	EventUnpaid        EventType = "subscription.unpaid"
+	EventPlanChanged   EventType = "subscription.plan_changed"
+	EventCanceled      EventType = "subscription.canceled"
+)
+
+type Plan struct {
+	ID              string    `json:"id"`
+	MerchantID      string    `json:"merchant_id"`
+	Mode            mode.Mode `json:"mode"`
+	Name            string    `json:"name"`
+	Amount          float64   `json:"amount"`
+	Currency        string    `json:"currency"`
+	Interval        Interval  `json:"interval"`
+	IntervalCount   int       `json:"interval_count"`
+	TrialPeriodDays int       `json:"trial_period_days"`
+	Active          bool      `json:"active"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type Subscription struct {
+	ID            string    `json:"id"`
+	MerchantID    string    `json:"merchant_id"`
+	Mode          mode.Mode `json:"mode"`
+	PlanID        string    `json:"plan_id"`
+	PaymentMethod string    `json:"payment_method"`
+	// PaymentToken references stored card details. It is only accepted on
+	// creation, through CreateRequest, and never returned.
+	PaymentToken       string    `json:"-"`
+	Status             Status    `json:"status"`
+	CurrentPeriodStart time.Time `json:"current_period_start"`
+	CurrentPeriodEnd   time.Time `json:"current_period_end"`
+	// BillingAnchorDay is the day of the month monthly and yearly periods end
+	// on, taken from when billing started; months too short for it end on
+	// their last day.
+	BillingAnchorDay  int        `json:"billing_anchor_day"`
+	TrialEnd          *time.Time `json:"trial_end,omitempty"`
+	CancelAtPeriodEnd bool       `json:"cancel_at_period_end"`
+	CanceledAt        *time.Time `json:"canceled_at,omitempty"`
+	// ProrationBalance is added to the next renewal charge. It is negative when
+	// the customer has credit left over from a downgrade.
+	ProrationBalance float64    `json:"proration_balance"`
+	FailedAttempts   int        `json:"failed_attempts"`
+	NextRetryAt      *time.Time `json:"next_retry_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// Event records a lifecycle change so merchants can follow what happened to
+// a subscription and why.
+type Event struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	MerchantID     string    `json:"merchant_id"`
+	Type           EventType `json:"type"`
+	PaymentID      string    `json:"payment_id,omitempty"`
+	Message        string    `json:"message,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type CreateRequest struct {
+	MerchantID    string `json:"merchant_id"`
+	PlanID        string `json:"plan_id"`
+	PaymentMethod string `json:"payment_method"`
+	PaymentToken  string `json:"payment_token"`
+}
+
+type ChangePlanRequest struct {
+	PlanID string `json:"plan_id"`
+}
+
+type CancelRequest struct {
+	AtPeriodEnd bool `json:"at_period_end"`
+}
+
+// BillingResult summarises a single run of the billing job.
+type BillingResult struct {
+	Renewed  int  `json:"renewed"`
+	Failed   int  `json:"failed"`
+	Canceled int  `json:"canceled"`
+	Skipped  bool `json:"skipped"`
+}
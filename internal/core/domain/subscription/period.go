@@ -0,0 +1,61 @@
+package subscription
+
+import (
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/currency"
+)
+
+// NextPeriodEnd returns the end of a billing period that starts at start.
+// Monthly and yearly periods end on anchorDay, clamped to the last day of the
+// target month, so a plan anchored on Jan 31 renews on Feb 28/29 and then on
+// Mar 31 rather than on the 28th from then on. An anchorDay of 0 uses the day
+// of start.
+func (p *Plan) NextPeriodEnd(start time.Time, anchorDay int) time.Time {
+	count := p.IntervalCount
+	if count < 1 {
+		count = 1
+	}
+
+	switch p.Interval {
+	case IntervalDay:
+		return start.AddDate(0, 0, count)
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7*count)
+	case IntervalYear:
+		return addMonths(start, 12*count, anchorDay)
+	default:
+		return addMonths(start, count, anchorDay)
+	}
+}
+
+func addMonths(t time.Time, months, anchorDay int) time.Time {
+	firstOfTarget := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := firstOfTarget.AddDate(0, 1, -1).Day()
+
+	day := anchorDay
+	if day < 1 {
+		day = t.Day()
+	}
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return firstOfTarget.AddDate(0, 0, day-1)
+}
+
+// Prorate returns the amount owed (positive) or credited (negative) for
+// switching from oldAmount to newAmount, both in currencyCode, for the
+// remainder of a period.
+func Prorate(oldAmount, newAmount float64, currencyCode string, periodStart, periodEnd, now time.Time) float64 {
+	total := periodEnd.Sub(periodStart)
+	if total <= 0 || !now.Before(periodEnd) {
+		return 0
+	}
+	if now.Before(periodStart) {
+		now = periodStart
+	}
+
+	remaining := float64(periodEnd.Sub(now)) / float64(total)
+	return currency.Round((newAmount-oldAmount)*remaining, currencyCode)
+}
@@ -0,0 +1,101 @@
+package user
+
+import (
+	"fmt"
+	"time"
+)
+
+type ThrottleScope string
+
+const (
+	ThrottleAccount ThrottleScope = "account"
+	ThrottleIP      ThrottleScope = "ip"
+)
+
+// LoginThrottle counts the recent failed logins for an account, keyed by
+// email address, or for a client IP address.
+type LoginThrottle struct {
+	Scope        ThrottleScope
+	Key          string
+	Failures     int
+	LastFailedAt time.Time
+	// LockedUntil is when the next attempt is allowed, after a progressive
+	// delay or a lockout.
+	LockedUntil *time.Time
+}
+
+// RetryAfter returns how long logins stay blocked, or zero.
+func (t *LoginThrottle) RetryAfter(now time.Time) time.Duration {
+	if t == nil || t.LockedUntil == nil || !now.Before(*t.LockedUntil) {
+		return 0
+	}
+	return t.LockedUntil.Sub(now)
+}
+
+// LockoutPolicy decides how failed logins are throttled. Accounts get
+// progressive delays after DelayAfter failures and are locked after
+// AccountThreshold; IP addresses, which may be shared, are only locked after
+// IPThreshold. Zero thresholds turn the respective check off.
+type LockoutPolicy struct {
+	// Window is how long a failure counts; the count restarts after a quiet
+	// Window.
+	Window     time.Duration
+	DelayAfter int
+	// BaseDelay doubles with every failure past DelayAfter, up to MaxDelay.
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	AccountThreshold int
+	IPThreshold      int
+	LockoutDuration  time.Duration
+}
+
+func (p LockoutPolicy) threshold(scope ThrottleScope) int {
+	if scope == ThrottleIP {
+		return p.IPThreshold
+	}
+	return p.AccountThreshold
+}
+
+// Locked reports whether t has reached the lockout threshold.
+func (p LockoutPolicy) Locked(t *LoginThrottle) bool {
+	threshold := p.threshold(t.Scope)
+	return threshold > 0 && t.Failures >= threshold
+}
+
+// BlockedUntil returns when the next attempt is allowed after t's latest
+// failure, or nil if it is allowed right away.
+func (p LockoutPolicy) BlockedUntil(t *LoginThrottle) *time.Time {
+	if p.Locked(t) {
+		until := t.LastFailedAt.Add(p.LockoutDuration)
+		return &until
+	}
+
+	if t.Scope != ThrottleAccount || p.DelayAfter <= 0 || t.Failures < p.DelayAfter {
+		return nil
+	}
+
+	delay := p.MaxDelay
+	if shift := t.Failures - p.DelayAfter; shift < 16 && p.BaseDelay<<shift < p.MaxDelay {
+		delay = p.BaseDelay << shift
+	}
+	until := t.LastFailedAt.Add(delay)
+	return &until
+}
+
+// LoginThrottledError is returned for logins that are refused because of
+// earlier failures.
+type LoginThrottledError struct {
+	Scope      ThrottleScope
+	RetryAfter time.Duration
+	// Locked is set for a lockout, as opposed to a progressive delay, and
+	// LockedNow on the failed attempt that started it.
+	Locked    bool
+	LockedNow bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("too many failed logins, locked for %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many failed logins, retry in %s", e.RetryAfter.Round(time.Second))
+}
@@ -0,0 +1,78 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrWeakPassword is wrapped by the errors of PasswordPolicy.Check, which say
+// what the password is missing.
+var ErrWeakPassword = errors.New("password does not meet the password policy")
+
+// BreachedPasswords is a set of passwords known from breaches, lowercased.
+type BreachedPasswords map[string]struct{}
+
+// Contains matches case-insensitively, so that capitalizing a breached
+// password does not make it acceptable.
+func (b BreachedPasswords) Contains(password string) bool {
+	_, ok := b[strings.ToLower(password)]
+	return ok
+}
+
+// PasswordPolicy decides which new passwords are accepted. Lengths count
+// characters, not bytes. The character classes are lowercase and uppercase
+// letters, digits and everything else; a password has to use at least
+// MinCharacterClasses of them. Zero limits turn the respective check off.
+type PasswordPolicy struct {
+	MinLength           int
+	MaxLength           int
+	MinCharacterClasses int
+	Breached            BreachedPasswords
+}
+
+func (p PasswordPolicy) Check(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("%w: it must be at least %d characters long", ErrWeakPassword, p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("%w: it must be at most %d characters long", ErrWeakPassword, p.MaxLength)
+	}
+
+	if classes := characterClasses(password); classes < p.MinCharacterClasses {
+		return fmt.Errorf("%w: it must mix at least %d of lowercase letters, uppercase letters, digits and symbols", ErrWeakPassword, p.MinCharacterClasses)
+	}
+
+	if p.Breached.Contains(password) {
+		return fmt.Errorf("%w: it appears in a list of breached passwords", ErrWeakPassword)
+	}
+
+	return nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	n := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,29 @@
+package user
+
+import "time"
+
+type TokenPurpose string
+
+const (
+	TokenEmailVerification TokenPurpose = "email_verification"
+	TokenPasswordReset     TokenPurpose = "password_reset"
+	// TokenTwoFactorLogin is handed out after the password step of a login
+	// and exchanged, with a second factor, for the JWT pair.
+	TokenTwoFactorLogin TokenPurpose = "two_factor_login"
+)
+
+// Token is a single-use secret given to the user. Only its hash is stored.
+type Token struct {
+	ID        string
+	UserID    string
+	Purpose   TokenPurpose
+	Hash      string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// Usable reports whether the token has neither been used nor expired.
+func (t *Token) Usable(now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,127 @@
+package user
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters are the RFC 6238 defaults, which every authenticator app
+// supports.
+const (
+	TOTPDigits = 6
+	TOTPPeriod = 30 * time.Second
+	// TOTPSkew is how many periods before and after the current one a code is
+	// accepted for, to allow for clock drift on the user's device.
+	TOTPSkew = 1
+
+	RecoveryCodeCount = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new base32 encoded 160-bit secret.
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI returns the otpauth:// URI authenticator apps read from
+// a QR code.
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(TOTPDigits))
+	params.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// TOTPCounter returns the number of periods since the Unix epoch at t.
+func TOTPCounter(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+// TOTPCode returns the code for the secret and counter (RFC 4226 HOTP).
+func TOTPCode(secret string, counter int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid totp secret: %w", err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", TOTPDigits, value%1000000), nil
+}
+
+// MatchTOTP checks code against the periods around now and returns the
+// counter it belongs to. Only counters after lastCounter match, so a code
+// cannot be used twice.
+func MatchTOTP(secret, code string, now time.Time, lastCounter int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	current := TOTPCounter(now)
+	for counter := current - TOTPSkew; counter <= current+TOTPSkew; counter++ {
+		if counter <= lastCounter {
+			continue
+		}
+		expected, err := TOTPCode(secret, counter)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes returns RecoveryCodeCount one-time codes of the form
+// "1a2b3-c4d5e".
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		code := hex.EncodeToString(b)
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode drops the separator and spacing users may type
+// differently, so "1A2B3 C4D5E" matches "1a2b3-c4d5e".
+func NormalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', ' ':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
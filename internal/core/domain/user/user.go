@@ -1,15 +1,42 @@
 package user
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrEmailNotVerified is returned for operations that need a verified address.
+var ErrEmailNotVerified = errors.New("email address is not verified")
 
 type User struct {
-	ID           string    `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	FirstName    string    `json:"first_name"`
-	LastName     string    `json:"last_name"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           string `json:"id"`
+	Email        string `json:"email"`
+	PasswordHash string `json:"-"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	// Staff are platform operators, e.g. the reviewers of merchant
+	// applications. The flag is only set directly in the database.
+	Staff bool `json:"staff"`
+	// EmailVerifiedAt is set once the user follows the link sent to their
+	// address; creating merchants and managing keys and members requires it.
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	// TOTPSecret is set when enrollment starts; two-factor authentication is
+	// only on once TwoFactorEnabledAt is set by confirming a first code.
+	TOTPSecret         string     `json:"-"`
+	TwoFactorEnabledAt *time.Time `json:"two_factor_enabled_at,omitempty"`
+	// TOTPLastCounter is the period of the last accepted code, which cannot be
+	// used again.
+	TOTPLastCounter int64     `json:"-"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u *User) TwoFactorEnabled() bool {
+	return u.TwoFactorEnabledAt != nil
 }
 
 type RegisterRequest struct {
@@ -33,3 +60,42 @@ type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password"`
 	NewPassword string `json:"new_password"`
 }
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// TwoFactorLoginRequest completes a login for a user with two-factor
+// authentication. It carries the MFA token the password step returned and
+// either a TOTP code or a recovery code.
+type TwoFactorLoginRequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// TwoFactorEnrollment is shown to the user once, to add the account to an
+// authenticator app.
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
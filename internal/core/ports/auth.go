@@ -1,28 +1,84 @@
 package ports
 
-import "github.com/popeskul/payment-gateway/internal/domain"
+import (
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/domain"
+)
 
 type AuthConfig interface {
 	GetAccessTokenSecret() string
 	GetRefreshTokenSecret() string
-	GetAccessTokenTTL() int
-	GetRefreshTokenTTL() int
+	GetAccessTokenTTL() time.Duration
+	GetRefreshTokenTTL() time.Duration
+	GetClientTokenTTL() time.Duration
 }
 
+// TokenStore keeps sessions and their refresh tokens, stored by hash and
+// grouped into rotation families that share the session's ID.
 type TokenStore interface {
-	StoreRefreshToken(userID string, token string) error
-	DeleteRefreshToken(userID string, token string) error
-	IsRefreshTokenValid(userID string, token string) bool
+	StoreRefreshToken(token *domain.RefreshToken) error
+	GetRefreshToken(tokenHash string) (*domain.RefreshToken, error)
+	// RotateRefreshToken marks the token as rotated. It returns
+	// domain.ErrRefreshTokenReused if it already was or has been revoked, so a
+	// token can be rotated at most once even under concurrent requests.
+	RotateRefreshToken(tokenHash string) error
+	// RevokeTokenFamily revokes the session and every refresh token of it.
+	RevokeTokenFamily(familyID string) error
+
+	CreateSession(session *domain.Session) error
+	// GetSession returns domain.ErrSessionNotFound for unknown IDs.
+	GetSession(sessionID string) (*domain.Session, error)
+	// TouchSession records a refresh of the session.
+	TouchSession(sessionID, ip string, lastUsedAt, expiresAt time.Time) error
+	// ListSessions returns the user's sessions that are neither revoked nor
+	// expired, most recently used first.
+	ListSessions(userID string) ([]*domain.Session, error)
+	// RevokeOtherSessions revokes every session of the user but keepSessionID,
+	// with their refresh tokens, and returns the IDs it revoked.
+	RevokeOtherSessions(userID, keepSessionID string) ([]string, error)
+	// ListRevokedSessions returns the IDs of sessions revoked since the time.
+	ListRevokedSessions(since time.Time) ([]string, error)
 }
 
 type JWTManager interface {
-	GenerateTokenPair(userID string) (accessToken, refreshToken string, err error)
+	// GenerateTokenPair starts a new session, e.g. on login.
+	GenerateTokenPair(userID string, client domain.SessionClient) (accessToken, refreshToken string, err error)
 	ValidateAccessToken(tokenString string) (*domain.Claims, error)
-	RefreshTokens(userID string, refreshToken string) (newAccessToken, newRefreshToken string, err error)
+	// GenerateClientToken issues an access token of the client credentials
+	// grant. There is no refresh token; clients request a new one instead.
+	GenerateClientToken(clientID, scope string) (accessToken string, expiresIn time.Duration, err error)
+	// RefreshTokens rotates the refresh token and issues the next pair in its
+	// family. Presenting a rotated token revokes the family and returns
+	// domain.ErrRefreshTokenReused.
+	RefreshTokens(refreshToken string, client domain.SessionClient) (newAccessToken, newRefreshToken string, err error)
+	// InvalidateRefreshToken revokes the session of the user's refresh token.
 	InvalidateRefreshToken(userID string, refreshToken string) error
+
+	ListSessions(userID string) ([]*domain.Session, error)
+	// RevokeSession returns domain.ErrSessionNotFound unless the session is
+	// the user's.
+	RevokeSession(userID, sessionID string) error
+	// RevokeOtherSessions revokes all of the user's sessions but
+	// currentSessionID and returns how many it revoked.
+	RevokeOtherSessions(userID, currentSessionID string) (int, error)
+	// RevokeAllSessions revokes every session of the user, e.g. after their
+	// password was reset, and returns how many it revoked.
+	RevokeAllSessions(userID string) (int, error)
+	// IsSessionRevoked reports whether access tokens of the session have to be
+	// rejected even though they have not expired yet.
+	IsSessionRevoked(sessionID string) bool
+	// SyncRevokedSessions picks up sessions revoked by other instances.
+	SyncRevokedSessions() error
+	// JWKS returns the public keys access tokens are verified with. It is
+	// empty when tokens are signed with a shared secret.
+	JWKS() domain.JSONWebKeySet
 }
 
 type PasswordHasher interface {
 	HashPassword(password string) (string, error)
 	ComparePasswordAndHash(password, hash string) error
+	// NeedsRehash reports whether hash was made with another algorithm or
+	// other parameters than HashPassword uses now.
+	NeedsRehash(hash string) bool
 }
@@ -11,6 +11,7 @@ package ports
 
 import (
 	reflect "reflect"
+	time "time"
 
 	domain "github.com/popeskul/payment-gateway/internal/domain"
 	gomock "go.uber.org/mock/gomock"
@@ -54,10 +55,10 @@ func (mr *MockAuthConfigMockRecorder) GetAccessTokenSecret() *gomock.Call {
 }
 
 // GetAccessTokenTTL mocks base method.
-func (m *MockAuthConfig) GetAccessTokenTTL() int {
+func (m *MockAuthConfig) GetAccessTokenTTL() time.Duration {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetAccessTokenTTL")
-	ret0, _ := ret[0].(int)
+	ret0, _ := ret[0].(time.Duration)
 	return ret0
 }
 
@@ -67,6 +68,20 @@ func (mr *MockAuthConfigMockRecorder) GetAccessTokenTTL() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessTokenTTL", reflect.TypeOf((*MockAuthConfig)(nil).GetAccessTokenTTL))
 }
 
+// GetClientTokenTTL mocks base method.
+func (m *MockAuthConfig) GetClientTokenTTL() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClientTokenTTL")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetClientTokenTTL indicates an expected call of GetClientTokenTTL.
+func (mr *MockAuthConfigMockRecorder) GetClientTokenTTL() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClientTokenTTL", reflect.TypeOf((*MockAuthConfig)(nil).GetClientTokenTTL))
+}
+
 // GetRefreshTokenSecret mocks base method.
 func (m *MockAuthConfig) GetRefreshTokenSecret() string {
 	m.ctrl.T.Helper()
@@ -82,10 +97,10 @@ func (mr *MockAuthConfigMockRecorder) GetRefreshTokenSecret() *gomock.Call {
 }
 
 // GetRefreshTokenTTL mocks base method.
-func (m *MockAuthConfig) GetRefreshTokenTTL() int {
+func (m *MockAuthConfig) GetRefreshTokenTTL() time.Duration {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetRefreshTokenTTL")
-	ret0, _ := ret[0].(int)
+	ret0, _ := ret[0].(time.Duration)
 	return ret0
 }
 
@@ -118,46 +133,149 @@ func (m *MockTokenStore) EXPECT() *MockTokenStoreMockRecorder {
 	return m.recorder
 }
 
-// DeleteRefreshToken mocks base method.
-func (m *MockTokenStore) DeleteRefreshToken(arg0, arg1 string) error {
+// CreateSession mocks base method.
+func (m *MockTokenStore) CreateSession(arg0 *domain.Session) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteRefreshToken", arg0, arg1)
+	ret := m.ctrl.Call(m, "CreateSession", arg0)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteRefreshToken indicates an expected call of DeleteRefreshToken.
-func (mr *MockTokenStoreMockRecorder) DeleteRefreshToken(arg0, arg1 any) *gomock.Call {
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockTokenStoreMockRecorder) CreateSession(arg0 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRefreshToken", reflect.TypeOf((*MockTokenStore)(nil).DeleteRefreshToken), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockTokenStore)(nil).CreateSession), arg0)
 }
 
-// IsRefreshTokenValid mocks base method.
-func (m *MockTokenStore) IsRefreshTokenValid(arg0, arg1 string) bool {
+// GetRefreshToken mocks base method.
+func (m *MockTokenStore) GetRefreshToken(arg0 string) (*domain.RefreshToken, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsRefreshTokenValid", arg0, arg1)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "GetRefreshToken", arg0)
+	ret0, _ := ret[0].(*domain.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshToken indicates an expected call of GetRefreshToken.
+func (mr *MockTokenStoreMockRecorder) GetRefreshToken(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshToken", reflect.TypeOf((*MockTokenStore)(nil).GetRefreshToken), arg0)
+}
+
+// GetSession mocks base method.
+func (m *MockTokenStore) GetSession(arg0 string) (*domain.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", arg0)
+	ret0, _ := ret[0].(*domain.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockTokenStoreMockRecorder) GetSession(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockTokenStore)(nil).GetSession), arg0)
+}
+
+// ListRevokedSessions mocks base method.
+func (m *MockTokenStore) ListRevokedSessions(arg0 time.Time) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRevokedSessions", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRevokedSessions indicates an expected call of ListRevokedSessions.
+func (mr *MockTokenStoreMockRecorder) ListRevokedSessions(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRevokedSessions", reflect.TypeOf((*MockTokenStore)(nil).ListRevokedSessions), arg0)
+}
+
+// ListSessions mocks base method.
+func (m *MockTokenStore) ListSessions(arg0 string) ([]*domain.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", arg0)
+	ret0, _ := ret[0].([]*domain.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockTokenStoreMockRecorder) ListSessions(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockTokenStore)(nil).ListSessions), arg0)
+}
+
+// RevokeOtherSessions mocks base method.
+func (m *MockTokenStore) RevokeOtherSessions(arg0, arg1 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeOtherSessions", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeOtherSessions indicates an expected call of RevokeOtherSessions.
+func (mr *MockTokenStoreMockRecorder) RevokeOtherSessions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOtherSessions", reflect.TypeOf((*MockTokenStore)(nil).RevokeOtherSessions), arg0, arg1)
+}
+
+// RevokeTokenFamily mocks base method.
+func (m *MockTokenStore) RevokeTokenFamily(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeTokenFamily", arg0)
+	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// IsRefreshTokenValid indicates an expected call of IsRefreshTokenValid.
-func (mr *MockTokenStoreMockRecorder) IsRefreshTokenValid(arg0, arg1 any) *gomock.Call {
+// RevokeTokenFamily indicates an expected call of RevokeTokenFamily.
+func (mr *MockTokenStoreMockRecorder) RevokeTokenFamily(arg0 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRefreshTokenValid", reflect.TypeOf((*MockTokenStore)(nil).IsRefreshTokenValid), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeTokenFamily", reflect.TypeOf((*MockTokenStore)(nil).RevokeTokenFamily), arg0)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MockTokenStore) RotateRefreshToken(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockTokenStoreMockRecorder) RotateRefreshToken(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockTokenStore)(nil).RotateRefreshToken), arg0)
 }
 
 // StoreRefreshToken mocks base method.
-func (m *MockTokenStore) StoreRefreshToken(arg0, arg1 string) error {
+func (m *MockTokenStore) StoreRefreshToken(arg0 *domain.RefreshToken) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StoreRefreshToken", arg0, arg1)
+	ret := m.ctrl.Call(m, "StoreRefreshToken", arg0)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // StoreRefreshToken indicates an expected call of StoreRefreshToken.
-func (mr *MockTokenStoreMockRecorder) StoreRefreshToken(arg0, arg1 any) *gomock.Call {
+func (mr *MockTokenStoreMockRecorder) StoreRefreshToken(arg0 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreRefreshToken", reflect.TypeOf((*MockTokenStore)(nil).StoreRefreshToken), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreRefreshToken", reflect.TypeOf((*MockTokenStore)(nil).StoreRefreshToken), arg0)
+}
+
+// TouchSession mocks base method.
+func (m *MockTokenStore) TouchSession(arg0, arg1 string, arg2, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchSession", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchSession indicates an expected call of TouchSession.
+func (mr *MockTokenStoreMockRecorder) TouchSession(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchSession", reflect.TypeOf((*MockTokenStore)(nil).TouchSession), arg0, arg1, arg2, arg3)
 }
 
 // MockJWTManager is a mock of JWTManager interface.
@@ -183,10 +301,26 @@ func (m *MockJWTManager) EXPECT() *MockJWTManagerMockRecorder {
 	return m.recorder
 }
 
+// GenerateClientToken mocks base method.
+func (m *MockJWTManager) GenerateClientToken(arg0, arg1 string) (string, time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateClientToken", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(time.Duration)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GenerateClientToken indicates an expected call of GenerateClientToken.
+func (mr *MockJWTManagerMockRecorder) GenerateClientToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateClientToken", reflect.TypeOf((*MockJWTManager)(nil).GenerateClientToken), arg0, arg1)
+}
+
 // GenerateTokenPair mocks base method.
-func (m *MockJWTManager) GenerateTokenPair(arg0 string) (string, string, error) {
+func (m *MockJWTManager) GenerateTokenPair(arg0 string, arg1 domain.SessionClient) (string, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateTokenPair", arg0)
+	ret := m.ctrl.Call(m, "GenerateTokenPair", arg0, arg1)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(string)
 	ret2, _ := ret[2].(error)
@@ -194,9 +328,9 @@ func (m *MockJWTManager) GenerateTokenPair(arg0 string) (string, string, error)
 }
 
 // GenerateTokenPair indicates an expected call of GenerateTokenPair.
-func (mr *MockJWTManagerMockRecorder) GenerateTokenPair(arg0 any) *gomock.Call {
+func (mr *MockJWTManagerMockRecorder) GenerateTokenPair(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateTokenPair", reflect.TypeOf((*MockJWTManager)(nil).GenerateTokenPair), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateTokenPair", reflect.TypeOf((*MockJWTManager)(nil).GenerateTokenPair), arg0, arg1)
 }
 
 // InvalidateRefreshToken mocks base method.
@@ -213,8 +347,51 @@ func (mr *MockJWTManagerMockRecorder) InvalidateRefreshToken(arg0, arg1 any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateRefreshToken", reflect.TypeOf((*MockJWTManager)(nil).InvalidateRefreshToken), arg0, arg1)
 }
 
+// IsSessionRevoked mocks base method.
+func (m *MockJWTManager) IsSessionRevoked(arg0 string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsSessionRevoked", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsSessionRevoked indicates an expected call of IsSessionRevoked.
+func (mr *MockJWTManagerMockRecorder) IsSessionRevoked(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSessionRevoked", reflect.TypeOf((*MockJWTManager)(nil).IsSessionRevoked), arg0)
+}
+
+// JWKS mocks base method.
+func (m *MockJWTManager) JWKS() domain.JSONWebKeySet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(domain.JSONWebKeySet)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockJWTManagerMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockJWTManager)(nil).JWKS))
+}
+
+// ListSessions mocks base method.
+func (m *MockJWTManager) ListSessions(arg0 string) ([]*domain.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", arg0)
+	ret0, _ := ret[0].([]*domain.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockJWTManagerMockRecorder) ListSessions(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockJWTManager)(nil).ListSessions), arg0)
+}
+
 // RefreshTokens mocks base method.
-func (m *MockJWTManager) RefreshTokens(arg0, arg1 string) (string, string, error) {
+func (m *MockJWTManager) RefreshTokens(arg0 string, arg1 domain.SessionClient) (string, string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "RefreshTokens", arg0, arg1)
 	ret0, _ := ret[0].(string)
@@ -229,6 +406,64 @@ func (mr *MockJWTManagerMockRecorder) RefreshTokens(arg0, arg1 any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*MockJWTManager)(nil).RefreshTokens), arg0, arg1)
 }
 
+// RevokeAllSessions mocks base method.
+func (m *MockJWTManager) RevokeAllSessions(arg0 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllSessions", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAllSessions indicates an expected call of RevokeAllSessions.
+func (mr *MockJWTManagerMockRecorder) RevokeAllSessions(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllSessions", reflect.TypeOf((*MockJWTManager)(nil).RevokeAllSessions), arg0)
+}
+
+// RevokeOtherSessions mocks base method.
+func (m *MockJWTManager) RevokeOtherSessions(arg0, arg1 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeOtherSessions", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeOtherSessions indicates an expected call of RevokeOtherSessions.
+func (mr *MockJWTManagerMockRecorder) RevokeOtherSessions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOtherSessions", reflect.TypeOf((*MockJWTManager)(nil).RevokeOtherSessions), arg0, arg1)
+}
+
+// RevokeSession mocks base method.
+func (m *MockJWTManager) RevokeSession(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockJWTManagerMockRecorder) RevokeSession(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockJWTManager)(nil).RevokeSession), arg0, arg1)
+}
+
+// SyncRevokedSessions mocks base method.
+func (m *MockJWTManager) SyncRevokedSessions() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncRevokedSessions")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncRevokedSessions indicates an expected call of SyncRevokedSessions.
+func (mr *MockJWTManagerMockRecorder) SyncRevokedSessions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncRevokedSessions", reflect.TypeOf((*MockJWTManager)(nil).SyncRevokedSessions))
+}
+
 // ValidateAccessToken mocks base method.
 func (m *MockJWTManager) ValidateAccessToken(arg0 string) (*domain.Claims, error) {
 	m.ctrl.T.Helper()
@@ -295,3 +530,17 @@ func (mr *MockPasswordHasherMockRecorder) HashPassword(arg0 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HashPassword", reflect.TypeOf((*MockPasswordHasher)(nil).HashPassword), arg0)
 }
+
+// NeedsRehash mocks base method.
+func (m *MockPasswordHasher) NeedsRehash(arg0 string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NeedsRehash", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// NeedsRehash indicates an expected call of NeedsRehash.
+func (mr *MockPasswordHasherMockRecorder) NeedsRehash(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NeedsRehash", reflect.TypeOf((*MockPasswordHasher)(nil).NeedsRehash), arg0)
+}
@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+	"io"
+)
+
+// FileStore keeps uploaded files such as KYC documents. Keys are opaque,
+// slash-separated paths chosen by the caller.
+type FileStore interface {
+	Put(ctx context.Context, key string, content io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
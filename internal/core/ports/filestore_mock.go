@@ -0,0 +1,84 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/popeskul/payment-gateway/internal/core/ports (interfaces: FileStore)
+//
+// Generated by this command:
+//
+//	mockgen -destination=filestore_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports FileStore
+//
+
+// Package ports is a generated GoMock package.
+package ports
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFileStore is a mock of FileStore interface.
+type MockFileStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileStoreMockRecorder
+}
+
+// MockFileStoreMockRecorder is the mock recorder for MockFileStore.
+type MockFileStoreMockRecorder struct {
+	mock *MockFileStore
+}
+
+// NewMockFileStore creates a new mock instance.
+func NewMockFileStore(ctrl *gomock.Controller) *MockFileStore {
+	mock := &MockFileStore{ctrl: ctrl}
+	mock.recorder = &MockFileStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileStore) EXPECT() *MockFileStoreMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockFileStore) Delete(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockFileStoreMockRecorder) Delete(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockFileStore)(nil).Delete), arg0, arg1)
+}
+
+// Get mocks base method.
+func (m *MockFileStore) Get(arg0 context.Context, arg1 string) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0, arg1)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockFileStoreMockRecorder) Get(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockFileStore)(nil).Get), arg0, arg1)
+}
+
+// Put mocks base method.
+func (m *MockFileStore) Put(arg0 context.Context, arg1 string, arg2 io.Reader) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockFileStoreMockRecorder) Put(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockFileStore)(nil).Put), arg0, arg1, arg2)
+}
@@ -0,0 +1,11 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/currency"
+)
+
+type FXRateProvider interface {
+	GetRate(ctx context.Context, from, to string) (*currency.Rate, error)
+}
@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/popeskul/payment-gateway/internal/core/ports (interfaces: FXRateProvider)
+//
+// Generated by this command:
+//
+//	mockgen -destination=fx_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports FXRateProvider
+//
+
+// Package ports is a generated GoMock package.
+package ports
+
+import (
+	context "context"
+	reflect "reflect"
+
+	currency "github.com/popeskul/payment-gateway/internal/core/domain/currency"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFXRateProvider is a mock of FXRateProvider interface.
+type MockFXRateProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockFXRateProviderMockRecorder
+}
+
+// MockFXRateProviderMockRecorder is the mock recorder for MockFXRateProvider.
+type MockFXRateProviderMockRecorder struct {
+	mock *MockFXRateProvider
+}
+
+// NewMockFXRateProvider creates a new mock instance.
+func NewMockFXRateProvider(ctrl *gomock.Controller) *MockFXRateProvider {
+	mock := &MockFXRateProvider{ctrl: ctrl}
+	mock.recorder = &MockFXRateProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFXRateProvider) EXPECT() *MockFXRateProviderMockRecorder {
+	return m.recorder
+}
+
+// GetRate mocks base method.
+func (m *MockFXRateProvider) GetRate(arg0 context.Context, arg1, arg2 string) (*currency.Rate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*currency.Rate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRate indicates an expected call of GetRate.
+func (mr *MockFXRateProviderMockRecorder) GetRate(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRate", reflect.TypeOf((*MockFXRateProvider)(nil).GetRate), arg0, arg1, arg2)
+}
@@ -1,8 +1,14 @@
 package ports
 
-//go:generate mockgen -destination=repository_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports Repositories,MerchantRepository,PaymentRepository,RefundRepository,UserRepository
-//go:generate mockgen -destination=service_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports Services,MerchantService,AcquiringBank,PaymentService,RefundService,UserService
+//go:generate mockgen -destination=repository_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports Repositories,MerchantRepository,PaymentRepository,RefundRepository,UserRepository,ReconciliationRepository,SubscriptionRepository,CustomerRepository,MemberRepository,APIKeyRepository,OnboardingRepository,AuditRepository,OAuthClientRepository
+//go:generate mockgen -destination=service_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports Services,MerchantService,AcquiringBank,PaymentService,RefundService,UserService,ReconciliationService,PaymentSweeper,SubscriptionService,CustomerService,MemberService,APIKeyService,OnboardingService,MerchantExportService,AuditService,OAuthService
 //go:generate mockgen -destination=auth_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports AuthConfig,TokenStore,JWTManager,PasswordHasher
 //go:generate mockgen -destination=logger_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports Logger
+//go:generate mockgen -destination=mailer_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports Mailer
 //go:generate mockgen -destination=transaction_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports Transaction
 //go:generate mockgen -destination=uuid_generator_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports UUIDGenerator
+//go:generate mockgen -destination=fx_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports FXRateProvider
+//go:generate mockgen -destination=settlement_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports SettlementFileSource
+//go:generate mockgen -destination=locker_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports Locker
+//go:generate mockgen -destination=filestore_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports FileStore
+//go:generate mockgen -destination=ratelimit_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports RateLimitStore
@@ -0,0 +1,10 @@
+package ports
+
+import "context"
+
+// Locker provides cluster-wide mutual exclusion for background jobs.
+type Locker interface {
+	// TryLock does not wait: acquired is false when another holder has the
+	// lock. The returned unlock func must be called once the work is done.
+	TryLock(ctx context.Context, key string) (unlock func(), acquired bool, err error)
+}
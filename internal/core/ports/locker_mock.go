@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/popeskul/payment-gateway/internal/core/ports (interfaces: Locker)
+//
+// Generated by this command:
+//
+//	mockgen -destination=locker_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports Locker
+//
+
+// Package ports is a generated GoMock package.
+package ports
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLocker is a mock of Locker interface.
+type MockLocker struct {
+	ctrl     *gomock.Controller
+	recorder *MockLockerMockRecorder
+}
+
+// MockLockerMockRecorder is the mock recorder for MockLocker.
+type MockLockerMockRecorder struct {
+	mock *MockLocker
+}
+
+// NewMockLocker creates a new mock instance.
+func NewMockLocker(ctrl *gomock.Controller) *MockLocker {
+	mock := &MockLocker{ctrl: ctrl}
+	mock.recorder = &MockLockerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLocker) EXPECT() *MockLockerMockRecorder {
+	return m.recorder
+}
+
+// TryLock mocks base method.
+func (m *MockLocker) TryLock(arg0 context.Context, arg1 string) (func(), bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TryLock", arg0, arg1)
+	ret0, _ := ret[0].(func())
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// TryLock indicates an expected call of TryLock.
+func (mr *MockLockerMockRecorder) TryLock(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryLock", reflect.TypeOf((*MockLocker)(nil).TryLock), arg0, arg1)
+}
@@ -0,0 +1,9 @@
+package ports
+
+import "context"
+
+// Mailer sends plain text transactional email, such as verification and
+// password reset links.
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/ratelimit"
+)
+
+// RateLimitStore keeps token buckets by key. Implementations shared between
+// replicas must take from the buckets atomically.
+type RateLimitStore interface {
+	// Take takes a token from each of the buckets, creating full ones where
+	// there are none, as ratelimit.TakeAll does: only if every bucket has a
+	// token. The results are in the order of keys.
+	Take(ctx context.Context, keys []ratelimit.Key, now time.Time) ([]ratelimit.Result, error)
+	// Prune deletes buckets that have not been used since the given time.
+	Prune(ctx context.Context, idleSince time.Time) (int, error)
+}
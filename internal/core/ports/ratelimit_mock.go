@@ -0,0 +1,72 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/popeskul/payment-gateway/internal/core/ports (interfaces: RateLimitStore)
+//
+// Generated by this command:
+//
+//	mockgen -destination=ratelimit_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports RateLimitStore
+//
+
+// Package ports is a generated GoMock package.
+package ports
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	ratelimit "github.com/popeskul/payment-gateway/internal/core/domain/ratelimit"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRateLimitStore is a mock of RateLimitStore interface.
+type MockRateLimitStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockRateLimitStoreMockRecorder
+}
+
+// MockRateLimitStoreMockRecorder is the mock recorder for MockRateLimitStore.
+type MockRateLimitStoreMockRecorder struct {
+	mock *MockRateLimitStore
+}
+
+// NewMockRateLimitStore creates a new mock instance.
+func NewMockRateLimitStore(ctrl *gomock.Controller) *MockRateLimitStore {
+	mock := &MockRateLimitStore{ctrl: ctrl}
+	mock.recorder = &MockRateLimitStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRateLimitStore) EXPECT() *MockRateLimitStoreMockRecorder {
+	return m.recorder
+}
+
+// Prune mocks base method.
+func (m *MockRateLimitStore) Prune(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Prune", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Prune indicates an expected call of Prune.
+func (mr *MockRateLimitStoreMockRecorder) Prune(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prune", reflect.TypeOf((*MockRateLimitStore)(nil).Prune), arg0, arg1)
+}
+
+// Take mocks base method.
+func (m *MockRateLimitStore) Take(arg0 context.Context, arg1 []ratelimit.Key, arg2 time.Time) ([]ratelimit.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Take", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]ratelimit.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Take indicates an expected call of Take.
+func (mr *MockRateLimitStoreMockRecorder) Take(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Take", reflect.TypeOf((*MockRateLimitStore)(nil).Take), arg0, arg1, arg2)
+}
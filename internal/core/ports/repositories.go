@@ -2,10 +2,19 @@ package ports
 
 import (
 	"context"
+	"time"
 
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/audit"
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
 	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
 	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
 	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	"github.com/popeskul/payment-gateway/internal/core/domain/subscription"
 	"github.com/popeskul/payment-gateway/internal/core/domain/user"
 )
 
@@ -14,14 +23,37 @@ type Repositories interface {
 	Payments() PaymentRepository
 	Refunds() RefundRepository
 	Users() UserRepository
+	Reconciliations() ReconciliationRepository
+	Subscriptions() SubscriptionRepository
+	Customers() CustomerRepository
+	Members() MemberRepository
+	APIKeys() APIKeyRepository
+	Onboarding() OnboardingRepository
+	AuditLog() AuditRepository
+	OAuthClients() OAuthClientRepository
 }
 
 type MerchantRepository interface {
 	Create(ctx context.Context, m *merchant.Merchant) error
+	// CreateWithOwner creates the merchant, adds owner as its member and stores
+	// keys for it in one transaction, so there is never a merchant nobody can
+	// manage or use.
+	CreateWithOwner(ctx context.Context, m *merchant.Merchant, owner *member.Member, keys []*apikey.APIKey) error
 	GetByID(ctx context.Context, id string) (*merchant.Merchant, error)
 	Update(ctx context.Context, m *merchant.Merchant) error
+	// UpdateOnboarding writes the status, review and business details fields.
+	UpdateOnboarding(ctx context.Context, m *merchant.Merchant) error
+	UpdateSettings(ctx context.Context, m *merchant.Merchant) error
+	// SoftDelete records the deletion time and reason. Deleted merchants are
+	// left out of lists and email lookups but can still be fetched by id.
+	SoftDelete(ctx context.Context, m *merchant.Merchant) error
+	// Delete removes the row outright. It only succeeds for a merchant without
+	// any data.
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, limit, offset int) ([]*merchant.Merchant, error)
+	ListByStatus(ctx context.Context, status merchant.Status, limit, offset int) ([]*merchant.Merchant, error)
+	// ListByUser returns the merchants the user is a member of.
+	ListByUser(ctx context.Context, userID string, limit, offset int) ([]*merchant.Merchant, error)
 	GetByEmail(ctx context.Context, email string) (*merchant.Merchant, error)
 }
 
@@ -29,8 +61,30 @@ type PaymentRepository interface {
 	Create(ctx context.Context, p *payment.Payment) error
 	GetByID(ctx context.Context, id string) (*payment.Payment, error)
 	Update(ctx context.Context, p *payment.Payment) error
-	List(ctx context.Context, merchantID string, limit, offset int) ([]*payment.Payment, error)
+	// List returns a merchant's payments in the given mode, or in both modes
+	// when m is empty.
+	List(ctx context.Context, merchantID string, m mode.Mode, limit, offset int) ([]*payment.Payment, error)
+	ListByCustomer(ctx context.Context, customerID string, limit, offset int) ([]*payment.Payment, error)
 	UpdateStatus(ctx context.Context, id string, status payment.PaymentStatus) error
+	// GetByAcquirerReference and ListProcessedBetween only consider live
+	// payments, since settlement files come from the live acquirer.
+	GetByAcquirerReference(ctx context.Context, reference string) (*payment.Payment, error)
+	ListProcessedBetween(ctx context.Context, from, to time.Time) ([]*payment.Payment, error)
+	// TransitionStatus saves p, which must currently be in the from status, and
+	// records the change in the payment's status history.
+	TransitionStatus(ctx context.Context, p *payment.Payment, from payment.PaymentStatus, reason string) error
+	ListTransitions(ctx context.Context, paymentID string) ([]*payment.StatusTransition, error)
+	ListPendingCreatedBefore(ctx context.Context, before time.Time, limit int) ([]*payment.Payment, error)
+	ListAuthorizationsExpiredBefore(ctx context.Context, before time.Time, limit int) ([]*payment.Payment, error)
+	// SumVolume totals the gross settlement amount, before refunds, of the
+	// merchant's payments in the mode created since the given time that are
+	// pending, authorized or completed.
+	SumVolume(ctx context.Context, merchantID string, m mode.Mode, since time.Time) (float64, error)
+	// CreateWithinVolume passes check the merchant's SumVolume since each of
+	// the given times and creates p unless check returns an error, which is
+	// returned as is. Both happen under a lock on the merchant, so concurrent
+	// payments cannot both fit under a volume cap.
+	CreateWithinVolume(ctx context.Context, p *payment.Payment, since []time.Time, check func(volumes []float64) error) error
 }
 
 type RefundRepository interface {
@@ -38,8 +92,18 @@ type RefundRepository interface {
 	GetByID(ctx context.Context, id string) (*refund.Refund, error)
 	Update(ctx context.Context, r *refund.Refund) error
 	List(ctx context.Context, paymentID string, limit, offset int) ([]*refund.Refund, error)
+	ListByMerchant(ctx context.Context, merchantID string, limit, offset int) ([]*refund.Refund, error)
 	UpdateStatus(ctx context.Context, id string, status refund.RefundStatus) error
+	// TransitionStatus moves a refund from one status to another and fails if
+	// the refund is no longer in the from status.
+	TransitionStatus(ctx context.Context, id string, from, to refund.RefundStatus) error
+	// UpdateWithTransaction saves a refund that was being processed together
+	// with its payment, and fails if the refund is no longer processing.
 	UpdateWithTransaction(ctx context.Context, r *refund.Refund, p *payment.Payment) error
+	// GetByAcquirerReference and ListProcessedBetween only consider live refunds.
+	GetByAcquirerReference(ctx context.Context, reference string) (*refund.Refund, error)
+	ListProcessedBetween(ctx context.Context, from, to time.Time) ([]*refund.Refund, error)
+	TotalByPayment(ctx context.Context, paymentID string, status refund.RefundStatus) (float64, error)
 }
 
 type UserRepository interface {
@@ -47,4 +111,145 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id string) (*user.User, error)
 	GetByEmail(ctx context.Context, email string) (*user.User, error)
 	Update(ctx context.Context, u *user.User) error
+	CreateToken(ctx context.Context, t *user.Token) error
+	GetTokenByHash(ctx context.Context, hash string) (*user.Token, error)
+	// UseToken marks the token used and fails if it already was, so a token
+	// works at most once even under concurrent requests.
+	UseToken(ctx context.Context, id string, at time.Time) error
+	// InvalidateTokens marks the user's unused tokens for the purpose as used.
+	InvalidateTokens(ctx context.Context, userID string, purpose user.TokenPurpose, at time.Time) error
+	// AdvanceTOTPCounter records the period of an accepted TOTP code and fails
+	// unless it is later than the last one, so each code works once.
+	AdvanceTOTPCounter(ctx context.Context, userID string, counter int64) error
+	// ReplaceRecoveryCodes deletes the user's recovery codes and stores the
+	// given hashes instead. An empty list removes them all.
+	ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string, at time.Time) error
+	// UseRecoveryCode marks the code used and fails if it does not exist or
+	// already was.
+	UseRecoveryCode(ctx context.Context, userID, hash string, at time.Time) error
+	// GetLoginThrottle returns nil when there were no recent failures.
+	GetLoginThrottle(ctx context.Context, scope user.ThrottleScope, key string) (*user.LoginThrottle, error)
+	// RecordLoginFailure atomically counts a failed login and returns the
+	// updated throttle. The count restarts if the last failure is older than
+	// window, which also lifts an expired lock.
+	RecordLoginFailure(ctx context.Context, scope user.ThrottleScope, key string, at time.Time, window time.Duration) (*user.LoginThrottle, error)
+	LockLogin(ctx context.Context, scope user.ThrottleScope, key string, until time.Time) error
+	ClearLoginThrottle(ctx context.Context, scope user.ThrottleScope, key string) error
+}
+
+type ReconciliationRepository interface {
+	CreateReport(ctx context.Context, r *reconciliation.Report) error
+	UpdateReport(ctx context.Context, r *reconciliation.Report) error
+	GetReport(ctx context.Context, id string) (*reconciliation.Report, error)
+	GetReportByFileName(ctx context.Context, fileName string) (*reconciliation.Report, error)
+	ListReports(ctx context.Context, limit, offset int) ([]*reconciliation.Report, error)
+	// CompleteReport replaces the report's discrepancies and saves the report
+	// in one transaction, so a report never shows part of a run.
+	CompleteReport(ctx context.Context, r *reconciliation.Report, discrepancies []*reconciliation.Discrepancy) error
+	GetDiscrepancy(ctx context.Context, id string) (*reconciliation.Discrepancy, error)
+	UpdateDiscrepancy(ctx context.Context, d *reconciliation.Discrepancy) error
+	ListDiscrepancies(ctx context.Context, reportID string, status reconciliation.DiscrepancyStatus, limit, offset int) ([]*reconciliation.Discrepancy, error)
+}
+
+type SubscriptionRepository interface {
+	CreatePlan(ctx context.Context, p *subscription.Plan) error
+	GetPlan(ctx context.Context, id string) (*subscription.Plan, error)
+	UpdatePlan(ctx context.Context, p *subscription.Plan) error
+	// ListPlans and List filter by mode unless m is empty.
+	ListPlans(ctx context.Context, merchantID string, m mode.Mode, limit, offset int) ([]*subscription.Plan, error)
+	Create(ctx context.Context, s *subscription.Subscription) error
+	GetByID(ctx context.Context, id string) (*subscription.Subscription, error)
+	Update(ctx context.Context, s *subscription.Subscription) error
+	List(ctx context.Context, merchantID string, m mode.Mode, limit, offset int) ([]*subscription.Subscription, error)
+	// ListDue returns subscriptions whose period has ended or whose dunning
+	// retry is due at now.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*subscription.Subscription, error)
+	// CancelByMerchant cancels the merchant's subscriptions in both modes and
+	// records a canceled event with message for each of them.
+	CancelByMerchant(ctx context.Context, merchantID string, at time.Time, message string) error
+	CreateEvent(ctx context.Context, e *subscription.Event) error
+	ListEvents(ctx context.Context, subscriptionID string, limit, offset int) ([]*subscription.Event, error)
+}
+
+type CustomerRepository interface {
+	Create(ctx context.Context, c *customer.Customer) error
+	GetByID(ctx context.Context, id string) (*customer.Customer, error)
+	Update(ctx context.Context, c *customer.Customer) error
+	// List returns a merchant's customers in the mode, or in both modes when m
+	// is empty; a non-empty search matches on email or name.
+	List(ctx context.Context, merchantID string, m mode.Mode, search string, limit, offset int) ([]*customer.Customer, error)
+	// Delete erases the customer and their saved payment methods. Payments
+	// and refunds are kept but no longer point at the customer.
+	Delete(ctx context.Context, id string) error
+	AddPaymentMethod(ctx context.Context, pm *customer.PaymentMethod) error
+	GetPaymentMethod(ctx context.Context, id string) (*customer.PaymentMethod, error)
+	ListPaymentMethods(ctx context.Context, customerID string) ([]*customer.PaymentMethod, error)
+	SetDefaultPaymentMethod(ctx context.Context, customerID, id string) error
+	// DeletePaymentMethod deletes the payment method. If it was the default,
+	// the customer's newest remaining method becomes the default in the same
+	// transaction.
+	DeletePaymentMethod(ctx context.Context, id string) error
+}
+
+type MemberRepository interface {
+	Add(ctx context.Context, m *member.Member) error
+	Get(ctx context.Context, merchantID, userID string) (*member.Member, error)
+	List(ctx context.Context, merchantID string) ([]*member.Member, error)
+	Remove(ctx context.Context, merchantID, userID string) error
+	CountByRole(ctx context.Context, merchantID string, role member.Role) (int, error)
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, k *apikey.APIKey) error
+	GetByID(ctx context.Context, id string) (*apikey.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*apikey.APIKey, error)
+	ListByMerchant(ctx context.Context, merchantID string) ([]*apikey.APIKey, error)
+	Update(ctx context.Context, k *apikey.APIKey) error
+	// Rotate stores the replacement and updates the old key in one transaction.
+	Rotate(ctx context.Context, old, replacement *apikey.APIKey) error
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+	// RevokeByMerchant revokes every key of the merchant that is not revoked yet.
+	RevokeByMerchant(ctx context.Context, merchantID string, at time.Time) error
+	// UseNonce records the nonce of a signed request until expiresAt. It
+	// returns false if the key has used the nonce before.
+	UseNonce(ctx context.Context, keyID, nonce string, expiresAt time.Time) (bool, error)
+	// PruneNonces deletes the nonces that expired before the given time.
+	PruneNonces(ctx context.Context, before time.Time) (int, error)
+}
+
+type OnboardingRepository interface {
+	AddOwner(ctx context.Context, o *merchant.BeneficialOwner) error
+	ListOwners(ctx context.Context, merchantID string) ([]*merchant.BeneficialOwner, error)
+	DeleteOwner(ctx context.Context, merchantID, id string) error
+	AddDocument(ctx context.Context, d *merchant.Document) error
+	GetDocument(ctx context.Context, id string) (*merchant.Document, error)
+	ListDocuments(ctx context.Context, merchantID string) ([]*merchant.Document, error)
+}
+
+type AuditRepository interface {
+	// Append assigns the entry the next sequence number, links it to the
+	// current end of the chain and stores it. Appends are serialized.
+	Append(ctx context.Context, e *audit.Entry) error
+	List(ctx context.Context, filter audit.Filter) ([]*audit.Entry, error)
+	// ListAfter returns entries in sequence order, starting after the given
+	// sequence number.
+	ListAfter(ctx context.Context, sequence int64, limit int) ([]*audit.Entry, error)
+}
+
+type OAuthClientRepository interface {
+	Create(ctx context.Context, c *oauth.Client) error
+	// GetByID returns oauth.ErrClientNotFound for unknown IDs.
+	GetByID(ctx context.Context, id string) (*oauth.Client, error)
+	ListByOwner(ctx context.Context, ownerID string) ([]*oauth.Client, error)
+	Revoke(ctx context.Context, id string, revokedAt time.Time) error
+	// SaveGrant creates the grant or replaces the scopes of an existing one.
+	SaveGrant(ctx context.Context, g *oauth.Grant) error
+	// GetGrant returns oauth.ErrGrantNotFound if the merchant has not granted
+	// the client access.
+	GetGrant(ctx context.Context, clientID, merchantID string) (*oauth.Grant, error)
+	ListGrantsByMerchant(ctx context.Context, merchantID string) ([]*oauth.Grant, error)
+	ListGrantsByClient(ctx context.Context, clientID string) ([]*oauth.Grant, error)
+	// DeleteGrant returns oauth.ErrGrantNotFound if there is none.
+	DeleteGrant(ctx context.Context, clientID, merchantID string) error
+	DeleteGrantsByMerchant(ctx context.Context, merchantID string) error
 }
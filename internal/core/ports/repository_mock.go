@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/popeskul/payment-gateway/internal/core/ports (interfaces: Repositories,MerchantRepository,PaymentRepository,RefundRepository,UserRepository)
+// Source: github.com/popeskul/payment-gateway/internal/core/ports (interfaces: Repositories,MerchantRepository,PaymentRepository,RefundRepository,UserRepository,ReconciliationRepository,SubscriptionRepository,CustomerRepository,MemberRepository,APIKeyRepository,OnboardingRepository,AuditRepository,OAuthClientRepository)
 //
 // Generated by this command:
 //
-//	mockgen -destination=repository_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports Repositories,MerchantRepository,PaymentRepository,RefundRepository,UserRepository
+//	mockgen -destination=repository_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports Repositories,MerchantRepository,PaymentRepository,RefundRepository,UserRepository,ReconciliationRepository,SubscriptionRepository,CustomerRepository,MemberRepository,APIKeyRepository,OnboardingRepository,AuditRepository,OAuthClientRepository
 //
 
 // Package ports is a generated GoMock package.
@@ -12,10 +12,19 @@ package ports
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
+	apikey "github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	audit "github.com/popeskul/payment-gateway/internal/core/domain/audit"
+	customer "github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	member "github.com/popeskul/payment-gateway/internal/core/domain/member"
 	merchant "github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	mode "github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	oauth "github.com/popeskul/payment-gateway/internal/core/domain/oauth"
 	payment "github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	reconciliation "github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
 	refund "github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	subscription "github.com/popeskul/payment-gateway/internal/core/domain/subscription"
 	user "github.com/popeskul/payment-gateway/internal/core/domain/user"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -43,6 +52,62 @@ func (m *MockRepositories) EXPECT() *MockRepositoriesMockRecorder {
 	return m.recorder
 }
 
+// APIKeys mocks base method.
+func (m *MockRepositories) APIKeys() APIKeyRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "APIKeys")
+	ret0, _ := ret[0].(APIKeyRepository)
+	return ret0
+}
+
+// APIKeys indicates an expected call of APIKeys.
+func (mr *MockRepositoriesMockRecorder) APIKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "APIKeys", reflect.TypeOf((*MockRepositories)(nil).APIKeys))
+}
+
+// AuditLog mocks base method.
+func (m *MockRepositories) AuditLog() AuditRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuditLog")
+	ret0, _ := ret[0].(AuditRepository)
+	return ret0
+}
+
+// AuditLog indicates an expected call of AuditLog.
+func (mr *MockRepositoriesMockRecorder) AuditLog() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuditLog", reflect.TypeOf((*MockRepositories)(nil).AuditLog))
+}
+
+// Customers mocks base method.
+func (m *MockRepositories) Customers() CustomerRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Customers")
+	ret0, _ := ret[0].(CustomerRepository)
+	return ret0
+}
+
+// Customers indicates an expected call of Customers.
+func (mr *MockRepositoriesMockRecorder) Customers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Customers", reflect.TypeOf((*MockRepositories)(nil).Customers))
+}
+
+// Members mocks base method.
+func (m *MockRepositories) Members() MemberRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Members")
+	ret0, _ := ret[0].(MemberRepository)
+	return ret0
+}
+
+// Members indicates an expected call of Members.
+func (mr *MockRepositoriesMockRecorder) Members() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Members", reflect.TypeOf((*MockRepositories)(nil).Members))
+}
+
 // Merchants mocks base method.
 func (m *MockRepositories) Merchants() MerchantRepository {
 	m.ctrl.T.Helper()
@@ -57,6 +122,34 @@ func (mr *MockRepositoriesMockRecorder) Merchants() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merchants", reflect.TypeOf((*MockRepositories)(nil).Merchants))
 }
 
+// OAuthClients mocks base method.
+func (m *MockRepositories) OAuthClients() OAuthClientRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OAuthClients")
+	ret0, _ := ret[0].(OAuthClientRepository)
+	return ret0
+}
+
+// OAuthClients indicates an expected call of OAuthClients.
+func (mr *MockRepositoriesMockRecorder) OAuthClients() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OAuthClients", reflect.TypeOf((*MockRepositories)(nil).OAuthClients))
+}
+
+// Onboarding mocks base method.
+func (m *MockRepositories) Onboarding() OnboardingRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Onboarding")
+	ret0, _ := ret[0].(OnboardingRepository)
+	return ret0
+}
+
+// Onboarding indicates an expected call of Onboarding.
+func (mr *MockRepositoriesMockRecorder) Onboarding() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Onboarding", reflect.TypeOf((*MockRepositories)(nil).Onboarding))
+}
+
 // Payments mocks base method.
 func (m *MockRepositories) Payments() PaymentRepository {
 	m.ctrl.T.Helper()
@@ -71,6 +164,20 @@ func (mr *MockRepositoriesMockRecorder) Payments() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Payments", reflect.TypeOf((*MockRepositories)(nil).Payments))
 }
 
+// Reconciliations mocks base method.
+func (m *MockRepositories) Reconciliations() ReconciliationRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reconciliations")
+	ret0, _ := ret[0].(ReconciliationRepository)
+	return ret0
+}
+
+// Reconciliations indicates an expected call of Reconciliations.
+func (mr *MockRepositoriesMockRecorder) Reconciliations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reconciliations", reflect.TypeOf((*MockRepositories)(nil).Reconciliations))
+}
+
 // Refunds mocks base method.
 func (m *MockRepositories) Refunds() RefundRepository {
 	m.ctrl.T.Helper()
@@ -85,6 +192,20 @@ func (mr *MockRepositoriesMockRecorder) Refunds() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refunds", reflect.TypeOf((*MockRepositories)(nil).Refunds))
 }
 
+// Subscriptions mocks base method.
+func (m *MockRepositories) Subscriptions() SubscriptionRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscriptions")
+	ret0, _ := ret[0].(SubscriptionRepository)
+	return ret0
+}
+
+// Subscriptions indicates an expected call of Subscriptions.
+func (mr *MockRepositoriesMockRecorder) Subscriptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscriptions", reflect.TypeOf((*MockRepositories)(nil).Subscriptions))
+}
+
 // Users mocks base method.
 func (m *MockRepositories) Users() UserRepository {
 	m.ctrl.T.Helper()
@@ -136,6 +257,20 @@ func (mr *MockMerchantRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMerchantRepository)(nil).Create), arg0, arg1)
 }
 
+// CreateWithOwner mocks base method.
+func (m *MockMerchantRepository) CreateWithOwner(arg0 context.Context, arg1 *merchant.Merchant, arg2 *member.Member, arg3 []*apikey.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWithOwner", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWithOwner indicates an expected call of CreateWithOwner.
+func (mr *MockMerchantRepositoryMockRecorder) CreateWithOwner(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWithOwner", reflect.TypeOf((*MockMerchantRepository)(nil).CreateWithOwner), arg0, arg1, arg2, arg3)
+}
+
 // Delete mocks base method.
 func (m *MockMerchantRepository) Delete(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -195,6 +330,50 @@ func (mr *MockMerchantRepositoryMockRecorder) List(arg0, arg1, arg2 any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockMerchantRepository)(nil).List), arg0, arg1, arg2)
 }
 
+// ListByStatus mocks base method.
+func (m *MockMerchantRepository) ListByStatus(arg0 context.Context, arg1 merchant.Status, arg2, arg3 int) ([]*merchant.Merchant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByStatus", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*merchant.Merchant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByStatus indicates an expected call of ListByStatus.
+func (mr *MockMerchantRepositoryMockRecorder) ListByStatus(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByStatus", reflect.TypeOf((*MockMerchantRepository)(nil).ListByStatus), arg0, arg1, arg2, arg3)
+}
+
+// ListByUser mocks base method.
+func (m *MockMerchantRepository) ListByUser(arg0 context.Context, arg1 string, arg2, arg3 int) ([]*merchant.Merchant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*merchant.Merchant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockMerchantRepositoryMockRecorder) ListByUser(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockMerchantRepository)(nil).ListByUser), arg0, arg1, arg2, arg3)
+}
+
+// SoftDelete mocks base method.
+func (m *MockMerchantRepository) SoftDelete(arg0 context.Context, arg1 *merchant.Merchant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDelete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SoftDelete indicates an expected call of SoftDelete.
+func (mr *MockMerchantRepositoryMockRecorder) SoftDelete(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDelete", reflect.TypeOf((*MockMerchantRepository)(nil).SoftDelete), arg0, arg1)
+}
+
 // Update mocks base method.
 func (m *MockMerchantRepository) Update(arg0 context.Context, arg1 *merchant.Merchant) error {
 	m.ctrl.T.Helper()
@@ -209,6 +388,34 @@ func (mr *MockMerchantRepositoryMockRecorder) Update(arg0, arg1 any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockMerchantRepository)(nil).Update), arg0, arg1)
 }
 
+// UpdateOnboarding mocks base method.
+func (m *MockMerchantRepository) UpdateOnboarding(arg0 context.Context, arg1 *merchant.Merchant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOnboarding", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOnboarding indicates an expected call of UpdateOnboarding.
+func (mr *MockMerchantRepositoryMockRecorder) UpdateOnboarding(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOnboarding", reflect.TypeOf((*MockMerchantRepository)(nil).UpdateOnboarding), arg0, arg1)
+}
+
+// UpdateSettings mocks base method.
+func (m *MockMerchantRepository) UpdateSettings(arg0 context.Context, arg1 *merchant.Merchant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSettings", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSettings indicates an expected call of UpdateSettings.
+func (mr *MockMerchantRepositoryMockRecorder) UpdateSettings(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSettings", reflect.TypeOf((*MockMerchantRepository)(nil).UpdateSettings), arg0, arg1)
+}
+
 // MockPaymentRepository is a mock of PaymentRepository interface.
 type MockPaymentRepository struct {
 	ctrl     *gomock.Controller
@@ -246,6 +453,35 @@ func (mr *MockPaymentRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPaymentRepository)(nil).Create), arg0, arg1)
 }
 
+// CreateWithinVolume mocks base method.
+func (m *MockPaymentRepository) CreateWithinVolume(arg0 context.Context, arg1 *payment.Payment, arg2 []time.Time, arg3 func([]float64) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWithinVolume", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWithinVolume indicates an expected call of CreateWithinVolume.
+func (mr *MockPaymentRepositoryMockRecorder) CreateWithinVolume(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWithinVolume", reflect.TypeOf((*MockPaymentRepository)(nil).CreateWithinVolume), arg0, arg1, arg2, arg3)
+}
+
+// GetByAcquirerReference mocks base method.
+func (m *MockPaymentRepository) GetByAcquirerReference(arg0 context.Context, arg1 string) (*payment.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByAcquirerReference", arg0, arg1)
+	ret0, _ := ret[0].(*payment.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAcquirerReference indicates an expected call of GetByAcquirerReference.
+func (mr *MockPaymentRepositoryMockRecorder) GetByAcquirerReference(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAcquirerReference", reflect.TypeOf((*MockPaymentRepository)(nil).GetByAcquirerReference), arg0, arg1)
+}
+
 // GetByID mocks base method.
 func (m *MockPaymentRepository) GetByID(arg0 context.Context, arg1 string) (*payment.Payment, error) {
 	m.ctrl.T.Helper()
@@ -262,18 +498,122 @@ func (mr *MockPaymentRepositoryMockRecorder) GetByID(arg0, arg1 any) *gomock.Cal
 }
 
 // List mocks base method.
-func (m *MockPaymentRepository) List(arg0 context.Context, arg1 string, arg2, arg3 int) ([]*payment.Payment, error) {
+func (m *MockPaymentRepository) List(arg0 context.Context, arg1 string, arg2 mode.Mode, arg3, arg4 int) ([]*payment.Payment, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "List", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "List", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].([]*payment.Payment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // List indicates an expected call of List.
-func (mr *MockPaymentRepositoryMockRecorder) List(arg0, arg1, arg2, arg3 any) *gomock.Call {
+func (mr *MockPaymentRepositoryMockRecorder) List(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPaymentRepository)(nil).List), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ListAuthorizationsExpiredBefore mocks base method.
+func (m *MockPaymentRepository) ListAuthorizationsExpiredBefore(arg0 context.Context, arg1 time.Time, arg2 int) ([]*payment.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuthorizationsExpiredBefore", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*payment.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuthorizationsExpiredBefore indicates an expected call of ListAuthorizationsExpiredBefore.
+func (mr *MockPaymentRepositoryMockRecorder) ListAuthorizationsExpiredBefore(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuthorizationsExpiredBefore", reflect.TypeOf((*MockPaymentRepository)(nil).ListAuthorizationsExpiredBefore), arg0, arg1, arg2)
+}
+
+// ListByCustomer mocks base method.
+func (m *MockPaymentRepository) ListByCustomer(arg0 context.Context, arg1 string, arg2, arg3 int) ([]*payment.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByCustomer", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*payment.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByCustomer indicates an expected call of ListByCustomer.
+func (mr *MockPaymentRepositoryMockRecorder) ListByCustomer(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByCustomer", reflect.TypeOf((*MockPaymentRepository)(nil).ListByCustomer), arg0, arg1, arg2, arg3)
+}
+
+// ListPendingCreatedBefore mocks base method.
+func (m *MockPaymentRepository) ListPendingCreatedBefore(arg0 context.Context, arg1 time.Time, arg2 int) ([]*payment.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingCreatedBefore", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*payment.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingCreatedBefore indicates an expected call of ListPendingCreatedBefore.
+func (mr *MockPaymentRepositoryMockRecorder) ListPendingCreatedBefore(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingCreatedBefore", reflect.TypeOf((*MockPaymentRepository)(nil).ListPendingCreatedBefore), arg0, arg1, arg2)
+}
+
+// ListProcessedBetween mocks base method.
+func (m *MockPaymentRepository) ListProcessedBetween(arg0 context.Context, arg1, arg2 time.Time) ([]*payment.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListProcessedBetween", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*payment.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListProcessedBetween indicates an expected call of ListProcessedBetween.
+func (mr *MockPaymentRepositoryMockRecorder) ListProcessedBetween(arg0, arg1, arg2 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPaymentRepository)(nil).List), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProcessedBetween", reflect.TypeOf((*MockPaymentRepository)(nil).ListProcessedBetween), arg0, arg1, arg2)
+}
+
+// ListTransitions mocks base method.
+func (m *MockPaymentRepository) ListTransitions(arg0 context.Context, arg1 string) ([]*payment.StatusTransition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransitions", arg0, arg1)
+	ret0, _ := ret[0].([]*payment.StatusTransition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransitions indicates an expected call of ListTransitions.
+func (mr *MockPaymentRepositoryMockRecorder) ListTransitions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransitions", reflect.TypeOf((*MockPaymentRepository)(nil).ListTransitions), arg0, arg1)
+}
+
+// SumVolume mocks base method.
+func (m *MockPaymentRepository) SumVolume(arg0 context.Context, arg1 string, arg2 mode.Mode, arg3 time.Time) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumVolume", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumVolume indicates an expected call of SumVolume.
+func (mr *MockPaymentRepositoryMockRecorder) SumVolume(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumVolume", reflect.TypeOf((*MockPaymentRepository)(nil).SumVolume), arg0, arg1, arg2, arg3)
+}
+
+// TransitionStatus mocks base method.
+func (m *MockPaymentRepository) TransitionStatus(arg0 context.Context, arg1 *payment.Payment, arg2 payment.PaymentStatus, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransitionStatus", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TransitionStatus indicates an expected call of TransitionStatus.
+func (mr *MockPaymentRepositoryMockRecorder) TransitionStatus(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransitionStatus", reflect.TypeOf((*MockPaymentRepository)(nil).TransitionStatus), arg0, arg1, arg2, arg3)
 }
 
 // Update mocks base method.
@@ -341,6 +681,21 @@ func (mr *MockRefundRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRefundRepository)(nil).Create), arg0, arg1)
 }
 
+// GetByAcquirerReference mocks base method.
+func (m *MockRefundRepository) GetByAcquirerReference(arg0 context.Context, arg1 string) (*refund.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByAcquirerReference", arg0, arg1)
+	ret0, _ := ret[0].(*refund.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAcquirerReference indicates an expected call of GetByAcquirerReference.
+func (mr *MockRefundRepositoryMockRecorder) GetByAcquirerReference(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAcquirerReference", reflect.TypeOf((*MockRefundRepository)(nil).GetByAcquirerReference), arg0, arg1)
+}
+
 // GetByID mocks base method.
 func (m *MockRefundRepository) GetByID(arg0 context.Context, arg1 string) (*refund.Refund, error) {
 	m.ctrl.T.Helper()
@@ -371,6 +726,65 @@ func (mr *MockRefundRepositoryMockRecorder) List(arg0, arg1, arg2, arg3 any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockRefundRepository)(nil).List), arg0, arg1, arg2, arg3)
 }
 
+// ListByMerchant mocks base method.
+func (m *MockRefundRepository) ListByMerchant(arg0 context.Context, arg1 string, arg2, arg3 int) ([]*refund.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByMerchant", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*refund.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByMerchant indicates an expected call of ListByMerchant.
+func (mr *MockRefundRepositoryMockRecorder) ListByMerchant(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByMerchant", reflect.TypeOf((*MockRefundRepository)(nil).ListByMerchant), arg0, arg1, arg2, arg3)
+}
+
+// ListProcessedBetween mocks base method.
+func (m *MockRefundRepository) ListProcessedBetween(arg0 context.Context, arg1, arg2 time.Time) ([]*refund.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListProcessedBetween", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*refund.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListProcessedBetween indicates an expected call of ListProcessedBetween.
+func (mr *MockRefundRepositoryMockRecorder) ListProcessedBetween(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProcessedBetween", reflect.TypeOf((*MockRefundRepository)(nil).ListProcessedBetween), arg0, arg1, arg2)
+}
+
+// TotalByPayment mocks base method.
+func (m *MockRefundRepository) TotalByPayment(arg0 context.Context, arg1 string, arg2 refund.RefundStatus) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TotalByPayment", arg0, arg1, arg2)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TotalByPayment indicates an expected call of TotalByPayment.
+func (mr *MockRefundRepositoryMockRecorder) TotalByPayment(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TotalByPayment", reflect.TypeOf((*MockRefundRepository)(nil).TotalByPayment), arg0, arg1, arg2)
+}
+
+// TransitionStatus mocks base method.
+func (m *MockRefundRepository) TransitionStatus(arg0 context.Context, arg1 string, arg2, arg3 refund.RefundStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransitionStatus", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TransitionStatus indicates an expected call of TransitionStatus.
+func (mr *MockRefundRepositoryMockRecorder) TransitionStatus(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransitionStatus", reflect.TypeOf((*MockRefundRepository)(nil).TransitionStatus), arg0, arg1, arg2, arg3)
+}
+
 // Update mocks base method.
 func (m *MockRefundRepository) Update(arg0 context.Context, arg1 *refund.Refund) error {
 	m.ctrl.T.Helper()
@@ -436,6 +850,34 @@ func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AdvanceTOTPCounter mocks base method.
+func (m *MockUserRepository) AdvanceTOTPCounter(arg0 context.Context, arg1 string, arg2 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceTOTPCounter", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AdvanceTOTPCounter indicates an expected call of AdvanceTOTPCounter.
+func (mr *MockUserRepositoryMockRecorder) AdvanceTOTPCounter(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceTOTPCounter", reflect.TypeOf((*MockUserRepository)(nil).AdvanceTOTPCounter), arg0, arg1, arg2)
+}
+
+// ClearLoginThrottle mocks base method.
+func (m *MockUserRepository) ClearLoginThrottle(arg0 context.Context, arg1 user.ThrottleScope, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearLoginThrottle", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearLoginThrottle indicates an expected call of ClearLoginThrottle.
+func (mr *MockUserRepositoryMockRecorder) ClearLoginThrottle(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearLoginThrottle", reflect.TypeOf((*MockUserRepository)(nil).ClearLoginThrottle), arg0, arg1, arg2)
+}
+
 // Create mocks base method.
 func (m *MockUserRepository) Create(arg0 context.Context, arg1 *user.User) error {
 	m.ctrl.T.Helper()
@@ -450,6 +892,20 @@ func (mr *MockUserRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserRepository)(nil).Create), arg0, arg1)
 }
 
+// CreateToken mocks base method.
+func (m *MockUserRepository) CreateToken(arg0 context.Context, arg1 *user.Token) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateToken indicates an expected call of CreateToken.
+func (mr *MockUserRepositoryMockRecorder) CreateToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateToken", reflect.TypeOf((*MockUserRepository)(nil).CreateToken), arg0, arg1)
+}
+
 // GetByEmail mocks base method.
 func (m *MockUserRepository) GetByEmail(arg0 context.Context, arg1 string) (*user.User, error) {
 	m.ctrl.T.Helper()
@@ -480,12 +936,99 @@ func (mr *MockUserRepositoryMockRecorder) GetByID(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserRepository)(nil).GetByID), arg0, arg1)
 }
 
-// Update mocks base method.
-func (m *MockUserRepository) Update(arg0 context.Context, arg1 *user.User) error {
+// GetLoginThrottle mocks base method.
+func (m *MockUserRepository) GetLoginThrottle(arg0 context.Context, arg1 user.ThrottleScope, arg2 string) (*user.LoginThrottle, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetLoginThrottle", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*user.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginThrottle indicates an expected call of GetLoginThrottle.
+func (mr *MockUserRepositoryMockRecorder) GetLoginThrottle(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginThrottle", reflect.TypeOf((*MockUserRepository)(nil).GetLoginThrottle), arg0, arg1, arg2)
+}
+
+// GetTokenByHash mocks base method.
+func (m *MockUserRepository) GetTokenByHash(arg0 context.Context, arg1 string) (*user.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenByHash", arg0, arg1)
+	ret0, _ := ret[0].(*user.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenByHash indicates an expected call of GetTokenByHash.
+func (mr *MockUserRepositoryMockRecorder) GetTokenByHash(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenByHash", reflect.TypeOf((*MockUserRepository)(nil).GetTokenByHash), arg0, arg1)
+}
+
+// InvalidateTokens mocks base method.
+func (m *MockUserRepository) InvalidateTokens(arg0 context.Context, arg1 string, arg2 user.TokenPurpose, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidateTokens", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidateTokens indicates an expected call of InvalidateTokens.
+func (mr *MockUserRepositoryMockRecorder) InvalidateTokens(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateTokens", reflect.TypeOf((*MockUserRepository)(nil).InvalidateTokens), arg0, arg1, arg2, arg3)
+}
+
+// LockLogin mocks base method.
+func (m *MockUserRepository) LockLogin(arg0 context.Context, arg1 user.ThrottleScope, arg2 string, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockUserRepositoryMockRecorder) LockLogin(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockUserRepository)(nil).LockLogin), arg0, arg1, arg2, arg3)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockUserRepository) RecordLoginFailure(arg0 context.Context, arg1 user.ThrottleScope, arg2 string, arg3 time.Time, arg4 time.Duration) (*user.LoginThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*user.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockUserRepositoryMockRecorder) RecordLoginFailure(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockUserRepository)(nil).RecordLoginFailure), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ReplaceRecoveryCodes mocks base method.
+func (m *MockUserRepository) ReplaceRecoveryCodes(arg0 context.Context, arg1 string, arg2 []string, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceRecoveryCodes", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceRecoveryCodes indicates an expected call of ReplaceRecoveryCodes.
+func (mr *MockUserRepositoryMockRecorder) ReplaceRecoveryCodes(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceRecoveryCodes", reflect.TypeOf((*MockUserRepository)(nil).ReplaceRecoveryCodes), arg0, arg1, arg2, arg3)
+}
+
+// Update mocks base method.
+func (m *MockUserRepository) Update(arg0 context.Context, arg1 *user.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // Update indicates an expected call of Update.
@@ -493,3 +1036,1158 @@ func (mr *MockUserRepositoryMockRecorder) Update(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserRepository)(nil).Update), arg0, arg1)
 }
+
+// UseRecoveryCode mocks base method.
+func (m *MockUserRepository) UseRecoveryCode(arg0 context.Context, arg1, arg2 string, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockUserRepositoryMockRecorder) UseRecoveryCode(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockUserRepository)(nil).UseRecoveryCode), arg0, arg1, arg2, arg3)
+}
+
+// UseToken mocks base method.
+func (m *MockUserRepository) UseToken(arg0 context.Context, arg1 string, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseToken indicates an expected call of UseToken.
+func (mr *MockUserRepositoryMockRecorder) UseToken(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseToken", reflect.TypeOf((*MockUserRepository)(nil).UseToken), arg0, arg1, arg2)
+}
+
+// MockReconciliationRepository is a mock of ReconciliationRepository interface.
+type MockReconciliationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconciliationRepositoryMockRecorder
+}
+
+// MockReconciliationRepositoryMockRecorder is the mock recorder for MockReconciliationRepository.
+type MockReconciliationRepositoryMockRecorder struct {
+	mock *MockReconciliationRepository
+}
+
+// NewMockReconciliationRepository creates a new mock instance.
+func NewMockReconciliationRepository(ctrl *gomock.Controller) *MockReconciliationRepository {
+	mock := &MockReconciliationRepository{ctrl: ctrl}
+	mock.recorder = &MockReconciliationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciliationRepository) EXPECT() *MockReconciliationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CompleteReport mocks base method.
+func (m *MockReconciliationRepository) CompleteReport(arg0 context.Context, arg1 *reconciliation.Report, arg2 []*reconciliation.Discrepancy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteReport", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteReport indicates an expected call of CompleteReport.
+func (mr *MockReconciliationRepositoryMockRecorder) CompleteReport(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteReport", reflect.TypeOf((*MockReconciliationRepository)(nil).CompleteReport), arg0, arg1, arg2)
+}
+
+// CreateReport mocks base method.
+func (m *MockReconciliationRepository) CreateReport(arg0 context.Context, arg1 *reconciliation.Report) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReport", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateReport indicates an expected call of CreateReport.
+func (mr *MockReconciliationRepositoryMockRecorder) CreateReport(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReport", reflect.TypeOf((*MockReconciliationRepository)(nil).CreateReport), arg0, arg1)
+}
+
+// GetDiscrepancy mocks base method.
+func (m *MockReconciliationRepository) GetDiscrepancy(arg0 context.Context, arg1 string) (*reconciliation.Discrepancy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDiscrepancy", arg0, arg1)
+	ret0, _ := ret[0].(*reconciliation.Discrepancy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDiscrepancy indicates an expected call of GetDiscrepancy.
+func (mr *MockReconciliationRepositoryMockRecorder) GetDiscrepancy(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiscrepancy", reflect.TypeOf((*MockReconciliationRepository)(nil).GetDiscrepancy), arg0, arg1)
+}
+
+// GetReport mocks base method.
+func (m *MockReconciliationRepository) GetReport(arg0 context.Context, arg1 string) (*reconciliation.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReport", arg0, arg1)
+	ret0, _ := ret[0].(*reconciliation.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReport indicates an expected call of GetReport.
+func (mr *MockReconciliationRepositoryMockRecorder) GetReport(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReport", reflect.TypeOf((*MockReconciliationRepository)(nil).GetReport), arg0, arg1)
+}
+
+// GetReportByFileName mocks base method.
+func (m *MockReconciliationRepository) GetReportByFileName(arg0 context.Context, arg1 string) (*reconciliation.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReportByFileName", arg0, arg1)
+	ret0, _ := ret[0].(*reconciliation.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReportByFileName indicates an expected call of GetReportByFileName.
+func (mr *MockReconciliationRepositoryMockRecorder) GetReportByFileName(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReportByFileName", reflect.TypeOf((*MockReconciliationRepository)(nil).GetReportByFileName), arg0, arg1)
+}
+
+// ListDiscrepancies mocks base method.
+func (m *MockReconciliationRepository) ListDiscrepancies(arg0 context.Context, arg1 string, arg2 reconciliation.DiscrepancyStatus, arg3, arg4 int) ([]*reconciliation.Discrepancy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDiscrepancies", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]*reconciliation.Discrepancy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDiscrepancies indicates an expected call of ListDiscrepancies.
+func (mr *MockReconciliationRepositoryMockRecorder) ListDiscrepancies(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDiscrepancies", reflect.TypeOf((*MockReconciliationRepository)(nil).ListDiscrepancies), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ListReports mocks base method.
+func (m *MockReconciliationRepository) ListReports(arg0 context.Context, arg1, arg2 int) ([]*reconciliation.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReports", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*reconciliation.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReports indicates an expected call of ListReports.
+func (mr *MockReconciliationRepositoryMockRecorder) ListReports(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReports", reflect.TypeOf((*MockReconciliationRepository)(nil).ListReports), arg0, arg1, arg2)
+}
+
+// UpdateDiscrepancy mocks base method.
+func (m *MockReconciliationRepository) UpdateDiscrepancy(arg0 context.Context, arg1 *reconciliation.Discrepancy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDiscrepancy", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDiscrepancy indicates an expected call of UpdateDiscrepancy.
+func (mr *MockReconciliationRepositoryMockRecorder) UpdateDiscrepancy(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDiscrepancy", reflect.TypeOf((*MockReconciliationRepository)(nil).UpdateDiscrepancy), arg0, arg1)
+}
+
+// UpdateReport mocks base method.
+func (m *MockReconciliationRepository) UpdateReport(arg0 context.Context, arg1 *reconciliation.Report) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateReport", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateReport indicates an expected call of UpdateReport.
+func (mr *MockReconciliationRepositoryMockRecorder) UpdateReport(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateReport", reflect.TypeOf((*MockReconciliationRepository)(nil).UpdateReport), arg0, arg1)
+}
+
+// MockSubscriptionRepository is a mock of SubscriptionRepository interface.
+type MockSubscriptionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSubscriptionRepositoryMockRecorder
+}
+
+// MockSubscriptionRepositoryMockRecorder is the mock recorder for MockSubscriptionRepository.
+type MockSubscriptionRepositoryMockRecorder struct {
+	mock *MockSubscriptionRepository
+}
+
+// NewMockSubscriptionRepository creates a new mock instance.
+func NewMockSubscriptionRepository(ctrl *gomock.Controller) *MockSubscriptionRepository {
+	mock := &MockSubscriptionRepository{ctrl: ctrl}
+	mock.recorder = &MockSubscriptionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSubscriptionRepository) EXPECT() *MockSubscriptionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CancelByMerchant mocks base method.
+func (m *MockSubscriptionRepository) CancelByMerchant(arg0 context.Context, arg1 string, arg2 time.Time, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelByMerchant", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelByMerchant indicates an expected call of CancelByMerchant.
+func (mr *MockSubscriptionRepositoryMockRecorder) CancelByMerchant(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelByMerchant", reflect.TypeOf((*MockSubscriptionRepository)(nil).CancelByMerchant), arg0, arg1, arg2, arg3)
+}
+
+// Create mocks base method.
+func (m *MockSubscriptionRepository) Create(arg0 context.Context, arg1 *subscription.Subscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockSubscriptionRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSubscriptionRepository)(nil).Create), arg0, arg1)
+}
+
+// CreateEvent mocks base method.
+func (m *MockSubscriptionRepository) CreateEvent(arg0 context.Context, arg1 *subscription.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEvent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEvent indicates an expected call of CreateEvent.
+func (mr *MockSubscriptionRepositoryMockRecorder) CreateEvent(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEvent", reflect.TypeOf((*MockSubscriptionRepository)(nil).CreateEvent), arg0, arg1)
+}
+
+// CreatePlan mocks base method.
+func (m *MockSubscriptionRepository) CreatePlan(arg0 context.Context, arg1 *subscription.Plan) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePlan", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePlan indicates an expected call of CreatePlan.
+func (mr *MockSubscriptionRepositoryMockRecorder) CreatePlan(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePlan", reflect.TypeOf((*MockSubscriptionRepository)(nil).CreatePlan), arg0, arg1)
+}
+
+// GetByID mocks base method.
+func (m *MockSubscriptionRepository) GetByID(arg0 context.Context, arg1 string) (*subscription.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1)
+	ret0, _ := ret[0].(*subscription.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockSubscriptionRepositoryMockRecorder) GetByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockSubscriptionRepository)(nil).GetByID), arg0, arg1)
+}
+
+// GetPlan mocks base method.
+func (m *MockSubscriptionRepository) GetPlan(arg0 context.Context, arg1 string) (*subscription.Plan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPlan", arg0, arg1)
+	ret0, _ := ret[0].(*subscription.Plan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPlan indicates an expected call of GetPlan.
+func (mr *MockSubscriptionRepositoryMockRecorder) GetPlan(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPlan", reflect.TypeOf((*MockSubscriptionRepository)(nil).GetPlan), arg0, arg1)
+}
+
+// List mocks base method.
+func (m *MockSubscriptionRepository) List(arg0 context.Context, arg1 string, arg2 mode.Mode, arg3, arg4 int) ([]*subscription.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]*subscription.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockSubscriptionRepositoryMockRecorder) List(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockSubscriptionRepository)(nil).List), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ListDue mocks base method.
+func (m *MockSubscriptionRepository) ListDue(arg0 context.Context, arg1 time.Time, arg2 int) ([]*subscription.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDue", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*subscription.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDue indicates an expected call of ListDue.
+func (mr *MockSubscriptionRepositoryMockRecorder) ListDue(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDue", reflect.TypeOf((*MockSubscriptionRepository)(nil).ListDue), arg0, arg1, arg2)
+}
+
+// ListEvents mocks base method.
+func (m *MockSubscriptionRepository) ListEvents(arg0 context.Context, arg1 string, arg2, arg3 int) ([]*subscription.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEvents", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*subscription.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEvents indicates an expected call of ListEvents.
+func (mr *MockSubscriptionRepositoryMockRecorder) ListEvents(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEvents", reflect.TypeOf((*MockSubscriptionRepository)(nil).ListEvents), arg0, arg1, arg2, arg3)
+}
+
+// ListPlans mocks base method.
+func (m *MockSubscriptionRepository) ListPlans(arg0 context.Context, arg1 string, arg2 mode.Mode, arg3, arg4 int) ([]*subscription.Plan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPlans", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]*subscription.Plan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPlans indicates an expected call of ListPlans.
+func (mr *MockSubscriptionRepositoryMockRecorder) ListPlans(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPlans", reflect.TypeOf((*MockSubscriptionRepository)(nil).ListPlans), arg0, arg1, arg2, arg3, arg4)
+}
+
+// Update mocks base method.
+func (m *MockSubscriptionRepository) Update(arg0 context.Context, arg1 *subscription.Subscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockSubscriptionRepositoryMockRecorder) Update(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockSubscriptionRepository)(nil).Update), arg0, arg1)
+}
+
+// UpdatePlan mocks base method.
+func (m *MockSubscriptionRepository) UpdatePlan(arg0 context.Context, arg1 *subscription.Plan) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePlan", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePlan indicates an expected call of UpdatePlan.
+func (mr *MockSubscriptionRepositoryMockRecorder) UpdatePlan(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePlan", reflect.TypeOf((*MockSubscriptionRepository)(nil).UpdatePlan), arg0, arg1)
+}
+
+// MockCustomerRepository is a mock of CustomerRepository interface.
+type MockCustomerRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCustomerRepositoryMockRecorder
+}
+
+// MockCustomerRepositoryMockRecorder is the mock recorder for MockCustomerRepository.
+type MockCustomerRepositoryMockRecorder struct {
+	mock *MockCustomerRepository
+}
+
+// NewMockCustomerRepository creates a new mock instance.
+func NewMockCustomerRepository(ctrl *gomock.Controller) *MockCustomerRepository {
+	mock := &MockCustomerRepository{ctrl: ctrl}
+	mock.recorder = &MockCustomerRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCustomerRepository) EXPECT() *MockCustomerRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddPaymentMethod mocks base method.
+func (m *MockCustomerRepository) AddPaymentMethod(arg0 context.Context, arg1 *customer.PaymentMethod) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPaymentMethod", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPaymentMethod indicates an expected call of AddPaymentMethod.
+func (mr *MockCustomerRepositoryMockRecorder) AddPaymentMethod(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPaymentMethod", reflect.TypeOf((*MockCustomerRepository)(nil).AddPaymentMethod), arg0, arg1)
+}
+
+// Create mocks base method.
+func (m *MockCustomerRepository) Create(arg0 context.Context, arg1 *customer.Customer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockCustomerRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockCustomerRepository)(nil).Create), arg0, arg1)
+}
+
+// Delete mocks base method.
+func (m *MockCustomerRepository) Delete(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCustomerRepositoryMockRecorder) Delete(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCustomerRepository)(nil).Delete), arg0, arg1)
+}
+
+// DeletePaymentMethod mocks base method.
+func (m *MockCustomerRepository) DeletePaymentMethod(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePaymentMethod", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePaymentMethod indicates an expected call of DeletePaymentMethod.
+func (mr *MockCustomerRepositoryMockRecorder) DeletePaymentMethod(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePaymentMethod", reflect.TypeOf((*MockCustomerRepository)(nil).DeletePaymentMethod), arg0, arg1)
+}
+
+// GetByID mocks base method.
+func (m *MockCustomerRepository) GetByID(arg0 context.Context, arg1 string) (*customer.Customer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1)
+	ret0, _ := ret[0].(*customer.Customer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockCustomerRepositoryMockRecorder) GetByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockCustomerRepository)(nil).GetByID), arg0, arg1)
+}
+
+// GetPaymentMethod mocks base method.
+func (m *MockCustomerRepository) GetPaymentMethod(arg0 context.Context, arg1 string) (*customer.PaymentMethod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentMethod", arg0, arg1)
+	ret0, _ := ret[0].(*customer.PaymentMethod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentMethod indicates an expected call of GetPaymentMethod.
+func (mr *MockCustomerRepositoryMockRecorder) GetPaymentMethod(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentMethod", reflect.TypeOf((*MockCustomerRepository)(nil).GetPaymentMethod), arg0, arg1)
+}
+
+// List mocks base method.
+func (m *MockCustomerRepository) List(arg0 context.Context, arg1 string, arg2 mode.Mode, arg3 string, arg4, arg5 int) ([]*customer.Customer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].([]*customer.Customer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockCustomerRepositoryMockRecorder) List(arg0, arg1, arg2, arg3, arg4, arg5 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockCustomerRepository)(nil).List), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// ListPaymentMethods mocks base method.
+func (m *MockCustomerRepository) ListPaymentMethods(arg0 context.Context, arg1 string) ([]*customer.PaymentMethod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPaymentMethods", arg0, arg1)
+	ret0, _ := ret[0].([]*customer.PaymentMethod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPaymentMethods indicates an expected call of ListPaymentMethods.
+func (mr *MockCustomerRepositoryMockRecorder) ListPaymentMethods(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPaymentMethods", reflect.TypeOf((*MockCustomerRepository)(nil).ListPaymentMethods), arg0, arg1)
+}
+
+// SetDefaultPaymentMethod mocks base method.
+func (m *MockCustomerRepository) SetDefaultPaymentMethod(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDefaultPaymentMethod", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDefaultPaymentMethod indicates an expected call of SetDefaultPaymentMethod.
+func (mr *MockCustomerRepositoryMockRecorder) SetDefaultPaymentMethod(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDefaultPaymentMethod", reflect.TypeOf((*MockCustomerRepository)(nil).SetDefaultPaymentMethod), arg0, arg1, arg2)
+}
+
+// Update mocks base method.
+func (m *MockCustomerRepository) Update(arg0 context.Context, arg1 *customer.Customer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockCustomerRepositoryMockRecorder) Update(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockCustomerRepository)(nil).Update), arg0, arg1)
+}
+
+// MockMemberRepository is a mock of MemberRepository interface.
+type MockMemberRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockMemberRepositoryMockRecorder
+}
+
+// MockMemberRepositoryMockRecorder is the mock recorder for MockMemberRepository.
+type MockMemberRepositoryMockRecorder struct {
+	mock *MockMemberRepository
+}
+
+// NewMockMemberRepository creates a new mock instance.
+func NewMockMemberRepository(ctrl *gomock.Controller) *MockMemberRepository {
+	mock := &MockMemberRepository{ctrl: ctrl}
+	mock.recorder = &MockMemberRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMemberRepository) EXPECT() *MockMemberRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockMemberRepository) Add(arg0 context.Context, arg1 *member.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockMemberRepositoryMockRecorder) Add(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockMemberRepository)(nil).Add), arg0, arg1)
+}
+
+// CountByRole mocks base method.
+func (m *MockMemberRepository) CountByRole(arg0 context.Context, arg1 string, arg2 member.Role) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByRole", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByRole indicates an expected call of CountByRole.
+func (mr *MockMemberRepositoryMockRecorder) CountByRole(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByRole", reflect.TypeOf((*MockMemberRepository)(nil).CountByRole), arg0, arg1, arg2)
+}
+
+// Get mocks base method.
+func (m *MockMemberRepository) Get(arg0 context.Context, arg1, arg2 string) (*member.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*member.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockMemberRepositoryMockRecorder) Get(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockMemberRepository)(nil).Get), arg0, arg1, arg2)
+}
+
+// List mocks base method.
+func (m *MockMemberRepository) List(arg0 context.Context, arg1 string) ([]*member.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0, arg1)
+	ret0, _ := ret[0].([]*member.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockMemberRepositoryMockRecorder) List(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockMemberRepository)(nil).List), arg0, arg1)
+}
+
+// Remove mocks base method.
+func (m *MockMemberRepository) Remove(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockMemberRepositoryMockRecorder) Remove(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockMemberRepository)(nil).Remove), arg0, arg1, arg2)
+}
+
+// MockAPIKeyRepository is a mock of APIKeyRepository interface.
+type MockAPIKeyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyRepositoryMockRecorder
+}
+
+// MockAPIKeyRepositoryMockRecorder is the mock recorder for MockAPIKeyRepository.
+type MockAPIKeyRepositoryMockRecorder struct {
+	mock *MockAPIKeyRepository
+}
+
+// NewMockAPIKeyRepository creates a new mock instance.
+func NewMockAPIKeyRepository(ctrl *gomock.Controller) *MockAPIKeyRepository {
+	mock := &MockAPIKeyRepository{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyRepository) EXPECT() *MockAPIKeyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAPIKeyRepository) Create(arg0 context.Context, arg1 *apikey.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAPIKeyRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAPIKeyRepository)(nil).Create), arg0, arg1)
+}
+
+// GetByHash mocks base method.
+func (m *MockAPIKeyRepository) GetByHash(arg0 context.Context, arg1 string) (*apikey.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHash", arg0, arg1)
+	ret0, _ := ret[0].(*apikey.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByHash indicates an expected call of GetByHash.
+func (mr *MockAPIKeyRepositoryMockRecorder) GetByHash(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHash", reflect.TypeOf((*MockAPIKeyRepository)(nil).GetByHash), arg0, arg1)
+}
+
+// GetByID mocks base method.
+func (m *MockAPIKeyRepository) GetByID(arg0 context.Context, arg1 string) (*apikey.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1)
+	ret0, _ := ret[0].(*apikey.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockAPIKeyRepositoryMockRecorder) GetByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockAPIKeyRepository)(nil).GetByID), arg0, arg1)
+}
+
+// ListByMerchant mocks base method.
+func (m *MockAPIKeyRepository) ListByMerchant(arg0 context.Context, arg1 string) ([]*apikey.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByMerchant", arg0, arg1)
+	ret0, _ := ret[0].([]*apikey.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByMerchant indicates an expected call of ListByMerchant.
+func (mr *MockAPIKeyRepositoryMockRecorder) ListByMerchant(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByMerchant", reflect.TypeOf((*MockAPIKeyRepository)(nil).ListByMerchant), arg0, arg1)
+}
+
+// PruneNonces mocks base method.
+func (m *MockAPIKeyRepository) PruneNonces(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PruneNonces", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PruneNonces indicates an expected call of PruneNonces.
+func (mr *MockAPIKeyRepositoryMockRecorder) PruneNonces(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneNonces", reflect.TypeOf((*MockAPIKeyRepository)(nil).PruneNonces), arg0, arg1)
+}
+
+// RevokeByMerchant mocks base method.
+func (m *MockAPIKeyRepository) RevokeByMerchant(arg0 context.Context, arg1 string, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeByMerchant", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeByMerchant indicates an expected call of RevokeByMerchant.
+func (mr *MockAPIKeyRepositoryMockRecorder) RevokeByMerchant(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeByMerchant", reflect.TypeOf((*MockAPIKeyRepository)(nil).RevokeByMerchant), arg0, arg1, arg2)
+}
+
+// Rotate mocks base method.
+func (m *MockAPIKeyRepository) Rotate(arg0 context.Context, arg1, arg2 *apikey.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rotate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rotate indicates an expected call of Rotate.
+func (mr *MockAPIKeyRepositoryMockRecorder) Rotate(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rotate", reflect.TypeOf((*MockAPIKeyRepository)(nil).Rotate), arg0, arg1, arg2)
+}
+
+// TouchLastUsed mocks base method.
+func (m *MockAPIKeyRepository) TouchLastUsed(arg0 context.Context, arg1 string, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchLastUsed", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchLastUsed indicates an expected call of TouchLastUsed.
+func (mr *MockAPIKeyRepositoryMockRecorder) TouchLastUsed(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchLastUsed", reflect.TypeOf((*MockAPIKeyRepository)(nil).TouchLastUsed), arg0, arg1, arg2)
+}
+
+// Update mocks base method.
+func (m *MockAPIKeyRepository) Update(arg0 context.Context, arg1 *apikey.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockAPIKeyRepositoryMockRecorder) Update(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockAPIKeyRepository)(nil).Update), arg0, arg1)
+}
+
+// UseNonce mocks base method.
+func (m *MockAPIKeyRepository) UseNonce(arg0 context.Context, arg1, arg2 string, arg3 time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseNonce", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseNonce indicates an expected call of UseNonce.
+func (mr *MockAPIKeyRepositoryMockRecorder) UseNonce(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseNonce", reflect.TypeOf((*MockAPIKeyRepository)(nil).UseNonce), arg0, arg1, arg2, arg3)
+}
+
+// MockOnboardingRepository is a mock of OnboardingRepository interface.
+type MockOnboardingRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOnboardingRepositoryMockRecorder
+}
+
+// MockOnboardingRepositoryMockRecorder is the mock recorder for MockOnboardingRepository.
+type MockOnboardingRepositoryMockRecorder struct {
+	mock *MockOnboardingRepository
+}
+
+// NewMockOnboardingRepository creates a new mock instance.
+func NewMockOnboardingRepository(ctrl *gomock.Controller) *MockOnboardingRepository {
+	mock := &MockOnboardingRepository{ctrl: ctrl}
+	mock.recorder = &MockOnboardingRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOnboardingRepository) EXPECT() *MockOnboardingRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddDocument mocks base method.
+func (m *MockOnboardingRepository) AddDocument(arg0 context.Context, arg1 *merchant.Document) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddDocument", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddDocument indicates an expected call of AddDocument.
+func (mr *MockOnboardingRepositoryMockRecorder) AddDocument(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDocument", reflect.TypeOf((*MockOnboardingRepository)(nil).AddDocument), arg0, arg1)
+}
+
+// AddOwner mocks base method.
+func (m *MockOnboardingRepository) AddOwner(arg0 context.Context, arg1 *merchant.BeneficialOwner) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOwner", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddOwner indicates an expected call of AddOwner.
+func (mr *MockOnboardingRepositoryMockRecorder) AddOwner(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOwner", reflect.TypeOf((*MockOnboardingRepository)(nil).AddOwner), arg0, arg1)
+}
+
+// DeleteOwner mocks base method.
+func (m *MockOnboardingRepository) DeleteOwner(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOwner", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOwner indicates an expected call of DeleteOwner.
+func (mr *MockOnboardingRepositoryMockRecorder) DeleteOwner(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOwner", reflect.TypeOf((*MockOnboardingRepository)(nil).DeleteOwner), arg0, arg1, arg2)
+}
+
+// GetDocument mocks base method.
+func (m *MockOnboardingRepository) GetDocument(arg0 context.Context, arg1 string) (*merchant.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDocument", arg0, arg1)
+	ret0, _ := ret[0].(*merchant.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDocument indicates an expected call of GetDocument.
+func (mr *MockOnboardingRepositoryMockRecorder) GetDocument(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocument", reflect.TypeOf((*MockOnboardingRepository)(nil).GetDocument), arg0, arg1)
+}
+
+// ListDocuments mocks base method.
+func (m *MockOnboardingRepository) ListDocuments(arg0 context.Context, arg1 string) ([]*merchant.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDocuments", arg0, arg1)
+	ret0, _ := ret[0].([]*merchant.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDocuments indicates an expected call of ListDocuments.
+func (mr *MockOnboardingRepositoryMockRecorder) ListDocuments(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDocuments", reflect.TypeOf((*MockOnboardingRepository)(nil).ListDocuments), arg0, arg1)
+}
+
+// ListOwners mocks base method.
+func (m *MockOnboardingRepository) ListOwners(arg0 context.Context, arg1 string) ([]*merchant.BeneficialOwner, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOwners", arg0, arg1)
+	ret0, _ := ret[0].([]*merchant.BeneficialOwner)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOwners indicates an expected call of ListOwners.
+func (mr *MockOnboardingRepositoryMockRecorder) ListOwners(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOwners", reflect.TypeOf((*MockOnboardingRepository)(nil).ListOwners), arg0, arg1)
+}
+
+// MockAuditRepository is a mock of AuditRepository interface.
+type MockAuditRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRepositoryMockRecorder
+}
+
+// MockAuditRepositoryMockRecorder is the mock recorder for MockAuditRepository.
+type MockAuditRepositoryMockRecorder struct {
+	mock *MockAuditRepository
+}
+
+// NewMockAuditRepository creates a new mock instance.
+func NewMockAuditRepository(ctrl *gomock.Controller) *MockAuditRepository {
+	mock := &MockAuditRepository{ctrl: ctrl}
+	mock.recorder = &MockAuditRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRepository) EXPECT() *MockAuditRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockAuditRepository) Append(arg0 context.Context, arg1 *audit.Entry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockAuditRepositoryMockRecorder) Append(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockAuditRepository)(nil).Append), arg0, arg1)
+}
+
+// List mocks base method.
+func (m *MockAuditRepository) List(arg0 context.Context, arg1 audit.Filter) ([]*audit.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0, arg1)
+	ret0, _ := ret[0].([]*audit.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAuditRepositoryMockRecorder) List(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAuditRepository)(nil).List), arg0, arg1)
+}
+
+// ListAfter mocks base method.
+func (m *MockAuditRepository) ListAfter(arg0 context.Context, arg1 int64, arg2 int) ([]*audit.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAfter", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*audit.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAfter indicates an expected call of ListAfter.
+func (mr *MockAuditRepositoryMockRecorder) ListAfter(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAfter", reflect.TypeOf((*MockAuditRepository)(nil).ListAfter), arg0, arg1, arg2)
+}
+
+// MockOAuthClientRepository is a mock of OAuthClientRepository interface.
+type MockOAuthClientRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthClientRepositoryMockRecorder
+}
+
+// MockOAuthClientRepositoryMockRecorder is the mock recorder for MockOAuthClientRepository.
+type MockOAuthClientRepositoryMockRecorder struct {
+	mock *MockOAuthClientRepository
+}
+
+// NewMockOAuthClientRepository creates a new mock instance.
+func NewMockOAuthClientRepository(ctrl *gomock.Controller) *MockOAuthClientRepository {
+	mock := &MockOAuthClientRepository{ctrl: ctrl}
+	mock.recorder = &MockOAuthClientRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuthClientRepository) EXPECT() *MockOAuthClientRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockOAuthClientRepository) Create(arg0 context.Context, arg1 *oauth.Client) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockOAuthClientRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOAuthClientRepository)(nil).Create), arg0, arg1)
+}
+
+// DeleteGrant mocks base method.
+func (m *MockOAuthClientRepository) DeleteGrant(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGrant", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGrant indicates an expected call of DeleteGrant.
+func (mr *MockOAuthClientRepositoryMockRecorder) DeleteGrant(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGrant", reflect.TypeOf((*MockOAuthClientRepository)(nil).DeleteGrant), arg0, arg1, arg2)
+}
+
+// DeleteGrantsByMerchant mocks base method.
+func (m *MockOAuthClientRepository) DeleteGrantsByMerchant(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGrantsByMerchant", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGrantsByMerchant indicates an expected call of DeleteGrantsByMerchant.
+func (mr *MockOAuthClientRepositoryMockRecorder) DeleteGrantsByMerchant(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGrantsByMerchant", reflect.TypeOf((*MockOAuthClientRepository)(nil).DeleteGrantsByMerchant), arg0, arg1)
+}
+
+// GetByID mocks base method.
+func (m *MockOAuthClientRepository) GetByID(arg0 context.Context, arg1 string) (*oauth.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1)
+	ret0, _ := ret[0].(*oauth.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockOAuthClientRepositoryMockRecorder) GetByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockOAuthClientRepository)(nil).GetByID), arg0, arg1)
+}
+
+// GetGrant mocks base method.
+func (m *MockOAuthClientRepository) GetGrant(arg0 context.Context, arg1, arg2 string) (*oauth.Grant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGrant", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*oauth.Grant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGrant indicates an expected call of GetGrant.
+func (mr *MockOAuthClientRepositoryMockRecorder) GetGrant(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGrant", reflect.TypeOf((*MockOAuthClientRepository)(nil).GetGrant), arg0, arg1, arg2)
+}
+
+// ListByOwner mocks base method.
+func (m *MockOAuthClientRepository) ListByOwner(arg0 context.Context, arg1 string) ([]*oauth.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByOwner", arg0, arg1)
+	ret0, _ := ret[0].([]*oauth.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByOwner indicates an expected call of ListByOwner.
+func (mr *MockOAuthClientRepositoryMockRecorder) ListByOwner(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByOwner", reflect.TypeOf((*MockOAuthClientRepository)(nil).ListByOwner), arg0, arg1)
+}
+
+// ListGrantsByClient mocks base method.
+func (m *MockOAuthClientRepository) ListGrantsByClient(arg0 context.Context, arg1 string) ([]*oauth.Grant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGrantsByClient", arg0, arg1)
+	ret0, _ := ret[0].([]*oauth.Grant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGrantsByClient indicates an expected call of ListGrantsByClient.
+func (mr *MockOAuthClientRepositoryMockRecorder) ListGrantsByClient(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGrantsByClient", reflect.TypeOf((*MockOAuthClientRepository)(nil).ListGrantsByClient), arg0, arg1)
+}
+
+// ListGrantsByMerchant mocks base method.
+func (m *MockOAuthClientRepository) ListGrantsByMerchant(arg0 context.Context, arg1 string) ([]*oauth.Grant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGrantsByMerchant", arg0, arg1)
+	ret0, _ := ret[0].([]*oauth.Grant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGrantsByMerchant indicates an expected call of ListGrantsByMerchant.
+func (mr *MockOAuthClientRepositoryMockRecorder) ListGrantsByMerchant(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGrantsByMerchant", reflect.TypeOf((*MockOAuthClientRepository)(nil).ListGrantsByMerchant), arg0, arg1)
+}
+
+// Revoke mocks base method.
+func (m *MockOAuthClientRepository) Revoke(arg0 context.Context, arg1 string, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockOAuthClientRepositoryMockRecorder) Revoke(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockOAuthClientRepository)(nil).Revoke), arg0, arg1, arg2)
+}
+
+// SaveGrant mocks base method.
+func (m *MockOAuthClientRepository) SaveGrant(arg0 context.Context, arg1 *oauth.Grant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveGrant", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveGrant indicates an expected call of SaveGrant.
+func (mr *MockOAuthClientRepositoryMockRecorder) SaveGrant(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveGrant", reflect.TypeOf((*MockOAuthClientRepository)(nil).SaveGrant), arg0, arg1)
+}
@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/popeskul/payment-gateway/internal/core/ports (interfaces: Services,MerchantService,AcquiringBank,PaymentService,RefundService,UserService)
+// Source: github.com/popeskul/payment-gateway/internal/core/ports (interfaces: Services,MerchantService,AcquiringBank,PaymentService,RefundService,UserService,ReconciliationService,PaymentSweeper,SubscriptionService,CustomerService,MemberService,APIKeyService,OnboardingService,MerchantExportService,AuditService,OAuthService)
 //
 // Generated by this command:
 //
-//	mockgen -destination=service_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports Services,MerchantService,AcquiringBank,PaymentService,RefundService,UserService
+//	mockgen -destination=service_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports Services,MerchantService,AcquiringBank,PaymentService,RefundService,UserService,ReconciliationService,PaymentSweeper,SubscriptionService,CustomerService,MemberService,APIKeyService,OnboardingService,MerchantExportService,AuditService,OAuthService
 //
 
 // Package ports is a generated GoMock package.
@@ -11,12 +11,21 @@ package ports
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
 	time "time"
 
+	apikey "github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	audit "github.com/popeskul/payment-gateway/internal/core/domain/audit"
+	customer "github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	member "github.com/popeskul/payment-gateway/internal/core/domain/member"
 	merchant "github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	mode "github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	oauth "github.com/popeskul/payment-gateway/internal/core/domain/oauth"
 	payment "github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	reconciliation "github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
 	refund "github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	subscription "github.com/popeskul/payment-gateway/internal/core/domain/subscription"
 	user "github.com/popeskul/payment-gateway/internal/core/domain/user"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -44,6 +53,76 @@ func (m *MockServices) EXPECT() *MockServicesMockRecorder {
 	return m.recorder
 }
 
+// APIKeys mocks base method.
+func (m *MockServices) APIKeys() APIKeyService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "APIKeys")
+	ret0, _ := ret[0].(APIKeyService)
+	return ret0
+}
+
+// APIKeys indicates an expected call of APIKeys.
+func (mr *MockServicesMockRecorder) APIKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "APIKeys", reflect.TypeOf((*MockServices)(nil).APIKeys))
+}
+
+// Audit mocks base method.
+func (m *MockServices) Audit() AuditService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Audit")
+	ret0, _ := ret[0].(AuditService)
+	return ret0
+}
+
+// Audit indicates an expected call of Audit.
+func (mr *MockServicesMockRecorder) Audit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Audit", reflect.TypeOf((*MockServices)(nil).Audit))
+}
+
+// Customers mocks base method.
+func (m *MockServices) Customers() CustomerService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Customers")
+	ret0, _ := ret[0].(CustomerService)
+	return ret0
+}
+
+// Customers indicates an expected call of Customers.
+func (mr *MockServicesMockRecorder) Customers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Customers", reflect.TypeOf((*MockServices)(nil).Customers))
+}
+
+// Exports mocks base method.
+func (m *MockServices) Exports() MerchantExportService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exports")
+	ret0, _ := ret[0].(MerchantExportService)
+	return ret0
+}
+
+// Exports indicates an expected call of Exports.
+func (mr *MockServicesMockRecorder) Exports() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exports", reflect.TypeOf((*MockServices)(nil).Exports))
+}
+
+// Members mocks base method.
+func (m *MockServices) Members() MemberService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Members")
+	ret0, _ := ret[0].(MemberService)
+	return ret0
+}
+
+// Members indicates an expected call of Members.
+func (mr *MockServicesMockRecorder) Members() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Members", reflect.TypeOf((*MockServices)(nil).Members))
+}
+
 // Merchants mocks base method.
 func (m *MockServices) Merchants() MerchantService {
 	m.ctrl.T.Helper()
@@ -58,6 +137,34 @@ func (mr *MockServicesMockRecorder) Merchants() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merchants", reflect.TypeOf((*MockServices)(nil).Merchants))
 }
 
+// OAuth mocks base method.
+func (m *MockServices) OAuth() OAuthService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OAuth")
+	ret0, _ := ret[0].(OAuthService)
+	return ret0
+}
+
+// OAuth indicates an expected call of OAuth.
+func (mr *MockServicesMockRecorder) OAuth() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OAuth", reflect.TypeOf((*MockServices)(nil).OAuth))
+}
+
+// Onboarding mocks base method.
+func (m *MockServices) Onboarding() OnboardingService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Onboarding")
+	ret0, _ := ret[0].(OnboardingService)
+	return ret0
+}
+
+// Onboarding indicates an expected call of Onboarding.
+func (mr *MockServicesMockRecorder) Onboarding() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Onboarding", reflect.TypeOf((*MockServices)(nil).Onboarding))
+}
+
 // Payments mocks base method.
 func (m *MockServices) Payments() PaymentService {
 	m.ctrl.T.Helper()
@@ -72,6 +179,20 @@ func (mr *MockServicesMockRecorder) Payments() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Payments", reflect.TypeOf((*MockServices)(nil).Payments))
 }
 
+// Reconciliation mocks base method.
+func (m *MockServices) Reconciliation() ReconciliationService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reconciliation")
+	ret0, _ := ret[0].(ReconciliationService)
+	return ret0
+}
+
+// Reconciliation indicates an expected call of Reconciliation.
+func (mr *MockServicesMockRecorder) Reconciliation() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reconciliation", reflect.TypeOf((*MockServices)(nil).Reconciliation))
+}
+
 // Refunds mocks base method.
 func (m *MockServices) Refunds() RefundService {
 	m.ctrl.T.Helper()
@@ -86,6 +207,20 @@ func (mr *MockServicesMockRecorder) Refunds() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refunds", reflect.TypeOf((*MockServices)(nil).Refunds))
 }
 
+// Subscriptions mocks base method.
+func (m *MockServices) Subscriptions() SubscriptionService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscriptions")
+	ret0, _ := ret[0].(SubscriptionService)
+	return ret0
+}
+
+// Subscriptions indicates an expected call of Subscriptions.
+func (mr *MockServicesMockRecorder) Subscriptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscriptions", reflect.TypeOf((*MockServices)(nil).Subscriptions))
+}
+
 // Users mocks base method.
 func (m *MockServices) Users() UserService {
 	m.ctrl.T.Helper()
@@ -124,31 +259,32 @@ func (m *MockMerchantService) EXPECT() *MockMerchantServiceMockRecorder {
 }
 
 // CreateMerchant mocks base method.
-func (m *MockMerchantService) CreateMerchant(arg0 context.Context, arg1 *merchant.Merchant) error {
+func (m *MockMerchantService) CreateMerchant(arg0 context.Context, arg1 *merchant.Merchant, arg2 string) ([]*apikey.CreatedKey, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateMerchant", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "CreateMerchant", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*apikey.CreatedKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CreateMerchant indicates an expected call of CreateMerchant.
-func (mr *MockMerchantServiceMockRecorder) CreateMerchant(arg0, arg1 any) *gomock.Call {
+func (mr *MockMerchantServiceMockRecorder) CreateMerchant(arg0, arg1, arg2 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMerchant", reflect.TypeOf((*MockMerchantService)(nil).CreateMerchant), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMerchant", reflect.TypeOf((*MockMerchantService)(nil).CreateMerchant), arg0, arg1, arg2)
 }
 
 // DeleteMerchant mocks base method.
-func (m *MockMerchantService) DeleteMerchant(arg0 context.Context, arg1 string) error {
+func (m *MockMerchantService) DeleteMerchant(arg0 context.Context, arg1 string, arg2 *merchant.DeleteRequest) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteMerchant", arg0, arg1)
+	ret := m.ctrl.Call(m, "DeleteMerchant", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteMerchant indicates an expected call of DeleteMerchant.
-func (mr *MockMerchantServiceMockRecorder) DeleteMerchant(arg0, arg1 any) *gomock.Call {
+func (mr *MockMerchantServiceMockRecorder) DeleteMerchant(arg0, arg1, arg2 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMerchant", reflect.TypeOf((*MockMerchantService)(nil).DeleteMerchant), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMerchant", reflect.TypeOf((*MockMerchantService)(nil).DeleteMerchant), arg0, arg1, arg2)
 }
 
 // GetMerchant mocks base method.
@@ -167,18 +303,18 @@ func (mr *MockMerchantServiceMockRecorder) GetMerchant(arg0, arg1 any) *gomock.C
 }
 
 // ListMerchants mocks base method.
-func (m *MockMerchantService) ListMerchants(arg0 context.Context, arg1, arg2 int) ([]*merchant.Merchant, error) {
+func (m *MockMerchantService) ListMerchants(arg0 context.Context, arg1 string, arg2, arg3 int) ([]*merchant.Merchant, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListMerchants", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "ListMerchants", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].([]*merchant.Merchant)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListMerchants indicates an expected call of ListMerchants.
-func (mr *MockMerchantServiceMockRecorder) ListMerchants(arg0, arg1, arg2 any) *gomock.Call {
+func (mr *MockMerchantServiceMockRecorder) ListMerchants(arg0, arg1, arg2, arg3 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMerchants", reflect.TypeOf((*MockMerchantService)(nil).ListMerchants), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMerchants", reflect.TypeOf((*MockMerchantService)(nil).ListMerchants), arg0, arg1, arg2, arg3)
 }
 
 // UpdateMerchant mocks base method.
@@ -195,6 +331,21 @@ func (mr *MockMerchantServiceMockRecorder) UpdateMerchant(arg0, arg1 any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMerchant", reflect.TypeOf((*MockMerchantService)(nil).UpdateMerchant), arg0, arg1)
 }
 
+// UpdateSettings mocks base method.
+func (m *MockMerchantService) UpdateSettings(arg0 context.Context, arg1 string, arg2 *merchant.Settings) (*merchant.Merchant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSettings", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*merchant.Merchant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSettings indicates an expected call of UpdateSettings.
+func (mr *MockMerchantServiceMockRecorder) UpdateSettings(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSettings", reflect.TypeOf((*MockMerchantService)(nil).UpdateSettings), arg0, arg1, arg2)
+}
+
 // MockAcquiringBank is a mock of AcquiringBank interface.
 type MockAcquiringBank struct {
 	ctrl     *gomock.Controller
@@ -218,6 +369,34 @@ func (m *MockAcquiringBank) EXPECT() *MockAcquiringBankMockRecorder {
 	return m.recorder
 }
 
+// AuthorizePayment mocks base method.
+func (m *MockAcquiringBank) AuthorizePayment(arg0 context.Context, arg1 *payment.Payment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizePayment", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizePayment indicates an expected call of AuthorizePayment.
+func (mr *MockAcquiringBankMockRecorder) AuthorizePayment(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizePayment", reflect.TypeOf((*MockAcquiringBank)(nil).AuthorizePayment), arg0, arg1)
+}
+
+// CapturePayment mocks base method.
+func (m *MockAcquiringBank) CapturePayment(arg0 context.Context, arg1 *payment.Payment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CapturePayment", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CapturePayment indicates an expected call of CapturePayment.
+func (mr *MockAcquiringBankMockRecorder) CapturePayment(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CapturePayment", reflect.TypeOf((*MockAcquiringBank)(nil).CapturePayment), arg0, arg1)
+}
+
 // ProcessPayment mocks base method.
 func (m *MockAcquiringBank) ProcessPayment(arg0 context.Context, arg1 *payment.Payment) error {
 	m.ctrl.T.Helper()
@@ -270,6 +449,20 @@ func (mr *MockAcquiringBankMockRecorder) SetProcessingDelay(arg0 any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProcessingDelay", reflect.TypeOf((*MockAcquiringBank)(nil).SetProcessingDelay), arg0)
 }
 
+// VoidPayment mocks base method.
+func (m *MockAcquiringBank) VoidPayment(arg0 context.Context, arg1 *payment.Payment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidPayment", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VoidPayment indicates an expected call of VoidPayment.
+func (mr *MockAcquiringBankMockRecorder) VoidPayment(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidPayment", reflect.TypeOf((*MockAcquiringBank)(nil).VoidPayment), arg0, arg1)
+}
+
 // MockPaymentService is a mock of PaymentService interface.
 type MockPaymentService struct {
 	ctrl     *gomock.Controller
@@ -293,6 +486,20 @@ func (m *MockPaymentService) EXPECT() *MockPaymentServiceMockRecorder {
 	return m.recorder
 }
 
+// CapturePayment mocks base method.
+func (m *MockPaymentService) CapturePayment(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CapturePayment", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CapturePayment indicates an expected call of CapturePayment.
+func (mr *MockPaymentServiceMockRecorder) CapturePayment(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CapturePayment", reflect.TypeOf((*MockPaymentService)(nil).CapturePayment), arg0, arg1)
+}
+
 // CreatePayment mocks base method.
 func (m *MockPaymentService) CreatePayment(arg0 context.Context, arg1 *payment.Payment) error {
 	m.ctrl.T.Helper()
@@ -323,18 +530,33 @@ func (mr *MockPaymentServiceMockRecorder) GetPayment(arg0, arg1 any) *gomock.Cal
 }
 
 // ListPayments mocks base method.
-func (m *MockPaymentService) ListPayments(arg0 context.Context, arg1 string, arg2, arg3 int) ([]*payment.Payment, error) {
+func (m *MockPaymentService) ListPayments(arg0 context.Context, arg1 string, arg2 mode.Mode, arg3, arg4 int) ([]*payment.Payment, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListPayments", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "ListPayments", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].([]*payment.Payment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListPayments indicates an expected call of ListPayments.
-func (mr *MockPaymentServiceMockRecorder) ListPayments(arg0, arg1, arg2, arg3 any) *gomock.Call {
+func (mr *MockPaymentServiceMockRecorder) ListPayments(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPayments", reflect.TypeOf((*MockPaymentService)(nil).ListPayments), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ListTransitions mocks base method.
+func (m *MockPaymentService) ListTransitions(arg0 context.Context, arg1 string) ([]*payment.StatusTransition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransitions", arg0, arg1)
+	ret0, _ := ret[0].([]*payment.StatusTransition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransitions indicates an expected call of ListTransitions.
+func (mr *MockPaymentServiceMockRecorder) ListTransitions(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPayments", reflect.TypeOf((*MockPaymentService)(nil).ListPayments), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransitions", reflect.TypeOf((*MockPaymentService)(nil).ListTransitions), arg0, arg1)
 }
 
 // ProcessPayment mocks base method.
@@ -388,6 +610,21 @@ func (m *MockRefundService) EXPECT() *MockRefundServiceMockRecorder {
 	return m.recorder
 }
 
+// CancelRefund mocks base method.
+func (m *MockRefundService) CancelRefund(arg0 context.Context, arg1 string) (*refund.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelRefund", arg0, arg1)
+	ret0, _ := ret[0].(*refund.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelRefund indicates an expected call of CancelRefund.
+func (mr *MockRefundServiceMockRecorder) CancelRefund(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelRefund", reflect.TypeOf((*MockRefundService)(nil).CancelRefund), arg0, arg1)
+}
+
 // CreateRefund mocks base method.
 func (m *MockRefundService) CreateRefund(arg0 context.Context, arg1 *refund.Refund) error {
 	m.ctrl.T.Helper()
@@ -483,6 +720,21 @@ func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
 	return m.recorder
 }
 
+// BeginTwoFactorLogin mocks base method.
+func (m *MockUserService) BeginTwoFactorLogin(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginTwoFactorLogin", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginTwoFactorLogin indicates an expected call of BeginTwoFactorLogin.
+func (mr *MockUserServiceMockRecorder) BeginTwoFactorLogin(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTwoFactorLogin", reflect.TypeOf((*MockUserService)(nil).BeginTwoFactorLogin), arg0, arg1)
+}
+
 // ChangePassword mocks base method.
 func (m *MockUserService) ChangePassword(arg0 context.Context, arg1 string, arg2 *user.ChangePasswordRequest) error {
 	m.ctrl.T.Helper()
@@ -497,6 +749,65 @@ func (mr *MockUserServiceMockRecorder) ChangePassword(arg0, arg1, arg2 any) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUserService)(nil).ChangePassword), arg0, arg1, arg2)
 }
 
+// CompleteTwoFactorLogin mocks base method.
+func (m *MockUserService) CompleteTwoFactorLogin(arg0 context.Context, arg1 *user.TwoFactorLoginRequest, arg2 string) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteTwoFactorLogin", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteTwoFactorLogin indicates an expected call of CompleteTwoFactorLogin.
+func (mr *MockUserServiceMockRecorder) CompleteTwoFactorLogin(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteTwoFactorLogin", reflect.TypeOf((*MockUserService)(nil).CompleteTwoFactorLogin), arg0, arg1, arg2)
+}
+
+// ConfirmTwoFactor mocks base method.
+func (m *MockUserService) ConfirmTwoFactor(arg0 context.Context, arg1 string, arg2 *user.TwoFactorCodeRequest) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTwoFactor", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTwoFactor indicates an expected call of ConfirmTwoFactor.
+func (mr *MockUserServiceMockRecorder) ConfirmTwoFactor(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTwoFactor", reflect.TypeOf((*MockUserService)(nil).ConfirmTwoFactor), arg0, arg1, arg2)
+}
+
+// DisableTwoFactor mocks base method.
+func (m *MockUserService) DisableTwoFactor(arg0 context.Context, arg1 string, arg2 *user.DisableTwoFactorRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTwoFactor", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTwoFactor indicates an expected call of DisableTwoFactor.
+func (mr *MockUserServiceMockRecorder) DisableTwoFactor(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTwoFactor", reflect.TypeOf((*MockUserService)(nil).DisableTwoFactor), arg0, arg1, arg2)
+}
+
+// EnrollTwoFactor mocks base method.
+func (m *MockUserService) EnrollTwoFactor(arg0 context.Context, arg1 string) (*user.TwoFactorEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTwoFactor", arg0, arg1)
+	ret0, _ := ret[0].(*user.TwoFactorEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTwoFactor indicates an expected call of EnrollTwoFactor.
+func (mr *MockUserServiceMockRecorder) EnrollTwoFactor(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTwoFactor", reflect.TypeOf((*MockUserService)(nil).EnrollTwoFactor), arg0, arg1)
+}
+
 // GetUserByID mocks base method.
 func (m *MockUserService) GetUserByID(arg0 context.Context, arg1 string) (*user.User, error) {
 	m.ctrl.T.Helper()
@@ -513,18 +824,33 @@ func (mr *MockUserServiceMockRecorder) GetUserByID(arg0, arg1 any) *gomock.Call
 }
 
 // Login mocks base method.
-func (m *MockUserService) Login(arg0 context.Context, arg1 *user.LoginRequest) (*user.User, error) {
+func (m *MockUserService) Login(arg0 context.Context, arg1 *user.LoginRequest, arg2 string) (*user.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Login", arg0, arg1)
+	ret := m.ctrl.Call(m, "Login", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*user.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Login indicates an expected call of Login.
-func (mr *MockUserServiceMockRecorder) Login(arg0, arg1 any) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) Login(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUserService)(nil).Login), arg0, arg1, arg2)
+}
+
+// RegenerateRecoveryCodes mocks base method.
+func (m *MockUserService) RegenerateRecoveryCodes(arg0 context.Context, arg1 string, arg2 *user.TwoFactorCodeRequest) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenerateRecoveryCodes", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegenerateRecoveryCodes indicates an expected call of RegenerateRecoveryCodes.
+func (mr *MockUserServiceMockRecorder) RegenerateRecoveryCodes(arg0, arg1, arg2 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUserService)(nil).Login), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateRecoveryCodes", reflect.TypeOf((*MockUserService)(nil).RegenerateRecoveryCodes), arg0, arg1, arg2)
 }
 
 // Register mocks base method.
@@ -542,6 +868,49 @@ func (mr *MockUserServiceMockRecorder) Register(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockUserService)(nil).Register), arg0, arg1)
 }
 
+// RequestPasswordReset mocks base method.
+func (m *MockUserService) RequestPasswordReset(arg0 context.Context, arg1 *user.ForgotPasswordRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockUserServiceMockRecorder) RequestPasswordReset(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockUserService)(nil).RequestPasswordReset), arg0, arg1)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUserService) ResetPassword(arg0 context.Context, arg1 *user.ResetPasswordRequest) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUserServiceMockRecorder) ResetPassword(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUserService)(nil).ResetPassword), arg0, arg1)
+}
+
+// SendVerificationEmail mocks base method.
+func (m *MockUserService) SendVerificationEmail(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerificationEmail", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerificationEmail indicates an expected call of SendVerificationEmail.
+func (mr *MockUserServiceMockRecorder) SendVerificationEmail(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerificationEmail", reflect.TypeOf((*MockUserService)(nil).SendVerificationEmail), arg0, arg1)
+}
+
 // UpdateProfile mocks base method.
 func (m *MockUserService) UpdateProfile(arg0 context.Context, arg1 string, arg2 *user.UpdateProfileRequest) (*user.User, error) {
 	m.ctrl.T.Helper()
@@ -556,3 +925,1161 @@ func (mr *MockUserServiceMockRecorder) UpdateProfile(arg0, arg1, arg2 any) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUserService)(nil).UpdateProfile), arg0, arg1, arg2)
 }
+
+// VerifyEmail mocks base method.
+func (m *MockUserService) VerifyEmail(arg0 context.Context, arg1 *user.VerifyEmailRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockUserServiceMockRecorder) VerifyEmail(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUserService)(nil).VerifyEmail), arg0, arg1)
+}
+
+// MockReconciliationService is a mock of ReconciliationService interface.
+type MockReconciliationService struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconciliationServiceMockRecorder
+}
+
+// MockReconciliationServiceMockRecorder is the mock recorder for MockReconciliationService.
+type MockReconciliationServiceMockRecorder struct {
+	mock *MockReconciliationService
+}
+
+// NewMockReconciliationService creates a new mock instance.
+func NewMockReconciliationService(ctrl *gomock.Controller) *MockReconciliationService {
+	mock := &MockReconciliationService{ctrl: ctrl}
+	mock.recorder = &MockReconciliationServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciliationService) EXPECT() *MockReconciliationServiceMockRecorder {
+	return m.recorder
+}
+
+// GetReport mocks base method.
+func (m *MockReconciliationService) GetReport(arg0 context.Context, arg1 string) (*reconciliation.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReport", arg0, arg1)
+	ret0, _ := ret[0].(*reconciliation.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReport indicates an expected call of GetReport.
+func (mr *MockReconciliationServiceMockRecorder) GetReport(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReport", reflect.TypeOf((*MockReconciliationService)(nil).GetReport), arg0, arg1)
+}
+
+// ListDiscrepancies mocks base method.
+func (m *MockReconciliationService) ListDiscrepancies(arg0 context.Context, arg1 string, arg2 reconciliation.DiscrepancyStatus, arg3, arg4 int) ([]*reconciliation.Discrepancy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDiscrepancies", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]*reconciliation.Discrepancy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDiscrepancies indicates an expected call of ListDiscrepancies.
+func (mr *MockReconciliationServiceMockRecorder) ListDiscrepancies(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDiscrepancies", reflect.TypeOf((*MockReconciliationService)(nil).ListDiscrepancies), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ListReports mocks base method.
+func (m *MockReconciliationService) ListReports(arg0 context.Context, arg1, arg2 int) ([]*reconciliation.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReports", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*reconciliation.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReports indicates an expected call of ListReports.
+func (mr *MockReconciliationServiceMockRecorder) ListReports(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReports", reflect.TypeOf((*MockReconciliationService)(nil).ListReports), arg0, arg1, arg2)
+}
+
+// ReconcileFile mocks base method.
+func (m *MockReconciliationService) ReconcileFile(arg0 context.Context, arg1 string) (*reconciliation.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileFile", arg0, arg1)
+	ret0, _ := ret[0].(*reconciliation.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReconcileFile indicates an expected call of ReconcileFile.
+func (mr *MockReconciliationServiceMockRecorder) ReconcileFile(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileFile", reflect.TypeOf((*MockReconciliationService)(nil).ReconcileFile), arg0, arg1)
+}
+
+// ResolveDiscrepancy mocks base method.
+func (m *MockReconciliationService) ResolveDiscrepancy(arg0 context.Context, arg1, arg2 string, arg3 *reconciliation.ResolveRequest) (*reconciliation.Discrepancy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveDiscrepancy", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*reconciliation.Discrepancy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveDiscrepancy indicates an expected call of ResolveDiscrepancy.
+func (mr *MockReconciliationServiceMockRecorder) ResolveDiscrepancy(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveDiscrepancy", reflect.TypeOf((*MockReconciliationService)(nil).ResolveDiscrepancy), arg0, arg1, arg2, arg3)
+}
+
+// RunReconciliation mocks base method.
+func (m *MockReconciliationService) RunReconciliation(arg0 context.Context) ([]*reconciliation.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunReconciliation", arg0)
+	ret0, _ := ret[0].([]*reconciliation.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunReconciliation indicates an expected call of RunReconciliation.
+func (mr *MockReconciliationServiceMockRecorder) RunReconciliation(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunReconciliation", reflect.TypeOf((*MockReconciliationService)(nil).RunReconciliation), arg0)
+}
+
+// MockPaymentSweeper is a mock of PaymentSweeper interface.
+type MockPaymentSweeper struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentSweeperMockRecorder
+}
+
+// MockPaymentSweeperMockRecorder is the mock recorder for MockPaymentSweeper.
+type MockPaymentSweeperMockRecorder struct {
+	mock *MockPaymentSweeper
+}
+
+// NewMockPaymentSweeper creates a new mock instance.
+func NewMockPaymentSweeper(ctrl *gomock.Controller) *MockPaymentSweeper {
+	mock := &MockPaymentSweeper{ctrl: ctrl}
+	mock.recorder = &MockPaymentSweeperMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentSweeper) EXPECT() *MockPaymentSweeperMockRecorder {
+	return m.recorder
+}
+
+// Sweep mocks base method.
+func (m *MockPaymentSweeper) Sweep(arg0 context.Context) (*payment.SweepResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sweep", arg0)
+	ret0, _ := ret[0].(*payment.SweepResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sweep indicates an expected call of Sweep.
+func (mr *MockPaymentSweeperMockRecorder) Sweep(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sweep", reflect.TypeOf((*MockPaymentSweeper)(nil).Sweep), arg0)
+}
+
+// MockSubscriptionService is a mock of SubscriptionService interface.
+type MockSubscriptionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockSubscriptionServiceMockRecorder
+}
+
+// MockSubscriptionServiceMockRecorder is the mock recorder for MockSubscriptionService.
+type MockSubscriptionServiceMockRecorder struct {
+	mock *MockSubscriptionService
+}
+
+// NewMockSubscriptionService creates a new mock instance.
+func NewMockSubscriptionService(ctrl *gomock.Controller) *MockSubscriptionService {
+	mock := &MockSubscriptionService{ctrl: ctrl}
+	mock.recorder = &MockSubscriptionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSubscriptionService) EXPECT() *MockSubscriptionServiceMockRecorder {
+	return m.recorder
+}
+
+// CancelSubscription mocks base method.
+func (m *MockSubscriptionService) CancelSubscription(arg0 context.Context, arg1 string, arg2 *subscription.CancelRequest) (*subscription.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelSubscription", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*subscription.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelSubscription indicates an expected call of CancelSubscription.
+func (mr *MockSubscriptionServiceMockRecorder) CancelSubscription(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelSubscription", reflect.TypeOf((*MockSubscriptionService)(nil).CancelSubscription), arg0, arg1, arg2)
+}
+
+// ChangePlan mocks base method.
+func (m *MockSubscriptionService) ChangePlan(arg0 context.Context, arg1 string, arg2 *subscription.ChangePlanRequest) (*subscription.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePlan", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*subscription.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePlan indicates an expected call of ChangePlan.
+func (mr *MockSubscriptionServiceMockRecorder) ChangePlan(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePlan", reflect.TypeOf((*MockSubscriptionService)(nil).ChangePlan), arg0, arg1, arg2)
+}
+
+// CreatePlan mocks base method.
+func (m *MockSubscriptionService) CreatePlan(arg0 context.Context, arg1 *subscription.Plan) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePlan", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePlan indicates an expected call of CreatePlan.
+func (mr *MockSubscriptionServiceMockRecorder) CreatePlan(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePlan", reflect.TypeOf((*MockSubscriptionService)(nil).CreatePlan), arg0, arg1)
+}
+
+// CreateSubscription mocks base method.
+func (m *MockSubscriptionService) CreateSubscription(arg0 context.Context, arg1 *subscription.Subscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSubscription", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSubscription indicates an expected call of CreateSubscription.
+func (mr *MockSubscriptionServiceMockRecorder) CreateSubscription(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSubscription", reflect.TypeOf((*MockSubscriptionService)(nil).CreateSubscription), arg0, arg1)
+}
+
+// GetPlan mocks base method.
+func (m *MockSubscriptionService) GetPlan(arg0 context.Context, arg1 string) (*subscription.Plan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPlan", arg0, arg1)
+	ret0, _ := ret[0].(*subscription.Plan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPlan indicates an expected call of GetPlan.
+func (mr *MockSubscriptionServiceMockRecorder) GetPlan(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPlan", reflect.TypeOf((*MockSubscriptionService)(nil).GetPlan), arg0, arg1)
+}
+
+// GetSubscription mocks base method.
+func (m *MockSubscriptionService) GetSubscription(arg0 context.Context, arg1 string) (*subscription.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscription", arg0, arg1)
+	ret0, _ := ret[0].(*subscription.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscription indicates an expected call of GetSubscription.
+func (mr *MockSubscriptionServiceMockRecorder) GetSubscription(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscription", reflect.TypeOf((*MockSubscriptionService)(nil).GetSubscription), arg0, arg1)
+}
+
+// ListEvents mocks base method.
+func (m *MockSubscriptionService) ListEvents(arg0 context.Context, arg1 string, arg2, arg3 int) ([]*subscription.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEvents", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*subscription.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEvents indicates an expected call of ListEvents.
+func (mr *MockSubscriptionServiceMockRecorder) ListEvents(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEvents", reflect.TypeOf((*MockSubscriptionService)(nil).ListEvents), arg0, arg1, arg2, arg3)
+}
+
+// ListPlans mocks base method.
+func (m *MockSubscriptionService) ListPlans(arg0 context.Context, arg1 string, arg2 mode.Mode, arg3, arg4 int) ([]*subscription.Plan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPlans", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]*subscription.Plan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPlans indicates an expected call of ListPlans.
+func (mr *MockSubscriptionServiceMockRecorder) ListPlans(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPlans", reflect.TypeOf((*MockSubscriptionService)(nil).ListPlans), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ListSubscriptions mocks base method.
+func (m *MockSubscriptionService) ListSubscriptions(arg0 context.Context, arg1 string, arg2 mode.Mode, arg3, arg4 int) ([]*subscription.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscriptions", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]*subscription.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscriptions indicates an expected call of ListSubscriptions.
+func (mr *MockSubscriptionServiceMockRecorder) ListSubscriptions(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscriptions", reflect.TypeOf((*MockSubscriptionService)(nil).ListSubscriptions), arg0, arg1, arg2, arg3, arg4)
+}
+
+// RunBilling mocks base method.
+func (m *MockSubscriptionService) RunBilling(arg0 context.Context) (*subscription.BillingResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunBilling", arg0)
+	ret0, _ := ret[0].(*subscription.BillingResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunBilling indicates an expected call of RunBilling.
+func (mr *MockSubscriptionServiceMockRecorder) RunBilling(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunBilling", reflect.TypeOf((*MockSubscriptionService)(nil).RunBilling), arg0)
+}
+
+// MockCustomerService is a mock of CustomerService interface.
+type MockCustomerService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCustomerServiceMockRecorder
+}
+
+// MockCustomerServiceMockRecorder is the mock recorder for MockCustomerService.
+type MockCustomerServiceMockRecorder struct {
+	mock *MockCustomerService
+}
+
+// NewMockCustomerService creates a new mock instance.
+func NewMockCustomerService(ctrl *gomock.Controller) *MockCustomerService {
+	mock := &MockCustomerService{ctrl: ctrl}
+	mock.recorder = &MockCustomerServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCustomerService) EXPECT() *MockCustomerServiceMockRecorder {
+	return m.recorder
+}
+
+// AddPaymentMethod mocks base method.
+func (m *MockCustomerService) AddPaymentMethod(arg0 context.Context, arg1 string, arg2 *customer.PaymentMethod) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPaymentMethod", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPaymentMethod indicates an expected call of AddPaymentMethod.
+func (mr *MockCustomerServiceMockRecorder) AddPaymentMethod(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPaymentMethod", reflect.TypeOf((*MockCustomerService)(nil).AddPaymentMethod), arg0, arg1, arg2)
+}
+
+// CreateCustomer mocks base method.
+func (m *MockCustomerService) CreateCustomer(arg0 context.Context, arg1 *customer.Customer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCustomer", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCustomer indicates an expected call of CreateCustomer.
+func (mr *MockCustomerServiceMockRecorder) CreateCustomer(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCustomer", reflect.TypeOf((*MockCustomerService)(nil).CreateCustomer), arg0, arg1)
+}
+
+// DeleteCustomer mocks base method.
+func (m *MockCustomerService) DeleteCustomer(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCustomer", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCustomer indicates an expected call of DeleteCustomer.
+func (mr *MockCustomerServiceMockRecorder) DeleteCustomer(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCustomer", reflect.TypeOf((*MockCustomerService)(nil).DeleteCustomer), arg0, arg1)
+}
+
+// GetCustomer mocks base method.
+func (m *MockCustomerService) GetCustomer(arg0 context.Context, arg1 string) (*customer.Customer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCustomer", arg0, arg1)
+	ret0, _ := ret[0].(*customer.Customer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCustomer indicates an expected call of GetCustomer.
+func (mr *MockCustomerServiceMockRecorder) GetCustomer(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCustomer", reflect.TypeOf((*MockCustomerService)(nil).GetCustomer), arg0, arg1)
+}
+
+// ListCustomers mocks base method.
+func (m *MockCustomerService) ListCustomers(arg0 context.Context, arg1 string, arg2 mode.Mode, arg3 string, arg4, arg5 int) ([]*customer.Customer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCustomers", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].([]*customer.Customer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCustomers indicates an expected call of ListCustomers.
+func (mr *MockCustomerServiceMockRecorder) ListCustomers(arg0, arg1, arg2, arg3, arg4, arg5 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCustomers", reflect.TypeOf((*MockCustomerService)(nil).ListCustomers), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// ListPaymentMethods mocks base method.
+func (m *MockCustomerService) ListPaymentMethods(arg0 context.Context, arg1 string) ([]*customer.PaymentMethod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPaymentMethods", arg0, arg1)
+	ret0, _ := ret[0].([]*customer.PaymentMethod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPaymentMethods indicates an expected call of ListPaymentMethods.
+func (mr *MockCustomerServiceMockRecorder) ListPaymentMethods(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPaymentMethods", reflect.TypeOf((*MockCustomerService)(nil).ListPaymentMethods), arg0, arg1)
+}
+
+// ListPayments mocks base method.
+func (m *MockCustomerService) ListPayments(arg0 context.Context, arg1 string, arg2, arg3 int) ([]*payment.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPayments", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*payment.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPayments indicates an expected call of ListPayments.
+func (mr *MockCustomerServiceMockRecorder) ListPayments(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPayments", reflect.TypeOf((*MockCustomerService)(nil).ListPayments), arg0, arg1, arg2, arg3)
+}
+
+// RemovePaymentMethod mocks base method.
+func (m *MockCustomerService) RemovePaymentMethod(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemovePaymentMethod", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemovePaymentMethod indicates an expected call of RemovePaymentMethod.
+func (mr *MockCustomerServiceMockRecorder) RemovePaymentMethod(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePaymentMethod", reflect.TypeOf((*MockCustomerService)(nil).RemovePaymentMethod), arg0, arg1, arg2)
+}
+
+// SetDefaultPaymentMethod mocks base method.
+func (m *MockCustomerService) SetDefaultPaymentMethod(arg0 context.Context, arg1, arg2 string) (*customer.PaymentMethod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDefaultPaymentMethod", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*customer.PaymentMethod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetDefaultPaymentMethod indicates an expected call of SetDefaultPaymentMethod.
+func (mr *MockCustomerServiceMockRecorder) SetDefaultPaymentMethod(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDefaultPaymentMethod", reflect.TypeOf((*MockCustomerService)(nil).SetDefaultPaymentMethod), arg0, arg1, arg2)
+}
+
+// UpdateCustomer mocks base method.
+func (m *MockCustomerService) UpdateCustomer(arg0 context.Context, arg1 *customer.Customer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCustomer", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCustomer indicates an expected call of UpdateCustomer.
+func (mr *MockCustomerServiceMockRecorder) UpdateCustomer(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCustomer", reflect.TypeOf((*MockCustomerService)(nil).UpdateCustomer), arg0, arg1)
+}
+
+// MockMemberService is a mock of MemberService interface.
+type MockMemberService struct {
+	ctrl     *gomock.Controller
+	recorder *MockMemberServiceMockRecorder
+}
+
+// MockMemberServiceMockRecorder is the mock recorder for MockMemberService.
+type MockMemberServiceMockRecorder struct {
+	mock *MockMemberService
+}
+
+// NewMockMemberService creates a new mock instance.
+func NewMockMemberService(ctrl *gomock.Controller) *MockMemberService {
+	mock := &MockMemberService{ctrl: ctrl}
+	mock.recorder = &MockMemberServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMemberService) EXPECT() *MockMemberServiceMockRecorder {
+	return m.recorder
+}
+
+// Authorize mocks base method.
+func (m *MockMemberService) Authorize(arg0 context.Context, arg1, arg2 string, arg3 member.Permission) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockMemberServiceMockRecorder) Authorize(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockMemberService)(nil).Authorize), arg0, arg1, arg2, arg3)
+}
+
+// InviteMember mocks base method.
+func (m *MockMemberService) InviteMember(arg0 context.Context, arg1, arg2 string, arg3 *member.InviteRequest) (*member.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InviteMember", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*member.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InviteMember indicates an expected call of InviteMember.
+func (mr *MockMemberServiceMockRecorder) InviteMember(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InviteMember", reflect.TypeOf((*MockMemberService)(nil).InviteMember), arg0, arg1, arg2, arg3)
+}
+
+// ListMembers mocks base method.
+func (m *MockMemberService) ListMembers(arg0 context.Context, arg1 string) ([]*member.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMembers", arg0, arg1)
+	ret0, _ := ret[0].([]*member.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMembers indicates an expected call of ListMembers.
+func (mr *MockMemberServiceMockRecorder) ListMembers(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMembers", reflect.TypeOf((*MockMemberService)(nil).ListMembers), arg0, arg1)
+}
+
+// RemoveMember mocks base method.
+func (m *MockMemberService) RemoveMember(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMember", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveMember indicates an expected call of RemoveMember.
+func (mr *MockMemberServiceMockRecorder) RemoveMember(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMember", reflect.TypeOf((*MockMemberService)(nil).RemoveMember), arg0, arg1, arg2, arg3)
+}
+
+// MockAPIKeyService is a mock of APIKeyService interface.
+type MockAPIKeyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyServiceMockRecorder
+}
+
+// MockAPIKeyServiceMockRecorder is the mock recorder for MockAPIKeyService.
+type MockAPIKeyServiceMockRecorder struct {
+	mock *MockAPIKeyService
+}
+
+// NewMockAPIKeyService creates a new mock instance.
+func NewMockAPIKeyService(ctrl *gomock.Controller) *MockAPIKeyService {
+	mock := &MockAPIKeyService{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyService) EXPECT() *MockAPIKeyServiceMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockAPIKeyService) Authenticate(arg0 context.Context, arg1 string) (*apikey.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", arg0, arg1)
+	ret0, _ := ret[0].(*apikey.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockAPIKeyServiceMockRecorder) Authenticate(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAPIKeyService)(nil).Authenticate), arg0, arg1)
+}
+
+// AuthenticateSigned mocks base method.
+func (m *MockAPIKeyService) AuthenticateSigned(arg0 context.Context, arg1 *apikey.SignedRequest) (*apikey.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateSigned", arg0, arg1)
+	ret0, _ := ret[0].(*apikey.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateSigned indicates an expected call of AuthenticateSigned.
+func (mr *MockAPIKeyServiceMockRecorder) AuthenticateSigned(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateSigned", reflect.TypeOf((*MockAPIKeyService)(nil).AuthenticateSigned), arg0, arg1)
+}
+
+// CreateKey mocks base method.
+func (m *MockAPIKeyService) CreateKey(arg0 context.Context, arg1, arg2 string, arg3 *apikey.CreateRequest) (*apikey.CreatedKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateKey", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*apikey.CreatedKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateKey indicates an expected call of CreateKey.
+func (mr *MockAPIKeyServiceMockRecorder) CreateKey(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateKey", reflect.TypeOf((*MockAPIKeyService)(nil).CreateKey), arg0, arg1, arg2, arg3)
+}
+
+// ListKeys mocks base method.
+func (m *MockAPIKeyService) ListKeys(arg0 context.Context, arg1 string) ([]*apikey.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListKeys", arg0, arg1)
+	ret0, _ := ret[0].([]*apikey.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListKeys indicates an expected call of ListKeys.
+func (mr *MockAPIKeyServiceMockRecorder) ListKeys(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKeys", reflect.TypeOf((*MockAPIKeyService)(nil).ListKeys), arg0, arg1)
+}
+
+// PruneNonces mocks base method.
+func (m *MockAPIKeyService) PruneNonces(arg0 context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PruneNonces", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PruneNonces indicates an expected call of PruneNonces.
+func (mr *MockAPIKeyServiceMockRecorder) PruneNonces(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneNonces", reflect.TypeOf((*MockAPIKeyService)(nil).PruneNonces), arg0)
+}
+
+// RevokeKey mocks base method.
+func (m *MockAPIKeyService) RevokeKey(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeKey", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeKey indicates an expected call of RevokeKey.
+func (mr *MockAPIKeyServiceMockRecorder) RevokeKey(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeKey", reflect.TypeOf((*MockAPIKeyService)(nil).RevokeKey), arg0, arg1, arg2)
+}
+
+// RotateKey mocks base method.
+func (m *MockAPIKeyService) RotateKey(arg0 context.Context, arg1, arg2, arg3 string, arg4 *apikey.RotateRequest) (*apikey.CreatedKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateKey", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*apikey.CreatedKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateKey indicates an expected call of RotateKey.
+func (mr *MockAPIKeyServiceMockRecorder) RotateKey(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateKey", reflect.TypeOf((*MockAPIKeyService)(nil).RotateKey), arg0, arg1, arg2, arg3, arg4)
+}
+
+// MockOnboardingService is a mock of OnboardingService interface.
+type MockOnboardingService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOnboardingServiceMockRecorder
+}
+
+// MockOnboardingServiceMockRecorder is the mock recorder for MockOnboardingService.
+type MockOnboardingServiceMockRecorder struct {
+	mock *MockOnboardingService
+}
+
+// NewMockOnboardingService creates a new mock instance.
+func NewMockOnboardingService(ctrl *gomock.Controller) *MockOnboardingService {
+	mock := &MockOnboardingService{ctrl: ctrl}
+	mock.recorder = &MockOnboardingServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOnboardingService) EXPECT() *MockOnboardingServiceMockRecorder {
+	return m.recorder
+}
+
+// AddOwner mocks base method.
+func (m *MockOnboardingService) AddOwner(arg0 context.Context, arg1 string, arg2 *merchant.BeneficialOwner) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOwner", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddOwner indicates an expected call of AddOwner.
+func (mr *MockOnboardingServiceMockRecorder) AddOwner(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOwner", reflect.TypeOf((*MockOnboardingService)(nil).AddOwner), arg0, arg1, arg2)
+}
+
+// Decide mocks base method.
+func (m *MockOnboardingService) Decide(arg0 context.Context, arg1, arg2 string, arg3 *merchant.ReviewDecision) (*merchant.Merchant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decide", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*merchant.Merchant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Decide indicates an expected call of Decide.
+func (mr *MockOnboardingServiceMockRecorder) Decide(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decide", reflect.TypeOf((*MockOnboardingService)(nil).Decide), arg0, arg1, arg2, arg3)
+}
+
+// ListByStatus mocks base method.
+func (m *MockOnboardingService) ListByStatus(arg0 context.Context, arg1 merchant.Status, arg2, arg3 int) ([]*merchant.Merchant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByStatus", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*merchant.Merchant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByStatus indicates an expected call of ListByStatus.
+func (mr *MockOnboardingServiceMockRecorder) ListByStatus(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByStatus", reflect.TypeOf((*MockOnboardingService)(nil).ListByStatus), arg0, arg1, arg2, arg3)
+}
+
+// ListDocuments mocks base method.
+func (m *MockOnboardingService) ListDocuments(arg0 context.Context, arg1 string) ([]*merchant.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDocuments", arg0, arg1)
+	ret0, _ := ret[0].([]*merchant.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDocuments indicates an expected call of ListDocuments.
+func (mr *MockOnboardingServiceMockRecorder) ListDocuments(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDocuments", reflect.TypeOf((*MockOnboardingService)(nil).ListDocuments), arg0, arg1)
+}
+
+// ListOwners mocks base method.
+func (m *MockOnboardingService) ListOwners(arg0 context.Context, arg1 string) ([]*merchant.BeneficialOwner, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOwners", arg0, arg1)
+	ret0, _ := ret[0].([]*merchant.BeneficialOwner)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOwners indicates an expected call of ListOwners.
+func (mr *MockOnboardingServiceMockRecorder) ListOwners(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOwners", reflect.TypeOf((*MockOnboardingService)(nil).ListOwners), arg0, arg1)
+}
+
+// OpenDocument mocks base method.
+func (m *MockOnboardingService) OpenDocument(arg0 context.Context, arg1, arg2 string) (*merchant.Document, io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenDocument", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*merchant.Document)
+	ret1, _ := ret[1].(io.ReadCloser)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// OpenDocument indicates an expected call of OpenDocument.
+func (mr *MockOnboardingServiceMockRecorder) OpenDocument(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenDocument", reflect.TypeOf((*MockOnboardingService)(nil).OpenDocument), arg0, arg1, arg2)
+}
+
+// RemoveOwner mocks base method.
+func (m *MockOnboardingService) RemoveOwner(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveOwner", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveOwner indicates an expected call of RemoveOwner.
+func (mr *MockOnboardingServiceMockRecorder) RemoveOwner(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveOwner", reflect.TypeOf((*MockOnboardingService)(nil).RemoveOwner), arg0, arg1, arg2)
+}
+
+// StartReview mocks base method.
+func (m *MockOnboardingService) StartReview(arg0 context.Context, arg1, arg2 string) (*merchant.Merchant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartReview", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*merchant.Merchant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartReview indicates an expected call of StartReview.
+func (mr *MockOnboardingServiceMockRecorder) StartReview(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartReview", reflect.TypeOf((*MockOnboardingService)(nil).StartReview), arg0, arg1, arg2)
+}
+
+// Submit mocks base method.
+func (m *MockOnboardingService) Submit(arg0 context.Context, arg1 string) (*merchant.Merchant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Submit", arg0, arg1)
+	ret0, _ := ret[0].(*merchant.Merchant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Submit indicates an expected call of Submit.
+func (mr *MockOnboardingServiceMockRecorder) Submit(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Submit", reflect.TypeOf((*MockOnboardingService)(nil).Submit), arg0, arg1)
+}
+
+// UpdateBusinessDetails mocks base method.
+func (m *MockOnboardingService) UpdateBusinessDetails(arg0 context.Context, arg1 string, arg2 *merchant.BusinessDetails) (*merchant.Merchant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBusinessDetails", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*merchant.Merchant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateBusinessDetails indicates an expected call of UpdateBusinessDetails.
+func (mr *MockOnboardingServiceMockRecorder) UpdateBusinessDetails(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBusinessDetails", reflect.TypeOf((*MockOnboardingService)(nil).UpdateBusinessDetails), arg0, arg1, arg2)
+}
+
+// UploadDocument mocks base method.
+func (m *MockOnboardingService) UploadDocument(arg0 context.Context, arg1 *merchant.Document, arg2 io.Reader) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadDocument", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UploadDocument indicates an expected call of UploadDocument.
+func (mr *MockOnboardingServiceMockRecorder) UploadDocument(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadDocument", reflect.TypeOf((*MockOnboardingService)(nil).UploadDocument), arg0, arg1, arg2)
+}
+
+// MockMerchantExportService is a mock of MerchantExportService interface.
+type MockMerchantExportService struct {
+	ctrl     *gomock.Controller
+	recorder *MockMerchantExportServiceMockRecorder
+}
+
+// MockMerchantExportServiceMockRecorder is the mock recorder for MockMerchantExportService.
+type MockMerchantExportServiceMockRecorder struct {
+	mock *MockMerchantExportService
+}
+
+// NewMockMerchantExportService creates a new mock instance.
+func NewMockMerchantExportService(ctrl *gomock.Controller) *MockMerchantExportService {
+	mock := &MockMerchantExportService{ctrl: ctrl}
+	mock.recorder = &MockMerchantExportServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMerchantExportService) EXPECT() *MockMerchantExportServiceMockRecorder {
+	return m.recorder
+}
+
+// ExportMerchant mocks base method.
+func (m *MockMerchantExportService) ExportMerchant(arg0 context.Context, arg1 string) (*merchant.Export, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportMerchant", arg0, arg1)
+	ret0, _ := ret[0].(*merchant.Export)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportMerchant indicates an expected call of ExportMerchant.
+func (mr *MockMerchantExportServiceMockRecorder) ExportMerchant(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportMerchant", reflect.TypeOf((*MockMerchantExportService)(nil).ExportMerchant), arg0, arg1)
+}
+
+// MockAuditService is a mock of AuditService interface.
+type MockAuditService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditServiceMockRecorder
+}
+
+// MockAuditServiceMockRecorder is the mock recorder for MockAuditService.
+type MockAuditServiceMockRecorder struct {
+	mock *MockAuditService
+}
+
+// NewMockAuditService creates a new mock instance.
+func NewMockAuditService(ctrl *gomock.Controller) *MockAuditService {
+	mock := &MockAuditService{ctrl: ctrl}
+	mock.recorder = &MockAuditServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditService) EXPECT() *MockAuditServiceMockRecorder {
+	return m.recorder
+}
+
+// ListEntries mocks base method.
+func (m *MockAuditService) ListEntries(arg0 context.Context, arg1 audit.Filter) ([]*audit.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEntries", arg0, arg1)
+	ret0, _ := ret[0].([]*audit.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEntries indicates an expected call of ListEntries.
+func (mr *MockAuditServiceMockRecorder) ListEntries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockAuditService)(nil).ListEntries), arg0, arg1)
+}
+
+// Record mocks base method.
+func (m *MockAuditService) Record(arg0 context.Context, arg1 *audit.Entry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditServiceMockRecorder) Record(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAuditService)(nil).Record), arg0, arg1)
+}
+
+// VerifyChain mocks base method.
+func (m *MockAuditService) VerifyChain(arg0 context.Context) (*audit.Verification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyChain", arg0)
+	ret0, _ := ret[0].(*audit.Verification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyChain indicates an expected call of VerifyChain.
+func (mr *MockAuditServiceMockRecorder) VerifyChain(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyChain", reflect.TypeOf((*MockAuditService)(nil).VerifyChain), arg0)
+}
+
+// MockOAuthService is a mock of OAuthService interface.
+type MockOAuthService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthServiceMockRecorder
+}
+
+// MockOAuthServiceMockRecorder is the mock recorder for MockOAuthService.
+type MockOAuthServiceMockRecorder struct {
+	mock *MockOAuthService
+}
+
+// NewMockOAuthService creates a new mock instance.
+func NewMockOAuthService(ctrl *gomock.Controller) *MockOAuthService {
+	mock := &MockOAuthService{ctrl: ctrl}
+	mock.recorder = &MockOAuthServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuthService) EXPECT() *MockOAuthServiceMockRecorder {
+	return m.recorder
+}
+
+// AuthenticateClient mocks base method.
+func (m *MockOAuthService) AuthenticateClient(arg0 context.Context, arg1, arg2 string) (*oauth.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateClient", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*oauth.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateClient indicates an expected call of AuthenticateClient.
+func (mr *MockOAuthServiceMockRecorder) AuthenticateClient(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateClient", reflect.TypeOf((*MockOAuthService)(nil).AuthenticateClient), arg0, arg1, arg2)
+}
+
+// Authorize mocks base method.
+func (m *MockOAuthService) Authorize(arg0 context.Context, arg1, arg2 string, arg3 member.Permission) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockOAuthServiceMockRecorder) Authorize(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockOAuthService)(nil).Authorize), arg0, arg1, arg2, arg3)
+}
+
+// GrantClient mocks base method.
+func (m *MockOAuthService) GrantClient(arg0 context.Context, arg1, arg2 string, arg3 *oauth.GrantRequest) (*oauth.Grant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantClient", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*oauth.Grant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GrantClient indicates an expected call of GrantClient.
+func (mr *MockOAuthServiceMockRecorder) GrantClient(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantClient", reflect.TypeOf((*MockOAuthService)(nil).GrantClient), arg0, arg1, arg2, arg3)
+}
+
+// ListClientGrants mocks base method.
+func (m *MockOAuthService) ListClientGrants(arg0 context.Context, arg1, arg2 string) ([]*oauth.Grant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListClientGrants", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*oauth.Grant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListClientGrants indicates an expected call of ListClientGrants.
+func (mr *MockOAuthServiceMockRecorder) ListClientGrants(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClientGrants", reflect.TypeOf((*MockOAuthService)(nil).ListClientGrants), arg0, arg1, arg2)
+}
+
+// ListClients mocks base method.
+func (m *MockOAuthService) ListClients(arg0 context.Context, arg1 string) ([]*oauth.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListClients", arg0, arg1)
+	ret0, _ := ret[0].([]*oauth.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListClients indicates an expected call of ListClients.
+func (mr *MockOAuthServiceMockRecorder) ListClients(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClients", reflect.TypeOf((*MockOAuthService)(nil).ListClients), arg0, arg1)
+}
+
+// ListGrants mocks base method.
+func (m *MockOAuthService) ListGrants(arg0 context.Context, arg1 string) ([]*oauth.Grant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGrants", arg0, arg1)
+	ret0, _ := ret[0].([]*oauth.Grant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGrants indicates an expected call of ListGrants.
+func (mr *MockOAuthServiceMockRecorder) ListGrants(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGrants", reflect.TypeOf((*MockOAuthService)(nil).ListGrants), arg0, arg1)
+}
+
+// RegisterClient mocks base method.
+func (m *MockOAuthService) RegisterClient(arg0 context.Context, arg1 string, arg2 *oauth.RegisterClientRequest) (*oauth.RegisteredClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterClient", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*oauth.RegisteredClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterClient indicates an expected call of RegisterClient.
+func (mr *MockOAuthServiceMockRecorder) RegisterClient(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterClient", reflect.TypeOf((*MockOAuthService)(nil).RegisterClient), arg0, arg1, arg2)
+}
+
+// RevokeClient mocks base method.
+func (m *MockOAuthService) RevokeClient(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeClient", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeClient indicates an expected call of RevokeClient.
+func (mr *MockOAuthServiceMockRecorder) RevokeClient(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeClient", reflect.TypeOf((*MockOAuthService)(nil).RevokeClient), arg0, arg1, arg2)
+}
+
+// RevokeGrant mocks base method.
+func (m *MockOAuthService) RevokeGrant(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeGrant", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeGrant indicates an expected call of RevokeGrant.
+func (mr *MockOAuthServiceMockRecorder) RevokeGrant(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeGrant", reflect.TypeOf((*MockOAuthService)(nil).RevokeGrant), arg0, arg1, arg2)
+}
@@ -2,11 +2,20 @@ package ports
 
 import (
 	"context"
+	"io"
 	"time"
 
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/audit"
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
 	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
 	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
 	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	"github.com/popeskul/payment-gateway/internal/core/domain/subscription"
 	"github.com/popeskul/payment-gateway/internal/core/domain/user"
 )
 
@@ -15,19 +24,42 @@ type Services interface {
 	Payments() PaymentService
 	Refunds() RefundService
 	Users() UserService
+	Reconciliation() ReconciliationService
+	Subscriptions() SubscriptionService
+	Customers() CustomerService
+	Members() MemberService
+	APIKeys() APIKeyService
+	Onboarding() OnboardingService
+	Exports() MerchantExportService
+	Audit() AuditService
+	OAuth() OAuthService
 }
 
 type MerchantService interface {
-	CreateMerchant(ctx context.Context, m *merchant.Merchant) error
+	// CreateMerchant creates the merchant, makes ownerID its owner and issues
+	// its first test and live keys, whose secrets are returned only here.
+	CreateMerchant(ctx context.Context, m *merchant.Merchant, ownerID string) ([]*apikey.CreatedKey, error)
 	GetMerchant(ctx context.Context, id string) (*merchant.Merchant, error)
 	UpdateMerchant(ctx context.Context, m *merchant.Merchant) error
-	DeleteMerchant(ctx context.Context, id string) error
-	ListMerchants(ctx context.Context, limit, offset int) ([]*merchant.Merchant, error)
+	// DeleteMerchant offboards the merchant: it is soft deleted, stops taking
+	// payments and its API keys are revoked. Existing payments and refunds are
+	// kept and can still complete.
+	DeleteMerchant(ctx context.Context, id string, req *merchant.DeleteRequest) error
+	ListMerchants(ctx context.Context, userID string, limit, offset int) ([]*merchant.Merchant, error)
+	UpdateSettings(ctx context.Context, merchantID string, settings *merchant.Settings) (*merchant.Merchant, error)
+}
+
+type MerchantExportService interface {
+	ExportMerchant(ctx context.Context, merchantID string) (*merchant.Export, error)
 }
 
 type AcquiringBank interface {
+	// ProcessPayment authorizes and captures the payment in one step.
 	ProcessPayment(ctx context.Context, p *payment.Payment) error
+	AuthorizePayment(ctx context.Context, p *payment.Payment) error
+	CapturePayment(ctx context.Context, p *payment.Payment) error
 	ProcessRefund(ctx context.Context, r *refund.Refund) error
+	VoidPayment(ctx context.Context, p *payment.Payment) error
 	SetProcessingDelay(delay time.Duration)
 	SetFailureRate(rate float64)
 }
@@ -36,8 +68,18 @@ type PaymentService interface {
 	CreatePayment(ctx context.Context, p *payment.Payment) error
 	GetPayment(ctx context.Context, id string) (*payment.Payment, error)
 	UpdatePayment(ctx context.Context, p *payment.Payment) error
-	ListPayments(ctx context.Context, merchantID string, limit, offset int) ([]*payment.Payment, error)
+	ListPayments(ctx context.Context, merchantID string, m mode.Mode, limit, offset int) ([]*payment.Payment, error)
+	// ProcessPayment captures the payment, or only authorizes it when the
+	// merchant has auto-capture turned off.
 	ProcessPayment(ctx context.Context, paymentID string) error
+	CapturePayment(ctx context.Context, paymentID string) error
+	ListTransitions(ctx context.Context, paymentID string) ([]*payment.StatusTransition, error)
+}
+
+// PaymentSweeper moves payments that were abandoned in an intermediate
+// status to a terminal one.
+type PaymentSweeper interface {
+	Sweep(ctx context.Context) (*payment.SweepResult, error)
 }
 
 type RefundService interface {
@@ -46,12 +88,153 @@ type RefundService interface {
 	UpdateRefund(ctx context.Context, r *refund.Refund) error
 	ListRefunds(ctx context.Context, paymentID string, limit, offset int) ([]*refund.Refund, error)
 	ProcessRefund(ctx context.Context, refundID string) error
+	CancelRefund(ctx context.Context, refundID string) (*refund.Refund, error)
 }
 
 type UserService interface {
+	// Register creates the user and mails them an email verification link.
 	Register(ctx context.Context, req *user.RegisterRequest) (*user.User, error)
-	Login(ctx context.Context, req *user.LoginRequest) (*user.User, error)
+	// Login checks the password of a login from ip. Failed attempts are
+	// throttled per account and per IP; refused attempts return a
+	// *user.LoginThrottledError.
+	Login(ctx context.Context, req *user.LoginRequest, ip string) (*user.User, error)
 	GetUserByID(ctx context.Context, id string) (*user.User, error)
 	UpdateProfile(ctx context.Context, id string, req *user.UpdateProfileRequest) (*user.User, error)
 	ChangePassword(ctx context.Context, id string, req *user.ChangePasswordRequest) error
+	// SendVerificationEmail mails a new verification link and invalidates the
+	// previous ones.
+	SendVerificationEmail(ctx context.Context, id string) error
+	VerifyEmail(ctx context.Context, req *user.VerifyEmailRequest) error
+	// RequestPasswordReset mails a reset link if the address belongs to a user.
+	// It does not report whether it does.
+	RequestPasswordReset(ctx context.Context, req *user.ForgotPasswordRequest) error
+	// ResetPassword returns the ID of the user whose password it reset.
+	ResetPassword(ctx context.Context, req *user.ResetPasswordRequest) (string, error)
+	// BeginTwoFactorLogin is called after a successful password check for a
+	// user with two-factor authentication. It returns a short-lived MFA token
+	// for CompleteTwoFactorLogin.
+	BeginTwoFactorLogin(ctx context.Context, id string) (string, error)
+	// CompleteTwoFactorLogin checks the MFA token and the TOTP or recovery code
+	// and returns the user to issue tokens for. The MFA token works once. Wrong
+	// codes count toward the same throttling as wrong passwords in Login, and
+	// may return a *user.LoginThrottledError.
+	CompleteTwoFactorLogin(ctx context.Context, req *user.TwoFactorLoginRequest, ip string) (*user.User, error)
+	// EnrollTwoFactor starts enrollment with a new secret. Two-factor
+	// authentication is only turned on by ConfirmTwoFactor.
+	EnrollTwoFactor(ctx context.Context, id string) (*user.TwoFactorEnrollment, error)
+	// ConfirmTwoFactor turns two-factor authentication on with a first code
+	// from the authenticator app and returns the recovery codes.
+	ConfirmTwoFactor(ctx context.Context, id string, req *user.TwoFactorCodeRequest) ([]string, error)
+	DisableTwoFactor(ctx context.Context, id string, req *user.DisableTwoFactorRequest) error
+	// RegenerateRecoveryCodes replaces the user's recovery codes.
+	RegenerateRecoveryCodes(ctx context.Context, id string, req *user.TwoFactorCodeRequest) ([]string, error)
+}
+
+type ReconciliationService interface {
+	RunReconciliation(ctx context.Context) ([]*reconciliation.Report, error)
+	ReconcileFile(ctx context.Context, fileName string) (*reconciliation.Report, error)
+	GetReport(ctx context.Context, id string) (*reconciliation.Report, error)
+	ListReports(ctx context.Context, limit, offset int) ([]*reconciliation.Report, error)
+	ListDiscrepancies(ctx context.Context, reportID string, status reconciliation.DiscrepancyStatus, limit, offset int) ([]*reconciliation.Discrepancy, error)
+	ResolveDiscrepancy(ctx context.Context, id, userID string, req *reconciliation.ResolveRequest) (*reconciliation.Discrepancy, error)
+}
+
+type SubscriptionService interface {
+	CreatePlan(ctx context.Context, p *subscription.Plan) error
+	GetPlan(ctx context.Context, id string) (*subscription.Plan, error)
+	ListPlans(ctx context.Context, merchantID string, m mode.Mode, limit, offset int) ([]*subscription.Plan, error)
+	CreateSubscription(ctx context.Context, s *subscription.Subscription) error
+	GetSubscription(ctx context.Context, id string) (*subscription.Subscription, error)
+	ListSubscriptions(ctx context.Context, merchantID string, m mode.Mode, limit, offset int) ([]*subscription.Subscription, error)
+	ChangePlan(ctx context.Context, id string, req *subscription.ChangePlanRequest) (*subscription.Subscription, error)
+	CancelSubscription(ctx context.Context, id string, req *subscription.CancelRequest) (*subscription.Subscription, error)
+	ListEvents(ctx context.Context, subscriptionID string, limit, offset int) ([]*subscription.Event, error)
+	RunBilling(ctx context.Context) (*subscription.BillingResult, error)
+}
+
+type CustomerService interface {
+	CreateCustomer(ctx context.Context, c *customer.Customer) error
+	GetCustomer(ctx context.Context, id string) (*customer.Customer, error)
+	UpdateCustomer(ctx context.Context, c *customer.Customer) error
+	ListCustomers(ctx context.Context, merchantID string, m mode.Mode, search string, limit, offset int) ([]*customer.Customer, error)
+	DeleteCustomer(ctx context.Context, id string) error
+	AddPaymentMethod(ctx context.Context, customerID string, pm *customer.PaymentMethod) error
+	ListPaymentMethods(ctx context.Context, customerID string) ([]*customer.PaymentMethod, error)
+	SetDefaultPaymentMethod(ctx context.Context, customerID, paymentMethodID string) (*customer.PaymentMethod, error)
+	RemovePaymentMethod(ctx context.Context, customerID, paymentMethodID string) error
+	ListPayments(ctx context.Context, customerID string, limit, offset int) ([]*payment.Payment, error)
+}
+
+type MemberService interface {
+	// Authorize returns member.ErrForbidden unless the user's role on the
+	// merchant grants the permission.
+	Authorize(ctx context.Context, userID, merchantID string, permission member.Permission) error
+	ListMembers(ctx context.Context, merchantID string) ([]*member.Member, error)
+	InviteMember(ctx context.Context, merchantID, actorID string, req *member.InviteRequest) (*member.Member, error)
+	RemoveMember(ctx context.Context, merchantID, actorID, userID string) error
+}
+
+type APIKeyService interface {
+	CreateKey(ctx context.Context, merchantID, actorID string, req *apikey.CreateRequest) (*apikey.CreatedKey, error)
+	ListKeys(ctx context.Context, merchantID string) ([]*apikey.APIKey, error)
+	// RotateKey issues a replacement with the same name and scopes. The old key
+	// stays valid for the grace period.
+	RotateKey(ctx context.Context, merchantID, id, actorID string, req *apikey.RotateRequest) (*apikey.CreatedKey, error)
+	RevokeKey(ctx context.Context, merchantID, id string) error
+	// Authenticate resolves a secret to an active key and records its use.
+	// Keys that require signed requests fail with apikey.ErrSignatureRequired.
+	Authenticate(ctx context.Context, secret string) (*apikey.APIKey, error)
+	// AuthenticateSigned checks a signed request's signature, timestamp and
+	// nonce, and resolves it to an active key.
+	AuthenticateSigned(ctx context.Context, req *apikey.SignedRequest) (*apikey.APIKey, error)
+	// PruneNonces forgets the nonces of signatures too old to be accepted.
+	PruneNonces(ctx context.Context) (int, error)
+}
+
+// OnboardingService runs the KYC workflow. Merchants edit their application
+// while it is a draft or rejected, then submit it for a staff review.
+type OnboardingService interface {
+	UpdateBusinessDetails(ctx context.Context, merchantID string, details *merchant.BusinessDetails) (*merchant.Merchant, error)
+	AddOwner(ctx context.Context, merchantID string, o *merchant.BeneficialOwner) error
+	ListOwners(ctx context.Context, merchantID string) ([]*merchant.BeneficialOwner, error)
+	RemoveOwner(ctx context.Context, merchantID, id string) error
+	UploadDocument(ctx context.Context, d *merchant.Document, content io.Reader) error
+	ListDocuments(ctx context.Context, merchantID string) ([]*merchant.Document, error)
+	// OpenDocument returns the document and its content; the caller closes it.
+	OpenDocument(ctx context.Context, merchantID, id string) (*merchant.Document, io.ReadCloser, error)
+	Submit(ctx context.Context, merchantID string) (*merchant.Merchant, error)
+	ListByStatus(ctx context.Context, status merchant.Status, limit, offset int) ([]*merchant.Merchant, error)
+	StartReview(ctx context.Context, merchantID, reviewerID string) (*merchant.Merchant, error)
+	Decide(ctx context.Context, merchantID, reviewerID string, decision *merchant.ReviewDecision) (*merchant.Merchant, error)
+}
+
+// AuditService keeps the append-only log of mutating API actions.
+type AuditService interface {
+	// Record diffs the entry's Before and After into Changes, with sensitive
+	// fields redacted, and appends it to the log.
+	Record(ctx context.Context, e *audit.Entry) error
+	ListEntries(ctx context.Context, filter audit.Filter) ([]*audit.Entry, error)
+	// VerifyChain walks the log from the start and reports the first entry
+	// whose hash or link does not match.
+	VerifyChain(ctx context.Context) (*audit.Verification, error)
+}
+
+// OAuthService manages machine clients of the client credentials grant.
+// Partners register clients, and merchants grant them scoped access.
+type OAuthService interface {
+	RegisterClient(ctx context.Context, ownerID string, req *oauth.RegisterClientRequest) (*oauth.RegisteredClient, error)
+	ListClients(ctx context.Context, ownerID string) ([]*oauth.Client, error)
+	// RevokeClient returns oauth.ErrClientNotFound unless the owner registered
+	// the client. Its tokens stop working at once.
+	RevokeClient(ctx context.Context, ownerID, clientID string) error
+	ListClientGrants(ctx context.Context, ownerID, clientID string) ([]*oauth.Grant, error)
+	GrantClient(ctx context.Context, merchantID, actorID string, req *oauth.GrantRequest) (*oauth.Grant, error)
+	ListGrants(ctx context.Context, merchantID string) ([]*oauth.Grant, error)
+	RevokeGrant(ctx context.Context, merchantID, clientID string) error
+	// AuthenticateClient returns oauth.ErrInvalidClient unless the client is
+	// active and the secret matches.
+	AuthenticateClient(ctx context.Context, clientID, secret string) (*oauth.Client, error)
+	// Authorize returns member.ErrForbidden unless the client is active and
+	// the merchant's grant covers the permission.
+	Authorize(ctx context.Context, clientID, merchantID string, permission member.Permission) error
 }
@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
+)
+
+// SettlementFileSource gives access to the settlement files delivered by the acquirer.
+type SettlementFileSource interface {
+	ListFiles(ctx context.Context) ([]string, error)
+	ReadFile(ctx context.Context, name string) ([]*reconciliation.SettlementLine, error)
+}
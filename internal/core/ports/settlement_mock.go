@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/popeskul/payment-gateway/internal/core/ports (interfaces: SettlementFileSource)
+//
+// Generated by this command:
+//
+//	mockgen -destination=settlement_mock.go -package=ports github.com/popeskul/payment-gateway/internal/core/ports SettlementFileSource
+//
+
+// Package ports is a generated GoMock package.
+package ports
+
+import (
+	context "context"
+	reflect "reflect"
+
+	reconciliation "github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSettlementFileSource is a mock of SettlementFileSource interface.
+type MockSettlementFileSource struct {
+	ctrl     *gomock.Controller
+	recorder *MockSettlementFileSourceMockRecorder
+}
+
+// MockSettlementFileSourceMockRecorder is the mock recorder for MockSettlementFileSource.
+type MockSettlementFileSourceMockRecorder struct {
+	mock *MockSettlementFileSource
+}
+
+// NewMockSettlementFileSource creates a new mock instance.
+func NewMockSettlementFileSource(ctrl *gomock.Controller) *MockSettlementFileSource {
+	mock := &MockSettlementFileSource{ctrl: ctrl}
+	mock.recorder = &MockSettlementFileSourceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSettlementFileSource) EXPECT() *MockSettlementFileSourceMockRecorder {
+	return m.recorder
+}
+
+// ListFiles mocks base method.
+func (m *MockSettlementFileSource) ListFiles(arg0 context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFiles", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFiles indicates an expected call of ListFiles.
+func (mr *MockSettlementFileSourceMockRecorder) ListFiles(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFiles", reflect.TypeOf((*MockSettlementFileSource)(nil).ListFiles), arg0)
+}
+
+// ReadFile mocks base method.
+func (m *MockSettlementFileSource) ReadFile(arg0 context.Context, arg1 string) ([]*reconciliation.SettlementLine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadFile", arg0, arg1)
+	ret0, _ := ret[0].([]*reconciliation.SettlementLine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadFile indicates an expected call of ReadFile.
+func (mr *MockSettlementFileSourceMockRecorder) ReadFile(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadFile", reflect.TypeOf((*MockSettlementFileSource)(nil).ReadFile), arg0, arg1)
+}
@@ -0,0 +1,341 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/pkg/signing"
+)
+
+const (
+	// Secrets look like sk_live_... or sk_test_..., so the mode of a key is
+	// obvious wherever it is pasted.
+	apiKeyPrefix = "sk_"
+	// signingSecretPrefix starts the secrets that sign requests, which are
+	// never sent themselves.
+	signingSecretPrefix = "ss_"
+	// apiKeyVisibleLength is how many random characters are kept in plaintext,
+	// after the prefix and mode, to identify the key.
+	apiKeyVisibleLength = 8
+	// apiKeyLastUsedResolution limits last-used writes to one per key per
+	// interval instead of one per request.
+	apiKeyLastUsedResolution = time.Minute
+	// maxNonceLength matches the nonce column.
+	maxNonceLength = 128
+)
+
+var (
+	errInvalidAPIKey        = errors.New("invalid api key")
+	errInvalidSignature     = errors.New("invalid request signature")
+	errSigningNotConfigured = errors.New("request signing is not configured")
+)
+
+type apiKeyService struct {
+	repo        ports.APIKeyRepository
+	logger      ports.Logger
+	gracePeriod time.Duration
+	// maxClockSkew is how far the timestamp of a signed request may be from
+	// the server's clock, and so how long its nonce has to be remembered.
+	maxClockSkew time.Duration
+	// signingPepper derives each key's signing secret, see signingSecret.
+	// Without it signed requests are refused.
+	signingPepper []byte
+
+	mu sync.Mutex
+}
+
+func NewAPIKeyService(repo ports.APIKeyRepository, logger ports.Logger, gracePeriod, maxClockSkew time.Duration, signingPepper string) ports.APIKeyService {
+	return &apiKeyService{
+		repo:          repo,
+		logger:        logger,
+		gracePeriod:   gracePeriod,
+		maxClockSkew:  maxClockSkew,
+		signingPepper: []byte(signingPepper),
+	}
+}
+
+func (s *apiKeyService) CreateKey(ctx context.Context, merchantID, actorID string, req *apikey.CreateRequest) (*apikey.CreatedKey, error) {
+	if req == nil || req.Name == "" {
+		s.logger.Error("api key name is required")
+		return nil, errors.New("api key name is required")
+	}
+
+	if len(req.Scopes) == 0 {
+		s.logger.Error("at least one scope is required")
+		return nil, errors.New("at least one scope is required")
+	}
+	for _, scope := range req.Scopes {
+		if !scope.Valid() {
+			s.logger.Error("invalid api key scope", "scope", scope)
+			return nil, fmt.Errorf("invalid scope %q", scope)
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		s.logger.Error("api key expiry must be in the future")
+		return nil, errors.New("expiry must be in the future")
+	}
+
+	keyMode := req.Mode.OrLive()
+	if !keyMode.Valid() {
+		s.logger.Error("invalid api key mode", "mode", req.Mode)
+		return nil, fmt.Errorf("invalid mode %q", req.Mode)
+	}
+
+	if req.RequireSignature && len(s.signingPepper) == 0 {
+		s.logger.Error("signing-only api key requested without a signing pepper")
+		return nil, errSigningNotConfigured
+	}
+
+	secret, prefix, err := generateAPIKeySecret(keyMode)
+	if err != nil {
+		s.logger.Error("Failed to generate api key", "error", err)
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	k := &apikey.APIKey{
+		MerchantID:       merchantID,
+		Mode:             keyMode,
+		Name:             req.Name,
+		Prefix:           prefix,
+		Hash:             hashAPIKey(secret),
+		Scopes:           req.Scopes,
+		RequireSignature: req.RequireSignature,
+		ExpiresAt:        req.ExpiresAt,
+		CreatedBy:        actorID,
+		CreatedAt:        time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.Create(ctx, k); err != nil {
+		s.logger.Error("Failed to create api key", "error", err, "merchant_id", merchantID)
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return &apikey.CreatedKey{APIKey: k, Secret: secret, SigningSecret: signingSecret(s.signingPepper, k)}, nil
+}
+
+func (s *apiKeyService) ListKeys(ctx context.Context, merchantID string) ([]*apikey.APIKey, error) {
+	return s.repo.ListByMerchant(ctx, merchantID)
+}
+
+func (s *apiKeyService) RotateKey(ctx context.Context, merchantID, id, actorID string, req *apikey.RotateRequest) (*apikey.CreatedKey, error) {
+	grace := s.gracePeriod
+	if req != nil && req.GracePeriod != "" {
+		d, err := time.ParseDuration(req.GracePeriod)
+		if err != nil || d < 0 {
+			s.logger.Error("invalid grace period", "grace_period", req.GracePeriod)
+			return nil, fmt.Errorf("invalid grace period %q", req.GracePeriod)
+		}
+		grace = d
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, err := s.getKey(ctx, merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !old.Active(now) || old.RotatedTo != "" {
+		s.logger.Error("api key cannot be rotated", "id", id)
+		return nil, fmt.Errorf("api key %s is no longer active", id)
+	}
+
+	secret, prefix, err := generateAPIKeySecret(old.Mode.OrLive())
+	if err != nil {
+		s.logger.Error("Failed to generate api key", "error", err)
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	replacement := &apikey.APIKey{
+		MerchantID:       old.MerchantID,
+		Mode:             old.Mode,
+		Name:             old.Name,
+		Prefix:           prefix,
+		Hash:             hashAPIKey(secret),
+		Scopes:           old.Scopes,
+		RequireSignature: old.RequireSignature,
+		ExpiresAt:        old.ExpiresAt,
+		CreatedBy:        actorID,
+		CreatedAt:        now,
+	}
+
+	// Both keys work until the grace period ends, giving integrations time to
+	// switch over.
+	graceEnd := now.Add(grace)
+	if old.ExpiresAt == nil || graceEnd.Before(*old.ExpiresAt) {
+		old.ExpiresAt = &graceEnd
+	}
+
+	if err := s.repo.Rotate(ctx, old, replacement); err != nil {
+		s.logger.Error("Failed to rotate api key", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to rotate api key: %w", err)
+	}
+
+	return &apikey.CreatedKey{APIKey: replacement, Secret: secret, SigningSecret: signingSecret(s.signingPepper, replacement)}, nil
+}
+
+func (s *apiKeyService) RevokeKey(ctx context.Context, merchantID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, err := s.getKey(ctx, merchantID, id)
+	if err != nil {
+		return err
+	}
+
+	if k.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	k.RevokedAt = &now
+	if err := s.repo.Update(ctx, k); err != nil {
+		s.logger.Error("Failed to revoke api key", "error", err, "id", id)
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, secret string) (*apikey.APIKey, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, errInvalidAPIKey
+	}
+
+	k, err := s.repo.GetByHash(ctx, hashAPIKey(secret))
+	if err != nil {
+		return nil, errInvalidAPIKey
+	}
+
+	now := time.Now()
+	if !k.Active(now) {
+		s.logger.Warn("Inactive api key used", "id", k.ID, "merchant_id", k.MerchantID)
+		return nil, errInvalidAPIKey
+	}
+
+	if k.RequireSignature {
+		s.logger.Warn("Unsigned request with a signing-only api key", "id", k.ID, "merchant_id", k.MerchantID)
+		return nil, apikey.ErrSignatureRequired
+	}
+
+	s.touchLastUsed(ctx, k, now)
+	return k, nil
+}
+
+func (s *apiKeyService) AuthenticateSigned(ctx context.Context, req *apikey.SignedRequest) (*apikey.APIKey, error) {
+	if len(s.signingPepper) == 0 || req.KeyID == "" || req.Nonce == "" || len(req.Nonce) > maxNonceLength {
+		return nil, errInvalidSignature
+	}
+
+	k, err := s.repo.GetByID(ctx, req.KeyID)
+	if err != nil {
+		return nil, errInvalidSignature
+	}
+
+	now := time.Now()
+	if !k.Active(now) {
+		s.logger.Warn("Inactive api key used", "id", k.ID, "merchant_id", k.MerchantID)
+		return nil, errInvalidAPIKey
+	}
+
+	stringToSign := signing.StringToSign(req.Method, req.RequestURI, req.Timestamp, req.Nonce, req.BodyHash)
+	if !signing.Verify([]byte(signingSecret(s.signingPepper, k)), stringToSign, req.Signature) {
+		s.logger.Warn("Invalid request signature", "id", k.ID, "merchant_id", k.MerchantID)
+		return nil, errInvalidSignature
+	}
+
+	signedAt := time.Unix(req.Timestamp, 0)
+	if skew := now.Sub(signedAt); skew > s.maxClockSkew || skew < -s.maxClockSkew {
+		s.logger.Warn("Request signature outside the allowed clock skew", "id", k.ID, "skew", skew)
+		return nil, errInvalidSignature
+	}
+
+	fresh, err := s.repo.UseNonce(ctx, k.ID, req.Nonce, signedAt.Add(s.maxClockSkew))
+	if err != nil {
+		s.logger.Error("Failed to record request nonce", "error", err, "id", k.ID)
+		return nil, fmt.Errorf("failed to record request nonce: %w", err)
+	}
+	if !fresh {
+		s.logger.Warn("Replayed request signature", "id", k.ID, "merchant_id", k.MerchantID)
+		return nil, errInvalidSignature
+	}
+
+	s.touchLastUsed(ctx, k, now)
+	return k, nil
+}
+
+func (s *apiKeyService) PruneNonces(ctx context.Context) (int, error) {
+	return s.repo.PruneNonces(ctx, time.Now())
+}
+
+func (s *apiKeyService) touchLastUsed(ctx context.Context, k *apikey.APIKey, now time.Time) {
+	if k.LastUsedAt != nil && now.Sub(*k.LastUsedAt) < apiKeyLastUsedResolution {
+		return
+	}
+
+	// Tracking is best effort and must not fail the request.
+	if err := s.repo.TouchLastUsed(ctx, k.ID, now); err != nil {
+		s.logger.Error("Failed to record api key use", "error", err, "id", k.ID)
+	} else {
+		k.LastUsedAt = &now
+	}
+}
+
+func (s *apiKeyService) getKey(ctx context.Context, merchantID, id string) (*apikey.APIKey, error) {
+	k, err := s.repo.GetByID(ctx, id)
+	if err != nil || k.MerchantID != merchantID {
+		s.logger.Error("api key not found", "id", id, "merchant_id", merchantID)
+		return nil, fmt.Errorf("api key with id %s not found", id)
+	}
+	return k, nil
+}
+
+// generateAPIKeySecret returns a new secret for the mode and the part of it
+// that is stored in plaintext.
+func generateAPIKeySecret(m mode.Mode) (secret, prefix string, err error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	random := hex.EncodeToString(b)
+	prefix = apiKeyPrefix + string(m) + "_"
+	return prefix + random, prefix + random[:apiKeyVisibleLength], nil
+}
+
+// signingSecret derives the secret that signs requests for a key: an HMAC of
+// the key's ID and hash under the server's pepper. It never has to be stored,
+// and reading the api_keys table is not enough to sign requests. It is empty
+// without a pepper.
+func signingSecret(pepper []byte, k *apikey.APIKey) string {
+	if len(pepper) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write([]byte(k.ID + "\n" + k.Hash))
+	return signingSecretPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// hashAPIKey uses a plain SHA-256: keys are long random values, so a slow
+// password hash is unnecessary and lookups can be done by hash.
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,362 @@
+package services_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/core/services"
+	"github.com/popeskul/payment-gateway/pkg/signing"
+)
+
+func TestAPIKeyService_CreateKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockAPIKeyRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	apiKeyService := services.NewAPIKeyService(mockRepo, mockLogger, 24*time.Hour, 5*time.Minute, "pepper")
+
+	tests := []struct {
+		name          string
+		request       *apikey.CreateRequest
+		setupMocks    func()
+		expectedMode  mode.Mode
+		expectedError error
+	}{
+		{
+			name:    "Successful creation",
+			request: &apikey.CreateRequest{Name: "Backend", Scopes: []apikey.Scope{apikey.ScopePaymentsWrite}},
+			setupMocks: func() {
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedMode: mode.Live,
+		},
+		{
+			name:    "Test mode key",
+			request: &apikey.CreateRequest{Name: "Sandbox", Mode: mode.Test, Scopes: []apikey.Scope{apikey.ScopePaymentsWrite}},
+			setupMocks: func() {
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedMode: mode.Test,
+		},
+		{
+			name:    "Unknown mode",
+			request: &apikey.CreateRequest{Name: "Backend", Mode: "staging", Scopes: []apikey.Scope{apikey.ScopePaymentsWrite}},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error("invalid api key mode", "mode", mode.Mode("staging"))
+			},
+			expectedError: errors.New(`invalid mode "staging"`),
+		},
+		{
+			name:    "Unknown scope",
+			request: &apikey.CreateRequest{Name: "Backend", Scopes: []apikey.Scope{"admin"}},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New(`invalid scope "admin"`),
+		},
+		{
+			name:    "No scopes",
+			request: &apikey.CreateRequest{Name: "Backend"},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error(gomock.Any())
+			},
+			expectedError: errors.New("at least one scope is required"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			key, err := apiKeyService.CreateKey(context.Background(), "merchant1", "user1", tt.request)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				require.NoError(t, err)
+				assert.True(t, strings.HasPrefix(key.Secret, key.Prefix))
+				assert.True(t, strings.HasPrefix(key.Secret, "sk_"+string(tt.expectedMode)+"_"))
+				assert.Equal(t, tt.expectedMode, key.Mode)
+				sum := sha256.Sum256([]byte(key.Secret))
+				assert.Equal(t, hex.EncodeToString(sum[:]), key.Hash)
+				assert.NotContains(t, key.Hash, key.Secret)
+				assert.True(t, strings.HasPrefix(key.SigningSecret, "ss_"))
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_RotateKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockAPIKeyRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	apiKeyService := services.NewAPIKeyService(mockRepo, mockLogger, 24*time.Hour, 5*time.Minute, "pepper")
+
+	t.Run("Old key stays valid for the grace period", func(t *testing.T) {
+		old := &apikey.APIKey{ID: "key1", MerchantID: "merchant1", Name: "Backend", Scopes: []apikey.Scope{apikey.ScopeRefundsWrite}}
+		mockRepo.EXPECT().GetByID(gomock.Any(), "key1").Return(old, nil)
+		mockRepo.EXPECT().Rotate(gomock.Any(), old, gomock.Any()).Return(nil)
+
+		key, err := apiKeyService.RotateKey(context.Background(), "merchant1", "key1", "user1", &apikey.RotateRequest{GracePeriod: "1h"})
+
+		require.NoError(t, err)
+		assert.Equal(t, old.Scopes, key.Scopes)
+		require.NotNil(t, old.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *old.ExpiresAt, time.Minute)
+		assert.True(t, old.Active(time.Now()))
+	})
+
+	t.Run("Key of another merchant", func(t *testing.T) {
+		mockRepo.EXPECT().GetByID(gomock.Any(), "key2").Return(&apikey.APIKey{ID: "key2", MerchantID: "merchant2"}, nil)
+		mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+
+		_, err := apiKeyService.RotateKey(context.Background(), "merchant1", "key2", "user1", nil)
+
+		assert.EqualError(t, err, "api key with id key2 not found")
+	})
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockAPIKeyRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	apiKeyService := services.NewAPIKeyService(mockRepo, mockLogger, 24*time.Hour, 5*time.Minute, "pepper")
+
+	past := time.Now().Add(-time.Hour)
+	recent := time.Now().Add(-time.Second)
+
+	tests := []struct {
+		name          string
+		secret        string
+		setupMocks    func()
+		expectedError bool
+	}{
+		{
+			name:   "Active key records its use",
+			secret: "sk_valid",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).Return(&apikey.APIKey{ID: "key1"}, nil)
+				mockRepo.EXPECT().TouchLastUsed(gomock.Any(), "key1", gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:   "Recently used key skips the write",
+			secret: "sk_valid",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).Return(&apikey.APIKey{ID: "key1", LastUsedAt: &recent}, nil)
+			},
+		},
+		{
+			name:   "Expired key",
+			secret: "sk_expired",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).Return(&apikey.APIKey{ID: "key1", ExpiresAt: &past}, nil)
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: true,
+		},
+		{
+			name:   "Signing-only key",
+			secret: "sk_signing",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).Return(&apikey.APIKey{ID: "key1", RequireSignature: true}, nil)
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: true,
+		},
+		{
+			name:          "Malformed key",
+			secret:        "not-a-key",
+			setupMocks:    func() {},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			key, err := apiKeyService.Authenticate(context.Background(), tt.secret)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, key)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, key)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_AuthenticateSigned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockAPIKeyRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	apiKeyService := services.NewAPIKeyService(mockRepo, mockLogger, 24*time.Hour, 5*time.Minute, "pepper")
+
+	var key *apikey.APIKey
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, k *apikey.APIKey) error {
+		k.ID = "key1"
+		key = k
+		return nil
+	})
+	created, err := apiKeyService.CreateKey(context.Background(), "merchant1", "user1", &apikey.CreateRequest{
+		Name: "Backend", Scopes: []apikey.Scope{apikey.ScopePaymentsWrite}, RequireSignature: true,
+	})
+	require.NoError(t, err)
+
+	signedWith := func(signingKey []byte, timestamp time.Time, body string) *apikey.SignedRequest {
+		req := &apikey.SignedRequest{
+			KeyID:      "key1",
+			Method:     "POST",
+			RequestURI: "/api/v1/payments",
+			Timestamp:  timestamp.Unix(),
+			Nonce:      "nonce1",
+			BodyHash:   signing.BodyHash([]byte(`{"amount":100}`)),
+		}
+		stringToSign := signing.StringToSign(req.Method, req.RequestURI, req.Timestamp, req.Nonce, req.BodyHash)
+		req.Signature = signing.Sign(signingKey, stringToSign)
+		req.BodyHash = signing.BodyHash([]byte(body))
+		return req
+	}
+	signed := func(timestamp time.Time, body string) *apikey.SignedRequest {
+		return signedWith([]byte(created.SigningSecret), timestamp, body)
+	}
+
+	tests := []struct {
+		name          string
+		req           *apikey.SignedRequest
+		setupMocks    func()
+		expectedError bool
+	}{
+		{
+			name: "Valid signature",
+			req:  signed(time.Now(), `{"amount":100}`),
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "key1").Return(key, nil)
+				mockRepo.EXPECT().UseNonce(gomock.Any(), "key1", "nonce1", gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().TouchLastUsed(gomock.Any(), "key1", gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "Tampered body",
+			req:  signed(time.Now(), `{"amount":100000}`),
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "key1").Return(key, nil)
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: true,
+		},
+		{
+			name: "Signed with the stored hash",
+			req:  signedWith([]byte(key.Hash), time.Now(), `{"amount":100}`),
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "key1").Return(key, nil)
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: true,
+		},
+		{
+			name: "Timestamp outside the clock skew",
+			req:  signed(time.Now().Add(-10*time.Minute), `{"amount":100}`),
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "key1").Return(key, nil)
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: true,
+		},
+		{
+			name: "Replayed nonce",
+			req:  signed(time.Now(), `{"amount":100}`),
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "key1").Return(key, nil)
+				mockRepo.EXPECT().UseNonce(gomock.Any(), "key1", "nonce1", gomock.Any()).Return(false, nil)
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: true,
+		},
+		{
+			name: "Unknown key",
+			req:  signed(time.Now(), `{"amount":100}`),
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "key1").Return(nil, errors.New("api key not found"))
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			got, err := apiKeyService.AuthenticateSigned(context.Background(), tt.req)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "key1", got.ID)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_SigningWithoutPepper(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockAPIKeyRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	apiKeyService := services.NewAPIKeyService(mockRepo, mockLogger, 24*time.Hour, 5*time.Minute, "")
+
+	t.Run("Keys come without a signing secret", func(t *testing.T) {
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		key, err := apiKeyService.CreateKey(context.Background(), "merchant1", "user1", &apikey.CreateRequest{Name: "Backend", Scopes: []apikey.Scope{apikey.ScopePaymentsWrite}})
+
+		require.NoError(t, err)
+		assert.Empty(t, key.SigningSecret)
+	})
+
+	t.Run("Signing-only keys are refused", func(t *testing.T) {
+		mockLogger.EXPECT().Error("signing-only api key requested without a signing pepper")
+
+		_, err := apiKeyService.CreateKey(context.Background(), "merchant1", "user1", &apikey.CreateRequest{
+			Name: "Backend", Scopes: []apikey.Scope{apikey.ScopePaymentsWrite}, RequireSignature: true,
+		})
+
+		assert.EqualError(t, err, "request signing is not configured")
+	})
+
+	t.Run("Signed requests are refused", func(t *testing.T) {
+		_, err := apiKeyService.AuthenticateSigned(context.Background(), &apikey.SignedRequest{KeyID: "key1", Nonce: "nonce1", Signature: "v1=00"})
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/audit"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+const (
+	// auditVerifyBatchSize is how many entries are loaded at a time while
+	// walking the chain.
+	auditVerifyBatchSize = 500
+	auditMaxListLimit    = 100
+)
+
+type auditService struct {
+	repo   ports.AuditRepository
+	logger ports.Logger
+}
+
+func NewAuditService(repo ports.AuditRepository, logger ports.Logger) ports.AuditService {
+	return &auditService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *auditService) Record(ctx context.Context, e *audit.Entry) error {
+	if e == nil || e.Action == "" {
+		s.logger.Error("audit entry action is required")
+		return errors.New("audit entry action is required")
+	}
+
+	changes, err := audit.Diff(e.Before, e.After)
+	if err != nil {
+		s.logger.Error("Failed to diff audit entry", "error", err, "action", e.Action)
+		return fmt.Errorf("failed to diff audit entry: %w", err)
+	}
+
+	e.Changes = changes
+	e.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	if err := s.repo.Append(ctx, e); err != nil {
+		s.logger.Error("Failed to append audit entry", "error", err, "action", e.Action)
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+
+	return nil
+}
+
+func (s *auditService) ListEntries(ctx context.Context, filter audit.Filter) ([]*audit.Entry, error) {
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		s.logger.Error("audit log range start is after its end")
+		return nil, errors.New("from must not be after to")
+	}
+
+	if filter.Limit <= 0 || filter.Limit > auditMaxListLimit {
+		filter.Limit = auditMaxListLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	entries, err := s.repo.List(ctx, filter)
+	if err != nil {
+		s.logger.Error("Failed to list audit entries", "error", err)
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (s *auditService) VerifyChain(ctx context.Context) (*audit.Verification, error) {
+	result := &audit.Verification{Valid: true}
+
+	var prev *audit.Entry
+	for {
+		after := int64(0)
+		if prev != nil {
+			after = prev.Sequence
+		}
+
+		entries, err := s.repo.ListAfter(ctx, after, auditVerifyBatchSize)
+		if err != nil {
+			s.logger.Error("Failed to load audit entries", "error", err, "after", after)
+			return nil, fmt.Errorf("failed to load audit entries: %w", err)
+		}
+
+		for _, e := range entries {
+			if err := audit.VerifyLink(prev, e); err != nil {
+				s.logger.Warn("Audit log chain is broken", "sequence", e.Sequence, "reason", err.Error())
+				result.Valid = false
+				result.BrokenAt = e.Sequence
+				result.Reason = err.Error()
+				return result, nil
+			}
+			result.Checked++
+			prev = e
+		}
+
+		if len(entries) < auditVerifyBatchSize {
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/audit"
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/core/services"
+)
+
+func TestAuditService_Record(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockAuditRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	auditService := services.NewAuditService(mockRepo, mockLogger)
+
+	tests := []struct {
+		name            string
+		entry           *audit.Entry
+		setupMocks      func()
+		expectedChanges map[string]audit.Change
+		expectedError   error
+	}{
+		{
+			name: "Update records changed fields only",
+			entry: &audit.Entry{
+				Action: "PUT /api/v1/merchants/{id}",
+				Before: &merchant.Merchant{ID: "merchant1", Name: "Acme", SettlementCurrency: "USD"},
+				After:  &merchant.Merchant{ID: "merchant1", Name: "Acme", SettlementCurrency: "EUR"},
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedChanges: map[string]audit.Change{
+				"settlement_currency": {Before: "USD", After: "EUR"},
+			},
+		},
+		{
+			name: "Personal data is redacted",
+			entry: &audit.Entry{
+				Action: "PUT /api/v1/customers/{id}",
+				Before: &customer.Customer{ID: "customer1", Email: "a@example.com", Name: "Jane Doe"},
+				After: &customer.Customer{ID: "customer1", Email: "b@example.com", Name: "Jane Roe", Phone: "+15550100",
+					BillingAddress: &customer.Address{Line1: "1 Main St", City: "Springfield"}},
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedChanges: map[string]audit.Change{
+				"email":           {Before: audit.Redacted, After: audit.Redacted},
+				"name":            {Before: audit.Redacted, After: audit.Redacted},
+				"phone":           {After: audit.Redacted},
+				"billing_address": {After: audit.Redacted},
+			},
+		},
+		{
+			name: "Sensitive fields are redacted",
+			entry: &audit.Entry{
+				Action: "PUT /api/v1/merchants/{id}/business-details",
+				Before: &merchant.BusinessDetails{LegalName: "Acme", TaxID: "111"},
+				After:  &merchant.BusinessDetails{LegalName: "Acme", TaxID: "222"},
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedChanges: map[string]audit.Change{
+				"tax_id": {Before: audit.Redacted, After: audit.Redacted},
+			},
+		},
+		{
+			name: "Created record",
+			entry: &audit.Entry{
+				Action: "POST /api/v1/customers/{id}/payment-methods",
+				After:  &customer.PaymentMethod{ID: "pm1", Type: "card", Token: "tok_secret"},
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedChanges: map[string]audit.Change{
+				"id":          {After: "pm1"},
+				"customer_id": {After: ""},
+				"type":        {After: "card"},
+				"token":       {After: audit.Redacted},
+				"is_default":  {After: false},
+				"created_at":  {After: "0001-01-01T00:00:00Z"},
+			},
+		},
+		{
+			name:  "No changes",
+			entry: &audit.Entry{Action: "POST /api/v1/payments/{id}/process"},
+			setupMocks: func() {
+				mockRepo.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:  "Missing action",
+			entry: &audit.Entry{},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error(gomock.Any())
+			},
+			expectedError: errors.New("audit entry action is required"),
+		},
+		{
+			name:  "Repository error",
+			entry: &audit.Entry{Action: "DELETE /api/v1/customers/{id}"},
+			setupMocks: func() {
+				mockRepo.EXPECT().Append(gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("failed to append audit entry: db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := auditService.Record(context.Background(), tt.entry)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedChanges, tt.entry.Changes)
+			assert.False(t, tt.entry.CreatedAt.IsZero())
+		})
+	}
+}
+
+func TestAuditService_ListEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockAuditRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	auditService := services.NewAuditService(mockRepo, mockLogger)
+
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		filter        audit.Filter
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name:   "Limit is capped",
+			filter: audit.Filter{MerchantID: "merchant1", Limit: 1000},
+			setupMocks: func() {
+				mockRepo.EXPECT().List(gomock.Any(), audit.Filter{MerchantID: "merchant1", Limit: 100}).Return([]*audit.Entry{}, nil)
+			},
+		},
+		{
+			name:   "Time range",
+			filter: audit.Filter{ActorID: "user1", From: &earlier, To: &now, Limit: 10},
+			setupMocks: func() {
+				mockRepo.EXPECT().List(gomock.Any(), audit.Filter{ActorID: "user1", From: &earlier, To: &now, Limit: 10}).Return([]*audit.Entry{}, nil)
+			},
+		},
+		{
+			name:   "From after to",
+			filter: audit.Filter{From: &now, To: &earlier},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error(gomock.Any())
+			},
+			expectedError: errors.New("from must not be after to"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			_, err := auditService.ListEntries(context.Background(), tt.filter)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// auditChain builds n correctly linked entries.
+func auditChain(n int) []*audit.Entry {
+	var entries []*audit.Entry
+	prevHash := ""
+	for i := 1; i <= n; i++ {
+		e := &audit.Entry{
+			ID:        fmt.Sprintf("entry%d", i),
+			Sequence:  int64(i),
+			ActorType: audit.ActorUser,
+			ActorID:   "user1",
+			Action:    "PUT /api/v1/merchants/{id}",
+			Changes:   map[string]audit.Change{"name": {Before: "a", After: "b"}},
+			PrevHash:  prevHash,
+			CreatedAt: time.Date(2024, 1, 1, 0, 0, i, 0, time.UTC),
+		}
+		e.Hash = e.ComputeHash()
+		prevHash = e.Hash
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestAuditService_VerifyChain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockAuditRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	auditService := services.NewAuditService(mockRepo, mockLogger)
+
+	tests := []struct {
+		name       string
+		entries    func() []*audit.Entry
+		expectWarn bool
+		expected   *audit.Verification
+	}{
+		{
+			name:     "Intact chain",
+			entries:  func() []*audit.Entry { return auditChain(3) },
+			expected: &audit.Verification{Valid: true, Checked: 3},
+		},
+		{
+			name:     "Empty log",
+			entries:  func() []*audit.Entry { return nil },
+			expected: &audit.Verification{Valid: true},
+		},
+		{
+			name: "Modified entry",
+			entries: func() []*audit.Entry {
+				entries := auditChain(3)
+				entries[1].Changes["name"] = audit.Change{Before: "a", After: "c"}
+				return entries
+			},
+			expectWarn: true,
+			expected:   &audit.Verification{Valid: false, Checked: 1, BrokenAt: 2, Reason: "entry 2 was modified"},
+		},
+		{
+			name: "Deleted entry",
+			entries: func() []*audit.Entry {
+				entries := auditChain(3)
+				return []*audit.Entry{entries[0], entries[2]}
+			},
+			expectWarn: true,
+			expected:   &audit.Verification{Valid: false, Checked: 1, BrokenAt: 3, Reason: "entry 1 is followed by entry 3"},
+		},
+		{
+			name: "Rewritten history",
+			entries: func() []*audit.Entry {
+				entries := auditChain(3)
+				entries[1].ActorID = "someone"
+				entries[1].Hash = entries[1].ComputeHash()
+				return entries
+			},
+			expectWarn: true,
+			expected:   &audit.Verification{Valid: false, Checked: 2, BrokenAt: 3, Reason: "entry 3 does not link to entry 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo.EXPECT().ListAfter(gomock.Any(), int64(0), gomock.Any()).Return(tt.entries(), nil)
+			if tt.expectWarn {
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			}
+
+			result, err := auditService.VerifyChain(context.Background())
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/validator"
+)
+
+type customerService struct {
+	repo         ports.CustomerRepository
+	merchantRepo ports.MerchantRepository
+	paymentRepo  ports.PaymentRepository
+	logger       ports.Logger
+
+	mu sync.RWMutex
+}
+
+func NewCustomerService(repo ports.CustomerRepository, merchantRepo ports.MerchantRepository, paymentRepo ports.PaymentRepository, logger ports.Logger) ports.CustomerService {
+	return &customerService{
+		repo:         repo,
+		merchantRepo: merchantRepo,
+		paymentRepo:  paymentRepo,
+		logger:       logger,
+	}
+}
+
+func (s *customerService) CreateCustomer(ctx context.Context, c *customer.Customer) error {
+	if c == nil {
+		s.logger.Error("customer cannot be nil")
+		return errors.New("customer cannot be nil")
+	}
+
+	if err := validator.Field(c.Email, "required,email"); err != nil {
+		s.logger.Error("invalid customer email", "email", c.Email)
+		return errors.New("invalid customer email")
+	}
+
+	if _, err := s.merchantRepo.GetByID(ctx, c.MerchantID); err != nil {
+		s.logger.Error("merchant not found", "id", c.MerchantID)
+		return fmt.Errorf("merchant with id %s not found", c.MerchantID)
+	}
+
+	c.Mode = c.Mode.OrLive()
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.repo.Create(ctx, c)
+}
+
+func (s *customerService) GetCustomer(ctx context.Context, id string) (*customer.Customer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *customerService) UpdateCustomer(ctx context.Context, c *customer.Customer) error {
+	if c == nil {
+		s.logger.Error("customer cannot be nil")
+		return errors.New("customer cannot be nil")
+	}
+
+	if err := validator.Field(c.Email, "required,email"); err != nil {
+		s.logger.Error("invalid customer email", "email", c.Email)
+		return errors.New("invalid customer email")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.repo.GetByID(ctx, c.ID)
+	if err != nil {
+		s.logger.Error("customer not found", "id", c.ID)
+		return fmt.Errorf("customer with id %s not found", c.ID)
+	}
+
+	// A customer cannot move between merchants or modes.
+	c.MerchantID = existing.MerchantID
+	c.Mode = existing.Mode
+	c.CreatedAt = existing.CreatedAt
+	c.UpdatedAt = time.Now()
+
+	return s.repo.Update(ctx, c)
+}
+
+func (s *customerService) ListCustomers(ctx context.Context, merchantID string, m mode.Mode, search string, limit, offset int) ([]*customer.Customer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.repo.List(ctx, merchantID, m, search, limit, offset)
+}
+
+// DeleteCustomer erases the customer's personal data for GDPR requests. Their
+// payments and refunds stay on record without a link back to the customer.
+func (s *customerService) DeleteCustomer(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		s.logger.Error("Failed to delete customer", "error", err, "id", id)
+		return fmt.Errorf("failed to delete customer: %w", err)
+	}
+
+	return nil
+}
+
+func (s *customerService) AddPaymentMethod(ctx context.Context, customerID string, pm *customer.PaymentMethod) error {
+	if pm == nil {
+		s.logger.Error("payment method cannot be nil")
+		return errors.New("payment method cannot be nil")
+	}
+
+	if pm.Type == "" || pm.Token == "" {
+		s.logger.Error("payment method type and token are required")
+		return errors.New("payment method type and token are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.repo.GetByID(ctx, customerID); err != nil {
+		s.logger.Error("customer not found", "id", customerID)
+		return fmt.Errorf("customer with id %s not found", customerID)
+	}
+
+	existing, err := s.repo.ListPaymentMethods(ctx, customerID)
+	if err != nil {
+		s.logger.Error("Failed to list payment methods", "error", err, "customer_id", customerID)
+		return fmt.Errorf("failed to list payment methods: %w", err)
+	}
+
+	pm.CustomerID = customerID
+	pm.CreatedAt = time.Now()
+	// The first saved method becomes the default; later ones are promoted
+	// explicitly.
+	makeDefault := pm.IsDefault || len(existing) == 0
+	pm.IsDefault = false
+
+	if err := s.repo.AddPaymentMethod(ctx, pm); err != nil {
+		s.logger.Error("Failed to add payment method", "error", err, "customer_id", customerID)
+		return fmt.Errorf("failed to add payment method: %w", err)
+	}
+
+	if makeDefault {
+		if err := s.repo.SetDefaultPaymentMethod(ctx, customerID, pm.ID); err != nil {
+			s.logger.Error("Failed to set default payment method", "error", err, "customer_id", customerID)
+			return fmt.Errorf("failed to set default payment method: %w", err)
+		}
+		pm.IsDefault = true
+	}
+
+	return nil
+}
+
+func (s *customerService) ListPaymentMethods(ctx context.Context, customerID string) ([]*customer.PaymentMethod, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.repo.ListPaymentMethods(ctx, customerID)
+}
+
+func (s *customerService) SetDefaultPaymentMethod(ctx context.Context, customerID, paymentMethodID string) (*customer.PaymentMethod, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pm, err := s.getPaymentMethod(ctx, customerID, paymentMethodID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetDefaultPaymentMethod(ctx, customerID, pm.ID); err != nil {
+		s.logger.Error("Failed to set default payment method", "error", err, "customer_id", customerID)
+		return nil, fmt.Errorf("failed to set default payment method: %w", err)
+	}
+	pm.IsDefault = true
+
+	return pm, nil
+}
+
+func (s *customerService) RemovePaymentMethod(ctx context.Context, customerID, paymentMethodID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pm, err := s.getPaymentMethod(ctx, customerID, paymentMethodID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeletePaymentMethod(ctx, pm.ID); err != nil {
+		s.logger.Error("Failed to remove payment method", "error", err, "id", pm.ID)
+		return fmt.Errorf("failed to remove payment method: %w", err)
+	}
+
+	return nil
+}
+
+func (s *customerService) ListPayments(ctx context.Context, customerID string, limit, offset int) ([]*payment.Payment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.paymentRepo.ListByCustomer(ctx, customerID, limit, offset)
+}
+
+func (s *customerService) getPaymentMethod(ctx context.Context, customerID, paymentMethodID string) (*customer.PaymentMethod, error) {
+	pm, err := s.repo.GetPaymentMethod(ctx, paymentMethodID)
+	if err != nil || pm.CustomerID != customerID {
+		s.logger.Error("payment method not found", "id", paymentMethodID, "customer_id", customerID)
+		return nil, fmt.Errorf("payment method with id %s not found", paymentMethodID)
+	}
+	return pm, nil
+}
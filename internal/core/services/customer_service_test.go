@@ -0,0 +1,196 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/core/services"
+)
+
+func TestCustomerService_CreateCustomer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockCustomerRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	customerService := services.NewCustomerService(mockRepo, mockMerchantRepo, mockPaymentRepo, mockLogger)
+
+	tests := []struct {
+		name          string
+		customer      *customer.Customer
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name:     "Successful creation",
+			customer: &customer.Customer{MerchantID: "merchant1", Email: "jane@example.com", Name: "Jane"},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(&merchant.Merchant{ID: "merchant1"}, nil)
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:     "Invalid email",
+			customer: &customer.Customer{MerchantID: "merchant1", Email: "not-an-email"},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("invalid customer email"),
+		},
+		{
+			name:     "Unknown merchant",
+			customer: &customer.Customer{MerchantID: "nonexistent", Email: "jane@example.com"},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "nonexistent").Return(nil, errors.New("merchant not found"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("merchant with id nonexistent not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := customerService.CreateCustomer(context.Background(), tt.customer)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.NotZero(t, tt.customer.CreatedAt)
+			}
+		})
+	}
+}
+
+func TestCustomerService_AddPaymentMethod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockCustomerRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	customerService := services.NewCustomerService(mockRepo, mockMerchantRepo, mockPaymentRepo, mockLogger)
+
+	setID := func(_ context.Context, pm *customer.PaymentMethod) error {
+		pm.ID = "pm_new"
+		return nil
+	}
+
+	tests := []struct {
+		name            string
+		paymentMethod   *customer.PaymentMethod
+		setupMocks      func()
+		expectedDefault bool
+		expectedError   error
+	}{
+		{
+			name:          "First payment method becomes the default",
+			paymentMethod: &customer.PaymentMethod{Type: "card", Token: "tok_1"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "customer1").Return(&customer.Customer{ID: "customer1"}, nil)
+				mockRepo.EXPECT().ListPaymentMethods(gomock.Any(), "customer1").Return(nil, nil)
+				mockRepo.EXPECT().AddPaymentMethod(gomock.Any(), gomock.Any()).DoAndReturn(setID)
+				mockRepo.EXPECT().SetDefaultPaymentMethod(gomock.Any(), "customer1", "pm_new").Return(nil)
+			},
+			expectedDefault: true,
+		},
+		{
+			name:          "Additional payment method is not the default",
+			paymentMethod: &customer.PaymentMethod{Type: "card", Token: "tok_2"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "customer1").Return(&customer.Customer{ID: "customer1"}, nil)
+				mockRepo.EXPECT().ListPaymentMethods(gomock.Any(), "customer1").Return([]*customer.PaymentMethod{
+					{ID: "pm1", CustomerID: "customer1", IsDefault: true},
+				}, nil)
+				mockRepo.EXPECT().AddPaymentMethod(gomock.Any(), gomock.Any()).DoAndReturn(setID)
+			},
+			expectedDefault: false,
+		},
+		{
+			name:          "Missing token",
+			paymentMethod: &customer.PaymentMethod{Type: "card"},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error(gomock.Any())
+			},
+			expectedError: errors.New("payment method type and token are required"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := customerService.AddPaymentMethod(context.Background(), "customer1", tt.paymentMethod)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "customer1", tt.paymentMethod.CustomerID)
+				assert.Equal(t, tt.expectedDefault, tt.paymentMethod.IsDefault)
+			}
+		})
+	}
+}
+
+func TestCustomerService_RemovePaymentMethod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockCustomerRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	customerService := services.NewCustomerService(mockRepo, mockMerchantRepo, mockPaymentRepo, mockLogger)
+
+	tests := []struct {
+		name          string
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name: "Successful removal",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetPaymentMethod(gomock.Any(), "pm1").Return(&customer.PaymentMethod{ID: "pm1", CustomerID: "customer1"}, nil)
+				mockRepo.EXPECT().DeletePaymentMethod(gomock.Any(), "pm1").Return(nil)
+			},
+		},
+		{
+			name: "Payment method belongs to another customer",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetPaymentMethod(gomock.Any(), "pm1").Return(&customer.PaymentMethod{ID: "pm1", CustomerID: "customer2"}, nil)
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("payment method with id pm1 not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := customerService.RemovePaymentMethod(context.Background(), "customer1", "pm1")
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
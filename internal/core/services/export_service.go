@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	"github.com/popeskul/payment-gateway/internal/core/domain/subscription"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+// exportPageSize is how many rows are read per query while building an export.
+const exportPageSize = 500
+
+type merchantExportService struct {
+	merchantRepo     ports.MerchantRepository
+	memberRepo       ports.MemberRepository
+	apiKeyRepo       ports.APIKeyRepository
+	onboardingRepo   ports.OnboardingRepository
+	customerRepo     ports.CustomerRepository
+	paymentRepo      ports.PaymentRepository
+	refundRepo       ports.RefundRepository
+	subscriptionRepo ports.SubscriptionRepository
+	logger           ports.Logger
+}
+
+func NewMerchantExportService(merchantRepo ports.MerchantRepository, memberRepo ports.MemberRepository, apiKeyRepo ports.APIKeyRepository, onboardingRepo ports.OnboardingRepository, customerRepo ports.CustomerRepository, paymentRepo ports.PaymentRepository, refundRepo ports.RefundRepository, subscriptionRepo ports.SubscriptionRepository, logger ports.Logger) ports.MerchantExportService {
+	return &merchantExportService{
+		merchantRepo:     merchantRepo,
+		memberRepo:       memberRepo,
+		apiKeyRepo:       apiKeyRepo,
+		onboardingRepo:   onboardingRepo,
+		customerRepo:     customerRepo,
+		paymentRepo:      paymentRepo,
+		refundRepo:       refundRepo,
+		subscriptionRepo: subscriptionRepo,
+		logger:           logger,
+	}
+}
+
+// ExportMerchant collects all data held for the merchant, in both modes. It
+// works for deleted merchants too, which is when it is normally used.
+func (s *merchantExportService) ExportMerchant(ctx context.Context, merchantID string) (*merchant.Export, error) {
+	m, err := s.merchantRepo.GetByID(ctx, merchantID)
+	if err != nil {
+		s.logger.Error("merchant not found", "id", merchantID)
+		return nil, fmt.Errorf("merchant with id %s not found", merchantID)
+	}
+
+	export := &merchant.Export{GeneratedAt: time.Now(), Merchant: m}
+
+	if export.Members, err = s.memberRepo.List(ctx, merchantID); err != nil {
+		return nil, s.exportError("members", merchantID, err)
+	}
+	if export.APIKeys, err = s.apiKeyRepo.ListByMerchant(ctx, merchantID); err != nil {
+		return nil, s.exportError("api keys", merchantID, err)
+	}
+	if export.BeneficialOwners, err = s.onboardingRepo.ListOwners(ctx, merchantID); err != nil {
+		return nil, s.exportError("beneficial owners", merchantID, err)
+	}
+	if export.Documents, err = s.onboardingRepo.ListDocuments(ctx, merchantID); err != nil {
+		return nil, s.exportError("documents", merchantID, err)
+	}
+
+	customers, err := allPages(func(limit, offset int) ([]*merchant.ExportedCustomer, error) {
+		page, err := s.customerRepo.List(ctx, merchantID, "", "", limit, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		exported := make([]*merchant.ExportedCustomer, len(page))
+		for i, c := range page {
+			methods, err := s.customerRepo.ListPaymentMethods(ctx, c.ID)
+			if err != nil {
+				return nil, err
+			}
+			exported[i] = &merchant.ExportedCustomer{Customer: c, PaymentMethods: methods}
+		}
+		return exported, nil
+	})
+	if err != nil {
+		return nil, s.exportError("customers", merchantID, err)
+	}
+	export.Customers = customers
+
+	if export.Payments, err = allPages(func(limit, offset int) ([]*payment.Payment, error) {
+		return s.paymentRepo.List(ctx, merchantID, "", limit, offset)
+	}); err != nil {
+		return nil, s.exportError("payments", merchantID, err)
+	}
+	if export.Refunds, err = allPages(func(limit, offset int) ([]*refund.Refund, error) {
+		return s.refundRepo.ListByMerchant(ctx, merchantID, limit, offset)
+	}); err != nil {
+		return nil, s.exportError("refunds", merchantID, err)
+	}
+	if export.Plans, err = allPages(func(limit, offset int) ([]*subscription.Plan, error) {
+		return s.subscriptionRepo.ListPlans(ctx, merchantID, "", limit, offset)
+	}); err != nil {
+		return nil, s.exportError("plans", merchantID, err)
+	}
+	if export.Subscriptions, err = allPages(func(limit, offset int) ([]*subscription.Subscription, error) {
+		return s.subscriptionRepo.List(ctx, merchantID, "", limit, offset)
+	}); err != nil {
+		return nil, s.exportError("subscriptions", merchantID, err)
+	}
+
+	return export, nil
+}
+
+func (s *merchantExportService) exportError(what, merchantID string, err error) error {
+	s.logger.Error("Failed to export merchant data", "error", err, "merchant_id", merchantID, "data", what)
+	return fmt.Errorf("failed to export %s: %w", what, err)
+}
+
+// allPages calls fetch with increasing offsets until a short page comes back.
+func allPages[T any](fetch func(limit, offset int) ([]T, error)) ([]T, error) {
+	var all []T
+	for offset := 0; ; offset += exportPageSize {
+		page, err := fetch(exportPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < exportPageSize {
+			return all, nil
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/core/services"
+)
+
+func TestMerchantExportService_ExportMerchant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockMemberRepo := ports.NewMockMemberRepository(ctrl)
+	mockAPIKeyRepo := ports.NewMockAPIKeyRepository(ctrl)
+	mockOnboardingRepo := ports.NewMockOnboardingRepository(ctrl)
+	mockCustomerRepo := ports.NewMockCustomerRepository(ctrl)
+	mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+	mockRefundRepo := ports.NewMockRefundRepository(ctrl)
+	mockSubscriptionRepo := ports.NewMockSubscriptionRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	exportService := services.NewMerchantExportService(mockMerchantRepo, mockMemberRepo, mockAPIKeyRepo, mockOnboardingRepo,
+		mockCustomerRepo, mockPaymentRepo, mockRefundRepo, mockSubscriptionRepo, mockLogger)
+
+	deletedAt := time.Now()
+	deletedMerchant := &merchant.Merchant{ID: "merchant1", DeletedAt: &deletedAt, DeletionReason: "closed"}
+
+	fullPage := make([]*payment.Payment, 500)
+	for i := range fullPage {
+		fullPage[i] = &payment.Payment{ID: "payment", MerchantID: "merchant1"}
+	}
+
+	tests := []struct {
+		name             string
+		setupMocks       func()
+		expectedPayments int
+		expectedError    error
+	}{
+		{
+			name: "Deleted merchant is exported across pages",
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(deletedMerchant, nil)
+				mockMemberRepo.EXPECT().List(gomock.Any(), "merchant1").Return(nil, nil)
+				mockAPIKeyRepo.EXPECT().ListByMerchant(gomock.Any(), "merchant1").Return(nil, nil)
+				mockOnboardingRepo.EXPECT().ListOwners(gomock.Any(), "merchant1").Return(nil, nil)
+				mockOnboardingRepo.EXPECT().ListDocuments(gomock.Any(), "merchant1").Return(nil, nil)
+				mockCustomerRepo.EXPECT().List(gomock.Any(), "merchant1", mode.Mode(""), "", 500, 0).Return([]*customer.Customer{{ID: "customer1"}}, nil)
+				mockCustomerRepo.EXPECT().ListPaymentMethods(gomock.Any(), "customer1").Return([]*customer.PaymentMethod{{ID: "pm1"}}, nil)
+				mockPaymentRepo.EXPECT().List(gomock.Any(), "merchant1", mode.Mode(""), 500, 0).Return(fullPage, nil)
+				mockPaymentRepo.EXPECT().List(gomock.Any(), "merchant1", mode.Mode(""), 500, 500).Return([]*payment.Payment{{ID: "last"}}, nil)
+				mockRefundRepo.EXPECT().ListByMerchant(gomock.Any(), "merchant1", 500, 0).Return([]*refund.Refund{{ID: "refund1"}}, nil)
+				mockSubscriptionRepo.EXPECT().ListPlans(gomock.Any(), "merchant1", mode.Mode(""), 500, 0).Return(nil, nil)
+				mockSubscriptionRepo.EXPECT().List(gomock.Any(), "merchant1", mode.Mode(""), 500, 0).Return(nil, nil)
+			},
+			expectedPayments: 501,
+			expectedError:    nil,
+		},
+		{
+			name: "Repository failure",
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(deletedMerchant, nil)
+				mockMemberRepo.EXPECT().List(gomock.Any(), "merchant1").Return(nil, errors.New("database error"))
+				mockLogger.EXPECT().Error("Failed to export merchant data", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("failed to export members: database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			export, err := exportService.ExportMerchant(context.Background(), "merchant1")
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, deletedMerchant, export.Merchant)
+				assert.Len(t, export.Payments, tt.expectedPayments)
+				assert.Len(t, export.Customers, 1)
+				assert.Len(t, export.Customers[0].PaymentMethods, 1)
+				assert.Len(t, export.Refunds, 1)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type memberService struct {
+	repo         ports.MemberRepository
+	userRepo     ports.UserRepository
+	merchantRepo ports.MerchantRepository
+	logger       ports.Logger
+
+	mu sync.Mutex
+}
+
+func NewMemberService(repo ports.MemberRepository, userRepo ports.UserRepository, merchantRepo ports.MerchantRepository, logger ports.Logger) ports.MemberService {
+	return &memberService{
+		repo:         repo,
+		userRepo:     userRepo,
+		merchantRepo: merchantRepo,
+		logger:       logger,
+	}
+}
+
+func (s *memberService) Authorize(ctx context.Context, userID, merchantID string, permission member.Permission) error {
+	m, err := s.repo.Get(ctx, merchantID, userID)
+	if err != nil {
+		s.logger.Warn("User is not a member of merchant", "user_id", userID, "merchant_id", merchantID)
+		return member.ErrForbidden
+	}
+
+	if !m.Role.Can(permission) {
+		s.logger.Warn("Role does not grant permission", "user_id", userID, "merchant_id", merchantID, "role", m.Role, "permission", permission)
+		return member.ErrForbidden
+	}
+
+	merch, err := s.merchantRepo.GetByID(ctx, merchantID)
+	if err != nil {
+		return fmt.Errorf("failed to get merchant: %w", err)
+	}
+
+	if merch.ProcessingSettings().RequiresTwoFactor(m.Role) {
+		u, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if !u.TwoFactorEnabled() {
+			s.logger.Warn("Role requires two-factor authentication", "user_id", userID, "merchant_id", merchantID, "role", m.Role)
+			return member.ErrTwoFactorRequired
+		}
+	}
+
+	return nil
+}
+
+func (s *memberService) ListMembers(ctx context.Context, merchantID string) ([]*member.Member, error) {
+	return s.repo.List(ctx, merchantID)
+}
+
+// InviteMember gives an existing user a role on the merchant. Only owners may
+// grant the owner role.
+func (s *memberService) InviteMember(ctx context.Context, merchantID, actorID string, req *member.InviteRequest) (*member.Member, error) {
+	if req == nil || req.Email == "" {
+		s.logger.Error("email is required")
+		return nil, errors.New("email is required")
+	}
+
+	if !req.Role.Valid() {
+		s.logger.Error("invalid role", "role", req.Role)
+		return nil, fmt.Errorf("invalid role %q", req.Role)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	actor, err := s.repo.Get(ctx, merchantID, actorID)
+	if err != nil {
+		return nil, member.ErrForbidden
+	}
+
+	if req.Role == member.RoleOwner && actor.Role != member.RoleOwner {
+		s.logger.Error("only owners can add owners", "actor_id", actorID)
+		return nil, errors.New("only owners can add owners")
+	}
+
+	u, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		s.logger.Error("user not found", "email", req.Email)
+		return nil, fmt.Errorf("no user registered with email %s", req.Email)
+	}
+
+	if _, err := s.repo.Get(ctx, merchantID, u.ID); err == nil {
+		s.logger.Error("user is already a member", "user_id", u.ID)
+		return nil, errors.New("user is already a member of this merchant")
+	}
+
+	m := &member.Member{
+		MerchantID: merchantID,
+		UserID:     u.ID,
+		Email:      u.Email,
+		Role:       req.Role,
+		InvitedBy:  actorID,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if err := s.repo.Add(ctx, m); err != nil {
+		s.logger.Error("Failed to add member", "error", err, "merchant_id", merchantID)
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	}
+
+	return m, nil
+}
+
+// RemoveMember revokes a user's access to the merchant. Owners can only be
+// removed by another owner, and the last owner cannot be removed.
+func (s *memberService) RemoveMember(ctx context.Context, merchantID, actorID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	actor, err := s.repo.Get(ctx, merchantID, actorID)
+	if err != nil {
+		return member.ErrForbidden
+	}
+
+	target, err := s.repo.Get(ctx, merchantID, userID)
+	if err != nil {
+		s.logger.Error("member not found", "user_id", userID, "merchant_id", merchantID)
+		return fmt.Errorf("member %s not found", userID)
+	}
+
+	if target.Role == member.RoleOwner {
+		if actor.Role != member.RoleOwner {
+			s.logger.Error("only owners can remove owners", "actor_id", actorID)
+			return errors.New("only owners can remove owners")
+		}
+
+		owners, err := s.repo.CountByRole(ctx, merchantID, member.RoleOwner)
+		if err != nil {
+			s.logger.Error("Failed to count owners", "error", err, "merchant_id", merchantID)
+			return fmt.Errorf("failed to count owners: %w", err)
+		}
+		if owners <= 1 {
+			s.logger.Error("cannot remove the last owner", "merchant_id", merchantID)
+			return errors.New("cannot remove the last owner")
+		}
+	}
+
+	return s.repo.Remove(ctx, merchantID, userID)
+}
@@ -0,0 +1,229 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/user"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/core/services"
+)
+
+func TestMemberService_Authorize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockMemberRepository(ctrl)
+	mockUserRepo := ports.NewMockUserRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	memberService := services.NewMemberService(mockRepo, mockUserRepo, mockMerchantRepo, mockLogger)
+
+	tests := []struct {
+		name          string
+		permission    member.Permission
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name:       "Role grants permission",
+			permission: member.PermissionRefundsWrite,
+			setupMocks: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), "merchant1", "user1").Return(&member.Member{Role: member.RoleSupport}, nil)
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(&merchant.Merchant{ID: "merchant1"}, nil)
+			},
+		},
+		{
+			name:       "Role requires two-factor authentication",
+			permission: member.PermissionRefundsWrite,
+			setupMocks: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), "merchant1", "user1").Return(&member.Member{Role: member.RoleSupport}, nil)
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(&merchant.Merchant{
+					ID:       "merchant1",
+					Settings: &merchant.Settings{TwoFactorRoles: []member.Role{member.RoleOwner, member.RoleSupport}},
+				}, nil)
+				mockUserRepo.EXPECT().GetByID(gomock.Any(), "user1").Return(&user.User{ID: "user1"}, nil)
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: member.ErrTwoFactorRequired,
+		},
+		{
+			name:       "Role requires two-factor authentication and user has it",
+			permission: member.PermissionRefundsWrite,
+			setupMocks: func() {
+				enabledAt := time.Now()
+				mockRepo.EXPECT().Get(gomock.Any(), "merchant1", "user1").Return(&member.Member{Role: member.RoleSupport}, nil)
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(&merchant.Merchant{
+					ID:       "merchant1",
+					Settings: &merchant.Settings{TwoFactorRoles: []member.Role{member.RoleSupport}},
+				}, nil)
+				mockUserRepo.EXPECT().GetByID(gomock.Any(), "user1").Return(&user.User{ID: "user1", TwoFactorEnabledAt: &enabledAt}, nil)
+			},
+		},
+		{
+			name:       "Role lacks permission",
+			permission: member.PermissionPaymentsWrite,
+			setupMocks: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), "merchant1", "user1").Return(&member.Member{Role: member.RoleSupport}, nil)
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: member.ErrForbidden,
+		},
+		{
+			name:       "Not a member",
+			permission: member.PermissionRead,
+			setupMocks: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), "merchant1", "user1").Return(nil, errors.New("member not found"))
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: member.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := memberService.Authorize(context.Background(), "user1", "merchant1", tt.permission)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMemberService_InviteMember(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockMemberRepository(ctrl)
+	mockUserRepo := ports.NewMockUserRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	memberService := services.NewMemberService(mockRepo, mockUserRepo, mockMerchantRepo, mockLogger)
+
+	tests := []struct {
+		name          string
+		request       *member.InviteRequest
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name:    "Successful invite",
+			request: &member.InviteRequest{Email: "dev@example.com", Role: member.RoleDeveloper},
+			setupMocks: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), "merchant1", "admin1").Return(&member.Member{Role: member.RoleAdmin}, nil)
+				mockUserRepo.EXPECT().GetByEmail(gomock.Any(), "dev@example.com").Return(&user.User{ID: "user2", Email: "dev@example.com"}, nil)
+				mockRepo.EXPECT().Get(gomock.Any(), "merchant1", "user2").Return(nil, errors.New("member not found"))
+				mockRepo.EXPECT().Add(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:    "Invalid role",
+			request: &member.InviteRequest{Email: "dev@example.com", Role: "superuser"},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New(`invalid role "superuser"`),
+		},
+		{
+			name:    "Admin cannot add owners",
+			request: &member.InviteRequest{Email: "dev@example.com", Role: member.RoleOwner},
+			setupMocks: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), "merchant1", "admin1").Return(&member.Member{Role: member.RoleAdmin}, nil)
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("only owners can add owners"),
+		},
+		{
+			name:    "Already a member",
+			request: &member.InviteRequest{Email: "dev@example.com", Role: member.RoleSupport},
+			setupMocks: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), "merchant1", "admin1").Return(&member.Member{Role: member.RoleAdmin}, nil)
+				mockUserRepo.EXPECT().GetByEmail(gomock.Any(), "dev@example.com").Return(&user.User{ID: "user2", Email: "dev@example.com"}, nil)
+				mockRepo.EXPECT().Get(gomock.Any(), "merchant1", "user2").Return(&member.Member{Role: member.RoleReadOnly}, nil)
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("user is already a member of this merchant"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			m, err := memberService.InviteMember(context.Background(), "merchant1", "admin1", tt.request)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "user2", m.UserID)
+				assert.Equal(t, "admin1", m.InvitedBy)
+			}
+		})
+	}
+}
+
+func TestMemberService_RemoveMember(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockMemberRepository(ctrl)
+	mockUserRepo := ports.NewMockUserRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	memberService := services.NewMemberService(mockRepo, mockUserRepo, mockMerchantRepo, mockLogger)
+
+	tests := []struct {
+		name          string
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name: "Successful removal",
+			setupMocks: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), "merchant1", "owner1").Return(&member.Member{Role: member.RoleOwner}, nil)
+				mockRepo.EXPECT().Get(gomock.Any(), "merchant1", "user2").Return(&member.Member{Role: member.RoleDeveloper}, nil)
+				mockRepo.EXPECT().Remove(gomock.Any(), "merchant1", "user2").Return(nil)
+			},
+		},
+		{
+			name: "Last owner cannot be removed",
+			setupMocks: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), "merchant1", "owner1").Return(&member.Member{Role: member.RoleOwner}, nil)
+				mockRepo.EXPECT().Get(gomock.Any(), "merchant1", "user2").Return(&member.Member{Role: member.RoleOwner}, nil)
+				mockRepo.EXPECT().CountByRole(gomock.Any(), "merchant1", member.RoleOwner).Return(1, nil)
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("cannot remove the last owner"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := memberService.RemoveMember(context.Background(), "merchant1", "owner1", "user2")
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -7,30 +7,62 @@ import (
 	"sync"
 	"time"
 
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
 	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/validator"
 )
 
+const defaultSettlementCurrency = "USD"
+
+// initialKeyScopes are the scopes of the keys a merchant is created with, so
+// that it can take payments and refunds in both modes straight away.
+var initialKeyScopes = []apikey.Scope{apikey.ScopePaymentsWrite, apikey.ScopeRefundsWrite}
+
 type merchantService struct {
-	repo   ports.MerchantRepository
-	logger ports.Logger
+	repo             ports.MerchantRepository
+	memberRepo       ports.MemberRepository
+	apiKeyRepo       ports.APIKeyRepository
+	subscriptionRepo ports.SubscriptionRepository
+	oauthRepo        ports.OAuthClientRepository
+	logger           ports.Logger
+	// signingPepper derives the signing secrets of the initial keys, as in
+	// the api key service.
+	signingPepper []byte
 
 	mu sync.RWMutex
 }
 
-func NewMerchantService(repo ports.MerchantRepository, logger ports.Logger) ports.MerchantService {
+func NewMerchantService(repo ports.MerchantRepository, memberRepo ports.MemberRepository, apiKeyRepo ports.APIKeyRepository, subscriptionRepo ports.SubscriptionRepository, oauthRepo ports.OAuthClientRepository, logger ports.Logger, signingPepper string) ports.MerchantService {
 	return &merchantService{
-		repo:   repo,
-		logger: logger,
+		repo:             repo,
+		memberRepo:       memberRepo,
+		apiKeyRepo:       apiKeyRepo,
+		subscriptionRepo: subscriptionRepo,
+		oauthRepo:        oauthRepo,
+		logger:           logger,
+		signingPepper:    []byte(signingPepper),
 	}
 }
 
-func (s *merchantService) CreateMerchant(ctx context.Context, m *merchant.Merchant) error {
+func (s *merchantService) CreateMerchant(ctx context.Context, m *merchant.Merchant, ownerID string) ([]*apikey.CreatedKey, error) {
 	if m == nil {
 		s.logger.Error("merchant cannot be nil")
-		return errors.New("merchant cannot be nil")
+		return nil, errors.New("merchant cannot be nil")
 	}
 
+	if m.SettlementCurrency == "" {
+		m.SettlementCurrency = defaultSettlementCurrency
+	}
+	if !validator.IsCurrency(m.SettlementCurrency) {
+		s.logger.Error("unsupported settlement currency", "currency", m.SettlementCurrency)
+		return nil, fmt.Errorf("unsupported settlement currency %q", m.SettlementCurrency)
+	}
+
+	// New merchants start onboarding and cannot take payments until approved.
+	m.Status = merchant.StatusDraft
 	m.CreatedAt = time.Now()
 	m.UpdatedAt = time.Now()
 
@@ -40,10 +72,45 @@ func (s *merchantService) CreateMerchant(ctx context.Context, m *merchant.Mercha
 	existingMerchant, err := s.repo.GetByEmail(ctx, m.Email)
 	if err == nil && existingMerchant != nil {
 		s.logger.Error("merchant with this email already exists")
-		return errors.New("merchant with this email already exists")
+		return nil, errors.New("merchant with this email already exists")
+	}
+
+	owner := &member.Member{
+		UserID:    ownerID,
+		Role:      member.RoleOwner,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
 	}
 
-	return s.repo.Create(ctx, m)
+	var keys []*apikey.APIKey
+	var secrets []string
+	for _, keyMode := range []mode.Mode{mode.Test, mode.Live} {
+		secret, prefix, err := generateAPIKeySecret(keyMode)
+		if err != nil {
+			s.logger.Error("Failed to generate api key", "error", err)
+			return nil, fmt.Errorf("failed to generate api key: %w", err)
+		}
+		keys = append(keys, &apikey.APIKey{
+			Mode:      keyMode,
+			Name:      fmt.Sprintf("Default %s key", keyMode),
+			Prefix:    prefix,
+			Hash:      hashAPIKey(secret),
+			Scopes:    initialKeyScopes,
+			CreatedBy: ownerID,
+			CreatedAt: m.CreatedAt,
+		})
+		secrets = append(secrets, secret)
+	}
+
+	if err := s.repo.CreateWithOwner(ctx, m, owner, keys); err != nil {
+		return nil, err
+	}
+
+	created := make([]*apikey.CreatedKey, len(keys))
+	for i, k := range keys {
+		created[i] = &apikey.CreatedKey{APIKey: k, Secret: secrets[i], SigningSecret: signingSecret(s.signingPepper, k)}
+	}
+	return created, nil
 }
 
 func (s *merchantService) GetMerchant(ctx context.Context, id string) (*merchant.Merchant, error) {
@@ -68,22 +135,145 @@ func (s *merchantService) UpdateMerchant(ctx context.Context, m *merchant.Mercha
 		return fmt.Errorf("merchant with id %s not found", m.ID)
 	}
 
+	if existing.DeletedAt != nil {
+		s.logger.Error("merchant has been deleted", "id", m.ID)
+		return fmt.Errorf("merchant %s has been deleted", m.ID)
+	}
+
+	if m.SettlementCurrency == "" {
+		m.SettlementCurrency = existing.SettlementCurrency
+	}
+	if m.SettlementCurrency != "" && !validator.IsCurrency(m.SettlementCurrency) {
+		s.logger.Error("unsupported settlement currency", "currency", m.SettlementCurrency)
+		return fmt.Errorf("unsupported settlement currency %q", m.SettlementCurrency)
+	}
+
 	m.CreatedAt = existing.CreatedAt
 	m.UpdatedAt = time.Now()
 
 	return s.repo.Update(ctx, m)
 }
 
-func (s *merchantService) DeleteMerchant(ctx context.Context, id string) error {
+func (s *merchantService) DeleteMerchant(ctx context.Context, id string, req *merchant.DeleteRequest) error {
+	if req == nil || req.Reason == "" {
+		s.logger.Error("deletion reason is required")
+		return errors.New("deletion reason is required")
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	return s.repo.Delete(ctx, id)
+	m, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("merchant not found", "id", id)
+		return fmt.Errorf("merchant with id %s not found", id)
+	}
+
+	if m.DeletedAt != nil {
+		s.logger.Error("merchant has already been deleted", "id", id)
+		return fmt.Errorf("merchant %s has already been deleted", id)
+	}
+
+	now := time.Now()
+	m.DeletedAt = &now
+	m.DeletionReason = req.Reason
+	m.UpdatedAt = now
+
+	// Deleting first stops new payments even if what follows fails.
+	if err := s.repo.SoftDelete(ctx, m); err != nil {
+		s.logger.Error("Failed to delete merchant", "error", err, "id", id)
+		return fmt.Errorf("failed to delete merchant: %w", err)
+	}
+
+	if err := s.apiKeyRepo.RevokeByMerchant(ctx, id, now); err != nil {
+		s.logger.Error("Failed to revoke api keys of deleted merchant", "error", err, "id", id)
+		return fmt.Errorf("failed to revoke api keys: %w", err)
+	}
+
+	if err := s.subscriptionRepo.CancelByMerchant(ctx, id, now, "merchant deleted"); err != nil {
+		s.logger.Error("Failed to cancel subscriptions of deleted merchant", "error", err, "id", id)
+		return fmt.Errorf("failed to cancel subscriptions: %w", err)
+	}
+
+	if err := s.oauthRepo.DeleteGrantsByMerchant(ctx, id); err != nil {
+		s.logger.Error("Failed to delete oauth grants of deleted merchant", "error", err, "id", id)
+		return fmt.Errorf("failed to delete oauth grants: %w", err)
+	}
+
+	return nil
 }
 
-func (s *merchantService) ListMerchants(ctx context.Context, limit, offset int) ([]*merchant.Merchant, error) {
+func (s *merchantService) ListMerchants(ctx context.Context, userID string, limit, offset int) ([]*merchant.Merchant, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return s.repo.List(ctx, limit, offset)
+	return s.repo.ListByUser(ctx, userID, limit, offset)
+}
+
+func (s *merchantService) UpdateSettings(ctx context.Context, merchantID string, settings *merchant.Settings) (*merchant.Merchant, error) {
+	if settings == nil {
+		s.logger.Error("settings cannot be nil")
+		return nil, errors.New("settings cannot be nil")
+	}
+
+	if err := validateSettings(settings); err != nil {
+		s.logger.Error("invalid merchant settings", "error", err, "merchant_id", merchantID)
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.repo.GetByID(ctx, merchantID)
+	if err != nil {
+		s.logger.Error("merchant not found", "id", merchantID)
+		return nil, fmt.Errorf("merchant with id %s not found", merchantID)
+	}
+
+	if m.DeletedAt != nil {
+		s.logger.Error("merchant has been deleted", "id", merchantID)
+		return nil, fmt.Errorf("merchant %s has been deleted", merchantID)
+	}
+
+	m.Settings = settings
+	m.UpdatedAt = time.Now()
+	if err := s.repo.UpdateSettings(ctx, m); err != nil {
+		s.logger.Error("Failed to update merchant settings", "error", err, "merchant_id", merchantID)
+		return nil, fmt.Errorf("failed to update merchant settings: %w", err)
+	}
+
+	return m, nil
+}
+
+func validateSettings(settings *merchant.Settings) error {
+	for _, c := range settings.AllowedCurrencies {
+		if !validator.IsCurrency(c) {
+			return fmt.Errorf("unsupported currency %q", c)
+		}
+	}
+
+	if settings.MinAmount < 0 || settings.MaxAmount < 0 || settings.DailyVolumeLimit < 0 ||
+		settings.MonthlyVolumeLimit < 0 || settings.RefundWindowDays < 0 {
+		return errors.New("limits cannot be negative")
+	}
+
+	if settings.MaxAmount > 0 && settings.MinAmount > settings.MaxAmount {
+		return errors.New("minimum amount cannot be greater than maximum amount")
+	}
+
+	if settings.StatementDescriptor != "" && !validator.IsStatementDescriptor(settings.StatementDescriptor) {
+		return errors.New("statement descriptor must be 5 to 22 characters, contain a letter and none of < > \\ ' \" *")
+	}
+
+	for _, role := range settings.TwoFactorRoles {
+		if !role.Valid() {
+			return fmt.Errorf("invalid role %q", role)
+		}
+	}
+
+	if err := settings.APIKeyIPAllowlist.Validate(); err != nil {
+		return err
+	}
+
+	return nil
 }
@@ -3,13 +3,19 @@ package services_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
 	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
 	"github.com/popeskul/payment-gateway/internal/core/services"
 )
@@ -19,9 +25,13 @@ func TestMerchantService_CreateMerchant(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := ports.NewMockMerchantRepository(ctrl)
+	mockMemberRepo := ports.NewMockMemberRepository(ctrl)
+	mockAPIKeyRepo := ports.NewMockAPIKeyRepository(ctrl)
+	mockSubscriptionRepo := ports.NewMockSubscriptionRepository(ctrl)
+	mockOAuthRepo := ports.NewMockOAuthClientRepository(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 
-	merchantService := services.NewMerchantService(mockRepo, mockLogger)
+	merchantService := services.NewMerchantService(mockRepo, mockMemberRepo, mockAPIKeyRepo, mockSubscriptionRepo, mockOAuthRepo, mockLogger, "pepper")
 
 	tests := []struct {
 		name          string
@@ -37,10 +47,34 @@ func TestMerchantService_CreateMerchant(t *testing.T) {
 			},
 			setupMocks: func() {
 				mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(nil, errors.New("not found"))
-				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateWithOwner(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *merchant.Merchant, m *member.Member, keys []*apikey.APIKey) error {
+					assert.Equal(t, "user1", m.UserID)
+					assert.Equal(t, member.RoleOwner, m.Role)
+					require.Len(t, keys, 2)
+					assert.Equal(t, mode.Test, keys[0].Mode)
+					assert.Equal(t, mode.Live, keys[1].Mode)
+					for i, k := range keys {
+						k.ID = fmt.Sprintf("key%d", i+1)
+						assert.Equal(t, []apikey.Scope{apikey.ScopePaymentsWrite, apikey.ScopeRefundsWrite}, k.Scopes)
+						assert.Equal(t, "user1", k.CreatedBy)
+					}
+					return nil
+				})
 			},
 			expectedError: nil,
 		},
+		{
+			name: "Creation fails",
+			merchant: &merchant.Merchant{
+				Name:  "Test Merchant",
+				Email: "owner@example.com",
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByEmail(gomock.Any(), "owner@example.com").Return(nil, errors.New("not found"))
+				mockRepo.EXPECT().CreateWithOwner(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("failed to add member: database error"))
+			},
+			expectedError: errors.New("failed to add member: database error"),
+		},
 		{
 			name:     "Nil merchant",
 			merchant: nil,
@@ -67,13 +101,23 @@ func TestMerchantService_CreateMerchant(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setupMocks()
 
-			err := merchantService.CreateMerchant(context.Background(), tt.merchant)
+			keys, err := merchantService.CreateMerchant(context.Background(), tt.merchant, "user1")
 
 			if tt.expectedError != nil {
 				assert.EqualError(t, err, tt.expectedError.Error())
+				assert.Nil(t, keys)
 			} else {
 				assert.NoError(t, err)
+				require.Len(t, keys, 2)
+				assert.True(t, strings.HasPrefix(keys[0].Secret, "sk_test_"))
+				assert.True(t, strings.HasPrefix(keys[1].Secret, "sk_live_"))
+				for _, k := range keys {
+					assert.True(t, strings.HasPrefix(k.Secret, k.Prefix), "the stored prefix identifies the secret")
+					assert.True(t, strings.HasPrefix(k.SigningSecret, "ss_"))
+				}
+				assert.NotEqual(t, keys[0].SigningSecret, keys[1].SigningSecret)
 				if tt.merchant != nil {
+					assert.Equal(t, merchant.StatusDraft, tt.merchant.Status)
 					assert.NotZero(t, tt.merchant.CreatedAt)
 					assert.NotZero(t, tt.merchant.UpdatedAt)
 				}
@@ -87,9 +131,13 @@ func TestMerchantService_GetMerchant(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := ports.NewMockMerchantRepository(ctrl)
+	mockMemberRepo := ports.NewMockMemberRepository(ctrl)
+	mockAPIKeyRepo := ports.NewMockAPIKeyRepository(ctrl)
+	mockSubscriptionRepo := ports.NewMockSubscriptionRepository(ctrl)
+	mockOAuthRepo := ports.NewMockOAuthClientRepository(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 
-	merchantService := services.NewMerchantService(mockRepo, mockLogger)
+	merchantService := services.NewMerchantService(mockRepo, mockMemberRepo, mockAPIKeyRepo, mockSubscriptionRepo, mockOAuthRepo, mockLogger, "pepper")
 
 	tests := []struct {
 		name             string
@@ -146,9 +194,13 @@ func TestMerchantService_UpdateMerchant(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := ports.NewMockMerchantRepository(ctrl)
+	mockMemberRepo := ports.NewMockMemberRepository(ctrl)
+	mockAPIKeyRepo := ports.NewMockAPIKeyRepository(ctrl)
+	mockSubscriptionRepo := ports.NewMockSubscriptionRepository(ctrl)
+	mockOAuthRepo := ports.NewMockOAuthClientRepository(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 
-	merchantService := services.NewMerchantService(mockRepo, mockLogger)
+	merchantService := services.NewMerchantService(mockRepo, mockMemberRepo, mockAPIKeyRepo, mockSubscriptionRepo, mockOAuthRepo, mockLogger, "pepper")
 
 	tests := []struct {
 		name          string
@@ -216,31 +268,95 @@ func TestMerchantService_DeleteMerchant(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := ports.NewMockMerchantRepository(ctrl)
+	mockMemberRepo := ports.NewMockMemberRepository(ctrl)
+	mockAPIKeyRepo := ports.NewMockAPIKeyRepository(ctrl)
+	mockSubscriptionRepo := ports.NewMockSubscriptionRepository(ctrl)
+	mockOAuthRepo := ports.NewMockOAuthClientRepository(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 
-	merchantService := services.NewMerchantService(mockRepo, mockLogger)
+	merchantService := services.NewMerchantService(mockRepo, mockMemberRepo, mockAPIKeyRepo, mockSubscriptionRepo, mockOAuthRepo, mockLogger, "pepper")
 
 	tests := []struct {
 		name          string
 		merchantID    string
+		request       *merchant.DeleteRequest
 		setupMocks    func()
 		expectedError error
 	}{
 		{
 			name:       "Successful merchant deletion",
 			merchantID: "merchant123",
+			request:    &merchant.DeleteRequest{Reason: "closing the business"},
 			setupMocks: func() {
-				mockRepo.EXPECT().Delete(gomock.Any(), "merchant123").Return(nil)
+				mockRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{ID: "merchant123", Status: merchant.StatusActive}, nil)
+				mockRepo.EXPECT().SoftDelete(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m *merchant.Merchant) error {
+					assert.NotNil(t, m.DeletedAt)
+					assert.Equal(t, "closing the business", m.DeletionReason)
+					assert.False(t, m.AcceptsPayments(mode.Live))
+					return nil
+				})
+				mockAPIKeyRepo.EXPECT().RevokeByMerchant(gomock.Any(), "merchant123", gomock.Any()).Return(nil)
+				mockSubscriptionRepo.EXPECT().CancelByMerchant(gomock.Any(), "merchant123", gomock.Any(), "merchant deleted").Return(nil)
+				mockOAuthRepo.EXPECT().DeleteGrantsByMerchant(gomock.Any(), "merchant123").Return(nil)
 			},
 			expectedError: nil,
 		},
+		{
+			name:       "Subscriptions not canceled",
+			merchantID: "merchant123",
+			request:    &merchant.DeleteRequest{Reason: "closing the business"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{ID: "merchant123", Status: merchant.StatusActive}, nil)
+				mockRepo.EXPECT().SoftDelete(gomock.Any(), gomock.Any()).Return(nil)
+				mockAPIKeyRepo.EXPECT().RevokeByMerchant(gomock.Any(), "merchant123", gomock.Any()).Return(nil)
+				mockSubscriptionRepo.EXPECT().CancelByMerchant(gomock.Any(), "merchant123", gomock.Any(), "merchant deleted").Return(errors.New("db error"))
+				mockLogger.EXPECT().Error("Failed to cancel subscriptions of deleted merchant", "error", errors.New("db error"), "id", "merchant123")
+			},
+			expectedError: errors.New("failed to cancel subscriptions: db error"),
+		},
+		{
+			name:       "OAuth grants not deleted",
+			merchantID: "merchant123",
+			request:    &merchant.DeleteRequest{Reason: "closing the business"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{ID: "merchant123", Status: merchant.StatusActive}, nil)
+				mockRepo.EXPECT().SoftDelete(gomock.Any(), gomock.Any()).Return(nil)
+				mockAPIKeyRepo.EXPECT().RevokeByMerchant(gomock.Any(), "merchant123", gomock.Any()).Return(nil)
+				mockSubscriptionRepo.EXPECT().CancelByMerchant(gomock.Any(), "merchant123", gomock.Any(), "merchant deleted").Return(nil)
+				mockOAuthRepo.EXPECT().DeleteGrantsByMerchant(gomock.Any(), "merchant123").Return(errors.New("db error"))
+				mockLogger.EXPECT().Error("Failed to delete oauth grants of deleted merchant", "error", errors.New("db error"), "id", "merchant123")
+			},
+			expectedError: errors.New("failed to delete oauth grants: db error"),
+		},
+		{
+			name:       "Missing reason",
+			merchantID: "merchant123",
+			request:    &merchant.DeleteRequest{},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error("deletion reason is required")
+			},
+			expectedError: errors.New("deletion reason is required"),
+		},
+		{
+			name:       "Already deleted",
+			merchantID: "merchant123",
+			request:    &merchant.DeleteRequest{Reason: "duplicate account"},
+			setupMocks: func() {
+				deletedAt := time.Now()
+				mockRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{ID: "merchant123", DeletedAt: &deletedAt}, nil)
+				mockLogger.EXPECT().Error("merchant has already been deleted", "id", "merchant123")
+			},
+			expectedError: errors.New("merchant merchant123 has already been deleted"),
+		},
 		{
 			name:       "Merchant not found",
 			merchantID: "nonexistent",
+			request:    &merchant.DeleteRequest{Reason: "duplicate account"},
 			setupMocks: func() {
-				mockRepo.EXPECT().Delete(gomock.Any(), "nonexistent").Return(errors.New("merchant not found"))
+				mockRepo.EXPECT().GetByID(gomock.Any(), "nonexistent").Return(nil, errors.New("merchant not found"))
+				mockLogger.EXPECT().Error("merchant not found", "id", "nonexistent")
 			},
-			expectedError: errors.New("merchant not found"),
+			expectedError: errors.New("merchant with id nonexistent not found"),
 		},
 	}
 
@@ -248,7 +364,7 @@ func TestMerchantService_DeleteMerchant(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setupMocks()
 
-			err := merchantService.DeleteMerchant(context.Background(), tt.merchantID)
+			err := merchantService.DeleteMerchant(context.Background(), tt.merchantID, tt.request)
 
 			if tt.expectedError != nil {
 				assert.EqualError(t, err, tt.expectedError.Error())
@@ -264,9 +380,13 @@ func TestMerchantService_ListMerchants(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := ports.NewMockMerchantRepository(ctrl)
+	mockMemberRepo := ports.NewMockMemberRepository(ctrl)
+	mockAPIKeyRepo := ports.NewMockAPIKeyRepository(ctrl)
+	mockSubscriptionRepo := ports.NewMockSubscriptionRepository(ctrl)
+	mockOAuthRepo := ports.NewMockOAuthClientRepository(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 
-	merchantService := services.NewMerchantService(mockRepo, mockLogger)
+	merchantService := services.NewMerchantService(mockRepo, mockMemberRepo, mockAPIKeyRepo, mockSubscriptionRepo, mockOAuthRepo, mockLogger, "pepper")
 
 	tests := []struct {
 		name           string
@@ -281,7 +401,7 @@ func TestMerchantService_ListMerchants(t *testing.T) {
 			limit:  10,
 			offset: 0,
 			setupMocks: func() {
-				mockRepo.EXPECT().List(gomock.Any(), 10, 0).Return([]*merchant.Merchant{
+				mockRepo.EXPECT().ListByUser(gomock.Any(), "user1", 10, 0).Return([]*merchant.Merchant{
 					{ID: "merchant1", Name: "Merchant 1"},
 					{ID: "merchant2", Name: "Merchant 2"},
 				}, nil)
@@ -297,7 +417,7 @@ func TestMerchantService_ListMerchants(t *testing.T) {
 			limit:  10,
 			offset: 0,
 			setupMocks: func() {
-				mockRepo.EXPECT().List(gomock.Any(), 10, 0).Return([]*merchant.Merchant{}, nil)
+				mockRepo.EXPECT().ListByUser(gomock.Any(), "user1", 10, 0).Return([]*merchant.Merchant{}, nil)
 			},
 			expectedResult: []*merchant.Merchant{},
 			expectedError:  nil,
@@ -307,7 +427,7 @@ func TestMerchantService_ListMerchants(t *testing.T) {
 			limit:  10,
 			offset: 0,
 			setupMocks: func() {
-				mockRepo.EXPECT().List(gomock.Any(), 10, 0).Return(nil, errors.New("database error"))
+				mockRepo.EXPECT().ListByUser(gomock.Any(), "user1", 10, 0).Return(nil, errors.New("database error"))
 			},
 			expectedResult: nil,
 			expectedError:  errors.New("database error"),
@@ -318,7 +438,7 @@ func TestMerchantService_ListMerchants(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setupMocks()
 
-			merchants, err := merchantService.ListMerchants(context.Background(), tt.limit, tt.offset)
+			merchants, err := merchantService.ListMerchants(context.Background(), "user1", tt.limit, tt.offset)
 
 			if tt.expectedError != nil {
 				assert.EqualError(t, err, tt.expectedError.Error())
@@ -330,3 +450,91 @@ func TestMerchantService_ListMerchants(t *testing.T) {
 		})
 	}
 }
+
+func TestMerchantService_UpdateSettings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockMerchantRepository(ctrl)
+	mockMemberRepo := ports.NewMockMemberRepository(ctrl)
+	mockAPIKeyRepo := ports.NewMockAPIKeyRepository(ctrl)
+	mockSubscriptionRepo := ports.NewMockSubscriptionRepository(ctrl)
+	mockOAuthRepo := ports.NewMockOAuthClientRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	merchantService := services.NewMerchantService(mockRepo, mockMemberRepo, mockAPIKeyRepo, mockSubscriptionRepo, mockOAuthRepo, mockLogger, "pepper")
+
+	deletedAt := time.Now()
+
+	tests := []struct {
+		name          string
+		settings      *merchant.Settings
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name: "Successful settings update",
+			settings: &merchant.Settings{
+				AllowedCurrencies:   []string{"USD", "EUR"},
+				MinAmount:           1,
+				MaxAmount:           5000,
+				RefundWindowDays:    90,
+				StatementDescriptor: "ACME STORE",
+				APIKeyIPAllowlist:   merchant.IPAllowlist{Live: []string{"203.0.113.0/24", "2001:db8::/48"}},
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{ID: "merchant123"}, nil)
+				mockRepo.EXPECT().UpdateSettings(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name:     "Minimum above maximum",
+			settings: &merchant.Settings{MinAmount: 100, MaxAmount: 10},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error("invalid merchant settings", "error", gomock.Any(), "merchant_id", "merchant123")
+			},
+			expectedError: errors.New("minimum amount cannot be greater than maximum amount"),
+		},
+		{
+			name:     "Invalid statement descriptor",
+			settings: &merchant.Settings{StatementDescriptor: "<script>"},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error("invalid merchant settings", "error", gomock.Any(), "merchant_id", "merchant123")
+			},
+			expectedError: errors.New("statement descriptor must be 5 to 22 characters, contain a letter and none of < > \\ ' \" *"),
+		},
+		{
+			name:     "Invalid IP allowlist range",
+			settings: &merchant.Settings{APIKeyIPAllowlist: merchant.IPAllowlist{Test: []string{"203.0.113.7"}}},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error("invalid merchant settings", "error", gomock.Any(), "merchant_id", "merchant123")
+			},
+			expectedError: errors.New(`invalid CIDR range "203.0.113.7"`),
+		},
+		{
+			name:     "Deleted merchant",
+			settings: &merchant.Settings{AutoCapture: true},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{ID: "merchant123", DeletedAt: &deletedAt}, nil)
+				mockLogger.EXPECT().Error("merchant has been deleted", "id", "merchant123")
+			},
+			expectedError: errors.New("merchant merchant123 has been deleted"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			m, err := merchantService.UpdateSettings(context.Background(), "merchant123", tt.settings)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.settings, m.Settings)
+			}
+		})
+	}
+}
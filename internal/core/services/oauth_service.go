@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+// oauthClientSecretPrefix marks client secrets the way sk_ marks API keys.
+const oauthClientSecretPrefix = "cs_"
+
+type oauthService struct {
+	repo   ports.OAuthClientRepository
+	logger ports.Logger
+}
+
+func NewOAuthService(repo ports.OAuthClientRepository, logger ports.Logger) ports.OAuthService {
+	return &oauthService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *oauthService) RegisterClient(ctx context.Context, ownerID string, req *oauth.RegisterClientRequest) (*oauth.RegisteredClient, error) {
+	if req == nil || req.Name == "" {
+		s.logger.Error("oauth client name is required")
+		return nil, errors.New("client name is required")
+	}
+
+	if err := validateOAuthScopes(req.Scopes); err != nil {
+		s.logger.Error("invalid oauth client scopes", "error", err)
+		return nil, err
+	}
+
+	secret, err := generateOAuthClientSecret()
+	if err != nil {
+		s.logger.Error("Failed to generate oauth client secret", "error", err)
+		return nil, fmt.Errorf("failed to generate client secret: %w", err)
+	}
+
+	c := &oauth.Client{
+		OwnerID:    ownerID,
+		Name:       req.Name,
+		SecretHash: hashAPIKey(secret),
+		Scopes:     req.Scopes,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.repo.Create(ctx, c); err != nil {
+		s.logger.Error("Failed to create oauth client", "error", err, "owner_id", ownerID)
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	return &oauth.RegisteredClient{Client: c, Secret: secret}, nil
+}
+
+func (s *oauthService) ListClients(ctx context.Context, ownerID string) ([]*oauth.Client, error) {
+	return s.repo.ListByOwner(ctx, ownerID)
+}
+
+func (s *oauthService) RevokeClient(ctx context.Context, ownerID, clientID string) error {
+	c, err := s.ownedClient(ctx, ownerID, clientID)
+	if err != nil {
+		return err
+	}
+
+	if !c.Active() {
+		return nil
+	}
+
+	if err := s.repo.Revoke(ctx, clientID, time.Now()); err != nil {
+		s.logger.Error("Failed to revoke oauth client", "error", err, "client_id", clientID)
+		return fmt.Errorf("failed to revoke client: %w", err)
+	}
+	return nil
+}
+
+func (s *oauthService) ListClientGrants(ctx context.Context, ownerID, clientID string) ([]*oauth.Grant, error) {
+	if _, err := s.ownedClient(ctx, ownerID, clientID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListGrantsByClient(ctx, clientID)
+}
+
+func (s *oauthService) GrantClient(ctx context.Context, merchantID, actorID string, req *oauth.GrantRequest) (*oauth.Grant, error) {
+	if req == nil || req.ClientID == "" {
+		s.logger.Error("oauth grant client id is required")
+		return nil, errors.New("client_id is required")
+	}
+
+	if err := validateOAuthScopes(req.Scopes); err != nil {
+		s.logger.Error("invalid oauth grant scopes", "error", err)
+		return nil, err
+	}
+
+	c, err := s.repo.GetByID(ctx, req.ClientID)
+	if err != nil || !c.Active() {
+		s.logger.Error("oauth client not found", "client_id", req.ClientID)
+		return nil, oauth.ErrClientNotFound
+	}
+
+	g := &oauth.Grant{
+		ClientID:   c.ID,
+		MerchantID: merchantID,
+		Scopes:     req.Scopes,
+		GrantedBy:  actorID,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.repo.SaveGrant(ctx, g); err != nil {
+		s.logger.Error("Failed to save oauth grant", "error", err, "client_id", c.ID, "merchant_id", merchantID)
+		return nil, fmt.Errorf("failed to grant access: %w", err)
+	}
+
+	return g, nil
+}
+
+func (s *oauthService) ListGrants(ctx context.Context, merchantID string) ([]*oauth.Grant, error) {
+	return s.repo.ListGrantsByMerchant(ctx, merchantID)
+}
+
+func (s *oauthService) RevokeGrant(ctx context.Context, merchantID, clientID string) error {
+	return s.repo.DeleteGrant(ctx, clientID, merchantID)
+}
+
+func (s *oauthService) AuthenticateClient(ctx context.Context, clientID, secret string) (*oauth.Client, error) {
+	c, err := s.repo.GetByID(ctx, clientID)
+	if err != nil {
+		return nil, oauth.ErrInvalidClient
+	}
+
+	if subtle.ConstantTimeCompare([]byte(c.SecretHash), []byte(hashAPIKey(secret))) != 1 {
+		return nil, oauth.ErrInvalidClient
+	}
+
+	if !c.Active() {
+		s.logger.Warn("Revoked oauth client used", "client_id", clientID)
+		return nil, oauth.ErrInvalidClient
+	}
+
+	return c, nil
+}
+
+func (s *oauthService) Authorize(ctx context.Context, clientID, merchantID string, permission member.Permission) error {
+	c, err := s.repo.GetByID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, oauth.ErrClientNotFound) {
+			return member.ErrForbidden
+		}
+		return err
+	}
+	if !c.Active() {
+		return member.ErrForbidden
+	}
+
+	g, err := s.repo.GetGrant(ctx, clientID, merchantID)
+	if err != nil {
+		if errors.Is(err, oauth.ErrGrantNotFound) {
+			return member.ErrForbidden
+		}
+		return err
+	}
+	if !g.Allows(permission) {
+		return member.ErrForbidden
+	}
+
+	return nil
+}
+
+func (s *oauthService) ownedClient(ctx context.Context, ownerID, clientID string) (*oauth.Client, error) {
+	c, err := s.repo.GetByID(ctx, clientID)
+	if err != nil || c.OwnerID != ownerID {
+		s.logger.Error("oauth client not found", "client_id", clientID, "owner_id", ownerID)
+		return nil, oauth.ErrClientNotFound
+	}
+	return c, nil
+}
+
+func validateOAuthScopes(scopes []oauth.Scope) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", oauth.ErrInvalidScope)
+	}
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return fmt.Errorf("%w: %q", oauth.ErrInvalidScope, scope)
+		}
+	}
+	return nil
+}
+
+func generateOAuthClientSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return oauthClientSecretPrefix + hex.EncodeToString(b), nil
+}
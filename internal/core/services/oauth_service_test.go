@@ -0,0 +1,252 @@
+package services_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/core/services"
+)
+
+func TestOAuthService_RegisterClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockOAuthClientRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	oauthService := services.NewOAuthService(mockRepo, mockLogger)
+
+	tests := []struct {
+		name          string
+		request       *oauth.RegisterClientRequest
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name:    "Successful registration",
+			request: &oauth.RegisterClientRequest{Name: "Accounting sync", Scopes: []oauth.Scope{oauth.ScopeRead}},
+			setupMocks: func() {
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:    "Missing name",
+			request: &oauth.RegisterClientRequest{Scopes: []oauth.Scope{oauth.ScopeRead}},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error(gomock.Any())
+			},
+			expectedError: errors.New("client name is required"),
+		},
+		{
+			name:    "Unknown scope",
+			request: &oauth.RegisterClientRequest{Name: "Accounting sync", Scopes: []oauth.Scope{"api_keys:manage"}},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: oauth.ErrInvalidScope,
+		},
+		{
+			name:    "No scopes",
+			request: &oauth.RegisterClientRequest{Name: "Accounting sync"},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: oauth.ErrInvalidScope,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			client, err := oauthService.RegisterClient(context.Background(), "user1", tt.request)
+
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				if errors.Is(tt.expectedError, oauth.ErrInvalidScope) {
+					assert.ErrorIs(t, err, oauth.ErrInvalidScope)
+				} else {
+					assert.EqualError(t, err, tt.expectedError.Error())
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(client.Secret, "cs_"))
+			assert.Equal(t, "user1", client.OwnerID)
+
+			sum := sha256.Sum256([]byte(client.Secret))
+			assert.Equal(t, hex.EncodeToString(sum[:]), client.SecretHash, "only a hash of the secret is stored")
+		})
+	}
+}
+
+func TestOAuthService_AuthenticateClient(t *testing.T) {
+	secret := "cs_secret"
+	sum := sha256.Sum256([]byte(secret))
+	revokedAt := time.Now()
+
+	tests := []struct {
+		name          string
+		secret        string
+		stored        *oauth.Client
+		getErr        error
+		expectedError error
+	}{
+		{
+			name:   "Valid secret",
+			secret: secret,
+			stored: &oauth.Client{ID: "client1", SecretHash: hex.EncodeToString(sum[:])},
+		},
+		{
+			name:          "Wrong secret",
+			secret:        "cs_other",
+			stored:        &oauth.Client{ID: "client1", SecretHash: hex.EncodeToString(sum[:])},
+			expectedError: oauth.ErrInvalidClient,
+		},
+		{
+			name:          "Revoked client",
+			secret:        secret,
+			stored:        &oauth.Client{ID: "client1", SecretHash: hex.EncodeToString(sum[:]), RevokedAt: &revokedAt},
+			expectedError: oauth.ErrInvalidClient,
+		},
+		{
+			name:          "Unknown client",
+			secret:        secret,
+			getErr:        oauth.ErrClientNotFound,
+			expectedError: oauth.ErrInvalidClient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := ports.NewMockOAuthClientRepository(ctrl)
+			mockLogger := ports.NewMockLogger(ctrl)
+			mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+			mockRepo.EXPECT().GetByID(gomock.Any(), "client1").Return(tt.stored, tt.getErr)
+
+			client, err := services.NewOAuthService(mockRepo, mockLogger).AuthenticateClient(context.Background(), "client1", tt.secret)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "client1", client.ID)
+		})
+	}
+}
+
+func TestOAuthService_Authorize(t *testing.T) {
+	revokedAt := time.Now()
+	grant := &oauth.Grant{ClientID: "client1", MerchantID: "merchant1", Scopes: []oauth.Scope{oauth.ScopeRead, oauth.ScopeRefundsWrite}}
+
+	tests := []struct {
+		name          string
+		permission    member.Permission
+		client        *oauth.Client
+		grant         *oauth.Grant
+		grantErr      error
+		expectedError error
+	}{
+		{
+			name:       "Granted scope",
+			permission: member.PermissionRefundsWrite,
+			client:     &oauth.Client{ID: "client1"},
+			grant:      grant,
+		},
+		{
+			name:          "Scope outside the grant",
+			permission:    member.PermissionPaymentsWrite,
+			client:        &oauth.Client{ID: "client1"},
+			grant:         grant,
+			expectedError: member.ErrForbidden,
+		},
+		{
+			name:          "No grant on the merchant",
+			permission:    member.PermissionRead,
+			client:        &oauth.Client{ID: "client1"},
+			grantErr:      oauth.ErrGrantNotFound,
+			expectedError: member.ErrForbidden,
+		},
+		{
+			name:          "Revoked client",
+			permission:    member.PermissionRead,
+			client:        &oauth.Client{ID: "client1", RevokedAt: &revokedAt},
+			expectedError: member.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := ports.NewMockOAuthClientRepository(ctrl)
+			mockRepo.EXPECT().GetByID(gomock.Any(), "client1").Return(tt.client, nil)
+			if tt.client.Active() {
+				mockRepo.EXPECT().GetGrant(gomock.Any(), "client1", "merchant1").Return(tt.grant, tt.grantErr)
+			}
+
+			err := services.NewOAuthService(mockRepo, ports.NewMockLogger(ctrl)).Authorize(context.Background(), "client1", "merchant1", tt.permission)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestOAuthService_GrantClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockOAuthClientRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+	oauthService := services.NewOAuthService(mockRepo, mockLogger)
+
+	t.Run("Saves the grant", func(t *testing.T) {
+		mockRepo.EXPECT().GetByID(gomock.Any(), "client1").Return(&oauth.Client{ID: "client1"}, nil)
+		mockRepo.EXPECT().SaveGrant(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, g *oauth.Grant) error {
+			assert.Equal(t, "merchant1", g.MerchantID)
+			assert.Equal(t, "user1", g.GrantedBy)
+			return nil
+		})
+
+		g, err := oauthService.GrantClient(context.Background(), "merchant1", "user1", &oauth.GrantRequest{
+			ClientID: "client1",
+			Scopes:   []oauth.Scope{oauth.ScopeRead},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "client1", g.ClientID)
+	})
+
+	t.Run("Revoked client", func(t *testing.T) {
+		revokedAt := time.Now()
+		mockRepo.EXPECT().GetByID(gomock.Any(), "client1").Return(&oauth.Client{ID: "client1", RevokedAt: &revokedAt}, nil)
+		mockLogger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		_, err := oauthService.GrantClient(context.Background(), "merchant1", "user1", &oauth.GrantRequest{
+			ClientID: "client1",
+			Scopes:   []oauth.Scope{oauth.ScopeRead},
+		})
+		assert.ErrorIs(t, err, oauth.ErrClientNotFound)
+	})
+}
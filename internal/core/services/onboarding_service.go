@@ -0,0 +1,342 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+// allowedDocumentTypes are the content types accepted for KYC uploads.
+var allowedDocumentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+}
+
+type onboardingService struct {
+	merchantRepo    ports.MerchantRepository
+	repo            ports.OnboardingRepository
+	fileStore       ports.FileStore
+	logger          ports.Logger
+	maxDocumentSize int64
+
+	mu sync.Mutex
+}
+
+func NewOnboardingService(merchantRepo ports.MerchantRepository, repo ports.OnboardingRepository, fileStore ports.FileStore, logger ports.Logger, maxDocumentSize int64) ports.OnboardingService {
+	return &onboardingService{
+		merchantRepo:    merchantRepo,
+		repo:            repo,
+		fileStore:       fileStore,
+		logger:          logger,
+		maxDocumentSize: maxDocumentSize,
+	}
+}
+
+func (s *onboardingService) UpdateBusinessDetails(ctx context.Context, merchantID string, details *merchant.BusinessDetails) (*merchant.Merchant, error) {
+	if details == nil {
+		s.logger.Error("business details cannot be nil")
+		return nil, errors.New("business details cannot be nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.editableMerchant(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.BusinessDetails = details
+	m.UpdatedAt = time.Now()
+	if err := s.merchantRepo.UpdateOnboarding(ctx, m); err != nil {
+		s.logger.Error("Failed to update business details", "error", err, "merchant_id", merchantID)
+		return nil, fmt.Errorf("failed to update business details: %w", err)
+	}
+
+	return m, nil
+}
+
+func (s *onboardingService) AddOwner(ctx context.Context, merchantID string, o *merchant.BeneficialOwner) error {
+	if o == nil || o.FullName == "" || o.Nationality == "" {
+		s.logger.Error("owner name and nationality are required")
+		return errors.New("owner name and nationality are required")
+	}
+
+	if _, err := time.Parse("2006-01-02", o.DateOfBirth); err != nil {
+		s.logger.Error("invalid date of birth", "date_of_birth", o.DateOfBirth)
+		return errors.New("date of birth must be formatted as YYYY-MM-DD")
+	}
+
+	if o.OwnershipPercent <= 0 || o.OwnershipPercent > 100 {
+		s.logger.Error("invalid ownership percent", "ownership_percent", o.OwnershipPercent)
+		return errors.New("ownership percent must be between 0 and 100")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.editableMerchant(ctx, merchantID); err != nil {
+		return err
+	}
+
+	owners, err := s.repo.ListOwners(ctx, merchantID)
+	if err != nil {
+		s.logger.Error("Failed to list beneficial owners", "error", err, "merchant_id", merchantID)
+		return fmt.Errorf("failed to list beneficial owners: %w", err)
+	}
+
+	total := o.OwnershipPercent
+	for _, existing := range owners {
+		total += existing.OwnershipPercent
+	}
+	if total > 100 {
+		s.logger.Error("total ownership exceeds 100 percent", "merchant_id", merchantID)
+		return errors.New("total ownership cannot exceed 100 percent")
+	}
+
+	o.ID = ""
+	o.MerchantID = merchantID
+	o.CreatedAt = time.Now()
+
+	return s.repo.AddOwner(ctx, o)
+}
+
+func (s *onboardingService) ListOwners(ctx context.Context, merchantID string) ([]*merchant.BeneficialOwner, error) {
+	return s.repo.ListOwners(ctx, merchantID)
+}
+
+func (s *onboardingService) RemoveOwner(ctx context.Context, merchantID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.editableMerchant(ctx, merchantID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteOwner(ctx, merchantID, id)
+}
+
+// UploadDocument stores the file first and then records it, removing the file
+// again if the record cannot be written.
+func (s *onboardingService) UploadDocument(ctx context.Context, d *merchant.Document, content io.Reader) error {
+	if d == nil || content == nil {
+		s.logger.Error("document cannot be nil")
+		return errors.New("document cannot be nil")
+	}
+
+	if !d.Type.Valid() {
+		s.logger.Error("invalid document type", "type", d.Type)
+		return fmt.Errorf("invalid document type %q", d.Type)
+	}
+
+	if !allowedDocumentTypes[d.ContentType] {
+		s.logger.Error("unsupported document content type", "content_type", d.ContentType)
+		return fmt.Errorf("unsupported content type %q", d.ContentType)
+	}
+
+	if d.Size <= 0 || d.Size > s.maxDocumentSize {
+		s.logger.Error("invalid document size", "size", d.Size)
+		return fmt.Errorf("document must be between 1 and %d bytes", s.maxDocumentSize)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.editableMerchant(ctx, d.MerchantID); err != nil {
+		return err
+	}
+
+	suffix, err := randomHex(16)
+	if err != nil {
+		s.logger.Error("Failed to generate storage key", "error", err)
+		return fmt.Errorf("failed to generate storage key: %w", err)
+	}
+	d.StorageKey = fmt.Sprintf("merchants/%s/documents/%s", d.MerchantID, suffix)
+	d.CreatedAt = time.Now()
+
+	// Never read more than declared, so a wrong size cannot bypass the limit.
+	if err := s.fileStore.Put(ctx, d.StorageKey, io.LimitReader(content, d.Size)); err != nil {
+		s.logger.Error("Failed to store document", "error", err, "merchant_id", d.MerchantID)
+		return fmt.Errorf("failed to store document: %w", err)
+	}
+
+	if err := s.repo.AddDocument(ctx, d); err != nil {
+		s.logger.Error("Failed to record document", "error", err, "merchant_id", d.MerchantID)
+		if deleteErr := s.fileStore.Delete(ctx, d.StorageKey); deleteErr != nil {
+			s.logger.Error("Failed to remove orphaned document", "error", deleteErr, "key", d.StorageKey)
+		}
+		return fmt.Errorf("failed to record document: %w", err)
+	}
+
+	return nil
+}
+
+func (s *onboardingService) ListDocuments(ctx context.Context, merchantID string) ([]*merchant.Document, error) {
+	return s.repo.ListDocuments(ctx, merchantID)
+}
+
+func (s *onboardingService) OpenDocument(ctx context.Context, merchantID, id string) (*merchant.Document, io.ReadCloser, error) {
+	d, err := s.repo.GetDocument(ctx, id)
+	if err != nil || d.MerchantID != merchantID {
+		s.logger.Error("document not found", "id", id, "merchant_id", merchantID)
+		return nil, nil, fmt.Errorf("document with id %s not found", id)
+	}
+
+	content, err := s.fileStore.Get(ctx, d.StorageKey)
+	if err != nil {
+		s.logger.Error("Failed to read document", "error", err, "id", id)
+		return nil, nil, fmt.Errorf("failed to read document: %w", err)
+	}
+
+	return d, content, nil
+}
+
+// Submit sends the application for review once the business details, at
+// least one beneficial owner and one document are on file.
+func (s *onboardingService) Submit(ctx context.Context, merchantID string) (*merchant.Merchant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.editableMerchant(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !m.BusinessDetails.Complete() {
+		s.logger.Error("business details are incomplete", "merchant_id", merchantID)
+		return nil, errors.New("business details are incomplete")
+	}
+
+	owners, err := s.repo.ListOwners(ctx, merchantID)
+	if err != nil {
+		s.logger.Error("Failed to list beneficial owners", "error", err, "merchant_id", merchantID)
+		return nil, fmt.Errorf("failed to list beneficial owners: %w", err)
+	}
+	if len(owners) == 0 {
+		s.logger.Error("no beneficial owners", "merchant_id", merchantID)
+		return nil, errors.New("at least one beneficial owner is required")
+	}
+
+	documents, err := s.repo.ListDocuments(ctx, merchantID)
+	if err != nil {
+		s.logger.Error("Failed to list documents", "error", err, "merchant_id", merchantID)
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	if len(documents) == 0 {
+		s.logger.Error("no documents", "merchant_id", merchantID)
+		return nil, errors.New("at least one document is required")
+	}
+
+	now := time.Now()
+	m.SubmittedAt = &now
+	m.StatusReason = ""
+
+	return m, s.transition(ctx, m, merchant.StatusSubmitted)
+}
+
+func (s *onboardingService) ListByStatus(ctx context.Context, status merchant.Status, limit, offset int) ([]*merchant.Merchant, error) {
+	return s.merchantRepo.ListByStatus(ctx, status, limit, offset)
+}
+
+func (s *onboardingService) StartReview(ctx context.Context, merchantID, reviewerID string) (*merchant.Merchant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.getMerchant(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.ReviewedBy = reviewerID
+
+	return m, s.transition(ctx, m, merchant.StatusInReview)
+}
+
+func (s *onboardingService) Decide(ctx context.Context, merchantID, reviewerID string, decision *merchant.ReviewDecision) (*merchant.Merchant, error) {
+	if decision == nil {
+		s.logger.Error("decision cannot be nil")
+		return nil, errors.New("decision cannot be nil")
+	}
+
+	if (decision.Status == merchant.StatusRejected || decision.Status == merchant.StatusSuspended) && decision.Reason == "" {
+		s.logger.Error("reason is required", "status", decision.Status)
+		return nil, fmt.Errorf("a reason is required to move a merchant to %s", decision.Status)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.getMerchant(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	m.ReviewedAt = &now
+	m.ReviewedBy = reviewerID
+	m.StatusReason = decision.Reason
+
+	return m, s.transition(ctx, m, decision.Status)
+}
+
+func (s *onboardingService) transition(ctx context.Context, m *merchant.Merchant, to merchant.Status) error {
+	if !m.Status.CanTransitionTo(to) {
+		s.logger.Error("invalid merchant status transition", "from", m.Status, "to", to, "merchant_id", m.ID)
+		return fmt.Errorf("cannot move merchant from %s to %s", m.Status, to)
+	}
+
+	m.Status = to
+	m.UpdatedAt = time.Now()
+	if err := s.merchantRepo.UpdateOnboarding(ctx, m); err != nil {
+		s.logger.Error("Failed to update merchant status", "error", err, "merchant_id", m.ID)
+		return fmt.Errorf("failed to update merchant status: %w", err)
+	}
+
+	return nil
+}
+
+func (s *onboardingService) getMerchant(ctx context.Context, merchantID string) (*merchant.Merchant, error) {
+	m, err := s.merchantRepo.GetByID(ctx, merchantID)
+	if err != nil {
+		s.logger.Error("merchant not found", "id", merchantID)
+		return nil, fmt.Errorf("merchant with id %s not found", merchantID)
+	}
+
+	if m.DeletedAt != nil {
+		s.logger.Error("merchant has been deleted", "id", merchantID)
+		return nil, fmt.Errorf("merchant %s has been deleted", merchantID)
+	}
+	return m, nil
+}
+
+// editableMerchant returns the merchant if its application can still be
+// changed, i.e. it has not been submitted or it was rejected.
+func (s *onboardingService) editableMerchant(ctx context.Context, merchantID string) (*merchant.Merchant, error) {
+	m, err := s.getMerchant(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !m.Status.Editable() {
+		s.logger.Error("merchant application cannot be changed", "merchant_id", merchantID, "status", m.Status)
+		return nil, fmt.Errorf("application cannot be changed while the merchant is %s", m.Status)
+	}
+	return m, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
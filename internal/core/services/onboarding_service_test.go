@@ -0,0 +1,334 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/core/services"
+)
+
+func completeBusinessDetails() *merchant.BusinessDetails {
+	return &merchant.BusinessDetails{
+		LegalName:          "Test Merchant Ltd",
+		RegistrationNumber: "12345678",
+		BusinessType:       "limited_company",
+		Country:            "GB",
+		Address:            "1 High Street, London",
+	}
+}
+
+func TestOnboardingService_AddOwner(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockRepo := ports.NewMockOnboardingRepository(ctrl)
+	mockFileStore := ports.NewMockFileStore(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	onboardingService := services.NewOnboardingService(mockMerchantRepo, mockRepo, mockFileStore, mockLogger, 1024)
+
+	validOwner := func(percent float64) *merchant.BeneficialOwner {
+		return &merchant.BeneficialOwner{
+			FullName:         "Jane Doe",
+			DateOfBirth:      "1980-01-31",
+			Nationality:      "GB",
+			OwnershipPercent: percent,
+		}
+	}
+
+	tests := []struct {
+		name          string
+		owner         *merchant.BeneficialOwner
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name:  "Successful owner creation",
+			owner: validOwner(60),
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(&merchant.Merchant{ID: "merchant1", Status: merchant.StatusDraft}, nil)
+				mockRepo.EXPECT().ListOwners(gomock.Any(), "merchant1").Return([]*merchant.BeneficialOwner{{OwnershipPercent: 40}}, nil)
+				mockRepo.EXPECT().AddOwner(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *merchant.BeneficialOwner) error {
+					assert.Equal(t, "merchant1", o.MerchantID)
+					return nil
+				})
+			},
+			expectedError: nil,
+		},
+		{
+			name:  "Total ownership above 100 percent",
+			owner: validOwner(70),
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(&merchant.Merchant{ID: "merchant1", Status: merchant.StatusDraft}, nil)
+				mockRepo.EXPECT().ListOwners(gomock.Any(), "merchant1").Return([]*merchant.BeneficialOwner{{OwnershipPercent: 40}}, nil)
+				mockLogger.EXPECT().Error("total ownership exceeds 100 percent", "merchant_id", "merchant1")
+			},
+			expectedError: errors.New("total ownership cannot exceed 100 percent"),
+		},
+		{
+			name:  "Invalid date of birth",
+			owner: &merchant.BeneficialOwner{FullName: "Jane Doe", DateOfBirth: "31/01/1980", Nationality: "GB", OwnershipPercent: 50},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error("invalid date of birth", "date_of_birth", "31/01/1980")
+			},
+			expectedError: errors.New("date of birth must be formatted as YYYY-MM-DD"),
+		},
+		{
+			name:  "Application already submitted",
+			owner: validOwner(50),
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(&merchant.Merchant{ID: "merchant1", Status: merchant.StatusSubmitted}, nil)
+				mockLogger.EXPECT().Error("merchant application cannot be changed", "merchant_id", "merchant1", "status", merchant.StatusSubmitted)
+			},
+			expectedError: errors.New("application cannot be changed while the merchant is submitted"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := onboardingService.AddOwner(context.Background(), "merchant1", tt.owner)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestOnboardingService_UploadDocument(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockRepo := ports.NewMockOnboardingRepository(ctrl)
+	mockFileStore := ports.NewMockFileStore(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	onboardingService := services.NewOnboardingService(mockMerchantRepo, mockRepo, mockFileStore, mockLogger, 1024)
+
+	tests := []struct {
+		name          string
+		document      *merchant.Document
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name: "Successful upload",
+			document: &merchant.Document{
+				MerchantID: "merchant1", Type: merchant.DocumentIncorporation, FileName: "cert.pdf",
+				ContentType: "application/pdf", Size: 512,
+			},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(&merchant.Merchant{ID: "merchant1", Status: merchant.StatusDraft}, nil)
+				mockFileStore.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key string, _ interface{}) error {
+					assert.True(t, strings.HasPrefix(key, "merchants/merchant1/documents/"))
+					return nil
+				})
+				mockRepo.EXPECT().AddDocument(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "File removed when the record fails",
+			document: &merchant.Document{
+				MerchantID: "merchant1", Type: merchant.DocumentProofAddress, FileName: "bill.png",
+				ContentType: "image/png", Size: 512,
+			},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(&merchant.Merchant{ID: "merchant1", Status: merchant.StatusRejected}, nil)
+				mockFileStore.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().AddDocument(gomock.Any(), gomock.Any()).Return(errors.New("database error"))
+				mockLogger.EXPECT().Error("Failed to record document", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				mockFileStore.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedError: errors.New("failed to record document: database error"),
+		},
+		{
+			name: "Unsupported content type",
+			document: &merchant.Document{
+				MerchantID: "merchant1", Type: merchant.DocumentOther, FileName: "notes.txt",
+				ContentType: "text/plain", Size: 10,
+			},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error("unsupported document content type", "content_type", "text/plain")
+			},
+			expectedError: errors.New(`unsupported content type "text/plain"`),
+		},
+		{
+			name: "Document too large",
+			document: &merchant.Document{
+				MerchantID: "merchant1", Type: merchant.DocumentBankStatement, FileName: "statement.pdf",
+				ContentType: "application/pdf", Size: 2048,
+			},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error("invalid document size", "size", int64(2048))
+			},
+			expectedError: errors.New("document must be between 1 and 1024 bytes"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := onboardingService.UploadDocument(context.Background(), tt.document, strings.NewReader("content"))
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, tt.document.StorageKey)
+			}
+		})
+	}
+}
+
+func TestOnboardingService_Submit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockRepo := ports.NewMockOnboardingRepository(ctrl)
+	mockFileStore := ports.NewMockFileStore(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	onboardingService := services.NewOnboardingService(mockMerchantRepo, mockRepo, mockFileStore, mockLogger, 1024)
+
+	tests := []struct {
+		name          string
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name: "Successful submission",
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(&merchant.Merchant{
+					ID: "merchant1", Status: merchant.StatusRejected, StatusReason: "missing proof of address",
+					BusinessDetails: completeBusinessDetails(),
+				}, nil)
+				mockRepo.EXPECT().ListOwners(gomock.Any(), "merchant1").Return([]*merchant.BeneficialOwner{{ID: "owner1"}}, nil)
+				mockRepo.EXPECT().ListDocuments(gomock.Any(), "merchant1").Return([]*merchant.Document{{ID: "doc1"}}, nil)
+				mockMerchantRepo.EXPECT().UpdateOnboarding(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m *merchant.Merchant) error {
+					assert.Equal(t, merchant.StatusSubmitted, m.Status)
+					assert.Empty(t, m.StatusReason)
+					assert.NotNil(t, m.SubmittedAt)
+					return nil
+				})
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Incomplete business details",
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(&merchant.Merchant{
+					ID: "merchant1", Status: merchant.StatusDraft,
+				}, nil)
+				mockLogger.EXPECT().Error("business details are incomplete", "merchant_id", "merchant1")
+			},
+			expectedError: errors.New("business details are incomplete"),
+		},
+		{
+			name: "No documents uploaded",
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(&merchant.Merchant{
+					ID: "merchant1", Status: merchant.StatusDraft, BusinessDetails: completeBusinessDetails(),
+				}, nil)
+				mockRepo.EXPECT().ListOwners(gomock.Any(), "merchant1").Return([]*merchant.BeneficialOwner{{ID: "owner1"}}, nil)
+				mockRepo.EXPECT().ListDocuments(gomock.Any(), "merchant1").Return(nil, nil)
+				mockLogger.EXPECT().Error("no documents", "merchant_id", "merchant1")
+			},
+			expectedError: errors.New("at least one document is required"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			_, err := onboardingService.Submit(context.Background(), "merchant1")
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestOnboardingService_Decide(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockRepo := ports.NewMockOnboardingRepository(ctrl)
+	mockFileStore := ports.NewMockFileStore(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	onboardingService := services.NewOnboardingService(mockMerchantRepo, mockRepo, mockFileStore, mockLogger, 1024)
+
+	tests := []struct {
+		name           string
+		decision       *merchant.ReviewDecision
+		setupMocks     func()
+		expectedStatus merchant.Status
+		expectedError  error
+	}{
+		{
+			name:     "Approve merchant in review",
+			decision: &merchant.ReviewDecision{Status: merchant.StatusActive},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(&merchant.Merchant{ID: "merchant1", Status: merchant.StatusInReview}, nil)
+				mockMerchantRepo.EXPECT().UpdateOnboarding(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedStatus: merchant.StatusActive,
+			expectedError:  nil,
+		},
+		{
+			name:     "Rejection without a reason",
+			decision: &merchant.ReviewDecision{Status: merchant.StatusRejected},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error("reason is required", "status", merchant.StatusRejected)
+			},
+			expectedError: errors.New("a reason is required to move a merchant to rejected"),
+		},
+		{
+			name:     "Approve merchant that was never reviewed",
+			decision: &merchant.ReviewDecision{Status: merchant.StatusActive},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant1").Return(&merchant.Merchant{ID: "merchant1", Status: merchant.StatusSubmitted}, nil)
+				mockLogger.EXPECT().Error("invalid merchant status transition", "from", merchant.StatusSubmitted, "to", merchant.StatusActive, "merchant_id", "merchant1")
+			},
+			expectedError: errors.New("cannot move merchant from submitted to active"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			m, err := onboardingService.Decide(context.Background(), "merchant1", "staff1", tt.decision)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, m.Status)
+				assert.Equal(t, "staff1", m.ReviewedBy)
+				assert.NotNil(t, m.ReviewedAt)
+			}
+		})
+	}
+}
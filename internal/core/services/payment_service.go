@@ -7,22 +7,37 @@ import (
 	"sync"
 	"time"
 
+	"github.com/popeskul/payment-gateway/internal/core/domain/currency"
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
 	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/validator"
 )
 
+// authorizationValidity is how long the acquirer holds funds for an
+// authorization that has not been captured.
+const authorizationValidity = 7 * 24 * time.Hour
+
 type paymentService struct {
 	repo          ports.PaymentRepository
+	merchantRepo  ports.MerchantRepository
+	customerRepo  ports.CustomerRepository
 	acquiringBank ports.AcquiringBank
+	fxRates       ports.FXRateProvider
 	logger        ports.Logger
 
 	mu sync.RWMutex
 }
 
-func NewPaymentService(repo ports.PaymentRepository, acquiringBank ports.AcquiringBank, logger ports.Logger) ports.PaymentService {
+func NewPaymentService(repo ports.PaymentRepository, merchantRepo ports.MerchantRepository, customerRepo ports.CustomerRepository, acquiringBank ports.AcquiringBank, fxRates ports.FXRateProvider, logger ports.Logger) ports.PaymentService {
 	return &paymentService{
 		repo:          repo,
+		merchantRepo:  merchantRepo,
+		customerRepo:  customerRepo,
 		acquiringBank: acquiringBank,
+		fxRates:       fxRates,
 		logger:        logger,
 	}
 }
@@ -33,14 +48,182 @@ func (s *paymentService) CreatePayment(ctx context.Context, p *payment.Payment)
 		return errors.New("payment cannot be nil")
 	}
 
+	if !validator.IsCurrency(p.Currency) {
+		s.logger.Error("unsupported currency", "currency", p.Currency)
+		return fmt.Errorf("unsupported currency %q", p.Currency)
+	}
+
+	m, err := s.merchantRepo.GetByID(ctx, p.MerchantID)
+	if err != nil {
+		s.logger.Error("merchant not found", "id", p.MerchantID)
+		return fmt.Errorf("merchant with id %s not found", p.MerchantID)
+	}
+
+	p.Mode = p.Mode.OrLive()
+	if !m.AcceptsPayments(p.Mode) {
+		s.logger.Error("merchant is not active", "id", p.MerchantID, "status", m.Status)
+		return fmt.Errorf("merchant %s is not active", p.MerchantID)
+	}
+
+	settings := m.ProcessingSettings()
+	if !settings.AllowsCurrency(p.Currency) {
+		return s.rejectPayment(p, merchant.NewLimitError(merchant.LimitCurrencyNotAllowed,
+			"currency %s is not enabled for this merchant", p.Currency))
+	}
+
+	if err := s.attachCustomer(ctx, p); err != nil {
+		return err
+	}
+
+	if !settings.AllowsPaymentMethod(p.PaymentMethod) {
+		return s.rejectPayment(p, merchant.NewLimitError(merchant.LimitPaymentMethodNotAllowed,
+			"payment method %q is not enabled for this merchant", p.PaymentMethod))
+	}
+
+	if err := s.lockFXRate(ctx, p, m.SettlementCurrency); err != nil {
+		return err
+	}
+
+	p.StatementDescriptor = settings.StatementDescriptor
 	p.CreatedAt = time.Now()
 	p.UpdatedAt = time.Now()
 	p.Status = payment.PaymentStatusPending
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return s.createWithinLimits(ctx, p, settings)
+}
+
+// createWithinLimits applies the merchant's amount limits and volume caps,
+// which are expressed in the settlement currency, and creates the payment if
+// it is within them.
+func (s *paymentService) createWithinLimits(ctx context.Context, p *payment.Payment, settings merchant.Settings) error {
+	if settings.MinAmount > 0 && p.SettlementAmount < settings.MinAmount {
+		return s.rejectPayment(p, merchant.NewLimitError(merchant.LimitAmountTooSmall,
+			"amount is below the minimum of %.2f %s", settings.MinAmount, p.SettlementCurrency))
+	}
+
+	if settings.MaxAmount > 0 && p.SettlementAmount > settings.MaxAmount {
+		return s.rejectPayment(p, merchant.NewLimitError(merchant.LimitAmountTooLarge,
+			"amount is above the maximum of %.2f %s", settings.MaxAmount, p.SettlementCurrency))
+	}
+
+	type volumeCap struct {
+		limit float64
+		since time.Time
+		code  merchant.LimitCode
+		name  string
+	}
+
+	now := p.CreatedAt.UTC()
+	var caps []volumeCap
+	var since []time.Time
+	for _, c := range []volumeCap{
+		{settings.DailyVolumeLimit, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), merchant.LimitDailyVolumeExceeded, "daily"},
+		{settings.MonthlyVolumeLimit, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), merchant.LimitMonthlyVolumeExceeded, "monthly"},
+	} {
+		if c.limit > 0 {
+			caps = append(caps, c)
+			since = append(since, c.since)
+		}
+	}
+
+	if len(caps) == 0 {
+		return s.repo.Create(ctx, p)
+	}
+
+	// The repository sums the volume and creates the payment under a lock on
+	// the merchant, so concurrent payments cannot both fit under a cap.
+	return s.repo.CreateWithinVolume(ctx, p, since, func(volumes []float64) error {
+		for i, c := range caps {
+			if currency.Round(volumes[i]+p.SettlementAmount, p.SettlementCurrency) > c.limit {
+				return s.rejectPayment(p, merchant.NewLimitError(c.code,
+					"payment would exceed the %s volume limit of %.2f %s", c.name, c.limit, p.SettlementCurrency))
+			}
+		}
+		return nil
+	})
+}
+
+func (s *paymentService) rejectPayment(p *payment.Payment, err *merchant.LimitError) error {
+	s.logger.Warn("Payment rejected by merchant settings", "code", err.Code, "merchant_id", p.MerchantID)
+	return err
+}
+
+// attachCustomer resolves the saved payment method to charge when the payment
+// is made on behalf of a customer. Without an explicit payment method or
+// token, the customer's default payment method is used.
+func (s *paymentService) attachCustomer(ctx context.Context, p *payment.Payment) error {
+	if p.CustomerID == "" {
+		if p.PaymentMethodID != "" {
+			s.logger.Error("payment method requires a customer", "payment_method_id", p.PaymentMethodID)
+			return errors.New("payment method requires a customer")
+		}
+		return nil
+	}
+
+	c, err := s.customerRepo.GetByID(ctx, p.CustomerID)
+	if err != nil || c.MerchantID != p.MerchantID || c.Mode != p.Mode {
+		s.logger.Error("customer not found", "id", p.CustomerID)
+		return fmt.Errorf("customer with id %s not found", p.CustomerID)
+	}
+
+	var pm *customer.PaymentMethod
+	switch {
+	case p.PaymentMethodID != "":
+		pm, err = s.customerRepo.GetPaymentMethod(ctx, p.PaymentMethodID)
+		if err != nil || pm.CustomerID != c.ID {
+			s.logger.Error("payment method not found", "id", p.PaymentMethodID, "customer_id", c.ID)
+			return fmt.Errorf("payment method with id %s not found", p.PaymentMethodID)
+		}
+	case p.PaymentToken == "":
+		methods, err := s.customerRepo.ListPaymentMethods(ctx, c.ID)
+		if err != nil {
+			s.logger.Error("Failed to list payment methods", "error", err, "customer_id", c.ID)
+			return fmt.Errorf("failed to list payment methods: %w", err)
+		}
+		for _, m := range methods {
+			if m.IsDefault {
+				pm = m
+				break
+			}
+		}
+		if pm == nil {
+			s.logger.Error("customer has no default payment method", "customer_id", c.ID)
+			return errors.New("customer has no default payment method")
+		}
+	default:
+		// A one-off token supplied with the request.
+		return nil
+	}
+
+	p.PaymentMethodID = pm.ID
+	p.PaymentToken = pm.Token
+	if p.PaymentMethod == "" {
+		p.PaymentMethod = pm.Type
+	}
+
+	return nil
+}
+
+// lockFXRate converts the presentment amount into the merchant's settlement
+// currency and pins the rate onto the payment, so that reporting and refunds
+// keep using it even after market rates move.
+func (s *paymentService) lockFXRate(ctx context.Context, p *payment.Payment, settlementCurrency string) error {
+	if settlementCurrency == "" {
+		settlementCurrency = p.Currency
+	}
+
+	rate, err := s.fxRates.GetRate(ctx, p.Currency, settlementCurrency)
+	if err != nil {
+		s.logger.Error("Failed to get fx rate", "error", err, "from", p.Currency, "to", settlementCurrency)
+		return fmt.Errorf("failed to get fx rate: %w", err)
+	}
+
+	p.SettlementCurrency = settlementCurrency
+	p.SettlementAmount = rate.Convert(p.Amount)
+	p.FXRate = rate.Value
+	p.FXRateTimestamp = rate.AsOf
 
-	return s.repo.Create(ctx, p)
+	return nil
 }
 
 func (s *paymentService) GetPayment(ctx context.Context, id string) (*payment.Payment, error) {
@@ -65,17 +248,43 @@ func (s *paymentService) UpdatePayment(ctx context.Context, p *payment.Payment)
 		return fmt.Errorf("payment with id %s not found", p.ID)
 	}
 
+	if p.Currency != "" && p.Currency != existing.Currency {
+		s.logger.Error("payment currency cannot be changed", "id", p.ID)
+		return errors.New("payment currency cannot be changed")
+	}
+
+	// The FX rate is locked at creation; an amount change is re-converted
+	// with the same rate rather than a fresh one.
+	p.Currency = existing.Currency
+	p.SettlementCurrency = existing.SettlementCurrency
+	p.FXRate = existing.FXRate
+	p.FXRateTimestamp = existing.FXRateTimestamp
+	p.SettlementAmount = currency.Round(p.Amount*existing.FXRate, existing.SettlementCurrency)
+
+	// Status only moves through processing and the sweeper, which record
+	// each transition; a plain update must not bypass them.
+	p.Status = existing.Status
+	p.AcquirerReference = existing.AcquirerReference
+	p.ProcessedAt = existing.ProcessedAt
+	p.AuthorizationExpiresAt = existing.AuthorizationExpiresAt
+	p.PaymentToken = existing.PaymentToken
+	p.SubscriptionID = existing.SubscriptionID
+	p.CustomerID = existing.CustomerID
+	p.PaymentMethodID = existing.PaymentMethodID
+	p.StatementDescriptor = existing.StatementDescriptor
+	p.Mode = existing.Mode
+
 	p.CreatedAt = existing.CreatedAt
 	p.UpdatedAt = time.Now()
 
 	return s.repo.Update(ctx, p)
 }
 
-func (s *paymentService) ListPayments(ctx context.Context, merchantID string, limit, offset int) ([]*payment.Payment, error) {
+func (s *paymentService) ListPayments(ctx context.Context, merchantID string, m mode.Mode, limit, offset int) ([]*payment.Payment, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return s.repo.List(ctx, merchantID, limit, offset)
+	return s.repo.List(ctx, merchantID, m, limit, offset)
 }
 
 func (s *paymentService) ProcessPayment(ctx context.Context, paymentID string) error {
@@ -93,14 +302,128 @@ func (s *paymentService) ProcessPayment(ctx context.Context, paymentID string) e
 		return errors.New("payment is not in pending status")
 	}
 
+	m, err := s.merchantRepo.GetByID(ctx, p.MerchantID)
+	if err != nil {
+		s.logger.Error("merchant not found", "id", p.MerchantID)
+		return fmt.Errorf("merchant with id %s not found", p.MerchantID)
+	}
+
+	// A payment created before the merchant was offboarded must not reach the
+	// acquirer afterwards.
+	if m.DeletedAt != nil {
+		s.logger.Error("merchant has been deleted", "id", p.MerchantID, "payment_id", paymentID)
+		return fmt.Errorf("merchant %s has been deleted", p.MerchantID)
+	}
+
+	// Claim the payment before the acquirer sees it, so the sweeper cannot
+	// expire it and a concurrent call cannot send it twice.
+	p.Status = payment.PaymentStatusProcessing
+	p.UpdatedAt = time.Now()
+	if err := s.repo.TransitionStatus(ctx, p, payment.PaymentStatusPending, "sent to acquirer"); err != nil {
+		s.logger.Error("Failed to claim payment", "error", err, "payment_id", paymentID)
+		return fmt.Errorf("failed to claim payment: %w", err)
+	}
+
+	if !m.ProcessingSettings().AutoCapture {
+		if err := s.acquiringBank.AuthorizePayment(ctx, p); err != nil {
+			s.logger.Error("Failed to authorize payment", "error", err, "payment_id", paymentID)
+			s.releasePayment(ctx, p, payment.PaymentStatusPending, "authorization failed at acquirer")
+			return err
+		}
+
+		now := time.Now()
+		expiresAt := now.Add(authorizationValidity)
+		p.Status = payment.PaymentStatusAuthorized
+		p.AuthorizationExpiresAt = &expiresAt
+		p.UpdatedAt = now
+
+		return s.completePayment(ctx, p, "authorized by acquirer")
+	}
+
 	err = s.acquiringBank.ProcessPayment(ctx, p)
 	if err != nil {
 		s.logger.Error("Failed to process payment", "error", err, "payment_id", paymentID)
+		s.releasePayment(ctx, p, payment.PaymentStatusPending, "processing failed at acquirer")
+		return err
+	}
+
+	now := time.Now()
+	p.Status = payment.PaymentStatusCompleted
+	p.ProcessedAt = &now
+	p.UpdatedAt = now
+
+	return s.completePayment(ctx, p, "processed by acquirer")
+}
+
+// CapturePayment collects the funds of an authorized payment. Authorizations
+// past their expiry are left for the sweeper to void.
+func (s *paymentService) CapturePayment(ctx context.Context, paymentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.repo.GetByID(ctx, paymentID)
+	if err != nil {
+		s.logger.Error("payment not found", "id", paymentID)
+		return fmt.Errorf("payment with id %s not found", paymentID)
+	}
+
+	if p.Status != payment.PaymentStatusAuthorized {
+		s.logger.Error("payment is not in authorized status", "id", paymentID)
+		return errors.New("payment is not in authorized status")
+	}
+
+	now := time.Now()
+	if p.AuthorizationExpiresAt != nil && now.After(*p.AuthorizationExpiresAt) {
+		s.logger.Error("authorization has expired", "id", paymentID)
+		return errors.New("authorization has expired")
+	}
+
+	p.Status = payment.PaymentStatusProcessing
+	p.UpdatedAt = now
+	if err := s.repo.TransitionStatus(ctx, p, payment.PaymentStatusAuthorized, "sent to acquirer for capture"); err != nil {
+		s.logger.Error("Failed to claim payment", "error", err, "payment_id", paymentID)
+		return fmt.Errorf("failed to claim payment: %w", err)
+	}
+
+	if err := s.acquiringBank.CapturePayment(ctx, p); err != nil {
+		s.logger.Error("Failed to capture payment", "error", err, "payment_id", paymentID)
+		s.releasePayment(ctx, p, payment.PaymentStatusAuthorized, "capture failed at acquirer")
 		return err
 	}
 
+	now = time.Now()
 	p.Status = payment.PaymentStatusCompleted
+	p.ProcessedAt = &now
+	p.AuthorizationExpiresAt = nil
+	p.UpdatedAt = now
+
+	return s.completePayment(ctx, p, "captured")
+}
+
+// releasePayment hands a payment the acquirer refused back to the status it
+// was claimed from, so it can be retried.
+func (s *paymentService) releasePayment(ctx context.Context, p *payment.Payment, to payment.PaymentStatus, reason string) {
+	p.Status = to
 	p.UpdatedAt = time.Now()
+	if err := s.repo.TransitionStatus(ctx, p, payment.PaymentStatusProcessing, reason); err != nil {
+		s.logger.Error("Failed to release payment", "error", err, "payment_id", p.ID)
+	}
+}
 
-	return s.repo.Update(ctx, p)
+// completePayment records what the acquirer did with a claimed payment. If
+// that fails the payment stays processing, and the acquirer reference is
+// logged so it can be reconciled.
+func (s *paymentService) completePayment(ctx context.Context, p *payment.Payment, reason string) error {
+	if err := s.repo.TransitionStatus(ctx, p, payment.PaymentStatusProcessing, reason); err != nil {
+		s.logger.Error("Failed to complete payment", "error", err, "payment_id", p.ID, "acquirer_reference", p.AcquirerReference)
+		return fmt.Errorf("failed to complete payment: %w", err)
+	}
+	return nil
+}
+
+func (s *paymentService) ListTransitions(ctx context.Context, paymentID string) ([]*payment.StatusTransition, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.repo.ListTransitions(ctx, paymentID)
 }
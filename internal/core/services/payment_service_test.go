@@ -9,6 +9,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
+	"github.com/popeskul/payment-gateway/internal/core/domain/currency"
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
 	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
 	"github.com/popeskul/payment-gateway/internal/core/services"
@@ -19,16 +23,20 @@ func TestPaymentService_CreatePayment(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := ports.NewMockPaymentRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockCustomerRepo := ports.NewMockCustomerRepository(ctrl)
 	mockAcquiringBank := ports.NewMockAcquiringBank(ctrl)
+	mockFXRates := ports.NewMockFXRateProvider(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 
-	paymentService := services.NewPaymentService(mockRepo, mockAcquiringBank, mockLogger)
+	paymentService := services.NewPaymentService(mockRepo, mockMerchantRepo, mockCustomerRepo, mockAcquiringBank, mockFXRates, mockLogger)
 
 	tests := []struct {
-		name          string
-		payment       *payment.Payment
-		setupMocks    func()
-		expectedError error
+		name                     string
+		payment                  *payment.Payment
+		setupMocks               func()
+		expectedSettlementAmount float64
+		expectedError            error
 	}{
 		{
 			name: "Successful payment creation",
@@ -38,9 +46,273 @@ func TestPaymentService_CreatePayment(t *testing.T) {
 				Currency:   "USD",
 			},
 			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{
+					ID:                 "merchant123",
+					Status:             merchant.StatusActive,
+					SettlementCurrency: "USD",
+				}, nil)
+				mockFXRates.EXPECT().GetRate(gomock.Any(), "USD", "USD").Return(&currency.Rate{
+					From: "USD", To: "USD", Value: 1, AsOf: time.Now(),
+				}, nil)
 				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
 			},
-			expectedError: nil,
+			expectedSettlementAmount: 100.0,
+			expectedError:            nil,
+		},
+		{
+			name: "Payment converted to settlement currency",
+			payment: &payment.Payment{
+				MerchantID: "merchant123",
+				Amount:     100.0,
+				Currency:   "EUR",
+			},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{
+					ID:                 "merchant123",
+					Status:             merchant.StatusActive,
+					SettlementCurrency: "USD",
+				}, nil)
+				mockFXRates.EXPECT().GetRate(gomock.Any(), "EUR", "USD").Return(&currency.Rate{
+					From: "EUR", To: "USD", Value: 1.08695, AsOf: time.Now(),
+				}, nil)
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedSettlementAmount: 108.70,
+			expectedError:            nil,
+		},
+		{
+			name: "Customer's default payment method is charged",
+			payment: &payment.Payment{
+				MerchantID: "merchant123",
+				CustomerID: "customer1",
+				Amount:     50.0,
+				Currency:   "USD",
+			},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{
+					ID:                 "merchant123",
+					Status:             merchant.StatusActive,
+					SettlementCurrency: "USD",
+				}, nil)
+				mockCustomerRepo.EXPECT().GetByID(gomock.Any(), "customer1").Return(&customer.Customer{
+					ID: "customer1", MerchantID: "merchant123", Mode: mode.Live,
+				}, nil)
+				mockCustomerRepo.EXPECT().ListPaymentMethods(gomock.Any(), "customer1").Return([]*customer.PaymentMethod{
+					{ID: "pm1", CustomerID: "customer1", Type: "card", Token: "tok_old"},
+					{ID: "pm2", CustomerID: "customer1", Type: "card", Token: "tok_default", IsDefault: true},
+				}, nil)
+				mockFXRates.EXPECT().GetRate(gomock.Any(), "USD", "USD").Return(&currency.Rate{
+					From: "USD", To: "USD", Value: 1, AsOf: time.Now(),
+				}, nil)
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, p *payment.Payment) error {
+					assert.Equal(t, "pm2", p.PaymentMethodID)
+					assert.Equal(t, "tok_default", p.PaymentToken)
+					return nil
+				})
+			},
+			expectedSettlementAmount: 50.0,
+			expectedError:            nil,
+		},
+		{
+			name: "Customer without a default payment method",
+			payment: &payment.Payment{
+				MerchantID: "merchant123",
+				CustomerID: "customer1",
+				Amount:     50.0,
+				Currency:   "USD",
+			},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{
+					ID:                 "merchant123",
+					Status:             merchant.StatusActive,
+					SettlementCurrency: "USD",
+				}, nil)
+				mockCustomerRepo.EXPECT().GetByID(gomock.Any(), "customer1").Return(&customer.Customer{
+					ID: "customer1", MerchantID: "merchant123", Mode: mode.Live,
+				}, nil)
+				mockCustomerRepo.EXPECT().ListPaymentMethods(gomock.Any(), "customer1").Return(nil, nil)
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("customer has no default payment method"),
+		},
+		{
+			name: "Unsupported currency",
+			payment: &payment.Payment{
+				MerchantID: "merchant123",
+				Amount:     100.0,
+				Currency:   "XYZ",
+			},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error("unsupported currency", "currency", "XYZ")
+			},
+			expectedError: errors.New(`unsupported currency "XYZ"`),
+		},
+		{
+			name: "FX rate unavailable",
+			payment: &payment.Payment{
+				MerchantID: "merchant123",
+				Amount:     100.0,
+				Currency:   "JPY",
+			},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{
+					ID:                 "merchant123",
+					Status:             merchant.StatusActive,
+					SettlementCurrency: "GBP",
+				}, nil)
+				mockFXRates.EXPECT().GetRate(gomock.Any(), "JPY", "GBP").Return(nil, errors.New("no fx rate available for JPY"))
+				mockLogger.EXPECT().Error("Failed to get fx rate", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("failed to get fx rate: no fx rate available for JPY"),
+		},
+		{
+			name: "Merchant not active",
+			payment: &payment.Payment{
+				MerchantID: "merchant123",
+				Amount:     100.0,
+				Currency:   "USD",
+			},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{
+					ID:                 "merchant123",
+					Status:             merchant.StatusInReview,
+					SettlementCurrency: "USD",
+				}, nil)
+				mockLogger.EXPECT().Error("merchant is not active", "id", "merchant123", "status", merchant.StatusInReview)
+			},
+			expectedError: errors.New("merchant merchant123 is not active"),
+		},
+		{
+			name: "Test mode payment while merchant is in review",
+			payment: &payment.Payment{
+				MerchantID: "merchant123",
+				Mode:       mode.Test,
+				Amount:     100.0,
+				Currency:   "USD",
+			},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{
+					ID:                 "merchant123",
+					Status:             merchant.StatusInReview,
+					SettlementCurrency: "USD",
+				}, nil)
+				mockFXRates.EXPECT().GetRate(gomock.Any(), "USD", "USD").Return(&currency.Rate{
+					From: "USD", To: "USD", Value: 1, AsOf: time.Now(),
+				}, nil)
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedSettlementAmount: 100.0,
+			expectedError:            nil,
+		},
+		{
+			name: "Customer from the other mode",
+			payment: &payment.Payment{
+				MerchantID: "merchant123",
+				CustomerID: "customer1",
+				Amount:     50.0,
+				Currency:   "USD",
+			},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{
+					ID:                 "merchant123",
+					Status:             merchant.StatusActive,
+					SettlementCurrency: "USD",
+				}, nil)
+				mockCustomerRepo.EXPECT().GetByID(gomock.Any(), "customer1").Return(&customer.Customer{
+					ID: "customer1", MerchantID: "merchant123", Mode: mode.Test,
+				}, nil)
+				mockLogger.EXPECT().Error("customer not found", "id", "customer1")
+			},
+			expectedError: errors.New("customer with id customer1 not found"),
+		},
+		{
+			name: "Currency not enabled for merchant",
+			payment: &payment.Payment{
+				MerchantID: "merchant123",
+				Amount:     100.0,
+				Currency:   "EUR",
+			},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{
+					ID:                 "merchant123",
+					Status:             merchant.StatusActive,
+					SettlementCurrency: "USD",
+					Settings:           &merchant.Settings{AllowedCurrencies: []string{"USD"}},
+				}, nil)
+				mockLogger.EXPECT().Warn("Payment rejected by merchant settings", "code", merchant.LimitCurrencyNotAllowed, "merchant_id", "merchant123")
+			},
+			expectedError: errors.New("currency EUR is not enabled for this merchant"),
+		},
+		{
+			name: "Amount above maximum",
+			payment: &payment.Payment{
+				MerchantID: "merchant123",
+				Amount:     600.0,
+				Currency:   "USD",
+			},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{
+					ID:                 "merchant123",
+					Status:             merchant.StatusActive,
+					SettlementCurrency: "USD",
+					Settings:           &merchant.Settings{MaxAmount: 500},
+				}, nil)
+				mockFXRates.EXPECT().GetRate(gomock.Any(), "USD", "USD").Return(&currency.Rate{
+					From: "USD", To: "USD", Value: 1, AsOf: time.Now(),
+				}, nil)
+				mockLogger.EXPECT().Warn("Payment rejected by merchant settings", "code", merchant.LimitAmountTooLarge, "merchant_id", "merchant123")
+			},
+			expectedError: errors.New("amount is above the maximum of 500.00 USD"),
+		},
+		{
+			name: "Daily volume limit exceeded",
+			payment: &payment.Payment{
+				MerchantID: "merchant123",
+				Amount:     100.0,
+				Currency:   "USD",
+			},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{
+					ID:                 "merchant123",
+					Status:             merchant.StatusActive,
+					SettlementCurrency: "USD",
+					Settings:           &merchant.Settings{DailyVolumeLimit: 1000},
+				}, nil)
+				mockFXRates.EXPECT().GetRate(gomock.Any(), "USD", "USD").Return(&currency.Rate{
+					From: "USD", To: "USD", Value: 1, AsOf: time.Now(),
+				}, nil)
+				mockRepo.EXPECT().CreateWithinVolume(gomock.Any(), gomock.Any(), gomock.Len(1), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *payment.Payment, _ []time.Time, check func([]float64) error) error {
+						return check([]float64{950.0})
+					})
+				mockLogger.EXPECT().Warn("Payment rejected by merchant settings", "code", merchant.LimitDailyVolumeExceeded, "merchant_id", "merchant123")
+			},
+			expectedError: errors.New("payment would exceed the daily volume limit of 1000.00 USD"),
+		},
+		{
+			name: "Within the daily volume limit",
+			payment: &payment.Payment{
+				MerchantID: "merchant123",
+				Amount:     100.0,
+				Currency:   "USD",
+			},
+			setupMocks: func() {
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{
+					ID:                 "merchant123",
+					Status:             merchant.StatusActive,
+					SettlementCurrency: "USD",
+					Settings:           &merchant.Settings{DailyVolumeLimit: 1000},
+				}, nil)
+				mockFXRates.EXPECT().GetRate(gomock.Any(), "USD", "USD").Return(&currency.Rate{
+					From: "USD", To: "USD", Value: 1, AsOf: time.Now(),
+				}, nil)
+				mockRepo.EXPECT().CreateWithinVolume(gomock.Any(), gomock.Any(), gomock.Len(1), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *payment.Payment, _ []time.Time, check func([]float64) error) error {
+						return check([]float64{900.0})
+					})
+			},
+			expectedSettlementAmount: 100.0,
+			expectedError:            nil,
 		},
 		{
 			name:    "Nil payment",
@@ -64,6 +336,8 @@ func TestPaymentService_CreatePayment(t *testing.T) {
 				assert.NoError(t, err)
 				if tt.payment != nil {
 					assert.Equal(t, payment.PaymentStatusPending, tt.payment.Status)
+					assert.Equal(t, tt.expectedSettlementAmount, tt.payment.SettlementAmount)
+					assert.NotZero(t, tt.payment.FXRate)
 					assert.NotZero(t, tt.payment.CreatedAt)
 					assert.NotZero(t, tt.payment.UpdatedAt)
 				}
@@ -77,10 +351,13 @@ func TestPaymentService_GetPayment(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := ports.NewMockPaymentRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockCustomerRepo := ports.NewMockCustomerRepository(ctrl)
 	mockAcquiringBank := ports.NewMockAcquiringBank(ctrl)
+	mockFXRates := ports.NewMockFXRateProvider(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 
-	paymentService := services.NewPaymentService(mockRepo, mockAcquiringBank, mockLogger)
+	paymentService := services.NewPaymentService(mockRepo, mockMerchantRepo, mockCustomerRepo, mockAcquiringBank, mockFXRates, mockLogger)
 
 	tests := []struct {
 		name            string
@@ -137,10 +414,13 @@ func TestPaymentService_UpdatePayment(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := ports.NewMockPaymentRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockCustomerRepo := ports.NewMockCustomerRepository(ctrl)
 	mockAcquiringBank := ports.NewMockAcquiringBank(ctrl)
+	mockFXRates := ports.NewMockFXRateProvider(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 
-	paymentService := services.NewPaymentService(mockRepo, mockAcquiringBank, mockLogger)
+	paymentService := services.NewPaymentService(mockRepo, mockMerchantRepo, mockCustomerRepo, mockAcquiringBank, mockFXRates, mockLogger)
 
 	tests := []struct {
 		name          string
@@ -208,10 +488,13 @@ func TestPaymentService_ListPayments(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := ports.NewMockPaymentRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockCustomerRepo := ports.NewMockCustomerRepository(ctrl)
 	mockAcquiringBank := ports.NewMockAcquiringBank(ctrl)
+	mockFXRates := ports.NewMockFXRateProvider(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 
-	paymentService := services.NewPaymentService(mockRepo, mockAcquiringBank, mockLogger)
+	paymentService := services.NewPaymentService(mockRepo, mockMerchantRepo, mockCustomerRepo, mockAcquiringBank, mockFXRates, mockLogger)
 
 	tests := []struct {
 		name           string
@@ -228,7 +511,7 @@ func TestPaymentService_ListPayments(t *testing.T) {
 			limit:      10,
 			offset:     0,
 			setupMocks: func() {
-				mockRepo.EXPECT().List(gomock.Any(), "merchant123", 10, 0).Return([]*payment.Payment{
+				mockRepo.EXPECT().List(gomock.Any(), "merchant123", mode.Test, 10, 0).Return([]*payment.Payment{
 					{ID: "payment1", Amount: 100.0},
 					{ID: "payment2", Amount: 200.0},
 				}, nil)
@@ -245,7 +528,7 @@ func TestPaymentService_ListPayments(t *testing.T) {
 			limit:      10,
 			offset:     0,
 			setupMocks: func() {
-				mockRepo.EXPECT().List(gomock.Any(), "merchant456", 10, 0).Return([]*payment.Payment{}, nil)
+				mockRepo.EXPECT().List(gomock.Any(), "merchant456", mode.Test, 10, 0).Return([]*payment.Payment{}, nil)
 			},
 			expectedResult: []*payment.Payment{},
 			expectedError:  nil,
@@ -256,7 +539,7 @@ func TestPaymentService_ListPayments(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setupMocks()
 
-			payments, err := paymentService.ListPayments(context.Background(), tt.merchantID, tt.limit, tt.offset)
+			payments, err := paymentService.ListPayments(context.Background(), tt.merchantID, mode.Test, tt.limit, tt.offset)
 
 			if tt.expectedError != nil {
 				assert.EqualError(t, err, tt.expectedError.Error())
@@ -274,10 +557,13 @@ func TestPaymentService_ProcessPayment(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := ports.NewMockPaymentRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockCustomerRepo := ports.NewMockCustomerRepository(ctrl)
 	mockAcquiringBank := ports.NewMockAcquiringBank(ctrl)
+	mockFXRates := ports.NewMockFXRateProvider(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 
-	paymentService := services.NewPaymentService(mockRepo, mockAcquiringBank, mockLogger)
+	paymentService := services.NewPaymentService(mockRepo, mockMerchantRepo, mockCustomerRepo, mockAcquiringBank, mockFXRates, mockLogger)
 
 	tests := []struct {
 		name          string
@@ -293,11 +579,68 @@ func TestPaymentService_ProcessPayment(t *testing.T) {
 					ID:     "payment123",
 					Status: payment.PaymentStatusPending,
 				}, nil)
-				mockAcquiringBank.EXPECT().ProcessPayment(gomock.Any(), gomock.Any()).Return(nil)
-				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).Return(&merchant.Merchant{}, nil)
+				gomock.InOrder(
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusPending, "sent to acquirer").
+						DoAndReturn(func(_ context.Context, p *payment.Payment, _ payment.PaymentStatus, _ string) error {
+							assert.Equal(t, payment.PaymentStatusProcessing, p.Status)
+							return nil
+						}),
+					mockAcquiringBank.EXPECT().ProcessPayment(gomock.Any(), gomock.Any()).Return(nil),
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusProcessing, "processed by acquirer").
+						DoAndReturn(func(_ context.Context, p *payment.Payment, _ payment.PaymentStatus, _ string) error {
+							assert.Equal(t, payment.PaymentStatusCompleted, p.Status)
+							return nil
+						}),
+				)
+			},
+			expectedError: nil,
+		},
+		{
+			name:      "Auto capture off only authorizes",
+			paymentID: "payment321",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "payment321").Return(&payment.Payment{
+					ID:         "payment321",
+					MerchantID: "merchant123",
+					Status:     payment.PaymentStatusPending,
+				}, nil)
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{
+					ID:       "merchant123",
+					Settings: &merchant.Settings{AutoCapture: false},
+				}, nil)
+				gomock.InOrder(
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusPending, "sent to acquirer").Return(nil),
+					mockAcquiringBank.EXPECT().AuthorizePayment(gomock.Any(), gomock.Any()).Return(nil),
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusProcessing, "authorized by acquirer").
+						DoAndReturn(func(_ context.Context, p *payment.Payment, _ payment.PaymentStatus, _ string) error {
+							assert.Equal(t, payment.PaymentStatusAuthorized, p.Status)
+							assert.NotNil(t, p.AuthorizationExpiresAt)
+							return nil
+						}),
+				)
 			},
 			expectedError: nil,
 		},
+		{
+			name:      "Merchant deleted",
+			paymentID: "payment456",
+			setupMocks: func() {
+				deletedAt := time.Now()
+				mockRepo.EXPECT().GetByID(gomock.Any(), "payment456").Return(&payment.Payment{
+					ID:         "payment456",
+					MerchantID: "merchant123",
+					Status:     payment.PaymentStatusPending,
+				}, nil)
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{
+					ID:        "merchant123",
+					Status:    merchant.StatusActive,
+					DeletedAt: &deletedAt,
+				}, nil)
+				mockLogger.EXPECT().Error("merchant has been deleted", "id", "merchant123", "payment_id", "payment456")
+			},
+			expectedError: errors.New("merchant merchant123 has been deleted"),
+		},
 		{
 			name:      "Payment not found",
 			paymentID: "nonexistent",
@@ -327,11 +670,59 @@ func TestPaymentService_ProcessPayment(t *testing.T) {
 					ID:     "payment789",
 					Status: payment.PaymentStatusPending,
 				}, nil)
-				mockAcquiringBank.EXPECT().ProcessPayment(gomock.Any(), gomock.Any()).Return(errors.New("processing error"))
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).Return(&merchant.Merchant{}, nil)
+				gomock.InOrder(
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusPending, "sent to acquirer").Return(nil),
+					mockAcquiringBank.EXPECT().ProcessPayment(gomock.Any(), gomock.Any()).Return(errors.New("processing error")),
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusProcessing, "processing failed at acquirer").
+						DoAndReturn(func(_ context.Context, p *payment.Payment, _ payment.PaymentStatus, _ string) error {
+							assert.Equal(t, payment.PaymentStatusPending, p.Status, "a refused payment can be retried")
+							return nil
+						}),
+				)
 				mockLogger.EXPECT().Error("Failed to process payment", "error", errors.New("processing error"), "payment_id", "payment789")
 			},
 			expectedError: errors.New("processing error"),
 		},
+		{
+			name:      "Payment claimed concurrently",
+			paymentID: "payment790",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "payment790").Return(&payment.Payment{
+					ID:     "payment790",
+					Status: payment.PaymentStatusPending,
+				}, nil)
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).Return(&merchant.Merchant{}, nil)
+				claimErr := errors.New("payment is not in pending status")
+				mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusPending, "sent to acquirer").Return(claimErr)
+				mockLogger.EXPECT().Error("Failed to claim payment", "error", claimErr, "payment_id", "payment790")
+				// The acquirer is never called.
+			},
+			expectedError: errors.New("failed to claim payment: payment is not in pending status"),
+		},
+		{
+			name:      "Result not recorded",
+			paymentID: "payment791",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "payment791").Return(&payment.Payment{
+					ID:     "payment791",
+					Status: payment.PaymentStatusPending,
+				}, nil)
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).Return(&merchant.Merchant{}, nil)
+				dbErr := errors.New("db error")
+				gomock.InOrder(
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusPending, "sent to acquirer").Return(nil),
+					mockAcquiringBank.EXPECT().ProcessPayment(gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ context.Context, p *payment.Payment) error {
+							p.AcquirerReference = "acq123"
+							return nil
+						}),
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusProcessing, "processed by acquirer").Return(dbErr),
+				)
+				mockLogger.EXPECT().Error("Failed to complete payment", "error", dbErr, "payment_id", "payment791", "acquirer_reference", "acq123")
+			},
+			expectedError: errors.New("failed to complete payment: db error"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -348,3 +739,127 @@ func TestPaymentService_ProcessPayment(t *testing.T) {
 		})
 	}
 }
+
+func TestPaymentService_CapturePayment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockPaymentRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockCustomerRepo := ports.NewMockCustomerRepository(ctrl)
+	mockAcquiringBank := ports.NewMockAcquiringBank(ctrl)
+	mockFXRates := ports.NewMockFXRateProvider(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	paymentService := services.NewPaymentService(mockRepo, mockMerchantRepo, mockCustomerRepo, mockAcquiringBank, mockFXRates, mockLogger)
+
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name: "Successful capture",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "payment123").Return(&payment.Payment{
+					ID:                     "payment123",
+					Status:                 payment.PaymentStatusAuthorized,
+					AuthorizationExpiresAt: &future,
+				}, nil)
+				gomock.InOrder(
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusAuthorized, "sent to acquirer for capture").
+						DoAndReturn(func(_ context.Context, p *payment.Payment, _ payment.PaymentStatus, _ string) error {
+							assert.Equal(t, payment.PaymentStatusProcessing, p.Status)
+							return nil
+						}),
+					mockAcquiringBank.EXPECT().CapturePayment(gomock.Any(), gomock.Any()).Return(nil),
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusProcessing, "captured").
+						DoAndReturn(func(_ context.Context, p *payment.Payment, _ payment.PaymentStatus, _ string) error {
+							assert.Equal(t, payment.PaymentStatusCompleted, p.Status)
+							assert.Nil(t, p.AuthorizationExpiresAt)
+							return nil
+						}),
+				)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Authorization claimed by the sweeper",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "payment123").Return(&payment.Payment{
+					ID:                     "payment123",
+					Status:                 payment.PaymentStatusAuthorized,
+					AuthorizationExpiresAt: &future,
+				}, nil)
+				claimErr := errors.New("payment is not in authorized status")
+				mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusAuthorized, "sent to acquirer for capture").Return(claimErr)
+				mockLogger.EXPECT().Error("Failed to claim payment", "error", claimErr, "payment_id", "payment123")
+				// The acquirer is never called.
+			},
+			expectedError: errors.New("failed to claim payment: payment is not in authorized status"),
+		},
+		{
+			name: "Capture refused by the acquirer",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "payment123").Return(&payment.Payment{
+					ID:                     "payment123",
+					Status:                 payment.PaymentStatusAuthorized,
+					AuthorizationExpiresAt: &future,
+				}, nil)
+				captureErr := errors.New("capture error")
+				gomock.InOrder(
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusAuthorized, "sent to acquirer for capture").Return(nil),
+					mockAcquiringBank.EXPECT().CapturePayment(gomock.Any(), gomock.Any()).Return(captureErr),
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusProcessing, "capture failed at acquirer").
+						DoAndReturn(func(_ context.Context, p *payment.Payment, _ payment.PaymentStatus, _ string) error {
+							assert.Equal(t, payment.PaymentStatusAuthorized, p.Status)
+							assert.Equal(t, &future, p.AuthorizationExpiresAt)
+							return nil
+						}),
+				)
+				mockLogger.EXPECT().Error("Failed to capture payment", "error", captureErr, "payment_id", "payment123")
+			},
+			expectedError: errors.New("capture error"),
+		},
+		{
+			name: "Payment not authorized",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "payment123").Return(&payment.Payment{
+					ID:     "payment123",
+					Status: payment.PaymentStatusPending,
+				}, nil)
+				mockLogger.EXPECT().Error("payment is not in authorized status", "id", "payment123")
+			},
+			expectedError: errors.New("payment is not in authorized status"),
+		},
+		{
+			name: "Authorization expired",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "payment123").Return(&payment.Payment{
+					ID:                     "payment123",
+					Status:                 payment.PaymentStatusAuthorized,
+					AuthorizationExpiresAt: &past,
+				}, nil)
+				mockLogger.EXPECT().Error("authorization has expired", "id", "payment123")
+			},
+			expectedError: errors.New("authorization has expired"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := paymentService.CapturePayment(context.Background(), "payment123")
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+// sweeperLockKey is shared by every replica so only one of them sweeps at a time.
+const sweeperLockKey = "payment-sweeper"
+
+type paymentSweeper struct {
+	repo          ports.PaymentRepository
+	acquiringBank ports.AcquiringBank
+	locker        ports.Locker
+	logger        ports.Logger
+	pendingTTL    time.Duration
+	batchSize     int
+}
+
+func NewPaymentSweeper(repo ports.PaymentRepository, acquiringBank ports.AcquiringBank, locker ports.Locker, logger ports.Logger, pendingTTL time.Duration, batchSize int) ports.PaymentSweeper {
+	return &paymentSweeper{
+		repo:          repo,
+		acquiringBank: acquiringBank,
+		locker:        locker,
+		logger:        logger,
+		pendingTTL:    pendingTTL,
+		batchSize:     batchSize,
+	}
+}
+
+// Sweep expires payments that stayed pending longer than the TTL and voids
+// authorizations that reached their expiry without being captured. A payment
+// that fails to move is logged and picked up again on the next run; one the
+// acquirer voided but that could not be marked voided stays voiding.
+func (s *paymentSweeper) Sweep(ctx context.Context) (*payment.SweepResult, error) {
+	unlock, acquired, err := s.locker.TryLock(ctx, sweeperLockKey)
+	if err != nil {
+		s.logger.Error("Failed to acquire sweeper lock", "error", err)
+		return nil, fmt.Errorf("failed to acquire sweeper lock: %w", err)
+	}
+	if !acquired {
+		s.logger.Debug("Payment sweep skipped, another instance holds the lock")
+		return &payment.SweepResult{Skipped: true}, nil
+	}
+	defer unlock()
+
+	result := &payment.SweepResult{}
+	now := time.Now()
+
+	pending, err := s.repo.ListPendingCreatedBefore(ctx, now.Add(-s.pendingTTL), s.batchSize)
+	if err != nil {
+		s.logger.Error("Failed to list stale pending payments", "error", err)
+		return nil, fmt.Errorf("failed to list stale pending payments: %w", err)
+	}
+
+	for _, p := range pending {
+		p.Status = payment.PaymentStatusExpired
+		p.UpdatedAt = now
+
+		reason := fmt.Sprintf("pending for longer than %s", s.pendingTTL)
+		if err := s.repo.TransitionStatus(ctx, p, payment.PaymentStatusPending, reason); err != nil {
+			s.logger.Error("Failed to expire payment", "error", err, "payment_id", p.ID)
+			continue
+		}
+		result.Expired++
+	}
+
+	authorized, err := s.repo.ListAuthorizationsExpiredBefore(ctx, now, s.batchSize)
+	if err != nil {
+		s.logger.Error("Failed to list expired authorizations", "error", err)
+		return nil, fmt.Errorf("failed to list expired authorizations: %w", err)
+	}
+
+	for _, p := range authorized {
+		// Claim the authorization first, so a capture cannot start while the
+		// acquirer releases it.
+		p.Status = payment.PaymentStatusVoiding
+		p.UpdatedAt = now
+		if err := s.repo.TransitionStatus(ctx, p, payment.PaymentStatusAuthorized, "authorization expired without capture"); err != nil {
+			s.logger.Error("Failed to claim authorization", "error", err, "payment_id", p.ID)
+			continue
+		}
+
+		if err := s.acquiringBank.VoidPayment(ctx, p); err != nil {
+			s.logger.Error("Failed to void authorization", "error", err, "payment_id", p.ID)
+			p.Status = payment.PaymentStatusAuthorized
+			if err := s.repo.TransitionStatus(ctx, p, payment.PaymentStatusVoiding, "void failed at acquirer"); err != nil {
+				s.logger.Error("Failed to release authorization", "error", err, "payment_id", p.ID)
+			}
+			continue
+		}
+
+		p.Status = payment.PaymentStatusVoided
+		if err := s.repo.TransitionStatus(ctx, p, payment.PaymentStatusVoiding, "voided by acquirer"); err != nil {
+			s.logger.Error("Failed to void payment", "error", err, "payment_id", p.ID, "acquirer_reference", p.AcquirerReference)
+			continue
+		}
+		result.Voided++
+	}
+
+	if result.Expired > 0 || result.Voided > 0 {
+		s.logger.Info("Payment sweep completed", "expired", result.Expired, "voided", result.Voided)
+	}
+
+	return result, nil
+}
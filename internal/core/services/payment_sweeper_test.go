@@ -0,0 +1,154 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/core/services"
+)
+
+func TestPaymentSweeper_Sweep(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockPaymentRepository(ctrl)
+	mockAcquiringBank := ports.NewMockAcquiringBank(ctrl)
+	mockLocker := ports.NewMockLocker(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	sweeper := services.NewPaymentSweeper(mockRepo, mockAcquiringBank, mockLocker, mockLogger, 30*time.Minute, 100)
+
+	unlocked := false
+	unlock := func() { unlocked = true }
+
+	tests := []struct {
+		name           string
+		setupMocks     func()
+		expectedResult *payment.SweepResult
+		expectedError  error
+	}{
+		{
+			name: "Expires pending payments and voids expired authorizations",
+			setupMocks: func() {
+				mockLocker.EXPECT().TryLock(gomock.Any(), "payment-sweeper").Return(unlock, true, nil)
+				mockRepo.EXPECT().ListPendingCreatedBefore(gomock.Any(), gomock.Any(), 100).Return([]*payment.Payment{
+					{ID: "payment1", Status: payment.PaymentStatusPending},
+				}, nil)
+				mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusPending, gomock.Any()).
+					DoAndReturn(func(_ context.Context, p *payment.Payment, _ payment.PaymentStatus, _ string) error {
+						assert.Equal(t, payment.PaymentStatusExpired, p.Status)
+						return nil
+					})
+				mockRepo.EXPECT().ListAuthorizationsExpiredBefore(gomock.Any(), gomock.Any(), 100).Return([]*payment.Payment{
+					{ID: "payment2", Status: payment.PaymentStatusAuthorized},
+				}, nil)
+				gomock.InOrder(
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusAuthorized, gomock.Any()).
+						DoAndReturn(func(_ context.Context, p *payment.Payment, _ payment.PaymentStatus, _ string) error {
+							assert.Equal(t, payment.PaymentStatusVoiding, p.Status)
+							return nil
+						}),
+					mockAcquiringBank.EXPECT().VoidPayment(gomock.Any(), gomock.Any()).Return(nil),
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusVoiding, gomock.Any()).
+						DoAndReturn(func(_ context.Context, p *payment.Payment, _ payment.PaymentStatus, _ string) error {
+							assert.Equal(t, payment.PaymentStatusVoided, p.Status)
+							return nil
+						}),
+				)
+				mockLogger.EXPECT().Info(gomock.Any(), gomock.Any())
+			},
+			expectedResult: &payment.SweepResult{Expired: 1, Voided: 1},
+		},
+		{
+			name: "Another instance holds the lock",
+			setupMocks: func() {
+				mockLocker.EXPECT().TryLock(gomock.Any(), "payment-sweeper").Return(nil, false, nil)
+				mockLogger.EXPECT().Debug(gomock.Any())
+			},
+			expectedResult: &payment.SweepResult{Skipped: true},
+		},
+		{
+			name: "Payment processed concurrently is skipped",
+			setupMocks: func() {
+				mockLocker.EXPECT().TryLock(gomock.Any(), "payment-sweeper").Return(unlock, true, nil)
+				mockRepo.EXPECT().ListPendingCreatedBefore(gomock.Any(), gomock.Any(), 100).Return([]*payment.Payment{
+					{ID: "payment1", Status: payment.PaymentStatusPending},
+				}, nil)
+				mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusPending, gomock.Any()).
+					Return(errors.New("payment is not in pending status"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any())
+				mockRepo.EXPECT().ListAuthorizationsExpiredBefore(gomock.Any(), gomock.Any(), 100).Return(nil, nil)
+			},
+			expectedResult: &payment.SweepResult{},
+		},
+		{
+			name: "Void declined by acquirer",
+			setupMocks: func() {
+				mockLocker.EXPECT().TryLock(gomock.Any(), "payment-sweeper").Return(unlock, true, nil)
+				mockRepo.EXPECT().ListPendingCreatedBefore(gomock.Any(), gomock.Any(), 100).Return(nil, nil)
+				mockRepo.EXPECT().ListAuthorizationsExpiredBefore(gomock.Any(), gomock.Any(), 100).Return([]*payment.Payment{
+					{ID: "payment2", Status: payment.PaymentStatusAuthorized},
+				}, nil)
+				gomock.InOrder(
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusAuthorized, gomock.Any()).Return(nil),
+					mockAcquiringBank.EXPECT().VoidPayment(gomock.Any(), gomock.Any()).Return(errors.New("void failed")),
+					mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusVoiding, "void failed at acquirer").
+						DoAndReturn(func(_ context.Context, p *payment.Payment, _ payment.PaymentStatus, _ string) error {
+							assert.Equal(t, payment.PaymentStatusAuthorized, p.Status, "the next run retries the void")
+							return nil
+						}),
+				)
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any())
+			},
+			expectedResult: &payment.SweepResult{},
+		},
+		{
+			name: "Authorization captured concurrently is not voided",
+			setupMocks: func() {
+				mockLocker.EXPECT().TryLock(gomock.Any(), "payment-sweeper").Return(unlock, true, nil)
+				mockRepo.EXPECT().ListPendingCreatedBefore(gomock.Any(), gomock.Any(), 100).Return(nil, nil)
+				mockRepo.EXPECT().ListAuthorizationsExpiredBefore(gomock.Any(), gomock.Any(), 100).Return([]*payment.Payment{
+					{ID: "payment2", Status: payment.PaymentStatusAuthorized},
+				}, nil)
+				mockRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), payment.PaymentStatusAuthorized, gomock.Any()).
+					Return(errors.New("payment is not in authorized status"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any())
+				// The acquirer is never called.
+			},
+			expectedResult: &payment.SweepResult{},
+		},
+		{
+			name: "Lock error",
+			setupMocks: func() {
+				mockLocker.EXPECT().TryLock(gomock.Any(), "payment-sweeper").Return(nil, false, errors.New("connection refused"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("failed to acquire sweeper lock: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unlocked = false
+			tt.setupMocks()
+
+			result, err := sweeper.Sweep(context.Background())
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedResult, result)
+			assert.Equal(t, !tt.expectedResult.Skipped, unlocked)
+		})
+	}
+}
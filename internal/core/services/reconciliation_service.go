@@ -0,0 +1,357 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/currency"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
+	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+// reconciliationLockKey prefixes the per-file lock, so every replica skips a
+// settlement file another one is reconciling.
+const reconciliationLockKey = "settlement-reconciliation:"
+
+type reconciliationService struct {
+	repo        ports.ReconciliationRepository
+	paymentRepo ports.PaymentRepository
+	refundRepo  ports.RefundRepository
+	source      ports.SettlementFileSource
+	locker      ports.Locker
+	logger      ports.Logger
+}
+
+func NewReconciliationService(repo ports.ReconciliationRepository, paymentRepo ports.PaymentRepository, refundRepo ports.RefundRepository, source ports.SettlementFileSource, locker ports.Locker, logger ports.Logger) ports.ReconciliationService {
+	return &reconciliationService{
+		repo:        repo,
+		paymentRepo: paymentRepo,
+		refundRepo:  refundRepo,
+		source:      source,
+		locker:      locker,
+		logger:      logger,
+	}
+}
+
+// RunReconciliation reconciles every settlement file that does not have a
+// completed report yet. A failure on one file does not stop the others.
+func (s *reconciliationService) RunReconciliation(ctx context.Context) ([]*reconciliation.Report, error) {
+	files, err := s.source.ListFiles(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list settlement files", "error", err)
+		return nil, fmt.Errorf("failed to list settlement files: %w", err)
+	}
+
+	var reports []*reconciliation.Report
+	for _, name := range files {
+		existing, err := s.repo.GetReportByFileName(ctx, name)
+		if err != nil && !errors.Is(err, reconciliation.ErrReportNotFound) {
+			s.logger.Error("Failed to get reconciliation report", "error", err, "file", name)
+			continue
+		}
+		if existing != nil && existing.Status == reconciliation.ReportStatusCompleted {
+			continue
+		}
+
+		report, err := s.ReconcileFile(ctx, name)
+		if errors.Is(err, reconciliation.ErrInProgress) {
+			s.logger.Debug("Settlement file skipped, another instance is reconciling it", "file", name)
+			continue
+		}
+		if err != nil {
+			s.logger.Error("Failed to reconcile settlement file", "error", err, "file", name)
+		}
+		if report != nil {
+			reports = append(reports, report)
+		}
+	}
+
+	return reports, nil
+}
+
+func (s *reconciliationService) ReconcileFile(ctx context.Context, fileName string) (*reconciliation.Report, error) {
+	unlock, acquired, err := s.locker.TryLock(ctx, reconciliationLockKey+fileName)
+	if err != nil {
+		s.logger.Error("Failed to acquire reconciliation lock", "error", err, "file", fileName)
+		return nil, fmt.Errorf("failed to acquire reconciliation lock: %w", err)
+	}
+	if !acquired {
+		return nil, reconciliation.ErrInProgress
+	}
+	defer unlock()
+
+	report, err := s.repo.GetReportByFileName(ctx, fileName)
+	if errors.Is(err, reconciliation.ErrReportNotFound) {
+		report = &reconciliation.Report{
+			FileName:  fileName,
+			Status:    reconciliation.ReportStatusProcessing,
+			CreatedAt: time.Now(),
+		}
+		if err := s.repo.CreateReport(ctx, report); err != nil {
+			s.logger.Error("Failed to create reconciliation report", "error", err, "file", fileName)
+			return nil, fmt.Errorf("failed to create reconciliation report: %w", err)
+		}
+	} else if err != nil {
+		s.logger.Error("Failed to get reconciliation report", "error", err, "file", fileName)
+		return nil, fmt.Errorf("failed to get reconciliation report: %w", err)
+	} else if report.Status == reconciliation.ReportStatusCompleted {
+		return nil, fmt.Errorf("settlement file %s has already been reconciled", fileName)
+	}
+
+	if err := s.reconcile(ctx, report); err != nil {
+		report.Status = reconciliation.ReportStatusFailed
+		report.Error = err.Error()
+		if updateErr := s.repo.UpdateReport(ctx, report); updateErr != nil {
+			s.logger.Error("Failed to update reconciliation report", "error", updateErr, "report_id", report.ID)
+		}
+		return report, err
+	}
+
+	return report, nil
+}
+
+func (s *reconciliationService) reconcile(ctx context.Context, report *reconciliation.Report) error {
+	lines, err := s.source.ReadFile(ctx, report.FileName)
+	if err != nil {
+		return fmt.Errorf("failed to read settlement file: %w", err)
+	}
+
+	report.TotalLines = len(lines)
+	report.MatchedCount = 0
+	report.DiscrepancyCount = 0
+
+	var discrepancies []*reconciliation.Discrepancy
+	seen := map[reconciliation.RecordType]map[string]bool{
+		reconciliation.RecordTypePayment: {},
+		reconciliation.RecordTypeRefund:  {},
+	}
+
+	for _, line := range lines {
+		if report.PeriodStart == nil || line.ProcessedAt.Before(*report.PeriodStart) {
+			start := line.ProcessedAt
+			report.PeriodStart = &start
+		}
+		if report.PeriodEnd == nil || line.ProcessedAt.After(*report.PeriodEnd) {
+			end := line.ProcessedAt
+			report.PeriodEnd = &end
+		}
+
+		if _, ok := seen[line.RecordType]; !ok {
+			return fmt.Errorf("unknown record type %q for reference %s", line.RecordType, line.AcquirerReference)
+		}
+		seen[line.RecordType][line.AcquirerReference] = true
+
+		d, err := s.matchLine(ctx, line)
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			report.MatchedCount++
+			continue
+		}
+		discrepancies = append(discrepancies, s.newDiscrepancy(report, d))
+	}
+
+	if report.PeriodStart != nil {
+		missing, err := s.findMissingAtAcquirer(ctx, report, seen)
+		if err != nil {
+			return err
+		}
+		discrepancies = append(discrepancies, missing...)
+	}
+
+	now := time.Now()
+	report.DiscrepancyCount = len(discrepancies)
+	report.Status = reconciliation.ReportStatusCompleted
+	report.Error = ""
+	report.CompletedAt = &now
+
+	if err := s.repo.CompleteReport(ctx, report, discrepancies); err != nil {
+		return fmt.Errorf("failed to save reconciliation report: %w", err)
+	}
+
+	for _, d := range discrepancies {
+		s.logger.Warn("Settlement discrepancy found", "report_id", report.ID, "type", d.Type, "acquirer_reference", d.AcquirerReference)
+	}
+
+	return nil
+}
+
+// matchLine returns nil when the line matches our records.
+func (s *reconciliationService) matchLine(ctx context.Context, line *reconciliation.SettlementLine) (*reconciliation.Discrepancy, error) {
+	d := &reconciliation.Discrepancy{
+		RecordType:        line.RecordType,
+		AcquirerReference: line.AcquirerReference,
+		ActualAmount:      amountPtr(line.Amount),
+		Currency:          line.Currency,
+	}
+
+	var expected float64
+	switch line.RecordType {
+	case reconciliation.RecordTypePayment:
+		p, err := s.paymentRepo.GetByAcquirerReference(ctx, line.AcquirerReference)
+		if errors.Is(err, payment.ErrNotFound) {
+			d.Type = reconciliation.DiscrepancyMissingInternally
+			return d, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get payment: %w", err)
+		}
+		d.RecordID = p.ID
+
+		expected, err = s.capturedAmount(ctx, p, line.Currency)
+		if err != nil {
+			return nil, err
+		}
+	case reconciliation.RecordTypeRefund:
+		r, err := s.refundRepo.GetByAcquirerReference(ctx, line.AcquirerReference)
+		if errors.Is(err, refund.ErrNotFound) {
+			d.Type = reconciliation.DiscrepancyMissingInternally
+			return d, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get refund: %w", err)
+		}
+		d.RecordID = r.ID
+
+		expected = r.Amount
+		if line.Currency != r.Currency && line.Currency == r.SettlementCurrency {
+			expected = r.SettlementAmount
+		}
+	}
+
+	// Compare in the line currency's minor units so float rounding is not
+	// reported as a mismatch.
+	if currency.Round(expected, line.Currency) != currency.Round(line.Amount, line.Currency) {
+		d.Type = reconciliation.DiscrepancyAmountMismatch
+		d.ExpectedAmount = amountPtr(expected)
+		return d, nil
+	}
+
+	return nil, nil
+}
+
+// capturedAmount rebuilds the amount originally captured for a payment.
+// Completed refunds are deducted from payments.amount, so they are added back
+// before comparing with the acquirer's figure.
+func (s *reconciliationService) capturedAmount(ctx context.Context, p *payment.Payment, lineCurrency string) (float64, error) {
+	refunded, err := s.refundRepo.TotalByPayment(ctx, p.ID, refund.RefundStatusCompleted)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get refunded total: %w", err)
+	}
+
+	captured := currency.Round(p.Amount+refunded, p.Currency)
+	if lineCurrency != p.Currency && lineCurrency == p.SettlementCurrency {
+		return currency.Round(captured*p.FXRate, p.SettlementCurrency), nil
+	}
+
+	return captured, nil
+}
+
+func (s *reconciliationService) findMissingAtAcquirer(ctx context.Context, report *reconciliation.Report, seen map[reconciliation.RecordType]map[string]bool) ([]*reconciliation.Discrepancy, error) {
+	var missing []*reconciliation.Discrepancy
+
+	payments, err := s.paymentRepo.ListProcessedBetween(ctx, *report.PeriodStart, *report.PeriodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processed payments: %w", err)
+	}
+	for _, p := range payments {
+		if seen[reconciliation.RecordTypePayment][p.AcquirerReference] {
+			continue
+		}
+		captured, err := s.capturedAmount(ctx, p, p.Currency)
+		if err != nil {
+			return nil, err
+		}
+		d := &reconciliation.Discrepancy{
+			Type:              reconciliation.DiscrepancyMissingAtAcquirer,
+			RecordType:        reconciliation.RecordTypePayment,
+			RecordID:          p.ID,
+			AcquirerReference: p.AcquirerReference,
+			ExpectedAmount:    amountPtr(captured),
+			Currency:          p.Currency,
+		}
+		missing = append(missing, s.newDiscrepancy(report, d))
+	}
+
+	refunds, err := s.refundRepo.ListProcessedBetween(ctx, *report.PeriodStart, *report.PeriodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processed refunds: %w", err)
+	}
+	for _, r := range refunds {
+		if seen[reconciliation.RecordTypeRefund][r.AcquirerReference] {
+			continue
+		}
+		d := &reconciliation.Discrepancy{
+			Type:              reconciliation.DiscrepancyMissingAtAcquirer,
+			RecordType:        reconciliation.RecordTypeRefund,
+			RecordID:          r.ID,
+			AcquirerReference: r.AcquirerReference,
+			ExpectedAmount:    amountPtr(r.Amount),
+			Currency:          r.Currency,
+		}
+		missing = append(missing, s.newDiscrepancy(report, d))
+	}
+
+	return missing, nil
+}
+
+// newDiscrepancy opens d on the report. It is saved with the report once the
+// whole file has been reconciled.
+func (s *reconciliationService) newDiscrepancy(report *reconciliation.Report, d *reconciliation.Discrepancy) *reconciliation.Discrepancy {
+	d.ReportID = report.ID
+	d.Status = reconciliation.DiscrepancyStatusOpen
+	d.CreatedAt = time.Now()
+	return d
+}
+
+func (s *reconciliationService) GetReport(ctx context.Context, id string) (*reconciliation.Report, error) {
+	return s.repo.GetReport(ctx, id)
+}
+
+func (s *reconciliationService) ListReports(ctx context.Context, limit, offset int) ([]*reconciliation.Report, error) {
+	return s.repo.ListReports(ctx, limit, offset)
+}
+
+func (s *reconciliationService) ListDiscrepancies(ctx context.Context, reportID string, status reconciliation.DiscrepancyStatus, limit, offset int) ([]*reconciliation.Discrepancy, error) {
+	return s.repo.ListDiscrepancies(ctx, reportID, status, limit, offset)
+}
+
+func (s *reconciliationService) ResolveDiscrepancy(ctx context.Context, id, userID string, req *reconciliation.ResolveRequest) (*reconciliation.Discrepancy, error) {
+	if req == nil || req.Resolution == "" {
+		s.logger.Error("resolution is required", "id", id)
+		return nil, errors.New("resolution is required")
+	}
+
+	d, err := s.repo.GetDiscrepancy(ctx, id)
+	if err != nil {
+		s.logger.Error("discrepancy not found", "id", id)
+		return nil, fmt.Errorf("discrepancy with id %s not found", id)
+	}
+
+	if d.Status == reconciliation.DiscrepancyStatusResolved {
+		s.logger.Error("discrepancy is already resolved", "id", id)
+		return nil, errors.New("discrepancy is already resolved")
+	}
+
+	now := time.Now()
+	d.Status = reconciliation.DiscrepancyStatusResolved
+	d.Resolution = req.Resolution
+	d.ResolvedBy = userID
+	d.ResolvedAt = &now
+
+	if err := s.repo.UpdateDiscrepancy(ctx, d); err != nil {
+		s.logger.Error("Failed to resolve discrepancy", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to resolve discrepancy: %w", err)
+	}
+
+	return d, nil
+}
+
+func amountPtr(amount float64) *float64 {
+	return &amount
+}
@@ -0,0 +1,363 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
+	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/core/services"
+)
+
+func TestReconciliationService_ReconcileFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockReconciliationRepository(ctrl)
+	mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+	mockRefundRepo := ports.NewMockRefundRepository(ctrl)
+	mockSource := ports.NewMockSettlementFileSource(ctrl)
+	mockLocker := ports.NewMockLocker(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	reconciliationService := services.NewReconciliationService(mockRepo, mockPaymentRepo, mockRefundRepo, mockSource, mockLocker, mockLogger)
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	var created []reconciliation.DiscrepancyType
+
+	tests := []struct {
+		name                  string
+		lines                 []*reconciliation.SettlementLine
+		setupMocks            func()
+		expectedMatched       int
+		expectedDiscrepancies []reconciliation.DiscrepancyType
+	}{
+		{
+			name: "All lines match",
+			lines: []*reconciliation.SettlementLine{
+				{AcquirerReference: "REF1", RecordType: reconciliation.RecordTypePayment, Amount: 100.0, Currency: "USD", ProcessedAt: day},
+				{AcquirerReference: "REF2", RecordType: reconciliation.RecordTypeRefund, Amount: 25.0, Currency: "USD", ProcessedAt: day},
+			},
+			setupMocks: func() {
+				mockPaymentRepo.EXPECT().GetByAcquirerReference(gomock.Any(), "REF1").Return(&payment.Payment{
+					ID: "payment1", Amount: 75.0, Currency: "USD", SettlementCurrency: "USD", FXRate: 1, AcquirerReference: "REF1",
+				}, nil)
+				mockRefundRepo.EXPECT().TotalByPayment(gomock.Any(), "payment1", refund.RefundStatusCompleted).Return(25.0, nil)
+				mockRefundRepo.EXPECT().GetByAcquirerReference(gomock.Any(), "REF2").Return(&refund.Refund{
+					ID: "refund1", Amount: 25.0, Currency: "USD", AcquirerReference: "REF2",
+				}, nil)
+				mockPaymentRepo.EXPECT().ListProcessedBetween(gomock.Any(), day, day).Return([]*payment.Payment{
+					{ID: "payment1", AcquirerReference: "REF1"},
+				}, nil)
+				mockRefundRepo.EXPECT().ListProcessedBetween(gomock.Any(), day, day).Return([]*refund.Refund{
+					{ID: "refund1", AcquirerReference: "REF2"},
+				}, nil)
+			},
+			expectedMatched: 2,
+		},
+		{
+			name: "Settlement in converted currency",
+			lines: []*reconciliation.SettlementLine{
+				{AcquirerReference: "REF1", RecordType: reconciliation.RecordTypePayment, Amount: 108.70, Currency: "USD", ProcessedAt: day},
+			},
+			setupMocks: func() {
+				mockPaymentRepo.EXPECT().GetByAcquirerReference(gomock.Any(), "REF1").Return(&payment.Payment{
+					ID: "payment1", Amount: 100.0, Currency: "EUR", SettlementCurrency: "USD", FXRate: 1.087, AcquirerReference: "REF1",
+				}, nil)
+				mockRefundRepo.EXPECT().TotalByPayment(gomock.Any(), "payment1", refund.RefundStatusCompleted).Return(0.0, nil)
+				mockPaymentRepo.EXPECT().ListProcessedBetween(gomock.Any(), day, day).Return(nil, nil)
+				mockRefundRepo.EXPECT().ListProcessedBetween(gomock.Any(), day, day).Return(nil, nil)
+			},
+			expectedMatched: 1,
+		},
+		{
+			name: "Mismatches on both sides",
+			lines: []*reconciliation.SettlementLine{
+				{AcquirerReference: "REF1", RecordType: reconciliation.RecordTypePayment, Amount: 90.0, Currency: "USD", ProcessedAt: day},
+				{AcquirerReference: "UNKNOWN", RecordType: reconciliation.RecordTypePayment, Amount: 10.0, Currency: "USD", ProcessedAt: day},
+			},
+			setupMocks: func() {
+				mockPaymentRepo.EXPECT().GetByAcquirerReference(gomock.Any(), "REF1").Return(&payment.Payment{
+					ID: "payment1", Amount: 100.0, Currency: "USD", SettlementCurrency: "USD", FXRate: 1, AcquirerReference: "REF1",
+				}, nil)
+				mockRefundRepo.EXPECT().TotalByPayment(gomock.Any(), "payment1", refund.RefundStatusCompleted).Return(0.0, nil)
+				mockPaymentRepo.EXPECT().GetByAcquirerReference(gomock.Any(), "UNKNOWN").Return(nil, payment.ErrNotFound)
+				mockPaymentRepo.EXPECT().ListProcessedBetween(gomock.Any(), day, day).Return([]*payment.Payment{
+					{ID: "payment1", AcquirerReference: "REF1"},
+					{ID: "payment2", Amount: 40.0, Currency: "USD", AcquirerReference: "REF3"},
+				}, nil)
+				mockRefundRepo.EXPECT().TotalByPayment(gomock.Any(), "payment2", refund.RefundStatusCompleted).Return(0.0, nil)
+				mockRefundRepo.EXPECT().ListProcessedBetween(gomock.Any(), day, day).Return(nil, nil)
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).Times(3)
+			},
+			expectedDiscrepancies: []reconciliation.DiscrepancyType{
+				reconciliation.DiscrepancyAmountMismatch,
+				reconciliation.DiscrepancyMissingInternally,
+				reconciliation.DiscrepancyMissingAtAcquirer,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created = nil
+			unlocked := false
+			mockLocker.EXPECT().TryLock(gomock.Any(), "settlement-reconciliation:settlement.csv").Return(func() { unlocked = true }, true, nil)
+			mockRepo.EXPECT().GetReportByFileName(gomock.Any(), "settlement.csv").Return(nil, reconciliation.ErrReportNotFound)
+			mockRepo.EXPECT().CreateReport(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, r *reconciliation.Report) error {
+				r.ID = "report1"
+				return nil
+			})
+			mockSource.EXPECT().ReadFile(gomock.Any(), "settlement.csv").Return(tt.lines, nil)
+			mockRepo.EXPECT().CompleteReport(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, r *reconciliation.Report, discrepancies []*reconciliation.Discrepancy) error {
+					assert.Equal(t, reconciliation.ReportStatusCompleted, r.Status)
+					for _, d := range discrepancies {
+						assert.Equal(t, "report1", d.ReportID)
+						created = append(created, d.Type)
+					}
+					return nil
+				})
+			tt.setupMocks()
+
+			report, err := reconciliationService.ReconcileFile(context.Background(), "settlement.csv")
+
+			assert.NoError(t, err)
+			assert.Equal(t, reconciliation.ReportStatusCompleted, report.Status)
+			assert.Equal(t, len(tt.lines), report.TotalLines)
+			assert.Equal(t, tt.expectedMatched, report.MatchedCount)
+			assert.Equal(t, len(tt.expectedDiscrepancies), report.DiscrepancyCount)
+			assert.Equal(t, tt.expectedDiscrepancies, created)
+			assert.True(t, unlocked)
+		})
+	}
+}
+
+func TestReconciliationService_ReconcileFile_LookupFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockReconciliationRepository(ctrl)
+	mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+	mockRefundRepo := ports.NewMockRefundRepository(ctrl)
+	mockSource := ports.NewMockSettlementFileSource(ctrl)
+	mockLocker := ports.NewMockLocker(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	reconciliationService := services.NewReconciliationService(mockRepo, mockPaymentRepo, mockRefundRepo, mockSource, mockLocker, mockLogger)
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		line       *reconciliation.SettlementLine
+		setupMocks func()
+	}{
+		{
+			name: "Payment lookup fails",
+			line: &reconciliation.SettlementLine{AcquirerReference: "REF1", RecordType: reconciliation.RecordTypePayment, Amount: 10.0, Currency: "USD", ProcessedAt: day},
+			setupMocks: func() {
+				mockPaymentRepo.EXPECT().GetByAcquirerReference(gomock.Any(), "REF1").Return(nil, errors.New("connection reset"))
+			},
+		},
+		{
+			name: "Refund lookup fails",
+			line: &reconciliation.SettlementLine{AcquirerReference: "REF2", RecordType: reconciliation.RecordTypeRefund, Amount: 10.0, Currency: "USD", ProcessedAt: day},
+			setupMocks: func() {
+				mockRefundRepo.EXPECT().GetByAcquirerReference(gomock.Any(), "REF2").Return(nil, errors.New("connection reset"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLocker.EXPECT().TryLock(gomock.Any(), "settlement-reconciliation:settlement.csv").Return(func() {}, true, nil)
+			mockRepo.EXPECT().GetReportByFileName(gomock.Any(), "settlement.csv").Return(nil, reconciliation.ErrReportNotFound)
+			mockRepo.EXPECT().CreateReport(gomock.Any(), gomock.Any()).Return(nil)
+			mockSource.EXPECT().ReadFile(gomock.Any(), "settlement.csv").Return([]*reconciliation.SettlementLine{tt.line}, nil)
+			mockRepo.EXPECT().UpdateReport(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, r *reconciliation.Report) error {
+				assert.Equal(t, reconciliation.ReportStatusFailed, r.Status)
+				return nil
+			})
+			mockRepo.EXPECT().CompleteReport(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			tt.setupMocks()
+
+			report, err := reconciliationService.ReconcileFile(context.Background(), "settlement.csv")
+
+			assert.Error(t, err)
+			assert.Equal(t, reconciliation.ReportStatusFailed, report.Status)
+			assert.Zero(t, report.DiscrepancyCount)
+		})
+	}
+}
+
+func TestReconciliationService_RunReconciliation_SkipsCompletedFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockReconciliationRepository(ctrl)
+	mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+	mockRefundRepo := ports.NewMockRefundRepository(ctrl)
+	mockSource := ports.NewMockSettlementFileSource(ctrl)
+	mockLocker := ports.NewMockLocker(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	reconciliationService := services.NewReconciliationService(mockRepo, mockPaymentRepo, mockRefundRepo, mockSource, mockLocker, mockLogger)
+
+	mockSource.EXPECT().ListFiles(gomock.Any()).Return([]string{"done.csv"}, nil)
+	mockRepo.EXPECT().GetReportByFileName(gomock.Any(), "done.csv").Return(&reconciliation.Report{
+		ID:     "report1",
+		Status: reconciliation.ReportStatusCompleted,
+	}, nil)
+
+	reports, err := reconciliationService.RunReconciliation(context.Background())
+
+	assert.NoError(t, err)
+	assert.Empty(t, reports)
+}
+
+func TestReconciliationService_RunReconciliation_SkipsLockedFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockReconciliationRepository(ctrl)
+	mockSource := ports.NewMockSettlementFileSource(ctrl)
+	mockLocker := ports.NewMockLocker(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	reconciliationService := services.NewReconciliationService(mockRepo, ports.NewMockPaymentRepository(ctrl), ports.NewMockRefundRepository(ctrl), mockSource, mockLocker, mockLogger)
+
+	// Another replica holds the file, so it is neither read nor reported.
+	mockSource.EXPECT().ListFiles(gomock.Any()).Return([]string{"settlement.csv"}, nil)
+	mockRepo.EXPECT().GetReportByFileName(gomock.Any(), "settlement.csv").Return(nil, reconciliation.ErrReportNotFound)
+	mockLocker.EXPECT().TryLock(gomock.Any(), "settlement-reconciliation:settlement.csv").Return(nil, false, nil)
+	mockLogger.EXPECT().Debug("Settlement file skipped, another instance is reconciling it", "file", "settlement.csv")
+
+	reports, err := reconciliationService.RunReconciliation(context.Background())
+
+	assert.NoError(t, err)
+	assert.Empty(t, reports)
+}
+
+func TestReconciliationService_ReconcileFile_ReportLookupFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockReconciliationRepository(ctrl)
+	mockSource := ports.NewMockSettlementFileSource(ctrl)
+	mockLocker := ports.NewMockLocker(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	reconciliationService := services.NewReconciliationService(mockRepo, ports.NewMockPaymentRepository(ctrl), ports.NewMockRefundRepository(ctrl), mockSource, mockLocker, mockLogger)
+
+	// A failed lookup is not a missing report, so no second report is
+	// created for the file.
+	lookupErr := errors.New("connection reset")
+	mockLocker.EXPECT().TryLock(gomock.Any(), "settlement-reconciliation:settlement.csv").Return(func() {}, true, nil)
+	mockRepo.EXPECT().GetReportByFileName(gomock.Any(), "settlement.csv").Return(nil, lookupErr).Times(2)
+	mockLogger.EXPECT().Error("Failed to get reconciliation report", "error", lookupErr, "file", "settlement.csv").Times(2)
+
+	report, err := reconciliationService.ReconcileFile(context.Background(), "settlement.csv")
+	assert.EqualError(t, err, "failed to get reconciliation report: connection reset")
+	assert.Nil(t, report)
+
+	mockSource.EXPECT().ListFiles(gomock.Any()).Return([]string{"settlement.csv"}, nil)
+
+	reports, err := reconciliationService.RunReconciliation(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, reports)
+}
+
+func TestReconciliationService_ResolveDiscrepancy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockReconciliationRepository(ctrl)
+	mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+	mockRefundRepo := ports.NewMockRefundRepository(ctrl)
+	mockSource := ports.NewMockSettlementFileSource(ctrl)
+	mockLocker := ports.NewMockLocker(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	reconciliationService := services.NewReconciliationService(mockRepo, mockPaymentRepo, mockRefundRepo, mockSource, mockLocker, mockLogger)
+
+	tests := []struct {
+		name          string
+		id            string
+		request       *reconciliation.ResolveRequest
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name:    "Successful resolution",
+			id:      "discrepancy1",
+			request: &reconciliation.ResolveRequest{Resolution: "Acquirer fee, confirmed with bank"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetDiscrepancy(gomock.Any(), "discrepancy1").Return(&reconciliation.Discrepancy{
+					ID:     "discrepancy1",
+					Status: reconciliation.DiscrepancyStatusOpen,
+				}, nil)
+				mockRepo.EXPECT().UpdateDiscrepancy(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name:    "Missing resolution",
+			id:      "discrepancy1",
+			request: &reconciliation.ResolveRequest{},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("resolution is required"),
+		},
+		{
+			name:    "Discrepancy not found",
+			id:      "nonexistent",
+			request: &reconciliation.ResolveRequest{Resolution: "n/a"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetDiscrepancy(gomock.Any(), "nonexistent").Return(nil, errors.New("discrepancy not found"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("discrepancy with id nonexistent not found"),
+		},
+		{
+			name:    "Already resolved",
+			id:      "discrepancy2",
+			request: &reconciliation.ResolveRequest{Resolution: "again"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetDiscrepancy(gomock.Any(), "discrepancy2").Return(&reconciliation.Discrepancy{
+					ID:     "discrepancy2",
+					Status: reconciliation.DiscrepancyStatusResolved,
+				}, nil)
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("discrepancy is already resolved"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			d, err := reconciliationService.ResolveDiscrepancy(context.Background(), tt.id, "user123", tt.request)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+				assert.Nil(t, d)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, reconciliation.DiscrepancyStatusResolved, d.Status)
+				assert.Equal(t, "user123", d.ResolvedBy)
+				assert.NotNil(t, d.ResolvedAt)
+			}
+		})
+	}
+}
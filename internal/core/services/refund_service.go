@@ -7,24 +7,30 @@ import (
 	"sync"
 	"time"
 
+	"github.com/popeskul/payment-gateway/internal/core/domain/currency"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
 	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
 	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
 )
 
 type refundService struct {
-	refundRepo  ports.RefundRepository
-	paymentRepo ports.PaymentRepository
-	logger      ports.Logger
+	refundRepo    ports.RefundRepository
+	paymentRepo   ports.PaymentRepository
+	merchantRepo  ports.MerchantRepository
+	acquiringBank ports.AcquiringBank
+	logger        ports.Logger
 
 	mu sync.RWMutex
 }
 
-func NewRefundService(refundRepo ports.RefundRepository, paymentRepo ports.PaymentRepository, logger ports.Logger) ports.RefundService {
+func NewRefundService(refundRepo ports.RefundRepository, paymentRepo ports.PaymentRepository, merchantRepo ports.MerchantRepository, acquiringBank ports.AcquiringBank, logger ports.Logger) ports.RefundService {
 	return &refundService{
-		refundRepo:  refundRepo,
-		paymentRepo: paymentRepo,
-		logger:      logger,
+		refundRepo:    refundRepo,
+		paymentRepo:   paymentRepo,
+		merchantRepo:  merchantRepo,
+		acquiringBank: acquiringBank,
+		logger:        logger,
 	}
 }
 
@@ -48,11 +54,40 @@ func (s *refundService) CreateRefund(ctx context.Context, r *refund.Refund) erro
 		return errors.New("can only refund completed payments")
 	}
 
+	if err := s.checkRefundWindow(ctx, p); err != nil {
+		return err
+	}
+
 	if r.Amount > p.Amount {
 		s.logger.Error("refund amount cannot be greater than payment amount")
 		return errors.New("refund amount cannot be greater than payment amount")
 	}
 
+	// Pending and processing refunds hold their amount until they are
+	// completed or canceled.
+	var pending float64
+	for _, status := range []refund.RefundStatus{refund.RefundStatusPending, refund.RefundStatusProcessing} {
+		total, err := s.refundRepo.TotalByPayment(ctx, p.ID, status)
+		if err != nil {
+			s.logger.Error("failed to get pending refunds", "error", err)
+			return fmt.Errorf("failed to get pending refunds: %w", err)
+		}
+		pending += total
+	}
+
+	if r.Amount > currency.Round(p.Amount-pending, p.Currency) {
+		s.logger.Error("refund amount exceeds refundable balance", "payment_id", p.ID, "pending", pending)
+		return errors.New("refund amount exceeds refundable balance")
+	}
+
+	// Refunds always go back in the presentment currency and settle at the
+	// rate that was locked onto the payment.
+	r.CustomerID = p.CustomerID
+	r.Mode = p.Mode
+	r.Currency = p.Currency
+	r.SettlementCurrency = p.SettlementCurrency
+	r.SettlementAmount = currency.Round(r.Amount*p.FXRate, p.SettlementCurrency)
+
 	r.CreatedAt = time.Now()
 	r.UpdatedAt = time.Now()
 	r.Status = refund.RefundStatusPending
@@ -95,6 +130,9 @@ func (s *refundService) ListRefunds(ctx context.Context, paymentID string, limit
 	return s.refundRepo.List(ctx, paymentID, limit, offset)
 }
 
+// ProcessRefund claims a pending refund before sending it to the acquirer, so
+// it cannot be canceled, or processed by another request, while the acquirer
+// is refunding the money. If the acquirer fails the refund is pending again.
 func (s *refundService) ProcessRefund(ctx context.Context, refundID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -105,6 +143,10 @@ func (s *refundService) ProcessRefund(ctx context.Context, refundID string) erro
 		return fmt.Errorf("failed to get refund: %w", err)
 	}
 
+	if r.Status == refund.RefundStatusCanceled {
+		return errors.New("refund has been canceled")
+	}
+
 	if r.Status != refund.RefundStatusPending {
 		return errors.New("refund is not in pending status")
 	}
@@ -115,11 +157,91 @@ func (s *refundService) ProcessRefund(ctx context.Context, refundID string) erro
 		return fmt.Errorf("failed to get payment: %w", err)
 	}
 
+	if err := s.refundRepo.TransitionStatus(ctx, refundID, refund.RefundStatusPending, refund.RefundStatusProcessing); err != nil {
+		s.logger.Error("failed to claim refund", "error", err, "refund_id", refundID)
+		return fmt.Errorf("failed to claim refund: %w", err)
+	}
+	r.Status = refund.RefundStatusProcessing
+
+	err = s.acquiringBank.ProcessRefund(ctx, r)
+	if err != nil {
+		s.logger.Error("Failed to process refund", "error", err, "refund_id", refundID)
+		if releaseErr := s.refundRepo.TransitionStatus(ctx, refundID, refund.RefundStatusProcessing, refund.RefundStatusPending); releaseErr != nil {
+			s.logger.Error("Failed to release refund", "error", releaseErr, "refund_id", refundID)
+		}
+		return err
+	}
+
+	now := time.Now()
 	r.Status = refund.RefundStatusCompleted
-	r.UpdatedAt = time.Now()
+	r.ProcessedAt = &now
+	r.UpdatedAt = now
 
 	p.Amount -= r.Amount
-	p.UpdatedAt = time.Now()
+	p.SettlementAmount = currency.Round(p.SettlementAmount-r.SettlementAmount, p.SettlementCurrency)
+	p.UpdatedAt = now
+
+	if err := s.refundRepo.UpdateWithTransaction(ctx, r, p); err != nil {
+		// The acquirer has refunded the money; the refund stays processing
+		// so it is not sent again, and reconciliation reports the mismatch.
+		s.logger.Error("Failed to complete refund", "error", err, "refund_id", refundID, "acquirer_reference", r.AcquirerReference)
+		return fmt.Errorf("failed to complete refund: %w", err)
+	}
+
+	return nil
+}
+
+// CancelRefund withdraws a pending refund. Its amount stops counting against
+// the payment's refundable balance as soon as the status changes.
+func (s *refundService) CancelRefund(ctx context.Context, refundID string) (*refund.Refund, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.refundRepo.GetByID(ctx, refundID)
+	if err != nil {
+		s.logger.Error("failed to get refund", "error", err)
+		return nil, fmt.Errorf("failed to get refund: %w", err)
+	}
+
+	if r.Status != refund.RefundStatusPending {
+		s.logger.Error("only pending refunds can be canceled", "refund_id", refundID, "status", r.Status)
+		return nil, errors.New("only pending refunds can be canceled")
+	}
+
+	if err := s.refundRepo.TransitionStatus(ctx, refundID, refund.RefundStatusPending, refund.RefundStatusCanceled); err != nil {
+		s.logger.Error("failed to cancel refund", "error", err, "refund_id", refundID)
+		return nil, fmt.Errorf("failed to cancel refund: %w", err)
+	}
+
+	r.Status = refund.RefundStatusCanceled
+	r.UpdatedAt = time.Now()
+
+	return r, nil
+}
+
+// checkRefundWindow rejects refunds requested later than the merchant's
+// refund window, counted from when the payment was captured.
+func (s *refundService) checkRefundWindow(ctx context.Context, p *payment.Payment) error {
+	m, err := s.merchantRepo.GetByID(ctx, p.MerchantID)
+	if err != nil {
+		s.logger.Error("merchant not found", "id", p.MerchantID)
+		return fmt.Errorf("merchant with id %s not found", p.MerchantID)
+	}
+
+	days := m.ProcessingSettings().RefundWindowDays
+	if days <= 0 {
+		return nil
+	}
+
+	capturedAt := p.CreatedAt
+	if p.ProcessedAt != nil {
+		capturedAt = *p.ProcessedAt
+	}
+
+	if time.Since(capturedAt) > time.Duration(days)*24*time.Hour {
+		s.logger.Warn("Refund rejected by merchant settings", "code", merchant.LimitRefundWindowExpired, "payment_id", p.ID)
+		return merchant.NewLimitError(merchant.LimitRefundWindowExpired, "payments can only be refunded within %d days", days)
+	}
 
-	return s.refundRepo.UpdateWithTransaction(ctx, r, p)
+	return nil
 }
@@ -3,12 +3,15 @@ package services_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
 	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
 	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
@@ -21,9 +24,11 @@ func TestRefundService_CreateRefund(t *testing.T) {
 
 	mockRefundRepo := ports.NewMockRefundRepository(ctrl)
 	mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockAcquiringBank := ports.NewMockAcquiringBank(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 
-	refundService := services.NewRefundService(mockRefundRepo, mockPaymentRepo, mockLogger)
+	refundService := services.NewRefundService(mockRefundRepo, mockPaymentRepo, mockMerchantRepo, mockAcquiringBank, mockLogger)
 
 	tests := []struct {
 		name          string
@@ -43,10 +48,55 @@ func TestRefundService_CreateRefund(t *testing.T) {
 					Amount: 100.0,
 					Status: payment.PaymentStatusCompleted,
 				}, nil)
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).Return(&merchant.Merchant{}, nil)
+				mockRefundRepo.EXPECT().TotalByPayment(gomock.Any(), "payment123", refund.RefundStatusPending).Return(0.0, nil)
+				mockRefundRepo.EXPECT().TotalByPayment(gomock.Any(), "payment123", refund.RefundStatusProcessing).Return(0.0, nil)
 				mockRefundRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
 			},
 			expectedError: nil,
 		},
+		{
+			name: "Pending and processing refunds hold the balance",
+			refund: &refund.Refund{
+				PaymentID: "payment123",
+				Amount:    50.0,
+			},
+			setupMocks: func() {
+				mockPaymentRepo.EXPECT().GetByID(gomock.Any(), "payment123").Return(&payment.Payment{
+					ID:     "payment123",
+					Amount: 100.0,
+					Status: payment.PaymentStatusCompleted,
+				}, nil)
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).Return(&merchant.Merchant{}, nil)
+				mockRefundRepo.EXPECT().TotalByPayment(gomock.Any(), "payment123", refund.RefundStatusPending).Return(20.0, nil)
+				mockRefundRepo.EXPECT().TotalByPayment(gomock.Any(), "payment123", refund.RefundStatusProcessing).Return(40.0, nil)
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("refund amount exceeds refundable balance"),
+		},
+		{
+			name: "Refund window expired",
+			refund: &refund.Refund{
+				PaymentID: "payment123",
+				Amount:    50.0,
+			},
+			setupMocks: func() {
+				processedAt := time.Now().Add(-31 * 24 * time.Hour)
+				mockPaymentRepo.EXPECT().GetByID(gomock.Any(), "payment123").Return(&payment.Payment{
+					ID:          "payment123",
+					MerchantID:  "merchant123",
+					Amount:      100.0,
+					Status:      payment.PaymentStatusCompleted,
+					ProcessedAt: &processedAt,
+				}, nil)
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), "merchant123").Return(&merchant.Merchant{
+					ID:       "merchant123",
+					Settings: &merchant.Settings{RefundWindowDays: 30},
+				}, nil)
+				mockLogger.EXPECT().Warn("Refund rejected by merchant settings", "code", merchant.LimitRefundWindowExpired, "payment_id", "payment123")
+			},
+			expectedError: merchant.NewLimitError(merchant.LimitRefundWindowExpired, "payments can only be refunded within 30 days"),
+		},
 		{
 			name:   "Nil refund",
 			refund: nil,
@@ -95,6 +145,7 @@ func TestRefundService_CreateRefund(t *testing.T) {
 					Amount: 100.0,
 					Status: payment.PaymentStatusCompleted,
 				}, nil)
+				mockMerchantRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).Return(&merchant.Merchant{}, nil)
 				mockLogger.EXPECT().Error(gomock.Any())
 			},
 			expectedError: errors.New("refund amount cannot be greater than payment amount"),
@@ -116,15 +167,52 @@ func TestRefundService_CreateRefund(t *testing.T) {
 	}
 }
 
+func TestRefundService_CreateRefund_UsesLockedFXRate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRefundRepo := ports.NewMockRefundRepository(ctrl)
+	mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockAcquiringBank := ports.NewMockAcquiringBank(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	refundService := services.NewRefundService(mockRefundRepo, mockPaymentRepo, mockMerchantRepo, mockAcquiringBank, mockLogger)
+
+	mockPaymentRepo.EXPECT().GetByID(gomock.Any(), "payment123").Return(&payment.Payment{
+		ID:                 "payment123",
+		Amount:             100.0,
+		Currency:           "EUR",
+		SettlementAmount:   108.70,
+		SettlementCurrency: "USD",
+		FXRate:             1.087,
+		Status:             payment.PaymentStatusCompleted,
+	}, nil)
+	mockMerchantRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).Return(&merchant.Merchant{}, nil)
+	mockRefundRepo.EXPECT().TotalByPayment(gomock.Any(), "payment123", refund.RefundStatusPending).Return(0.0, nil)
+	mockRefundRepo.EXPECT().TotalByPayment(gomock.Any(), "payment123", refund.RefundStatusProcessing).Return(0.0, nil)
+	mockRefundRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	r := &refund.Refund{PaymentID: "payment123", Amount: 40.0}
+	err := refundService.CreateRefund(context.Background(), r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", r.Currency)
+	assert.Equal(t, "USD", r.SettlementCurrency)
+	assert.Equal(t, 43.48, r.SettlementAmount)
+}
+
 func TestRefundService_GetRefund(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRefundRepo := ports.NewMockRefundRepository(ctrl)
 	mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockAcquiringBank := ports.NewMockAcquiringBank(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 
-	refundService := services.NewRefundService(mockRefundRepo, mockPaymentRepo, mockLogger)
+	refundService := services.NewRefundService(mockRefundRepo, mockPaymentRepo, mockMerchantRepo, mockAcquiringBank, mockLogger)
 
 	tests := []struct {
 		name           string
@@ -182,9 +270,11 @@ func TestRefundService_UpdateRefund(t *testing.T) {
 
 	mockRefundRepo := ports.NewMockRefundRepository(ctrl)
 	mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockAcquiringBank := ports.NewMockAcquiringBank(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 
-	refundService := services.NewRefundService(mockRefundRepo, mockPaymentRepo, mockLogger)
+	refundService := services.NewRefundService(mockRefundRepo, mockPaymentRepo, mockMerchantRepo, mockAcquiringBank, mockLogger)
 
 	tests := []struct {
 		name          string
@@ -250,9 +340,11 @@ func TestRefundService_ListRefunds(t *testing.T) {
 
 	mockRefundRepo := ports.NewMockRefundRepository(ctrl)
 	mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockAcquiringBank := ports.NewMockAcquiringBank(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 
-	refundService := services.NewRefundService(mockRefundRepo, mockPaymentRepo, mockLogger)
+	refundService := services.NewRefundService(mockRefundRepo, mockPaymentRepo, mockMerchantRepo, mockAcquiringBank, mockLogger)
 
 	tests := []struct {
 		name           string
@@ -316,9 +408,11 @@ func TestRefundService_ProcessRefund(t *testing.T) {
 
 	mockRefundRepo := ports.NewMockRefundRepository(ctrl)
 	mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockAcquiringBank := ports.NewMockAcquiringBank(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 
-	refundService := services.NewRefundService(mockRefundRepo, mockPaymentRepo, mockLogger)
+	refundService := services.NewRefundService(mockRefundRepo, mockPaymentRepo, mockMerchantRepo, mockAcquiringBank, mockLogger)
 
 	tests := []struct {
 		name          string
@@ -340,10 +434,55 @@ func TestRefundService_ProcessRefund(t *testing.T) {
 					ID:     "payment123",
 					Amount: 100.0,
 				}, nil)
-				mockRefundRepo.EXPECT().UpdateWithTransaction(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				gomock.InOrder(
+					mockRefundRepo.EXPECT().TransitionStatus(gomock.Any(), "refund123", refund.RefundStatusPending, refund.RefundStatusProcessing).Return(nil),
+					mockAcquiringBank.EXPECT().ProcessRefund(gomock.Any(), gomock.Any()).Return(nil),
+					mockRefundRepo.EXPECT().UpdateWithTransaction(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil),
+				)
 			},
 			expectedError: nil,
 		},
+		{
+			name:     "Acquiring bank declines refund",
+			refundID: "refund321",
+			setupMocks: func() {
+				mockRefundRepo.EXPECT().GetByID(gomock.Any(), "refund321").Return(&refund.Refund{
+					ID:        "refund321",
+					PaymentID: "payment123",
+					Amount:    50.0,
+					Status:    refund.RefundStatusPending,
+				}, nil)
+				mockPaymentRepo.EXPECT().GetByID(gomock.Any(), "payment123").Return(&payment.Payment{
+					ID:     "payment123",
+					Amount: 100.0,
+				}, nil)
+				mockRefundRepo.EXPECT().TransitionStatus(gomock.Any(), "refund321", refund.RefundStatusPending, refund.RefundStatusProcessing).Return(nil)
+				mockAcquiringBank.EXPECT().ProcessRefund(gomock.Any(), gomock.Any()).Return(errors.New("refund processing failed"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				mockRefundRepo.EXPECT().TransitionStatus(gomock.Any(), "refund321", refund.RefundStatusProcessing, refund.RefundStatusPending).Return(nil)
+			},
+			expectedError: errors.New("refund processing failed"),
+		},
+		{
+			name:     "Refund claimed concurrently",
+			refundID: "refund987",
+			setupMocks: func() {
+				mockRefundRepo.EXPECT().GetByID(gomock.Any(), "refund987").Return(&refund.Refund{
+					ID:        "refund987",
+					PaymentID: "payment123",
+					Amount:    50.0,
+					Status:    refund.RefundStatusPending,
+				}, nil)
+				mockPaymentRepo.EXPECT().GetByID(gomock.Any(), "payment123").Return(&payment.Payment{
+					ID:     "payment123",
+					Amount: 100.0,
+				}, nil)
+				mockRefundRepo.EXPECT().TransitionStatus(gomock.Any(), "refund987", refund.RefundStatusPending, refund.RefundStatusProcessing).
+					Return(errors.New("refund is not in pending status"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("failed to claim refund: refund is not in pending status"),
+		},
 		{
 			name:     "Refund not found",
 			refundID: "nonexistent",
@@ -364,6 +503,17 @@ func TestRefundService_ProcessRefund(t *testing.T) {
 			},
 			expectedError: errors.New("refund is not in pending status"),
 		},
+		{
+			name:     "Refund canceled",
+			refundID: "refund654",
+			setupMocks: func() {
+				mockRefundRepo.EXPECT().GetByID(gomock.Any(), "refund654").Return(&refund.Refund{
+					ID:     "refund654",
+					Status: refund.RefundStatusCanceled,
+				}, nil)
+			},
+			expectedError: errors.New("refund has been canceled"),
+		},
 		{
 			name:     "Payment not found",
 			refundID: "refund789",
@@ -394,3 +544,179 @@ func TestRefundService_ProcessRefund(t *testing.T) {
 		})
 	}
 }
+
+// TestRefundService_ProcessRefund_RaceWithCancel runs two refund services, as
+// two replicas would, against one refund. Only the repository's guarded status
+// changes keep them apart.
+func TestRefundService_ProcessRefund_RaceWithCancel(t *testing.T) {
+	type replicas struct {
+		processor, canceler ports.RefundService
+		acquiringBank       *ports.MockAcquiringBank
+		paymentRepo         *ports.MockPaymentRepository
+		status              func() refund.RefundStatus
+	}
+
+	setup := func(t *testing.T) *replicas {
+		ctrl := gomock.NewController(t)
+
+		mockRefundRepo := ports.NewMockRefundRepository(ctrl)
+		mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+		mockAcquiringBank := ports.NewMockAcquiringBank(ctrl)
+		mockLogger := ports.NewMockLogger(ctrl)
+		mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+		var mu sync.Mutex
+		status := refund.RefundStatusPending
+
+		mockRefundRepo.EXPECT().GetByID(gomock.Any(), "refund123").DoAndReturn(func(context.Context, string) (*refund.Refund, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return &refund.Refund{ID: "refund123", PaymentID: "payment123", Amount: 50.0, Status: status}, nil
+		}).AnyTimes()
+		mockRefundRepo.EXPECT().TransitionStatus(gomock.Any(), "refund123", gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, from, to refund.RefundStatus) error {
+				mu.Lock()
+				defer mu.Unlock()
+				if status != from {
+					return fmt.Errorf("refund is not in %s status", from)
+				}
+				status = to
+				return nil
+			}).AnyTimes()
+		mockRefundRepo.EXPECT().UpdateWithTransaction(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, r *refund.Refund, _ *payment.Payment) error {
+				mu.Lock()
+				defer mu.Unlock()
+				if status != refund.RefundStatusProcessing {
+					return errors.New("refund is not in processing status")
+				}
+				status = r.Status
+				return nil
+			}).AnyTimes()
+
+		return &replicas{
+			processor:     services.NewRefundService(mockRefundRepo, mockPaymentRepo, nil, mockAcquiringBank, mockLogger),
+			canceler:      services.NewRefundService(mockRefundRepo, mockPaymentRepo, nil, mockAcquiringBank, mockLogger),
+			acquiringBank: mockAcquiringBank,
+			paymentRepo:   mockPaymentRepo,
+			status: func() refund.RefundStatus {
+				mu.Lock()
+				defer mu.Unlock()
+				return status
+			},
+		}
+	}
+
+	t.Run("Cancel while the acquirer is refunding", func(t *testing.T) {
+		r := setup(t)
+		r.paymentRepo.EXPECT().GetByID(gomock.Any(), "payment123").Return(&payment.Payment{ID: "payment123", Amount: 100.0}, nil)
+		r.acquiringBank.EXPECT().ProcessRefund(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, ref *refund.Refund) error {
+			_, err := r.canceler.CancelRefund(ctx, ref.ID)
+			assert.EqualError(t, err, "only pending refunds can be canceled")
+			return nil
+		})
+
+		assert.NoError(t, r.processor.ProcessRefund(context.Background(), "refund123"))
+		assert.Equal(t, refund.RefundStatusCompleted, r.status())
+	})
+
+	t.Run("Cancel after the refund was read", func(t *testing.T) {
+		r := setup(t)
+		// The processor has seen a pending refund when the cancel goes through;
+		// its claim fails and the acquirer is never called.
+		r.paymentRepo.EXPECT().GetByID(gomock.Any(), "payment123").DoAndReturn(func(ctx context.Context, id string) (*payment.Payment, error) {
+			_, err := r.canceler.CancelRefund(ctx, "refund123")
+			assert.NoError(t, err)
+			return &payment.Payment{ID: id, Amount: 100.0}, nil
+		})
+
+		err := r.processor.ProcessRefund(context.Background(), "refund123")
+
+		assert.EqualError(t, err, "failed to claim refund: refund is not in pending status")
+		assert.Equal(t, refund.RefundStatusCanceled, r.status())
+	})
+}
+
+func TestRefundService_CancelRefund(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRefundRepo := ports.NewMockRefundRepository(ctrl)
+	mockPaymentRepo := ports.NewMockPaymentRepository(ctrl)
+	mockMerchantRepo := ports.NewMockMerchantRepository(ctrl)
+	mockAcquiringBank := ports.NewMockAcquiringBank(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	refundService := services.NewRefundService(mockRefundRepo, mockPaymentRepo, mockMerchantRepo, mockAcquiringBank, mockLogger)
+
+	tests := []struct {
+		name          string
+		refundID      string
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name:     "Successful cancellation",
+			refundID: "refund123",
+			setupMocks: func() {
+				mockRefundRepo.EXPECT().GetByID(gomock.Any(), "refund123").Return(&refund.Refund{
+					ID:     "refund123",
+					Status: refund.RefundStatusPending,
+				}, nil)
+				mockRefundRepo.EXPECT().TransitionStatus(gomock.Any(), "refund123", refund.RefundStatusPending, refund.RefundStatusCanceled).Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name:     "Refund not found",
+			refundID: "nonexistent",
+			setupMocks: func() {
+				mockRefundRepo.EXPECT().GetByID(gomock.Any(), "nonexistent").Return(nil, errors.New("refund not found"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("failed to get refund: refund not found"),
+		},
+		{
+			name:     "Refund already completed",
+			refundID: "refund456",
+			setupMocks: func() {
+				mockRefundRepo.EXPECT().GetByID(gomock.Any(), "refund456").Return(&refund.Refund{
+					ID:     "refund456",
+					Status: refund.RefundStatusCompleted,
+				}, nil)
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("only pending refunds can be canceled"),
+		},
+		{
+			name:     "Refund processed concurrently",
+			refundID: "refund789",
+			setupMocks: func() {
+				mockRefundRepo.EXPECT().GetByID(gomock.Any(), "refund789").Return(&refund.Refund{
+					ID:     "refund789",
+					Status: refund.RefundStatusPending,
+				}, nil)
+				mockRefundRepo.EXPECT().TransitionStatus(gomock.Any(), "refund789", refund.RefundStatusPending, refund.RefundStatusCanceled).
+					Return(errors.New("refund is not in pending status"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("failed to cancel refund: refund is not in pending status"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			r, err := refundService.CancelRefund(context.Background(), tt.refundID)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+				assert.Nil(t, r)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, refund.RefundStatusCanceled, r.Status)
+			}
+		})
+	}
+}
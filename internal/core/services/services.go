@@ -5,18 +5,36 @@ import (
 )
 
 type Services struct {
-	merchantService ports.MerchantService
-	paymentService  ports.PaymentService
-	refundService   ports.RefundService
-	userService     ports.UserService
+	merchantService       ports.MerchantService
+	paymentService        ports.PaymentService
+	refundService         ports.RefundService
+	userService           ports.UserService
+	reconciliationService ports.ReconciliationService
+	subscriptionService   ports.SubscriptionService
+	customerService       ports.CustomerService
+	memberService         ports.MemberService
+	apiKeyService         ports.APIKeyService
+	onboardingService     ports.OnboardingService
+	exportService         ports.MerchantExportService
+	auditService          ports.AuditService
+	oauthService          ports.OAuthService
 }
 
-func NewServices(merchantService ports.MerchantService, paymentService ports.PaymentService, refundService ports.RefundService, userService ports.UserService) *Services {
+func NewServices(merchantService ports.MerchantService, paymentService ports.PaymentService, refundService ports.RefundService, userService ports.UserService, reconciliationService ports.ReconciliationService, subscriptionService ports.SubscriptionService, customerService ports.CustomerService, memberService ports.MemberService, apiKeyService ports.APIKeyService, onboardingService ports.OnboardingService, exportService ports.MerchantExportService, auditService ports.AuditService, oauthService ports.OAuthService) *Services {
 	return &Services{
-		merchantService: merchantService,
-		paymentService:  paymentService,
-		refundService:   refundService,
-		userService:     userService,
+		merchantService:       merchantService,
+		paymentService:        paymentService,
+		refundService:         refundService,
+		userService:           userService,
+		reconciliationService: reconciliationService,
+		subscriptionService:   subscriptionService,
+		customerService:       customerService,
+		memberService:         memberService,
+		apiKeyService:         apiKeyService,
+		onboardingService:     onboardingService,
+		exportService:         exportService,
+		auditService:          auditService,
+		oauthService:          oauthService,
 	}
 }
 
@@ -35,3 +53,39 @@ func (s *Services) Refunds() ports.RefundService {
 func (s *Services) Users() ports.UserService {
 	return s.userService
 }
+
+func (s *Services) Reconciliation() ports.ReconciliationService {
+	return s.reconciliationService
+}
+
+func (s *Services) Subscriptions() ports.SubscriptionService {
+	return s.subscriptionService
+}
+
+func (s *Services) Customers() ports.CustomerService {
+	return s.customerService
+}
+
+func (s *Services) Members() ports.MemberService {
+	return s.memberService
+}
+
+func (s *Services) APIKeys() ports.APIKeyService {
+	return s.apiKeyService
+}
+
+func (s *Services) Onboarding() ports.OnboardingService {
+	return s.onboardingService
+}
+
+func (s *Services) Exports() ports.MerchantExportService {
+	return s.exportService
+}
+
+func (s *Services) Audit() ports.AuditService {
+	return s.auditService
+}
+
+func (s *Services) OAuth() ports.OAuthService {
+	return s.oauthService
+}
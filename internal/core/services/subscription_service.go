@@ -0,0 +1,461 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/subscription"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/validator"
+)
+
+// billingLockKey keeps replicas from charging the same subscription twice.
+const billingLockKey = "subscription-billing"
+
+type subscriptionService struct {
+	repo           ports.SubscriptionRepository
+	paymentService ports.PaymentService
+	locker         ports.Locker
+	logger         ports.Logger
+	batchSize      int
+	retryIntervals []time.Duration
+
+	mu sync.Mutex
+}
+
+func NewSubscriptionService(repo ports.SubscriptionRepository, paymentService ports.PaymentService, locker ports.Locker, logger ports.Logger, batchSize int, retryIntervals []time.Duration) ports.SubscriptionService {
+	return &subscriptionService{
+		repo:           repo,
+		paymentService: paymentService,
+		locker:         locker,
+		logger:         logger,
+		batchSize:      batchSize,
+		retryIntervals: retryIntervals,
+	}
+}
+
+func (s *subscriptionService) CreatePlan(ctx context.Context, p *subscription.Plan) error {
+	if p == nil {
+		s.logger.Error("plan cannot be nil")
+		return errors.New("plan cannot be nil")
+	}
+
+	if p.Name == "" || p.MerchantID == "" {
+		s.logger.Error("plan name and merchant are required")
+		return errors.New("plan name and merchant are required")
+	}
+
+	if p.Amount <= 0 {
+		s.logger.Error("plan amount must be positive", "amount", p.Amount)
+		return errors.New("plan amount must be positive")
+	}
+
+	if !validator.IsCurrency(p.Currency) {
+		s.logger.Error("unsupported currency", "currency", p.Currency)
+		return fmt.Errorf("unsupported currency %q", p.Currency)
+	}
+
+	switch p.Interval {
+	case subscription.IntervalDay, subscription.IntervalWeek, subscription.IntervalMonth, subscription.IntervalYear:
+	default:
+		s.logger.Error("invalid plan interval", "interval", p.Interval)
+		return fmt.Errorf("invalid plan interval %q", p.Interval)
+	}
+
+	if p.IntervalCount == 0 {
+		p.IntervalCount = 1
+	}
+	if p.IntervalCount < 0 || p.TrialPeriodDays < 0 {
+		s.logger.Error("interval count and trial period cannot be negative")
+		return errors.New("interval count and trial period cannot be negative")
+	}
+
+	p.Mode = p.Mode.OrLive()
+	p.Active = true
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = time.Now()
+
+	return s.repo.CreatePlan(ctx, p)
+}
+
+func (s *subscriptionService) GetPlan(ctx context.Context, id string) (*subscription.Plan, error) {
+	return s.repo.GetPlan(ctx, id)
+}
+
+func (s *subscriptionService) ListPlans(ctx context.Context, merchantID string, m mode.Mode, limit, offset int) ([]*subscription.Plan, error) {
+	return s.repo.ListPlans(ctx, merchantID, m, limit, offset)
+}
+
+// CreateSubscription starts a trial when the plan has one; otherwise the
+// first period is charged straight away and a declined charge fails the
+// request rather than leaving an unpaid subscription behind.
+func (s *subscriptionService) CreateSubscription(ctx context.Context, sub *subscription.Subscription) error {
+	if sub == nil {
+		s.logger.Error("subscription cannot be nil")
+		return errors.New("subscription cannot be nil")
+	}
+
+	if sub.PaymentMethod == "" || sub.PaymentToken == "" {
+		s.logger.Error("payment method and token are required")
+		return errors.New("payment method and token are required")
+	}
+
+	// The plan must be in the subscription's mode, which renewals inherit.
+	sub.Mode = sub.Mode.OrLive()
+	plan, err := s.repo.GetPlan(ctx, sub.PlanID)
+	if err != nil || plan.Mode.OrLive() != sub.Mode {
+		s.logger.Error("plan not found", "id", sub.PlanID)
+		return fmt.Errorf("plan with id %s not found", sub.PlanID)
+	}
+
+	if !plan.Active {
+		s.logger.Error("plan is not active", "id", plan.ID)
+		return errors.New("plan is not active")
+	}
+
+	if sub.MerchantID != "" && sub.MerchantID != plan.MerchantID {
+		s.logger.Error("plan belongs to another merchant", "plan_id", plan.ID)
+		return errors.New("plan belongs to another merchant")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	sub.MerchantID = plan.MerchantID
+	sub.CurrentPeriodStart = now
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+
+	if plan.TrialPeriodDays > 0 {
+		trialEnd := now.AddDate(0, 0, plan.TrialPeriodDays)
+		sub.Status = subscription.StatusTrialing
+		sub.TrialEnd = &trialEnd
+		sub.CurrentPeriodEnd = trialEnd
+		sub.BillingAnchorDay = trialEnd.Day()
+	} else {
+		sub.Status = subscription.StatusActive
+		sub.BillingAnchorDay = now.Day()
+		sub.CurrentPeriodEnd = plan.NextPeriodEnd(now, sub.BillingAnchorDay)
+	}
+
+	if err := s.repo.Create(ctx, sub); err != nil {
+		s.logger.Error("Failed to create subscription", "error", err)
+		return fmt.Errorf("failed to create subscription: %w", err)
+	}
+	s.recordEvent(ctx, sub, subscription.EventCreated, "", "")
+
+	if sub.Status == subscription.StatusTrialing {
+		return nil
+	}
+
+	p, err := s.charge(ctx, sub, plan, plan.Amount)
+	if err != nil {
+		now = time.Now()
+		sub.Status = subscription.StatusCanceled
+		sub.CanceledAt = &now
+		sub.UpdatedAt = now
+		if updateErr := s.repo.Update(ctx, sub); updateErr != nil {
+			s.logger.Error("Failed to cancel subscription", "error", updateErr, "subscription_id", sub.ID)
+		}
+		s.recordEvent(ctx, sub, subscription.EventPaymentFailed, paymentID(p), err.Error())
+		return fmt.Errorf("initial payment failed: %w", err)
+	}
+
+	markPaid(sub, plan.Amount)
+	if err := s.repo.Update(ctx, sub); err != nil {
+		s.logger.Error("Failed to update subscription", "error", err, "subscription_id", sub.ID)
+		return fmt.Errorf("failed to update subscription: %w", err)
+	}
+	s.recordEvent(ctx, sub, subscription.EventRenewed, paymentID(p), "")
+
+	return nil
+}
+
+func (s *subscriptionService) GetSubscription(ctx context.Context, id string) (*subscription.Subscription, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *subscriptionService) ListSubscriptions(ctx context.Context, merchantID string, m mode.Mode, limit, offset int) ([]*subscription.Subscription, error) {
+	return s.repo.List(ctx, merchantID, m, limit, offset)
+}
+
+// ChangePlan switches the plan immediately. The difference for the rest of
+// the current period is carried on the subscription and settled with the
+// next renewal, so downgrades become a credit instead of a refund.
+func (s *subscriptionService) ChangePlan(ctx context.Context, id string, req *subscription.ChangePlanRequest) (*subscription.Subscription, error) {
+	if req == nil || req.PlanID == "" {
+		s.logger.Error("plan id is required")
+		return nil, errors.New("plan id is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("subscription not found", "id", id)
+		return nil, fmt.Errorf("subscription with id %s not found", id)
+	}
+
+	if sub.Status == subscription.StatusCanceled || sub.Status == subscription.StatusUnpaid {
+		s.logger.Error("subscription is not active", "id", id, "status", sub.Status)
+		return nil, fmt.Errorf("cannot change plan of a %s subscription", sub.Status)
+	}
+
+	if sub.PlanID == req.PlanID {
+		return sub, nil
+	}
+
+	current, err := s.repo.GetPlan(ctx, sub.PlanID)
+	if err != nil {
+		s.logger.Error("plan not found", "id", sub.PlanID)
+		return nil, fmt.Errorf("plan with id %s not found", sub.PlanID)
+	}
+
+	next, err := s.repo.GetPlan(ctx, req.PlanID)
+	if err != nil {
+		s.logger.Error("plan not found", "id", req.PlanID)
+		return nil, fmt.Errorf("plan with id %s not found", req.PlanID)
+	}
+
+	if !next.Active || next.MerchantID != sub.MerchantID || next.Mode != sub.Mode {
+		s.logger.Error("plan is not available", "id", next.ID)
+		return nil, errors.New("plan is not available for this subscription")
+	}
+
+	if next.Currency != current.Currency {
+		s.logger.Error("plan currency mismatch", "from", current.Currency, "to", next.Currency)
+		return nil, errors.New("cannot change to a plan in a different currency")
+	}
+
+	now := time.Now()
+	// A trial has not been paid for, so there is nothing to prorate.
+	if sub.Status != subscription.StatusTrialing {
+		sub.ProrationBalance += subscription.Prorate(current.Amount, next.Amount, current.Currency, sub.CurrentPeriodStart, sub.CurrentPeriodEnd, now)
+	}
+	sub.PlanID = next.ID
+	sub.UpdatedAt = now
+
+	if err := s.repo.Update(ctx, sub); err != nil {
+		s.logger.Error("Failed to change plan", "error", err, "subscription_id", id)
+		return nil, fmt.Errorf("failed to change plan: %w", err)
+	}
+	s.recordEvent(ctx, sub, subscription.EventPlanChanged, "", fmt.Sprintf("changed from plan %s to %s", current.ID, next.ID))
+
+	return sub, nil
+}
+
+func (s *subscriptionService) CancelSubscription(ctx context.Context, id string, req *subscription.CancelRequest) (*subscription.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("subscription not found", "id", id)
+		return nil, fmt.Errorf("subscription with id %s not found", id)
+	}
+
+	if sub.Status == subscription.StatusCanceled {
+		s.logger.Error("subscription is already canceled", "id", id)
+		return nil, errors.New("subscription is already canceled")
+	}
+
+	now := time.Now()
+	message := "canceled immediately"
+	if req != nil && req.AtPeriodEnd && sub.Status != subscription.StatusUnpaid {
+		sub.CancelAtPeriodEnd = true
+		message = "cancels at period end"
+	} else {
+		sub.Status = subscription.StatusCanceled
+		sub.CanceledAt = &now
+		sub.NextRetryAt = nil
+	}
+	sub.UpdatedAt = now
+
+	if err := s.repo.Update(ctx, sub); err != nil {
+		s.logger.Error("Failed to cancel subscription", "error", err, "subscription_id", id)
+		return nil, fmt.Errorf("failed to cancel subscription: %w", err)
+	}
+	s.recordEvent(ctx, sub, subscription.EventCanceled, "", message)
+
+	return sub, nil
+}
+
+func (s *subscriptionService) ListEvents(ctx context.Context, subscriptionID string, limit, offset int) ([]*subscription.Event, error) {
+	return s.repo.ListEvents(ctx, subscriptionID, limit, offset)
+}
+
+// RunBilling renews every subscription whose period has ended and retries
+// failed renewals whose dunning delay has passed. A subscription that fails
+// to bill is logged and picked up again on the next run.
+func (s *subscriptionService) RunBilling(ctx context.Context) (*subscription.BillingResult, error) {
+	unlock, acquired, err := s.locker.TryLock(ctx, billingLockKey)
+	if err != nil {
+		s.logger.Error("Failed to acquire billing lock", "error", err)
+		return nil, fmt.Errorf("failed to acquire billing lock: %w", err)
+	}
+	if !acquired {
+		s.logger.Debug("Subscription billing skipped, another instance holds the lock")
+		return &subscription.BillingResult{Skipped: true}, nil
+	}
+	defer unlock()
+
+	due, err := s.repo.ListDue(ctx, time.Now(), s.batchSize)
+	if err != nil {
+		s.logger.Error("Failed to list due subscriptions", "error", err)
+		return nil, fmt.Errorf("failed to list due subscriptions: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := &subscription.BillingResult{}
+	for _, sub := range due {
+		if err := s.bill(ctx, sub, result); err != nil {
+			s.logger.Error("Failed to bill subscription", "error", err, "subscription_id", sub.ID)
+		}
+	}
+
+	if result.Renewed > 0 || result.Failed > 0 || result.Canceled > 0 {
+		s.logger.Info("Subscription billing completed", "renewed", result.Renewed, "failed", result.Failed, "canceled", result.Canceled)
+	}
+
+	return result, nil
+}
+
+func (s *subscriptionService) bill(ctx context.Context, sub *subscription.Subscription, result *subscription.BillingResult) error {
+	now := time.Now()
+
+	if sub.CancelAtPeriodEnd {
+		sub.Status = subscription.StatusCanceled
+		sub.CanceledAt = &now
+		sub.NextRetryAt = nil
+		sub.UpdatedAt = now
+		if err := s.repo.Update(ctx, sub); err != nil {
+			return err
+		}
+		s.recordEvent(ctx, sub, subscription.EventCanceled, "", "canceled at period end")
+		result.Canceled++
+		return nil
+	}
+
+	plan, err := s.repo.GetPlan(ctx, sub.PlanID)
+	if err != nil {
+		return err
+	}
+
+	if sub.Status == subscription.StatusTrialing {
+		s.recordEvent(ctx, sub, subscription.EventTrialEnded, "", "")
+	}
+
+	// A retry covers the period that failed to renew, so it starts where that
+	// period ended rather than at the time of the retry.
+	if sub.Status != subscription.StatusPastDue {
+		sub.CurrentPeriodStart = sub.CurrentPeriodEnd
+		sub.CurrentPeriodEnd = plan.NextPeriodEnd(sub.CurrentPeriodStart, sub.BillingAnchorDay)
+	}
+
+	amount := plan.Amount + sub.ProrationBalance
+	p, err := s.charge(ctx, sub, plan, amount)
+	if err == nil {
+		markPaid(sub, amount)
+		if err := s.repo.Update(ctx, sub); err != nil {
+			return err
+		}
+		result.Renewed++
+		s.recordEvent(ctx, sub, subscription.EventRenewed, paymentID(p), "")
+		return nil
+	}
+
+	result.Failed++
+	sub.FailedAttempts++
+	sub.UpdatedAt = now
+
+	eventType := subscription.EventPaymentFailed
+	if sub.FailedAttempts > len(s.retryIntervals) {
+		sub.Status = subscription.StatusUnpaid
+		sub.NextRetryAt = nil
+		eventType = subscription.EventUnpaid
+	} else {
+		retryAt := now.Add(s.retryIntervals[sub.FailedAttempts-1])
+		sub.Status = subscription.StatusPastDue
+		sub.NextRetryAt = &retryAt
+	}
+
+	if updateErr := s.repo.Update(ctx, sub); updateErr != nil {
+		return updateErr
+	}
+	s.recordEvent(ctx, sub, eventType, paymentID(p), err.Error())
+
+	return nil
+}
+
+// charge collects amount with the subscription's stored token. Amounts fully
+// covered by proration credit are not sent to the acquirer.
+func (s *subscriptionService) charge(ctx context.Context, sub *subscription.Subscription, plan *subscription.Plan, amount float64) (*payment.Payment, error) {
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	p := &payment.Payment{
+		MerchantID:     sub.MerchantID,
+		Mode:           sub.Mode,
+		Amount:         amount,
+		Currency:       plan.Currency,
+		PaymentMethod:  sub.PaymentMethod,
+		PaymentToken:   sub.PaymentToken,
+		SubscriptionID: sub.ID,
+		Description:    fmt.Sprintf("Subscription %s: %s", sub.ID, plan.Name),
+	}
+
+	if err := s.paymentService.CreatePayment(ctx, p); err != nil {
+		return nil, err
+	}
+	if err := s.paymentService.ProcessPayment(ctx, p.ID); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+// markPaid clears dunning state after a successful charge of amount. Any
+// credit left over from a downgrade carries into the next period.
+func markPaid(sub *subscription.Subscription, amount float64) {
+	sub.ProrationBalance = 0
+	if amount < 0 {
+		sub.ProrationBalance = amount
+	}
+	sub.Status = subscription.StatusActive
+	sub.FailedAttempts = 0
+	sub.NextRetryAt = nil
+	sub.UpdatedAt = time.Now()
+}
+
+// recordEvent is best-effort: losing an event must not undo a charge that
+// has already gone through.
+func (s *subscriptionService) recordEvent(ctx context.Context, sub *subscription.Subscription, eventType subscription.EventType, paymentID, message string) {
+	event := &subscription.Event{
+		SubscriptionID: sub.ID,
+		MerchantID:     sub.MerchantID,
+		Type:           eventType,
+		PaymentID:      paymentID,
+		Message:        message,
+		CreatedAt:      time.Now(),
+	}
+	if err := s.repo.CreateEvent(ctx, event); err != nil {
+		s.logger.Error("Failed to record subscription event", "error", err, "subscription_id", sub.ID, "type", eventType)
+	}
+}
+
+func paymentID(p *payment.Payment) string {
+	if p == nil {
+		return ""
+	}
+	return p.ID
+}
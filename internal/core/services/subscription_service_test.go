@@ -0,0 +1,451 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/subscription"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/core/services"
+)
+
+var retryIntervals = []time.Duration{24 * time.Hour, 72 * time.Hour}
+
+func TestSubscriptionService_CreatePlan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockSubscriptionRepository(ctrl)
+	mockPaymentService := ports.NewMockPaymentService(ctrl)
+	mockLocker := ports.NewMockLocker(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	subscriptionService := services.NewSubscriptionService(mockRepo, mockPaymentService, mockLocker, mockLogger, 100, retryIntervals)
+
+	tests := []struct {
+		name          string
+		plan          *subscription.Plan
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name: "Successful creation",
+			plan: &subscription.Plan{MerchantID: "merchant1", Name: "Pro", Amount: 20.0, Currency: "USD", Interval: subscription.IntervalMonth},
+			setupMocks: func() {
+				mockRepo.EXPECT().CreatePlan(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "Invalid interval",
+			plan: &subscription.Plan{MerchantID: "merchant1", Name: "Pro", Amount: 20.0, Currency: "USD", Interval: "fortnight"},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New(`invalid plan interval "fortnight"`),
+		},
+		{
+			name: "Non-positive amount",
+			plan: &subscription.Plan{MerchantID: "merchant1", Name: "Free", Amount: 0, Currency: "USD", Interval: subscription.IntervalMonth},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("plan amount must be positive"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := subscriptionService.CreatePlan(context.Background(), tt.plan)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, tt.plan.Active)
+				assert.Equal(t, 1, tt.plan.IntervalCount)
+			}
+		})
+	}
+}
+
+func TestSubscriptionService_CreateSubscription(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockSubscriptionRepository(ctrl)
+	mockPaymentService := ports.NewMockPaymentService(ctrl)
+	mockLocker := ports.NewMockLocker(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	subscriptionService := services.NewSubscriptionService(mockRepo, mockPaymentService, mockLocker, mockLogger, 100, retryIntervals)
+
+	plan := &subscription.Plan{ID: "plan1", MerchantID: "merchant1", Name: "Pro", Amount: 20.0, Currency: "USD", Interval: subscription.IntervalMonth, IntervalCount: 1, Active: true}
+	trialPlan := &subscription.Plan{ID: "plan2", MerchantID: "merchant1", Name: "Pro", Amount: 20.0, Currency: "USD", Interval: subscription.IntervalMonth, IntervalCount: 1, TrialPeriodDays: 14, Active: true}
+	testPlan := &subscription.Plan{ID: "plan3", MerchantID: "merchant1", Mode: mode.Test, Name: "Pro", Amount: 20.0, Currency: "USD", Interval: subscription.IntervalMonth, IntervalCount: 1, Active: true}
+
+	tests := []struct {
+		name           string
+		planID         string
+		setupMocks     func()
+		expectedStatus subscription.Status
+		expectedError  error
+	}{
+		{
+			name:   "Trial period defers the first charge",
+			planID: "plan2",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetPlan(gomock.Any(), "plan2").Return(trialPlan, nil)
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateEvent(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedStatus: subscription.StatusTrialing,
+		},
+		{
+			name:   "First period charged immediately",
+			planID: "plan1",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetPlan(gomock.Any(), "plan1").Return(plan, nil)
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				mockPaymentService.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, p *payment.Payment) error {
+					assert.Equal(t, 20.0, p.Amount)
+					assert.Equal(t, "tok_123", p.PaymentToken)
+					assert.Equal(t, mode.Live, p.Mode)
+					p.ID = "payment1"
+					return nil
+				})
+				mockPaymentService.EXPECT().ProcessPayment(gomock.Any(), "payment1").Return(nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateEvent(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+			},
+			expectedStatus: subscription.StatusActive,
+		},
+		{
+			name:   "Declined first charge cancels the subscription",
+			planID: "plan1",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetPlan(gomock.Any(), "plan1").Return(plan, nil)
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				mockPaymentService.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, p *payment.Payment) error {
+					p.ID = "payment1"
+					return nil
+				})
+				mockPaymentService.EXPECT().ProcessPayment(gomock.Any(), "payment1").Return(errors.New("card declined"))
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateEvent(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+			},
+			expectedStatus: subscription.StatusCanceled,
+			expectedError:  errors.New("initial payment failed: card declined"),
+		},
+		{
+			name:   "Plan not found",
+			planID: "nonexistent",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetPlan(gomock.Any(), "nonexistent").Return(nil, errors.New("plan not found"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("plan with id nonexistent not found"),
+		},
+		{
+			name:   "Plan from the other mode",
+			planID: "plan3",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetPlan(gomock.Any(), "plan3").Return(testPlan, nil)
+				mockLogger.EXPECT().Error("plan not found", "id", "plan3")
+			},
+			expectedError: errors.New("plan with id plan3 not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			sub := &subscription.Subscription{PlanID: tt.planID, PaymentMethod: "credit_card", PaymentToken: "tok_123"}
+			err := subscriptionService.CreateSubscription(context.Background(), sub)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.expectedStatus != "" {
+				assert.Equal(t, tt.expectedStatus, sub.Status)
+			}
+		})
+	}
+}
+
+func TestSubscriptionService_ChangePlan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockSubscriptionRepository(ctrl)
+	mockPaymentService := ports.NewMockPaymentService(ctrl)
+	mockLocker := ports.NewMockLocker(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	subscriptionService := services.NewSubscriptionService(mockRepo, mockPaymentService, mockLocker, mockLogger, 100, retryIntervals)
+
+	now := time.Now()
+	basic := &subscription.Plan{ID: "basic", MerchantID: "merchant1", Amount: 10.0, Currency: "USD", Interval: subscription.IntervalMonth, Active: true}
+	pro := &subscription.Plan{ID: "pro", MerchantID: "merchant1", Amount: 30.0, Currency: "USD", Interval: subscription.IntervalMonth, Active: true}
+	euro := &subscription.Plan{ID: "euro", MerchantID: "merchant1", Amount: 30.0, Currency: "EUR", Interval: subscription.IntervalMonth, Active: true}
+
+	activeSub := func() *subscription.Subscription {
+		// Exactly half of the period remains.
+		return &subscription.Subscription{
+			ID:                 "sub1",
+			MerchantID:         "merchant1",
+			PlanID:             "basic",
+			Status:             subscription.StatusActive,
+			CurrentPeriodStart: now.Add(-15 * 24 * time.Hour),
+			CurrentPeriodEnd:   now.Add(15 * 24 * time.Hour),
+		}
+	}
+
+	tests := []struct {
+		name            string
+		planID          string
+		setupMocks      func()
+		expectedBalance float64
+		expectedError   error
+	}{
+		{
+			name:   "Upgrade mid-period is prorated",
+			planID: "pro",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "sub1").Return(activeSub(), nil)
+				mockRepo.EXPECT().GetPlan(gomock.Any(), "basic").Return(basic, nil)
+				mockRepo.EXPECT().GetPlan(gomock.Any(), "pro").Return(pro, nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateEvent(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedBalance: 10.0,
+		},
+		{
+			name:   "Different currency is rejected",
+			planID: "euro",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "sub1").Return(activeSub(), nil)
+				mockRepo.EXPECT().GetPlan(gomock.Any(), "basic").Return(basic, nil)
+				mockRepo.EXPECT().GetPlan(gomock.Any(), "euro").Return(euro, nil)
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("cannot change to a plan in a different currency"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			sub, err := subscriptionService.ChangePlan(context.Background(), "sub1", &subscription.ChangePlanRequest{PlanID: tt.planID})
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+				assert.Nil(t, sub)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.planID, sub.PlanID)
+				assert.InDelta(t, tt.expectedBalance, sub.ProrationBalance, 0.01)
+			}
+		})
+	}
+}
+
+func TestSubscriptionService_RunBilling(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockSubscriptionRepository(ctrl)
+	mockPaymentService := ports.NewMockPaymentService(ctrl)
+	mockLocker := ports.NewMockLocker(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	subscriptionService := services.NewSubscriptionService(mockRepo, mockPaymentService, mockLocker, mockLogger, 100, retryIntervals)
+
+	unlock := func() {}
+	periodEnd := time.Now().Add(-time.Minute)
+	plan := &subscription.Plan{ID: "plan1", MerchantID: "merchant1", Name: "Pro", Amount: 20.0, Currency: "USD", Interval: subscription.IntervalMonth, IntervalCount: 1, Active: true}
+
+	var sub *subscription.Subscription
+
+	tests := []struct {
+		name             string
+		subscription     *subscription.Subscription
+		setupMocks       func()
+		expectedResult   *subscription.BillingResult
+		expectedStatus   subscription.Status
+		expectedAttempts int
+	}{
+		{
+			name: "Renewal applies proration balance",
+			subscription: &subscription.Subscription{
+				ID: "sub1", MerchantID: "merchant1", PlanID: "plan1", Status: subscription.StatusActive,
+				CurrentPeriodEnd: periodEnd, ProrationBalance: 5.0,
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetPlan(gomock.Any(), "plan1").Return(plan, nil)
+				mockPaymentService.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, p *payment.Payment) error {
+					assert.Equal(t, 25.0, p.Amount)
+					assert.Equal(t, "sub1", p.SubscriptionID)
+					p.ID = "payment1"
+					return nil
+				})
+				mockPaymentService.EXPECT().ProcessPayment(gomock.Any(), "payment1").Return(nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateEvent(gomock.Any(), gomock.Any()).Return(nil)
+				mockLogger.EXPECT().Info(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedResult: &subscription.BillingResult{Renewed: 1},
+			expectedStatus: subscription.StatusActive,
+		},
+		{
+			name: "Failed renewal schedules a dunning retry",
+			subscription: &subscription.Subscription{
+				ID: "sub1", MerchantID: "merchant1", PlanID: "plan1", Status: subscription.StatusActive, CurrentPeriodEnd: periodEnd,
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetPlan(gomock.Any(), "plan1").Return(plan, nil)
+				mockPaymentService.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, p *payment.Payment) error {
+					p.ID = "payment1"
+					return nil
+				})
+				mockPaymentService.EXPECT().ProcessPayment(gomock.Any(), "payment1").Return(errors.New("card declined"))
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateEvent(gomock.Any(), gomock.Any()).Return(nil)
+				mockLogger.EXPECT().Info(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedResult:   &subscription.BillingResult{Failed: 1},
+			expectedStatus:   subscription.StatusPastDue,
+			expectedAttempts: 1,
+		},
+		{
+			name: "Exhausted retries mark the subscription unpaid",
+			subscription: &subscription.Subscription{
+				ID: "sub1", MerchantID: "merchant1", PlanID: "plan1", Status: subscription.StatusPastDue, CurrentPeriodEnd: periodEnd,
+				FailedAttempts: 2,
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetPlan(gomock.Any(), "plan1").Return(plan, nil)
+				mockPaymentService.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, p *payment.Payment) error {
+					p.ID = "payment1"
+					return nil
+				})
+				mockPaymentService.EXPECT().ProcessPayment(gomock.Any(), "payment1").Return(errors.New("card declined"))
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateEvent(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e *subscription.Event) error {
+					assert.Equal(t, subscription.EventUnpaid, e.Type)
+					return nil
+				})
+				mockLogger.EXPECT().Info(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedResult:   &subscription.BillingResult{Failed: 1},
+			expectedStatus:   subscription.StatusUnpaid,
+			expectedAttempts: 3,
+		},
+		{
+			name: "Cancel at period end",
+			subscription: &subscription.Subscription{
+				ID: "sub1", MerchantID: "merchant1", PlanID: "plan1", Status: subscription.StatusActive, CurrentPeriodEnd: periodEnd,
+				CancelAtPeriodEnd: true,
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateEvent(gomock.Any(), gomock.Any()).Return(nil)
+				mockLogger.EXPECT().Info(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedResult: &subscription.BillingResult{Canceled: 1},
+			expectedStatus: subscription.StatusCanceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub = tt.subscription
+			mockLocker.EXPECT().TryLock(gomock.Any(), "subscription-billing").Return(unlock, true, nil)
+			mockRepo.EXPECT().ListDue(gomock.Any(), gomock.Any(), 100).Return([]*subscription.Subscription{sub}, nil)
+			tt.setupMocks()
+
+			result, err := subscriptionService.RunBilling(context.Background())
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedResult, result)
+			assert.Equal(t, tt.expectedStatus, sub.Status)
+			assert.Equal(t, tt.expectedAttempts, sub.FailedAttempts)
+		})
+	}
+}
+
+func TestSubscriptionService_RunBilling_KeepsBillingAnchor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockSubscriptionRepository(ctrl)
+	mockPaymentService := ports.NewMockPaymentService(ctrl)
+	mockLocker := ports.NewMockLocker(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	subscriptionService := services.NewSubscriptionService(mockRepo, mockPaymentService, mockLocker, mockLogger, 100, retryIntervals)
+
+	plan := &subscription.Plan{ID: "plan1", MerchantID: "merchant1", Name: "Pro", Amount: 20.0, Currency: "USD", Interval: subscription.IntervalMonth, IntervalCount: 1, Active: true}
+	sub := &subscription.Subscription{
+		ID: "sub1", MerchantID: "merchant1", PlanID: "plan1", Status: subscription.StatusActive,
+		CurrentPeriodStart: time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC),
+		CurrentPeriodEnd:   time.Date(2025, time.January, 31, 0, 0, 0, 0, time.UTC),
+		BillingAnchorDay:   31,
+	}
+
+	mockRepo.EXPECT().GetPlan(gomock.Any(), "plan1").Return(plan, nil).AnyTimes()
+	mockPaymentService.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, p *payment.Payment) error {
+		p.ID = "payment1"
+		return nil
+	}).AnyTimes()
+	mockPaymentService.EXPECT().ProcessPayment(gomock.Any(), "payment1").Return(nil).AnyTimes()
+	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockRepo.EXPECT().CreateEvent(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	for _, want := range []time.Time{
+		time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC),
+	} {
+		mockLocker.EXPECT().TryLock(gomock.Any(), "subscription-billing").Return(func() {}, true, nil)
+		mockRepo.EXPECT().ListDue(gomock.Any(), gomock.Any(), 100).Return([]*subscription.Subscription{sub}, nil)
+
+		result, err := subscriptionService.RunBilling(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, &subscription.BillingResult{Renewed: 1}, result)
+		assert.Equal(t, want, sub.CurrentPeriodEnd)
+	}
+}
+
+func TestSubscriptionService_RunBilling_SkipsWhenLocked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockSubscriptionRepository(ctrl)
+	mockPaymentService := ports.NewMockPaymentService(ctrl)
+	mockLocker := ports.NewMockLocker(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+
+	subscriptionService := services.NewSubscriptionService(mockRepo, mockPaymentService, mockLocker, mockLogger, 100, retryIntervals)
+
+	mockLocker.EXPECT().TryLock(gomock.Any(), "subscription-billing").Return(nil, false, nil)
+	mockLogger.EXPECT().Debug(gomock.Any())
+
+	result, err := subscriptionService.RunBilling(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, &subscription.BillingResult{Skipped: true}, result)
+}
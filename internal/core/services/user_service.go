@@ -2,24 +2,67 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/popeskul/payment-gateway/internal/core/domain/user"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
 )
 
+var (
+	errInvalidUserToken        = errors.New("invalid or expired token")
+	errInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	errTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	errTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+)
+
 type userService struct {
 	repo           ports.UserRepository
 	logger         ports.Logger
 	passwordHasher ports.PasswordHasher
+	mailer         ports.Mailer
+	// appURL is the front end the mailed links point to.
+	appURL          string
+	verificationTTL time.Duration
+	resetTTL        time.Duration
+	// totpIssuer names the account in authenticator apps.
+	totpIssuer string
+	mfaTTL     time.Duration
+	lockout    user.LockoutPolicy
+	passwords  user.PasswordPolicy
+}
+
+// UserServiceConfig holds the settings of the user service.
+type UserServiceConfig struct {
+	// AppURL is the front end the mailed links point to.
+	AppURL          string
+	VerificationTTL time.Duration
+	ResetTTL        time.Duration
+	// TOTPIssuer names the account in authenticator apps.
+	TOTPIssuer string
+	MFATTL     time.Duration
+	Lockout    user.LockoutPolicy
+	Passwords  user.PasswordPolicy
 }
 
-func NewUserService(repo ports.UserRepository, logger ports.Logger, passwordHasher ports.PasswordHasher) ports.UserService {
+func NewUserService(repo ports.UserRepository, logger ports.Logger, passwordHasher ports.PasswordHasher, mailer ports.Mailer, cfg UserServiceConfig) ports.UserService {
 	return &userService{
-		repo:           repo,
-		logger:         logger,
-		passwordHasher: passwordHasher,
+		repo:            repo,
+		logger:          logger,
+		passwordHasher:  passwordHasher,
+		mailer:          mailer,
+		appURL:          cfg.AppURL,
+		verificationTTL: cfg.VerificationTTL,
+		resetTTL:        cfg.ResetTTL,
+		totpIssuer:      cfg.TOTPIssuer,
+		mfaTTL:          cfg.MFATTL,
+		lockout:         cfg.Lockout,
+		passwords:       cfg.Passwords,
 	}
 }
 
@@ -30,6 +73,11 @@ func (s *userService) Register(ctx context.Context, req *user.RegisterRequest) (
 		return nil, errors.New("user with this email already exists")
 	}
 
+	if err := s.passwords.Check(req.Password); err != nil {
+		s.logger.Error("Password rejected by policy", "error", err)
+		return nil, err
+	}
+
 	hashedPassword, err := s.passwordHasher.HashPassword(req.Password)
 	if err != nil {
 		s.logger.Error("Failed to hash password", "error", err)
@@ -51,25 +99,136 @@ func (s *userService) Register(ctx context.Context, req *user.RegisterRequest) (
 		return nil, errors.New("failed to create user")
 	}
 
+	// The account exists either way; the user can ask for another link.
+	if err := s.sendVerification(ctx, newUser); err != nil {
+		s.logger.Error("Failed to send verification email", "error", err, "id", newUser.ID)
+	}
+
 	return newUser, nil
 }
 
-func (s *userService) Login(ctx context.Context, req *user.LoginRequest) (*user.User, error) {
+func (s *userService) Login(ctx context.Context, req *user.LoginRequest, ip string) (*user.User, error) {
+	throttles := loginThrottleKeys(req.Email, ip)
+	now := time.Now()
+
+	if err := s.loginThrottled(ctx, throttles, now); err != nil {
+		return nil, err
+	}
+
 	u, err := s.repo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		s.logger.Error("User not found", "email", req.Email)
-		return nil, errors.New("invalid credentials")
+		return nil, s.loginFailed(ctx, throttles, now)
 	}
 
 	err = s.passwordHasher.ComparePasswordAndHash(req.Password, u.PasswordHash)
 	if err != nil {
 		s.logger.Error("Invalid password", "email", req.Email)
-		return nil, errors.New("invalid credentials")
+		return nil, s.loginFailed(ctx, throttles, now)
+	}
+
+	if s.passwordHasher.NeedsRehash(u.PasswordHash) {
+		s.rehashPassword(ctx, u, req.Password)
+	}
+
+	// The IP's count is left alone: one good password must not clear the
+	// failures of an address trying many accounts. With two-factor
+	// authentication the account's count is only cleared once the second
+	// factor is checked too, so wrong codes keep adding up.
+	if !u.TwoFactorEnabled() {
+		if err := s.repo.ClearLoginThrottle(ctx, user.ThrottleAccount, throttles[0].key); err != nil {
+			s.logger.Error("Failed to clear login throttle", "error", err)
+		}
 	}
 
 	return u, nil
 }
 
+// rehashPassword replaces a hash made with an outdated algorithm or outdated
+// parameters, while the password is at hand. Failing to is logged only; the
+// old hash keeps working.
+func (s *userService) rehashPassword(ctx context.Context, u *user.User, password string) {
+	hashedPassword, err := s.passwordHasher.HashPassword(password)
+	if err != nil {
+		s.logger.Error("Failed to rehash password", "error", err, "id", u.ID)
+		return
+	}
+
+	u.PasswordHash = hashedPassword
+	if err := s.repo.Update(ctx, u); err != nil {
+		s.logger.Error("Failed to store rehashed password", "error", err, "id", u.ID)
+	}
+}
+
+// loginThrottled returns a *user.LoginThrottledError if any of the keys is
+// blocked.
+func (s *userService) loginThrottled(ctx context.Context, throttles []loginThrottleKey, now time.Time) error {
+	for _, k := range throttles {
+		t, err := s.repo.GetLoginThrottle(ctx, k.scope, k.key)
+		if err != nil {
+			s.logger.Error("Failed to get login throttle", "error", err, "scope", k.scope)
+			continue
+		}
+		if wait := t.RetryAfter(now); wait > 0 {
+			s.logger.Warn("Login throttled", "scope", k.scope, "key", k.key, "retry_after", wait)
+			return &user.LoginThrottledError{Scope: k.scope, RetryAfter: wait, Locked: s.lockout.Locked(t)}
+		}
+	}
+	return nil
+}
+
+// loginFailed counts a failed login and returns the error for it: a
+// *user.LoginThrottledError if the failure blocks further attempts.
+func (s *userService) loginFailed(ctx context.Context, throttles []loginThrottleKey, now time.Time) error {
+	var throttled *user.LoginThrottledError
+	for _, k := range throttles {
+		scope, key := k.scope, k.key
+		t, err := s.repo.RecordLoginFailure(ctx, scope, key, now, s.lockout.Window)
+		if err != nil {
+			s.logger.Error("Failed to record login failure", "error", err, "scope", scope)
+			continue
+		}
+
+		until := s.lockout.BlockedUntil(t)
+		if until == nil {
+			continue
+		}
+		if err := s.repo.LockLogin(ctx, scope, key, *until); err != nil {
+			s.logger.Error("Failed to lock login", "error", err, "scope", scope)
+			continue
+		}
+
+		locked := s.lockout.Locked(t)
+		if locked {
+			s.logger.Warn("Login locked out", "scope", scope, "key", key, "failures", t.Failures, "until", *until)
+		}
+		if wait := until.Sub(now); throttled == nil || wait > throttled.RetryAfter {
+			throttled = &user.LoginThrottledError{Scope: scope, RetryAfter: wait, Locked: locked, LockedNow: locked}
+		}
+	}
+
+	if throttled != nil {
+		return throttled
+	}
+	return errors.New("invalid credentials")
+}
+
+type loginThrottleKey struct {
+	scope user.ThrottleScope
+	key   string
+}
+
+// loginThrottleKeys returns the keys failed logins are counted under, the
+// account first. Email addresses are compared case-insensitively, so the
+// count can't be split across spellings.
+func loginThrottleKeys(email, ip string) []loginThrottleKey {
+	keys := []loginThrottleKey{{user.ThrottleAccount, strings.ToLower(strings.TrimSpace(email))}}
+	if ip != "" {
+		keys = append(keys, loginThrottleKey{user.ThrottleIP, ip})
+	}
+	return keys
+}
+
 func (s *userService) GetUserByID(ctx context.Context, id string) (*user.User, error) {
 	return s.repo.GetByID(ctx, id)
 }
@@ -107,6 +266,11 @@ func (s *userService) ChangePassword(ctx context.Context, id string, req *user.C
 		return errors.New("invalid old password")
 	}
 
+	if err := s.passwords.Check(req.NewPassword); err != nil {
+		s.logger.Error("Password rejected by policy", "error", err, "id", id)
+		return err
+	}
+
 	hashedPassword, err := s.passwordHasher.HashPassword(req.NewPassword)
 	if err != nil {
 		s.logger.Error("Failed to hash new password", "error", err)
@@ -124,3 +288,419 @@ func (s *userService) ChangePassword(ctx context.Context, id string, req *user.C
 
 	return nil
 }
+
+func (s *userService) SendVerificationEmail(ctx context.Context, id string) error {
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("User not found", "id", id)
+		return errors.New("user not found")
+	}
+
+	if u.EmailVerified() {
+		return errors.New("email address is already verified")
+	}
+
+	if err := s.sendVerification(ctx, u); err != nil {
+		s.logger.Error("Failed to send verification email", "error", err, "id", id)
+		return errors.New("failed to send verification email")
+	}
+
+	return nil
+}
+
+func (s *userService) VerifyEmail(ctx context.Context, req *user.VerifyEmailRequest) error {
+	if req == nil || req.Token == "" {
+		return errInvalidUserToken
+	}
+
+	u, err := s.useToken(ctx, req.Token, user.TokenEmailVerification)
+	if err != nil {
+		return err
+	}
+
+	if u.EmailVerified() {
+		return nil
+	}
+
+	now := time.Now()
+	u.EmailVerifiedAt = &now
+	u.UpdatedAt = now
+	if err := s.repo.Update(ctx, u); err != nil {
+		s.logger.Error("Failed to update user", "error", err, "id", u.ID)
+		return errors.New("failed to verify email address")
+	}
+
+	return nil
+}
+
+func (s *userService) RequestPasswordReset(ctx context.Context, req *user.ForgotPasswordRequest) error {
+	if req == nil || req.Email == "" {
+		return errors.New("email is required")
+	}
+
+	u, err := s.repo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		// Answer the same way for unknown addresses so the endpoint cannot be
+		// used to find out who has an account.
+		s.logger.Warn("Password reset requested for unknown email", "email", req.Email)
+		return nil
+	}
+
+	token, err := s.issueToken(ctx, u.ID, user.TokenPasswordReset, s.resetTTL)
+	if err != nil {
+		s.logger.Error("Failed to issue password reset token", "error", err, "id", u.ID)
+		return errors.New("failed to send password reset email")
+	}
+
+	body := fmt.Sprintf("Someone asked to reset the password of your account. If it was you, follow this link within %s:\n\n%s/reset-password?token=%s\n\nOtherwise you can ignore this email.",
+		s.resetTTL, s.appURL, token)
+	if err := s.mailer.Send(ctx, u.Email, "Reset your password", body); err != nil {
+		s.logger.Error("Failed to send password reset email", "error", err, "id", u.ID)
+		return errors.New("failed to send password reset email")
+	}
+
+	return nil
+}
+
+func (s *userService) ResetPassword(ctx context.Context, req *user.ResetPasswordRequest) (string, error) {
+	if req == nil || req.Token == "" {
+		return "", errInvalidUserToken
+	}
+	if req.NewPassword == "" {
+		return "", errors.New("new password is required")
+	}
+	// Checked before the token is used up, so that the user can try another
+	// password with the same link.
+	if err := s.passwords.Check(req.NewPassword); err != nil {
+		s.logger.Error("Password rejected by policy", "error", err)
+		return "", err
+	}
+
+	u, err := s.useToken(ctx, req.Token, user.TokenPasswordReset)
+	if err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := s.passwordHasher.HashPassword(req.NewPassword)
+	if err != nil {
+		s.logger.Error("Failed to hash new password", "error", err)
+		return "", errors.New("failed to reset password")
+	}
+
+	now := time.Now()
+	u.PasswordHash = hashedPassword
+	// Receiving the reset link proves the user controls the address.
+	if !u.EmailVerified() {
+		u.EmailVerifiedAt = &now
+	}
+	u.UpdatedAt = now
+	if err := s.repo.Update(ctx, u); err != nil {
+		s.logger.Error("Failed to update user password", "error", err, "id", u.ID)
+		return "", errors.New("failed to reset password")
+	}
+
+	// A reset also lifts a lockout of the account.
+	key := loginThrottleKeys(u.Email, "")[0].key
+	if err := s.repo.ClearLoginThrottle(ctx, user.ThrottleAccount, key); err != nil {
+		s.logger.Error("Failed to clear login throttle", "error", err, "id", u.ID)
+	}
+
+	return u.ID, nil
+}
+
+func (s *userService) BeginTwoFactorLogin(ctx context.Context, id string) (string, error) {
+	token, err := s.issueToken(ctx, id, user.TokenTwoFactorLogin, s.mfaTTL)
+	if err != nil {
+		s.logger.Error("Failed to issue MFA token", "error", err, "id", id)
+		return "", errors.New("failed to start two-factor login")
+	}
+	return token, nil
+}
+
+func (s *userService) CompleteTwoFactorLogin(ctx context.Context, req *user.TwoFactorLoginRequest, ip string) (*user.User, error) {
+	if req == nil || req.MFAToken == "" {
+		return nil, errInvalidUserToken
+	}
+
+	// The token is used up even when the code is wrong, so guessing a code
+	// takes a password check per attempt.
+	u, err := s.useToken(ctx, req.MFAToken, user.TokenTwoFactorLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wrong codes count as failed logins, so guessing codes runs into the
+	// same delays and lockout as guessing passwords.
+	throttles := loginThrottleKeys(u.Email, ip)
+	now := time.Now()
+	if err := s.loginThrottled(ctx, throttles, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkSecondFactor(ctx, u, req.Code, req.RecoveryCode); err != nil {
+		if !errors.Is(err, errInvalidTwoFactorCode) {
+			return nil, err
+		}
+		var throttled *user.LoginThrottledError
+		if errors.As(s.loginFailed(ctx, throttles, now), &throttled) {
+			return nil, throttled
+		}
+		return nil, err
+	}
+
+	if err := s.repo.ClearLoginThrottle(ctx, user.ThrottleAccount, throttles[0].key); err != nil {
+		s.logger.Error("Failed to clear login throttle", "error", err)
+	}
+
+	return u, nil
+}
+
+func (s *userService) EnrollTwoFactor(ctx context.Context, id string) (*user.TwoFactorEnrollment, error) {
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("User not found", "id", id)
+		return nil, errors.New("user not found")
+	}
+
+	if u.TwoFactorEnabled() {
+		return nil, errTwoFactorAlreadyEnabled
+	}
+
+	secret, err := user.GenerateTOTPSecret()
+	if err != nil {
+		s.logger.Error("Failed to generate TOTP secret", "error", err)
+		return nil, errors.New("failed to start two-factor enrollment")
+	}
+
+	u.TOTPSecret = secret
+	u.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, u); err != nil {
+		s.logger.Error("Failed to update user", "error", err, "id", id)
+		return nil, errors.New("failed to start two-factor enrollment")
+	}
+
+	return &user.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: user.TOTPProvisioningURI(s.totpIssuer, u.Email, secret),
+	}, nil
+}
+
+func (s *userService) ConfirmTwoFactor(ctx context.Context, id string, req *user.TwoFactorCodeRequest) ([]string, error) {
+	if req == nil || req.Code == "" {
+		return nil, errors.New("code is required")
+	}
+
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("User not found", "id", id)
+		return nil, errors.New("user not found")
+	}
+
+	if u.TwoFactorEnabled() {
+		return nil, errTwoFactorAlreadyEnabled
+	}
+	if u.TOTPSecret == "" {
+		return nil, errors.New("two-factor enrollment has not been started")
+	}
+
+	if err := s.checkTOTP(ctx, u, req.Code); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	u.TwoFactorEnabledAt = &now
+	u.UpdatedAt = now
+	if err := s.repo.Update(ctx, u); err != nil {
+		s.logger.Error("Failed to update user", "error", err, "id", id)
+		return nil, errors.New("failed to enable two-factor authentication")
+	}
+
+	return s.newRecoveryCodes(ctx, id)
+}
+
+func (s *userService) DisableTwoFactor(ctx context.Context, id string, req *user.DisableTwoFactorRequest) error {
+	if req == nil {
+		return errors.New("request is required")
+	}
+
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("User not found", "id", id)
+		return errors.New("user not found")
+	}
+
+	if !u.TwoFactorEnabled() {
+		return errTwoFactorNotEnabled
+	}
+
+	if err := s.passwordHasher.ComparePasswordAndHash(req.Password, u.PasswordHash); err != nil {
+		s.logger.Warn("Invalid password", "id", id)
+		return errors.New("invalid password")
+	}
+
+	if err := s.checkSecondFactor(ctx, u, req.Code, req.RecoveryCode); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	u.TOTPSecret = ""
+	u.TwoFactorEnabledAt = nil
+	u.UpdatedAt = now
+	if err := s.repo.Update(ctx, u); err != nil {
+		s.logger.Error("Failed to update user", "error", err, "id", id)
+		return errors.New("failed to disable two-factor authentication")
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, id, nil, now); err != nil {
+		s.logger.Error("Failed to delete recovery codes", "error", err, "id", id)
+	}
+
+	return nil
+}
+
+func (s *userService) RegenerateRecoveryCodes(ctx context.Context, id string, req *user.TwoFactorCodeRequest) ([]string, error) {
+	if req == nil || req.Code == "" {
+		return nil, errors.New("code is required")
+	}
+
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("User not found", "id", id)
+		return nil, errors.New("user not found")
+	}
+
+	if !u.TwoFactorEnabled() {
+		return nil, errTwoFactorNotEnabled
+	}
+
+	if err := s.checkTOTP(ctx, u, req.Code); err != nil {
+		return nil, err
+	}
+
+	return s.newRecoveryCodes(ctx, id)
+}
+
+// checkSecondFactor accepts either a TOTP code or, when given, a recovery code.
+func (s *userService) checkSecondFactor(ctx context.Context, u *user.User, code, recoveryCode string) error {
+	if !u.TwoFactorEnabled() {
+		return errTwoFactorNotEnabled
+	}
+
+	if recoveryCode == "" {
+		return s.checkTOTP(ctx, u, code)
+	}
+
+	hash := hashUserToken(user.NormalizeRecoveryCode(recoveryCode))
+	if err := s.repo.UseRecoveryCode(ctx, u.ID, hash, time.Now()); err != nil {
+		s.logger.Warn("Invalid recovery code", "id", u.ID)
+		return errInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+func (s *userService) checkTOTP(ctx context.Context, u *user.User, code string) error {
+	counter, ok := user.MatchTOTP(u.TOTPSecret, code, time.Now(), u.TOTPLastCounter)
+	if !ok {
+		s.logger.Warn("Invalid two-factor code", "id", u.ID)
+		return errInvalidTwoFactorCode
+	}
+
+	if err := s.repo.AdvanceTOTPCounter(ctx, u.ID, counter); err != nil {
+		s.logger.Warn("Two-factor code already used", "id", u.ID)
+		return errInvalidTwoFactorCode
+	}
+	u.TOTPLastCounter = counter
+
+	return nil
+}
+
+// newRecoveryCodes replaces the user's recovery codes and returns the
+// plaintext, which is only shown this once.
+func (s *userService) newRecoveryCodes(ctx context.Context, id string) ([]string, error) {
+	codes, err := user.GenerateRecoveryCodes()
+	if err != nil {
+		s.logger.Error("Failed to generate recovery codes", "error", err)
+		return nil, errors.New("failed to generate recovery codes")
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = hashUserToken(user.NormalizeRecoveryCode(code))
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, id, hashes, time.Now()); err != nil {
+		s.logger.Error("Failed to store recovery codes", "error", err, "id", id)
+		return nil, errors.New("failed to generate recovery codes")
+	}
+
+	return codes, nil
+}
+
+func (s *userService) sendVerification(ctx context.Context, u *user.User) error {
+	token, err := s.issueToken(ctx, u.ID, user.TokenEmailVerification, s.verificationTTL)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Confirm your email address by following this link within %s:\n\n%s/verify-email?token=%s",
+		s.verificationTTL, s.appURL, token)
+	return s.mailer.Send(ctx, u.Email, "Verify your email address", body)
+}
+
+// issueToken stores a new token for the purpose, invalidating the user's
+// earlier ones, and returns the plaintext to mail.
+func (s *userService) issueToken(ctx context.Context, userID string, purpose user.TokenPurpose, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	now := time.Now()
+	if err := s.repo.InvalidateTokens(ctx, userID, purpose, now); err != nil {
+		return "", fmt.Errorf("failed to invalidate tokens: %w", err)
+	}
+
+	t := &user.Token{
+		UserID:    userID,
+		Purpose:   purpose,
+		Hash:      hashUserToken(token),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	if err := s.repo.CreateToken(ctx, t); err != nil {
+		return "", fmt.Errorf("failed to store token: %w", err)
+	}
+
+	return token, nil
+}
+
+// useToken consumes a token for the purpose and returns its user.
+func (s *userService) useToken(ctx context.Context, token string, purpose user.TokenPurpose) (*user.User, error) {
+	t, err := s.repo.GetTokenByHash(ctx, hashUserToken(token))
+	if err != nil || t.Purpose != purpose || !t.Usable(time.Now()) {
+		s.logger.Warn("Invalid user token", "purpose", purpose)
+		return nil, errInvalidUserToken
+	}
+
+	if err := s.repo.UseToken(ctx, t.ID, time.Now()); err != nil {
+		s.logger.Warn("User token already used", "id", t.ID)
+		return nil, errInvalidUserToken
+	}
+
+	u, err := s.repo.GetByID(ctx, t.UserID)
+	if err != nil {
+		s.logger.Error("User not found", "id", t.UserID)
+		return nil, errors.New("user not found")
+	}
+
+	return u, nil
+}
+
+// hashUserToken uses SHA-256 like API keys: tokens are long random values and
+// are looked up by hash.
+func hashUserToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,10 +2,13 @@ package services_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"testing"
+	"time"
 
 	"github.com/popeskul/payment-gateway/internal/core/domain/user"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
@@ -19,8 +22,9 @@ func TestUserService_Register(t *testing.T) {
 	mockRepo := ports.NewMockUserRepository(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 	mockPasswordHasher := ports.NewMockPasswordHasher(ctrl)
+	mockMailer := ports.NewMockMailer(ctrl)
 
-	userService := services.NewUserService(mockRepo, mockLogger, mockPasswordHasher)
+	userService := services.NewUserService(mockRepo, mockLogger, mockPasswordHasher, mockMailer, testUserServiceConfig)
 
 	tests := []struct {
 		name          string
@@ -40,6 +44,26 @@ func TestUserService_Register(t *testing.T) {
 				mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(nil, errors.New("not found"))
 				mockPasswordHasher.EXPECT().HashPassword("password123").Return("hashedPassword", nil)
 				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().InvalidateTokens(gomock.Any(), gomock.Any(), user.TokenEmailVerification, gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateToken(gomock.Any(), gomock.Any()).Return(nil)
+				mockMailer.EXPECT().Send(gomock.Any(), "test@example.com", "Verify your email address", gomock.Any()).Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Mail failure does not fail registration",
+			req: &user.RegisterRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(nil, errors.New("not found"))
+				mockPasswordHasher.EXPECT().HashPassword("password123").Return("hashedPassword", nil)
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().InvalidateTokens(gomock.Any(), gomock.Any(), user.TokenEmailVerification, gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateToken(gomock.Any(), gomock.Any()).Return(nil)
+				mockMailer.EXPECT().Send(gomock.Any(), "test@example.com", gomock.Any(), gomock.Any()).Return(errors.New("smtp down"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			},
 			expectedError: nil,
 		},
@@ -54,6 +78,30 @@ func TestUserService_Register(t *testing.T) {
 			},
 			expectedError: errors.New("user with this email already exists"),
 		},
+		{
+			name: "Password too short",
+			req: &user.RegisterRequest{
+				Email:    "test@example.com",
+				Password: "ab1",
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(nil, errors.New("not found"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("password does not meet the password policy: it must be at least 6 characters long"),
+		},
+		{
+			name: "Breached password",
+			req: &user.RegisterRequest{
+				Email:    "test@example.com",
+				Password: "LetMeIn123",
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(nil, errors.New("not found"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("password does not meet the password policy: it appears in a list of breached passwords"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -71,6 +119,33 @@ func TestUserService_Register(t *testing.T) {
 	}
 }
 
+var testLockoutPolicy = user.LockoutPolicy{
+	Window:           15 * time.Minute,
+	DelayAfter:       3,
+	BaseDelay:        time.Second,
+	MaxDelay:         30 * time.Second,
+	AccountThreshold: 5,
+	IPThreshold:      20,
+	LockoutDuration:  15 * time.Minute,
+}
+
+var testPasswordPolicy = user.PasswordPolicy{
+	MinLength:           6,
+	MaxLength:           64,
+	MinCharacterClasses: 2,
+	Breached:            user.BreachedPasswords{"letmein123": {}},
+}
+
+var testUserServiceConfig = services.UserServiceConfig{
+	AppURL:          "https://app.example.com",
+	VerificationTTL: 48 * time.Hour,
+	ResetTTL:        time.Hour,
+	TOTPIssuer:      "Payment Gateway",
+	MFATTL:          5 * time.Minute,
+	Lockout:         testLockoutPolicy,
+	Passwords:       testPasswordPolicy,
+}
+
 func TestUserService_Login(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -78,8 +153,19 @@ func TestUserService_Login(t *testing.T) {
 	mockRepo := ports.NewMockUserRepository(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 	mockPasswordHasher := ports.NewMockPasswordHasher(ctrl)
+	mockMailer := ports.NewMockMailer(ctrl)
 
-	userService := services.NewUserService(mockRepo, mockLogger, mockPasswordHasher)
+	userService := services.NewUserService(mockRepo, mockLogger, mockPasswordHasher, mockMailer, testUserServiceConfig)
+
+	const ip = "203.0.113.7"
+	lockedUntil := time.Now().Add(10 * time.Minute)
+	failure := func(scope user.ThrottleScope, key string, failures int) *user.LoginThrottle {
+		return &user.LoginThrottle{Scope: scope, Key: key, Failures: failures, LastFailedAt: time.Now()}
+	}
+	notThrottled := func(email string) {
+		mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), user.ThrottleAccount, email).Return(nil, nil)
+		mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), user.ThrottleIP, ip).Return(nil, nil)
+	}
 
 	tests := []struct {
 		name          string
@@ -89,13 +175,51 @@ func TestUserService_Login(t *testing.T) {
 	}{
 		{
 			name: "Successful login",
+			req: &user.LoginRequest{
+				Email:    "Test@Example.com",
+				Password: "password123",
+			},
+			setupMocks: func() {
+				notThrottled("test@example.com")
+				mockRepo.EXPECT().GetByEmail(gomock.Any(), "Test@Example.com").Return(&user.User{PasswordHash: "hashedPassword"}, nil)
+				mockPasswordHasher.EXPECT().ComparePasswordAndHash("password123", "hashedPassword").Return(nil)
+				mockPasswordHasher.EXPECT().NeedsRehash("hashedPassword").Return(false)
+				mockRepo.EXPECT().ClearLoginThrottle(gomock.Any(), user.ThrottleAccount, "test@example.com").Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Two-factor login keeps the account's failures until the code is checked",
 			req: &user.LoginRequest{
 				Email:    "test@example.com",
 				Password: "password123",
 			},
 			setupMocks: func() {
-				mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(&user.User{PasswordHash: "hashedPassword"}, nil)
+				enabledAt := time.Now()
+				notThrottled("test@example.com")
+				mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(&user.User{PasswordHash: "hashedPassword", TwoFactorEnabledAt: &enabledAt}, nil)
 				mockPasswordHasher.EXPECT().ComparePasswordAndHash("password123", "hashedPassword").Return(nil)
+				mockPasswordHasher.EXPECT().NeedsRehash("hashedPassword").Return(false)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Outdated hash is replaced",
+			req: &user.LoginRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
+			setupMocks: func() {
+				notThrottled("test@example.com")
+				mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(&user.User{ID: "user1", PasswordHash: "$2a$10$bcrypt"}, nil)
+				mockPasswordHasher.EXPECT().ComparePasswordAndHash("password123", "$2a$10$bcrypt").Return(nil)
+				mockPasswordHasher.EXPECT().NeedsRehash("$2a$10$bcrypt").Return(true)
+				mockPasswordHasher.EXPECT().HashPassword("password123").Return("$argon2id$hash", nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u *user.User) error {
+					assert.Equal(t, "$argon2id$hash", u.PasswordHash)
+					return nil
+				})
+				mockRepo.EXPECT().ClearLoginThrottle(gomock.Any(), user.ThrottleAccount, "test@example.com").Return(nil)
 			},
 			expectedError: nil,
 		},
@@ -105,18 +229,91 @@ func TestUserService_Login(t *testing.T) {
 				Email: "nonexistent@example.com",
 			},
 			setupMocks: func() {
+				notThrottled("nonexistent@example.com")
 				mockRepo.EXPECT().GetByEmail(gomock.Any(), "nonexistent@example.com").Return(nil, errors.New("not found"))
 				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+				mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), user.ThrottleAccount, "nonexistent@example.com", gomock.Any(), 15*time.Minute).
+					Return(failure(user.ThrottleAccount, "nonexistent@example.com", 1), nil)
+				mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), user.ThrottleIP, ip, gomock.Any(), 15*time.Minute).
+					Return(failure(user.ThrottleIP, ip, 1), nil)
 			},
 			expectedError: errors.New("invalid credentials"),
 		},
+		{
+			name: "Progressive delay",
+			req: &user.LoginRequest{
+				Email:    "test@example.com",
+				Password: "wrong",
+			},
+			setupMocks: func() {
+				notThrottled("test@example.com")
+				mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(&user.User{PasswordHash: "hashedPassword"}, nil)
+				mockPasswordHasher.EXPECT().ComparePasswordAndHash("wrong", "hashedPassword").Return(errors.New("mismatch"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+				mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), user.ThrottleAccount, "test@example.com", gomock.Any(), gomock.Any()).
+					Return(failure(user.ThrottleAccount, "test@example.com", 4), nil)
+				mockRepo.EXPECT().LockLogin(gomock.Any(), user.ThrottleAccount, "test@example.com", gomock.Any()).Return(nil)
+				mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), user.ThrottleIP, ip, gomock.Any(), gomock.Any()).
+					Return(failure(user.ThrottleIP, ip, 4), nil)
+			},
+			expectedError: errors.New("too many failed logins, retry in 2s"),
+		},
+		{
+			name: "Failure reaching the threshold locks the account",
+			req: &user.LoginRequest{
+				Email:    "test@example.com",
+				Password: "wrong",
+			},
+			setupMocks: func() {
+				notThrottled("test@example.com")
+				mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(&user.User{PasswordHash: "hashedPassword"}, nil)
+				mockPasswordHasher.EXPECT().ComparePasswordAndHash("wrong", "hashedPassword").Return(errors.New("mismatch"))
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+				mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), user.ThrottleAccount, "test@example.com", gomock.Any(), gomock.Any()).
+					Return(failure(user.ThrottleAccount, "test@example.com", 5), nil)
+				mockRepo.EXPECT().LockLogin(gomock.Any(), user.ThrottleAccount, "test@example.com", gomock.Any()).Return(nil)
+				mockLogger.EXPECT().Warn("Login locked out", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), user.ThrottleIP, ip, gomock.Any(), gomock.Any()).
+					Return(failure(user.ThrottleIP, ip, 5), nil)
+			},
+			expectedError: errors.New("too many failed logins, locked for 15m0s"),
+		},
+		{
+			name: "Locked account is refused without checking the password",
+			req: &user.LoginRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), user.ThrottleAccount, "test@example.com").Return(&user.LoginThrottle{
+					Scope: user.ThrottleAccount, Key: "test@example.com", Failures: 5, LastFailedAt: time.Now(), LockedUntil: &lockedUntil,
+				}, nil)
+				mockLogger.EXPECT().Warn("Login throttled", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("too many failed logins, locked for 10m0s"),
+		},
+		{
+			name: "Locked IP address",
+			req: &user.LoginRequest{
+				Email:    "other@example.com",
+				Password: "password123",
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), user.ThrottleAccount, "other@example.com").Return(nil, nil)
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), user.ThrottleIP, ip).Return(&user.LoginThrottle{
+					Scope: user.ThrottleIP, Key: ip, Failures: 20, LastFailedAt: time.Now(), LockedUntil: &lockedUntil,
+				}, nil)
+				mockLogger.EXPECT().Warn("Login throttled", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("too many failed logins, locked for 10m0s"),
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setupMocks()
 
-			_, err := userService.Login(context.Background(), tt.req)
+			_, err := userService.Login(context.Background(), tt.req, ip)
 
 			if tt.expectedError != nil {
 				assert.EqualError(t, err, tt.expectedError.Error())
@@ -134,8 +331,9 @@ func TestUserService_UpdateProfile(t *testing.T) {
 	mockRepo := ports.NewMockUserRepository(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 	mockPasswordHasher := ports.NewMockPasswordHasher(ctrl)
+	mockMailer := ports.NewMockMailer(ctrl)
 
-	userService := services.NewUserService(mockRepo, mockLogger, mockPasswordHasher)
+	userService := services.NewUserService(mockRepo, mockLogger, mockPasswordHasher, mockMailer, testUserServiceConfig)
 
 	tests := []struct {
 		name          string
@@ -191,8 +389,9 @@ func TestUserService_ChangePassword(t *testing.T) {
 	mockRepo := ports.NewMockUserRepository(ctrl)
 	mockLogger := ports.NewMockLogger(ctrl)
 	mockPasswordHasher := ports.NewMockPasswordHasher(ctrl)
+	mockMailer := ports.NewMockMailer(ctrl)
 
-	userService := services.NewUserService(mockRepo, mockLogger, mockPasswordHasher)
+	userService := services.NewUserService(mockRepo, mockLogger, mockPasswordHasher, mockMailer, testUserServiceConfig)
 
 	tests := []struct {
 		name          string
@@ -246,3 +445,571 @@ func TestUserService_ChangePassword(t *testing.T) {
 		})
 	}
 }
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestUserService_VerifyEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockUserRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+	mockPasswordHasher := ports.NewMockPasswordHasher(ctrl)
+	mockMailer := ports.NewMockMailer(ctrl)
+
+	userService := services.NewUserService(mockRepo, mockLogger, mockPasswordHasher, mockMailer, testUserServiceConfig)
+
+	validToken := &user.Token{
+		ID:        "token1",
+		UserID:    "user123",
+		Purpose:   user.TokenEmailVerification,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	usedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name          string
+		req           *user.VerifyEmailRequest
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name: "Successful verification",
+			req:  &user.VerifyEmailRequest{Token: "abc"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetTokenByHash(gomock.Any(), hashToken("abc")).Return(validToken, nil)
+				mockRepo.EXPECT().UseToken(gomock.Any(), "token1", gomock.Any()).Return(nil)
+				mockRepo.EXPECT().GetByID(gomock.Any(), "user123").Return(&user.User{ID: "user123"}, nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u *user.User) error {
+					assert.True(t, u.EmailVerified())
+					return nil
+				})
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Expired token",
+			req:  &user.VerifyEmailRequest{Token: "abc"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetTokenByHash(gomock.Any(), hashToken("abc")).Return(&user.Token{
+					ID: "token1", UserID: "user123", Purpose: user.TokenEmailVerification, ExpiresAt: time.Now().Add(-time.Hour),
+				}, nil)
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("invalid or expired token"),
+		},
+		{
+			name: "Used token",
+			req:  &user.VerifyEmailRequest{Token: "abc"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetTokenByHash(gomock.Any(), hashToken("abc")).Return(&user.Token{
+					ID: "token1", UserID: "user123", Purpose: user.TokenEmailVerification, ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt,
+				}, nil)
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("invalid or expired token"),
+		},
+		{
+			name: "Password reset token",
+			req:  &user.VerifyEmailRequest{Token: "abc"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetTokenByHash(gomock.Any(), hashToken("abc")).Return(&user.Token{
+					ID: "token1", UserID: "user123", Purpose: user.TokenPasswordReset, ExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("invalid or expired token"),
+		},
+		{
+			name: "Concurrent use",
+			req:  &user.VerifyEmailRequest{Token: "abc"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetTokenByHash(gomock.Any(), hashToken("abc")).Return(validToken, nil)
+				mockRepo.EXPECT().UseToken(gomock.Any(), "token1", gomock.Any()).Return(errors.New("token already used"))
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("invalid or expired token"),
+		},
+		{
+			name:          "Missing token",
+			req:           &user.VerifyEmailRequest{},
+			setupMocks:    func() {},
+			expectedError: errors.New("invalid or expired token"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := userService.VerifyEmail(context.Background(), tt.req)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUserService_RequestPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockUserRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+	mockPasswordHasher := ports.NewMockPasswordHasher(ctrl)
+	mockMailer := ports.NewMockMailer(ctrl)
+
+	userService := services.NewUserService(mockRepo, mockLogger, mockPasswordHasher, mockMailer, testUserServiceConfig)
+
+	tests := []struct {
+		name          string
+		req           *user.ForgotPasswordRequest
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name: "Known email",
+			req:  &user.ForgotPasswordRequest{Email: "test@example.com"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(&user.User{ID: "user123", Email: "test@example.com"}, nil)
+				mockRepo.EXPECT().InvalidateTokens(gomock.Any(), "user123", user.TokenPasswordReset, gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateToken(gomock.Any(), gomock.Any()).Return(nil)
+				mockMailer.EXPECT().Send(gomock.Any(), "test@example.com", "Reset your password", gomock.Any()).Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Unknown email",
+			req:  &user.ForgotPasswordRequest{Email: "nobody@example.com"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByEmail(gomock.Any(), "nobody@example.com").Return(nil, errors.New("user not found"))
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := userService.RequestPasswordReset(context.Background(), tt.req)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUserService_ResetPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockUserRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+	mockPasswordHasher := ports.NewMockPasswordHasher(ctrl)
+	mockMailer := ports.NewMockMailer(ctrl)
+
+	userService := services.NewUserService(mockRepo, mockLogger, mockPasswordHasher, mockMailer, testUserServiceConfig)
+
+	tests := []struct {
+		name           string
+		req            *user.ResetPasswordRequest
+		setupMocks     func()
+		expectedUserID string
+		expectedError  error
+	}{
+		{
+			name: "Successful reset",
+			req:  &user.ResetPasswordRequest{Token: "abc", NewPassword: "newPass"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetTokenByHash(gomock.Any(), hashToken("abc")).Return(&user.Token{
+					ID: "token1", UserID: "user123", Purpose: user.TokenPasswordReset, ExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
+				mockRepo.EXPECT().UseToken(gomock.Any(), "token1", gomock.Any()).Return(nil)
+				mockRepo.EXPECT().GetByID(gomock.Any(), "user123").Return(&user.User{ID: "user123", Email: "Test@example.com", PasswordHash: "oldHash"}, nil)
+				mockPasswordHasher.EXPECT().HashPassword("newPass").Return("newHash", nil)
+				mockRepo.EXPECT().ClearLoginThrottle(gomock.Any(), user.ThrottleAccount, "test@example.com").Return(nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u *user.User) error {
+					assert.Equal(t, "newHash", u.PasswordHash)
+					assert.True(t, u.EmailVerified())
+					return nil
+				})
+			},
+			expectedUserID: "user123",
+			expectedError:  nil,
+		},
+		{
+			name: "Verification token",
+			req:  &user.ResetPasswordRequest{Token: "abc", NewPassword: "newPass"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetTokenByHash(gomock.Any(), hashToken("abc")).Return(&user.Token{
+					ID: "token1", UserID: "user123", Purpose: user.TokenEmailVerification, ExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("invalid or expired token"),
+		},
+		{
+			name:          "Missing password",
+			req:           &user.ResetPasswordRequest{Token: "abc"},
+			setupMocks:    func() {},
+			expectedError: errors.New("new password is required"),
+		},
+		{
+			name: "Weak password leaves the token unused",
+			req:  &user.ResetPasswordRequest{Token: "abc", NewPassword: "newpass"},
+			setupMocks: func() {
+				mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("password does not meet the password policy: it must mix at least 2 of lowercase letters, uppercase letters, digits and symbols"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			userID, err := userService.ResetPassword(context.Background(), tt.req)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedUserID, userID)
+			}
+		})
+	}
+}
+
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func currentTOTPCode(t *testing.T) string {
+	code, err := user.TOTPCode(testTOTPSecret, user.TOTPCounter(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestUserService_CompleteTwoFactorLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockUserRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+	mockPasswordHasher := ports.NewMockPasswordHasher(ctrl)
+	mockMailer := ports.NewMockMailer(ctrl)
+
+	userService := services.NewUserService(mockRepo, mockLogger, mockPasswordHasher, mockMailer, testUserServiceConfig)
+
+	const ip = "203.0.113.7"
+	enabledAt := time.Now().Add(-24 * time.Hour)
+	mfaToken := &user.Token{
+		ID:        "token1",
+		UserID:    "user123",
+		Purpose:   user.TokenTwoFactorLogin,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+	expectToken := func() {
+		mockRepo.EXPECT().GetTokenByHash(gomock.Any(), hashToken("mfa")).Return(mfaToken, nil)
+		mockRepo.EXPECT().UseToken(gomock.Any(), "token1", gomock.Any()).Return(nil)
+		mockRepo.EXPECT().GetByID(gomock.Any(), "user123").Return(&user.User{
+			ID: "user123", Email: "User@Example.com", TOTPSecret: testTOTPSecret, TwoFactorEnabledAt: &enabledAt,
+		}, nil)
+		mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), user.ThrottleAccount, "user@example.com").Return(nil, nil)
+		mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), user.ThrottleIP, ip).Return(nil, nil)
+	}
+	failure := func(scope user.ThrottleScope, key string, failures int) *user.LoginThrottle {
+		return &user.LoginThrottle{Scope: scope, Key: key, Failures: failures, LastFailedAt: time.Now()}
+	}
+	expectFailure := func(accountFailures int) {
+		mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), user.ThrottleAccount, "user@example.com", gomock.Any(), 15*time.Minute).
+			Return(failure(user.ThrottleAccount, "user@example.com", accountFailures), nil)
+		mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), user.ThrottleIP, ip, gomock.Any(), 15*time.Minute).
+			Return(failure(user.ThrottleIP, ip, 1), nil)
+	}
+	expectCleared := func() {
+		mockRepo.EXPECT().ClearLoginThrottle(gomock.Any(), user.ThrottleAccount, "user@example.com").Return(nil)
+	}
+
+	tests := []struct {
+		name          string
+		req           func() *user.TwoFactorLoginRequest
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name: "Valid TOTP code",
+			req: func() *user.TwoFactorLoginRequest {
+				return &user.TwoFactorLoginRequest{MFAToken: "mfa", Code: currentTOTPCode(t)}
+			},
+			setupMocks: func() {
+				expectToken()
+				mockRepo.EXPECT().AdvanceTOTPCounter(gomock.Any(), "user123", gomock.Any()).Return(nil)
+				expectCleared()
+			},
+		},
+		{
+			name: "Wrong TOTP code",
+			req: func() *user.TwoFactorLoginRequest {
+				return &user.TwoFactorLoginRequest{MFAToken: "mfa", Code: "000000x"}
+			},
+			setupMocks: func() {
+				expectToken()
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any())
+				expectFailure(1)
+			},
+			expectedError: errors.New("invalid two-factor code"),
+		},
+		{
+			name: "Wrong code reaching the threshold locks the account",
+			req: func() *user.TwoFactorLoginRequest {
+				return &user.TwoFactorLoginRequest{MFAToken: "mfa", Code: "000000x"}
+			},
+			setupMocks: func() {
+				expectToken()
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any())
+				expectFailure(5)
+				mockRepo.EXPECT().LockLogin(gomock.Any(), user.ThrottleAccount, "user@example.com", gomock.Any()).Return(nil)
+				mockLogger.EXPECT().Warn("Login locked out", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("too many failed logins, locked for 15m0s"),
+		},
+		{
+			name: "Locked account is refused without checking the code",
+			req: func() *user.TwoFactorLoginRequest {
+				return &user.TwoFactorLoginRequest{MFAToken: "mfa", Code: currentTOTPCode(t)}
+			},
+			setupMocks: func() {
+				lockedUntil := time.Now().Add(10 * time.Minute)
+				mockRepo.EXPECT().GetTokenByHash(gomock.Any(), hashToken("mfa")).Return(mfaToken, nil)
+				mockRepo.EXPECT().UseToken(gomock.Any(), "token1", gomock.Any()).Return(nil)
+				mockRepo.EXPECT().GetByID(gomock.Any(), "user123").Return(&user.User{
+					ID: "user123", Email: "user@example.com", TOTPSecret: testTOTPSecret, TwoFactorEnabledAt: &enabledAt,
+				}, nil)
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), user.ThrottleAccount, "user@example.com").Return(&user.LoginThrottle{
+					Scope: user.ThrottleAccount, Key: "user@example.com", Failures: 5, LastFailedAt: time.Now(), LockedUntil: &lockedUntil,
+				}, nil)
+				mockLogger.EXPECT().Warn("Login throttled", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("too many failed logins, locked for 10m0s"),
+		},
+		{
+			name: "Replayed TOTP code",
+			req: func() *user.TwoFactorLoginRequest {
+				return &user.TwoFactorLoginRequest{MFAToken: "mfa", Code: currentTOTPCode(t)}
+			},
+			setupMocks: func() {
+				expectToken()
+				mockRepo.EXPECT().AdvanceTOTPCounter(gomock.Any(), "user123", gomock.Any()).Return(errors.New("totp code already used"))
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any())
+				expectFailure(1)
+			},
+			expectedError: errors.New("invalid two-factor code"),
+		},
+		{
+			name: "Recovery code",
+			req: func() *user.TwoFactorLoginRequest {
+				return &user.TwoFactorLoginRequest{MFAToken: "mfa", RecoveryCode: "1A2B3 C4D5E"}
+			},
+			setupMocks: func() {
+				expectToken()
+				mockRepo.EXPECT().UseRecoveryCode(gomock.Any(), "user123", hashToken("1a2b3c4d5e"), gomock.Any()).Return(nil)
+				expectCleared()
+			},
+		},
+		{
+			name: "Used recovery code",
+			req: func() *user.TwoFactorLoginRequest {
+				return &user.TwoFactorLoginRequest{MFAToken: "mfa", RecoveryCode: "1a2b3-c4d5e"}
+			},
+			setupMocks: func() {
+				expectToken()
+				mockRepo.EXPECT().UseRecoveryCode(gomock.Any(), "user123", hashToken("1a2b3c4d5e"), gomock.Any()).Return(errors.New("recovery code not found or already used"))
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any())
+				expectFailure(1)
+			},
+			expectedError: errors.New("invalid two-factor code"),
+		},
+		{
+			name: "Password reset token",
+			req: func() *user.TwoFactorLoginRequest {
+				return &user.TwoFactorLoginRequest{MFAToken: "mfa", Code: "123456"}
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetTokenByHash(gomock.Any(), hashToken("mfa")).Return(&user.Token{
+					ID: "token1", UserID: "user123", Purpose: user.TokenPasswordReset, ExpiresAt: time.Now().Add(time.Minute),
+				}, nil)
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("invalid or expired token"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			u, err := userService.CompleteTwoFactorLogin(context.Background(), tt.req(), ip)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "user123", u.ID)
+			}
+		})
+	}
+}
+
+func TestUserService_ConfirmTwoFactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockUserRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+	mockPasswordHasher := ports.NewMockPasswordHasher(ctrl)
+	mockMailer := ports.NewMockMailer(ctrl)
+
+	userService := services.NewUserService(mockRepo, mockLogger, mockPasswordHasher, mockMailer, testUserServiceConfig)
+
+	enabledAt := time.Now()
+
+	tests := []struct {
+		name          string
+		code          func() string
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name: "Successful confirmation",
+			code: func() string { return currentTOTPCode(t) },
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "user123").Return(&user.User{ID: "user123", TOTPSecret: testTOTPSecret}, nil)
+				mockRepo.EXPECT().AdvanceTOTPCounter(gomock.Any(), "user123", gomock.Any()).Return(nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u *user.User) error {
+					assert.True(t, u.TwoFactorEnabled())
+					return nil
+				})
+				mockRepo.EXPECT().ReplaceRecoveryCodes(gomock.Any(), "user123", gomock.Len(user.RecoveryCodeCount), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "Enrollment not started",
+			code: func() string { return "123456" },
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "user123").Return(&user.User{ID: "user123"}, nil)
+			},
+			expectedError: errors.New("two-factor enrollment has not been started"),
+		},
+		{
+			name: "Already enabled",
+			code: func() string { return "123456" },
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "user123").Return(&user.User{ID: "user123", TOTPSecret: testTOTPSecret, TwoFactorEnabledAt: &enabledAt}, nil)
+			},
+			expectedError: errors.New("two-factor authentication is already enabled"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			codes, err := userService.ConfirmTwoFactor(context.Background(), "user123", &user.TwoFactorCodeRequest{Code: tt.code()})
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, codes, user.RecoveryCodeCount)
+			}
+		})
+	}
+}
+
+func TestUserService_DisableTwoFactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := ports.NewMockUserRepository(ctrl)
+	mockLogger := ports.NewMockLogger(ctrl)
+	mockPasswordHasher := ports.NewMockPasswordHasher(ctrl)
+	mockMailer := ports.NewMockMailer(ctrl)
+
+	userService := services.NewUserService(mockRepo, mockLogger, mockPasswordHasher, mockMailer, testUserServiceConfig)
+
+	enabledAt := time.Now()
+	enabledUser := func() *user.User {
+		return &user.User{ID: "user123", PasswordHash: "hash", TOTPSecret: testTOTPSecret, TwoFactorEnabledAt: &enabledAt}
+	}
+
+	tests := []struct {
+		name          string
+		req           func() *user.DisableTwoFactorRequest
+		setupMocks    func()
+		expectedError error
+	}{
+		{
+			name: "Successful disable",
+			req: func() *user.DisableTwoFactorRequest {
+				return &user.DisableTwoFactorRequest{Password: "password", Code: currentTOTPCode(t)}
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "user123").Return(enabledUser(), nil)
+				mockPasswordHasher.EXPECT().ComparePasswordAndHash("password", "hash").Return(nil)
+				mockRepo.EXPECT().AdvanceTOTPCounter(gomock.Any(), "user123", gomock.Any()).Return(nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u *user.User) error {
+					assert.False(t, u.TwoFactorEnabled())
+					assert.Empty(t, u.TOTPSecret)
+					return nil
+				})
+				mockRepo.EXPECT().ReplaceRecoveryCodes(gomock.Any(), "user123", gomock.Nil(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "Wrong password",
+			req: func() *user.DisableTwoFactorRequest {
+				return &user.DisableTwoFactorRequest{Password: "wrong", Code: currentTOTPCode(t)}
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "user123").Return(enabledUser(), nil)
+				mockPasswordHasher.EXPECT().ComparePasswordAndHash("wrong", "hash").Return(errors.New("mismatch"))
+				mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any())
+			},
+			expectedError: errors.New("invalid password"),
+		},
+		{
+			name: "Not enabled",
+			req:  func() *user.DisableTwoFactorRequest { return &user.DisableTwoFactorRequest{Password: "password"} },
+			setupMocks: func() {
+				mockRepo.EXPECT().GetByID(gomock.Any(), "user123").Return(&user.User{ID: "user123"}, nil)
+			},
+			expectedError: errors.New("two-factor authentication is not enabled"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := userService.DisableTwoFactor(context.Background(), "user123", tt.req())
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
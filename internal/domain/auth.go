@@ -6,7 +6,34 @@ import (
 )
 
 type Claims struct {
-	UserID    string    `json:"user_id"`
+	UserID string `json:"user_id"`
+	// FamilyID is set on refresh tokens and names their rotation family.
+	FamilyID string `json:"fid,omitempty"`
+	// SessionID is set on access tokens, so that revoking the session revokes
+	// them before they expire.
+	SessionID string `json:"sid,omitempty"`
+	// ClientID and Scope are set on tokens of the client credentials grant,
+	// which have no user.
+	ClientID  string    `json:"client_id,omitempty"`
+	Scope     string    `json:"scope,omitempty"`
 	ExpiresAt time.Time `json:"expires_at"`
 	jwt.RegisteredClaims
 }
+
+// JSONWebKey is the public half of an access token signing key (RFC 7517).
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	// N and E are the RSA modulus and exponent.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Curve and X are the Ed25519 curve name and public key.
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
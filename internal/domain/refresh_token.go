@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRefreshTokenReused is returned when a refresh token that was already
+// rotated is presented again, which means it was copied. The whole family is
+// revoked when that happens.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// RefreshToken is the stored record of an issued refresh token. Tokens are
+// kept as SHA-256 hashes. Each login starts a family; every refresh rotates
+// the presented token and issues the next one in the same family.
+type RefreshToken struct {
+	ID        string
+	UserID    string
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
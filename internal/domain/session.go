@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session is one login of a user. It shares its ID with the refresh token
+// family issued at login, and access tokens carry it as the sid claim.
+type Session struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	Device    string `json:"device"`
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`
+	// Current is set on the session of the request that lists sessions.
+	Current    bool       `json:"current"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// SessionClient describes the client a session is used from.
+type SessionClient struct {
+	UserAgent string
+	IP        string
+}
+
+var (
+	userAgentPlatforms = []struct{ token, name string }{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"CrOS", "ChromeOS"},
+		{"Macintosh", "macOS"},
+		{"Linux", "Linux"},
+	}
+	// Order matters: most browsers also claim to be Chrome and Safari.
+	userAgentBrowsers = []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+)
+
+// DeviceFromUserAgent returns a short label such as "Firefox on Linux" for a
+// User-Agent header. Clients that are not browsers are named by their first
+// product token, e.g. "curl".
+func DeviceFromUserAgent(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	var platform, browser string
+	for _, p := range userAgentPlatforms {
+		if strings.Contains(userAgent, p.token) {
+			platform = p.name
+			break
+		}
+	}
+	for _, b := range userAgentBrowsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	}
+
+	product, _, _ := strings.Cut(userAgent, "/")
+	product, _, _ = strings.Cut(product, " ")
+	return product
+}
@@ -0,0 +1,108 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var (
+	ErrPasswordMismatch = errors.New("password does not match")
+	ErrUnknownHash      = errors.New("unknown password hash format")
+)
+
+// Argon2Params are the cost parameters of new Argon2id hashes. Memory is in
+// KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// argon2idPasswordHasher hashes passwords with Argon2id in the PHC string
+// format, $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>, which carries the
+// algorithm and its parameters. It still verifies the bcrypt hashes of older
+// accounts; those, and Argon2id hashes with other parameters, need a rehash.
+type argon2idPasswordHasher struct {
+	params Argon2Params
+}
+
+func NewArgon2idPasswordHasher(params Argon2Params) ports.PasswordHasher {
+	return &argon2idPasswordHasher{params: params}
+}
+
+func (h *argon2idPasswordHasher) HashPassword(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, argon2KeyLength)
+	return encodeArgon2id(h.params, salt, key), nil
+}
+
+func (h *argon2idPasswordHasher) ComparePasswordAndHash(password, hash string) error {
+	if isBcryptHash(hash) {
+		return compareBcrypt(password, hash)
+	}
+
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func (h *argon2idPasswordHasher) NeedsRehash(hash string) bool {
+	params, _, key, err := decodeArgon2id(hash)
+	return err != nil || params != h.params || len(key) != argon2KeyLength
+}
+
+func encodeArgon2id(params Argon2Params, salt, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2id(hash string) (params Argon2Params, salt, key []byte, err error) {
+	// The leading $ leaves an empty first field.
+	fields := strings.Split(hash, "$")
+	if len(fields) != 6 || fields[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(fields[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownHash
+	}
+	if _, err := fmt.Sscanf(fields[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(fields[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownHash
+	}
+	key, err = base64.RawStdEncoding.DecodeString(fields[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	return params, salt, key, nil
+}
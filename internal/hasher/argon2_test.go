@@ -0,0 +1,85 @@
+package hasher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testParams keep the tests fast; production parameters come from config.
+var testParams = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func TestArgon2idPasswordHasher(t *testing.T) {
+	h := NewArgon2idPasswordHasher(testParams)
+
+	hash, err := h.HashPassword("correct horse battery staple")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"), hash)
+
+	assert.NoError(t, h.ComparePasswordAndHash("correct horse battery staple", hash))
+	assert.ErrorIs(t, h.ComparePasswordAndHash("correct horse battery stapler", hash), ErrPasswordMismatch)
+	assert.False(t, h.NeedsRehash(hash))
+
+	other, err := h.HashPassword("correct horse battery staple")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "every hash gets its own salt")
+}
+
+func TestArgon2idPasswordHasher_OtherParameters(t *testing.T) {
+	old, err := NewArgon2idPasswordHasher(testParams).HashPassword("secret")
+	require.NoError(t, err)
+
+	h := NewArgon2idPasswordHasher(Argon2Params{Memory: 2048, Iterations: 2, Parallelism: 1})
+
+	// Hashes are verified with the parameters they carry.
+	assert.NoError(t, h.ComparePasswordAndHash("secret", old))
+	assert.True(t, h.NeedsRehash(old))
+}
+
+func TestArgon2idPasswordHasher_Bcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	h := NewArgon2idPasswordHasher(testParams)
+
+	assert.NoError(t, h.ComparePasswordAndHash("secret", string(legacy)))
+	assert.Error(t, h.ComparePasswordAndHash("wrong", string(legacy)))
+	assert.True(t, h.NeedsRehash(string(legacy)))
+}
+
+func TestArgon2idPasswordHasher_MalformedHash(t *testing.T) {
+	h := NewArgon2idPasswordHasher(testParams)
+
+	for _, hash := range []string{
+		"",
+		"plaintext",
+		"$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$",
+	} {
+		assert.ErrorIs(t, h.ComparePasswordAndHash("secret", hash), ErrUnknownHash, hash)
+		assert.True(t, h.NeedsRehash(hash), hash)
+	}
+}
+
+func TestLoadBreachedPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# top passwords\nPassword1\n\n  qwerty  \n"), 0o600))
+
+	breached, err := LoadBreachedPasswords(path)
+	require.NoError(t, err)
+
+	assert.Len(t, breached, 2)
+	assert.True(t, breached.Contains("password1"))
+	assert.True(t, breached.Contains("QWERTY"))
+	assert.False(t, breached.Contains("# top passwords"))
+
+	_, err = LoadBreachedPasswords(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -1,24 +1,18 @@
 package hasher
 
 import (
-	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"strings"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
-type bcryptPasswordHasher struct{}
-
-func NewBcryptPasswordHasher() ports.PasswordHasher {
-	return &bcryptPasswordHasher{}
-}
+// Passwords used to be hashed with bcrypt. Those hashes are only verified
+// now, until the next login replaces them.
 
-func (h *bcryptPasswordHasher) HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(hashedPassword), nil
+func isBcryptHash(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
 }
 
-func (h *bcryptPasswordHasher) ComparePasswordAndHash(password, hash string) error {
+func compareBcrypt(password, hash string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
@@ -0,0 +1,35 @@
+package hasher
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/user"
+)
+
+// LoadBreachedPasswords reads a breached password list with one password per
+// line. Blank lines and lines starting with # are skipped.
+func LoadBreachedPasswords(path string) (user.BreachedPasswords, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	defer f.Close()
+
+	breached := make(user.BreachedPasswords)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		breached[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+
+	return breached, nil
+}
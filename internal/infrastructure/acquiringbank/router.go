@@ -0,0 +1,59 @@
+package acquiringbank
+
+import (
+	"context"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+// modeRouter sends each payment and refund to the acquirer for its mode, so
+// test traffic never reaches the live acquirer.
+type modeRouter struct {
+	live ports.AcquiringBank
+	test ports.AcquiringBank
+}
+
+func NewModeRouter(live, test ports.AcquiringBank) ports.AcquiringBank {
+	return &modeRouter{live: live, test: test}
+}
+
+func (r *modeRouter) forMode(m mode.Mode) ports.AcquiringBank {
+	if m == mode.Test {
+		return r.test
+	}
+	return r.live
+}
+
+func (r *modeRouter) ProcessPayment(ctx context.Context, p *payment.Payment) error {
+	return r.forMode(p.Mode).ProcessPayment(ctx, p)
+}
+
+func (r *modeRouter) AuthorizePayment(ctx context.Context, p *payment.Payment) error {
+	return r.forMode(p.Mode).AuthorizePayment(ctx, p)
+}
+
+func (r *modeRouter) CapturePayment(ctx context.Context, p *payment.Payment) error {
+	return r.forMode(p.Mode).CapturePayment(ctx, p)
+}
+
+func (r *modeRouter) ProcessRefund(ctx context.Context, ref *refund.Refund) error {
+	return r.forMode(ref.Mode).ProcessRefund(ctx, ref)
+}
+
+func (r *modeRouter) VoidPayment(ctx context.Context, p *payment.Payment) error {
+	return r.forMode(p.Mode).VoidPayment(ctx, p)
+}
+
+// SetProcessingDelay and SetFailureRate tune the simulator and only apply to
+// test mode.
+func (r *modeRouter) SetProcessingDelay(delay time.Duration) {
+	r.test.SetProcessingDelay(delay)
+}
+
+func (r *modeRouter) SetFailureRate(rate float64) {
+	r.test.SetFailureRate(rate)
+}
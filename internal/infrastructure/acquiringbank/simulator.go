@@ -43,7 +43,51 @@ func (s *acquiringBankSimulator) ProcessPayment(ctx context.Context, p *payment.
 		return fmt.Errorf("payment processing failed")
 	}
 
-	s.logger.Info("Payment processed successfully", "payment_id", p.ID)
+	p.AcquirerReference = s.newReference()
+
+	s.logger.Info("Payment processed successfully", "payment_id", p.ID, "acquirer_reference", p.AcquirerReference)
+	return nil
+}
+
+func (s *acquiringBankSimulator) AuthorizePayment(ctx context.Context, p *payment.Payment) error {
+	s.logger.Info("Authorizing payment", "payment_id", p.ID, "amount", p.Amount, "currency", p.Currency)
+
+	// Simulate processing delay
+	select {
+	case <-time.After(s.processingDelay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// Simulate random failures
+	if s.randomGenerator.Float64() < s.failureRate {
+		s.logger.Warn("Payment authorization failed", "payment_id", p.ID)
+		return fmt.Errorf("payment authorization failed")
+	}
+
+	p.AcquirerReference = s.newReference()
+
+	s.logger.Info("Payment authorized successfully", "payment_id", p.ID, "acquirer_reference", p.AcquirerReference)
+	return nil
+}
+
+func (s *acquiringBankSimulator) CapturePayment(ctx context.Context, p *payment.Payment) error {
+	s.logger.Info("Capturing payment", "payment_id", p.ID, "acquirer_reference", p.AcquirerReference)
+
+	// Simulate processing delay
+	select {
+	case <-time.After(s.processingDelay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// Simulate random failures
+	if s.randomGenerator.Float64() < s.failureRate {
+		s.logger.Warn("Payment capture failed", "payment_id", p.ID)
+		return fmt.Errorf("payment capture failed")
+	}
+
+	s.logger.Info("Payment captured successfully", "payment_id", p.ID)
 	return nil
 }
 
@@ -63,10 +107,38 @@ func (s *acquiringBankSimulator) ProcessRefund(ctx context.Context, r *refund.Re
 		return fmt.Errorf("refund processing failed")
 	}
 
-	s.logger.Info("Refund processed successfully", "refund_id", r.ID)
+	r.AcquirerReference = s.newReference()
+
+	s.logger.Info("Refund processed successfully", "refund_id", r.ID, "acquirer_reference", r.AcquirerReference)
 	return nil
 }
 
+func (s *acquiringBankSimulator) VoidPayment(ctx context.Context, p *payment.Payment) error {
+	s.logger.Info("Voiding authorization", "payment_id", p.ID, "acquirer_reference", p.AcquirerReference)
+
+	// Simulate processing delay
+	select {
+	case <-time.After(s.processingDelay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// Simulate random failures
+	if s.randomGenerator.Float64() < s.failureRate {
+		s.logger.Warn("Void failed", "payment_id", p.ID)
+		return fmt.Errorf("void failed")
+	}
+
+	s.logger.Info("Authorization voided successfully", "payment_id", p.ID)
+	return nil
+}
+
+// newReference mimics the transaction identifier a real acquirer returns and
+// later reports in its settlement files.
+func (s *acquiringBankSimulator) newReference() string {
+	return fmt.Sprintf("SIM%016X", s.randomGenerator.Uint64())
+}
+
 // SetProcessingDelay allows to configure processing delay
 func (s *acquiringBankSimulator) SetProcessingDelay(delay time.Duration) {
 	s.processingDelay = delay
@@ -0,0 +1,202 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+// nonceKey is the primary key of api_key_nonces; the value is the expiry.
+type nonceKey struct {
+	keyID string
+	nonce string
+}
+
+type APIKeyRepository struct {
+	store         *Store
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewAPIKeyRepository(store *Store, uuidGenerator ports.UUIDGenerator) ports.APIKeyRepository {
+	return &APIKeyRepository{
+		store:         store,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+// insert and update must run inside a Store.write.
+func (r *APIKeyRepository) insert(tx *tx, k *apikey.APIKey) error {
+	if k.ID == "" {
+		k.ID = r.uuidGenerator.Generate()
+	}
+	return r.store.insertAPIKey(tx, k)
+}
+
+// insertAPIKey stores k, which must already have an id.
+func (s *Store) insertAPIKey(tx *tx, k *apikey.APIKey) error {
+	row := cloneAPIKey(k)
+	row.LastUsedAt = nil
+	row.RevokedAt = nil
+	row.RotatedTo = ""
+
+	if _, ok := s.merchants.get(row.MerchantID); !ok {
+		return fmt.Errorf("failed to create api key: %v", foreignKeyViolation("api_keys", "api_keys_merchant_id_fkey"))
+	}
+	if err := references(s.users, row.CreatedBy, "api_keys", "api_keys_created_by_fkey"); err != nil {
+		return fmt.Errorf("failed to create api key: %v", err)
+	}
+	if s.apiKeys.exists(func(other *apikey.APIKey) bool { return other.Hash == row.Hash }) {
+		return fmt.Errorf("failed to create api key: %v", uniqueViolation("api_keys_key_hash_key"))
+	}
+	if err := s.apiKeys.insert(tx, row.ID, row, "api_keys_pkey"); err != nil {
+		return fmt.Errorf("failed to create api key: %v", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) update(tx *tx, k *apikey.APIKey) error {
+	current, ok := r.store.apiKeys.get(k.ID)
+	if !ok {
+		return fmt.Errorf("api key not found")
+	}
+	if err := references(r.store.apiKeys, k.RotatedTo, "api_keys", "api_keys_rotated_to_fkey"); err != nil {
+		return fmt.Errorf("failed to update api key: %v", err)
+	}
+
+	row := cloneAPIKey(current)
+	row.Name = k.Name
+	row.ExpiresAt = clonePtr(k.ExpiresAt)
+	row.RevokedAt = clonePtr(k.RevokedAt)
+	row.RotatedTo = k.RotatedTo
+	r.store.apiKeys.put(tx, row.ID, row)
+	return nil
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, k *apikey.APIKey) error {
+	return r.store.write(func(tx *tx) error {
+		return r.insert(tx, k)
+	})
+}
+
+func (r *APIKeyRepository) get(match func(*apikey.APIKey) bool) (*apikey.APIKey, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.apiKeys.where(match)
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("api key not found")
+	}
+	return cloneAPIKey(rows[0]), nil
+}
+
+func (r *APIKeyRepository) GetByID(ctx context.Context, id string) (*apikey.APIKey, error) {
+	return r.get(func(k *apikey.APIKey) bool { return k.ID == id })
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*apikey.APIKey, error) {
+	return r.get(func(k *apikey.APIKey) bool { return k.Hash == hash })
+}
+
+func (r *APIKeyRepository) ListByMerchant(ctx context.Context, merchantID string) ([]*apikey.APIKey, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.apiKeys.where(func(k *apikey.APIKey) bool { return k.MerchantID == merchantID })
+	sortByTime(rows, func(k *apikey.APIKey) time.Time { return k.CreatedAt }, true)
+
+	var keys []*apikey.APIKey
+	for _, k := range rows {
+		keys = append(keys, cloneAPIKey(k))
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepository) Update(ctx context.Context, k *apikey.APIKey) error {
+	return r.store.write(func(tx *tx) error {
+		return r.update(tx, k)
+	})
+}
+
+func (r *APIKeyRepository) Rotate(ctx context.Context, old, replacement *apikey.APIKey) error {
+	return r.store.write(func(tx *tx) error {
+		if err := r.insert(tx, replacement); err != nil {
+			return err
+		}
+
+		old.RotatedTo = replacement.ID
+		return r.update(tx, old)
+	})
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	return r.store.write(func(tx *tx) error {
+		current, ok := r.store.apiKeys.get(id)
+		if !ok {
+			return nil
+		}
+
+		row := cloneAPIKey(current)
+		row.LastUsedAt = timePtr(at)
+		r.store.apiKeys.put(tx, id, row)
+		return nil
+	})
+}
+
+func (r *APIKeyRepository) RevokeByMerchant(ctx context.Context, merchantID string, at time.Time) error {
+	return r.store.write(func(tx *tx) error {
+		for _, k := range r.store.apiKeys.where(func(k *apikey.APIKey) bool {
+			return k.MerchantID == merchantID && k.RevokedAt == nil
+		}) {
+			row := cloneAPIKey(k)
+			row.RevokedAt = timePtr(at)
+			r.store.apiKeys.put(tx, row.ID, row)
+		}
+		return nil
+	})
+}
+
+func (r *APIKeyRepository) UseNonce(ctx context.Context, keyID, nonce string, expiresAt time.Time) (bool, error) {
+	fresh := false
+	err := r.store.write(func(tx *tx) error {
+		if _, ok := r.store.apiKeys.get(keyID); !ok {
+			return foreignKeyViolation("api_key_nonces", "api_key_nonces_api_key_id_fkey")
+		}
+
+		k := nonceKey{keyID: keyID, nonce: nonce}
+		if _, ok := r.store.apiKeyNonces.get(k); ok {
+			return nil
+		}
+		r.store.apiKeyNonces.put(tx, k, expiresAt)
+		fresh = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to record api key nonce: %v", err)
+	}
+	return fresh, nil
+}
+
+func (r *APIKeyRepository) PruneNonces(ctx context.Context, before time.Time) (int, error) {
+	pruned := 0
+	err := r.store.write(func(tx *tx) error {
+		for _, k := range r.store.apiKeyNonces.keysWhere(func(expiresAt time.Time) bool { return expiresAt.Before(before) }) {
+			r.store.apiKeyNonces.delete(tx, k)
+			pruned++
+		}
+		return nil
+	})
+	return pruned, err
+}
+
+// deleteNonces cascades the deletion of a key to its nonces. It must run
+// inside a Store.write.
+func (s *Store) deleteNonces(tx *tx, keyID string) {
+	for k := range s.apiKeyNonces.rows {
+		if k.keyID == keyID {
+			s.apiKeyNonces.delete(tx, k)
+		}
+	}
+}
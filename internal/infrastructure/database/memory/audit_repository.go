@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/audit"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+// auditRow keeps the changes JSON encoded, as the changes column does, so an
+// entry reads back with the same values Postgres would return.
+type auditRow struct {
+	entry   audit.Entry
+	changes []byte
+}
+
+type AuditRepository struct {
+	store         *Store
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewAuditRepository(store *Store, uuidGenerator ports.UUIDGenerator) ports.AuditRepository {
+	return &AuditRepository{
+		store:         store,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+func (r *AuditRepository) Append(ctx context.Context, e *audit.Entry) error {
+	if e.ID == "" {
+		e.ID = r.uuidGenerator.Generate()
+	}
+
+	changes, err := json.Marshal(e.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit changes: %v", err)
+	}
+
+	return r.store.write(func(tx *tx) error {
+		if r.store.auditLog.exists(func(row *auditRow) bool { return row.entry.ID == e.ID }) {
+			return fmt.Errorf("failed to create audit entry: %v", uniqueViolation("audit_log_id_key"))
+		}
+
+		var lastSequence int64
+		var lastHash string
+		if last, ok := r.store.auditLog.get(int64(len(r.store.auditLog.rows))); ok {
+			lastSequence = last.entry.Sequence
+			lastHash = last.entry.Hash
+		}
+
+		e.Sequence = lastSequence + 1
+		e.PrevHash = lastHash
+		e.Hash = e.ComputeHash()
+
+		row := &auditRow{entry: *e, changes: changes}
+		row.entry.Changes = nil
+		row.entry.Before = nil
+		row.entry.After = nil
+		return r.store.auditLog.insert(tx, e.Sequence, row, "audit_log_pkey")
+	})
+}
+
+func (row *auditRow) decode() (*audit.Entry, error) {
+	e := row.entry
+	if err := json.Unmarshal(row.changes, &e.Changes); err != nil {
+		return nil, fmt.Errorf("failed to scan audit entry: %v", err)
+	}
+	return &e, nil
+}
+
+func decodeAuditRows(rows []*auditRow) ([]*audit.Entry, error) {
+	entries := make([]*audit.Entry, 0, len(rows))
+	for _, row := range rows {
+		e, err := row.decode()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func (r *AuditRepository) List(ctx context.Context, filter audit.Filter) ([]*audit.Entry, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.auditLog.where(func(row *auditRow) bool {
+		e := &row.entry
+		return (filter.MerchantID == "" || e.MerchantID == filter.MerchantID) &&
+			(filter.ActorID == "" || e.ActorID == filter.ActorID) &&
+			(filter.From == nil || !e.CreatedAt.Before(*filter.From)) &&
+			(filter.To == nil || e.CreatedAt.Before(*filter.To))
+	})
+
+	// where returns the rows in sequence order, and List returns the newest first.
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+	return decodeAuditRows(page(rows, filter.Limit, filter.Offset))
+}
+
+func (r *AuditRepository) ListAfter(ctx context.Context, sequence int64, limit int) ([]*audit.Entry, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.auditLog.where(func(row *auditRow) bool { return row.entry.Sequence > sequence })
+	return decodeAuditRows(page(rows, limit, 0))
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
+	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	"github.com/popeskul/payment-gateway/internal/core/domain/subscription"
+	"github.com/popeskul/payment-gateway/internal/core/domain/user"
+	"github.com/popeskul/payment-gateway/internal/domain"
+)
+
+// The clone functions copy a row deeply enough that neither the stored row
+// nor the caller's copy can change the other.
+
+func clonePtr[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
+
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append([]T(nil), s...)
+}
+
+func cloneMerchant(m *merchant.Merchant) *merchant.Merchant {
+	c := *m
+	c.BusinessDetails = clonePtr(m.BusinessDetails)
+	c.SubmittedAt = clonePtr(m.SubmittedAt)
+	c.ReviewedAt = clonePtr(m.ReviewedAt)
+	c.Settings = cloneSettings(m.Settings)
+	c.DeletedAt = clonePtr(m.DeletedAt)
+	return &c
+}
+
+func cloneSettings(s *merchant.Settings) *merchant.Settings {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.AllowedCurrencies = cloneSlice(s.AllowedCurrencies)
+	c.AllowedPaymentMethods = cloneSlice(s.AllowedPaymentMethods)
+	c.TwoFactorRoles = cloneSlice(s.TwoFactorRoles)
+	return &c
+}
+
+func clonePayment(p *payment.Payment) *payment.Payment {
+	c := *p
+	c.ProcessedAt = clonePtr(p.ProcessedAt)
+	c.AuthorizationExpiresAt = clonePtr(p.AuthorizationExpiresAt)
+	return &c
+}
+
+func cloneRefund(r *refund.Refund) *refund.Refund {
+	c := *r
+	c.ProcessedAt = clonePtr(r.ProcessedAt)
+	return &c
+}
+
+func cloneUser(u *user.User) *user.User {
+	c := *u
+	c.EmailVerifiedAt = clonePtr(u.EmailVerifiedAt)
+	c.TwoFactorEnabledAt = clonePtr(u.TwoFactorEnabledAt)
+	return &c
+}
+
+func cloneUserToken(t *user.Token) *user.Token {
+	c := *t
+	c.UsedAt = clonePtr(t.UsedAt)
+	return &c
+}
+
+func cloneLoginThrottle(t *user.LoginThrottle) *user.LoginThrottle {
+	c := *t
+	c.LockedUntil = clonePtr(t.LockedUntil)
+	return &c
+}
+
+func cloneReport(r *reconciliation.Report) *reconciliation.Report {
+	c := *r
+	c.PeriodStart = clonePtr(r.PeriodStart)
+	c.PeriodEnd = clonePtr(r.PeriodEnd)
+	c.CompletedAt = clonePtr(r.CompletedAt)
+	return &c
+}
+
+func cloneDiscrepancy(d *reconciliation.Discrepancy) *reconciliation.Discrepancy {
+	c := *d
+	c.ExpectedAmount = clonePtr(d.ExpectedAmount)
+	c.ActualAmount = clonePtr(d.ActualAmount)
+	c.ResolvedAt = clonePtr(d.ResolvedAt)
+	return &c
+}
+
+func cloneSubscription(s *subscription.Subscription) *subscription.Subscription {
+	c := *s
+	c.TrialEnd = clonePtr(s.TrialEnd)
+	c.CanceledAt = clonePtr(s.CanceledAt)
+	c.NextRetryAt = clonePtr(s.NextRetryAt)
+	return &c
+}
+
+// cloneCustomer returns an empty rather than a nil Metadata map, since the
+// column is never NULL in Postgres.
+func cloneCustomer(cu *customer.Customer) *customer.Customer {
+	c := *cu
+	c.BillingAddress = clonePtr(cu.BillingAddress)
+	c.ShippingAddress = clonePtr(cu.ShippingAddress)
+	c.Metadata = make(map[string]string, len(cu.Metadata))
+	for k, v := range cu.Metadata {
+		c.Metadata[k] = v
+	}
+	return &c
+}
+
+func cloneAPIKey(k *apikey.APIKey) *apikey.APIKey {
+	c := *k
+	c.Scopes = cloneSlice(k.Scopes)
+	c.ExpiresAt = clonePtr(k.ExpiresAt)
+	c.LastUsedAt = clonePtr(k.LastUsedAt)
+	c.RevokedAt = clonePtr(k.RevokedAt)
+	return &c
+}
+
+func cloneOAuthClient(cl *oauth.Client) *oauth.Client {
+	c := *cl
+	c.Scopes = cloneSlice(cl.Scopes)
+	c.RevokedAt = clonePtr(cl.RevokedAt)
+	return &c
+}
+
+func cloneOAuthGrant(g *oauth.Grant) *oauth.Grant {
+	c := *g
+	c.Scopes = cloneSlice(g.Scopes)
+	return &c
+}
+
+func cloneSession(s *domain.Session) *domain.Session {
+	c := *s
+	c.RevokedAt = clonePtr(s.RevokedAt)
+	return &c
+}
+
+func cloneRefreshToken(t *domain.RefreshToken) *domain.RefreshToken {
+	c := *t
+	c.RotatedAt = clonePtr(t.RotatedAt)
+	c.RevokedAt = clonePtr(t.RevokedAt)
+	return &c
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,273 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type CustomerRepository struct {
+	store         *Store
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewCustomerRepository(store *Store, uuidGenerator ports.UUIDGenerator) ports.CustomerRepository {
+	return &CustomerRepository{
+		store:         store,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+func (s *Store) checkCustomer(c *customer.Customer) error {
+	if _, ok := s.merchants.get(c.MerchantID); !ok {
+		return foreignKeyViolation("customers", "customers_merchant_id_fkey")
+	}
+
+	taken := s.customers.exists(func(other *customer.Customer) bool {
+		return other.ID != c.ID && other.MerchantID == c.MerchantID && other.Email == c.Email
+	})
+	if taken {
+		return uniqueViolation("uq_customers_merchant_email")
+	}
+	return nil
+}
+
+func (r *CustomerRepository) Create(ctx context.Context, c *customer.Customer) error {
+	if c.ID == "" {
+		c.ID = r.uuidGenerator.Generate()
+	}
+
+	row := cloneCustomer(c)
+	err := r.store.write(func(tx *tx) error {
+		if err := r.store.checkCustomer(row); err != nil {
+			return err
+		}
+		return r.store.customers.insert(tx, row.ID, row, "customers_pkey")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create customer: %v", err)
+	}
+	return nil
+}
+
+func (r *CustomerRepository) GetByID(ctx context.Context, id string) (*customer.Customer, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	c, ok := r.store.customers.get(id)
+	if !ok {
+		return nil, fmt.Errorf("customer not found")
+	}
+	return cloneCustomer(c), nil
+}
+
+func (r *CustomerRepository) Update(ctx context.Context, c *customer.Customer) error {
+	err := r.store.write(func(tx *tx) error {
+		current, ok := r.store.customers.get(c.ID)
+		if !ok {
+			return nil
+		}
+
+		updated := cloneCustomer(c)
+		row := cloneCustomer(current)
+		row.Email = updated.Email
+		row.Name = updated.Name
+		row.Phone = updated.Phone
+		row.BillingAddress = updated.BillingAddress
+		row.ShippingAddress = updated.ShippingAddress
+		row.Metadata = updated.Metadata
+		row.UpdatedAt = updated.UpdatedAt
+		if err := r.store.checkCustomer(row); err != nil {
+			return err
+		}
+
+		r.store.customers.put(tx, row.ID, row)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update customer: %v", err)
+	}
+	return nil
+}
+
+func (r *CustomerRepository) List(ctx context.Context, merchantID string, m mode.Mode, search string, limit, offset int) ([]*customer.Customer, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	search = strings.ToLower(search)
+	rows := r.store.customers.where(func(c *customer.Customer) bool {
+		return c.MerchantID == merchantID && (m == "" || c.Mode == m) &&
+			(strings.Contains(strings.ToLower(c.Email), search) || strings.Contains(strings.ToLower(c.Name), search))
+	})
+	sortByTime(rows, customerCreatedAt, true)
+
+	var customers []*customer.Customer
+	for _, c := range page(rows, limit, offset) {
+		customers = append(customers, cloneCustomer(c))
+	}
+	return customers, nil
+}
+
+func customerCreatedAt(c *customer.Customer) time.Time { return c.CreatedAt }
+
+func (r *CustomerRepository) Delete(ctx context.Context, id string) error {
+	return r.store.write(func(tx *tx) error {
+		if _, ok := r.store.customers.get(id); !ok {
+			return fmt.Errorf("customer not found")
+		}
+
+		// Payment history is kept for accounting, but the stored token is
+		// personal data tied to the customer and goes with them.
+		for _, p := range r.store.payments.where(func(p *payment.Payment) bool { return p.CustomerID == id }) {
+			row := clonePayment(p)
+			row.PaymentToken = ""
+			row.CustomerID = ""
+			r.store.payments.put(tx, row.ID, row)
+		}
+		for _, ref := range r.store.refunds.where(func(ref *refund.Refund) bool { return ref.CustomerID == id }) {
+			row := cloneRefund(ref)
+			row.CustomerID = ""
+			r.store.refunds.put(tx, row.ID, row)
+		}
+		for _, pm := range r.store.paymentMethods.where(func(pm *customer.PaymentMethod) bool { return pm.CustomerID == id }) {
+			r.store.deletePaymentMethod(tx, pm.ID)
+		}
+
+		r.store.customers.delete(tx, id)
+		return nil
+	})
+}
+
+// deletePaymentMethod removes the payment method and clears it from the
+// payments made with it, like ON DELETE SET NULL.
+func (s *Store) deletePaymentMethod(tx *tx, id string) {
+	for _, p := range s.payments.where(func(p *payment.Payment) bool { return p.PaymentMethodID == id }) {
+		row := clonePayment(p)
+		row.PaymentMethodID = ""
+		s.payments.put(tx, row.ID, row)
+	}
+	s.paymentMethods.delete(tx, id)
+}
+
+// checkDefaultPaymentMethod enforces that a customer has at most one default
+// payment method.
+func (s *Store) checkDefaultPaymentMethod(pm *customer.PaymentMethod) error {
+	if !pm.IsDefault {
+		return nil
+	}
+
+	taken := s.paymentMethods.exists(func(other *customer.PaymentMethod) bool {
+		return other.ID != pm.ID && other.CustomerID == pm.CustomerID && other.IsDefault
+	})
+	if taken {
+		return uniqueViolation("idx_customer_payment_methods_default")
+	}
+	return nil
+}
+
+func (r *CustomerRepository) AddPaymentMethod(ctx context.Context, pm *customer.PaymentMethod) error {
+	if pm.ID == "" {
+		pm.ID = r.uuidGenerator.Generate()
+	}
+
+	row := clonePtr(pm)
+	err := r.store.write(func(tx *tx) error {
+		if _, ok := r.store.customers.get(row.CustomerID); !ok {
+			return foreignKeyViolation("customer_payment_methods", "customer_payment_methods_customer_id_fkey")
+		}
+		if err := r.store.checkDefaultPaymentMethod(row); err != nil {
+			return err
+		}
+		return r.store.paymentMethods.insert(tx, row.ID, row, "customer_payment_methods_pkey")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create payment method: %v", err)
+	}
+	return nil
+}
+
+func (r *CustomerRepository) GetPaymentMethod(ctx context.Context, id string) (*customer.PaymentMethod, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	pm, ok := r.store.paymentMethods.get(id)
+	if !ok {
+		return nil, fmt.Errorf("payment method not found")
+	}
+	return clonePtr(pm), nil
+}
+
+func paymentMethodCreatedAt(pm *customer.PaymentMethod) time.Time { return pm.CreatedAt }
+
+// ListPaymentMethods returns the default payment method first and the rest
+// newest first.
+func (r *CustomerRepository) ListPaymentMethods(ctx context.Context, customerID string) ([]*customer.PaymentMethod, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.paymentMethods.where(func(pm *customer.PaymentMethod) bool {
+		return pm.CustomerID == customerID
+	})
+	sortByTime(rows, paymentMethodCreatedAt, true)
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].IsDefault && !rows[j].IsDefault })
+
+	var methods []*customer.PaymentMethod
+	for _, pm := range rows {
+		methods = append(methods, clonePtr(pm))
+	}
+	return methods, nil
+}
+
+func (r *CustomerRepository) SetDefaultPaymentMethod(ctx context.Context, customerID, id string) error {
+	return r.store.write(func(tx *tx) error {
+		for _, pm := range r.store.paymentMethods.where(func(pm *customer.PaymentMethod) bool {
+			return pm.CustomerID == customerID && pm.IsDefault
+		}) {
+			row := clonePtr(pm)
+			row.IsDefault = false
+			r.store.paymentMethods.put(tx, row.ID, row)
+		}
+
+		current, ok := r.store.paymentMethods.get(id)
+		if !ok || current.CustomerID != customerID {
+			return fmt.Errorf("payment method not found")
+		}
+		row := clonePtr(current)
+		row.IsDefault = true
+		r.store.paymentMethods.put(tx, row.ID, row)
+		return nil
+	})
+}
+
+func (r *CustomerRepository) DeletePaymentMethod(ctx context.Context, id string) error {
+	return r.store.write(func(tx *tx) error {
+		deleted, ok := r.store.paymentMethods.get(id)
+		if !ok {
+			return nil
+		}
+		r.store.deletePaymentMethod(tx, id)
+		if !deleted.IsDefault {
+			return nil
+		}
+
+		rows := r.store.paymentMethods.where(func(pm *customer.PaymentMethod) bool {
+			return pm.CustomerID == deleted.CustomerID
+		})
+		if len(rows) == 0 {
+			return nil
+		}
+		sortByTime(rows, paymentMethodCreatedAt, true)
+		row := clonePtr(rows[0])
+		row.IsDefault = true
+		r.store.paymentMethods.put(tx, row.ID, row)
+		return nil
+	})
+}
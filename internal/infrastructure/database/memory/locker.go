@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type Locker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+// NewLocker returns a Locker that only excludes holders within this process,
+// which is all there is when the data lives in memory.
+func NewLocker() ports.Locker {
+	return &Locker{
+		held: make(map[string]bool),
+	}
+}
+
+func (l *Locker) TryLock(ctx context.Context, key string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held[key] {
+		return nil, false, nil
+	}
+	l.held[key] = true
+
+	var once sync.Once
+	unlock := func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			delete(l.held, key)
+		})
+	}
+
+	return unlock, true, nil
+}
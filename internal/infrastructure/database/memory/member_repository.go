@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type memberKey struct {
+	merchantID string
+	userID     string
+}
+
+type MemberRepository struct {
+	store *Store
+}
+
+func NewMemberRepository(store *Store) ports.MemberRepository {
+	return &MemberRepository{
+		store: store,
+	}
+}
+
+func (r *MemberRepository) Add(ctx context.Context, m *member.Member) error {
+	err := r.store.write(func(tx *tx) error {
+		return r.store.addMember(tx, m)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add member: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) addMember(tx *tx, m *member.Member) error {
+	row := clonePtr(m)
+	row.Email = ""
+	if _, ok := s.merchants.get(row.MerchantID); !ok {
+		return foreignKeyViolation("merchant_members", "merchant_members_merchant_id_fkey")
+	}
+	if _, ok := s.users.get(row.UserID); !ok {
+		return foreignKeyViolation("merchant_members", "merchant_members_user_id_fkey")
+	}
+	if err := references(s.users, row.InvitedBy, "merchant_members", "merchant_members_invited_by_fkey"); err != nil {
+		return err
+	}
+	k := memberKey{merchantID: row.MerchantID, userID: row.UserID}
+	return s.members.insert(tx, k, row, "merchant_members_pkey")
+}
+
+// withEmail returns a copy of the member with the email of its user, which
+// the Postgres queries join in.
+func (s *Store) withEmail(m *member.Member) *member.Member {
+	c := clonePtr(m)
+	if u, ok := s.users.get(m.UserID); ok {
+		c.Email = u.Email
+	}
+	return c
+}
+
+func (r *MemberRepository) Get(ctx context.Context, merchantID, userID string) (*member.Member, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	m, ok := r.store.members.get(memberKey{merchantID: merchantID, userID: userID})
+	if !ok {
+		return nil, fmt.Errorf("member not found")
+	}
+	return r.store.withEmail(m), nil
+}
+
+func (r *MemberRepository) List(ctx context.Context, merchantID string) ([]*member.Member, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.members.where(func(m *member.Member) bool { return m.MerchantID == merchantID })
+	sortByTime(rows, func(m *member.Member) time.Time { return m.CreatedAt }, false)
+
+	var members []*member.Member
+	for _, m := range rows {
+		members = append(members, r.store.withEmail(m))
+	}
+	return members, nil
+}
+
+func (r *MemberRepository) Remove(ctx context.Context, merchantID, userID string) error {
+	removed := false
+	_ = r.store.write(func(tx *tx) error {
+		removed = r.store.members.delete(tx, memberKey{merchantID: merchantID, userID: userID})
+		return nil
+	})
+	if !removed {
+		return fmt.Errorf("member not found")
+	}
+	return nil
+}
+
+func (r *MemberRepository) CountByRole(ctx context.Context, merchantID string, role member.Role) (int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.members.where(func(m *member.Member) bool {
+		return m.MerchantID == merchantID && m.Role == role
+	})
+	return len(rows), nil
+}
@@ -0,0 +1,22 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/popeskul/payment-gateway/internal/infrastructure/database/memory"
+	"github.com/popeskul/payment-gateway/internal/infrastructure/database/storagetest"
+	"github.com/popeskul/payment-gateway/internal/infrastructure/uuid"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		store := memory.NewStore()
+		uuidGenerator := uuid.NewUUIDGenerator()
+		return storagetest.Backend{
+			Repositories: memory.NewRepositories(store, uuidGenerator),
+			TokenStore:   memory.NewTokenStore(store, uuidGenerator),
+			Locker:       memory.NewLocker(),
+			RateLimits:   memory.NewRateLimitStore(),
+		}
+	})
+}
@@ -0,0 +1,322 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/subscription"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type MerchantRepository struct {
+	store         *Store
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewMerchantRepository(store *Store, uuidGenerator ports.UUIDGenerator) ports.MerchantRepository {
+	return &MerchantRepository{
+		store:         store,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+// checkMerchantEmail enforces that no two merchants that are not deleted
+// share an email address.
+func (s *Store) checkMerchantEmail(m *merchant.Merchant) error {
+	taken := s.merchants.exists(func(other *merchant.Merchant) bool {
+		return other.ID != m.ID && other.Email == m.Email && other.DeletedAt == nil
+	})
+	if taken {
+		return uniqueViolation("uq_merchants_email_active")
+	}
+	return nil
+}
+
+// checkMerchantUnreferenced fails like the foreign keys without ON DELETE
+// CASCADE that point at merchants.
+func (s *Store) checkMerchantUnreferenced(id string) error {
+	switch {
+	case s.payments.exists(func(p *payment.Payment) bool { return p.MerchantID == id }):
+		return referencedViolation("merchants", "payments_merchant_id_fkey", "payments")
+	case s.customers.exists(func(c *customer.Customer) bool { return c.MerchantID == id }):
+		return referencedViolation("merchants", "customers_merchant_id_fkey", "customers")
+	case s.plans.exists(func(p *subscription.Plan) bool { return p.MerchantID == id }):
+		return referencedViolation("merchants", "plans_merchant_id_fkey", "plans")
+	case s.subscriptions.exists(func(sub *subscription.Subscription) bool { return sub.MerchantID == id }):
+		return referencedViolation("merchants", "subscriptions_merchant_id_fkey", "subscriptions")
+	case s.oauthGrants.exists(func(g *oauth.Grant) bool { return g.MerchantID == id }):
+		return referencedViolation("merchants", "oauth_grants_merchant_id_fkey", "oauth_grants")
+	}
+	return nil
+}
+
+func (r *MerchantRepository) Create(ctx context.Context, m *merchant.Merchant) error {
+	row := r.newRow(m)
+	err := r.store.write(func(tx *tx) error {
+		return r.insert(tx, row)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create merchant: %v", err)
+	}
+	return nil
+}
+
+func (r *MerchantRepository) CreateWithOwner(ctx context.Context, m *merchant.Merchant, owner *member.Member, keys []*apikey.APIKey) error {
+	row := r.newRow(m)
+	err := r.store.write(func(tx *tx) error {
+		if err := r.insert(tx, row); err != nil {
+			return err
+		}
+		owner.MerchantID = row.ID
+		if err := r.store.addMember(tx, owner); err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if k.ID == "" {
+				k.ID = r.uuidGenerator.Generate()
+			}
+			k.MerchantID = row.ID
+			if err := r.store.insertAPIKey(tx, k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create merchant: %v", err)
+	}
+	return nil
+}
+
+// newRow assigns m an id if it has none and returns the columns Create
+// writes.
+func (r *MerchantRepository) newRow(m *merchant.Merchant) *merchant.Merchant {
+	if m.ID == "" {
+		m.ID = r.uuidGenerator.Generate()
+	}
+
+	return &merchant.Merchant{
+		ID:                 m.ID,
+		Name:               m.Name,
+		Email:              m.Email,
+		SettlementCurrency: m.SettlementCurrency,
+		Status:             m.Status,
+		CreatedAt:          m.CreatedAt,
+		UpdatedAt:          m.UpdatedAt,
+	}
+}
+
+func (r *MerchantRepository) insert(tx *tx, row *merchant.Merchant) error {
+	if err := r.store.checkMerchantEmail(row); err != nil {
+		return err
+	}
+	return r.store.merchants.insert(tx, row.ID, row, "merchants_pkey")
+}
+
+func (r *MerchantRepository) GetByID(ctx context.Context, id string) (*merchant.Merchant, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	m, ok := r.store.merchants.get(id)
+	if !ok {
+		return nil, fmt.Errorf("merchant not found")
+	}
+	return cloneMerchant(m), nil
+}
+
+// update replaces the row of id with a copy changed by fn. A missing row is
+// not an error, like an UPDATE that matches nothing.
+func (r *MerchantRepository) update(id string, fn func(row *merchant.Merchant) error) error {
+	return r.store.write(func(tx *tx) error {
+		current, ok := r.store.merchants.get(id)
+		if !ok {
+			return nil
+		}
+
+		row := cloneMerchant(current)
+		if err := fn(row); err != nil {
+			return err
+		}
+		r.store.merchants.put(tx, id, row)
+		return nil
+	})
+}
+
+func (r *MerchantRepository) Update(ctx context.Context, m *merchant.Merchant) error {
+	err := r.update(m.ID, func(row *merchant.Merchant) error {
+		row.Name = m.Name
+		row.Email = m.Email
+		row.SettlementCurrency = m.SettlementCurrency
+		row.UpdatedAt = m.UpdatedAt
+		return r.store.checkMerchantEmail(row)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update merchant: %v", err)
+	}
+	return nil
+}
+
+func (r *MerchantRepository) UpdateOnboarding(ctx context.Context, m *merchant.Merchant) error {
+	err := r.update(m.ID, func(row *merchant.Merchant) error {
+		if err := references(r.store.users, m.ReviewedBy, "merchants", "merchants_reviewed_by_fkey"); err != nil {
+			return err
+		}
+
+		row.Status = m.Status
+		row.StatusReason = m.StatusReason
+		row.BusinessDetails = clonePtr(m.BusinessDetails)
+		row.SubmittedAt = clonePtr(m.SubmittedAt)
+		row.ReviewedAt = clonePtr(m.ReviewedAt)
+		row.ReviewedBy = m.ReviewedBy
+		row.UpdatedAt = m.UpdatedAt
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update merchant onboarding: %v", err)
+	}
+	return nil
+}
+
+func (r *MerchantRepository) UpdateSettings(ctx context.Context, m *merchant.Merchant) error {
+	err := r.update(m.ID, func(row *merchant.Merchant) error {
+		row.Settings = cloneSettings(m.Settings)
+		row.UpdatedAt = m.UpdatedAt
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update merchant settings: %v", err)
+	}
+	return nil
+}
+
+func (r *MerchantRepository) SoftDelete(ctx context.Context, m *merchant.Merchant) error {
+	found := false
+	err := r.update(m.ID, func(row *merchant.Merchant) error {
+		if row.DeletedAt != nil {
+			return nil
+		}
+		found = true
+		row.DeletedAt = clonePtr(m.DeletedAt)
+		row.DeletionReason = m.DeletionReason
+		row.UpdatedAt = m.UpdatedAt
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete merchant: %v", err)
+	}
+	if !found {
+		return fmt.Errorf("merchant not found")
+	}
+	return nil
+}
+
+// Delete fails while payments, customers, plans, subscriptions or OAuth
+// grants refer to the merchant, and takes its members, API keys and
+// onboarding data with it.
+func (r *MerchantRepository) Delete(ctx context.Context, id string) error {
+	err := r.store.write(func(tx *tx) error {
+		if _, ok := r.store.merchants.get(id); !ok {
+			return nil
+		}
+		if err := r.store.checkMerchantUnreferenced(id); err != nil {
+			return err
+		}
+
+		for _, k := range r.store.members.keysWhere(func(m *member.Member) bool { return m.MerchantID == id }) {
+			r.store.members.delete(tx, k)
+		}
+		for _, k := range r.store.apiKeys.where(func(k *apikey.APIKey) bool { return k.MerchantID == id }) {
+			r.store.deleteNonces(tx, k.ID)
+			r.store.apiKeys.delete(tx, k.ID)
+		}
+		for _, o := range r.store.beneficialOwners.where(func(o *merchant.BeneficialOwner) bool { return o.MerchantID == id }) {
+			r.store.beneficialOwners.delete(tx, o.ID)
+		}
+		for _, d := range r.store.documents.where(func(d *merchant.Document) bool { return d.MerchantID == id }) {
+			r.store.documents.delete(tx, d.ID)
+		}
+
+		r.store.merchants.delete(tx, id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete merchant: %v", err)
+	}
+	return nil
+}
+
+func (r *MerchantRepository) list(match func(*merchant.Merchant) bool, at func(*merchant.Merchant) time.Time, desc bool, limit, offset int) []*merchant.Merchant {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.merchants.where(match)
+	sortByTime(rows, at, desc)
+
+	var merchants []*merchant.Merchant
+	for _, m := range page(rows, limit, offset) {
+		merchants = append(merchants, cloneMerchant(m))
+	}
+	return merchants
+}
+
+func merchantCreatedAt(m *merchant.Merchant) time.Time { return m.CreatedAt }
+
+func (r *MerchantRepository) List(ctx context.Context, limit, offset int) ([]*merchant.Merchant, error) {
+	return r.list(func(m *merchant.Merchant) bool {
+		return m.DeletedAt == nil
+	}, merchantCreatedAt, true, limit, offset), nil
+}
+
+func (r *MerchantRepository) ListByUser(ctx context.Context, userID string, limit, offset int) ([]*merchant.Merchant, error) {
+	return r.list(func(m *merchant.Merchant) bool {
+		_, isMember := r.store.members.get(memberKey{merchantID: m.ID, userID: userID})
+		return isMember && m.DeletedAt == nil
+	}, merchantCreatedAt, true, limit, offset), nil
+}
+
+// ListByStatus returns the oldest submissions first so the review queue is
+// worked in order. Merchants that were never submitted come last.
+func (r *MerchantRepository) ListByStatus(ctx context.Context, status merchant.Status, limit, offset int) ([]*merchant.Merchant, error) {
+	r.store.mu.RLock()
+	rows := r.store.merchants.where(func(m *merchant.Merchant) bool {
+		return m.Status == status && m.DeletedAt == nil
+	})
+	r.store.mu.RUnlock()
+
+	sortByTime(rows, merchantCreatedAt, false)
+	var submitted, unsubmitted []*merchant.Merchant
+	for _, m := range rows {
+		if m.SubmittedAt != nil {
+			submitted = append(submitted, m)
+		} else {
+			unsubmitted = append(unsubmitted, m)
+		}
+	}
+	sortByTime(submitted, func(m *merchant.Merchant) time.Time { return *m.SubmittedAt }, false)
+
+	var merchants []*merchant.Merchant
+	for _, m := range page(append(submitted, unsubmitted...), limit, offset) {
+		merchants = append(merchants, cloneMerchant(m))
+	}
+	return merchants, nil
+}
+
+func (r *MerchantRepository) GetByEmail(ctx context.Context, email string) (*merchant.Merchant, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.merchants.where(func(m *merchant.Merchant) bool {
+		return m.Email == email && m.DeletedAt == nil
+	})
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("merchant not found")
+	}
+	return cloneMerchant(rows[0]), nil
+}
@@ -0,0 +1,166 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type grantKey struct {
+	clientID   string
+	merchantID string
+}
+
+type OAuthClientRepository struct {
+	store         *Store
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewOAuthClientRepository(store *Store, uuidGenerator ports.UUIDGenerator) ports.OAuthClientRepository {
+	return &OAuthClientRepository{
+		store:         store,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+func (r *OAuthClientRepository) Create(ctx context.Context, c *oauth.Client) error {
+	if c.ID == "" {
+		c.ID = r.uuidGenerator.Generate()
+	}
+
+	row := cloneOAuthClient(c)
+	row.RevokedAt = nil
+	err := r.store.write(func(tx *tx) error {
+		if _, ok := r.store.users.get(row.OwnerID); !ok {
+			return foreignKeyViolation("oauth_clients", "oauth_clients_owner_id_fkey")
+		}
+		return r.store.oauthClients.insert(tx, row.ID, row, "oauth_clients_pkey")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create oauth client: %v", err)
+	}
+	return nil
+}
+
+func (r *OAuthClientRepository) GetByID(ctx context.Context, id string) (*oauth.Client, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	c, ok := r.store.oauthClients.get(id)
+	if !ok {
+		return nil, oauth.ErrClientNotFound
+	}
+	return cloneOAuthClient(c), nil
+}
+
+func (r *OAuthClientRepository) ListByOwner(ctx context.Context, ownerID string) ([]*oauth.Client, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.oauthClients.where(func(c *oauth.Client) bool { return c.OwnerID == ownerID })
+	sortByTime(rows, func(c *oauth.Client) time.Time { return c.CreatedAt }, true)
+
+	var clients []*oauth.Client
+	for _, c := range rows {
+		clients = append(clients, cloneOAuthClient(c))
+	}
+	return clients, nil
+}
+
+func (r *OAuthClientRepository) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	return r.store.write(func(tx *tx) error {
+		current, ok := r.store.oauthClients.get(id)
+		if !ok || current.RevokedAt != nil {
+			return nil
+		}
+
+		row := cloneOAuthClient(current)
+		row.RevokedAt = timePtr(revokedAt)
+		r.store.oauthClients.put(tx, id, row)
+		return nil
+	})
+}
+
+// SaveGrant keeps the creation time of a grant that already exists.
+func (r *OAuthClientRepository) SaveGrant(ctx context.Context, g *oauth.Grant) error {
+	err := r.store.write(func(tx *tx) error {
+		if _, ok := r.store.oauthClients.get(g.ClientID); !ok {
+			return foreignKeyViolation("oauth_grants", "oauth_grants_client_id_fkey")
+		}
+		if _, ok := r.store.merchants.get(g.MerchantID); !ok {
+			return foreignKeyViolation("oauth_grants", "oauth_grants_merchant_id_fkey")
+		}
+		if err := references(r.store.users, g.GrantedBy, "oauth_grants", "oauth_grants_granted_by_fkey"); err != nil {
+			return err
+		}
+
+		k := grantKey{clientID: g.ClientID, merchantID: g.MerchantID}
+		row := cloneOAuthGrant(g)
+		if current, ok := r.store.oauthGrants.get(k); ok {
+			row.CreatedAt = current.CreatedAt
+		}
+		r.store.oauthGrants.put(tx, k, row)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save oauth grant: %v", err)
+	}
+	return nil
+}
+
+func (r *OAuthClientRepository) GetGrant(ctx context.Context, clientID, merchantID string) (*oauth.Grant, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	g, ok := r.store.oauthGrants.get(grantKey{clientID: clientID, merchantID: merchantID})
+	if !ok {
+		return nil, oauth.ErrGrantNotFound
+	}
+	return cloneOAuthGrant(g), nil
+}
+
+func (r *OAuthClientRepository) listGrants(match func(*oauth.Grant) bool) []*oauth.Grant {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.oauthGrants.where(match)
+	sortByTime(rows, func(g *oauth.Grant) time.Time { return g.CreatedAt }, true)
+
+	var grants []*oauth.Grant
+	for _, g := range rows {
+		grants = append(grants, cloneOAuthGrant(g))
+	}
+	return grants
+}
+
+func (r *OAuthClientRepository) ListGrantsByMerchant(ctx context.Context, merchantID string) ([]*oauth.Grant, error) {
+	return r.listGrants(func(g *oauth.Grant) bool { return g.MerchantID == merchantID }), nil
+}
+
+func (r *OAuthClientRepository) ListGrantsByClient(ctx context.Context, clientID string) ([]*oauth.Grant, error) {
+	return r.listGrants(func(g *oauth.Grant) bool { return g.ClientID == clientID }), nil
+}
+
+func (r *OAuthClientRepository) DeleteGrant(ctx context.Context, clientID, merchantID string) error {
+	deleted := false
+	_ = r.store.write(func(tx *tx) error {
+		deleted = r.store.oauthGrants.delete(tx, grantKey{clientID: clientID, merchantID: merchantID})
+		return nil
+	})
+	if !deleted {
+		return oauth.ErrGrantNotFound
+	}
+	return nil
+}
+
+func (r *OAuthClientRepository) DeleteGrantsByMerchant(ctx context.Context, merchantID string) error {
+	return r.store.write(func(tx *tx) error {
+		for _, k := range r.store.oauthGrants.keysWhere(func(g *oauth.Grant) bool { return g.MerchantID == merchantID }) {
+			r.store.oauthGrants.delete(tx, k)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type OnboardingRepository struct {
+	store         *Store
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewOnboardingRepository(store *Store, uuidGenerator ports.UUIDGenerator) ports.OnboardingRepository {
+	return &OnboardingRepository{
+		store:         store,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+func (r *OnboardingRepository) AddOwner(ctx context.Context, o *merchant.BeneficialOwner) error {
+	if o.ID == "" {
+		o.ID = r.uuidGenerator.Generate()
+	}
+
+	row := clonePtr(o)
+	err := r.store.write(func(tx *tx) error {
+		if _, ok := r.store.merchants.get(row.MerchantID); !ok {
+			return foreignKeyViolation("merchant_beneficial_owners", "merchant_beneficial_owners_merchant_id_fkey")
+		}
+		return r.store.beneficialOwners.insert(tx, row.ID, row, "merchant_beneficial_owners_pkey")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create beneficial owner: %v", err)
+	}
+	return nil
+}
+
+func (r *OnboardingRepository) ListOwners(ctx context.Context, merchantID string) ([]*merchant.BeneficialOwner, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.beneficialOwners.where(func(o *merchant.BeneficialOwner) bool { return o.MerchantID == merchantID })
+	sortByTime(rows, func(o *merchant.BeneficialOwner) time.Time { return o.CreatedAt }, false)
+
+	var owners []*merchant.BeneficialOwner
+	for _, o := range rows {
+		owners = append(owners, clonePtr(o))
+	}
+	return owners, nil
+}
+
+func (r *OnboardingRepository) DeleteOwner(ctx context.Context, merchantID, id string) error {
+	deleted := false
+	_ = r.store.write(func(tx *tx) error {
+		if o, ok := r.store.beneficialOwners.get(id); ok && o.MerchantID == merchantID {
+			deleted = r.store.beneficialOwners.delete(tx, id)
+		}
+		return nil
+	})
+	if !deleted {
+		return fmt.Errorf("beneficial owner not found")
+	}
+	return nil
+}
+
+func (r *OnboardingRepository) AddDocument(ctx context.Context, d *merchant.Document) error {
+	if d.ID == "" {
+		d.ID = r.uuidGenerator.Generate()
+	}
+
+	row := clonePtr(d)
+	err := r.store.write(func(tx *tx) error {
+		if _, ok := r.store.merchants.get(row.MerchantID); !ok {
+			return foreignKeyViolation("merchant_documents", "merchant_documents_merchant_id_fkey")
+		}
+		if err := references(r.store.users, row.UploadedBy, "merchant_documents", "merchant_documents_uploaded_by_fkey"); err != nil {
+			return err
+		}
+		if r.store.documents.exists(func(other *merchant.Document) bool { return other.StorageKey == row.StorageKey }) {
+			return uniqueViolation("merchant_documents_storage_key_key")
+		}
+		return r.store.documents.insert(tx, row.ID, row, "merchant_documents_pkey")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create document: %v", err)
+	}
+	return nil
+}
+
+func (r *OnboardingRepository) GetDocument(ctx context.Context, id string) (*merchant.Document, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	d, ok := r.store.documents.get(id)
+	if !ok {
+		return nil, fmt.Errorf("document not found")
+	}
+	return clonePtr(d), nil
+}
+
+func (r *OnboardingRepository) ListDocuments(ctx context.Context, merchantID string) ([]*merchant.Document, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.documents.where(func(d *merchant.Document) bool { return d.MerchantID == merchantID })
+	sortByTime(rows, func(d *merchant.Document) time.Time { return d.CreatedAt }, false)
+
+	var documents []*merchant.Document
+	for _, d := range rows {
+		documents = append(documents, clonePtr(d))
+	}
+	return documents, nil
+}
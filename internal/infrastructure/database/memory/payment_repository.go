@@ -0,0 +1,297 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type PaymentRepository struct {
+	store         *Store
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewPaymentRepository(store *Store, uuidGenerator ports.UUIDGenerator) ports.PaymentRepository {
+	return &PaymentRepository{
+		store:         store,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+// checkPayment enforces the foreign keys of a payment and the uniqueness of
+// its acquirer reference.
+func (s *Store) checkPayment(p *payment.Payment) error {
+	if err := references(s.merchants, p.MerchantID, "payments", "payments_merchant_id_fkey"); err != nil {
+		return err
+	}
+	if err := references(s.subscriptions, p.SubscriptionID, "payments", "payments_subscription_id_fkey"); err != nil {
+		return err
+	}
+	if err := references(s.customers, p.CustomerID, "payments", "payments_customer_id_fkey"); err != nil {
+		return err
+	}
+	if err := references(s.paymentMethods, p.PaymentMethodID, "payments", "payments_payment_method_id_fkey"); err != nil {
+		return err
+	}
+
+	if p.AcquirerReference != "" {
+		taken := s.payments.exists(func(other *payment.Payment) bool {
+			return other.ID != p.ID && other.AcquirerReference == p.AcquirerReference
+		})
+		if taken {
+			return uniqueViolation("idx_payments_acquirer_reference")
+		}
+	}
+	return nil
+}
+
+func (r *PaymentRepository) Create(ctx context.Context, p *payment.Payment) error {
+	if p.ID == "" {
+		p.ID = r.uuidGenerator.Generate()
+	}
+
+	row := clonePayment(p)
+	err := r.store.write(func(tx *tx) error {
+		return r.insert(tx, row)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create payment: %v", err)
+	}
+	return nil
+}
+
+func (r *PaymentRepository) insert(tx *tx, row *payment.Payment) error {
+	if err := r.store.checkPayment(row); err != nil {
+		return err
+	}
+	return r.store.payments.insert(tx, row.ID, row, "payments_pkey")
+}
+
+func (r *PaymentRepository) GetByID(ctx context.Context, id string) (*payment.Payment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	p, ok := r.store.payments.get(id)
+	if !ok {
+		return nil, payment.ErrNotFound
+	}
+	return clonePayment(p), nil
+}
+
+func (r *PaymentRepository) Update(ctx context.Context, p *payment.Payment) error {
+	err := r.store.write(func(tx *tx) error {
+		current, ok := r.store.payments.get(p.ID)
+		if !ok {
+			return nil
+		}
+
+		row := clonePayment(current)
+		row.MerchantID = p.MerchantID
+		row.Amount = p.Amount
+		row.Currency = p.Currency
+		row.SettlementAmount = p.SettlementAmount
+		row.SettlementCurrency = p.SettlementCurrency
+		row.FXRate = p.FXRate
+		row.FXRateTimestamp = p.FXRateTimestamp
+		row.Status = p.Status
+		row.PaymentMethod = p.PaymentMethod
+		row.Description = p.Description
+		row.AcquirerReference = p.AcquirerReference
+		row.ProcessedAt = clonePtr(p.ProcessedAt)
+		row.AuthorizationExpiresAt = clonePtr(p.AuthorizationExpiresAt)
+		row.UpdatedAt = p.UpdatedAt
+		if err := r.store.checkPayment(row); err != nil {
+			return err
+		}
+
+		r.store.payments.put(tx, row.ID, row)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update payment: %v", err)
+	}
+	return nil
+}
+
+func (r *PaymentRepository) query(match func(*payment.Payment) bool, at func(*payment.Payment) time.Time, desc bool, limit, offset int) []*payment.Payment {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.payments.where(match)
+	sortByTime(rows, at, desc)
+
+	var payments []*payment.Payment
+	for _, p := range page(rows, limit, offset) {
+		payments = append(payments, clonePayment(p))
+	}
+	return payments
+}
+
+func paymentCreatedAt(p *payment.Payment) time.Time { return p.CreatedAt }
+
+func (r *PaymentRepository) List(ctx context.Context, merchantID string, m mode.Mode, limit, offset int) ([]*payment.Payment, error) {
+	return r.query(func(p *payment.Payment) bool {
+		return p.MerchantID == merchantID && (m == "" || p.Mode == m)
+	}, paymentCreatedAt, true, limit, offset), nil
+}
+
+func (r *PaymentRepository) ListByCustomer(ctx context.Context, customerID string, limit, offset int) ([]*payment.Payment, error) {
+	return r.query(func(p *payment.Payment) bool {
+		return p.CustomerID == customerID
+	}, paymentCreatedAt, true, limit, offset), nil
+}
+
+func (r *PaymentRepository) UpdateStatus(ctx context.Context, id string, status payment.PaymentStatus) error {
+	return r.store.write(func(tx *tx) error {
+		current, ok := r.store.payments.get(id)
+		if !ok {
+			return nil
+		}
+
+		row := clonePayment(current)
+		row.Status = status
+		row.UpdatedAt = time.Now()
+		r.store.payments.put(tx, id, row)
+		return nil
+	})
+}
+
+func (r *PaymentRepository) GetByAcquirerReference(ctx context.Context, reference string) (*payment.Payment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.payments.where(func(p *payment.Payment) bool {
+		return p.AcquirerReference == reference && p.Mode == mode.Live
+	})
+	if reference == "" || len(rows) == 0 {
+		return nil, payment.ErrNotFound
+	}
+	return clonePayment(rows[0]), nil
+}
+
+func (r *PaymentRepository) ListProcessedBetween(ctx context.Context, from, to time.Time) ([]*payment.Payment, error) {
+	return r.query(func(p *payment.Payment) bool {
+		return p.ProcessedAt != nil && !p.ProcessedAt.Before(from) && !p.ProcessedAt.After(to) &&
+			p.AcquirerReference != "" && p.Mode == mode.Live
+	}, func(p *payment.Payment) time.Time { return *p.ProcessedAt }, false, noLimit, 0), nil
+}
+
+func (r *PaymentRepository) TransitionStatus(ctx context.Context, p *payment.Payment, from payment.PaymentStatus, reason string) error {
+	return r.store.write(func(tx *tx) error {
+		current, ok := r.store.payments.get(p.ID)
+		if !ok || current.Status != from {
+			return fmt.Errorf("payment is not in %s status", from)
+		}
+
+		row := clonePayment(current)
+		row.Status = p.Status
+		row.AcquirerReference = p.AcquirerReference
+		row.ProcessedAt = clonePtr(p.ProcessedAt)
+		row.AuthorizationExpiresAt = clonePtr(p.AuthorizationExpiresAt)
+		row.UpdatedAt = p.UpdatedAt
+		if err := r.store.checkPayment(row); err != nil {
+			return fmt.Errorf("failed to update payment status: %v", err)
+		}
+		r.store.payments.put(tx, row.ID, row)
+
+		transition := &payment.StatusTransition{
+			ID:         r.uuidGenerator.Generate(),
+			PaymentID:  p.ID,
+			FromStatus: from,
+			ToStatus:   p.Status,
+			Reason:     reason,
+			CreatedAt:  p.UpdatedAt,
+		}
+		if err := r.store.paymentTransitions.insert(tx, transition.ID, transition, "payment_status_transitions_pkey"); err != nil {
+			return fmt.Errorf("failed to record payment status transition: %v", err)
+		}
+		return nil
+	})
+}
+
+func (r *PaymentRepository) ListTransitions(ctx context.Context, paymentID string) ([]*payment.StatusTransition, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.paymentTransitions.where(func(t *payment.StatusTransition) bool {
+		return t.PaymentID == paymentID
+	})
+	sortByTime(rows, func(t *payment.StatusTransition) time.Time { return t.CreatedAt }, false)
+
+	var transitions []*payment.StatusTransition
+	for _, t := range rows {
+		transitions = append(transitions, clonePtr(t))
+	}
+	return transitions, nil
+}
+
+func (r *PaymentRepository) ListPendingCreatedBefore(ctx context.Context, before time.Time, limit int) ([]*payment.Payment, error) {
+	return r.query(func(p *payment.Payment) bool {
+		return p.Status == payment.PaymentStatusPending && p.CreatedAt.Before(before)
+	}, paymentCreatedAt, false, limit, 0), nil
+}
+
+func (r *PaymentRepository) ListAuthorizationsExpiredBefore(ctx context.Context, before time.Time, limit int) ([]*payment.Payment, error) {
+	return r.query(func(p *payment.Payment) bool {
+		return p.Status == payment.PaymentStatusAuthorized && p.AuthorizationExpiresAt != nil && p.AuthorizationExpiresAt.Before(before)
+	}, func(p *payment.Payment) time.Time { return *p.AuthorizationExpiresAt }, false, limit, 0), nil
+}
+
+func (r *PaymentRepository) SumVolume(ctx context.Context, merchantID string, m mode.Mode, since time.Time) (float64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.sumVolume(merchantID, m, since), nil
+}
+
+// sumVolume adds completed refunds back onto the settlement amount, which
+// they reduce, to get the gross volume. The caller holds the store's lock.
+func (r *PaymentRepository) sumVolume(merchantID string, m mode.Mode, since time.Time) float64 {
+	var total float64
+	for _, p := range r.store.payments.where(func(p *payment.Payment) bool {
+		return p.MerchantID == merchantID && p.Mode == m && !p.CreatedAt.Before(since)
+	}) {
+		switch p.Status {
+		case payment.PaymentStatusPending, payment.PaymentStatusProcessing, payment.PaymentStatusAuthorized,
+			payment.PaymentStatusVoiding, payment.PaymentStatusCompleted:
+			total += p.SettlementAmount
+			for _, ref := range r.store.refunds.where(func(ref *refund.Refund) bool {
+				return ref.PaymentID == p.ID && ref.Status == refund.RefundStatusCompleted
+			}) {
+				total += ref.SettlementAmount
+			}
+		}
+	}
+	return total
+}
+
+func (r *PaymentRepository) CreateWithinVolume(ctx context.Context, p *payment.Payment, since []time.Time, check func(volumes []float64) error) error {
+	if p.ID == "" {
+		p.ID = r.uuidGenerator.Generate()
+	}
+
+	row := clonePayment(p)
+	var checkErr error
+	err := r.store.write(func(tx *tx) error {
+		volumes := make([]float64, len(since))
+		for i, t := range since {
+			volumes[i] = r.sumVolume(p.MerchantID, p.Mode, t)
+		}
+		if checkErr = check(volumes); checkErr != nil {
+			return checkErr
+		}
+		return r.insert(tx, row)
+	})
+	if checkErr != nil {
+		return checkErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create payment: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/ratelimit"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type RateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*ratelimit.Bucket
+}
+
+// NewRateLimitStore returns a RateLimitStore local to this process, so each
+// replica enforces the limits on its own.
+func NewRateLimitStore() ports.RateLimitStore {
+	return &RateLimitStore{
+		buckets: make(map[string]*ratelimit.Bucket),
+	}
+}
+
+func (s *RateLimitStore) Take(ctx context.Context, keys []ratelimit.Key, now time.Time) ([]ratelimit.Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	limits := make([]ratelimit.Limit, len(keys))
+	buckets := make([]*ratelimit.Bucket, len(keys))
+	for i, k := range keys {
+		b, ok := s.buckets[k.Name]
+		if !ok {
+			bucket := k.Limit.NewBucket(now)
+			b = &bucket
+			s.buckets[k.Name] = b
+		}
+		limits[i] = k.Limit
+		buckets[i] = b
+	}
+	return ratelimit.TakeAll(limits, buckets, now), nil
+}
+
+func (s *RateLimitStore) Prune(ctx context.Context, idleSince time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pruned := 0
+	for key, b := range s.buckets {
+		if b.UpdatedAt.Before(idleSince) {
+			delete(s.buckets, key)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
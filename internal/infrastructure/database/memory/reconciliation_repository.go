@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type ReconciliationRepository struct {
+	store         *Store
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewReconciliationRepository(store *Store, uuidGenerator ports.UUIDGenerator) ports.ReconciliationRepository {
+	return &ReconciliationRepository{
+		store:         store,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+func (r *ReconciliationRepository) CreateReport(ctx context.Context, rep *reconciliation.Report) error {
+	if rep.ID == "" {
+		rep.ID = r.uuidGenerator.Generate()
+	}
+
+	row := cloneReport(rep)
+	err := r.store.write(func(tx *tx) error {
+		if r.store.reports.exists(func(other *reconciliation.Report) bool { return other.FileName == row.FileName }) {
+			return uniqueViolation("reconciliation_reports_file_name_key")
+		}
+		return r.store.reports.insert(tx, row.ID, row, "reconciliation_reports_pkey")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create reconciliation report: %v", err)
+	}
+	return nil
+}
+
+func (r *ReconciliationRepository) UpdateReport(ctx context.Context, rep *reconciliation.Report) error {
+	return r.store.write(func(tx *tx) error {
+		r.updateReport(tx, rep)
+		return nil
+	})
+}
+
+// updateReport is called with the store's write lock held.
+func (r *ReconciliationRepository) updateReport(tx *tx, rep *reconciliation.Report) {
+	current, ok := r.store.reports.get(rep.ID)
+	if !ok {
+		return
+	}
+
+	row := cloneReport(current)
+	row.Status = rep.Status
+	row.PeriodStart = clonePtr(rep.PeriodStart)
+	row.PeriodEnd = clonePtr(rep.PeriodEnd)
+	row.TotalLines = rep.TotalLines
+	row.MatchedCount = rep.MatchedCount
+	row.DiscrepancyCount = rep.DiscrepancyCount
+	row.Error = rep.Error
+	row.CompletedAt = clonePtr(rep.CompletedAt)
+	r.store.reports.put(tx, row.ID, row)
+}
+
+func (r *ReconciliationRepository) GetReport(ctx context.Context, id string) (*reconciliation.Report, error) {
+	return r.getReport(func(rep *reconciliation.Report) bool { return rep.ID == id })
+}
+
+func (r *ReconciliationRepository) GetReportByFileName(ctx context.Context, fileName string) (*reconciliation.Report, error) {
+	return r.getReport(func(rep *reconciliation.Report) bool { return rep.FileName == fileName })
+}
+
+func (r *ReconciliationRepository) getReport(match func(*reconciliation.Report) bool) (*reconciliation.Report, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.reports.where(match)
+	if len(rows) == 0 {
+		return nil, reconciliation.ErrReportNotFound
+	}
+	return cloneReport(rows[0]), nil
+}
+
+func (r *ReconciliationRepository) ListReports(ctx context.Context, limit, offset int) ([]*reconciliation.Report, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.reports.where(all[*reconciliation.Report])
+	sortByTime(rows, func(rep *reconciliation.Report) time.Time { return rep.CreatedAt }, true)
+
+	var reports []*reconciliation.Report
+	for _, rep := range page(rows, limit, offset) {
+		reports = append(reports, cloneReport(rep))
+	}
+	return reports, nil
+}
+
+func (r *ReconciliationRepository) CompleteReport(ctx context.Context, rep *reconciliation.Report, discrepancies []*reconciliation.Discrepancy) error {
+	for _, d := range discrepancies {
+		if d.ID == "" {
+			d.ID = r.uuidGenerator.Generate()
+		}
+	}
+
+	return r.store.write(func(tx *tx) error {
+		for _, d := range r.store.discrepancies.where(func(d *reconciliation.Discrepancy) bool { return d.ReportID == rep.ID }) {
+			r.store.discrepancies.delete(tx, d.ID)
+		}
+
+		for _, d := range discrepancies {
+			row := cloneDiscrepancy(d)
+			row.Resolution = ""
+			row.ResolvedBy = ""
+			row.ResolvedAt = nil
+			if _, ok := r.store.reports.get(row.ReportID); !ok {
+				return fmt.Errorf("failed to create discrepancy: %v",
+					foreignKeyViolation("reconciliation_discrepancies", "reconciliation_discrepancies_report_id_fkey"))
+			}
+			if err := r.store.discrepancies.insert(tx, row.ID, row, "reconciliation_discrepancies_pkey"); err != nil {
+				return fmt.Errorf("failed to create discrepancy: %v", err)
+			}
+		}
+
+		r.updateReport(tx, rep)
+		return nil
+	})
+}
+
+func (r *ReconciliationRepository) GetDiscrepancy(ctx context.Context, id string) (*reconciliation.Discrepancy, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	d, ok := r.store.discrepancies.get(id)
+	if !ok {
+		return nil, fmt.Errorf("discrepancy not found")
+	}
+	return cloneDiscrepancy(d), nil
+}
+
+func (r *ReconciliationRepository) UpdateDiscrepancy(ctx context.Context, d *reconciliation.Discrepancy) error {
+	err := r.store.write(func(tx *tx) error {
+		current, ok := r.store.discrepancies.get(d.ID)
+		if !ok {
+			return nil
+		}
+		if err := references(r.store.users, d.ResolvedBy, "reconciliation_discrepancies", "reconciliation_discrepancies_resolved_by_fkey"); err != nil {
+			return err
+		}
+
+		row := cloneDiscrepancy(current)
+		row.Status = d.Status
+		row.Resolution = d.Resolution
+		row.ResolvedBy = d.ResolvedBy
+		row.ResolvedAt = clonePtr(d.ResolvedAt)
+		r.store.discrepancies.put(tx, row.ID, row)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update discrepancy: %v", err)
+	}
+	return nil
+}
+
+func (r *ReconciliationRepository) ListDiscrepancies(ctx context.Context, reportID string, status reconciliation.DiscrepancyStatus, limit, offset int) ([]*reconciliation.Discrepancy, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.discrepancies.where(func(d *reconciliation.Discrepancy) bool {
+		return d.ReportID == reportID && (status == "" || d.Status == status)
+	})
+	sortByTime(rows, func(d *reconciliation.Discrepancy) time.Time { return d.CreatedAt }, false)
+
+	var discrepancies []*reconciliation.Discrepancy
+	for _, d := range page(rows, limit, offset) {
+		discrepancies = append(discrepancies, cloneDiscrepancy(d))
+	}
+	return discrepancies, nil
+}
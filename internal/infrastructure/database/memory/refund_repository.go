@@ -0,0 +1,227 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type RefundRepository struct {
+	store         *Store
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewRefundRepository(store *Store, uuidGenerator ports.UUIDGenerator) ports.RefundRepository {
+	return &RefundRepository{
+		store:         store,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+// checkRefund enforces the foreign keys of a refund and the uniqueness of its
+// acquirer reference.
+func (s *Store) checkRefund(ref *refund.Refund) error {
+	if _, ok := s.payments.get(ref.PaymentID); !ok {
+		return foreignKeyViolation("refunds", "refunds_payment_id_fkey")
+	}
+	if err := references(s.customers, ref.CustomerID, "refunds", "refunds_customer_id_fkey"); err != nil {
+		return err
+	}
+
+	if ref.AcquirerReference != "" {
+		taken := s.refunds.exists(func(other *refund.Refund) bool {
+			return other.ID != ref.ID && other.AcquirerReference == ref.AcquirerReference
+		})
+		if taken {
+			return uniqueViolation("idx_refunds_acquirer_reference")
+		}
+	}
+	return nil
+}
+
+func (r *RefundRepository) Create(ctx context.Context, ref *refund.Refund) error {
+	if ref.ID == "" {
+		ref.ID = r.uuidGenerator.Generate()
+	}
+
+	row := cloneRefund(ref)
+	err := r.store.write(func(tx *tx) error {
+		if err := r.store.checkRefund(row); err != nil {
+			return err
+		}
+		return r.store.refunds.insert(tx, row.ID, row, "refunds_pkey")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create refund: %v", err)
+	}
+	return nil
+}
+
+func (r *RefundRepository) GetByID(ctx context.Context, id string) (*refund.Refund, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	ref, ok := r.store.refunds.get(id)
+	if !ok {
+		return nil, refund.ErrNotFound
+	}
+	return cloneRefund(ref), nil
+}
+
+func (r *RefundRepository) Update(ctx context.Context, ref *refund.Refund) error {
+	err := r.store.write(func(tx *tx) error {
+		current, ok := r.store.refunds.get(ref.ID)
+		if !ok {
+			return nil
+		}
+
+		row := cloneRefund(current)
+		row.PaymentID = ref.PaymentID
+		row.Amount = ref.Amount
+		row.Currency = ref.Currency
+		row.SettlementAmount = ref.SettlementAmount
+		row.SettlementCurrency = ref.SettlementCurrency
+		row.Reason = ref.Reason
+		row.Status = ref.Status
+		row.AcquirerReference = ref.AcquirerReference
+		row.ProcessedAt = clonePtr(ref.ProcessedAt)
+		row.UpdatedAt = ref.UpdatedAt
+		if err := r.store.checkRefund(row); err != nil {
+			return err
+		}
+
+		r.store.refunds.put(tx, row.ID, row)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update refund: %v", err)
+	}
+	return nil
+}
+
+func (r *RefundRepository) query(match func(*refund.Refund) bool, at func(*refund.Refund) time.Time, desc bool, limit, offset int) []*refund.Refund {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.refunds.where(match)
+	sortByTime(rows, at, desc)
+
+	var refunds []*refund.Refund
+	for _, ref := range page(rows, limit, offset) {
+		refunds = append(refunds, cloneRefund(ref))
+	}
+	return refunds
+}
+
+func refundCreatedAt(ref *refund.Refund) time.Time { return ref.CreatedAt }
+
+func (r *RefundRepository) List(ctx context.Context, paymentID string, limit, offset int) ([]*refund.Refund, error) {
+	return r.query(func(ref *refund.Refund) bool {
+		return ref.PaymentID == paymentID
+	}, refundCreatedAt, true, limit, offset), nil
+}
+
+func (r *RefundRepository) ListByMerchant(ctx context.Context, merchantID string, limit, offset int) ([]*refund.Refund, error) {
+	return r.query(func(ref *refund.Refund) bool {
+		p, ok := r.store.payments.get(ref.PaymentID)
+		return ok && p.MerchantID == merchantID
+	}, refundCreatedAt, false, limit, offset), nil
+}
+
+// setStatus changes the status of the refund, if it is in one of the given
+// statuses or any is empty, and reports whether it did.
+func (r *RefundRepository) setStatus(id string, from refund.RefundStatus, to refund.RefundStatus) bool {
+	changed := false
+	_ = r.store.write(func(tx *tx) error {
+		current, ok := r.store.refunds.get(id)
+		if !ok || (from != "" && current.Status != from) {
+			return nil
+		}
+
+		row := cloneRefund(current)
+		row.Status = to
+		row.UpdatedAt = time.Now()
+		r.store.refunds.put(tx, id, row)
+		changed = true
+		return nil
+	})
+	return changed
+}
+
+func (r *RefundRepository) UpdateStatus(ctx context.Context, id string, status refund.RefundStatus) error {
+	r.setStatus(id, "", status)
+	return nil
+}
+
+func (r *RefundRepository) TransitionStatus(ctx context.Context, id string, from, to refund.RefundStatus) error {
+	if !r.setStatus(id, from, to) {
+		return fmt.Errorf("refund is not in %s status", from)
+	}
+	return nil
+}
+
+func (r *RefundRepository) UpdateWithTransaction(ctx context.Context, ref *refund.Refund, p *payment.Payment) error {
+	return r.store.write(func(tx *tx) error {
+		current, ok := r.store.refunds.get(ref.ID)
+		if !ok || current.Status != refund.RefundStatusProcessing {
+			return fmt.Errorf("refund is not in processing status")
+		}
+
+		row := cloneRefund(current)
+		row.Status = ref.Status
+		row.AcquirerReference = ref.AcquirerReference
+		row.ProcessedAt = clonePtr(ref.ProcessedAt)
+		row.UpdatedAt = ref.UpdatedAt
+		if err := r.store.checkRefund(row); err != nil {
+			return fmt.Errorf("failed to update refund in transaction: %v", err)
+		}
+		r.store.refunds.put(tx, row.ID, row)
+
+		if current, ok := r.store.payments.get(p.ID); ok {
+			updated := clonePayment(current)
+			updated.Amount = p.Amount
+			updated.SettlementAmount = p.SettlementAmount
+			updated.UpdatedAt = p.UpdatedAt
+			r.store.payments.put(tx, updated.ID, updated)
+		}
+		return nil
+	})
+}
+
+func (r *RefundRepository) GetByAcquirerReference(ctx context.Context, reference string) (*refund.Refund, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.refunds.where(func(ref *refund.Refund) bool {
+		return ref.AcquirerReference == reference && ref.Mode == mode.Live
+	})
+	if reference == "" || len(rows) == 0 {
+		return nil, refund.ErrNotFound
+	}
+	return cloneRefund(rows[0]), nil
+}
+
+func (r *RefundRepository) ListProcessedBetween(ctx context.Context, from, to time.Time) ([]*refund.Refund, error) {
+	return r.query(func(ref *refund.Refund) bool {
+		return ref.ProcessedAt != nil && !ref.ProcessedAt.Before(from) && !ref.ProcessedAt.After(to) &&
+			ref.AcquirerReference != "" && ref.Mode == mode.Live
+	}, func(ref *refund.Refund) time.Time { return *ref.ProcessedAt }, false, noLimit, 0), nil
+}
+
+func (r *RefundRepository) TotalByPayment(ctx context.Context, paymentID string, status refund.RefundStatus) (float64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var total float64
+	for _, ref := range r.store.refunds.where(func(ref *refund.Refund) bool {
+		return ref.PaymentID == paymentID && ref.Status == status
+	}) {
+		total += ref.Amount
+	}
+	return total, nil
+}
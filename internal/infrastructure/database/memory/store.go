@@ -0,0 +1,277 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
+	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	"github.com/popeskul/payment-gateway/internal/core/domain/subscription"
+	"github.com/popeskul/payment-gateway/internal/core/domain/user"
+	"github.com/popeskul/payment-gateway/internal/domain"
+)
+
+// Store holds the tables of the in-memory backend. The repositories built on
+// one Store share its data and its lock, which makes every repository call a
+// transaction: writes that span tables, such as cascades or Rotate, happen
+// together or not at all, and foreign keys are checked against the same
+// snapshot.
+//
+// Rows are never changed in place. Writes store a copy and reads return one,
+// so callers cannot change stored data by holding on to a result.
+type Store struct {
+	mu sync.RWMutex
+
+	merchants          *table[string, *merchant.Merchant]
+	beneficialOwners   *table[string, *merchant.BeneficialOwner]
+	documents          *table[string, *merchant.Document]
+	members            *table[memberKey, *member.Member]
+	apiKeys            *table[string, *apikey.APIKey]
+	apiKeyNonces       *table[nonceKey, time.Time]
+	payments           *table[string, *payment.Payment]
+	paymentTransitions *table[string, *payment.StatusTransition]
+	refunds            *table[string, *refund.Refund]
+	reports            *table[string, *reconciliation.Report]
+	discrepancies      *table[string, *reconciliation.Discrepancy]
+	plans              *table[string, *subscription.Plan]
+	subscriptions      *table[string, *subscription.Subscription]
+	subscriptionEvents *table[string, *subscription.Event]
+	customers          *table[string, *customer.Customer]
+	paymentMethods     *table[string, *customer.PaymentMethod]
+	users              *table[string, *user.User]
+	userTokens         *table[string, *user.Token]
+	recoveryCodes      *table[recoveryCodeKey, *recoveryCode]
+	loginThrottles     *table[throttleKey, *user.LoginThrottle]
+	auditLog           *table[int64, *auditRow]
+	oauthClients       *table[string, *oauth.Client]
+	oauthGrants        *table[grantKey, *oauth.Grant]
+	sessions           *table[string, *domain.Session]
+	refreshTokens      *table[string, *domain.RefreshToken]
+}
+
+func NewStore() *Store {
+	return &Store{
+		merchants:          newTable[string, *merchant.Merchant](),
+		beneficialOwners:   newTable[string, *merchant.BeneficialOwner](),
+		documents:          newTable[string, *merchant.Document](),
+		members:            newTable[memberKey, *member.Member](),
+		apiKeys:            newTable[string, *apikey.APIKey](),
+		apiKeyNonces:       newTable[nonceKey, time.Time](),
+		payments:           newTable[string, *payment.Payment](),
+		paymentTransitions: newTable[string, *payment.StatusTransition](),
+		refunds:            newTable[string, *refund.Refund](),
+		reports:            newTable[string, *reconciliation.Report](),
+		discrepancies:      newTable[string, *reconciliation.Discrepancy](),
+		plans:              newTable[string, *subscription.Plan](),
+		subscriptions:      newTable[string, *subscription.Subscription](),
+		subscriptionEvents: newTable[string, *subscription.Event](),
+		customers:          newTable[string, *customer.Customer](),
+		paymentMethods:     newTable[string, *customer.PaymentMethod](),
+		users:              newTable[string, *user.User](),
+		userTokens:         newTable[string, *user.Token](),
+		recoveryCodes:      newTable[recoveryCodeKey, *recoveryCode](),
+		loginThrottles:     newTable[throttleKey, *user.LoginThrottle](),
+		auditLog:           newTable[int64, *auditRow](),
+		oauthClients:       newTable[string, *oauth.Client](),
+		oauthGrants:        newTable[grantKey, *oauth.Grant](),
+		sessions:           newTable[string, *domain.Session](),
+		refreshTokens:      newTable[string, *domain.RefreshToken](),
+	}
+}
+
+// write runs fn under the write lock. If fn fails, the writes it made so far
+// are undone, as a rolled back transaction would be.
+func (s *Store) write(fn func(tx *tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &tx{}
+	if err := fn(tx); err != nil {
+		tx.rollback()
+		return err
+	}
+	return nil
+}
+
+// tx records how to undo the writes of one Store.write call.
+type tx struct {
+	undo []func()
+}
+
+func (tx *tx) rollback() {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+}
+
+type tableRow[V any] struct {
+	seq uint64
+	v   V
+}
+
+// table is a map that remembers the order rows were inserted in, which
+// breaks ties when results are sorted like the ORDER BY of the Postgres
+// queries.
+type table[K comparable, V any] struct {
+	rows map[K]tableRow[V]
+	next uint64
+}
+
+func newTable[K comparable, V any]() *table[K, V] {
+	return &table[K, V]{rows: make(map[K]tableRow[V])}
+}
+
+func (t *table[K, V]) get(key K) (V, bool) {
+	row, ok := t.rows[key]
+	return row.v, ok
+}
+
+// insert adds a row and fails with a unique violation of the primary key
+// constraint if the key is taken.
+func (t *table[K, V]) insert(tx *tx, key K, v V, constraint string) error {
+	if _, ok := t.rows[key]; ok {
+		return uniqueViolation(constraint)
+	}
+	t.put(tx, key, v)
+	return nil
+}
+
+// put inserts or replaces a row. A replaced row keeps its place in the
+// insertion order.
+func (t *table[K, V]) put(tx *tx, key K, v V) {
+	old, existed := t.rows[key]
+	row := tableRow[V]{seq: old.seq, v: v}
+	if !existed {
+		t.next++
+		row.seq = t.next
+	}
+	t.rows[key] = row
+
+	tx.undo = append(tx.undo, func() {
+		if existed {
+			t.rows[key] = old
+		} else {
+			delete(t.rows, key)
+		}
+	})
+}
+
+func (t *table[K, V]) delete(tx *tx, key K) bool {
+	old, ok := t.rows[key]
+	if !ok {
+		return false
+	}
+	delete(t.rows, key)
+
+	tx.undo = append(tx.undo, func() { t.rows[key] = old })
+	return true
+}
+
+// keysWhere returns the keys of the matching rows in insertion order.
+func (t *table[K, V]) keysWhere(match func(V) bool) []K {
+	type keyed struct {
+		key K
+		seq uint64
+	}
+	var found []keyed
+	for k, row := range t.rows {
+		if match(row.v) {
+			found = append(found, keyed{key: k, seq: row.seq})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].seq < found[j].seq })
+
+	keys := make([]K, len(found))
+	for i, f := range found {
+		keys[i] = f.key
+	}
+	return keys
+}
+
+// where returns the matching rows in insertion order.
+func (t *table[K, V]) where(match func(V) bool) []V {
+	keys := t.keysWhere(match)
+	rows := make([]V, len(keys))
+	for i, k := range keys {
+		rows[i] = t.rows[k].v
+	}
+	return rows
+}
+
+func (t *table[K, V]) exists(match func(V) bool) bool {
+	for _, row := range t.rows {
+		if match(row.v) {
+			return true
+		}
+	}
+	return false
+}
+
+func all[V any](V) bool { return true }
+
+// sortByTime orders rows by the time, oldest first unless desc is set. Rows
+// with the same time stay in insertion order, or the reverse of it when desc
+// is set.
+func sortByTime[V any](rows []V, at func(V) time.Time, desc bool) {
+	if desc {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if desc {
+			return at(rows[i]).After(at(rows[j]))
+		}
+		return at(rows[i]).Before(at(rows[j]))
+	})
+}
+
+// noLimit stands in for a query without a LIMIT clause.
+const noLimit = -1
+
+// page applies LIMIT and OFFSET.
+func page[V any](rows []V, limit, offset int) []V {
+	if offset >= len(rows) {
+		return nil
+	}
+	rows = rows[offset:]
+	if limit != noLimit && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// The constraint errors read like the ones Postgres reports, and the
+// repositories wrap them the same way.
+
+func uniqueViolation(constraint string) error {
+	return fmt.Errorf("duplicate key value violates unique constraint %q", constraint)
+}
+
+func foreignKeyViolation(tableName, constraint string) error {
+	return fmt.Errorf("insert or update on table %q violates foreign key constraint %q", tableName, constraint)
+}
+
+func referencedViolation(tableName, constraint, referencing string) error {
+	return fmt.Errorf("update or delete on table %q violates foreign key constraint %q on table %q", tableName, constraint, referencing)
+}
+
+// references checks an optional foreign key: an empty id is NULL and always
+// passes.
+func references[V any](t *table[string, V], id, tableName, constraint string) error {
+	if id == "" {
+		return nil
+	}
+	if _, ok := t.get(id); !ok {
+		return foreignKeyViolation(tableName, constraint)
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/subscription"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type SubscriptionRepository struct {
+	store         *Store
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewSubscriptionRepository(store *Store, uuidGenerator ports.UUIDGenerator) ports.SubscriptionRepository {
+	return &SubscriptionRepository{
+		store:         store,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+func (r *SubscriptionRepository) CreatePlan(ctx context.Context, p *subscription.Plan) error {
+	if p.ID == "" {
+		p.ID = r.uuidGenerator.Generate()
+	}
+
+	row := clonePtr(p)
+	err := r.store.write(func(tx *tx) error {
+		if _, ok := r.store.merchants.get(row.MerchantID); !ok {
+			return foreignKeyViolation("plans", "plans_merchant_id_fkey")
+		}
+		return r.store.plans.insert(tx, row.ID, row, "plans_pkey")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create plan: %v", err)
+	}
+	return nil
+}
+
+func (r *SubscriptionRepository) GetPlan(ctx context.Context, id string) (*subscription.Plan, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	p, ok := r.store.plans.get(id)
+	if !ok {
+		return nil, fmt.Errorf("plan not found")
+	}
+	return clonePtr(p), nil
+}
+
+func (r *SubscriptionRepository) UpdatePlan(ctx context.Context, p *subscription.Plan) error {
+	return r.store.write(func(tx *tx) error {
+		current, ok := r.store.plans.get(p.ID)
+		if !ok {
+			return nil
+		}
+
+		row := clonePtr(current)
+		row.Name = p.Name
+		row.Active = p.Active
+		row.UpdatedAt = p.UpdatedAt
+		r.store.plans.put(tx, row.ID, row)
+		return nil
+	})
+}
+
+func (r *SubscriptionRepository) ListPlans(ctx context.Context, merchantID string, m mode.Mode, limit, offset int) ([]*subscription.Plan, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.plans.where(func(p *subscription.Plan) bool {
+		return p.MerchantID == merchantID && (m == "" || p.Mode == m)
+	})
+	sortByTime(rows, func(p *subscription.Plan) time.Time { return p.CreatedAt }, true)
+
+	var plans []*subscription.Plan
+	for _, p := range page(rows, limit, offset) {
+		plans = append(plans, clonePtr(p))
+	}
+	return plans, nil
+}
+
+func (s *Store) checkSubscription(sub *subscription.Subscription) error {
+	if _, ok := s.merchants.get(sub.MerchantID); !ok {
+		return foreignKeyViolation("subscriptions", "subscriptions_merchant_id_fkey")
+	}
+	if _, ok := s.plans.get(sub.PlanID); !ok {
+		return foreignKeyViolation("subscriptions", "subscriptions_plan_id_fkey")
+	}
+	return nil
+}
+
+func (r *SubscriptionRepository) Create(ctx context.Context, s *subscription.Subscription) error {
+	if s.ID == "" {
+		s.ID = r.uuidGenerator.Generate()
+	}
+
+	row := cloneSubscription(s)
+	err := r.store.write(func(tx *tx) error {
+		if err := r.store.checkSubscription(row); err != nil {
+			return err
+		}
+		return r.store.subscriptions.insert(tx, row.ID, row, "subscriptions_pkey")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create subscription: %v", err)
+	}
+	return nil
+}
+
+func (r *SubscriptionRepository) GetByID(ctx context.Context, id string) (*subscription.Subscription, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	s, ok := r.store.subscriptions.get(id)
+	if !ok {
+		return nil, fmt.Errorf("subscription not found")
+	}
+	return cloneSubscription(s), nil
+}
+
+func (r *SubscriptionRepository) Update(ctx context.Context, s *subscription.Subscription) error {
+	err := r.store.write(func(tx *tx) error {
+		current, ok := r.store.subscriptions.get(s.ID)
+		if !ok {
+			return nil
+		}
+
+		row := cloneSubscription(current)
+		row.PlanID = s.PlanID
+		row.PaymentMethod = s.PaymentMethod
+		row.PaymentToken = s.PaymentToken
+		row.Status = s.Status
+		row.CurrentPeriodStart = s.CurrentPeriodStart
+		row.CurrentPeriodEnd = s.CurrentPeriodEnd
+		row.TrialEnd = clonePtr(s.TrialEnd)
+		row.CancelAtPeriodEnd = s.CancelAtPeriodEnd
+		row.CanceledAt = clonePtr(s.CanceledAt)
+		row.ProrationBalance = s.ProrationBalance
+		row.FailedAttempts = s.FailedAttempts
+		row.NextRetryAt = clonePtr(s.NextRetryAt)
+		row.UpdatedAt = s.UpdatedAt
+		if err := r.store.checkSubscription(row); err != nil {
+			return err
+		}
+
+		r.store.subscriptions.put(tx, row.ID, row)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update subscription: %v", err)
+	}
+	return nil
+}
+
+func (r *SubscriptionRepository) query(match func(*subscription.Subscription) bool, at func(*subscription.Subscription) time.Time, desc bool, limit, offset int) []*subscription.Subscription {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.subscriptions.where(match)
+	sortByTime(rows, at, desc)
+
+	var subscriptions []*subscription.Subscription
+	for _, s := range page(rows, limit, offset) {
+		subscriptions = append(subscriptions, cloneSubscription(s))
+	}
+	return subscriptions
+}
+
+func (r *SubscriptionRepository) List(ctx context.Context, merchantID string, m mode.Mode, limit, offset int) ([]*subscription.Subscription, error) {
+	return r.query(func(s *subscription.Subscription) bool {
+		return s.MerchantID == merchantID && (m == "" || s.Mode == m)
+	}, func(s *subscription.Subscription) time.Time { return s.CreatedAt }, true, limit, offset), nil
+}
+
+func (r *SubscriptionRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*subscription.Subscription, error) {
+	return r.query(func(s *subscription.Subscription) bool {
+		switch s.Status {
+		case subscription.StatusActive, subscription.StatusTrialing:
+			return !s.CurrentPeriodEnd.After(now)
+		case subscription.StatusPastDue:
+			return s.NextRetryAt != nil && !s.NextRetryAt.After(now)
+		}
+		return false
+	}, func(s *subscription.Subscription) time.Time { return s.CurrentPeriodEnd }, false, limit, 0), nil
+}
+
+func (r *SubscriptionRepository) CancelByMerchant(ctx context.Context, merchantID string, at time.Time, message string) error {
+	return r.store.write(func(tx *tx) error {
+		for _, s := range r.store.subscriptions.where(func(s *subscription.Subscription) bool {
+			return s.MerchantID == merchantID && s.Status != subscription.StatusCanceled
+		}) {
+			row := cloneSubscription(s)
+			row.Status = subscription.StatusCanceled
+			row.CanceledAt = timePtr(at)
+			row.NextRetryAt = nil
+			row.UpdatedAt = at
+			r.store.subscriptions.put(tx, row.ID, row)
+
+			event := &subscription.Event{
+				ID:             r.uuidGenerator.Generate(),
+				SubscriptionID: row.ID,
+				MerchantID:     merchantID,
+				Type:           subscription.EventCanceled,
+				Message:        message,
+				CreatedAt:      at,
+			}
+			if err := r.store.subscriptionEvents.insert(tx, event.ID, event, "subscription_events_pkey"); err != nil {
+				return fmt.Errorf("failed to create subscription event: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SubscriptionRepository) CreateEvent(ctx context.Context, e *subscription.Event) error {
+	if e.ID == "" {
+		e.ID = r.uuidGenerator.Generate()
+	}
+
+	row := clonePtr(e)
+	err := r.store.write(func(tx *tx) error {
+		if _, ok := r.store.subscriptions.get(row.SubscriptionID); !ok {
+			return foreignKeyViolation("subscription_events", "subscription_events_subscription_id_fkey")
+		}
+		if err := references(r.store.payments, row.PaymentID, "subscription_events", "subscription_events_payment_id_fkey"); err != nil {
+			return err
+		}
+		return r.store.subscriptionEvents.insert(tx, row.ID, row, "subscription_events_pkey")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create subscription event: %v", err)
+	}
+	return nil
+}
+
+func (r *SubscriptionRepository) ListEvents(ctx context.Context, subscriptionID string, limit, offset int) ([]*subscription.Event, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.subscriptionEvents.where(func(e *subscription.Event) bool {
+		return e.SubscriptionID == subscriptionID
+	})
+	sortByTime(rows, func(e *subscription.Event) time.Time { return e.CreatedAt }, true)
+
+	var events []*subscription.Event
+	for _, e := range page(rows, limit, offset) {
+		events = append(events, clonePtr(e))
+	}
+	return events, nil
+}
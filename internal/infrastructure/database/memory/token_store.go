@@ -0,0 +1,201 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/domain"
+)
+
+type TokenStore struct {
+	store         *Store
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewTokenStore(store *Store, uuidGenerator ports.UUIDGenerator) ports.TokenStore {
+	return &TokenStore{
+		store:         store,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+// StoreRefreshToken generates the ID, as the column default does in
+// Postgres, and leaves the caller's token unchanged.
+func (s *TokenStore) StoreRefreshToken(token *domain.RefreshToken) error {
+	row := cloneRefreshToken(token)
+	row.ID = s.uuidGenerator.Generate()
+	row.RotatedAt = nil
+	row.RevokedAt = nil
+
+	err := s.store.write(func(tx *tx) error {
+		if _, ok := s.store.users.get(row.UserID); !ok {
+			return foreignKeyViolation("refresh_tokens", "refresh_tokens_user_id_fkey")
+		}
+		if _, ok := s.store.sessions.get(row.FamilyID); !ok {
+			return foreignKeyViolation("refresh_tokens", "fk_refresh_tokens_session")
+		}
+		if s.store.refreshTokens.exists(func(other *domain.RefreshToken) bool { return other.TokenHash == row.TokenHash }) {
+			return uniqueViolation("refresh_tokens_token_hash_key")
+		}
+		return s.store.refreshTokens.insert(tx, row.ID, row, "refresh_tokens_pkey")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store refresh token: %v", err)
+	}
+	return nil
+}
+
+func (s *TokenStore) getRefreshToken(tokenHash string) (*domain.RefreshToken, bool) {
+	rows := s.store.refreshTokens.where(func(t *domain.RefreshToken) bool { return t.TokenHash == tokenHash })
+	if len(rows) == 0 {
+		return nil, false
+	}
+	return rows[0], true
+}
+
+func (s *TokenStore) GetRefreshToken(tokenHash string) (*domain.RefreshToken, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	t, ok := s.getRefreshToken(tokenHash)
+	if !ok {
+		return nil, errors.New("refresh token not found")
+	}
+	return cloneRefreshToken(t), nil
+}
+
+func (s *TokenStore) RotateRefreshToken(tokenHash string) error {
+	return s.store.write(func(tx *tx) error {
+		current, ok := s.getRefreshToken(tokenHash)
+		if !ok || current.RotatedAt != nil || current.RevokedAt != nil {
+			return domain.ErrRefreshTokenReused
+		}
+
+		row := cloneRefreshToken(current)
+		row.RotatedAt = timePtr(time.Now())
+		s.store.refreshTokens.put(tx, row.ID, row)
+		return nil
+	})
+}
+
+// revokeFamilies revokes the refresh tokens of the families that are not
+// revoked yet. It must run inside a Store.write.
+func (s *TokenStore) revokeFamilies(tx *tx, familyIDs map[string]bool, at time.Time) {
+	for _, t := range s.store.refreshTokens.where(func(t *domain.RefreshToken) bool {
+		return familyIDs[t.FamilyID] && t.RevokedAt == nil
+	}) {
+		row := cloneRefreshToken(t)
+		row.RevokedAt = timePtr(at)
+		s.store.refreshTokens.put(tx, row.ID, row)
+	}
+}
+
+// revokeSession must run inside a Store.write.
+func (s *TokenStore) revokeSession(tx *tx, session *domain.Session, at time.Time) {
+	row := cloneSession(session)
+	row.RevokedAt = timePtr(at)
+	s.store.sessions.put(tx, row.ID, row)
+}
+
+func (s *TokenStore) RevokeTokenFamily(familyID string) error {
+	return s.store.write(func(tx *tx) error {
+		now := time.Now()
+		s.revokeFamilies(tx, map[string]bool{familyID: true}, now)
+		if session, ok := s.store.sessions.get(familyID); ok && session.RevokedAt == nil {
+			s.revokeSession(tx, session, now)
+		}
+		return nil
+	})
+}
+
+func (s *TokenStore) CreateSession(session *domain.Session) error {
+	row := cloneSession(session)
+	err := s.store.write(func(tx *tx) error {
+		if _, ok := s.store.users.get(row.UserID); !ok {
+			return foreignKeyViolation("sessions", "sessions_user_id_fkey")
+		}
+		return s.store.sessions.insert(tx, row.ID, row, "sessions_pkey")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create session: %v", err)
+	}
+	return nil
+}
+
+func (s *TokenStore) GetSession(sessionID string) (*domain.Session, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	session, ok := s.store.sessions.get(sessionID)
+	if !ok {
+		return nil, domain.ErrSessionNotFound
+	}
+	return cloneSession(session), nil
+}
+
+func (s *TokenStore) TouchSession(sessionID, ip string, lastUsedAt, expiresAt time.Time) error {
+	return s.store.write(func(tx *tx) error {
+		current, ok := s.store.sessions.get(sessionID)
+		if !ok {
+			return nil
+		}
+
+		row := cloneSession(current)
+		row.IP = ip
+		row.LastUsedAt = lastUsedAt
+		row.ExpiresAt = expiresAt
+		s.store.sessions.put(tx, row.ID, row)
+		return nil
+	})
+}
+
+func (s *TokenStore) ListSessions(userID string) ([]*domain.Session, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	now := time.Now()
+	rows := s.store.sessions.where(func(session *domain.Session) bool {
+		return session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now)
+	})
+	sortByTime(rows, func(session *domain.Session) time.Time { return session.LastUsedAt }, true)
+
+	sessions := []*domain.Session{}
+	for _, session := range rows {
+		sessions = append(sessions, cloneSession(session))
+	}
+	return sessions, nil
+}
+
+func (s *TokenStore) RevokeOtherSessions(userID, keepSessionID string) ([]string, error) {
+	revoked := []string{}
+	_ = s.store.write(func(tx *tx) error {
+		now := time.Now()
+		families := make(map[string]bool)
+		for _, session := range s.store.sessions.where(func(session *domain.Session) bool {
+			return session.UserID == userID && session.ID != keepSessionID && session.RevokedAt == nil
+		}) {
+			s.revokeSession(tx, session, now)
+			families[session.ID] = true
+			revoked = append(revoked, session.ID)
+		}
+
+		s.revokeFamilies(tx, families, now)
+		return nil
+	})
+	return revoked, nil
+}
+
+func (s *TokenStore) ListRevokedSessions(since time.Time) ([]string, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	var revoked []string
+	for _, session := range s.store.sessions.where(func(session *domain.Session) bool {
+		return session.RevokedAt != nil && !session.RevokedAt.Before(since)
+	}) {
+		revoked = append(revoked, session.ID)
+	}
+	return revoked, nil
+}
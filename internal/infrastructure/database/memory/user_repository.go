@@ -0,0 +1,302 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/user"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type recoveryCodeKey struct {
+	userID   string
+	codeHash string
+}
+
+type recoveryCode struct {
+	usedAt    *time.Time
+	createdAt time.Time
+}
+
+type throttleKey struct {
+	scope user.ThrottleScope
+	key   string
+}
+
+type UserRepository struct {
+	store         *Store
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewUserRepository(store *Store, uuidGenerator ports.UUIDGenerator) ports.UserRepository {
+	return &UserRepository{
+		store:         store,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+func (s *Store) checkUserEmail(u *user.User) error {
+	taken := s.users.exists(func(other *user.User) bool {
+		return other.ID != u.ID && other.Email == u.Email
+	})
+	if taken {
+		return uniqueViolation("users_email_key")
+	}
+	return nil
+}
+
+func (r *UserRepository) Create(ctx context.Context, u *user.User) error {
+	if u.ID == "" {
+		u.ID = r.uuidGenerator.Generate()
+	}
+
+	row := &user.User{
+		ID:           u.ID,
+		Email:        u.Email,
+		PasswordHash: u.PasswordHash,
+		FirstName:    u.FirstName,
+		LastName:     u.LastName,
+		CreatedAt:    u.CreatedAt,
+		UpdatedAt:    u.UpdatedAt,
+	}
+
+	return r.store.write(func(tx *tx) error {
+		if err := r.store.checkUserEmail(row); err != nil {
+			return err
+		}
+		return r.store.users.insert(tx, row.ID, row, "users_pkey")
+	})
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*user.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	u, ok := r.store.users.get(id)
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return cloneUser(u), nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*user.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.users.where(func(u *user.User) bool { return u.Email == email })
+	if len(rows) == 0 {
+		return nil, errors.New("user not found")
+	}
+	return cloneUser(rows[0]), nil
+}
+
+// update replaces the row of id with a copy changed by fn and reports whether
+// there was a row to change. fn may refuse the change by returning false.
+func (r *UserRepository) update(id string, fn func(row *user.User) (bool, error)) (bool, error) {
+	changed := false
+	err := r.store.write(func(tx *tx) error {
+		current, ok := r.store.users.get(id)
+		if !ok {
+			return nil
+		}
+
+		row := cloneUser(current)
+		ok, err := fn(row)
+		if err != nil || !ok {
+			return err
+		}
+		r.store.users.put(tx, id, row)
+		changed = true
+		return nil
+	})
+	return changed, err
+}
+
+func (r *UserRepository) Update(ctx context.Context, u *user.User) error {
+	_, err := r.update(u.ID, func(row *user.User) (bool, error) {
+		row.Email = u.Email
+		row.PasswordHash = u.PasswordHash
+		row.FirstName = u.FirstName
+		row.LastName = u.LastName
+		row.EmailVerifiedAt = clonePtr(u.EmailVerifiedAt)
+		row.TOTPSecret = u.TOTPSecret
+		row.TwoFactorEnabledAt = clonePtr(u.TwoFactorEnabledAt)
+		row.UpdatedAt = u.UpdatedAt
+		return true, r.store.checkUserEmail(row)
+	})
+	return err
+}
+
+func (r *UserRepository) CreateToken(ctx context.Context, t *user.Token) error {
+	if t.ID == "" {
+		t.ID = r.uuidGenerator.Generate()
+	}
+
+	row := cloneUserToken(t)
+	row.UsedAt = nil
+	err := r.store.write(func(tx *tx) error {
+		if _, ok := r.store.users.get(row.UserID); !ok {
+			return foreignKeyViolation("user_tokens", "user_tokens_user_id_fkey")
+		}
+		if r.store.userTokens.exists(func(other *user.Token) bool { return other.Hash == row.Hash }) {
+			return uniqueViolation("user_tokens_token_hash_key")
+		}
+		return r.store.userTokens.insert(tx, row.ID, row, "user_tokens_pkey")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create user token: %v", err)
+	}
+	return nil
+}
+
+func (r *UserRepository) GetTokenByHash(ctx context.Context, hash string) (*user.Token, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := r.store.userTokens.where(func(t *user.Token) bool { return t.Hash == hash })
+	if len(rows) == 0 {
+		return nil, errors.New("user token not found")
+	}
+	return cloneUserToken(rows[0]), nil
+}
+
+// useTokens marks the unused tokens that match as used at the given time and
+// returns how many there were.
+func (r *UserRepository) useTokens(match func(*user.Token) bool, at time.Time) int {
+	used := 0
+	_ = r.store.write(func(tx *tx) error {
+		for _, t := range r.store.userTokens.where(func(t *user.Token) bool {
+			return t.UsedAt == nil && match(t)
+		}) {
+			row := cloneUserToken(t)
+			row.UsedAt = timePtr(at)
+			r.store.userTokens.put(tx, row.ID, row)
+			used++
+		}
+		return nil
+	})
+	return used
+}
+
+func (r *UserRepository) UseToken(ctx context.Context, id string, at time.Time) error {
+	if r.useTokens(func(t *user.Token) bool { return t.ID == id }, at) == 0 {
+		return errors.New("user token already used")
+	}
+	return nil
+}
+
+func (r *UserRepository) InvalidateTokens(ctx context.Context, userID string, purpose user.TokenPurpose, at time.Time) error {
+	r.useTokens(func(t *user.Token) bool {
+		return t.UserID == userID && t.Purpose == purpose
+	}, at)
+	return nil
+}
+
+func (r *UserRepository) AdvanceTOTPCounter(ctx context.Context, userID string, counter int64) error {
+	advanced, _ := r.update(userID, func(row *user.User) (bool, error) {
+		if row.TOTPLastCounter >= counter {
+			return false, nil
+		}
+		row.TOTPLastCounter = counter
+		return true, nil
+	})
+	if !advanced {
+		return errors.New("totp code already used")
+	}
+	return nil
+}
+
+func (r *UserRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string, at time.Time) error {
+	return r.store.write(func(tx *tx) error {
+		for _, k := range r.store.recoveryCodes.keysWhere(all[*recoveryCode]) {
+			if k.userID == userID {
+				r.store.recoveryCodes.delete(tx, k)
+			}
+		}
+
+		if len(hashes) > 0 {
+			if _, ok := r.store.users.get(userID); !ok {
+				return fmt.Errorf("failed to create recovery code: %v",
+					foreignKeyViolation("user_recovery_codes", "user_recovery_codes_user_id_fkey"))
+			}
+		}
+		for _, hash := range hashes {
+			k := recoveryCodeKey{userID: userID, codeHash: hash}
+			if err := r.store.recoveryCodes.insert(tx, k, &recoveryCode{createdAt: at}, "user_recovery_codes_user_id_code_hash_key"); err != nil {
+				return fmt.Errorf("failed to create recovery code: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *UserRepository) UseRecoveryCode(ctx context.Context, userID, hash string, at time.Time) error {
+	used := false
+	_ = r.store.write(func(tx *tx) error {
+		k := recoveryCodeKey{userID: userID, codeHash: hash}
+		code, ok := r.store.recoveryCodes.get(k)
+		if !ok || code.usedAt != nil {
+			return nil
+		}
+		r.store.recoveryCodes.put(tx, k, &recoveryCode{usedAt: timePtr(at), createdAt: code.createdAt})
+		used = true
+		return nil
+	})
+	if !used {
+		return errors.New("recovery code not found or already used")
+	}
+	return nil
+}
+
+func (r *UserRepository) GetLoginThrottle(ctx context.Context, scope user.ThrottleScope, key string) (*user.LoginThrottle, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	t, ok := r.store.loginThrottles.get(throttleKey{scope: scope, key: key})
+	if !ok {
+		return nil, nil
+	}
+	return cloneLoginThrottle(t), nil
+}
+
+func (r *UserRepository) RecordLoginFailure(ctx context.Context, scope user.ThrottleScope, key string, at time.Time, window time.Duration) (*user.LoginThrottle, error) {
+	var result *user.LoginThrottle
+	_ = r.store.write(func(tx *tx) error {
+		k := throttleKey{scope: scope, key: key}
+		row := &user.LoginThrottle{Scope: scope, Key: key, Failures: 1}
+		if current, ok := r.store.loginThrottles.get(k); ok && !current.LastFailedAt.Before(at.Add(-window)) {
+			row = cloneLoginThrottle(current)
+			row.Failures++
+		}
+		row.LastFailedAt = at
+
+		r.store.loginThrottles.put(tx, k, row)
+		result = cloneLoginThrottle(row)
+		return nil
+	})
+	return result, nil
+}
+
+func (r *UserRepository) LockLogin(ctx context.Context, scope user.ThrottleScope, key string, until time.Time) error {
+	return r.store.write(func(tx *tx) error {
+		k := throttleKey{scope: scope, key: key}
+		current, ok := r.store.loginThrottles.get(k)
+		if !ok {
+			return nil
+		}
+
+		row := cloneLoginThrottle(current)
+		row.LockedUntil = timePtr(until)
+		r.store.loginThrottles.put(tx, k, row)
+		return nil
+	})
+}
+
+func (r *UserRepository) ClearLoginThrottle(ctx context.Context, scope user.ThrottleScope, key string) error {
+	return r.store.write(func(tx *tx) error {
+		r.store.loginThrottles.delete(tx, throttleKey{scope: scope, key: key})
+		return nil
+	})
+}
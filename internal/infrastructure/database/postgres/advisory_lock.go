@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type AdvisoryLocker struct {
+	db     *Database
+	logger ports.Logger
+}
+
+// NewAdvisoryLocker returns a Locker backed by session-level Postgres advisory
+// locks, so that only one replica holds a given key at a time.
+func NewAdvisoryLocker(db *Database, logger ports.Logger) ports.Locker {
+	return &AdvisoryLocker{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (l *AdvisoryLocker) TryLock(ctx context.Context, key string) (func(), bool, error) {
+	// Advisory locks belong to the session, so the same connection has to be
+	// held until unlock.
+	conn, err := l.db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection: %v", err)
+	}
+
+	var acquired bool
+	err = conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, key).Scan(&acquired)
+	if err != nil {
+		conn.Release()
+		return nil, false, fmt.Errorf("failed to acquire advisory lock: %v", err)
+	}
+	if !acquired {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		defer conn.Release()
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, key); err != nil {
+			l.logger.Error("Failed to release advisory lock", "error", err, "key", key)
+		}
+	}
+
+	return unlock, true, nil
+}
@@ -0,0 +1,209 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+const apiKeyColumns = `id, merchant_id, mode, name, prefix, key_hash, scopes, require_signature, expires_at, last_used_at, revoked_at,
+		       COALESCE(rotated_to::text, ''), COALESCE(created_by::text, ''), created_at`
+
+type APIKeyRepository struct {
+	db            *Database
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewAPIKeyRepository(db *Database, uuidGenerator ports.UUIDGenerator) ports.APIKeyRepository {
+	return &APIKeyRepository{
+		db:            db,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+func scanAPIKey(row rowScanner) (*apikey.APIKey, error) {
+	var k apikey.APIKey
+	var scopes []string
+	err := row.Scan(&k.ID, &k.MerchantID, &k.Mode, &k.Name, &k.Prefix, &k.Hash, &scopes, &k.RequireSignature, &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt,
+		&k.RotatedTo, &k.CreatedBy, &k.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range scopes {
+		k.Scopes = append(k.Scopes, apikey.Scope(s))
+	}
+	return &k, nil
+}
+
+func scopeStrings(k *apikey.APIKey) []string {
+	scopes := make([]string, len(k.Scopes))
+	for i, s := range k.Scopes {
+		scopes[i] = string(s)
+	}
+	return scopes
+}
+
+func (r *APIKeyRepository) insert(ctx context.Context, q execer, k *apikey.APIKey) error {
+	if k.ID == "" {
+		k.ID = r.uuidGenerator.Generate()
+	}
+	return insertAPIKey(ctx, q, k)
+}
+
+// insertAPIKey stores k, which must already have an id.
+func insertAPIKey(ctx context.Context, q execer, k *apikey.APIKey) error {
+	query := `
+		INSERT INTO api_keys (id, merchant_id, mode, name, prefix, key_hash, scopes, require_signature, expires_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := q.Exec(ctx, query, k.ID, k.MerchantID, string(k.Mode), k.Name, k.Prefix, k.Hash, scopeStrings(k), k.RequireSignature,
+		k.ExpiresAt, nullString(k.CreatedBy), k.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %v", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) update(ctx context.Context, q execer, k *apikey.APIKey) error {
+	query := `
+		UPDATE api_keys
+		SET name = $2, expires_at = $3, revoked_at = $4, rotated_to = $5
+		WHERE id = $1
+	`
+	tag, err := q.Exec(ctx, query, k.ID, k.Name, k.ExpiresAt, k.RevokedAt, nullString(k.RotatedTo))
+	if err != nil {
+		return fmt.Errorf("failed to update api key: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("api key not found")
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, k *apikey.APIKey) error {
+	return r.insert(ctx, r.db.Pool, k)
+}
+
+func (r *APIKeyRepository) get(ctx context.Context, where string, arg string) (*apikey.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE ` + where + ` = $1
+	`
+	k, err := scanAPIKey(r.db.Pool.QueryRow(ctx, query, arg))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("api key not found")
+		}
+		return nil, fmt.Errorf("failed to get api key: %v", err)
+	}
+	return k, nil
+}
+
+func (r *APIKeyRepository) GetByID(ctx context.Context, id string) (*apikey.APIKey, error) {
+	return r.get(ctx, "id", id)
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*apikey.APIKey, error) {
+	return r.get(ctx, "key_hash", hash)
+}
+
+func (r *APIKeyRepository) ListByMerchant(ctx context.Context, merchantID string) ([]*apikey.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE merchant_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Pool.Query(ctx, query, merchantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []*apikey.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %v", err)
+		}
+		keys = append(keys, k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating api keys: %v", err)
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) Update(ctx context.Context, k *apikey.APIKey) error {
+	return r.update(ctx, r.db.Pool, k)
+}
+
+func (r *APIKeyRepository) Rotate(ctx context.Context, old, replacement *apikey.APIKey) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.insert(ctx, tx, replacement); err != nil {
+		return err
+	}
+
+	old.RotatedTo = replacement.ID
+	if err := r.update(ctx, tx, old); err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	_, err := r.db.Pool.Exec(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, at)
+	if err != nil {
+		return fmt.Errorf("failed to update api key last use: %v", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) RevokeByMerchant(ctx context.Context, merchantID string, at time.Time) error {
+	_, err := r.db.Pool.Exec(ctx, `UPDATE api_keys SET revoked_at = $2 WHERE merchant_id = $1 AND revoked_at IS NULL`, merchantID, at)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api keys: %v", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) UseNonce(ctx context.Context, keyID, nonce string, expiresAt time.Time) (bool, error) {
+	query := `
+		INSERT INTO api_key_nonces (api_key_id, nonce, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (api_key_id, nonce) DO NOTHING
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, keyID, nonce, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to record api key nonce: %v", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *APIKeyRepository) PruneNonces(ctx context.Context, before time.Time) (int, error) {
+	tag, err := r.db.Pool.Exec(ctx, `DELETE FROM api_key_nonces WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune api key nonces: %v", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/popeskul/payment-gateway/internal/core/domain/audit"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+const auditEntryColumns = `sequence, id, COALESCE(merchant_id::text, ''), actor_type, actor_id, ip, request_id, action, target_type,
+		       target_id, changes, prev_hash, hash, created_at`
+
+type AuditRepository struct {
+	db            *Database
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewAuditRepository(db *Database, uuidGenerator ports.UUIDGenerator) ports.AuditRepository {
+	return &AuditRepository{
+		db:            db,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+func scanAuditEntry(row rowScanner) (*audit.Entry, error) {
+	var e audit.Entry
+	var changes []byte
+	err := row.Scan(&e.Sequence, &e.ID, &e.MerchantID, &e.ActorType, &e.ActorID, &e.IP, &e.RequestID, &e.Action, &e.TargetType,
+		&e.TargetID, &changes, &e.PrevHash, &e.Hash, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if changes != nil {
+		if err := json.Unmarshal(changes, &e.Changes); err != nil {
+			return nil, err
+		}
+	}
+	return &e, nil
+}
+
+func (r *AuditRepository) Append(ctx context.Context, e *audit.Entry) error {
+	if e.ID == "" {
+		e.ID = r.uuidGenerator.Generate()
+	}
+
+	changes, err := json.Marshal(e.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit changes: %v", err)
+	}
+
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Only one append at a time may read the end of the chain.
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('audit_log'))`); err != nil {
+		return fmt.Errorf("failed to lock audit log: %v", err)
+	}
+
+	var lastSequence int64
+	var lastHash string
+	err = tx.QueryRow(ctx, `SELECT sequence, hash FROM audit_log ORDER BY sequence DESC LIMIT 1`).Scan(&lastSequence, &lastHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to read audit log head: %v", err)
+	}
+
+	e.Sequence = lastSequence + 1
+	e.PrevHash = lastHash
+	e.Hash = e.ComputeHash()
+
+	query := `
+		INSERT INTO audit_log (sequence, id, merchant_id, actor_type, actor_id, ip, request_id, action, target_type, target_id,
+		                       changes, prev_hash, hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+	_, err = tx.Exec(ctx, query, e.Sequence, e.ID, nullString(e.MerchantID), string(e.ActorType), e.ActorID, e.IP, e.RequestID,
+		e.Action, e.TargetType, e.TargetID, changes, e.PrevHash, e.Hash, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create audit entry: %v", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+func (r *AuditRepository) List(ctx context.Context, filter audit.Filter) ([]*audit.Entry, error) {
+	query := `
+		SELECT ` + auditEntryColumns + `
+		FROM audit_log
+		WHERE ($1::uuid IS NULL OR merchant_id = $1)
+		  AND ($2 = '' OR actor_id = $2)
+		  AND ($3::timestamptz IS NULL OR created_at >= $3)
+		  AND ($4::timestamptz IS NULL OR created_at < $4)
+		ORDER BY sequence DESC
+		LIMIT $5 OFFSET $6
+	`
+	rows, err := r.db.Pool.Query(ctx, query, nullString(filter.MerchantID), filter.ActorID, filter.From, filter.To,
+		filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %v", err)
+	}
+	defer rows.Close()
+
+	return collectAuditEntries(rows)
+}
+
+func (r *AuditRepository) ListAfter(ctx context.Context, sequence int64, limit int) ([]*audit.Entry, error) {
+	query := `
+		SELECT ` + auditEntryColumns + `
+		FROM audit_log
+		WHERE sequence > $1
+		ORDER BY sequence ASC
+		LIMIT $2
+	`
+	rows, err := r.db.Pool.Query(ctx, query, sequence, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %v", err)
+	}
+	defer rows.Close()
+
+	return collectAuditEntries(rows)
+}
+
+func collectAuditEntries(rows pgx.Rows) ([]*audit.Entry, error) {
+	var entries []*audit.Entry
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %v", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit entries: %v", err)
+	}
+
+	return entries, nil
+}
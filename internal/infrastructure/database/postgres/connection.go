@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
@@ -80,3 +81,27 @@ func (t *Transaction) Commit(ctx context.Context) error {
 func (t *Transaction) Rollback(ctx context.Context) error {
 	return t.tx.Rollback(ctx)
 }
+
+// rowScanner is satisfied by both pgx.Row and pgx.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// execer is satisfied by both the pool and a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+}
+
+// rowQuerier is satisfied by both the pool and a transaction.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// nullString stores empty optional strings as NULL so that unique indexes
+// only apply to values that are actually set.
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
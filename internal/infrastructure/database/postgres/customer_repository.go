@@ -0,0 +1,345 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+const customerColumns = `id, merchant_id, mode, email, name, COALESCE(phone, ''), billing_address, shipping_address, metadata,
+		       created_at, updated_at`
+
+const paymentMethodColumns = `id, customer_id, type, token, COALESCE(brand, ''), COALESCE(last4, ''), COALESCE(exp_month, 0),
+		       COALESCE(exp_year, 0), is_default, created_at`
+
+type CustomerRepository struct {
+	db            *Database
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewCustomerRepository(db *Database, uuidGenerator ports.UUIDGenerator) ports.CustomerRepository {
+	return &CustomerRepository{
+		db:            db,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+func scanCustomer(row rowScanner) (*customer.Customer, error) {
+	var c customer.Customer
+	var billing, shipping, metadata []byte
+	err := row.Scan(&c.ID, &c.MerchantID, &c.Mode, &c.Email, &c.Name, &c.Phone, &billing, &shipping, &metadata, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if billing != nil {
+		if err := json.Unmarshal(billing, &c.BillingAddress); err != nil {
+			return nil, err
+		}
+	}
+	if shipping != nil {
+		if err := json.Unmarshal(shipping, &c.ShippingAddress); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(metadata, &c.Metadata); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func scanPaymentMethod(row rowScanner) (*customer.PaymentMethod, error) {
+	var pm customer.PaymentMethod
+	err := row.Scan(&pm.ID, &pm.CustomerID, &pm.Type, &pm.Token, &pm.Brand, &pm.Last4, &pm.ExpMonth, &pm.ExpYear, &pm.IsDefault, &pm.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &pm, nil
+}
+
+// customerJSON encodes the JSONB columns. Addresses stay NULL when unset;
+// metadata is always an object.
+func customerJSON(c *customer.Customer) (billing, shipping, metadata []byte, err error) {
+	if c.BillingAddress != nil {
+		if billing, err = json.Marshal(c.BillingAddress); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if c.ShippingAddress != nil {
+		if shipping, err = json.Marshal(c.ShippingAddress); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if c.Metadata == nil {
+		metadata = []byte("{}")
+	} else if metadata, err = json.Marshal(c.Metadata); err != nil {
+		return nil, nil, nil, err
+	}
+	return billing, shipping, metadata, nil
+}
+
+func (r *CustomerRepository) Create(ctx context.Context, c *customer.Customer) error {
+	if c.ID == "" {
+		c.ID = r.uuidGenerator.Generate()
+	}
+
+	billing, shipping, metadata, err := customerJSON(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode customer: %v", err)
+	}
+
+	query := `
+		INSERT INTO customers (id, merchant_id, mode, email, name, phone, billing_address, shipping_address, metadata, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err = r.db.Pool.Exec(ctx, query, c.ID, c.MerchantID, string(c.Mode), c.Email, c.Name, nullString(c.Phone), billing, shipping, metadata,
+		c.CreatedAt, c.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create customer: %v", err)
+	}
+	return nil
+}
+
+func (r *CustomerRepository) GetByID(ctx context.Context, id string) (*customer.Customer, error) {
+	query := `
+		SELECT ` + customerColumns + `
+		FROM customers
+		WHERE id = $1
+	`
+	c, err := scanCustomer(r.db.Pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("customer not found")
+		}
+		return nil, fmt.Errorf("failed to get customer: %v", err)
+	}
+	return c, nil
+}
+
+func (r *CustomerRepository) Update(ctx context.Context, c *customer.Customer) error {
+	billing, shipping, metadata, err := customerJSON(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode customer: %v", err)
+	}
+
+	query := `
+		UPDATE customers
+		SET email = $2, name = $3, phone = $4, billing_address = $5, shipping_address = $6, metadata = $7, updated_at = $8
+		WHERE id = $1
+	`
+	_, err = r.db.Pool.Exec(ctx, query, c.ID, c.Email, c.Name, nullString(c.Phone), billing, shipping, metadata, c.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update customer: %v", err)
+	}
+	return nil
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns, and the escape character
+// itself.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern returns a LIKE pattern, for use with ESCAPE '\', matching
+// values that contain s literally.
+func containsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+func (r *CustomerRepository) List(ctx context.Context, merchantID string, m mode.Mode, search string, limit, offset int) ([]*customer.Customer, error) {
+	query := `
+		SELECT ` + customerColumns + `
+		FROM customers
+		WHERE merchant_id = $1
+		  AND ($2 = '' OR mode = $2)
+		  AND ($3 = '' OR email ILIKE $6 ESCAPE '\' OR name ILIKE $6 ESCAPE '\')
+		ORDER BY created_at DESC
+		LIMIT $4 OFFSET $5
+	`
+	rows, err := r.db.Pool.Query(ctx, query, merchantID, string(m), search, limit, offset, containsPattern(search))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customers: %v", err)
+	}
+	defer rows.Close()
+
+	var customers []*customer.Customer
+	for rows.Next() {
+		c, err := scanCustomer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan customer: %v", err)
+		}
+		customers = append(customers, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating customers: %v", err)
+	}
+
+	return customers, nil
+}
+
+func (r *CustomerRepository) Delete(ctx context.Context, id string) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Payment history is kept for accounting, but the stored token is personal
+	// data tied to the customer and goes with them.
+	_, err = tx.Exec(ctx, `UPDATE payments SET payment_token = NULL WHERE customer_id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to detach customer payments: %v", err)
+	}
+
+	// Payment methods cascade; payments and refunds have customer_id set to NULL.
+	tag, err := tx.Exec(ctx, `DELETE FROM customers WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete customer: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("customer not found")
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (r *CustomerRepository) AddPaymentMethod(ctx context.Context, pm *customer.PaymentMethod) error {
+	if pm.ID == "" {
+		pm.ID = r.uuidGenerator.Generate()
+	}
+
+	query := `
+		INSERT INTO customer_payment_methods (id, customer_id, type, token, brand, last4, exp_month, exp_year, is_default, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := r.db.Pool.Exec(ctx, query, pm.ID, pm.CustomerID, pm.Type, pm.Token, nullString(pm.Brand), nullString(pm.Last4),
+		pm.ExpMonth, pm.ExpYear, pm.IsDefault, pm.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create payment method: %v", err)
+	}
+	return nil
+}
+
+func (r *CustomerRepository) GetPaymentMethod(ctx context.Context, id string) (*customer.PaymentMethod, error) {
+	query := `
+		SELECT ` + paymentMethodColumns + `
+		FROM customer_payment_methods
+		WHERE id = $1
+	`
+	pm, err := scanPaymentMethod(r.db.Pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("payment method not found")
+		}
+		return nil, fmt.Errorf("failed to get payment method: %v", err)
+	}
+	return pm, nil
+}
+
+func (r *CustomerRepository) ListPaymentMethods(ctx context.Context, customerID string) ([]*customer.PaymentMethod, error) {
+	query := `
+		SELECT ` + paymentMethodColumns + `
+		FROM customer_payment_methods
+		WHERE customer_id = $1
+		ORDER BY is_default DESC, created_at DESC
+	`
+	rows, err := r.db.Pool.Query(ctx, query, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment methods: %v", err)
+	}
+	defer rows.Close()
+
+	var methods []*customer.PaymentMethod
+	for rows.Next() {
+		pm, err := scanPaymentMethod(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payment method: %v", err)
+		}
+		methods = append(methods, pm)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payment methods: %v", err)
+	}
+
+	return methods, nil
+}
+
+func (r *CustomerRepository) SetDefaultPaymentMethod(ctx context.Context, customerID, id string) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `UPDATE customer_payment_methods SET is_default = FALSE WHERE customer_id = $1 AND is_default`, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to clear default payment method: %v", err)
+	}
+
+	tag, err := tx.Exec(ctx, `UPDATE customer_payment_methods SET is_default = TRUE WHERE id = $1 AND customer_id = $2`, id, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to set default payment method: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("payment method not found")
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (r *CustomerRepository) DeletePaymentMethod(ctx context.Context, id string) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var customerID string
+	var wasDefault bool
+	err = tx.QueryRow(ctx, `DELETE FROM customer_payment_methods WHERE id = $1 RETURNING customer_id, is_default`, id).
+		Scan(&customerID, &wasDefault)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete payment method: %v", err)
+	}
+
+	if wasDefault {
+		_, err = tx.Exec(ctx, `
+			UPDATE customer_payment_methods SET is_default = TRUE
+			WHERE id = (
+				SELECT id FROM customer_payment_methods
+				WHERE customer_id = $1
+				ORDER BY created_at DESC, id DESC
+				LIMIT 1
+			)
+		`, customerID)
+		if err != nil {
+			return fmt.Errorf("failed to set default payment method: %v", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
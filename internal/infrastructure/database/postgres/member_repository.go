@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+const memberColumns = `mm.merchant_id, mm.user_id, u.email, mm.role, COALESCE(mm.invited_by::text, ''), mm.created_at, mm.updated_at`
+
+type MemberRepository struct {
+	db *Database
+}
+
+func NewMemberRepository(db *Database) ports.MemberRepository {
+	return &MemberRepository{
+		db: db,
+	}
+}
+
+func scanMember(row rowScanner) (*member.Member, error) {
+	var m member.Member
+	err := row.Scan(&m.MerchantID, &m.UserID, &m.Email, &m.Role, &m.InvitedBy, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *MemberRepository) Add(ctx context.Context, m *member.Member) error {
+	return addMember(ctx, r.db.Pool, m)
+}
+
+func addMember(ctx context.Context, db execer, m *member.Member) error {
+	query := `
+		INSERT INTO merchant_members (merchant_id, user_id, role, invited_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := db.Exec(ctx, query, m.MerchantID, m.UserID, m.Role, nullString(m.InvitedBy), m.CreatedAt, m.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add member: %v", err)
+	}
+	return nil
+}
+
+func (r *MemberRepository) Get(ctx context.Context, merchantID, userID string) (*member.Member, error) {
+	query := `
+		SELECT ` + memberColumns + `
+		FROM merchant_members mm
+		JOIN users u ON u.id = mm.user_id
+		WHERE mm.merchant_id = $1 AND mm.user_id = $2
+	`
+	m, err := scanMember(r.db.Pool.QueryRow(ctx, query, merchantID, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("member not found")
+		}
+		return nil, fmt.Errorf("failed to get member: %v", err)
+	}
+	return m, nil
+}
+
+func (r *MemberRepository) List(ctx context.Context, merchantID string) ([]*member.Member, error) {
+	query := `
+		SELECT ` + memberColumns + `
+		FROM merchant_members mm
+		JOIN users u ON u.id = mm.user_id
+		WHERE mm.merchant_id = $1
+		ORDER BY mm.created_at
+	`
+	rows, err := r.db.Pool.Query(ctx, query, merchantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %v", err)
+	}
+	defer rows.Close()
+
+	var members []*member.Member
+	for rows.Next() {
+		m, err := scanMember(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan member: %v", err)
+		}
+		members = append(members, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating members: %v", err)
+	}
+
+	return members, nil
+}
+
+func (r *MemberRepository) Remove(ctx context.Context, merchantID, userID string) error {
+	query := `DELETE FROM merchant_members WHERE merchant_id = $1 AND user_id = $2`
+	tag, err := r.db.Pool.Exec(ctx, query, merchantID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove member: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("member not found")
+	}
+	return nil
+}
+
+func (r *MemberRepository) CountByRole(ctx context.Context, merchantID string, role member.Role) (int, error) {
+	query := `SELECT COUNT(*) FROM merchant_members WHERE merchant_id = $1 AND role = $2`
+	var count int
+	if err := r.db.Pool.QueryRow(ctx, query, merchantID, role).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count members: %v", err)
+	}
+	return count, nil
+}
@@ -2,14 +2,21 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
 	"github.com/jackc/pgx/v4"
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
 	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
 )
 
+const merchantColumns = `m.id, m.name, m.email, m.settlement_currency, m.status, COALESCE(m.status_reason, ''),
+		       m.business_details, m.settings, m.submitted_at, m.reviewed_at, COALESCE(m.reviewed_by::text, ''), m.deleted_at,
+		       COALESCE(m.deletion_reason, ''), m.created_at, m.updated_at`
+
 type MerchantRepository struct {
 	db            *Database
 	uuidGenerator ports.UUIDGenerator
@@ -22,54 +29,168 @@ func NewMerchantRepository(db *Database, uuidGenerator ports.UUIDGenerator) port
 	}
 }
 
+func scanMerchant(row rowScanner) (*merchant.Merchant, error) {
+	var m merchant.Merchant
+	var details, settings []byte
+	err := row.Scan(&m.ID, &m.Name, &m.Email, &m.SettlementCurrency, &m.Status, &m.StatusReason,
+		&details, &settings, &m.SubmittedAt, &m.ReviewedAt, &m.ReviewedBy, &m.DeletedAt,
+		&m.DeletionReason, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if details != nil {
+		if err := json.Unmarshal(details, &m.BusinessDetails); err != nil {
+			return nil, err
+		}
+	}
+	if settings != nil {
+		if err := json.Unmarshal(settings, &m.Settings); err != nil {
+			return nil, err
+		}
+	}
+	return &m, nil
+}
+
 func (r *MerchantRepository) Create(ctx context.Context, m *merchant.Merchant) error {
+	return r.create(ctx, r.db.Pool, m)
+}
+
+func (r *MerchantRepository) CreateWithOwner(ctx context.Context, m *merchant.Merchant, owner *member.Member, keys []*apikey.APIKey) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.create(ctx, tx, m); err != nil {
+		return err
+	}
+
+	owner.MerchantID = m.ID
+	if err := addMember(ctx, tx, owner); err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if k.ID == "" {
+			k.ID = r.uuidGenerator.Generate()
+		}
+		k.MerchantID = m.ID
+		if err := insertAPIKey(ctx, tx, k); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+func (r *MerchantRepository) create(ctx context.Context, db execer, m *merchant.Merchant) error {
 	if m.ID == "" {
 		m.ID = r.uuidGenerator.Generate()
 	}
 
 	query := `
-        INSERT INTO merchants (id, name, email, api_key, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO merchants (id, name, email, settlement_currency, status, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
     `
-	_, err := r.db.Pool.Exec(ctx, query, m.ID, m.Name, m.Email, m.ApiKey, m.CreatedAt, m.UpdatedAt)
+	_, err := db.Exec(ctx, query, m.ID, m.Name, m.Email, m.SettlementCurrency, m.Status, m.CreatedAt, m.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create merchant: %v", err)
 	}
 	return nil
 }
 
-func (r *MerchantRepository) GetByID(ctx context.Context, id string) (*merchant.Merchant, error) {
+func (r *MerchantRepository) get(ctx context.Context, where, value string) (*merchant.Merchant, error) {
 	query := `
-		SELECT id, name, email, api_key, created_at, updated_at
-		FROM merchants
-		WHERE id = $1
+		SELECT ` + merchantColumns + `
+		FROM merchants m
+		WHERE ` + where + `
 	`
-	var m merchant.Merchant
-	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
-		&m.ID, &m.Name, &m.Email, &m.ApiKey, &m.CreatedAt, &m.UpdatedAt,
-	)
+	m, err := scanMerchant(r.db.Pool.QueryRow(ctx, query, value))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("merchant not found")
 		}
 		return nil, fmt.Errorf("failed to get merchant: %v", err)
 	}
-	return &m, nil
+	return m, nil
+}
+
+func (r *MerchantRepository) GetByID(ctx context.Context, id string) (*merchant.Merchant, error) {
+	return r.get(ctx, "m.id = $1", id)
 }
 
 func (r *MerchantRepository) Update(ctx context.Context, m *merchant.Merchant) error {
 	query := `
 		UPDATE merchants
-		SET name = $2, email = $3, api_key = $4, updated_at = $5
+		SET name = $2, email = $3, settlement_currency = $4, updated_at = $5
 		WHERE id = $1
 	`
-	_, err := r.db.Pool.Exec(ctx, query, m.ID, m.Name, m.Email, m.ApiKey, m.UpdatedAt)
+	_, err := r.db.Pool.Exec(ctx, query, m.ID, m.Name, m.Email, m.SettlementCurrency, m.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to update merchant: %v", err)
 	}
 	return nil
 }
 
+// UpdateOnboarding writes the onboarding status and business details, which
+// Update leaves alone so that profile edits cannot change them.
+func (r *MerchantRepository) UpdateOnboarding(ctx context.Context, m *merchant.Merchant) error {
+	var details []byte
+	if m.BusinessDetails != nil {
+		var err error
+		if details, err = json.Marshal(m.BusinessDetails); err != nil {
+			return fmt.Errorf("failed to encode business details: %v", err)
+		}
+	}
+
+	query := `
+		UPDATE merchants
+		SET status = $2, status_reason = $3, business_details = $4, submitted_at = $5, reviewed_at = $6,
+		    reviewed_by = $7, updated_at = $8
+		WHERE id = $1
+	`
+	_, err := r.db.Pool.Exec(ctx, query, m.ID, m.Status, nullString(m.StatusReason), details, m.SubmittedAt, m.ReviewedAt,
+		nullString(m.ReviewedBy), m.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update merchant onboarding: %v", err)
+	}
+	return nil
+}
+
+func (r *MerchantRepository) UpdateSettings(ctx context.Context, m *merchant.Merchant) error {
+	settings, err := json.Marshal(m.Settings)
+	if err != nil {
+		return fmt.Errorf("failed to encode merchant settings: %v", err)
+	}
+
+	_, err = r.db.Pool.Exec(ctx, `UPDATE merchants SET settings = $2, updated_at = $3 WHERE id = $1`, m.ID, settings, m.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update merchant settings: %v", err)
+	}
+	return nil
+}
+
+func (r *MerchantRepository) SoftDelete(ctx context.Context, m *merchant.Merchant) error {
+	query := `
+		UPDATE merchants
+		SET deleted_at = $2, deletion_reason = $3, updated_at = $4
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, m.ID, m.DeletedAt, m.DeletionReason, m.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to delete merchant: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("merchant not found")
+	}
+	return nil
+}
+
 func (r *MerchantRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM merchants WHERE id = $1`
 	_, err := r.db.Pool.Exec(ctx, query, id)
@@ -79,14 +200,8 @@ func (r *MerchantRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (r *MerchantRepository) List(ctx context.Context, limit, offset int) ([]*merchant.Merchant, error) {
-	query := `
-		SELECT id, name, email, api_key, created_at, updated_at
-		FROM merchants
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`
-	rows, err := r.db.Pool.Query(ctx, query, limit, offset)
+func (r *MerchantRepository) query(ctx context.Context, query string, args ...interface{}) ([]*merchant.Merchant, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list merchants: %v", err)
 	}
@@ -94,12 +209,11 @@ func (r *MerchantRepository) List(ctx context.Context, limit, offset int) ([]*me
 
 	var merchants []*merchant.Merchant
 	for rows.Next() {
-		var m merchant.Merchant
-		err := rows.Scan(&m.ID, &m.Name, &m.Email, &m.ApiKey, &m.CreatedAt, &m.UpdatedAt)
+		m, err := scanMerchant(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan merchant: %v", err)
 		}
-		merchants = append(merchants, &m)
+		merchants = append(merchants, m)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -109,21 +223,42 @@ func (r *MerchantRepository) List(ctx context.Context, limit, offset int) ([]*me
 	return merchants, nil
 }
 
-func (r *MerchantRepository) GetByEmail(ctx context.Context, email string) (*merchant.Merchant, error) {
+func (r *MerchantRepository) List(ctx context.Context, limit, offset int) ([]*merchant.Merchant, error) {
 	query := `
-		SELECT id, name, email, api_key, created_at, updated_at
-		FROM merchants
-		WHERE email = $1
+		SELECT ` + merchantColumns + `
+		FROM merchants m
+		WHERE m.deleted_at IS NULL
+		ORDER BY m.created_at DESC
+		LIMIT $1 OFFSET $2
 	`
-	var m merchant.Merchant
-	err := r.db.Pool.QueryRow(ctx, query, email).Scan(
-		&m.ID, &m.Name, &m.Email, &m.ApiKey, &m.CreatedAt, &m.UpdatedAt,
-	)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("merchant not found")
-		}
-		return nil, fmt.Errorf("failed to get merchant: %v", err)
-	}
-	return &m, nil
+	return r.query(ctx, query, limit, offset)
+}
+
+func (r *MerchantRepository) ListByUser(ctx context.Context, userID string, limit, offset int) ([]*merchant.Merchant, error) {
+	query := `
+		SELECT ` + merchantColumns + `
+		FROM merchants m
+		JOIN merchant_members mm ON mm.merchant_id = m.id
+		WHERE mm.user_id = $1 AND m.deleted_at IS NULL
+		ORDER BY m.created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	return r.query(ctx, query, userID, limit, offset)
+}
+
+// ListByStatus returns the oldest submissions first so the review queue is
+// worked in order.
+func (r *MerchantRepository) ListByStatus(ctx context.Context, status merchant.Status, limit, offset int) ([]*merchant.Merchant, error) {
+	query := `
+		SELECT ` + merchantColumns + `
+		FROM merchants m
+		WHERE m.status = $1 AND m.deleted_at IS NULL
+		ORDER BY m.submitted_at ASC NULLS LAST, m.created_at ASC
+		LIMIT $2 OFFSET $3
+	`
+	return r.query(ctx, query, status, limit, offset)
+}
+
+func (r *MerchantRepository) GetByEmail(ctx context.Context, email string) (*merchant.Merchant, error) {
+	return r.get(ctx, "m.email = $1 AND m.deleted_at IS NULL", email)
 }
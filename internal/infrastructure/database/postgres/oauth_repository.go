@@ -0,0 +1,212 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+const (
+	oauthClientColumns = `id, owner_id, name, secret_hash, scopes, revoked_at, created_at`
+	oauthGrantColumns  = `client_id, merchant_id, scopes, COALESCE(granted_by::text, ''), created_at`
+)
+
+type OAuthClientRepository struct {
+	db            *Database
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewOAuthClientRepository(db *Database, uuidGenerator ports.UUIDGenerator) ports.OAuthClientRepository {
+	return &OAuthClientRepository{
+		db:            db,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+func oauthScopes(strs []string) []oauth.Scope {
+	scopes := make([]oauth.Scope, len(strs))
+	for i, s := range strs {
+		scopes[i] = oauth.Scope(s)
+	}
+	return scopes
+}
+
+func oauthScopeStrings(scopes []oauth.Scope) []string {
+	strs := make([]string, len(scopes))
+	for i, s := range scopes {
+		strs[i] = string(s)
+	}
+	return strs
+}
+
+func scanOAuthClient(row rowScanner) (*oauth.Client, error) {
+	var c oauth.Client
+	var scopes []string
+	if err := row.Scan(&c.ID, &c.OwnerID, &c.Name, &c.SecretHash, &scopes, &c.RevokedAt, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	c.Scopes = oauthScopes(scopes)
+	return &c, nil
+}
+
+func scanOAuthGrant(row rowScanner) (*oauth.Grant, error) {
+	var g oauth.Grant
+	var scopes []string
+	if err := row.Scan(&g.ClientID, &g.MerchantID, &scopes, &g.GrantedBy, &g.CreatedAt); err != nil {
+		return nil, err
+	}
+	g.Scopes = oauthScopes(scopes)
+	return &g, nil
+}
+
+func (r *OAuthClientRepository) Create(ctx context.Context, c *oauth.Client) error {
+	if c.ID == "" {
+		c.ID = r.uuidGenerator.Generate()
+	}
+
+	query := `
+		INSERT INTO oauth_clients (id, owner_id, name, secret_hash, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.db.Pool.Exec(ctx, query, c.ID, c.OwnerID, c.Name, c.SecretHash, oauthScopeStrings(c.Scopes), c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create oauth client: %v", err)
+	}
+	return nil
+}
+
+func (r *OAuthClientRepository) GetByID(ctx context.Context, id string) (*oauth.Client, error) {
+	query := `SELECT ` + oauthClientColumns + ` FROM oauth_clients WHERE id = $1`
+	c, err := scanOAuthClient(r.db.Pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, oauth.ErrClientNotFound
+		}
+		return nil, fmt.Errorf("failed to get oauth client: %v", err)
+	}
+	return c, nil
+}
+
+func (r *OAuthClientRepository) ListByOwner(ctx context.Context, ownerID string) ([]*oauth.Client, error) {
+	query := `
+		SELECT ` + oauthClientColumns + `
+		FROM oauth_clients
+		WHERE owner_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Pool.Query(ctx, query, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list oauth clients: %v", err)
+	}
+	defer rows.Close()
+
+	var clients []*oauth.Client
+	for rows.Next() {
+		c, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan oauth client: %v", err)
+		}
+		clients = append(clients, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating oauth clients: %v", err)
+	}
+
+	return clients, nil
+}
+
+func (r *OAuthClientRepository) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	_, err := r.db.Pool.Exec(ctx, `UPDATE oauth_clients SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`, id, revokedAt)
+	if err != nil {
+		return fmt.Errorf("failed to revoke oauth client: %v", err)
+	}
+	return nil
+}
+
+func (r *OAuthClientRepository) SaveGrant(ctx context.Context, g *oauth.Grant) error {
+	query := `
+		INSERT INTO oauth_grants (client_id, merchant_id, scopes, granted_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (client_id, merchant_id)
+		DO UPDATE SET scopes = EXCLUDED.scopes, granted_by = EXCLUDED.granted_by
+	`
+	_, err := r.db.Pool.Exec(ctx, query, g.ClientID, g.MerchantID, oauthScopeStrings(g.Scopes), nullString(g.GrantedBy), g.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save oauth grant: %v", err)
+	}
+	return nil
+}
+
+func (r *OAuthClientRepository) GetGrant(ctx context.Context, clientID, merchantID string) (*oauth.Grant, error) {
+	query := `SELECT ` + oauthGrantColumns + ` FROM oauth_grants WHERE client_id = $1 AND merchant_id = $2`
+	g, err := scanOAuthGrant(r.db.Pool.QueryRow(ctx, query, clientID, merchantID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, oauth.ErrGrantNotFound
+		}
+		return nil, fmt.Errorf("failed to get oauth grant: %v", err)
+	}
+	return g, nil
+}
+
+func (r *OAuthClientRepository) listGrants(ctx context.Context, column, id string) ([]*oauth.Grant, error) {
+	query := `
+		SELECT ` + oauthGrantColumns + `
+		FROM oauth_grants
+		WHERE ` + column + ` = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Pool.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list oauth grants: %v", err)
+	}
+	defer rows.Close()
+
+	var grants []*oauth.Grant
+	for rows.Next() {
+		g, err := scanOAuthGrant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan oauth grant: %v", err)
+		}
+		grants = append(grants, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating oauth grants: %v", err)
+	}
+
+	return grants, nil
+}
+
+func (r *OAuthClientRepository) ListGrantsByMerchant(ctx context.Context, merchantID string) ([]*oauth.Grant, error) {
+	return r.listGrants(ctx, "merchant_id", merchantID)
+}
+
+func (r *OAuthClientRepository) ListGrantsByClient(ctx context.Context, clientID string) ([]*oauth.Grant, error) {
+	return r.listGrants(ctx, "client_id", clientID)
+}
+
+func (r *OAuthClientRepository) DeleteGrant(ctx context.Context, clientID, merchantID string) error {
+	tag, err := r.db.Pool.Exec(ctx, `DELETE FROM oauth_grants WHERE client_id = $1 AND merchant_id = $2`, clientID, merchantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete oauth grant: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return oauth.ErrGrantNotFound
+	}
+	return nil
+}
+
+func (r *OAuthClientRepository) DeleteGrantsByMerchant(ctx context.Context, merchantID string) error {
+	_, err := r.db.Pool.Exec(ctx, `DELETE FROM oauth_grants WHERE merchant_id = $1`, merchantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete oauth grants: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+const beneficialOwnerColumns = `id, merchant_id, full_name, date_of_birth::text, nationality, ownership_percent, created_at`
+
+const documentColumns = `id, merchant_id, type, file_name, content_type, size, storage_key, COALESCE(uploaded_by::text, ''),
+		       created_at`
+
+type OnboardingRepository struct {
+	db            *Database
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewOnboardingRepository(db *Database, uuidGenerator ports.UUIDGenerator) ports.OnboardingRepository {
+	return &OnboardingRepository{
+		db:            db,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+func scanBeneficialOwner(row rowScanner) (*merchant.BeneficialOwner, error) {
+	var o merchant.BeneficialOwner
+	err := row.Scan(&o.ID, &o.MerchantID, &o.FullName, &o.DateOfBirth, &o.Nationality, &o.OwnershipPercent, &o.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func scanDocument(row rowScanner) (*merchant.Document, error) {
+	var d merchant.Document
+	err := row.Scan(&d.ID, &d.MerchantID, &d.Type, &d.FileName, &d.ContentType, &d.Size, &d.StorageKey, &d.UploadedBy,
+		&d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *OnboardingRepository) AddOwner(ctx context.Context, o *merchant.BeneficialOwner) error {
+	if o.ID == "" {
+		o.ID = r.uuidGenerator.Generate()
+	}
+
+	query := `
+		INSERT INTO merchant_beneficial_owners (id, merchant_id, full_name, date_of_birth, nationality, ownership_percent, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := r.db.Pool.Exec(ctx, query, o.ID, o.MerchantID, o.FullName, o.DateOfBirth, o.Nationality, o.OwnershipPercent,
+		o.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create beneficial owner: %v", err)
+	}
+	return nil
+}
+
+func (r *OnboardingRepository) ListOwners(ctx context.Context, merchantID string) ([]*merchant.BeneficialOwner, error) {
+	query := `
+		SELECT ` + beneficialOwnerColumns + `
+		FROM merchant_beneficial_owners
+		WHERE merchant_id = $1
+		ORDER BY created_at ASC
+	`
+	rows, err := r.db.Pool.Query(ctx, query, merchantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list beneficial owners: %v", err)
+	}
+	defer rows.Close()
+
+	var owners []*merchant.BeneficialOwner
+	for rows.Next() {
+		o, err := scanBeneficialOwner(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan beneficial owner: %v", err)
+		}
+		owners = append(owners, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating beneficial owners: %v", err)
+	}
+
+	return owners, nil
+}
+
+func (r *OnboardingRepository) DeleteOwner(ctx context.Context, merchantID, id string) error {
+	tag, err := r.db.Pool.Exec(ctx, `DELETE FROM merchant_beneficial_owners WHERE id = $1 AND merchant_id = $2`, id, merchantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete beneficial owner: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("beneficial owner not found")
+	}
+	return nil
+}
+
+func (r *OnboardingRepository) AddDocument(ctx context.Context, d *merchant.Document) error {
+	if d.ID == "" {
+		d.ID = r.uuidGenerator.Generate()
+	}
+
+	query := `
+		INSERT INTO merchant_documents (id, merchant_id, type, file_name, content_type, size, storage_key, uploaded_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := r.db.Pool.Exec(ctx, query, d.ID, d.MerchantID, d.Type, d.FileName, d.ContentType, d.Size, d.StorageKey,
+		nullString(d.UploadedBy), d.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create document: %v", err)
+	}
+	return nil
+}
+
+func (r *OnboardingRepository) GetDocument(ctx context.Context, id string) (*merchant.Document, error) {
+	query := `
+		SELECT ` + documentColumns + `
+		FROM merchant_documents
+		WHERE id = $1
+	`
+	d, err := scanDocument(r.db.Pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("document not found")
+		}
+		return nil, fmt.Errorf("failed to get document: %v", err)
+	}
+	return d, nil
+}
+
+func (r *OnboardingRepository) ListDocuments(ctx context.Context, merchantID string) ([]*merchant.Document, error) {
+	query := `
+		SELECT ` + documentColumns + `
+		FROM merchant_documents
+		WHERE merchant_id = $1
+		ORDER BY created_at ASC
+	`
+	rows, err := r.db.Pool.Query(ctx, query, merchantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %v", err)
+	}
+	defer rows.Close()
+
+	var documents []*merchant.Document
+	for rows.Next() {
+		d, err := scanDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan document: %v", err)
+		}
+		documents = append(documents, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating documents: %v", err)
+	}
+
+	return documents, nil
+}
@@ -4,12 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v4"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
 	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
 )
 
+const paymentColumns = `id, merchant_id, mode, amount, currency, settlement_amount, settlement_currency, fx_rate, fx_rate_timestamp,
+		       status, payment_method, COALESCE(payment_token, ''), COALESCE(subscription_id::text, ''),
+		       COALESCE(customer_id::text, ''), COALESCE(payment_method_id::text, ''), description,
+		       COALESCE(statement_descriptor, ''), COALESCE(acquirer_reference, ''), processed_at, authorization_expires_at, created_at, updated_at`
+
 type PaymentRepository struct {
 	db            *Database
 	uuidGenerator ports.UUIDGenerator
@@ -22,16 +29,34 @@ func NewPaymentRepository(db *Database, uuidGenerator ports.UUIDGenerator) ports
 	}
 }
 
+func scanPayment(row rowScanner) (*payment.Payment, error) {
+	var p payment.Payment
+	err := row.Scan(&p.ID, &p.MerchantID, &p.Mode, &p.Amount, &p.Currency, &p.SettlementAmount, &p.SettlementCurrency, &p.FXRate, &p.FXRateTimestamp,
+		&p.Status, &p.PaymentMethod, &p.PaymentToken, &p.SubscriptionID, &p.CustomerID, &p.PaymentMethodID, &p.Description, &p.StatementDescriptor, &p.AcquirerReference, &p.ProcessedAt, &p.AuthorizationExpiresAt,
+		&p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
 func (r *PaymentRepository) Create(ctx context.Context, p *payment.Payment) error {
+	return r.create(ctx, r.db.Pool, p)
+}
+
+func (r *PaymentRepository) create(ctx context.Context, db execer, p *payment.Payment) error {
 	if p.ID == "" {
 		p.ID = r.uuidGenerator.Generate()
 	}
 
 	query := `
-		INSERT INTO payments (id, merchant_id, amount, currency, status, payment_method, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO payments (id, merchant_id, mode, amount, currency, settlement_amount, settlement_currency, fx_rate, fx_rate_timestamp,
+		                      status, payment_method, payment_token, subscription_id, customer_id, payment_method_id, description,
+		                      statement_descriptor, acquirer_reference, processed_at, authorization_expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 	`
-	_, err := r.db.Pool.Exec(ctx, query, p.ID, p.MerchantID, p.Amount, p.Currency, p.Status, p.PaymentMethod, p.Description, p.CreatedAt, p.UpdatedAt)
+	_, err := db.Exec(ctx, query, p.ID, p.MerchantID, string(p.Mode), p.Amount, p.Currency, p.SettlementAmount, p.SettlementCurrency, p.FXRate, p.FXRateTimestamp,
+		p.Status, p.PaymentMethod, nullString(p.PaymentToken), nullString(p.SubscriptionID), nullString(p.CustomerID), nullString(p.PaymentMethodID), p.Description, nullString(p.StatementDescriptor), nullString(p.AcquirerReference), p.ProcessedAt, p.AuthorizationExpiresAt, p.CreatedAt, p.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create payment: %v", err)
 	}
@@ -40,77 +65,266 @@ func (r *PaymentRepository) Create(ctx context.Context, p *payment.Payment) erro
 
 func (r *PaymentRepository) GetByID(ctx context.Context, id string) (*payment.Payment, error) {
 	query := `
-		SELECT id, merchant_id, amount, currency, status, payment_method, description, created_at, updated_at
+		SELECT ` + paymentColumns + `
 		FROM payments
 		WHERE id = $1
 	`
-	var p payment.Payment
-	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
-		&p.ID, &p.MerchantID, &p.Amount, &p.Currency, &p.Status, &p.PaymentMethod, &p.Description, &p.CreatedAt, &p.UpdatedAt,
-	)
+	p, err := scanPayment(r.db.Pool.QueryRow(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("payment not found")
+			return nil, payment.ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get payment: %v", err)
 	}
-	return &p, nil
+	return p, nil
 }
 
 func (r *PaymentRepository) Update(ctx context.Context, p *payment.Payment) error {
 	query := `
 		UPDATE payments
-		SET merchant_id = $2, amount = $3, currency = $4, status = $5, payment_method = $6, description = $7, updated_at = $8
+		SET merchant_id = $2, amount = $3, currency = $4, settlement_amount = $5, settlement_currency = $6, fx_rate = $7,
+		    fx_rate_timestamp = $8, status = $9, payment_method = $10, description = $11, acquirer_reference = $12,
+		    processed_at = $13, authorization_expires_at = $14, updated_at = $15
 		WHERE id = $1
 	`
 	_, err := r.db.Pool.Exec(ctx, query,
-		p.ID, p.MerchantID, p.Amount, p.Currency, p.Status, p.PaymentMethod, p.Description, p.UpdatedAt)
+		p.ID, p.MerchantID, p.Amount, p.Currency, p.SettlementAmount, p.SettlementCurrency, p.FXRate,
+		p.FXRateTimestamp, p.Status, p.PaymentMethod, p.Description, nullString(p.AcquirerReference), p.ProcessedAt,
+		p.AuthorizationExpiresAt, p.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to update payment: %v", err)
 	}
 	return nil
 }
 
-func (r *PaymentRepository) List(ctx context.Context, merchantID string, limit, offset int) ([]*payment.Payment, error) {
+func (r *PaymentRepository) List(ctx context.Context, merchantID string, m mode.Mode, limit, offset int) ([]*payment.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE merchant_id = $1 AND ($2 = '' OR mode = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	return r.query(ctx, query, merchantID, string(m), limit, offset)
+}
+
+func (r *PaymentRepository) ListByCustomer(ctx context.Context, customerID string, limit, offset int) ([]*payment.Payment, error) {
 	query := `
-		SELECT id, merchant_id, amount, currency, status, payment_method, description, created_at, updated_at
+		SELECT ` + paymentColumns + `
 		FROM payments
-		WHERE merchant_id = $1
+		WHERE customer_id = $1
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`
-	rows, err := r.db.Pool.Query(ctx, query, merchantID, limit, offset)
+	return r.query(ctx, query, customerID, limit, offset)
+}
+
+func (r *PaymentRepository) UpdateStatus(ctx context.Context, id string, status payment.PaymentStatus) error {
+	query := `
+		UPDATE payments
+		SET status = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Pool.Exec(ctx, query, id, status)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list payments: %v", err)
+		return fmt.Errorf("failed to update payment status: %v", err)
+	}
+	return nil
+}
+
+func (r *PaymentRepository) GetByAcquirerReference(ctx context.Context, reference string) (*payment.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE acquirer_reference = $1 AND mode = 'live'
+	`
+	p, err := scanPayment(r.db.Pool.QueryRow(ctx, query, reference))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, payment.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get payment: %v", err)
+	}
+	return p, nil
+}
+
+func (r *PaymentRepository) ListProcessedBetween(ctx context.Context, from, to time.Time) ([]*payment.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE processed_at BETWEEN $1 AND $2 AND acquirer_reference IS NOT NULL AND mode = 'live'
+		ORDER BY processed_at
+	`
+	return r.query(ctx, query, from, to)
+}
+
+func (r *PaymentRepository) TransitionStatus(ctx context.Context, p *payment.Payment, from payment.PaymentStatus, reason string) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	updateQuery := `
+		UPDATE payments
+		SET status = $3, acquirer_reference = $4, processed_at = $5, authorization_expires_at = $6, updated_at = $7
+		WHERE id = $1 AND status = $2
+	`
+	tag, err := tx.Exec(ctx, updateQuery, p.ID, from, p.Status, nullString(p.AcquirerReference), p.ProcessedAt,
+		p.AuthorizationExpiresAt, p.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update payment status: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("payment is not in %s status", from)
+	}
+
+	transitionQuery := `
+		INSERT INTO payment_status_transitions (id, payment_id, from_status, to_status, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err = tx.Exec(ctx, transitionQuery, r.uuidGenerator.Generate(), p.ID, from, p.Status, reason, p.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record payment status transition: %v", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (r *PaymentRepository) ListTransitions(ctx context.Context, paymentID string) ([]*payment.StatusTransition, error) {
+	query := `
+		SELECT id, payment_id, from_status, to_status, reason, created_at
+		FROM payment_status_transitions
+		WHERE payment_id = $1
+		ORDER BY created_at
+	`
+	rows, err := r.db.Pool.Query(ctx, query, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment status transitions: %v", err)
 	}
 	defer rows.Close()
 
-	var payments []*payment.Payment
+	var transitions []*payment.StatusTransition
 	for rows.Next() {
-		var p payment.Payment
-		err := rows.Scan(&p.ID, &p.MerchantID, &p.Amount, &p.Currency, &p.Status, &p.PaymentMethod, &p.Description, &p.CreatedAt, &p.UpdatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan payment: %v", err)
+		var t payment.StatusTransition
+		if err := rows.Scan(&t.ID, &t.PaymentID, &t.FromStatus, &t.ToStatus, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan payment status transition: %v", err)
 		}
-		payments = append(payments, &p)
+		transitions = append(transitions, &t)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating payments: %v", err)
+		return nil, fmt.Errorf("error iterating payment status transitions: %v", err)
 	}
 
-	return payments, nil
+	return transitions, nil
 }
 
-func (r *PaymentRepository) UpdateStatus(ctx context.Context, id string, status payment.PaymentStatus) error {
+func (r *PaymentRepository) ListPendingCreatedBefore(ctx context.Context, before time.Time, limit int) ([]*payment.Payment, error) {
 	query := `
-		UPDATE payments
-		SET status = $2, updated_at = NOW()
-		WHERE id = $1
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE status = 'pending' AND created_at < $1
+		ORDER BY created_at
+		LIMIT $2
 	`
-	_, err := r.db.Pool.Exec(ctx, query, id, status)
+	return r.query(ctx, query, before, limit)
+}
+
+func (r *PaymentRepository) ListAuthorizationsExpiredBefore(ctx context.Context, before time.Time, limit int) ([]*payment.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE status = 'authorized' AND authorization_expires_at < $1
+		ORDER BY authorization_expires_at
+		LIMIT $2
+	`
+	return r.query(ctx, query, before, limit)
+}
+
+func (r *PaymentRepository) SumVolume(ctx context.Context, merchantID string, m mode.Mode, since time.Time) (float64, error) {
+	return sumVolume(ctx, r.db.Pool, merchantID, m, since)
+}
+
+// sumVolume adds completed refunds back onto settlement_amount, which they
+// reduce, to get the gross volume.
+func sumVolume(ctx context.Context, db rowQuerier, merchantID string, m mode.Mode, since time.Time) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(p.settlement_amount + COALESCE((
+			SELECT SUM(r.settlement_amount)
+			FROM refunds r
+			WHERE r.payment_id = p.id AND r.status = 'completed'
+		), 0)), 0)
+		FROM payments p
+		WHERE p.merchant_id = $1 AND p.mode = $2 AND p.created_at >= $3 AND p.status IN ('pending', 'processing', 'authorized', 'voiding', 'completed')
+	`
+	var total float64
+	if err := db.QueryRow(ctx, query, merchantID, string(m), since).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to sum payment volume: %v", err)
+	}
+	return total, nil
+}
+
+func (r *PaymentRepository) CreateWithinVolume(ctx context.Context, p *payment.Payment, since []time.Time, check func(volumes []float64) error) error {
+	if p.ID == "" {
+		p.ID = r.uuidGenerator.Generate()
+	}
+
+	tx, err := r.db.Pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to update payment status: %v", err)
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Held until the transaction ends, so the next payment of the merchant
+	// sums the volume only once this one is committed.
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('payment_volume:' || $1))`, p.MerchantID); err != nil {
+		return fmt.Errorf("failed to lock merchant volume: %v", err)
+	}
+
+	volumes := make([]float64, len(since))
+	for i, t := range since {
+		if volumes[i], err = sumVolume(ctx, tx, p.MerchantID, p.Mode, t); err != nil {
+			return err
+		}
+	}
+	if err := check(volumes); err != nil {
+		return err
+	}
+
+	if err := r.create(ctx, tx, p); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
 	}
 	return nil
 }
+
+func (r *PaymentRepository) query(ctx context.Context, query string, args ...interface{}) ([]*payment.Payment, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments: %v", err)
+	}
+	defer rows.Close()
+
+	var payments []*payment.Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payment: %v", err)
+		}
+		payments = append(payments, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payments: %v", err)
+	}
+
+	return payments, nil
+}
@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/ratelimit"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type RateLimitStore struct {
+	db *Database
+}
+
+// NewRateLimitStore returns a RateLimitStore shared by every replica using
+// the database. Each take locks the buckets' rows for its transaction.
+func NewRateLimitStore(db *Database) ports.RateLimitStore {
+	return &RateLimitStore{db: db}
+}
+
+// takeQuery creates a full bucket or refills an existing one as
+// ratelimit.TakeAll does, takes a token, and returns the tokens as refilled
+// before the take. Tokens can go below zero here; Take rolls those back.
+const takeQuery = `
+	INSERT INTO rate_limit_buckets AS b (bucket_key, tokens, updated_at)
+	VALUES ($1, $2::double precision - 1, $3)
+	ON CONFLICT (bucket_key) DO UPDATE
+	SET tokens = LEAST($2::double precision, b.tokens + GREATEST(EXTRACT(EPOCH FROM $3 - b.updated_at), 0) / $4::double precision) - 1,
+	    updated_at = GREATEST(b.updated_at, $3)
+	RETURNING tokens + 1, updated_at
+`
+
+func (s *RateLimitStore) Take(ctx context.Context, keys []ratelimit.Key, now time.Time) ([]ratelimit.Result, error) {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Rows are locked in key order, so takes sharing buckets cannot deadlock.
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return keys[order[a]].Name < keys[order[b]].Name })
+
+	limits := make([]ratelimit.Limit, len(keys))
+	buckets := make([]*ratelimit.Bucket, len(keys))
+	for _, i := range order {
+		k := keys[i]
+		var b ratelimit.Bucket
+		err := tx.QueryRow(ctx, takeQuery, k.Name, float64(k.Limit.Requests), now, k.Limit.Interval().Seconds()).
+			Scan(&b.Tokens, &b.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to take from rate limit bucket: %v", err)
+		}
+		limits[i] = k.Limit
+		buckets[i] = &b
+	}
+
+	// The buckets are already refilled, so this only decides whether the
+	// tokens taken above are kept.
+	results := ratelimit.TakeAll(limits, buckets, now)
+	for _, result := range results {
+		if !result.Allowed {
+			return results, nil
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return results, nil
+}
+
+func (s *RateLimitStore) Prune(ctx context.Context, idleSince time.Time) (int, error) {
+	tag, err := s.db.Pool.Exec(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < $1`, idleSince)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune rate limit buckets: %v", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
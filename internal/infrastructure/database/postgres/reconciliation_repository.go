@@ -0,0 +1,250 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+const reportColumns = `id, file_name, status, period_start, period_end, total_lines, matched_count, discrepancy_count,
+		       COALESCE(error, ''), created_at, completed_at`
+
+const discrepancyColumns = `id, report_id, type, record_type, COALESCE(record_id::text, ''), acquirer_reference, expected_amount,
+		       actual_amount, currency, status, COALESCE(resolution, ''), COALESCE(resolved_by::text, ''), resolved_at, created_at`
+
+type ReconciliationRepository struct {
+	db            *Database
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewReconciliationRepository(db *Database, uuidGenerator ports.UUIDGenerator) ports.ReconciliationRepository {
+	return &ReconciliationRepository{
+		db:            db,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+func scanReport(row rowScanner) (*reconciliation.Report, error) {
+	var rep reconciliation.Report
+	err := row.Scan(&rep.ID, &rep.FileName, &rep.Status, &rep.PeriodStart, &rep.PeriodEnd, &rep.TotalLines, &rep.MatchedCount,
+		&rep.DiscrepancyCount, &rep.Error, &rep.CreatedAt, &rep.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &rep, nil
+}
+
+func scanDiscrepancy(row rowScanner) (*reconciliation.Discrepancy, error) {
+	var d reconciliation.Discrepancy
+	err := row.Scan(&d.ID, &d.ReportID, &d.Type, &d.RecordType, &d.RecordID, &d.AcquirerReference, &d.ExpectedAmount,
+		&d.ActualAmount, &d.Currency, &d.Status, &d.Resolution, &d.ResolvedBy, &d.ResolvedAt, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *ReconciliationRepository) CreateReport(ctx context.Context, rep *reconciliation.Report) error {
+	if rep.ID == "" {
+		rep.ID = r.uuidGenerator.Generate()
+	}
+
+	query := `
+		INSERT INTO reconciliation_reports (id, file_name, status, period_start, period_end, total_lines, matched_count,
+		                                    discrepancy_count, error, created_at, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := r.db.Pool.Exec(ctx, query, rep.ID, rep.FileName, rep.Status, rep.PeriodStart, rep.PeriodEnd, rep.TotalLines,
+		rep.MatchedCount, rep.DiscrepancyCount, nullString(rep.Error), rep.CreatedAt, rep.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create reconciliation report: %v", err)
+	}
+	return nil
+}
+
+func (r *ReconciliationRepository) UpdateReport(ctx context.Context, rep *reconciliation.Report) error {
+	return updateReport(ctx, r.db.Pool, rep)
+}
+
+func updateReport(ctx context.Context, db execer, rep *reconciliation.Report) error {
+	query := `
+		UPDATE reconciliation_reports
+		SET status = $2, period_start = $3, period_end = $4, total_lines = $5, matched_count = $6,
+		    discrepancy_count = $7, error = $8, completed_at = $9
+		WHERE id = $1
+	`
+	_, err := db.Exec(ctx, query, rep.ID, rep.Status, rep.PeriodStart, rep.PeriodEnd, rep.TotalLines,
+		rep.MatchedCount, rep.DiscrepancyCount, nullString(rep.Error), rep.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update reconciliation report: %v", err)
+	}
+	return nil
+}
+
+func (r *ReconciliationRepository) GetReport(ctx context.Context, id string) (*reconciliation.Report, error) {
+	query := `
+		SELECT ` + reportColumns + `
+		FROM reconciliation_reports
+		WHERE id = $1
+	`
+	return r.getReport(ctx, query, id)
+}
+
+func (r *ReconciliationRepository) GetReportByFileName(ctx context.Context, fileName string) (*reconciliation.Report, error) {
+	query := `
+		SELECT ` + reportColumns + `
+		FROM reconciliation_reports
+		WHERE file_name = $1
+	`
+	return r.getReport(ctx, query, fileName)
+}
+
+func (r *ReconciliationRepository) getReport(ctx context.Context, query string, arg string) (*reconciliation.Report, error) {
+	rep, err := scanReport(r.db.Pool.QueryRow(ctx, query, arg))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, reconciliation.ErrReportNotFound
+		}
+		return nil, fmt.Errorf("failed to get reconciliation report: %v", err)
+	}
+	return rep, nil
+}
+
+func (r *ReconciliationRepository) ListReports(ctx context.Context, limit, offset int) ([]*reconciliation.Report, error) {
+	query := `
+		SELECT ` + reportColumns + `
+		FROM reconciliation_reports
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`
+	rows, err := r.db.Pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reconciliation reports: %v", err)
+	}
+	defer rows.Close()
+
+	var reports []*reconciliation.Report
+	for rows.Next() {
+		rep, err := scanReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reconciliation report: %v", err)
+		}
+		reports = append(reports, rep)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reconciliation reports: %v", err)
+	}
+
+	return reports, nil
+}
+
+func (r *ReconciliationRepository) CompleteReport(ctx context.Context, rep *reconciliation.Report, discrepancies []*reconciliation.Discrepancy) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Discrepancies of an earlier attempt are replaced, not added to.
+	if _, err := tx.Exec(ctx, `DELETE FROM reconciliation_discrepancies WHERE report_id = $1`, rep.ID); err != nil {
+		return fmt.Errorf("failed to delete discrepancies: %v", err)
+	}
+
+	for _, d := range discrepancies {
+		if err := r.createDiscrepancy(ctx, tx, d); err != nil {
+			return err
+		}
+	}
+
+	if err := updateReport(ctx, tx, rep); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (r *ReconciliationRepository) createDiscrepancy(ctx context.Context, db execer, d *reconciliation.Discrepancy) error {
+	if d.ID == "" {
+		d.ID = r.uuidGenerator.Generate()
+	}
+
+	query := `
+		INSERT INTO reconciliation_discrepancies (id, report_id, type, record_type, record_id, acquirer_reference,
+		                                          expected_amount, actual_amount, currency, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := db.Exec(ctx, query, d.ID, d.ReportID, d.Type, d.RecordType, nullString(d.RecordID), d.AcquirerReference,
+		d.ExpectedAmount, d.ActualAmount, d.Currency, d.Status, d.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create discrepancy: %v", err)
+	}
+	return nil
+}
+
+func (r *ReconciliationRepository) GetDiscrepancy(ctx context.Context, id string) (*reconciliation.Discrepancy, error) {
+	query := `
+		SELECT ` + discrepancyColumns + `
+		FROM reconciliation_discrepancies
+		WHERE id = $1
+	`
+	d, err := scanDiscrepancy(r.db.Pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("discrepancy not found")
+		}
+		return nil, fmt.Errorf("failed to get discrepancy: %v", err)
+	}
+	return d, nil
+}
+
+func (r *ReconciliationRepository) UpdateDiscrepancy(ctx context.Context, d *reconciliation.Discrepancy) error {
+	query := `
+		UPDATE reconciliation_discrepancies
+		SET status = $2, resolution = $3, resolved_by = $4, resolved_at = $5
+		WHERE id = $1
+	`
+	_, err := r.db.Pool.Exec(ctx, query, d.ID, d.Status, nullString(d.Resolution), nullString(d.ResolvedBy), d.ResolvedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update discrepancy: %v", err)
+	}
+	return nil
+}
+
+func (r *ReconciliationRepository) ListDiscrepancies(ctx context.Context, reportID string, status reconciliation.DiscrepancyStatus, limit, offset int) ([]*reconciliation.Discrepancy, error) {
+	query := `
+		SELECT ` + discrepancyColumns + `
+		FROM reconciliation_discrepancies
+		WHERE report_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := r.db.Pool.Query(ctx, query, reportID, string(status), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list discrepancies: %v", err)
+	}
+	defer rows.Close()
+
+	var discrepancies []*reconciliation.Discrepancy
+	for rows.Next() {
+		d, err := scanDiscrepancy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan discrepancy: %v", err)
+		}
+		discrepancies = append(discrepancies, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating discrepancies: %v", err)
+	}
+
+	return discrepancies, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
@@ -11,6 +12,9 @@ import (
 	"github.com/popeskul/payment-gateway/internal/core/ports"
 )
 
+const refundColumns = `id, payment_id, COALESCE(customer_id::text, ''), mode, amount, currency, settlement_amount, settlement_currency, reason, status,
+		       COALESCE(acquirer_reference, ''), processed_at, created_at, updated_at`
+
 type RefundRepository struct {
 	db            *Database
 	uuidGenerator ports.UUIDGenerator
@@ -23,17 +27,29 @@ func NewRefundRepository(db *Database, uuidGenerator ports.UUIDGenerator) ports.
 	}
 }
 
+func scanRefund(row rowScanner) (*refund.Refund, error) {
+	var ref refund.Refund
+	err := row.Scan(&ref.ID, &ref.PaymentID, &ref.CustomerID, &ref.Mode, &ref.Amount, &ref.Currency, &ref.SettlementAmount, &ref.SettlementCurrency, &ref.Reason, &ref.Status,
+		&ref.AcquirerReference, &ref.ProcessedAt, &ref.CreatedAt, &ref.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &ref, nil
+}
+
 func (r *RefundRepository) Create(ctx context.Context, ref *refund.Refund) error {
 	if ref.ID == "" {
 		ref.ID = r.uuidGenerator.Generate()
 	}
 
 	query := `
-		INSERT INTO refunds (id, payment_id, amount, reason, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO refunds (id, payment_id, customer_id, mode, amount, currency, settlement_amount, settlement_currency, reason, status,
+		                     acquirer_reference, processed_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 	_, err := r.db.Pool.Exec(ctx, query,
-		ref.ID, ref.PaymentID, ref.Amount, ref.Reason, ref.Status, ref.CreatedAt, ref.UpdatedAt)
+		ref.ID, ref.PaymentID, nullString(ref.CustomerID), string(ref.Mode), ref.Amount, ref.Currency, ref.SettlementAmount, ref.SettlementCurrency, ref.Reason, ref.Status,
+		nullString(ref.AcquirerReference), ref.ProcessedAt, ref.CreatedAt, ref.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create refund: %v", err)
 	}
@@ -42,31 +58,30 @@ func (r *RefundRepository) Create(ctx context.Context, ref *refund.Refund) error
 
 func (r *RefundRepository) GetByID(ctx context.Context, id string) (*refund.Refund, error) {
 	query := `
-		SELECT id, payment_id, amount, reason, status, created_at, updated_at
+		SELECT ` + refundColumns + `
 		FROM refunds
 		WHERE id = $1
 	`
-	var ref refund.Refund
-	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
-		&ref.ID, &ref.PaymentID, &ref.Amount, &ref.Reason, &ref.Status, &ref.CreatedAt, &ref.UpdatedAt,
-	)
+	ref, err := scanRefund(r.db.Pool.QueryRow(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("refund not found")
+			return nil, refund.ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get refund: %v", err)
 	}
-	return &ref, nil
+	return ref, nil
 }
 
 func (r *RefundRepository) Update(ctx context.Context, ref *refund.Refund) error {
 	query := `
 		UPDATE refunds
-		SET payment_id = $2, amount = $3, reason = $4, status = $5, updated_at = $6
+		SET payment_id = $2, amount = $3, currency = $4, settlement_amount = $5, settlement_currency = $6, reason = $7, status = $8,
+		    acquirer_reference = $9, processed_at = $10, updated_at = $11
 		WHERE id = $1
 	`
 	_, err := r.db.Pool.Exec(ctx, query,
-		ref.ID, ref.PaymentID, ref.Amount, ref.Reason, ref.Status, ref.UpdatedAt)
+		ref.ID, ref.PaymentID, ref.Amount, ref.Currency, ref.SettlementAmount, ref.SettlementCurrency, ref.Reason, ref.Status,
+		nullString(ref.AcquirerReference), ref.ProcessedAt, ref.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to update refund: %v", err)
 	}
@@ -75,33 +90,24 @@ func (r *RefundRepository) Update(ctx context.Context, ref *refund.Refund) error
 
 func (r *RefundRepository) List(ctx context.Context, paymentID string, limit, offset int) ([]*refund.Refund, error) {
 	query := `
-		SELECT id, payment_id, amount, reason, status, created_at, updated_at
+		SELECT ` + refundColumns + `
 		FROM refunds
 		WHERE payment_id = $1
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`
-	rows, err := r.db.Pool.Query(ctx, query, paymentID, limit, offset)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list refunds: %v", err)
-	}
-	defer rows.Close()
-
-	var refunds []*refund.Refund
-	for rows.Next() {
-		var ref refund.Refund
-		err := rows.Scan(&ref.ID, &ref.PaymentID, &ref.Amount, &ref.Reason, &ref.Status, &ref.CreatedAt, &ref.UpdatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan refund: %v", err)
-		}
-		refunds = append(refunds, &ref)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating refunds: %v", err)
-	}
+	return r.query(ctx, query, paymentID, limit, offset)
+}
 
-	return refunds, nil
+func (r *RefundRepository) ListByMerchant(ctx context.Context, merchantID string, limit, offset int) ([]*refund.Refund, error) {
+	query := `
+		SELECT ` + refundColumns + `
+		FROM refunds
+		WHERE payment_id IN (SELECT id FROM payments WHERE merchant_id = $1)
+		ORDER BY created_at ASC, id ASC
+		LIMIT $2 OFFSET $3
+	`
+	return r.query(ctx, query, merchantID, limit, offset)
 }
 
 func (r *RefundRepository) UpdateStatus(ctx context.Context, id string, status refund.RefundStatus) error {
@@ -117,6 +123,22 @@ func (r *RefundRepository) UpdateStatus(ctx context.Context, id string, status r
 	return nil
 }
 
+func (r *RefundRepository) TransitionStatus(ctx context.Context, id string, from, to refund.RefundStatus) error {
+	query := `
+		UPDATE refunds
+		SET status = $3, updated_at = NOW()
+		WHERE id = $1 AND status = $2
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, id, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to update refund status: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("refund is not in %s status", from)
+	}
+	return nil
+}
+
 func (r *RefundRepository) UpdateWithTransaction(ctx context.Context, ref *refund.Refund, p *payment.Payment) error {
 	tx, err := r.db.Pool.Begin(ctx)
 	if err != nil {
@@ -126,20 +148,23 @@ func (r *RefundRepository) UpdateWithTransaction(ctx context.Context, ref *refun
 
 	refundQuery := `
 		UPDATE refunds
-		SET status = $2, updated_at = $3
-		WHERE id = $1
+		SET status = $2, acquirer_reference = $3, processed_at = $4, updated_at = $5
+		WHERE id = $1 AND status = 'processing'
 	`
-	_, err = tx.Exec(ctx, refundQuery, ref.ID, ref.Status, ref.UpdatedAt)
+	tag, err := tx.Exec(ctx, refundQuery, ref.ID, ref.Status, nullString(ref.AcquirerReference), ref.ProcessedAt, ref.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to update refund in transaction: %v", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("refund is not in processing status")
+	}
 
 	paymentQuery := `
 		UPDATE payments
-		SET amount = $2, updated_at = $3
+		SET amount = $2, settlement_amount = $3, updated_at = $4
 		WHERE id = $1
 	`
-	_, err = tx.Exec(ctx, paymentQuery, p.ID, p.Amount, p.UpdatedAt)
+	_, err = tx.Exec(ctx, paymentQuery, p.ID, p.Amount, p.SettlementAmount, p.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to update payment in transaction: %v", err)
 	}
@@ -151,3 +176,65 @@ func (r *RefundRepository) UpdateWithTransaction(ctx context.Context, ref *refun
 
 	return nil
 }
+
+func (r *RefundRepository) GetByAcquirerReference(ctx context.Context, reference string) (*refund.Refund, error) {
+	query := `
+		SELECT ` + refundColumns + `
+		FROM refunds
+		WHERE acquirer_reference = $1 AND mode = 'live'
+	`
+	ref, err := scanRefund(r.db.Pool.QueryRow(ctx, query, reference))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, refund.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get refund: %v", err)
+	}
+	return ref, nil
+}
+
+func (r *RefundRepository) ListProcessedBetween(ctx context.Context, from, to time.Time) ([]*refund.Refund, error) {
+	query := `
+		SELECT ` + refundColumns + `
+		FROM refunds
+		WHERE processed_at BETWEEN $1 AND $2 AND acquirer_reference IS NOT NULL AND mode = 'live'
+		ORDER BY processed_at
+	`
+	return r.query(ctx, query, from, to)
+}
+
+func (r *RefundRepository) TotalByPayment(ctx context.Context, paymentID string, status refund.RefundStatus) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM refunds
+		WHERE payment_id = $1 AND status = $2
+	`
+	var total float64
+	if err := r.db.Pool.QueryRow(ctx, query, paymentID, status).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to sum refunds: %v", err)
+	}
+	return total, nil
+}
+
+func (r *RefundRepository) query(ctx context.Context, query string, args ...interface{}) ([]*refund.Refund, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refunds: %v", err)
+	}
+	defer rows.Close()
+
+	var refunds []*refund.Refund
+	for rows.Next() {
+		ref, err := scanRefund(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan refund: %v", err)
+		}
+		refunds = append(refunds, ref)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating refunds: %v", err)
+	}
+
+	return refunds, nil
+}
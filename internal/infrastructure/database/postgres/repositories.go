@@ -0,0 +1,48 @@
+package postgres
+
+import "github.com/popeskul/payment-gateway/internal/core/ports"
+
+type repositories struct {
+	merchants       ports.MerchantRepository
+	payments        ports.PaymentRepository
+	refunds         ports.RefundRepository
+	users           ports.UserRepository
+	reconciliations ports.ReconciliationRepository
+	subscriptions   ports.SubscriptionRepository
+	customers       ports.CustomerRepository
+	members         ports.MemberRepository
+	apiKeys         ports.APIKeyRepository
+	onboarding      ports.OnboardingRepository
+	auditLog        ports.AuditRepository
+	oauthClients    ports.OAuthClientRepository
+}
+
+func NewRepositories(db *Database, uuidGenerator ports.UUIDGenerator) ports.Repositories {
+	return &repositories{
+		merchants:       NewMerchantRepository(db, uuidGenerator),
+		payments:        NewPaymentRepository(db, uuidGenerator),
+		refunds:         NewRefundRepository(db, uuidGenerator),
+		users:           NewUserRepository(db, uuidGenerator),
+		reconciliations: NewReconciliationRepository(db, uuidGenerator),
+		subscriptions:   NewSubscriptionRepository(db, uuidGenerator),
+		customers:       NewCustomerRepository(db, uuidGenerator),
+		members:         NewMemberRepository(db),
+		apiKeys:         NewAPIKeyRepository(db, uuidGenerator),
+		onboarding:      NewOnboardingRepository(db, uuidGenerator),
+		auditLog:        NewAuditRepository(db, uuidGenerator),
+		oauthClients:    NewOAuthClientRepository(db, uuidGenerator),
+	}
+}
+
+func (r *repositories) Merchants() ports.MerchantRepository             { return r.merchants }
+func (r *repositories) Payments() ports.PaymentRepository               { return r.payments }
+func (r *repositories) Refunds() ports.RefundRepository                 { return r.refunds }
+func (r *repositories) Users() ports.UserRepository                     { return r.users }
+func (r *repositories) Reconciliations() ports.ReconciliationRepository { return r.reconciliations }
+func (r *repositories) Subscriptions() ports.SubscriptionRepository     { return r.subscriptions }
+func (r *repositories) Customers() ports.CustomerRepository             { return r.customers }
+func (r *repositories) Members() ports.MemberRepository                 { return r.members }
+func (r *repositories) APIKeys() ports.APIKeyRepository                 { return r.apiKeys }
+func (r *repositories) Onboarding() ports.OnboardingRepository          { return r.onboarding }
+func (r *repositories) AuditLog() ports.AuditRepository                 { return r.auditLog }
+func (r *repositories) OAuthClients() ports.OAuthClientRepository       { return r.oauthClients }
@@ -0,0 +1,325 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/subscription"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+const planColumns = `id, merchant_id, mode, name, amount, currency, interval, interval_count, trial_period_days, active, created_at, updated_at`
+
+const subscriptionColumns = `id, merchant_id, mode, plan_id, payment_method, COALESCE(payment_token, ''), status, current_period_start,
+		       current_period_end, trial_end, cancel_at_period_end, canceled_at, proration_balance, failed_attempts,
+		       next_retry_at, billing_anchor_day, created_at, updated_at`
+
+type SubscriptionRepository struct {
+	db            *Database
+	uuidGenerator ports.UUIDGenerator
+}
+
+func NewSubscriptionRepository(db *Database, uuidGenerator ports.UUIDGenerator) ports.SubscriptionRepository {
+	return &SubscriptionRepository{
+		db:            db,
+		uuidGenerator: uuidGenerator,
+	}
+}
+
+func scanPlan(row rowScanner) (*subscription.Plan, error) {
+	var p subscription.Plan
+	err := row.Scan(&p.ID, &p.MerchantID, &p.Mode, &p.Name, &p.Amount, &p.Currency, &p.Interval, &p.IntervalCount, &p.TrialPeriodDays,
+		&p.Active, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func scanSubscription(row rowScanner) (*subscription.Subscription, error) {
+	var s subscription.Subscription
+	err := row.Scan(&s.ID, &s.MerchantID, &s.Mode, &s.PlanID, &s.PaymentMethod, &s.PaymentToken, &s.Status, &s.CurrentPeriodStart,
+		&s.CurrentPeriodEnd, &s.TrialEnd, &s.CancelAtPeriodEnd, &s.CanceledAt, &s.ProrationBalance, &s.FailedAttempts,
+		&s.NextRetryAt, &s.BillingAnchorDay, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *SubscriptionRepository) CreatePlan(ctx context.Context, p *subscription.Plan) error {
+	if p.ID == "" {
+		p.ID = r.uuidGenerator.Generate()
+	}
+
+	query := `
+		INSERT INTO plans (` + planColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+	_, err := r.db.Pool.Exec(ctx, query, p.ID, p.MerchantID, string(p.Mode), p.Name, p.Amount, p.Currency, p.Interval, p.IntervalCount,
+		p.TrialPeriodDays, p.Active, p.CreatedAt, p.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create plan: %v", err)
+	}
+	return nil
+}
+
+func (r *SubscriptionRepository) GetPlan(ctx context.Context, id string) (*subscription.Plan, error) {
+	query := `
+		SELECT ` + planColumns + `
+		FROM plans
+		WHERE id = $1
+	`
+	p, err := scanPlan(r.db.Pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("plan not found")
+		}
+		return nil, fmt.Errorf("failed to get plan: %v", err)
+	}
+	return p, nil
+}
+
+func (r *SubscriptionRepository) UpdatePlan(ctx context.Context, p *subscription.Plan) error {
+	query := `
+		UPDATE plans
+		SET name = $2, active = $3, updated_at = $4
+		WHERE id = $1
+	`
+	_, err := r.db.Pool.Exec(ctx, query, p.ID, p.Name, p.Active, p.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update plan: %v", err)
+	}
+	return nil
+}
+
+func (r *SubscriptionRepository) ListPlans(ctx context.Context, merchantID string, m mode.Mode, limit, offset int) ([]*subscription.Plan, error) {
+	query := `
+		SELECT ` + planColumns + `
+		FROM plans
+		WHERE merchant_id = $1 AND ($2 = '' OR mode = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := r.db.Pool.Query(ctx, query, merchantID, string(m), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plans: %v", err)
+	}
+	defer rows.Close()
+
+	var plans []*subscription.Plan
+	for rows.Next() {
+		p, err := scanPlan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan plan: %v", err)
+		}
+		plans = append(plans, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating plans: %v", err)
+	}
+
+	return plans, nil
+}
+
+func (r *SubscriptionRepository) Create(ctx context.Context, s *subscription.Subscription) error {
+	if s.ID == "" {
+		s.ID = r.uuidGenerator.Generate()
+	}
+
+	query := `
+		INSERT INTO subscriptions (id, merchant_id, mode, plan_id, payment_method, payment_token, status, current_period_start,
+		                           current_period_end, trial_end, cancel_at_period_end, canceled_at, proration_balance,
+		                           failed_attempts, next_retry_at, billing_anchor_day, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	`
+	_, err := r.db.Pool.Exec(ctx, query, s.ID, s.MerchantID, string(s.Mode), s.PlanID, s.PaymentMethod, nullString(s.PaymentToken), s.Status,
+		s.CurrentPeriodStart, s.CurrentPeriodEnd, s.TrialEnd, s.CancelAtPeriodEnd, s.CanceledAt, s.ProrationBalance,
+		s.FailedAttempts, s.NextRetryAt, s.BillingAnchorDay, s.CreatedAt, s.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create subscription: %v", err)
+	}
+	return nil
+}
+
+func (r *SubscriptionRepository) GetByID(ctx context.Context, id string) (*subscription.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE id = $1
+	`
+	s, err := scanSubscription(r.db.Pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("subscription not found")
+		}
+		return nil, fmt.Errorf("failed to get subscription: %v", err)
+	}
+	return s, nil
+}
+
+func (r *SubscriptionRepository) Update(ctx context.Context, s *subscription.Subscription) error {
+	query := `
+		UPDATE subscriptions
+		SET plan_id = $2, payment_method = $3, payment_token = $4, status = $5, current_period_start = $6,
+		    current_period_end = $7, trial_end = $8, cancel_at_period_end = $9, canceled_at = $10,
+		    proration_balance = $11, failed_attempts = $12, next_retry_at = $13, updated_at = $14
+		WHERE id = $1
+	`
+	_, err := r.db.Pool.Exec(ctx, query, s.ID, s.PlanID, s.PaymentMethod, nullString(s.PaymentToken), s.Status,
+		s.CurrentPeriodStart, s.CurrentPeriodEnd, s.TrialEnd, s.CancelAtPeriodEnd, s.CanceledAt, s.ProrationBalance,
+		s.FailedAttempts, s.NextRetryAt, s.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update subscription: %v", err)
+	}
+	return nil
+}
+
+func (r *SubscriptionRepository) List(ctx context.Context, merchantID string, m mode.Mode, limit, offset int) ([]*subscription.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE merchant_id = $1 AND ($2 = '' OR mode = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	return r.query(ctx, query, merchantID, string(m), limit, offset)
+}
+
+func (r *SubscriptionRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*subscription.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE (status IN ('active', 'trialing') AND current_period_end <= $1)
+		   OR (status = 'past_due' AND next_retry_at <= $1)
+		ORDER BY current_period_end
+		LIMIT $2
+	`
+	return r.query(ctx, query, now, limit)
+}
+
+func (r *SubscriptionRepository) CancelByMerchant(ctx context.Context, merchantID string, at time.Time, message string) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	cancelQuery := `
+		UPDATE subscriptions
+		SET status = 'canceled', canceled_at = $2, next_retry_at = NULL, updated_at = $2
+		WHERE merchant_id = $1 AND status <> 'canceled'
+		RETURNING id
+	`
+	rows, err := tx.Query(ctx, cancelQuery, merchantID, at)
+	if err != nil {
+		return fmt.Errorf("failed to cancel subscriptions: %v", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan subscription id: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to cancel subscriptions: %v", err)
+	}
+
+	eventQuery := `
+		INSERT INTO subscription_events (id, subscription_id, merchant_id, type, message, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	for _, id := range ids {
+		_, err := tx.Exec(ctx, eventQuery, r.uuidGenerator.Generate(), id, merchantID, subscription.EventCanceled,
+			nullString(message), at)
+		if err != nil {
+			return fmt.Errorf("failed to create subscription event: %v", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepository) query(ctx context.Context, query string, args ...interface{}) ([]*subscription.Subscription, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %v", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*subscription.Subscription
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %v", err)
+		}
+		subscriptions = append(subscriptions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating subscriptions: %v", err)
+	}
+
+	return subscriptions, nil
+}
+
+func (r *SubscriptionRepository) CreateEvent(ctx context.Context, e *subscription.Event) error {
+	if e.ID == "" {
+		e.ID = r.uuidGenerator.Generate()
+	}
+
+	query := `
+		INSERT INTO subscription_events (id, subscription_id, merchant_id, type, payment_id, message, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := r.db.Pool.Exec(ctx, query, e.ID, e.SubscriptionID, e.MerchantID, e.Type, nullString(e.PaymentID),
+		nullString(e.Message), e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create subscription event: %v", err)
+	}
+	return nil
+}
+
+func (r *SubscriptionRepository) ListEvents(ctx context.Context, subscriptionID string, limit, offset int) ([]*subscription.Event, error) {
+	query := `
+		SELECT id, subscription_id, merchant_id, type, COALESCE(payment_id::text, ''), COALESCE(message, ''), created_at
+		FROM subscription_events
+		WHERE subscription_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.Pool.Query(ctx, query, subscriptionID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscription events: %v", err)
+	}
+	defer rows.Close()
+
+	var events []*subscription.Event
+	for rows.Next() {
+		var e subscription.Event
+		err := rows.Scan(&e.ID, &e.SubscriptionID, &e.MerchantID, &e.Type, &e.PaymentID, &e.Message, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription event: %v", err)
+		}
+		events = append(events, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating subscription events: %v", err)
+	}
+
+	return events, nil
+}
@@ -2,10 +2,14 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/domain"
 )
 
 type PostgresTokenStore struct {
@@ -16,34 +20,222 @@ func NewPostgresTokenStore(pool *pgxpool.Pool) ports.TokenStore {
 	return &PostgresTokenStore{pool: pool}
 }
 
-func (s *PostgresTokenStore) StoreRefreshToken(userID string, token string) error {
+func (s *PostgresTokenStore) StoreRefreshToken(token *domain.RefreshToken) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	_, err := s.pool.Exec(ctx,
-		"INSERT INTO refresh_tokens (user_id, token, expires_at) VALUES ($1, $2, $3)",
-		userID, token, time.Now().Add(7*24*time.Hour)) // token valid for 7 days
-	return err
+		"INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)",
+		token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store refresh token: %v", err)
+	}
+	return nil
 }
 
-func (s *PostgresTokenStore) DeleteRefreshToken(userID string, token string) error {
+func (s *PostgresTokenStore) GetRefreshToken(tokenHash string) (*domain.RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var t domain.RefreshToken
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, user_id, family_id, token_hash, expires_at, rotated_at, revoked_at, created_at
+		 FROM refresh_tokens WHERE token_hash = $1`,
+		tokenHash).Scan(&t.ID, &t.UserID, &t.FamilyID, &t.TokenHash, &t.ExpiresAt, &t.RotatedAt, &t.RevokedAt, &t.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("refresh token not found")
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %v", err)
+	}
+	return &t, nil
+}
+
+func (s *PostgresTokenStore) RotateRefreshToken(tokenHash string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tag, err := s.pool.Exec(ctx,
+		"UPDATE refresh_tokens SET rotated_at = NOW() WHERE token_hash = $1 AND rotated_at IS NULL AND revoked_at IS NULL",
+		tokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to rotate refresh token: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrRefreshTokenReused
+	}
+	return nil
+}
+
+func (s *PostgresTokenStore) RevokeTokenFamily(familyID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		"UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL",
+		familyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %v", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL",
+		familyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %v", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+const sessionColumns = "id, user_id, device, user_agent, ip, created_at, last_used_at, expires_at, revoked_at"
+
+func scanSession(row rowScanner) (*domain.Session, error) {
+	var session domain.Session
+	err := row.Scan(&session.ID, &session.UserID, &session.Device, &session.UserAgent, &session.IP,
+		&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &session.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *PostgresTokenStore) CreateSession(session *domain.Session) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	_, err := s.pool.Exec(ctx,
-		"DELETE FROM refresh_tokens WHERE user_id = $1 AND token = $2",
-		userID, token)
-	return err
+		"INSERT INTO sessions ("+sessionColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		session.ID, session.UserID, session.Device, session.UserAgent, session.IP,
+		session.CreatedAt, session.LastUsedAt, session.ExpiresAt, session.RevokedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %v", err)
+	}
+	return nil
 }
 
-func (s *PostgresTokenStore) IsRefreshTokenValid(userID string, token string) bool {
+func (s *PostgresTokenStore) GetSession(sessionID string) (*domain.Session, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	var count int
-	err := s.pool.QueryRow(ctx,
-		"SELECT COUNT(*) FROM refresh_tokens WHERE user_id = $1 AND token = $2 AND expires_at > NOW()",
-		userID, token).Scan(&count)
+	session, err := scanSession(s.pool.QueryRow(ctx,
+		"SELECT "+sessionColumns+" FROM sessions WHERE id::text = $1", sessionID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %v", err)
+	}
+	return session, nil
+}
+
+func (s *PostgresTokenStore) TouchSession(sessionID, ip string, lastUsedAt, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.pool.Exec(ctx,
+		"UPDATE sessions SET ip = $2, last_used_at = $3, expires_at = $4 WHERE id = $1",
+		sessionID, ip, lastUsedAt, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %v", err)
+	}
+	return nil
+}
+
+func (s *PostgresTokenStore) ListSessions(userID string) ([]*domain.Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+sessionColumns+` FROM sessions
+		 WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		 ORDER BY last_used_at DESC`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %v", err)
+	}
+	defer rows.Close()
+
+	sessions := []*domain.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %v", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (s *PostgresTokenStore) RevokeOtherSessions(userID, keepSessionID string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx,
+		`UPDATE sessions SET revoked_at = NOW()
+		 WHERE user_id = $1 AND id::text <> $2 AND revoked_at IS NULL
+		 RETURNING id`,
+		userID, keepSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %v", err)
+	}
+
+	revoked := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan session id: %v", err)
+		}
+		revoked = append(revoked, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %v", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id::text = ANY($1) AND revoked_at IS NULL",
+		revoked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke refresh tokens: %v", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return revoked, nil
+}
+
+func (s *PostgresTokenStore) ListRevokedSessions(since time.Time) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := s.pool.Query(ctx, "SELECT id FROM sessions WHERE revoked_at >= $1", since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revoked sessions: %v", err)
+	}
+	defer rows.Close()
 
-	return err == nil && count > 0
+	var revoked []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan session id: %v", err)
+		}
+		revoked = append(revoked, id)
+	}
+	return revoked, rows.Err()
 }
@@ -3,6 +3,8 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/popeskul/payment-gateway/internal/core/domain/user"
@@ -40,13 +42,15 @@ func (r *UserRepository) Create(ctx context.Context, u *user.User) error {
 
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*user.User, error) {
 	query := `
-		SELECT id, email, password_hash, first_name, last_name, created_at, updated_at
+		SELECT id, email, password_hash, first_name, last_name, is_staff, email_verified_at, totp_secret,
+		       two_factor_enabled_at, totp_last_counter, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
 	var u user.User
 	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
-		&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.CreatedAt, &u.UpdatedAt,
+		&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.Staff, &u.EmailVerifiedAt, &u.TOTPSecret,
+		&u.TwoFactorEnabledAt, &u.TOTPLastCounter, &u.CreatedAt, &u.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -59,13 +63,15 @@ func (r *UserRepository) GetByID(ctx context.Context, id string) (*user.User, er
 
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*user.User, error) {
 	query := `
-		SELECT id, email, password_hash, first_name, last_name, created_at, updated_at
+		SELECT id, email, password_hash, first_name, last_name, is_staff, email_verified_at, totp_secret,
+		       two_factor_enabled_at, totp_last_counter, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
 	var u user.User
 	err := r.db.Pool.QueryRow(ctx, query, email).Scan(
-		&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.CreatedAt, &u.UpdatedAt,
+		&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.Staff, &u.EmailVerifiedAt, &u.TOTPSecret,
+		&u.TwoFactorEnabledAt, &u.TOTPLastCounter, &u.CreatedAt, &u.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -79,13 +85,174 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*user.Us
 func (r *UserRepository) Update(ctx context.Context, u *user.User) error {
 	query := `
 		UPDATE users
-		SET email = $2, password_hash = $3, first_name = $4, last_name = $5, updated_at = $6
+		SET email = $2, password_hash = $3, first_name = $4, last_name = $5, email_verified_at = $6, totp_secret = $7,
+		    two_factor_enabled_at = $8, updated_at = $9
 		WHERE id = $1
 	`
 	_, err := r.db.Pool.Exec(ctx, query,
-		u.ID, u.Email, u.PasswordHash, u.FirstName, u.LastName, u.UpdatedAt)
+		u.ID, u.Email, u.PasswordHash, u.FirstName, u.LastName, u.EmailVerifiedAt, u.TOTPSecret, u.TwoFactorEnabledAt, u.UpdatedAt)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+func (r *UserRepository) CreateToken(ctx context.Context, t *user.Token) error {
+	if t.ID == "" {
+		t.ID = r.uuidGenerator.Generate()
+	}
+
+	query := `
+		INSERT INTO user_tokens (id, user_id, purpose, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.db.Pool.Exec(ctx, query, t.ID, t.UserID, string(t.Purpose), t.Hash, t.ExpiresAt, t.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create user token: %v", err)
+	}
+	return nil
+}
+
+func (r *UserRepository) GetTokenByHash(ctx context.Context, hash string) (*user.Token, error) {
+	query := `
+		SELECT id, user_id, purpose, token_hash, expires_at, used_at, created_at
+		FROM user_tokens
+		WHERE token_hash = $1
+	`
+	var t user.Token
+	err := r.db.Pool.QueryRow(ctx, query, hash).Scan(&t.ID, &t.UserID, &t.Purpose, &t.Hash, &t.ExpiresAt, &t.UsedAt, &t.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("user token not found")
+		}
+		return nil, fmt.Errorf("failed to get user token: %v", err)
+	}
+	return &t, nil
+}
+
+func (r *UserRepository) UseToken(ctx context.Context, id string, at time.Time) error {
+	tag, err := r.db.Pool.Exec(ctx, `UPDATE user_tokens SET used_at = $2 WHERE id = $1 AND used_at IS NULL`, id, at)
+	if err != nil {
+		return fmt.Errorf("failed to use user token: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("user token already used")
+	}
+	return nil
+}
+
+func (r *UserRepository) InvalidateTokens(ctx context.Context, userID string, purpose user.TokenPurpose, at time.Time) error {
+	query := `UPDATE user_tokens SET used_at = $3 WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`
+	if _, err := r.db.Pool.Exec(ctx, query, userID, string(purpose), at); err != nil {
+		return fmt.Errorf("failed to invalidate user tokens: %v", err)
+	}
+	return nil
+}
+
+func (r *UserRepository) AdvanceTOTPCounter(ctx context.Context, userID string, counter int64) error {
+	query := `UPDATE users SET totp_last_counter = $2 WHERE id = $1 AND totp_last_counter < $2`
+	tag, err := r.db.Pool.Exec(ctx, query, userID, counter)
+	if err != nil {
+		return fmt.Errorf("failed to update totp counter: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("totp code already used")
+	}
+	return nil
+}
+
+func (r *UserRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string, at time.Time) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %v", err)
+	}
+
+	for _, hash := range hashes {
+		query := `
+			INSERT INTO user_recovery_codes (id, user_id, code_hash, created_at)
+			VALUES ($1, $2, $3, $4)
+		`
+		if _, err := tx.Exec(ctx, query, r.uuidGenerator.Generate(), userID, hash, at); err != nil {
+			return fmt.Errorf("failed to create recovery code: %v", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+func (r *UserRepository) UseRecoveryCode(ctx context.Context, userID, hash string, at time.Time) error {
+	query := `UPDATE user_recovery_codes SET used_at = $3 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	tag, err := r.db.Pool.Exec(ctx, query, userID, hash, at)
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("recovery code not found or already used")
+	}
+	return nil
+}
+
+func scanLoginThrottle(row rowScanner) (*user.LoginThrottle, error) {
+	var t user.LoginThrottle
+	if err := row.Scan(&t.Scope, &t.Key, &t.Failures, &t.LastFailedAt, &t.LockedUntil); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *UserRepository) GetLoginThrottle(ctx context.Context, scope user.ThrottleScope, key string) (*user.LoginThrottle, error) {
+	query := `
+		SELECT scope, throttle_key, failures, last_failed_at, locked_until
+		FROM login_throttles
+		WHERE scope = $1 AND throttle_key = $2
+	`
+	t, err := scanLoginThrottle(r.db.Pool.QueryRow(ctx, query, string(scope), key))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get login throttle: %v", err)
+	}
+	return t, nil
+}
+
+func (r *UserRepository) RecordLoginFailure(ctx context.Context, scope user.ThrottleScope, key string, at time.Time, window time.Duration) (*user.LoginThrottle, error) {
+	query := `
+		INSERT INTO login_throttles (scope, throttle_key, failures, last_failed_at)
+		VALUES ($1, $2, 1, $3)
+		ON CONFLICT (scope, throttle_key) DO UPDATE SET
+			failures = CASE WHEN login_throttles.last_failed_at < $4 THEN 1 ELSE login_throttles.failures + 1 END,
+			locked_until = CASE WHEN login_throttles.last_failed_at < $4 THEN NULL ELSE login_throttles.locked_until END,
+			last_failed_at = $3
+		RETURNING scope, throttle_key, failures, last_failed_at, locked_until
+	`
+	t, err := scanLoginThrottle(r.db.Pool.QueryRow(ctx, query, string(scope), key, at, at.Add(-window)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record login failure: %v", err)
+	}
+	return t, nil
+}
+
+func (r *UserRepository) LockLogin(ctx context.Context, scope user.ThrottleScope, key string, until time.Time) error {
+	query := `UPDATE login_throttles SET locked_until = $3 WHERE scope = $1 AND throttle_key = $2`
+	if _, err := r.db.Pool.Exec(ctx, query, string(scope), key, until); err != nil {
+		return fmt.Errorf("failed to lock login: %v", err)
+	}
+	return nil
+}
+
+func (r *UserRepository) ClearLoginThrottle(ctx context.Context, scope user.ThrottleScope, key string) error {
+	query := `DELETE FROM login_throttles WHERE scope = $1 AND throttle_key = $2`
+	if _, err := r.db.Pool.Exec(ctx, query, string(scope), key); err != nil {
+		return fmt.Errorf("failed to clear login throttle: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+)
+
+func testAPIKeys(t *testing.T, f *fixtures) {
+	repo := f.Repositories.APIKeys()
+
+	newKey := func(merchantID string, createdAt time.Time) *apikey.APIKey {
+		return &apikey.APIKey{
+			MerchantID: merchantID,
+			Mode:       mode.Live,
+			Name:       "Server",
+			Prefix:     "pk_live_abc",
+			Hash:       newHash(),
+			Scopes:     []apikey.Scope{apikey.ScopePaymentsWrite, apikey.ScopeReadOnly},
+			CreatedAt:  createdAt,
+		}
+	}
+
+	t.Run("hash is unique", func(t *testing.T) {
+		m := f.merchant(t)
+		assert.Error(t, repo.Create(f.ctx, newKey(newID(), f.now)))
+
+		k := newKey(m.ID, f.now)
+		k.CreatedBy = f.user(t).ID
+		require.NoError(t, repo.Create(f.ctx, k))
+
+		got, err := repo.GetByHash(f.ctx, k.Hash)
+		require.NoError(t, err)
+		assert.Equal(t, k.ID, got.ID)
+		assert.Equal(t, k.Scopes, got.Scopes)
+		assert.Equal(t, k.CreatedBy, got.CreatedBy)
+
+		duplicate := newKey(m.ID, f.now)
+		duplicate.Hash = k.Hash
+		assert.Error(t, repo.Create(f.ctx, duplicate))
+	})
+
+	t.Run("rotate links the old key to its replacement", func(t *testing.T) {
+		m := f.merchant(t)
+		old := newKey(m.ID, f.now)
+		require.NoError(t, repo.Create(f.ctx, old))
+
+		taken := newKey(m.ID, f.now)
+		require.NoError(t, repo.Create(f.ctx, taken))
+
+		expiresAt := f.now.Add(time.Hour)
+		old.ExpiresAt = &expiresAt
+		failed := newKey(m.ID, f.now)
+		failed.Hash = taken.Hash
+		assert.Error(t, repo.Rotate(f.ctx, old, failed))
+
+		got, err := repo.GetByID(f.ctx, old.ID)
+		require.NoError(t, err)
+		assert.Nil(t, got.ExpiresAt)
+		assert.Empty(t, got.RotatedTo)
+
+		replacement := newKey(m.ID, f.now.Add(time.Minute))
+		require.NoError(t, repo.Rotate(f.ctx, old, replacement))
+
+		got, err = repo.GetByID(f.ctx, old.ID)
+		require.NoError(t, err)
+		assert.Equal(t, replacement.ID, got.RotatedTo)
+		require.NotNil(t, got.ExpiresAt)
+		assert.True(t, got.ExpiresAt.Equal(expiresAt))
+
+		keys, err := repo.ListByMerchant(f.ctx, m.ID)
+		require.NoError(t, err)
+		require.Len(t, keys, 3)
+		assert.Equal(t, replacement.ID, keys[0].ID)
+	})
+
+	t.Run("revoke by merchant keeps earlier revocations", func(t *testing.T) {
+		m := f.merchant(t)
+		revoked := newKey(m.ID, f.now)
+		active := newKey(m.ID, f.now)
+		require.NoError(t, repo.Create(f.ctx, revoked))
+		require.NoError(t, repo.Create(f.ctx, active))
+
+		earlier := f.now.Add(-time.Hour)
+		revoked.RevokedAt = &earlier
+		require.NoError(t, repo.Update(f.ctx, revoked))
+		require.NoError(t, repo.TouchLastUsed(f.ctx, active.ID, f.now))
+
+		require.NoError(t, repo.RevokeByMerchant(f.ctx, m.ID, f.now))
+
+		got, err := repo.GetByID(f.ctx, revoked.ID)
+		require.NoError(t, err)
+		require.NotNil(t, got.RevokedAt)
+		assert.True(t, got.RevokedAt.Equal(earlier))
+
+		got, err = repo.GetByID(f.ctx, active.ID)
+		require.NoError(t, err)
+		require.NotNil(t, got.RevokedAt)
+		assert.True(t, got.RevokedAt.Equal(f.now))
+		require.NotNil(t, got.LastUsedAt)
+		assert.True(t, got.LastUsedAt.Equal(f.now))
+	})
+	t.Run("nonces are used once per key", func(t *testing.T) {
+		m := f.merchant(t)
+		k := newKey(m.ID, f.now)
+		k.RequireSignature = true
+		other := newKey(m.ID, f.now)
+		require.NoError(t, repo.Create(f.ctx, k))
+		require.NoError(t, repo.Create(f.ctx, other))
+
+		got, err := repo.GetByID(f.ctx, k.ID)
+		require.NoError(t, err)
+		assert.True(t, got.RequireSignature)
+
+		_, err = repo.UseNonce(f.ctx, newID(), "nonce", f.now)
+		assert.Error(t, err)
+
+		fresh, err := repo.UseNonce(f.ctx, k.ID, "nonce", f.now.Add(-time.Minute))
+		require.NoError(t, err)
+		assert.True(t, fresh)
+
+		fresh, err = repo.UseNonce(f.ctx, k.ID, "nonce", f.now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.False(t, fresh)
+
+		fresh, err = repo.UseNonce(f.ctx, other.ID, "nonce", f.now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.True(t, fresh)
+
+		pruned, err := repo.PruneNonces(f.ctx, f.now)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, pruned, 1)
+
+		fresh, err = repo.UseNonce(f.ctx, k.ID, "nonce", f.now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.True(t, fresh, "an expired nonce is forgotten once pruned")
+
+		fresh, err = repo.UseNonce(f.ctx, other.ID, "nonce", f.now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.False(t, fresh, "pruning keeps nonces that have not expired")
+	})
+}
@@ -0,0 +1,84 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/audit"
+)
+
+func testAuditLog(t *testing.T, f *fixtures) {
+	repo := f.Repositories.AuditLog()
+
+	entry := func(merchantID, action string, createdAt time.Time) *audit.Entry {
+		return &audit.Entry{
+			MerchantID: merchantID,
+			ActorType:  audit.ActorUser,
+			ActorID:    newID(),
+			IP:         "192.0.2.1",
+			RequestID:  newID(),
+			Action:     action,
+			TargetType: "merchant",
+			TargetID:   merchantID,
+			CreatedAt:  createdAt,
+		}
+	}
+
+	t.Run("entries are chained", func(t *testing.T) {
+		merchantID := newID()
+		first := entry(merchantID, "merchant.created", f.now)
+		second := entry(merchantID, "merchant.updated", f.now.Add(time.Second))
+		second.Changes = map[string]audit.Change{"name": {Before: "Acme", After: "Acme Ltd"}}
+		require.NoError(t, repo.Append(f.ctx, first))
+		require.NoError(t, repo.Append(f.ctx, second))
+
+		assert.Equal(t, first.Sequence+1, second.Sequence)
+		assert.Equal(t, first.Hash, second.PrevHash)
+
+		entries, err := repo.ListAfter(f.ctx, first.Sequence-1, 2)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, first.ID, entries[0].ID)
+		assert.Equal(t, second.ID, entries[1].ID)
+		assert.NoError(t, audit.VerifyLink(entries[0], entries[1]))
+		assert.Equal(t, second.Changes, entries[1].Changes)
+	})
+
+	t.Run("list filters and returns the newest first", func(t *testing.T) {
+		merchantID := newID()
+		older := entry(merchantID, "member.added", f.now.Add(-time.Hour))
+		newer := entry(merchantID, "member.removed", f.now)
+		require.NoError(t, repo.Append(f.ctx, older))
+		require.NoError(t, repo.Append(f.ctx, entry(newID(), "member.added", f.now)))
+		require.NoError(t, repo.Append(f.ctx, newer))
+
+		entries, err := repo.List(f.ctx, audit.Filter{MerchantID: merchantID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, newer.ID, entries[0].ID)
+		assert.Equal(t, older.ID, entries[1].ID)
+
+		from := f.now.Add(-time.Minute)
+		entries, err = repo.List(f.ctx, audit.Filter{MerchantID: merchantID, From: &from, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, newer.ID, entries[0].ID)
+
+		entries, err = repo.List(f.ctx, audit.Filter{ActorID: older.ActorID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, older.ID, entries[0].ID)
+	})
+
+	t.Run("id is unique", func(t *testing.T) {
+		e := entry(newID(), "merchant.created", f.now)
+		require.NoError(t, repo.Append(f.ctx, e))
+
+		duplicate := entry(e.MerchantID, "merchant.updated", f.now)
+		duplicate.ID = e.ID
+		assert.Error(t, repo.Append(f.ctx, duplicate))
+	})
+}
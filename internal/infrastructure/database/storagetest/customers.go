@@ -0,0 +1,191 @@
+package storagetest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+)
+
+func testCustomers(t *testing.T, f *fixtures) {
+	repo := f.Repositories.Customers()
+
+	paymentMethod := func(customerID string, isDefault bool, createdAt time.Time) *customer.PaymentMethod {
+		return &customer.PaymentMethod{
+			CustomerID: customerID,
+			Type:       "card",
+			Token:      "tok_" + newID(),
+			Brand:      "visa",
+			Last4:      "4242",
+			ExpMonth:   12,
+			ExpYear:    2030,
+			IsDefault:  isDefault,
+			CreatedAt:  createdAt,
+		}
+	}
+
+	t.Run("email is unique per merchant", func(t *testing.T) {
+		assert.Error(t, repo.Create(f.ctx, f.newCustomer(newID())))
+
+		c := f.customer(t, f.merchant(t).ID)
+
+		duplicate := f.newCustomer(c.MerchantID)
+		duplicate.Email = c.Email
+		assert.Error(t, repo.Create(f.ctx, duplicate))
+
+		elsewhere := f.newCustomer(f.merchant(t).ID)
+		elsewhere.Email = c.Email
+		assert.NoError(t, repo.Create(f.ctx, elsewhere))
+	})
+
+	t.Run("update", func(t *testing.T) {
+		c := f.customer(t, f.merchant(t).ID)
+		c.Name = "Grace Brewster Hopper"
+		c.BillingAddress = &customer.Address{Line1: "1 Main St", City: "Arlington", PostalCode: "22201", Country: "US"}
+		c.Metadata = map[string]string{"tier": "gold"}
+		require.NoError(t, repo.Update(f.ctx, c))
+
+		got, err := repo.GetByID(f.ctx, c.ID)
+		require.NoError(t, err)
+		assert.Equal(t, c.Name, got.Name)
+		assert.Equal(t, c.BillingAddress, got.BillingAddress)
+		assert.Nil(t, got.ShippingAddress)
+		assert.Equal(t, c.Metadata, got.Metadata)
+	})
+
+	t.Run("list searches name and email without regard to case", func(t *testing.T) {
+		m := f.merchant(t)
+		older := f.newCustomer(m.ID)
+		older.Name = "Alan Turing"
+		older.CreatedAt = f.now.Add(-time.Minute)
+		require.NoError(t, repo.Create(f.ctx, older))
+		newer := f.customer(t, m.ID)
+		test := f.newCustomer(m.ID)
+		test.Mode = mode.Test
+		require.NoError(t, repo.Create(f.ctx, test))
+
+		customers, err := repo.List(f.ctx, m.ID, mode.Live, "", 10, 0)
+		require.NoError(t, err)
+		require.Len(t, customers, 2)
+		assert.Equal(t, newer.ID, customers[0].ID)
+		assert.Equal(t, older.ID, customers[1].ID)
+
+		customers, err = repo.List(f.ctx, m.ID, "", "TURING", 10, 0)
+		require.NoError(t, err)
+		require.Len(t, customers, 1)
+		assert.Equal(t, older.ID, customers[0].ID)
+
+		customers, err = repo.List(f.ctx, m.ID, "", strings.ToUpper(test.Email[:8]), 10, 0)
+		require.NoError(t, err)
+		require.Len(t, customers, 1)
+		assert.Equal(t, test.ID, customers[0].ID)
+	})
+
+	t.Run("list search matches wildcards literally", func(t *testing.T) {
+		m := f.merchant(t)
+		f.customer(t, m.ID)
+		literal := f.newCustomer(m.ID)
+		literal.Name = `100% Off_Sales\Co`
+		require.NoError(t, repo.Create(f.ctx, literal))
+
+		for _, search := range []string{"%", "_", `\`, "0% o", `s\c`} {
+			customers, err := repo.List(f.ctx, m.ID, "", search, 10, 0)
+			require.NoError(t, err)
+			require.Len(t, customers, 1, "search %q", search)
+			assert.Equal(t, literal.ID, customers[0].ID)
+		}
+	})
+
+	t.Run("a customer has at most one default payment method", func(t *testing.T) {
+		c := f.customer(t, f.merchant(t).ID)
+
+		assert.Error(t, repo.AddPaymentMethod(f.ctx, paymentMethod(newID(), false, f.now)))
+
+		first := paymentMethod(c.ID, true, f.now)
+		second := paymentMethod(c.ID, false, f.now.Add(time.Minute))
+		require.NoError(t, repo.AddPaymentMethod(f.ctx, first))
+		require.NoError(t, repo.AddPaymentMethod(f.ctx, second))
+		assert.Error(t, repo.AddPaymentMethod(f.ctx, paymentMethod(c.ID, true, f.now)))
+
+		methods, err := repo.ListPaymentMethods(f.ctx, c.ID)
+		require.NoError(t, err)
+		require.Len(t, methods, 2)
+		assert.Equal(t, first.ID, methods[0].ID)
+		assert.Equal(t, second.ID, methods[1].ID)
+
+		require.NoError(t, repo.SetDefaultPaymentMethod(f.ctx, c.ID, second.ID))
+		assert.Error(t, repo.SetDefaultPaymentMethod(f.ctx, c.ID, newID()))
+
+		methods, err = repo.ListPaymentMethods(f.ctx, c.ID)
+		require.NoError(t, err)
+		require.Len(t, methods, 2)
+		assert.Equal(t, second.ID, methods[0].ID)
+		assert.True(t, methods[0].IsDefault)
+		assert.False(t, methods[1].IsDefault)
+	})
+
+	t.Run("deleting the default payment method promotes the newest other one", func(t *testing.T) {
+		c := f.customer(t, f.merchant(t).ID)
+
+		oldest := paymentMethod(c.ID, false, f.now)
+		newest := paymentMethod(c.ID, false, f.now.Add(2*time.Minute))
+		def := paymentMethod(c.ID, true, f.now.Add(time.Minute))
+		for _, pm := range []*customer.PaymentMethod{oldest, newest, def} {
+			require.NoError(t, repo.AddPaymentMethod(f.ctx, pm))
+		}
+
+		require.NoError(t, repo.DeletePaymentMethod(f.ctx, oldest.ID))
+		got, err := repo.GetPaymentMethod(f.ctx, def.ID)
+		require.NoError(t, err)
+		assert.True(t, got.IsDefault, "deleting another method keeps the default")
+
+		require.NoError(t, repo.DeletePaymentMethod(f.ctx, def.ID))
+		got, err = repo.GetPaymentMethod(f.ctx, newest.ID)
+		require.NoError(t, err)
+		assert.True(t, got.IsDefault)
+
+		require.NoError(t, repo.DeletePaymentMethod(f.ctx, newest.ID))
+		methods, err := repo.ListPaymentMethods(f.ctx, c.ID)
+		require.NoError(t, err)
+		assert.Empty(t, methods)
+	})
+
+	t.Run("delete keeps payments and refunds but detaches them", func(t *testing.T) {
+		m := f.merchant(t)
+		c := f.customer(t, m.ID)
+		pm := paymentMethod(c.ID, true, f.now)
+		require.NoError(t, repo.AddPaymentMethod(f.ctx, pm))
+
+		p := f.newPayment(m.ID)
+		p.CustomerID = c.ID
+		p.PaymentMethodID = pm.ID
+		p.PaymentToken = pm.Token
+		require.NoError(t, f.Repositories.Payments().Create(f.ctx, p))
+		r := f.newRefund(p.ID)
+		r.CustomerID = c.ID
+		require.NoError(t, f.Repositories.Refunds().Create(f.ctx, r))
+
+		require.NoError(t, repo.Delete(f.ctx, c.ID))
+		assert.Error(t, repo.Delete(f.ctx, c.ID))
+
+		_, err := repo.GetByID(f.ctx, c.ID)
+		assert.Error(t, err)
+		_, err = repo.GetPaymentMethod(f.ctx, pm.ID)
+		assert.Error(t, err)
+
+		gotPayment, err := f.Repositories.Payments().GetByID(f.ctx, p.ID)
+		require.NoError(t, err)
+		assert.Empty(t, gotPayment.CustomerID)
+		assert.Empty(t, gotPayment.PaymentMethodID)
+		assert.Empty(t, gotPayment.PaymentToken)
+
+		gotRefund, err := f.Repositories.Refunds().GetByID(f.ctx, r.ID)
+		require.NoError(t, err)
+		assert.Empty(t, gotRefund.CustomerID)
+	})
+}
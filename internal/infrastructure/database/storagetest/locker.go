@@ -0,0 +1,31 @@
+package storagetest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLocker(t *testing.T, f *fixtures) {
+	key := "storagetest:" + newID()
+
+	unlock, acquired, err := f.Locker.TryLock(f.ctx, key)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	_, acquired, err = f.Locker.TryLock(f.ctx, key)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	otherUnlock, acquired, err := f.Locker.TryLock(f.ctx, "storagetest:"+newID())
+	require.NoError(t, err)
+	require.True(t, acquired)
+	otherUnlock()
+
+	unlock()
+	unlock, acquired, err = f.Locker.TryLock(f.ctx, key)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	unlock()
+}
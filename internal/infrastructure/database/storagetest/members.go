@@ -0,0 +1,72 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+)
+
+func testMembers(t *testing.T, f *fixtures) {
+	repo := f.Repositories.Members()
+
+	newMember := func(merchantID, userID string, role member.Role, createdAt time.Time) *member.Member {
+		return &member.Member{
+			MerchantID: merchantID,
+			UserID:     userID,
+			Role:       role,
+			CreatedAt:  createdAt,
+			UpdatedAt:  createdAt,
+		}
+	}
+
+	t.Run("add requires the merchant and users to exist", func(t *testing.T) {
+		m := f.merchant(t)
+		u := f.user(t)
+
+		assert.Error(t, repo.Add(f.ctx, newMember(newID(), u.ID, member.RoleOwner, f.now)))
+		assert.Error(t, repo.Add(f.ctx, newMember(m.ID, newID(), member.RoleOwner, f.now)))
+
+		invited := newMember(m.ID, u.ID, member.RoleAdmin, f.now)
+		invited.InvitedBy = newID()
+		assert.Error(t, repo.Add(f.ctx, invited))
+
+		invited.InvitedBy = f.user(t).ID
+		require.NoError(t, repo.Add(f.ctx, invited))
+		assert.Error(t, repo.Add(f.ctx, newMember(m.ID, u.ID, member.RoleOwner, f.now)))
+
+		got, err := repo.Get(f.ctx, m.ID, u.ID)
+		require.NoError(t, err)
+		assert.Equal(t, u.Email, got.Email)
+		assert.Equal(t, member.RoleAdmin, got.Role)
+		assert.Equal(t, invited.InvitedBy, got.InvitedBy)
+	})
+
+	t.Run("list, count and remove", func(t *testing.T) {
+		m := f.merchant(t)
+		owner := f.user(t)
+		developer := f.user(t)
+		require.NoError(t, repo.Add(f.ctx, newMember(m.ID, owner.ID, member.RoleOwner, f.now)))
+		require.NoError(t, repo.Add(f.ctx, newMember(m.ID, developer.ID, member.RoleDeveloper, f.now.Add(time.Minute))))
+
+		members, err := repo.List(f.ctx, m.ID)
+		require.NoError(t, err)
+		require.Len(t, members, 2)
+		assert.Equal(t, owner.ID, members[0].UserID)
+		assert.Equal(t, owner.Email, members[0].Email)
+		assert.Equal(t, developer.ID, members[1].UserID)
+
+		owners, err := repo.CountByRole(f.ctx, m.ID, member.RoleOwner)
+		require.NoError(t, err)
+		assert.Equal(t, 1, owners)
+
+		require.NoError(t, repo.Remove(f.ctx, m.ID, developer.ID))
+		assert.Error(t, repo.Remove(f.ctx, m.ID, developer.ID))
+
+		_, err = repo.Get(f.ctx, m.ID, developer.ID)
+		assert.Error(t, err)
+	})
+}
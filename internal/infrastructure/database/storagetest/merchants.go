@@ -0,0 +1,217 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/apikey"
+	"github.com/popeskul/payment-gateway/internal/core/domain/member"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+)
+
+func testMerchants(t *testing.T, f *fixtures) {
+	repo := f.Repositories.Merchants()
+
+	t.Run("create and get", func(t *testing.T) {
+		m := f.merchant(t)
+		require.NotEmpty(t, m.ID)
+
+		got, err := repo.GetByID(f.ctx, m.ID)
+		require.NoError(t, err)
+		assert.Equal(t, m.Name, got.Name)
+		assert.Equal(t, m.Email, got.Email)
+		assert.Equal(t, merchant.StatusActive, got.Status)
+
+		_, err = repo.GetByID(f.ctx, newID())
+		assert.Error(t, err)
+	})
+
+	t.Run("create with owner", func(t *testing.T) {
+		newMerchant := func() *merchant.Merchant {
+			return &merchant.Merchant{
+				Name:               "Acme",
+				Email:              newEmail(),
+				SettlementCurrency: "USD",
+				Status:             merchant.StatusDraft,
+				CreatedAt:          f.now,
+				UpdatedAt:          f.now,
+			}
+		}
+		newOwner := func(userID string) *member.Member {
+			return &member.Member{UserID: userID, Role: member.RoleOwner, CreatedAt: f.now, UpdatedAt: f.now}
+		}
+
+		newKey := func(keyMode mode.Mode, hash string) *apikey.APIKey {
+			return &apikey.APIKey{
+				Mode:      keyMode,
+				Name:      "Default",
+				Prefix:    "sk_" + string(keyMode) + "_abc",
+				Hash:      hash,
+				Scopes:    []apikey.Scope{apikey.ScopePaymentsWrite},
+				CreatedAt: f.now,
+			}
+		}
+
+		u := f.user(t)
+		m := newMerchant()
+		keys := []*apikey.APIKey{newKey(mode.Test, newHash()), newKey(mode.Live, newHash())}
+		require.NoError(t, repo.CreateWithOwner(f.ctx, m, newOwner(u.ID), keys))
+		owner, err := f.Repositories.Members().Get(f.ctx, m.ID, u.ID)
+		require.NoError(t, err)
+		assert.Equal(t, member.RoleOwner, owner.Role)
+		stored, err := f.Repositories.APIKeys().ListByMerchant(f.ctx, m.ID)
+		require.NoError(t, err)
+		assert.Len(t, stored, 2)
+		for _, k := range keys {
+			assert.NotEmpty(t, k.ID)
+			assert.Equal(t, m.ID, k.MerchantID)
+		}
+
+		orphan := newMerchant()
+		assert.Error(t, repo.CreateWithOwner(f.ctx, orphan, newOwner(newID()), nil))
+		_, err = repo.GetByEmail(f.ctx, orphan.Email)
+		assert.Error(t, err)
+
+		duplicateKey := newMerchant()
+		assert.Error(t, repo.CreateWithOwner(f.ctx, duplicateKey, newOwner(u.ID), []*apikey.APIKey{newKey(mode.Test, keys[0].Hash)}))
+		_, err = repo.GetByEmail(f.ctx, duplicateKey.Email)
+		assert.Error(t, err, "a key that cannot be stored undoes the merchant")
+	})
+
+	t.Run("email is unique among merchants that are not deleted", func(t *testing.T) {
+		m := f.merchant(t)
+
+		duplicate := &merchant.Merchant{
+			Name:               "Copy",
+			Email:              m.Email,
+			SettlementCurrency: "USD",
+			Status:             merchant.StatusActive,
+			CreatedAt:          f.now,
+			UpdatedAt:          f.now,
+		}
+		assert.Error(t, repo.Create(f.ctx, duplicate))
+
+		m.DeletedAt = &f.now
+		m.DeletionReason = "closed"
+		require.NoError(t, repo.SoftDelete(f.ctx, m))
+
+		duplicate.ID = ""
+		assert.NoError(t, repo.Create(f.ctx, duplicate))
+
+		got, err := repo.GetByEmail(f.ctx, m.Email)
+		require.NoError(t, err)
+		assert.Equal(t, duplicate.ID, got.ID)
+	})
+
+	t.Run("soft delete hides the merchant from its members", func(t *testing.T) {
+		u := f.user(t)
+		m := f.merchant(t)
+		require.NoError(t, f.Repositories.Members().Add(f.ctx, &member.Member{
+			MerchantID: m.ID,
+			UserID:     u.ID,
+			Role:       member.RoleOwner,
+			CreatedAt:  f.now,
+			UpdatedAt:  f.now,
+		}))
+
+		merchants, err := repo.ListByUser(f.ctx, u.ID, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, merchants, 1)
+		assert.Equal(t, m.ID, merchants[0].ID)
+
+		m.DeletedAt = &f.now
+		require.NoError(t, repo.SoftDelete(f.ctx, m))
+		assert.Error(t, repo.SoftDelete(f.ctx, m))
+
+		merchants, err = repo.ListByUser(f.ctx, u.ID, 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, merchants)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		m := f.merchant(t)
+		m.Name = "Renamed"
+		m.SettlementCurrency = "EUR"
+		require.NoError(t, repo.Update(f.ctx, m))
+
+		got, err := repo.GetByID(f.ctx, m.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Renamed", got.Name)
+		assert.Equal(t, "EUR", got.SettlementCurrency)
+	})
+
+	t.Run("delete is restricted by payments and cascades to members and keys", func(t *testing.T) {
+		u := f.user(t)
+		m := f.merchant(t)
+		require.NoError(t, f.Repositories.Members().Add(f.ctx, &member.Member{
+			MerchantID: m.ID,
+			UserID:     u.ID,
+			Role:       member.RoleOwner,
+			CreatedAt:  f.now,
+			UpdatedAt:  f.now,
+		}))
+		key := &apikey.APIKey{
+			MerchantID: m.ID,
+			Mode:       mode.Live,
+			Name:       "Server",
+			Prefix:     "pk_live_abc",
+			Hash:       newHash(),
+			Scopes:     []apikey.Scope{apikey.ScopeReadOnly},
+			CreatedAt:  f.now,
+		}
+		require.NoError(t, f.Repositories.APIKeys().Create(f.ctx, key))
+
+		withPayment := f.merchant(t)
+		f.payment(t, withPayment.ID)
+		assert.Error(t, repo.Delete(f.ctx, withPayment.ID))
+		_, err := repo.GetByID(f.ctx, withPayment.ID)
+		assert.NoError(t, err)
+
+		require.NoError(t, repo.Delete(f.ctx, m.ID))
+		_, err = repo.GetByID(f.ctx, m.ID)
+		assert.Error(t, err)
+		_, err = f.Repositories.Members().Get(f.ctx, m.ID, u.ID)
+		assert.Error(t, err)
+		_, err = f.Repositories.APIKeys().GetByID(f.ctx, key.ID)
+		assert.Error(t, err)
+	})
+
+	t.Run("list by status orders the review queue by submission", func(t *testing.T) {
+		submit := func(submittedAt *time.Time) *merchant.Merchant {
+			m := f.merchant(t)
+			m.Status = merchant.StatusInReview
+			m.SubmittedAt = submittedAt
+			require.NoError(t, repo.UpdateOnboarding(f.ctx, m))
+			return m
+		}
+		earlier, later := f.now.Add(-2*time.Hour), f.now.Add(-time.Hour)
+		never := submit(nil)
+		second := submit(&later)
+		first := submit(&earlier)
+
+		merchants, err := repo.ListByStatus(f.ctx, merchant.StatusInReview, 1000, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{first.ID, second.ID, never.ID}, filterIDs(merchants, first.ID, second.ID, never.ID))
+	})
+}
+
+// filterIDs returns the IDs of the merchants that are in ids, in list order,
+// leaving out rows other tests created.
+func filterIDs(merchants []*merchant.Merchant, ids ...string) []string {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	var got []string
+	for _, m := range merchants {
+		if wanted[m.ID] {
+			got = append(got, m.ID)
+		}
+	}
+	return got
+}
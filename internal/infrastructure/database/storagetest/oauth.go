@@ -0,0 +1,136 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/oauth"
+)
+
+func testOAuthClients(t *testing.T, f *fixtures) {
+	repo := f.Repositories.OAuthClients()
+
+	client := func(t *testing.T, ownerID string, createdAt time.Time) *oauth.Client {
+		t.Helper()
+
+		c := &oauth.Client{
+			OwnerID:    ownerID,
+			Name:       "Partner",
+			SecretHash: newHash(),
+			Scopes:     []oauth.Scope{oauth.ScopeRead, oauth.ScopePaymentsWrite},
+			CreatedAt:  createdAt,
+		}
+		require.NoError(t, repo.Create(f.ctx, c))
+		return c
+	}
+
+	t.Run("clients", func(t *testing.T) {
+		assert.Error(t, repo.Create(f.ctx, &oauth.Client{
+			OwnerID:    newID(),
+			Name:       "Orphan",
+			SecretHash: newHash(),
+			Scopes:     []oauth.Scope{oauth.ScopeRead},
+			CreatedAt:  f.now,
+		}))
+
+		owner := f.user(t)
+		older := client(t, owner.ID, f.now.Add(-time.Minute))
+		newer := client(t, owner.ID, f.now)
+
+		got, err := repo.GetByID(f.ctx, older.ID)
+		require.NoError(t, err)
+		assert.Equal(t, older.Scopes, got.Scopes)
+		assert.Equal(t, older.SecretHash, got.SecretHash)
+
+		_, err = repo.GetByID(f.ctx, newID())
+		assert.ErrorIs(t, err, oauth.ErrClientNotFound)
+
+		clients, err := repo.ListByOwner(f.ctx, owner.ID)
+		require.NoError(t, err)
+		require.Len(t, clients, 2)
+		assert.Equal(t, newer.ID, clients[0].ID)
+		assert.Equal(t, older.ID, clients[1].ID)
+
+		require.NoError(t, repo.Revoke(f.ctx, older.ID, f.now))
+		require.NoError(t, repo.Revoke(f.ctx, older.ID, f.now.Add(time.Hour)))
+		got, err = repo.GetByID(f.ctx, older.ID)
+		require.NoError(t, err)
+		require.NotNil(t, got.RevokedAt)
+		assert.True(t, got.RevokedAt.Equal(f.now))
+	})
+
+	t.Run("grants", func(t *testing.T) {
+		owner := f.user(t)
+		c := client(t, owner.ID, f.now)
+		m := f.merchant(t)
+
+		assert.Error(t, repo.SaveGrant(f.ctx, &oauth.Grant{
+			ClientID:   c.ID,
+			MerchantID: newID(),
+			Scopes:     []oauth.Scope{oauth.ScopeRead},
+			CreatedAt:  f.now,
+		}))
+
+		grant := &oauth.Grant{
+			ClientID:   c.ID,
+			MerchantID: m.ID,
+			Scopes:     []oauth.Scope{oauth.ScopeRead},
+			GrantedBy:  owner.ID,
+			CreatedAt:  f.now,
+		}
+		require.NoError(t, repo.SaveGrant(f.ctx, grant))
+
+		// Saving again changes the scopes and keeps the original creation time.
+		grant.Scopes = []oauth.Scope{oauth.ScopeRead, oauth.ScopeRefundsWrite}
+		grant.CreatedAt = f.now.Add(time.Hour)
+		require.NoError(t, repo.SaveGrant(f.ctx, grant))
+
+		got, err := repo.GetGrant(f.ctx, c.ID, m.ID)
+		require.NoError(t, err)
+		assert.Equal(t, grant.Scopes, got.Scopes)
+		assert.True(t, got.CreatedAt.Equal(f.now))
+
+		grants, err := repo.ListGrantsByMerchant(f.ctx, m.ID)
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		grants, err = repo.ListGrantsByClient(f.ctx, c.ID)
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+
+		require.NoError(t, repo.DeleteGrant(f.ctx, c.ID, m.ID))
+		assert.ErrorIs(t, repo.DeleteGrant(f.ctx, c.ID, m.ID), oauth.ErrGrantNotFound)
+		_, err = repo.GetGrant(f.ctx, c.ID, m.ID)
+		assert.ErrorIs(t, err, oauth.ErrGrantNotFound)
+	})
+
+	t.Run("delete grants by merchant", func(t *testing.T) {
+		owner := f.user(t)
+		first := client(t, owner.ID, f.now)
+		second := client(t, owner.ID, f.now)
+		m := f.merchant(t)
+		other := f.merchant(t)
+
+		for _, g := range []*oauth.Grant{
+			{ClientID: first.ID, MerchantID: m.ID},
+			{ClientID: second.ID, MerchantID: m.ID},
+			{ClientID: first.ID, MerchantID: other.ID},
+		} {
+			g.Scopes = []oauth.Scope{oauth.ScopeRead}
+			g.GrantedBy = owner.ID
+			g.CreatedAt = f.now
+			require.NoError(t, repo.SaveGrant(f.ctx, g))
+		}
+
+		require.NoError(t, repo.DeleteGrantsByMerchant(f.ctx, m.ID))
+
+		grants, err := repo.ListGrantsByMerchant(f.ctx, m.ID)
+		require.NoError(t, err)
+		assert.Empty(t, grants)
+		grants, err = repo.ListGrantsByMerchant(f.ctx, other.ID)
+		require.NoError(t, err)
+		assert.Len(t, grants, 1)
+	})
+}
@@ -0,0 +1,93 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+)
+
+func testOnboarding(t *testing.T, f *fixtures) {
+	repo := f.Repositories.Onboarding()
+
+	t.Run("owners", func(t *testing.T) {
+		m := f.merchant(t)
+		owner := func(name string, createdAt time.Time) *merchant.BeneficialOwner {
+			return &merchant.BeneficialOwner{
+				MerchantID:       m.ID,
+				FullName:         name,
+				DateOfBirth:      "1980-01-02",
+				Nationality:      "GB",
+				OwnershipPercent: 50,
+				CreatedAt:        createdAt,
+			}
+		}
+
+		orphan := owner("Nobody", f.now)
+		orphan.MerchantID = newID()
+		assert.Error(t, repo.AddOwner(f.ctx, orphan))
+
+		first := owner("Ada Lovelace", f.now)
+		second := owner("Charles Babbage", f.now.Add(time.Minute))
+		require.NoError(t, repo.AddOwner(f.ctx, first))
+		require.NoError(t, repo.AddOwner(f.ctx, second))
+
+		owners, err := repo.ListOwners(f.ctx, m.ID)
+		require.NoError(t, err)
+		require.Len(t, owners, 2)
+		assert.Equal(t, first.ID, owners[0].ID)
+		assert.Equal(t, "Ada Lovelace", owners[0].FullName)
+		assert.Equal(t, 50.0, owners[0].OwnershipPercent)
+		assert.Equal(t, second.ID, owners[1].ID)
+
+		assert.Error(t, repo.DeleteOwner(f.ctx, f.merchant(t).ID, first.ID))
+		require.NoError(t, repo.DeleteOwner(f.ctx, m.ID, first.ID))
+		assert.Error(t, repo.DeleteOwner(f.ctx, m.ID, first.ID))
+
+		owners, err = repo.ListOwners(f.ctx, m.ID)
+		require.NoError(t, err)
+		require.Len(t, owners, 1)
+		assert.Equal(t, second.ID, owners[0].ID)
+	})
+
+	t.Run("documents", func(t *testing.T) {
+		m := f.merchant(t)
+		document := func(uploadedBy string) *merchant.Document {
+			return &merchant.Document{
+				MerchantID:  m.ID,
+				Type:        merchant.DocumentIdentity,
+				FileName:    "passport.pdf",
+				ContentType: "application/pdf",
+				Size:        1024,
+				StorageKey:  "documents/" + newID(),
+				UploadedBy:  uploadedBy,
+				CreatedAt:   f.now,
+			}
+		}
+
+		assert.Error(t, repo.AddDocument(f.ctx, document(newID())))
+
+		d := document(f.user(t).ID)
+		require.NoError(t, repo.AddDocument(f.ctx, d))
+
+		duplicate := document("")
+		duplicate.StorageKey = d.StorageKey
+		assert.Error(t, repo.AddDocument(f.ctx, duplicate))
+
+		got, err := repo.GetDocument(f.ctx, d.ID)
+		require.NoError(t, err)
+		assert.Equal(t, d.StorageKey, got.StorageKey)
+		assert.Equal(t, d.UploadedBy, got.UploadedBy)
+		assert.Equal(t, int64(1024), got.Size)
+
+		documents, err := repo.ListDocuments(f.ctx, m.ID)
+		require.NoError(t, err)
+		require.Len(t, documents, 1)
+
+		_, err = repo.GetDocument(f.ctx, newID())
+		assert.Error(t, err)
+	})
+}
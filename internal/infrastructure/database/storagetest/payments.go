@@ -0,0 +1,248 @@
+package storagetest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
+)
+
+func testPayments(t *testing.T, f *fixtures) {
+	repo := f.Repositories.Payments()
+
+	t.Run("create requires the merchant and customer to exist", func(t *testing.T) {
+		assert.Error(t, repo.Create(f.ctx, f.newPayment(newID())))
+
+		m := f.merchant(t)
+		p := f.newPayment(m.ID)
+		p.CustomerID = newID()
+		assert.Error(t, repo.Create(f.ctx, p))
+
+		p.ID = ""
+		p.CustomerID = f.customer(t, m.ID).ID
+		require.NoError(t, repo.Create(f.ctx, p))
+
+		got, err := repo.GetByID(f.ctx, p.ID)
+		require.NoError(t, err)
+		assert.Equal(t, p.CustomerID, got.CustomerID)
+		assert.Equal(t, 100.0, got.Amount)
+		assert.Equal(t, payment.PaymentStatusPending, got.Status)
+	})
+
+	t.Run("acquirer reference is unique and only found for live payments", func(t *testing.T) {
+		m := f.merchant(t)
+		reference := newID()
+
+		live := f.payment(t, m.ID)
+		live.AcquirerReference = reference
+		require.NoError(t, repo.Update(f.ctx, live))
+
+		other := f.payment(t, m.ID)
+		other.AcquirerReference = reference
+		assert.Error(t, repo.Update(f.ctx, other))
+
+		got, err := repo.GetByAcquirerReference(f.ctx, reference)
+		require.NoError(t, err)
+		assert.Equal(t, live.ID, got.ID)
+
+		testReference := newID()
+		test := f.newPayment(m.ID)
+		test.Mode = mode.Test
+		test.AcquirerReference = testReference
+		require.NoError(t, repo.Create(f.ctx, test))
+		_, err = repo.GetByAcquirerReference(f.ctx, testReference)
+		assert.ErrorIs(t, err, payment.ErrNotFound)
+	})
+
+	t.Run("transition status records the transition", func(t *testing.T) {
+		p := f.payment(t, f.merchant(t).ID)
+
+		p.Status = payment.PaymentStatusAuthorized
+		p.UpdatedAt = f.now.Add(time.Second)
+		require.NoError(t, repo.TransitionStatus(f.ctx, p, payment.PaymentStatusPending, "authorized"))
+
+		p.Status = payment.PaymentStatusCompleted
+		assert.Error(t, repo.TransitionStatus(f.ctx, p, payment.PaymentStatusPending, "captured"))
+
+		got, err := repo.GetByID(f.ctx, p.ID)
+		require.NoError(t, err)
+		assert.Equal(t, payment.PaymentStatusAuthorized, got.Status)
+
+		transitions, err := repo.ListTransitions(f.ctx, p.ID)
+		require.NoError(t, err)
+		require.Len(t, transitions, 1)
+		assert.Equal(t, payment.PaymentStatusPending, transitions[0].FromStatus)
+		assert.Equal(t, payment.PaymentStatusAuthorized, transitions[0].ToStatus)
+		assert.Equal(t, "authorized", transitions[0].Reason)
+	})
+
+	t.Run("list filters by mode and pages newest first", func(t *testing.T) {
+		m := f.merchant(t)
+		var live []string
+		for i := 0; i < 3; i++ {
+			p := f.newPayment(m.ID)
+			p.CreatedAt = f.now.Add(time.Duration(i) * time.Minute)
+			require.NoError(t, repo.Create(f.ctx, p))
+			live = append(live, p.ID)
+		}
+		test := f.newPayment(m.ID)
+		test.Mode = mode.Test
+		require.NoError(t, repo.Create(f.ctx, test))
+
+		payments, err := repo.List(f.ctx, m.ID, mode.Live, 2, 1)
+		require.NoError(t, err)
+		require.Len(t, payments, 2)
+		assert.Equal(t, live[1], payments[0].ID)
+		assert.Equal(t, live[0], payments[1].ID)
+
+		payments, err = repo.List(f.ctx, m.ID, mode.Test, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, payments, 1)
+		assert.Equal(t, test.ID, payments[0].ID)
+	})
+
+	t.Run("sum volume counts payments that have not failed", func(t *testing.T) {
+		m := f.merchant(t)
+		f.payment(t, m.ID)
+		refunded := f.payment(t, m.ID)
+
+		// Refunds reduce the payment's amounts but not its volume.
+		r := f.refund(t, refunded.ID)
+		require.NoError(t, f.Repositories.Refunds().TransitionStatus(f.ctx, r.ID, refund.RefundStatusPending, refund.RefundStatusProcessing))
+		r.Status = refund.RefundStatusCompleted
+		refunded.Amount = 75
+		refunded.SettlementAmount = 75
+		require.NoError(t, f.Repositories.Refunds().UpdateWithTransaction(f.ctx, r, refunded))
+		f.refund(t, refunded.ID)
+
+		failed := f.newPayment(m.ID)
+		failed.Status = payment.PaymentStatusFailed
+		require.NoError(t, repo.Create(f.ctx, failed))
+
+		old := f.newPayment(m.ID)
+		old.CreatedAt = f.now.Add(-48 * time.Hour)
+		require.NoError(t, repo.Create(f.ctx, old))
+
+		total, err := repo.SumVolume(f.ctx, m.ID, mode.Live, f.now.Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 200.0, total)
+	})
+
+	t.Run("create within volume", func(t *testing.T) {
+		m := f.merchant(t)
+		f.payment(t, m.ID)
+		old := f.newPayment(m.ID)
+		old.CreatedAt = f.now.Add(-48 * time.Hour)
+		require.NoError(t, repo.Create(f.ctx, old))
+
+		since := []time.Time{f.now.Add(-time.Hour), f.now.Add(-72 * time.Hour)}
+		errOverLimit := errors.New("over the limit")
+
+		rejected := f.newPayment(m.ID)
+		err := repo.CreateWithinVolume(f.ctx, rejected, since, func(volumes []float64) error {
+			assert.Equal(t, []float64{100, 200}, volumes)
+			return errOverLimit
+		})
+		assert.ErrorIs(t, err, errOverLimit)
+		_, err = repo.GetByID(f.ctx, rejected.ID)
+		assert.ErrorIs(t, err, payment.ErrNotFound)
+
+		accepted := f.newPayment(m.ID)
+		require.NoError(t, repo.CreateWithinVolume(f.ctx, accepted, since, func([]float64) error { return nil }))
+		_, err = repo.GetByID(f.ctx, accepted.ID)
+		require.NoError(t, err)
+
+		total, err := repo.SumVolume(f.ctx, m.ID, mode.Live, since[0])
+		require.NoError(t, err)
+		assert.Equal(t, 200.0, total)
+	})
+}
+
+func testRefunds(t *testing.T, f *fixtures) {
+	repo := f.Repositories.Refunds()
+
+	t.Run("create requires the payment to exist", func(t *testing.T) {
+		assert.Error(t, repo.Create(f.ctx, f.newRefund(newID())))
+
+		p := f.payment(t, f.merchant(t).ID)
+		r := f.refund(t, p.ID)
+
+		got, err := repo.GetByID(f.ctx, r.ID)
+		require.NoError(t, err)
+		assert.Equal(t, p.ID, got.PaymentID)
+		assert.Equal(t, 25.0, got.Amount)
+	})
+
+	t.Run("list returns the newest first", func(t *testing.T) {
+		p := f.payment(t, f.merchant(t).ID)
+		older := f.newRefund(p.ID)
+		older.CreatedAt = f.now.Add(-time.Minute)
+		require.NoError(t, repo.Create(f.ctx, older))
+		newer := f.refund(t, p.ID)
+
+		refunds, err := repo.List(f.ctx, p.ID, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, refunds, 2)
+		assert.Equal(t, newer.ID, refunds[0].ID)
+		assert.Equal(t, older.ID, refunds[1].ID)
+	})
+
+	t.Run("transition status checks the current status", func(t *testing.T) {
+		r := f.refund(t, f.payment(t, f.merchant(t).ID).ID)
+
+		require.NoError(t, repo.TransitionStatus(f.ctx, r.ID, refund.RefundStatusPending, refund.RefundStatusCanceled))
+		assert.Error(t, repo.TransitionStatus(f.ctx, r.ID, refund.RefundStatusPending, refund.RefundStatusCompleted))
+
+		got, err := repo.GetByID(f.ctx, r.ID)
+		require.NoError(t, err)
+		assert.Equal(t, refund.RefundStatusCanceled, got.Status)
+	})
+
+	t.Run("update with transaction completes a processing refund once", func(t *testing.T) {
+		p := f.payment(t, f.merchant(t).ID)
+		r := f.refund(t, p.ID)
+
+		processedAt := f.now
+		r.Status = refund.RefundStatusCompleted
+		r.AcquirerReference = newID()
+		r.ProcessedAt = &processedAt
+		p.Amount = 75
+		p.SettlementAmount = 75
+		assert.Error(t, repo.UpdateWithTransaction(f.ctx, r, p), "the refund has not been claimed")
+
+		require.NoError(t, repo.TransitionStatus(f.ctx, r.ID, refund.RefundStatusPending, refund.RefundStatusProcessing))
+		require.NoError(t, repo.UpdateWithTransaction(f.ctx, r, p))
+
+		p.Amount = 50
+		p.SettlementAmount = 50
+		assert.Error(t, repo.UpdateWithTransaction(f.ctx, r, p))
+
+		gotRefund, err := repo.GetByID(f.ctx, r.ID)
+		require.NoError(t, err)
+		assert.Equal(t, refund.RefundStatusCompleted, gotRefund.Status)
+		assert.Equal(t, r.AcquirerReference, gotRefund.AcquirerReference)
+
+		gotPayment, err := f.Repositories.Payments().GetByID(f.ctx, p.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 75.0, gotPayment.Amount)
+		assert.Equal(t, 75.0, gotPayment.SettlementAmount)
+	})
+
+	t.Run("total by payment sums refunds in the status", func(t *testing.T) {
+		p := f.payment(t, f.merchant(t).ID)
+		f.refund(t, p.ID)
+		f.refund(t, p.ID)
+		canceled := f.refund(t, p.ID)
+		require.NoError(t, repo.UpdateStatus(f.ctx, canceled.ID, refund.RefundStatusCanceled))
+
+		total, err := repo.TotalByPayment(f.ctx, p.ID, refund.RefundStatusPending)
+		require.NoError(t, err)
+		assert.Equal(t, 50.0, total)
+	})
+}
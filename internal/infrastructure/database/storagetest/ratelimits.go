@@ -0,0 +1,69 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/ratelimit"
+)
+
+func testRateLimits(t *testing.T, f *fixtures) {
+	limit := ratelimit.Limit{Requests: 2, Period: time.Minute}
+	key := "storagetest:" + newID()
+	take := func(key string, limit ratelimit.Limit, at time.Time) ratelimit.Result {
+		results, err := f.RateLimits.Take(f.ctx, []ratelimit.Key{{Name: key, Limit: limit}}, at)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		return results[0]
+	}
+
+	for want := 1; want >= 0; want-- {
+		result := take(key, limit, f.now)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, want, result.Remaining)
+	}
+
+	result := take(key, limit, f.now)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 30*time.Second, result.RetryAfter)
+
+	// Another key has a bucket of its own.
+	result = take("storagetest:"+newID(), limit, f.now)
+	assert.True(t, result.Allowed)
+
+	// Half the period refills one token.
+	result = take(key, limit, f.now.Add(30*time.Second))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// A take refused by one bucket takes nothing from the others.
+	open := "storagetest:" + newID()
+	results, err := f.RateLimits.Take(f.ctx, []ratelimit.Key{{Name: open, Limit: limit}, {Name: key, Limit: limit}}, f.now.Add(30*time.Second))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Allowed)
+	assert.Equal(t, 2, results[0].Remaining)
+	assert.False(t, results[1].Allowed)
+	for want := 1; want >= 0; want-- {
+		result = take(open, limit, f.now.Add(30*time.Second))
+		assert.True(t, result.Allowed)
+		assert.Equal(t, want, result.Remaining)
+	}
+
+	// Buckets idle since before the cutoff are pruned and start full again.
+	idle := "storagetest:" + newID()
+	take(idle, ratelimit.Limit{Requests: 1, Period: time.Minute}, f.now.Add(-time.Hour))
+
+	pruned, err := f.RateLimits.Prune(f.ctx, f.now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, pruned, 1)
+
+	result = take(idle, ratelimit.Limit{Requests: 1, Period: time.Minute}, f.now)
+	assert.True(t, result.Allowed)
+
+	result = take(key, limit, f.now.Add(30*time.Second))
+	assert.False(t, result.Allowed, "pruning must keep buckets in use")
+}
@@ -0,0 +1,144 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
+)
+
+func testReconciliations(t *testing.T, f *fixtures) {
+	repo := f.Repositories.Reconciliations()
+
+	report := func(t *testing.T) *reconciliation.Report {
+		t.Helper()
+
+		r := &reconciliation.Report{
+			FileName:  newID() + ".csv",
+			Status:    reconciliation.ReportStatusProcessing,
+			CreatedAt: f.now,
+		}
+		require.NoError(t, repo.CreateReport(f.ctx, r))
+		return r
+	}
+	discrepancy := func(reportID string, createdAt time.Time) *reconciliation.Discrepancy {
+		expected := 10.0
+		return &reconciliation.Discrepancy{
+			ReportID:          reportID,
+			Type:              reconciliation.DiscrepancyMissingAtAcquirer,
+			RecordType:        reconciliation.RecordTypePayment,
+			AcquirerReference: newID(),
+			ExpectedAmount:    &expected,
+			Currency:          "USD",
+			Status:            reconciliation.DiscrepancyStatusOpen,
+			CreatedAt:         createdAt,
+		}
+	}
+
+	t.Run("file name is unique", func(t *testing.T) {
+		r := report(t)
+
+		got, err := repo.GetReportByFileName(f.ctx, r.FileName)
+		require.NoError(t, err)
+		assert.Equal(t, r.ID, got.ID)
+
+		_, err = repo.GetReportByFileName(f.ctx, newID()+".csv")
+		assert.ErrorIs(t, err, reconciliation.ErrReportNotFound)
+
+		assert.Error(t, repo.CreateReport(f.ctx, &reconciliation.Report{
+			FileName:  r.FileName,
+			Status:    reconciliation.ReportStatusProcessing,
+			CreatedAt: f.now,
+		}))
+	})
+
+	t.Run("update report", func(t *testing.T) {
+		r := report(t)
+		completedAt := f.now.Add(time.Minute)
+		r.Status = reconciliation.ReportStatusCompleted
+		r.TotalLines = 3
+		r.MatchedCount = 2
+		r.DiscrepancyCount = 1
+		r.CompletedAt = &completedAt
+		require.NoError(t, repo.UpdateReport(f.ctx, r))
+
+		got, err := repo.GetReport(f.ctx, r.ID)
+		require.NoError(t, err)
+		assert.Equal(t, reconciliation.ReportStatusCompleted, got.Status)
+		assert.Equal(t, 2, got.MatchedCount)
+		require.NotNil(t, got.CompletedAt)
+		assert.True(t, got.CompletedAt.Equal(completedAt))
+	})
+
+	t.Run("discrepancies", func(t *testing.T) {
+		r := report(t)
+		first := discrepancy(r.ID, f.now)
+		second := discrepancy(r.ID, f.now.Add(time.Second))
+		r.Status = reconciliation.ReportStatusCompleted
+		r.DiscrepancyCount = 2
+		require.NoError(t, repo.CompleteReport(f.ctx, r, []*reconciliation.Discrepancy{first, second}))
+
+		got, err := repo.GetReport(f.ctx, r.ID)
+		require.NoError(t, err)
+		assert.Equal(t, reconciliation.ReportStatusCompleted, got.Status)
+		assert.Equal(t, 2, got.DiscrepancyCount)
+
+		resolvedAt := f.now.Add(time.Minute)
+		first.Status = reconciliation.DiscrepancyStatusResolved
+		first.Resolution = "written off"
+		first.ResolvedAt = &resolvedAt
+		first.ResolvedBy = newID()
+		assert.Error(t, repo.UpdateDiscrepancy(f.ctx, first))
+
+		first.ResolvedBy = f.user(t).ID
+		require.NoError(t, repo.UpdateDiscrepancy(f.ctx, first))
+
+		gotDiscrepancy, err := repo.GetDiscrepancy(f.ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "written off", gotDiscrepancy.Resolution)
+		assert.Equal(t, first.ResolvedBy, gotDiscrepancy.ResolvedBy)
+		require.NotNil(t, gotDiscrepancy.ExpectedAmount)
+		assert.Equal(t, 10.0, *gotDiscrepancy.ExpectedAmount)
+		assert.Nil(t, gotDiscrepancy.ActualAmount)
+
+		all, err := repo.ListDiscrepancies(f.ctx, r.ID, "", 10, 0)
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+		assert.Equal(t, first.ID, all[0].ID)
+		assert.Equal(t, second.ID, all[1].ID)
+
+		open, err := repo.ListDiscrepancies(f.ctx, r.ID, reconciliation.DiscrepancyStatusOpen, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, open, 1)
+		assert.Equal(t, second.ID, open[0].ID)
+
+		third := discrepancy(r.ID, f.now.Add(2*time.Second))
+		require.NoError(t, repo.CompleteReport(f.ctx, r, []*reconciliation.Discrepancy{third}))
+		all, err = repo.ListDiscrepancies(f.ctx, r.ID, "", 10, 0)
+		require.NoError(t, err)
+		require.Len(t, all, 1, "a rerun replaces the discrepancies")
+		assert.Equal(t, third.ID, all[0].ID)
+	})
+
+	t.Run("complete report is atomic", func(t *testing.T) {
+		r := report(t)
+		r.Status = reconciliation.ReportStatusCompleted
+		r.DiscrepancyCount = 2
+		assert.Error(t, repo.CompleteReport(f.ctx, r, []*reconciliation.Discrepancy{
+			discrepancy(r.ID, f.now),
+			discrepancy(newID(), f.now),
+		}))
+
+		got, err := repo.GetReport(f.ctx, r.ID)
+		require.NoError(t, err)
+		assert.Equal(t, reconciliation.ReportStatusProcessing, got.Status)
+		assert.Zero(t, got.DiscrepancyCount)
+
+		all, err := repo.ListDiscrepancies(f.ctx, r.ID, "", 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, all)
+	})
+}
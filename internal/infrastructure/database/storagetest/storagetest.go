@@ -0,0 +1,231 @@
+// Package storagetest is a conformance suite for the storage backends. Each
+// backend runs it from a test in its own package, so they all keep the
+// uniqueness, foreign key and atomicity guarantees the services rely on.
+package storagetest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/customer"
+	"github.com/popeskul/payment-gateway/internal/core/domain/merchant"
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/payment"
+	"github.com/popeskul/payment-gateway/internal/core/domain/refund"
+	"github.com/popeskul/payment-gateway/internal/core/domain/subscription"
+	"github.com/popeskul/payment-gateway/internal/core/domain/user"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+	"github.com/popeskul/payment-gateway/internal/domain"
+)
+
+type Backend struct {
+	Repositories ports.Repositories
+	TokenStore   ports.TokenStore
+	Locker       ports.Locker
+	RateLimits   ports.RateLimitStore
+}
+
+// Run runs the suite against the backend returned by newBackend, which is
+// called once per group of tests. The tests create their own rows with fresh
+// IDs and email addresses, so the backend may already hold data.
+func Run(t *testing.T, newBackend func(t *testing.T) Backend) {
+	groups := []struct {
+		name string
+		run  func(t *testing.T, f *fixtures)
+	}{
+		{"Merchants", testMerchants},
+		{"Payments", testPayments},
+		{"Refunds", testRefunds},
+		{"Users", testUsers},
+		{"Reconciliations", testReconciliations},
+		{"Subscriptions", testSubscriptions},
+		{"Customers", testCustomers},
+		{"Members", testMembers},
+		{"APIKeys", testAPIKeys},
+		{"Onboarding", testOnboarding},
+		{"AuditLog", testAuditLog},
+		{"OAuthClients", testOAuthClients},
+		{"TokenStore", testTokenStore},
+		{"Locker", testLocker},
+		{"RateLimits", testRateLimits},
+	}
+
+	for _, g := range groups {
+		t.Run(g.name, func(t *testing.T) {
+			g.run(t, newFixtures(newBackend(t)))
+		})
+	}
+}
+
+// fixtures creates valid rows for the tests. Times are in UTC and whole
+// milliseconds, which every backend stores exactly.
+type fixtures struct {
+	Backend
+	ctx context.Context
+	now time.Time
+}
+
+func newFixtures(b Backend) *fixtures {
+	return &fixtures{
+		Backend: b,
+		ctx:     context.Background(),
+		now:     time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+func newID() string {
+	return uuid.NewString()
+}
+
+func newEmail() string {
+	return newID() + "@example.com"
+}
+
+// newHash returns a value shaped like the SHA-256 hex digests stored for
+// tokens, keys and codes.
+func newHash() string {
+	sum := sha256.Sum256([]byte(newID()))
+	return hex.EncodeToString(sum[:])
+}
+
+func (f *fixtures) user(t *testing.T) *user.User {
+	t.Helper()
+
+	u := &user.User{
+		Email:        newEmail(),
+		PasswordHash: "hash",
+		FirstName:    "Ada",
+		LastName:     "Lovelace",
+		CreatedAt:    f.now,
+		UpdatedAt:    f.now,
+	}
+	require.NoError(t, f.Repositories.Users().Create(f.ctx, u))
+	return u
+}
+
+func (f *fixtures) merchant(t *testing.T) *merchant.Merchant {
+	t.Helper()
+
+	m := &merchant.Merchant{
+		Name:               "Acme",
+		Email:              newEmail(),
+		SettlementCurrency: "USD",
+		Status:             merchant.StatusActive,
+		CreatedAt:          f.now,
+		UpdatedAt:          f.now,
+	}
+	require.NoError(t, f.Repositories.Merchants().Create(f.ctx, m))
+	return m
+}
+
+// newPayment returns a pending live payment of 100 USD that is not stored
+// yet.
+func (f *fixtures) newPayment(merchantID string) *payment.Payment {
+	return &payment.Payment{
+		MerchantID:         merchantID,
+		Mode:               mode.Live,
+		Amount:             100,
+		Currency:           "USD",
+		SettlementAmount:   100,
+		SettlementCurrency: "USD",
+		FXRate:             1,
+		FXRateTimestamp:    f.now,
+		Status:             payment.PaymentStatusPending,
+		PaymentMethod:      "card",
+		Description:        "Order",
+		CreatedAt:          f.now,
+		UpdatedAt:          f.now,
+	}
+}
+
+func (f *fixtures) payment(t *testing.T, merchantID string) *payment.Payment {
+	t.Helper()
+
+	p := f.newPayment(merchantID)
+	require.NoError(t, f.Repositories.Payments().Create(f.ctx, p))
+	return p
+}
+
+func (f *fixtures) newRefund(paymentID string) *refund.Refund {
+	return &refund.Refund{
+		PaymentID:          paymentID,
+		Mode:               mode.Live,
+		Amount:             25,
+		Currency:           "USD",
+		SettlementAmount:   25,
+		SettlementCurrency: "USD",
+		Reason:             "Damaged",
+		Status:             refund.RefundStatusPending,
+		CreatedAt:          f.now,
+		UpdatedAt:          f.now,
+	}
+}
+
+func (f *fixtures) refund(t *testing.T, paymentID string) *refund.Refund {
+	t.Helper()
+
+	r := f.newRefund(paymentID)
+	require.NoError(t, f.Repositories.Refunds().Create(f.ctx, r))
+	return r
+}
+
+func (f *fixtures) newCustomer(merchantID string) *customer.Customer {
+	return &customer.Customer{
+		MerchantID: merchantID,
+		Mode:       mode.Live,
+		Email:      newEmail(),
+		Name:       "Grace Hopper",
+		CreatedAt:  f.now,
+		UpdatedAt:  f.now,
+	}
+}
+
+func (f *fixtures) customer(t *testing.T, merchantID string) *customer.Customer {
+	t.Helper()
+
+	c := f.newCustomer(merchantID)
+	require.NoError(t, f.Repositories.Customers().Create(f.ctx, c))
+	return c
+}
+
+func (f *fixtures) plan(t *testing.T, merchantID string) *subscription.Plan {
+	t.Helper()
+
+	p := &subscription.Plan{
+		MerchantID:    merchantID,
+		Mode:          mode.Live,
+		Name:          "Pro",
+		Amount:        20,
+		Currency:      "USD",
+		Interval:      subscription.IntervalMonth,
+		IntervalCount: 1,
+		Active:        true,
+		CreatedAt:     f.now,
+		UpdatedAt:     f.now,
+	}
+	require.NoError(t, f.Repositories.Subscriptions().CreatePlan(f.ctx, p))
+	return p
+}
+
+func (f *fixtures) session(t *testing.T, userID string) *domain.Session {
+	t.Helper()
+
+	s := &domain.Session{
+		ID:         newID(),
+		UserID:     userID,
+		Device:     "Firefox on Linux",
+		UserAgent:  "Mozilla/5.0",
+		IP:         "192.0.2.1",
+		CreatedAt:  f.now,
+		LastUsedAt: f.now,
+		ExpiresAt:  f.now.Add(time.Hour),
+	}
+	require.NoError(t, f.TokenStore.CreateSession(s))
+	return s
+}
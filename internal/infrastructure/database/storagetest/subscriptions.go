@@ -0,0 +1,209 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/mode"
+	"github.com/popeskul/payment-gateway/internal/core/domain/subscription"
+)
+
+func testSubscriptions(t *testing.T, f *fixtures) {
+	repo := f.Repositories.Subscriptions()
+
+	newSubscription := func(merchantID, planID string) *subscription.Subscription {
+		return &subscription.Subscription{
+			MerchantID:         merchantID,
+			Mode:               mode.Live,
+			PlanID:             planID,
+			PaymentMethod:      "card",
+			PaymentToken:       "tok_visa",
+			Status:             subscription.StatusActive,
+			CurrentPeriodStart: f.now,
+			CurrentPeriodEnd:   f.now.AddDate(0, 1, 0),
+			BillingAnchorDay:   f.now.Day(),
+			CreatedAt:          f.now,
+			UpdatedAt:          f.now,
+		}
+	}
+
+	t.Run("plans", func(t *testing.T) {
+		m := f.merchant(t)
+		p := f.plan(t, m.ID)
+
+		p.Name = "Pro Plus"
+		p.Active = false
+		require.NoError(t, repo.UpdatePlan(f.ctx, p))
+		got, err := repo.GetPlan(f.ctx, p.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Pro Plus", got.Name)
+		assert.False(t, got.Active)
+		assert.Equal(t, subscription.IntervalMonth, got.Interval)
+
+		plans, err := repo.ListPlans(f.ctx, m.ID, mode.Test, 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, plans)
+
+		orphan := *p
+		orphan.ID = ""
+		orphan.MerchantID = newID()
+		assert.Error(t, repo.CreatePlan(f.ctx, &orphan))
+	})
+
+	t.Run("create and update", func(t *testing.T) {
+		m := f.merchant(t)
+		p := f.plan(t, m.ID)
+
+		assert.Error(t, repo.Create(f.ctx, newSubscription(m.ID, newID())))
+
+		s := newSubscription(m.ID, p.ID)
+		require.NoError(t, repo.Create(f.ctx, s))
+
+		retryAt := f.now.Add(time.Hour)
+		s.Status = subscription.StatusPastDue
+		s.FailedAttempts = 1
+		s.NextRetryAt = &retryAt
+		require.NoError(t, repo.Update(f.ctx, s))
+
+		s.PlanID = newID()
+		assert.Error(t, repo.Update(f.ctx, s))
+
+		got, err := repo.GetByID(f.ctx, s.ID)
+		require.NoError(t, err)
+		assert.Equal(t, p.ID, got.PlanID)
+		assert.Equal(t, subscription.StatusPastDue, got.Status)
+		assert.Equal(t, 1, got.FailedAttempts)
+		assert.Equal(t, f.now.Day(), got.BillingAnchorDay)
+		require.NotNil(t, got.NextRetryAt)
+		assert.True(t, got.NextRetryAt.Equal(retryAt))
+	})
+
+	t.Run("list due", func(t *testing.T) {
+		m := f.merchant(t)
+		p := f.plan(t, m.ID)
+
+		create := func(status subscription.Status, periodEnd time.Time, nextRetryAt *time.Time) *subscription.Subscription {
+			s := newSubscription(m.ID, p.ID)
+			s.Status = status
+			s.CurrentPeriodEnd = periodEnd
+			s.NextRetryAt = nextRetryAt
+			require.NoError(t, repo.Create(f.ctx, s))
+			return s
+		}
+		past, future := f.now.Add(-time.Hour), f.now.Add(time.Hour)
+		renewal := create(subscription.StatusActive, past, nil)
+		trial := create(subscription.StatusTrialing, past.Add(-time.Hour), nil)
+		retry := create(subscription.StatusPastDue, past.Add(time.Minute), &past)
+		notYet := create(subscription.StatusActive, future, nil)
+		retryLater := create(subscription.StatusPastDue, past, &future)
+		canceled := create(subscription.StatusCanceled, past, nil)
+
+		// Leave nothing due behind for later runs against the same database.
+		t.Cleanup(func() {
+			for _, s := range []*subscription.Subscription{renewal, trial, retry} {
+				s.Status = subscription.StatusCanceled
+				_ = repo.Update(f.ctx, s)
+			}
+		})
+
+		due, err := repo.ListDue(f.ctx, f.now, 1000)
+		require.NoError(t, err)
+
+		var ids []string
+		for _, s := range due {
+			switch s.ID {
+			case renewal.ID, trial.ID, retry.ID, notYet.ID, retryLater.ID, canceled.ID:
+				ids = append(ids, s.ID)
+			}
+		}
+		assert.Equal(t, []string{trial.ID, renewal.ID, retry.ID}, ids)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		m := f.merchant(t)
+		s := newSubscription(m.ID, f.plan(t, m.ID).ID)
+		require.NoError(t, repo.Create(f.ctx, s))
+
+		event := func(eventType subscription.EventType, createdAt time.Time) *subscription.Event {
+			return &subscription.Event{
+				SubscriptionID: s.ID,
+				MerchantID:     m.ID,
+				Type:           eventType,
+				CreatedAt:      createdAt,
+			}
+		}
+
+		assert.Error(t, repo.CreateEvent(f.ctx, &subscription.Event{
+			SubscriptionID: newID(),
+			MerchantID:     m.ID,
+			Type:           subscription.EventCreated,
+			CreatedAt:      f.now,
+		}))
+		withPayment := event(subscription.EventRenewed, f.now)
+		withPayment.PaymentID = newID()
+		assert.Error(t, repo.CreateEvent(f.ctx, withPayment))
+
+		created := event(subscription.EventCreated, f.now)
+		renewed := event(subscription.EventRenewed, f.now.Add(time.Minute))
+		renewed.PaymentID = f.payment(t, m.ID).ID
+		require.NoError(t, repo.CreateEvent(f.ctx, created))
+		require.NoError(t, repo.CreateEvent(f.ctx, renewed))
+
+		events, err := repo.ListEvents(f.ctx, s.ID, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, renewed.ID, events[0].ID)
+		assert.Equal(t, renewed.PaymentID, events[0].PaymentID)
+		assert.Equal(t, created.ID, events[1].ID)
+	})
+
+	t.Run("cancel by merchant", func(t *testing.T) {
+		m := f.merchant(t)
+		other := f.merchant(t)
+		p := f.plan(t, m.ID)
+
+		active := newSubscription(m.ID, p.ID)
+		require.NoError(t, repo.Create(f.ctx, active))
+		test := newSubscription(m.ID, p.ID)
+		test.Mode = mode.Test
+		require.NoError(t, repo.Create(f.ctx, test))
+		canceledAt := f.now.Add(-time.Hour)
+		canceled := newSubscription(m.ID, p.ID)
+		canceled.Status = subscription.StatusCanceled
+		canceled.CanceledAt = &canceledAt
+		require.NoError(t, repo.Create(f.ctx, canceled))
+		untouched := newSubscription(other.ID, f.plan(t, other.ID).ID)
+		require.NoError(t, repo.Create(f.ctx, untouched))
+
+		at := f.now.Add(time.Minute)
+		require.NoError(t, repo.CancelByMerchant(f.ctx, m.ID, at, "merchant deleted"))
+
+		for _, s := range []*subscription.Subscription{active, test} {
+			got, err := repo.GetByID(f.ctx, s.ID)
+			require.NoError(t, err)
+			assert.Equal(t, subscription.StatusCanceled, got.Status)
+			require.NotNil(t, got.CanceledAt)
+			assert.True(t, got.CanceledAt.Equal(at))
+
+			events, err := repo.ListEvents(f.ctx, s.ID, 10, 0)
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			assert.Equal(t, subscription.EventCanceled, events[0].Type)
+			assert.Equal(t, "merchant deleted", events[0].Message)
+		}
+
+		got, err := repo.GetByID(f.ctx, canceled.ID)
+		require.NoError(t, err)
+		assert.True(t, got.CanceledAt.Equal(canceledAt), "already canceled subscriptions keep their date")
+		events, err := repo.ListEvents(f.ctx, canceled.ID, 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+
+		got, err = repo.GetByID(f.ctx, untouched.ID)
+		require.NoError(t, err)
+		assert.Equal(t, subscription.StatusActive, got.Status)
+	})
+}
@@ -0,0 +1,138 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/payment-gateway/internal/domain"
+)
+
+func testTokenStore(t *testing.T, f *fixtures) {
+	store := f.TokenStore
+
+	refreshToken := func(t *testing.T, userID, familyID string) *domain.RefreshToken {
+		t.Helper()
+
+		token := &domain.RefreshToken{
+			UserID:    userID,
+			FamilyID:  familyID,
+			TokenHash: newHash(),
+			ExpiresAt: f.now.Add(24 * time.Hour),
+			CreatedAt: f.now,
+		}
+		require.NoError(t, store.StoreRefreshToken(token))
+		return token
+	}
+
+	t.Run("a refresh token rotates once", func(t *testing.T) {
+		u := f.user(t)
+		session := f.session(t, u.ID)
+
+		assert.Error(t, store.StoreRefreshToken(&domain.RefreshToken{
+			UserID:    u.ID,
+			FamilyID:  newID(),
+			TokenHash: newHash(),
+			ExpiresAt: f.now.Add(time.Hour),
+			CreatedAt: f.now,
+		}))
+
+		token := refreshToken(t, u.ID, session.ID)
+		got, err := store.GetRefreshToken(token.TokenHash)
+		require.NoError(t, err)
+		assert.NotEmpty(t, got.ID)
+		assert.Equal(t, session.ID, got.FamilyID)
+		assert.Nil(t, got.RotatedAt)
+
+		require.NoError(t, store.RotateRefreshToken(token.TokenHash))
+		assert.ErrorIs(t, store.RotateRefreshToken(token.TokenHash), domain.ErrRefreshTokenReused)
+		assert.ErrorIs(t, store.RotateRefreshToken(newHash()), domain.ErrRefreshTokenReused)
+
+		_, err = store.GetRefreshToken(newHash())
+		assert.Error(t, err)
+	})
+
+	t.Run("revoking a family revokes its tokens and session", func(t *testing.T) {
+		u := f.user(t)
+		session := f.session(t, u.ID)
+		token := refreshToken(t, u.ID, session.ID)
+		other := refreshToken(t, u.ID, f.session(t, u.ID).ID)
+
+		require.NoError(t, store.RevokeTokenFamily(session.ID))
+
+		got, err := store.GetRefreshToken(token.TokenHash)
+		require.NoError(t, err)
+		assert.NotNil(t, got.RevokedAt)
+		assert.ErrorIs(t, store.RotateRefreshToken(token.TokenHash), domain.ErrRefreshTokenReused)
+
+		gotSession, err := store.GetSession(session.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, gotSession.RevokedAt)
+
+		assert.NoError(t, store.RotateRefreshToken(other.TokenHash))
+	})
+
+	t.Run("sessions", func(t *testing.T) {
+		assert.Error(t, store.CreateSession(&domain.Session{
+			ID:         newID(),
+			UserID:     newID(),
+			CreatedAt:  f.now,
+			LastUsedAt: f.now,
+			ExpiresAt:  f.now.Add(time.Hour),
+		}))
+
+		u := f.user(t)
+		older := f.session(t, u.ID)
+		newer := f.session(t, u.ID)
+		expired := f.session(t, u.ID)
+		require.NoError(t, store.TouchSession(newer.ID, "198.51.100.7", f.now.Add(time.Minute), f.now.Add(2*time.Hour)))
+		require.NoError(t, store.TouchSession(expired.ID, expired.IP, f.now, f.now.Add(-time.Minute)))
+
+		got, err := store.GetSession(newer.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "198.51.100.7", got.IP)
+		assert.True(t, got.LastUsedAt.Equal(f.now.Add(time.Minute)))
+
+		_, err = store.GetSession(newID())
+		assert.ErrorIs(t, err, domain.ErrSessionNotFound)
+
+		sessions, err := store.ListSessions(u.ID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.Equal(t, newer.ID, sessions[0].ID)
+		assert.Equal(t, older.ID, sessions[1].ID)
+
+		sessions, err = store.ListSessions(newID())
+		require.NoError(t, err)
+		assert.NotNil(t, sessions)
+		assert.Empty(t, sessions)
+	})
+
+	t.Run("revoke other sessions", func(t *testing.T) {
+		u := f.user(t)
+		kept := f.session(t, u.ID)
+		keptToken := refreshToken(t, u.ID, kept.ID)
+		other := f.session(t, u.ID)
+		otherToken := refreshToken(t, u.ID, other.ID)
+		since := time.Now().Add(-time.Minute)
+
+		revoked, err := store.RevokeOtherSessions(u.ID, kept.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{other.ID}, revoked)
+
+		revoked, err = store.RevokeOtherSessions(u.ID, kept.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, revoked)
+		assert.Empty(t, revoked)
+
+		assert.ErrorIs(t, store.RotateRefreshToken(otherToken.TokenHash), domain.ErrRefreshTokenReused)
+		assert.NoError(t, store.RotateRefreshToken(keptToken.TokenHash))
+
+		revoked, err = store.ListRevokedSessions(since)
+		require.NoError(t, err)
+		assert.Contains(t, revoked, other.ID)
+		assert.NotContains(t, revoked, kept.ID)
+	})
+}
@@ -0,0 +1,151 @@
+package storagetest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/user"
+)
+
+func testUsers(t *testing.T, f *fixtures) {
+	repo := f.Repositories.Users()
+
+	t.Run("email is unique", func(t *testing.T) {
+		u := f.user(t)
+
+		got, err := repo.GetByEmail(f.ctx, u.Email)
+		require.NoError(t, err)
+		assert.Equal(t, u.ID, got.ID)
+
+		duplicate := *u
+		duplicate.ID = ""
+		assert.Error(t, repo.Create(f.ctx, &duplicate))
+	})
+
+	t.Run("a token is used once", func(t *testing.T) {
+		u := f.user(t)
+		token := &user.Token{
+			UserID:    u.ID,
+			Purpose:   user.TokenEmailVerification,
+			Hash:      newHash(),
+			ExpiresAt: f.now.Add(time.Hour),
+			CreatedAt: f.now,
+		}
+		require.NoError(t, repo.CreateToken(f.ctx, token))
+
+		duplicate := *token
+		duplicate.ID = ""
+		assert.Error(t, repo.CreateToken(f.ctx, &duplicate))
+
+		var wg sync.WaitGroup
+		results := make(chan error, 5)
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results <- repo.UseToken(f.ctx, token.ID, f.now)
+			}()
+		}
+		wg.Wait()
+		close(results)
+
+		used := 0
+		for err := range results {
+			if err == nil {
+				used++
+			}
+		}
+		assert.Equal(t, 1, used)
+
+		got, err := repo.GetTokenByHash(f.ctx, token.Hash)
+		require.NoError(t, err)
+		require.NotNil(t, got.UsedAt)
+	})
+
+	t.Run("invalidate tokens only touches the purpose", func(t *testing.T) {
+		u := f.user(t)
+		create := func(purpose user.TokenPurpose) *user.Token {
+			token := &user.Token{
+				UserID:    u.ID,
+				Purpose:   purpose,
+				Hash:      newHash(),
+				ExpiresAt: f.now.Add(time.Hour),
+				CreatedAt: f.now,
+			}
+			require.NoError(t, repo.CreateToken(f.ctx, token))
+			return token
+		}
+		verification := create(user.TokenEmailVerification)
+		reset := create(user.TokenPasswordReset)
+
+		require.NoError(t, repo.InvalidateTokens(f.ctx, u.ID, user.TokenPasswordReset, f.now))
+
+		assert.NoError(t, repo.UseToken(f.ctx, verification.ID, f.now))
+		assert.Error(t, repo.UseToken(f.ctx, reset.ID, f.now))
+	})
+
+	t.Run("totp counter only moves forward", func(t *testing.T) {
+		u := f.user(t)
+
+		require.NoError(t, repo.AdvanceTOTPCounter(f.ctx, u.ID, 10))
+		assert.Error(t, repo.AdvanceTOTPCounter(f.ctx, u.ID, 10))
+		assert.Error(t, repo.AdvanceTOTPCounter(f.ctx, u.ID, 9))
+		assert.NoError(t, repo.AdvanceTOTPCounter(f.ctx, u.ID, 11))
+	})
+
+	t.Run("recovery codes are replaced atomically", func(t *testing.T) {
+		u := f.user(t)
+		kept := newHash()
+		require.NoError(t, repo.ReplaceRecoveryCodes(f.ctx, u.ID, []string{kept, newHash()}, f.now))
+
+		duplicate := newHash()
+		assert.Error(t, repo.ReplaceRecoveryCodes(f.ctx, u.ID, []string{duplicate, duplicate}, f.now))
+
+		assert.Error(t, repo.UseRecoveryCode(f.ctx, u.ID, duplicate, f.now))
+		require.NoError(t, repo.UseRecoveryCode(f.ctx, u.ID, kept, f.now))
+		assert.Error(t, repo.UseRecoveryCode(f.ctx, u.ID, kept, f.now))
+
+		assert.Error(t, repo.ReplaceRecoveryCodes(f.ctx, newID(), []string{newHash()}, f.now))
+	})
+
+	t.Run("login throttle", func(t *testing.T) {
+		key := newEmail()
+		window := 15 * time.Minute
+
+		throttle, err := repo.GetLoginThrottle(f.ctx, user.ThrottleAccount, key)
+		require.NoError(t, err)
+		assert.Nil(t, throttle)
+
+		_, err = repo.RecordLoginFailure(f.ctx, user.ThrottleAccount, key, f.now, window)
+		require.NoError(t, err)
+		throttle, err = repo.RecordLoginFailure(f.ctx, user.ThrottleAccount, key, f.now.Add(time.Minute), window)
+		require.NoError(t, err)
+		assert.Equal(t, 2, throttle.Failures)
+
+		until := f.now.Add(time.Hour)
+		require.NoError(t, repo.LockLogin(f.ctx, user.ThrottleAccount, key, until))
+		throttle, err = repo.GetLoginThrottle(f.ctx, user.ThrottleAccount, key)
+		require.NoError(t, err)
+		require.NotNil(t, throttle.LockedUntil)
+		assert.True(t, throttle.LockedUntil.Equal(until))
+
+		ip, err := repo.GetLoginThrottle(f.ctx, user.ThrottleIP, key)
+		require.NoError(t, err)
+		assert.Nil(t, ip)
+
+		// A failure after the window starts counting again.
+		throttle, err = repo.RecordLoginFailure(f.ctx, user.ThrottleAccount, key, f.now.Add(time.Hour), window)
+		require.NoError(t, err)
+		assert.Equal(t, 1, throttle.Failures)
+		assert.Nil(t, throttle.LockedUntil)
+
+		require.NoError(t, repo.ClearLoginThrottle(f.ctx, user.ThrottleAccount, key))
+		throttle, err = repo.GetLoginThrottle(f.ctx, user.ThrottleAccount, key)
+		require.NoError(t, err)
+		assert.Nil(t, throttle)
+	})
+}
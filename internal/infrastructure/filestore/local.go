@@ -0,0 +1,88 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type localFileStore struct {
+	dir string
+}
+
+// NewLocalFileStore keeps files on the local disk under dir. It suits single
+// instance deployments; replicas need a shared volume or an object store
+// implementation of ports.FileStore.
+func NewLocalFileStore(dir string) ports.FileStore {
+	return &localFileStore{dir: dir}
+}
+
+func (s *localFileStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if clean == "." || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file key %q", key)
+	}
+	return filepath.Join(s.dir, clean), nil
+}
+
+func (s *localFileStore) Put(ctx context.Context, key string, content io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Write to a temporary file first so a failed upload never leaves a
+	// partial file under the final key.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	return nil
+}
+
+func (s *localFileStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return f, nil
+}
+
+func (s *localFileStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package fxrates
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/currency"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type cachedRateProvider struct {
+	next   ports.FXRateProvider
+	bucket time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	current time.Time
+	rates   map[string]*currency.Rate
+}
+
+// NewCachedRateProvider caches rates per currency pair within fixed time
+// buckets. Every payment created inside the same bucket gets exactly the same
+// rate, and the cache is dropped as soon as a new bucket starts.
+func NewCachedRateProvider(next ports.FXRateProvider, bucket time.Duration) ports.FXRateProvider {
+	return &cachedRateProvider{
+		next:   next,
+		bucket: bucket,
+		now:    time.Now,
+		rates:  make(map[string]*currency.Rate),
+	}
+}
+
+func (c *cachedRateProvider) GetRate(ctx context.Context, from, to string) (*currency.Rate, error) {
+	bucketStart := c.now().UTC().Truncate(c.bucket)
+	key := from + "/" + to
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !bucketStart.Equal(c.current) {
+		c.current = bucketStart
+		c.rates = make(map[string]*currency.Rate)
+	}
+
+	if rate, ok := c.rates[key]; ok {
+		cached := *rate
+		return &cached, nil
+	}
+
+	rate, err := c.next.GetRate(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := *rate
+	cached.AsOf = bucketStart
+	c.rates[key] = &cached
+
+	result := cached
+	return &result, nil
+}
@@ -0,0 +1,73 @@
+package fxrates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/currency"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+// ratesFile is the on-disk format: every rate is the price of one unit of
+// Base in the keyed currency.
+type ratesFile struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+type staticRateProvider struct {
+	path string
+}
+
+// NewStaticRateProvider serves rates from a JSON file. The file is re-read on
+// every lookup so that it can be updated without a restart; wrap the provider
+// with NewCachedRateProvider to avoid hitting the disk for each payment.
+func NewStaticRateProvider(path string) ports.FXRateProvider {
+	return &staticRateProvider{path: path}
+}
+
+func (p *staticRateProvider) GetRate(ctx context.Context, from, to string) (*currency.Rate, error) {
+	if from == to {
+		return &currency.Rate{From: from, To: to, Value: 1, AsOf: time.Now()}, nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fx rates file: %w", err)
+	}
+
+	var file ratesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse fx rates file: %w", err)
+	}
+
+	fromRate, err := file.rate(from)
+	if err != nil {
+		return nil, err
+	}
+	toRate, err := file.rate(to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &currency.Rate{
+		From:  from,
+		To:    to,
+		Value: toRate / fromRate,
+		AsOf:  time.Now(),
+	}, nil
+}
+
+func (f *ratesFile) rate(code string) (float64, error) {
+	if code == f.Base {
+		return 1, nil
+	}
+	rate, ok := f.Rates[code]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("no fx rate available for %s", code)
+	}
+	return rate, nil
+}
@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type fileMailer struct {
+	from string
+	dir  string
+	out  io.Writer
+
+	mu sync.Mutex
+}
+
+// NewFileMailer does not deliver mail. It writes each message as an .eml file
+// in dir, or to stdout when dir is empty, for local development and tests.
+func NewFileMailer(from, dir string) ports.Mailer {
+	return &fileMailer{from: from, dir: dir, out: os.Stdout}
+}
+
+func (m *fileMailer) Send(ctx context.Context, to, subject, body string) error {
+	now := time.Now()
+	msg, err := buildMessage(m.from, to, subject, body, now)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.dir == "" {
+		_, err := fmt.Fprintf(m.out, "%s\n", msg)
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(m.dir, now.UTC().Format("20060102T150405")+"-*.eml")
+	if err != nil {
+		return fmt.Errorf("failed to create mail file: %w", err)
+	}
+	if _, err := f.Write(msg); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+
+	return nil
+}
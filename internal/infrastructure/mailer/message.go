@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// buildMessage renders a plain text RFC 5322 message. Addresses and the
+// subject must not contain line breaks, which would let them inject headers.
+func buildMessage(from, to, subject, body string, date time.Time) ([]byte, error) {
+	for _, v := range []string{from, to, subject} {
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, fmt.Errorf("mail header contains a line break: %q", v)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes(), nil
+}
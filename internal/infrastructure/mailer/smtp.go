@@ -0,0 +1,100 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+type smtpMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPMailer sends mail through an SMTP server. The connection is upgraded
+// with STARTTLS when the server offers it; credentials, when set, are only
+// sent over TLS or to localhost, as net/smtp enforces.
+func NewSMTPMailer(host string, port int, username, password, from string) ports.Mailer {
+	return &smtpMailer{
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (m *smtpMailer) Send(ctx context.Context, to, subject, body string) error {
+	msg, err := buildMessage(m.from, to, subject, body, time.Now())
+	if err != nil {
+		return err
+	}
+
+	sender, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	recipient, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to mail server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to mail server: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+
+	if m.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("failed to authenticate with mail server: %w", err)
+		}
+	}
+
+	if err := c.Mail(sender.Address); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	if err := c.Rcpt(recipient.Address); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+
+	return c.Quit()
+}
@@ -67,6 +67,22 @@ var (
 		},
 		[]string{"status"},
 	)
+
+	LoginLockouts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "login_lockouts_total",
+			Help: "Total number of accounts and IP addresses locked out after failed logins",
+		},
+		[]string{"scope"},
+	)
+
+	RateLimitedRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limited_requests_total",
+			Help: "Total number of requests refused for exceeding a rate limit",
+		},
+		[]string{"group", "kind"},
+	)
 )
 
 func InitMetrics() {
@@ -77,6 +93,8 @@ func InitMetrics() {
 	prometheus.MustRegister(APIRequestDuration)
 	prometheus.MustRegister(DatabaseQueryDuration)
 	prometheus.MustRegister(AuthenticationAttempts)
+	prometheus.MustRegister(LoginLockouts)
+	prometheus.MustRegister(RateLimitedRequests)
 }
 
 func MetricsHandler() http.Handler {
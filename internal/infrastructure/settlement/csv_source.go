@@ -0,0 +1,183 @@
+package settlement
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/popeskul/payment-gateway/internal/core/domain/reconciliation"
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+// CSVFormat describes how an acquirer lays out its settlement CSV. Columns are
+// looked up by header name, so their order in the file does not matter.
+type CSVFormat struct {
+	Delimiter         string
+	TimeLayout        string
+	ReferenceColumn   string
+	TypeColumn        string
+	AmountColumn      string
+	CurrencyColumn    string
+	ProcessedAtColumn string
+	PaymentType       string
+	RefundType        string
+}
+
+type csvFileSource struct {
+	dir    string
+	format CSVFormat
+}
+
+// NewCSVFileSource reads settlement files with a .csv extension from dir.
+func NewCSVFileSource(dir string, format CSVFormat) ports.SettlementFileSource {
+	return &csvFileSource{dir: dir, format: format}
+}
+
+func (s *csvFileSource) ListFiles(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read settlement directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".csv") {
+			continue
+		}
+		files = append(files, entry.Name())
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+func (s *csvFileSource) ReadFile(ctx context.Context, name string) ([]*reconciliation.SettlementLine, error) {
+	// Only plain file names are accepted so a caller can't escape the directory.
+	if name != filepath.Base(name) {
+		return nil, fmt.Errorf("invalid settlement file name %q", name)
+	}
+
+	f, err := os.Open(filepath.Join(s.dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open settlement file: %w", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.TrimLeadingSpace = true
+	if s.format.Delimiter != "" {
+		delimiter, _ := utf8.DecodeRuneInString(s.format.Delimiter)
+		reader.Comma = delimiter
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settlement file header: %w", err)
+	}
+	columns, err := s.columnIndexes(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []*reconciliation.SettlementLine
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read settlement file line %d: %w", row, err)
+		}
+
+		line, err := s.parseLine(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", row, err)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+type columnIndexes struct {
+	reference, recordType, amount, currency, processedAt int
+}
+
+func (s *csvFileSource) columnIndexes(header []string) (*columnIndexes, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		positions[strings.TrimSpace(name)] = i
+	}
+
+	lookup := func(name string) (int, error) {
+		i, ok := positions[name]
+		if !ok {
+			return 0, fmt.Errorf("settlement file is missing column %q", name)
+		}
+		return i, nil
+	}
+
+	var c columnIndexes
+	var err error
+	if c.reference, err = lookup(s.format.ReferenceColumn); err != nil {
+		return nil, err
+	}
+	if c.recordType, err = lookup(s.format.TypeColumn); err != nil {
+		return nil, err
+	}
+	if c.amount, err = lookup(s.format.AmountColumn); err != nil {
+		return nil, err
+	}
+	if c.currency, err = lookup(s.format.CurrencyColumn); err != nil {
+		return nil, err
+	}
+	if c.processedAt, err = lookup(s.format.ProcessedAtColumn); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (s *csvFileSource) parseLine(record []string, c *columnIndexes) (*reconciliation.SettlementLine, error) {
+	var recordType reconciliation.RecordType
+	switch value := strings.TrimSpace(record[c.recordType]); {
+	case strings.EqualFold(value, s.format.PaymentType):
+		recordType = reconciliation.RecordTypePayment
+	case strings.EqualFold(value, s.format.RefundType):
+		recordType = reconciliation.RecordTypeRefund
+	default:
+		return nil, fmt.Errorf("unknown transaction type %q", value)
+	}
+
+	amount, err := strconv.ParseFloat(strings.TrimSpace(record[c.amount]), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q", record[c.amount])
+	}
+
+	processedAt, err := time.Parse(s.format.TimeLayout, strings.TrimSpace(record[c.processedAt]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid processed at %q", record[c.processedAt])
+	}
+
+	return &reconciliation.SettlementLine{
+		AcquirerReference: strings.TrimSpace(record[c.reference]),
+		RecordType:        recordType,
+		// Refunds are sometimes reported as negative amounts.
+		Amount:      math.Abs(amount),
+		Currency:    strings.ToUpper(strings.TrimSpace(record[c.currency])),
+		ProcessedAt: processedAt,
+	}, nil
+}
@@ -0,0 +1,26 @@
+package validator
+
+import (
+	"strings"
+	"unicode"
+)
+
+// IsStatementDescriptor applies the card networks' rules for the text shown on
+// a cardholder's statement: 5 to 22 printable ASCII characters, at least one
+// letter, and none of the characters acquirers reject.
+func IsStatementDescriptor(s string) bool {
+	if len(s) < 5 || len(s) > 22 || strings.ContainsAny(s, `<>\'"*`) {
+		return false
+	}
+
+	hasLetter := false
+	for _, r := range s {
+		if r < ' ' || r > '~' {
+			return false
+		}
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+	return hasLetter
+}
@@ -19,14 +19,7 @@ func Field(field interface{}, tag string) error {
 }
 
 func ValidateCurrency(fl validator.FieldLevel) bool {
-	currency := fl.Field().String()
-	validCurrencies := map[string]bool{
-		"USD": true,
-		"EUR": true,
-		"GBP": true,
-		// other
-	}
-	return validCurrencies[currency]
+	return IsCurrency(fl.Field().String())
 }
 
 func init() {
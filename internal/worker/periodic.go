@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/popeskul/payment-gateway/internal/core/ports"
+)
+
+// RunPeriodically calls job once immediately and then every interval until ctx
+// is cancelled. Errors are logged and do not stop the loop.
+func RunPeriodically(ctx context.Context, interval time.Duration, logger ports.Logger, name string, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		logger.Debug("Running background job", "job", name)
+		if err := job(ctx); err != nil {
+			logger.Error("Background job failed", "job", name, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Background job stopped", "job", name)
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,15 @@
+ALTER TABLE refunds DROP CONSTRAINT IF EXISTS chk_refund_currency;
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_fx_rate;
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_settlement_currency;
+ALTER TABLE merchants DROP CONSTRAINT IF EXISTS chk_merchant_settlement_currency;
+
+ALTER TABLE refunds DROP COLUMN IF EXISTS settlement_currency;
+ALTER TABLE refunds DROP COLUMN IF EXISTS settlement_amount;
+ALTER TABLE refunds DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS fx_rate_timestamp;
+ALTER TABLE payments DROP COLUMN IF EXISTS fx_rate;
+ALTER TABLE payments DROP COLUMN IF EXISTS settlement_currency;
+ALTER TABLE payments DROP COLUMN IF EXISTS settlement_amount;
+
+ALTER TABLE merchants DROP COLUMN IF EXISTS settlement_currency;
@@ -0,0 +1,34 @@
+ALTER TABLE merchants ADD COLUMN IF NOT EXISTS settlement_currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS settlement_amount DECIMAL(10, 2);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS settlement_currency VARCHAR(3);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS fx_rate DECIMAL(18, 8);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS fx_rate_timestamp TIMESTAMP;
+
+-- Existing payments were never converted: they settle in their own currency.
+UPDATE payments
+SET settlement_amount = amount, settlement_currency = currency, fx_rate = 1, fx_rate_timestamp = created_at
+WHERE settlement_currency IS NULL;
+
+ALTER TABLE payments ALTER COLUMN settlement_amount SET NOT NULL;
+ALTER TABLE payments ALTER COLUMN settlement_currency SET NOT NULL;
+ALTER TABLE payments ALTER COLUMN fx_rate SET NOT NULL;
+ALTER TABLE payments ALTER COLUMN fx_rate_timestamp SET NOT NULL;
+
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS currency VARCHAR(3);
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS settlement_amount DECIMAL(10, 2);
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS settlement_currency VARCHAR(3);
+
+UPDATE refunds r
+SET currency = p.currency, settlement_amount = ROUND(r.amount * p.fx_rate, 2), settlement_currency = p.settlement_currency
+FROM payments p
+WHERE r.payment_id = p.id AND r.currency IS NULL;
+
+ALTER TABLE refunds ALTER COLUMN currency SET NOT NULL;
+ALTER TABLE refunds ALTER COLUMN settlement_amount SET NOT NULL;
+ALTER TABLE refunds ALTER COLUMN settlement_currency SET NOT NULL;
+
+ALTER TABLE merchants ADD CONSTRAINT chk_merchant_settlement_currency CHECK (settlement_currency ~ '^[A-Z]{3}$');
+ALTER TABLE payments ADD CONSTRAINT chk_settlement_currency CHECK (settlement_currency ~ '^[A-Z]{3}$');
+ALTER TABLE payments ADD CONSTRAINT chk_fx_rate CHECK (fx_rate > 0);
+ALTER TABLE refunds ADD CONSTRAINT chk_refund_currency CHECK (currency ~ '^[A-Z]{3}$');
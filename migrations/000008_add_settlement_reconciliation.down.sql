@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS reconciliation_discrepancies;
+DROP TABLE IF EXISTS reconciliation_reports;
+
+DROP INDEX IF EXISTS idx_refunds_processed_at;
+DROP INDEX IF EXISTS idx_payments_processed_at;
+DROP INDEX IF EXISTS idx_refunds_acquirer_reference;
+DROP INDEX IF EXISTS idx_payments_acquirer_reference;
+
+ALTER TABLE refunds DROP COLUMN IF EXISTS processed_at;
+ALTER TABLE refunds DROP COLUMN IF EXISTS acquirer_reference;
+ALTER TABLE payments DROP COLUMN IF EXISTS processed_at;
+ALTER TABLE payments DROP COLUMN IF EXISTS acquirer_reference;
@@ -0,0 +1,48 @@
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS acquirer_reference VARCHAR(64);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS processed_at TIMESTAMP;
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS acquirer_reference VARCHAR(64);
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS processed_at TIMESTAMP;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_acquirer_reference ON payments(acquirer_reference) WHERE acquirer_reference IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refunds_acquirer_reference ON refunds(acquirer_reference) WHERE acquirer_reference IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_payments_processed_at ON payments(processed_at);
+CREATE INDEX IF NOT EXISTS idx_refunds_processed_at ON refunds(processed_at);
+
+CREATE TABLE IF NOT EXISTS reconciliation_reports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    file_name VARCHAR(255) UNIQUE NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    period_start TIMESTAMP,
+    period_end TIMESTAMP,
+    total_lines INTEGER NOT NULL DEFAULT 0,
+    matched_count INTEGER NOT NULL DEFAULT 0,
+    discrepancy_count INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    CONSTRAINT chk_reconciliation_report_status CHECK (status IN ('processing', 'completed', 'failed'))
+);
+
+CREATE TABLE IF NOT EXISTS reconciliation_discrepancies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    report_id UUID NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    record_type VARCHAR(20) NOT NULL,
+    record_id UUID,
+    acquirer_reference VARCHAR(64) NOT NULL,
+    expected_amount DECIMAL(10, 2),
+    actual_amount DECIMAL(10, 2),
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    resolution TEXT,
+    resolved_by UUID,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (report_id) REFERENCES reconciliation_reports(id) ON DELETE CASCADE,
+    FOREIGN KEY (resolved_by) REFERENCES users(id),
+    CONSTRAINT chk_discrepancy_type CHECK (type IN ('missing_internally', 'missing_at_acquirer', 'amount_mismatch')),
+    CONSTRAINT chk_discrepancy_record_type CHECK (record_type IN ('payment', 'refund')),
+    CONSTRAINT chk_discrepancy_status CHECK (status IN ('open', 'resolved'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_discrepancies_report_id ON reconciliation_discrepancies(report_id);
@@ -0,0 +1,5 @@
+-- Canceled refunds never reached the acquirer, so failed is the closest match.
+UPDATE refunds SET status = 'failed' WHERE status = 'canceled';
+
+ALTER TABLE refunds DROP CONSTRAINT IF EXISTS chk_refund_status;
+ALTER TABLE refunds ADD CONSTRAINT chk_refund_status CHECK (status IN ('pending', 'completed', 'failed'));
@@ -0,0 +1,18 @@
+-- Authorized, expired and voided payments have no equivalent in the older
+-- schema, and calling them failed would lose what happened at the acquirer.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM payments WHERE status IN ('authorized', 'expired', 'voided')) THEN
+        RAISE EXCEPTION 'payments are authorized, expired or voided';
+    END IF;
+END $$;
+
+DROP TABLE IF EXISTS payment_status_transitions;
+
+DROP INDEX IF EXISTS idx_payments_authorization_expires_at;
+DROP INDEX IF EXISTS idx_payments_status_created_at;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payment_status;
+ALTER TABLE payments ADD CONSTRAINT chk_payment_status CHECK (status IN ('pending', 'completed', 'failed'));
+
+ALTER TABLE payments DROP COLUMN IF EXISTS authorization_expires_at;
@@ -0,0 +1,20 @@
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS authorization_expires_at TIMESTAMP;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payment_status;
+ALTER TABLE payments ADD CONSTRAINT chk_payment_status
+    CHECK (status IN ('pending', 'authorized', 'completed', 'failed', 'expired', 'voided'));
+
+CREATE INDEX IF NOT EXISTS idx_payments_status_created_at ON payments(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_payments_authorization_expires_at ON payments(authorization_expires_at) WHERE status = 'authorized';
+
+CREATE TABLE IF NOT EXISTS payment_status_transitions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    payment_id UUID NOT NULL,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (payment_id) REFERENCES payments(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_status_transitions_payment_id ON payment_status_transitions(payment_id);
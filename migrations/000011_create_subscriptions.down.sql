@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_payments_subscription_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS subscription_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS payment_token;
+
+DROP TABLE IF EXISTS subscription_events;
+DROP TABLE IF EXISTS subscriptions;
+DROP TABLE IF EXISTS plans;
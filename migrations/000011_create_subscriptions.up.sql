@@ -0,0 +1,64 @@
+CREATE TABLE IF NOT EXISTS plans (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    merchant_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    interval VARCHAR(10) NOT NULL,
+    interval_count INTEGER NOT NULL DEFAULT 1,
+    trial_period_days INTEGER NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (merchant_id) REFERENCES merchants(id),
+    CONSTRAINT chk_plan_amount CHECK (amount > 0),
+    CONSTRAINT chk_plan_currency CHECK (currency ~ '^[A-Z]{3}$'),
+    CONSTRAINT chk_plan_interval CHECK (interval IN ('day', 'week', 'month', 'year')),
+    CONSTRAINT chk_plan_interval_count CHECK (interval_count > 0),
+    CONSTRAINT chk_plan_trial_period_days CHECK (trial_period_days >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    merchant_id UUID NOT NULL,
+    plan_id UUID NOT NULL,
+    payment_method VARCHAR(50) NOT NULL,
+    payment_token VARCHAR(255),
+    status VARCHAR(20) NOT NULL,
+    current_period_start TIMESTAMP NOT NULL,
+    current_period_end TIMESTAMP NOT NULL,
+    trial_end TIMESTAMP,
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE,
+    canceled_at TIMESTAMP,
+    proration_balance DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    next_retry_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (merchant_id) REFERENCES merchants(id),
+    FOREIGN KEY (plan_id) REFERENCES plans(id),
+    CONSTRAINT chk_subscription_status CHECK (status IN ('trialing', 'active', 'past_due', 'unpaid', 'canceled'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_plans_merchant_id ON plans(merchant_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_merchant_id ON subscriptions(merchant_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_due ON subscriptions(status, current_period_end);
+
+CREATE TABLE IF NOT EXISTS subscription_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subscription_id UUID NOT NULL,
+    merchant_id UUID NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    payment_id UUID,
+    message TEXT,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (subscription_id) REFERENCES subscriptions(id),
+    FOREIGN KEY (payment_id) REFERENCES payments(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_events_subscription_id ON subscription_events(subscription_id);
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS payment_token VARCHAR(255);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS subscription_id UUID REFERENCES subscriptions(id);
+
+CREATE INDEX IF NOT EXISTS idx_payments_subscription_id ON payments(subscription_id);
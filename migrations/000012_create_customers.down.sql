@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_refunds_customer_id;
+DROP INDEX IF EXISTS idx_payments_customer_id;
+ALTER TABLE refunds DROP COLUMN IF EXISTS customer_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS payment_method_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS customer_id;
+
+DROP TABLE IF EXISTS customer_payment_methods;
+DROP TABLE IF EXISTS customers;
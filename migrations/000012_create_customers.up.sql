@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS customers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    merchant_id UUID NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    phone VARCHAR(50),
+    billing_address JSONB,
+    shipping_address JSONB,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (merchant_id) REFERENCES merchants(id),
+    CONSTRAINT uq_customers_merchant_email UNIQUE (merchant_id, email)
+);
+
+CREATE INDEX IF NOT EXISTS idx_customers_merchant_id ON customers(merchant_id);
+
+CREATE TABLE IF NOT EXISTS customer_payment_methods (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    customer_id UUID NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    token VARCHAR(255) NOT NULL,
+    brand VARCHAR(50),
+    last4 VARCHAR(4),
+    exp_month INTEGER,
+    exp_year INTEGER,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_payment_methods_customer_id ON customer_payment_methods(customer_id);
+-- At most one default payment method per customer.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_payment_methods_default
+    ON customer_payment_methods(customer_id) WHERE is_default;
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS customer_id UUID REFERENCES customers(id) ON DELETE SET NULL;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS payment_method_id UUID REFERENCES customer_payment_methods(id) ON DELETE SET NULL;
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS customer_id UUID REFERENCES customers(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_payments_customer_id ON payments(customer_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_customer_id ON refunds(customer_id);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS merchant_members (
+    merchant_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    invited_by UUID,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (merchant_id, user_id),
+    FOREIGN KEY (merchant_id) REFERENCES merchants(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT chk_merchant_member_role CHECK (role IN ('owner', 'admin', 'developer', 'support', 'read_only'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchant_members_user_id ON merchant_members(user_id);
+
+-- Existing merchants had no members; the user registered with the merchant's
+-- email becomes its owner.
+INSERT INTO merchant_members (merchant_id, user_id, role, created_at, updated_at)
+SELECT m.id, u.id, 'owner', NOW(), NOW()
+FROM merchants m
+JOIN users u ON u.email = m.email
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,8 @@
+-- Plaintext keys cannot be recovered from their hashes; merchants get a
+-- placeholder unique value.
+ALTER TABLE merchants ADD COLUMN IF NOT EXISTS api_key VARCHAR(255);
+UPDATE merchants SET api_key = id::text WHERE api_key IS NULL;
+ALTER TABLE merchants ALTER COLUMN api_key SET NOT NULL;
+ALTER TABLE merchants ADD CONSTRAINT merchants_api_key_key UNIQUE (api_key);
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY,
+    merchant_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(32) NOT NULL,
+    key_hash CHAR(64) UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    rotated_to UUID,
+    created_by UUID,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (merchant_id) REFERENCES merchants(id) ON DELETE CASCADE,
+    FOREIGN KEY (rotated_to) REFERENCES api_keys(id) ON DELETE SET NULL,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_merchant_id ON api_keys(merchant_id);
+
+-- Existing plaintext keys have been readable by anyone with access to the
+-- database, so they are not carried over as working keys. They are kept as
+-- revoked keys, so merchants can see what was replaced, and each merchant
+-- creates a new key before its integration works again. Then the plaintext
+-- column is dropped.
+INSERT INTO api_keys (id, merchant_id, name, prefix, key_hash, scopes, revoked_at, created_at)
+SELECT uuid_generate_v4(), id, 'Legacy key', LEFT(api_key, 11), encode(sha256(api_key::bytea), 'hex'),
+       ARRAY['read'], NOW(), created_at
+FROM merchants
+WHERE api_key <> '';
+
+ALTER TABLE merchants DROP COLUMN IF EXISTS api_key;
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS merchant_documents;
+DROP TABLE IF EXISTS merchant_beneficial_owners;
+
+ALTER TABLE users DROP COLUMN IF EXISTS is_staff;
+
+DROP INDEX IF EXISTS idx_merchants_status;
+ALTER TABLE merchants DROP CONSTRAINT IF EXISTS chk_merchant_status;
+ALTER TABLE merchants DROP COLUMN IF EXISTS reviewed_by;
+ALTER TABLE merchants DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE merchants DROP COLUMN IF EXISTS submitted_at;
+ALTER TABLE merchants DROP COLUMN IF EXISTS business_details;
+ALTER TABLE merchants DROP COLUMN IF EXISTS status_reason;
+ALTER TABLE merchants DROP COLUMN IF EXISTS status;
@@ -0,0 +1,46 @@
+-- Merchants that already exist were activated without KYC and stay active;
+-- new merchants start as drafts.
+ALTER TABLE merchants ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active';
+ALTER TABLE merchants ALTER COLUMN status SET DEFAULT 'draft';
+ALTER TABLE merchants ADD CONSTRAINT chk_merchant_status
+    CHECK (status IN ('draft', 'submitted', 'in_review', 'active', 'rejected', 'suspended'));
+ALTER TABLE merchants ADD COLUMN IF NOT EXISTS status_reason TEXT;
+ALTER TABLE merchants ADD COLUMN IF NOT EXISTS business_details JSONB;
+ALTER TABLE merchants ADD COLUMN IF NOT EXISTS submitted_at TIMESTAMP;
+ALTER TABLE merchants ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP;
+ALTER TABLE merchants ADD COLUMN IF NOT EXISTS reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_merchants_status ON merchants(status);
+
+-- Staff are platform operators who review merchant applications.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_staff BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS merchant_beneficial_owners (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    merchant_id UUID NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    date_of_birth DATE NOT NULL,
+    nationality CHAR(2) NOT NULL,
+    ownership_percent DECIMAL(5, 2) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (merchant_id) REFERENCES merchants(id) ON DELETE CASCADE,
+    CONSTRAINT chk_ownership_percent CHECK (ownership_percent > 0 AND ownership_percent <= 100)
+);
+
+CREATE INDEX IF NOT EXISTS idx_beneficial_owners_merchant_id ON merchant_beneficial_owners(merchant_id);
+
+CREATE TABLE IF NOT EXISTS merchant_documents (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    merchant_id UUID NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(255) UNIQUE NOT NULL,
+    uploaded_by UUID,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (merchant_id) REFERENCES merchants(id) ON DELETE CASCADE,
+    FOREIGN KEY (uploaded_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchant_documents_merchant_id ON merchant_documents(merchant_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS uq_merchants_email_active;
+ALTER TABLE merchants ADD CONSTRAINT merchants_email_key UNIQUE (email);
+
+ALTER TABLE merchants DROP COLUMN IF EXISTS deletion_reason;
+ALTER TABLE merchants DROP COLUMN IF EXISTS deleted_at;
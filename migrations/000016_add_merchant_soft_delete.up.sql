@@ -0,0 +1,7 @@
+-- Offboarded merchants are kept so that their payments and refunds survive.
+ALTER TABLE merchants ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE merchants ADD COLUMN IF NOT EXISTS deletion_reason TEXT;
+
+-- The email of a deleted merchant can be used again.
+ALTER TABLE merchants DROP CONSTRAINT IF EXISTS merchants_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_merchants_email_active ON merchants(email) WHERE deleted_at IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_payments_merchant_created_at;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS statement_descriptor;
+
+ALTER TABLE merchants DROP COLUMN IF EXISTS settings;
@@ -0,0 +1,7 @@
+-- Merchants without settings use the defaults: no limits and auto-capture on.
+ALTER TABLE merchants ADD COLUMN IF NOT EXISTS settings JSONB;
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS statement_descriptor VARCHAR(22);
+
+-- Volume caps sum a merchant's payments since the start of the day or month.
+CREATE INDEX IF NOT EXISTS idx_payments_merchant_created_at ON payments(merchant_id, created_at);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_subscriptions_merchant_mode;
+DROP INDEX IF EXISTS idx_customers_merchant_mode;
+DROP INDEX IF EXISTS idx_payments_merchant_mode_created_at;
+CREATE INDEX IF NOT EXISTS idx_payments_merchant_created_at ON payments(merchant_id, created_at);
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS mode;
+ALTER TABLE plans DROP COLUMN IF EXISTS mode;
+ALTER TABLE customers DROP COLUMN IF EXISTS mode;
+ALTER TABLE refunds DROP COLUMN IF EXISTS mode;
+ALTER TABLE payments DROP COLUMN IF EXISTS mode;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS mode;
@@ -0,0 +1,13 @@
+-- Existing data was all created against the live acquirer.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS mode VARCHAR(4) NOT NULL DEFAULT 'live' CHECK (mode IN ('live', 'test'));
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS mode VARCHAR(4) NOT NULL DEFAULT 'live' CHECK (mode IN ('live', 'test'));
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS mode VARCHAR(4) NOT NULL DEFAULT 'live' CHECK (mode IN ('live', 'test'));
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS mode VARCHAR(4) NOT NULL DEFAULT 'live' CHECK (mode IN ('live', 'test'));
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS mode VARCHAR(4) NOT NULL DEFAULT 'live' CHECK (mode IN ('live', 'test'));
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS mode VARCHAR(4) NOT NULL DEFAULT 'live' CHECK (mode IN ('live', 'test'));
+
+-- Lists and volume caps are always scoped to one mode.
+DROP INDEX IF EXISTS idx_payments_merchant_created_at;
+CREATE INDEX IF NOT EXISTS idx_payments_merchant_mode_created_at ON payments(merchant_id, mode, created_at);
+CREATE INDEX IF NOT EXISTS idx_customers_merchant_mode ON customers(merchant_id, mode);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_merchant_mode ON subscriptions(merchant_id, mode);
@@ -0,0 +1,37 @@
+-- Entries are chained by hash in sequence order. merchant_id has no foreign
+-- key so the log outlives anything it refers to.
+CREATE TABLE IF NOT EXISTS audit_log (
+    sequence BIGINT PRIMARY KEY,
+    id UUID NOT NULL UNIQUE,
+    merchant_id UUID,
+    actor_type VARCHAR(16) NOT NULL CHECK (actor_type IN ('user', 'api_key')),
+    actor_id TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    action TEXT NOT NULL,
+    target_type VARCHAR(32) NOT NULL DEFAULT '',
+    target_id TEXT NOT NULL DEFAULT '',
+    changes JSONB,
+    prev_hash CHAR(64) NOT NULL DEFAULT '',
+    hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_merchant_created_at ON audit_log(merchant_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor_created_at ON audit_log(actor_id, created_at);
+
+-- The log is append-only; the hash chain makes edits by anyone who bypasses
+-- this trigger evident.
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS user_recovery_codes;
+
+DELETE FROM user_tokens WHERE purpose = 'two_factor_login';
+ALTER TABLE user_tokens DROP CONSTRAINT IF EXISTS user_tokens_purpose_check;
+ALTER TABLE user_tokens ADD CONSTRAINT user_tokens_purpose_check
+    CHECK (purpose IN ('email_verification', 'password_reset'));
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS totp_last_counter,
+    DROP COLUMN IF EXISTS two_factor_enabled_at,
+    DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,19 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS two_factor_enabled_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS totp_last_counter BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE user_tokens DROP CONSTRAINT IF EXISTS user_tokens_purpose_check;
+ALTER TABLE user_tokens ADD CONSTRAINT user_tokens_purpose_check
+    CHECK (purpose IN ('email_verification', 'password_reset', 'two_factor_login'));
+
+-- One-time codes for logging in without the authenticator app. Only a hash of
+-- each code is stored.
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (user_id, code_hash)
+);
@@ -0,0 +1,10 @@
+-- Recent failed logins per account (keyed by email address) and per client
+-- IP address, for progressive delays and lockouts.
+CREATE TABLE IF NOT EXISTS login_throttles (
+    scope VARCHAR(16) NOT NULL CHECK (scope IN ('account', 'ip')),
+    throttle_key VARCHAR(255) NOT NULL,
+    failures INTEGER NOT NULL,
+    last_failed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (scope, throttle_key)
+);
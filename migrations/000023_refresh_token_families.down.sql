@@ -0,0 +1,12 @@
+DELETE FROM refresh_tokens;
+
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS rotated_at,
+    DROP COLUMN IF EXISTS token_hash,
+    DROP COLUMN IF EXISTS family_id,
+    ALTER COLUMN expires_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE refresh_tokens ADD COLUMN token VARCHAR(255) NOT NULL;
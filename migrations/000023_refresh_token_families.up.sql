@@ -0,0 +1,14 @@
+-- Raw tokens can't be assigned to families, so existing sessions end and
+-- users log in again.
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS token;
+ALTER TABLE refresh_tokens
+    ADD COLUMN family_id UUID NOT NULL,
+    ADD COLUMN token_hash CHAR(64) NOT NULL UNIQUE,
+    ADD COLUMN rotated_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN revoked_at TIMESTAMP WITH TIME ZONE,
+    ALTER COLUMN expires_at TYPE TIMESTAMP WITH TIME ZONE,
+    ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    device VARCHAR(255) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_revoked_at ON sessions(revoked_at) WHERE revoked_at IS NOT NULL;
+
+-- Every existing refresh token family becomes a session without client details.
+INSERT INTO sessions (id, user_id, created_at, last_used_at, expires_at, revoked_at)
+SELECT family_id, user_id, MIN(created_at), MAX(created_at), MAX(expires_at),
+       CASE WHEN BOOL_AND(revoked_at IS NOT NULL) THEN MAX(revoked_at) END
+FROM refresh_tokens
+GROUP BY family_id, user_id;
+
+ALTER TABLE refresh_tokens
+    ADD CONSTRAINT fk_refresh_tokens_session FOREIGN KEY (family_id) REFERENCES sessions(id);
@@ -0,0 +1,7 @@
+-- The audit log is append-only, so entries of OAuth clients stay and the old
+-- constraint only applies to new rows.
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_actor_type_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_actor_type_check CHECK (actor_type IN ('user', 'api_key')) NOT VALID;
+
+DROP TABLE IF EXISTS oauth_grants;
+DROP TABLE IF EXISTS oauth_clients;
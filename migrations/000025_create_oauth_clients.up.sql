@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id UUID PRIMARY KEY,
+    owner_id UUID NOT NULL REFERENCES users(id),
+    name VARCHAR(255) NOT NULL,
+    secret_hash CHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_clients_owner_id ON oauth_clients(owner_id);
+
+-- A merchant's consent for a client to act on its behalf.
+CREATE TABLE IF NOT EXISTS oauth_grants (
+    client_id UUID NOT NULL REFERENCES oauth_clients(id),
+    merchant_id UUID NOT NULL REFERENCES merchants(id),
+    scopes TEXT[] NOT NULL,
+    granted_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (client_id, merchant_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_grants_merchant_id ON oauth_grants(merchant_id);
+
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_actor_type_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_actor_type_check CHECK (actor_type IN ('user', 'api_key', 'oauth_client'));
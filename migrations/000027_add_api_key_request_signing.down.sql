@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS api_key_nonces;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS require_signature;
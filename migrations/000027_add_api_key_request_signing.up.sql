@@ -0,0 +1,12 @@
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS require_signature BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Nonces of signed requests, kept until their signature is too old to be
+-- accepted, so a request cannot be replayed.
+CREATE TABLE IF NOT EXISTS api_key_nonces (
+    api_key_id UUID NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    nonce VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (api_key_id, nonce)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_key_nonces_expires_at ON api_key_nonces (expires_at);
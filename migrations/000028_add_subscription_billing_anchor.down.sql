@@ -0,0 +1 @@
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_anchor_day;
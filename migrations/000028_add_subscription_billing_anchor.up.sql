@@ -0,0 +1,6 @@
+-- The day of the month billing periods end on, so a period clamped to a short
+-- month does not move every later renewal. Existing subscriptions take it from
+-- when billing started: the end of the trial, or creation.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS billing_anchor_day SMALLINT NOT NULL DEFAULT 0;
+
+UPDATE subscriptions SET billing_anchor_day = EXTRACT(DAY FROM COALESCE(trial_end, created_at));
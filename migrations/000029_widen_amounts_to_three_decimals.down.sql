@@ -0,0 +1,8 @@
+ALTER TABLE subscriptions ALTER COLUMN proration_balance TYPE DECIMAL(10, 2);
+ALTER TABLE plans ALTER COLUMN amount TYPE DECIMAL(10, 2);
+ALTER TABLE reconciliation_discrepancies ALTER COLUMN actual_amount TYPE DECIMAL(10, 2);
+ALTER TABLE reconciliation_discrepancies ALTER COLUMN expected_amount TYPE DECIMAL(10, 2);
+ALTER TABLE refunds ALTER COLUMN settlement_amount TYPE DECIMAL(10, 2);
+ALTER TABLE refunds ALTER COLUMN amount TYPE DECIMAL(10, 2);
+ALTER TABLE payments ALTER COLUMN settlement_amount TYPE DECIMAL(10, 2);
+ALTER TABLE payments ALTER COLUMN amount TYPE DECIMAL(10, 2);
@@ -0,0 +1,10 @@
+-- Amounts are kept in their currency's minor units, which go down to a
+-- thousandth for currencies such as KWD and BHD.
+ALTER TABLE payments ALTER COLUMN amount TYPE DECIMAL(11, 3);
+ALTER TABLE payments ALTER COLUMN settlement_amount TYPE DECIMAL(11, 3);
+ALTER TABLE refunds ALTER COLUMN amount TYPE DECIMAL(11, 3);
+ALTER TABLE refunds ALTER COLUMN settlement_amount TYPE DECIMAL(11, 3);
+ALTER TABLE reconciliation_discrepancies ALTER COLUMN expected_amount TYPE DECIMAL(11, 3);
+ALTER TABLE reconciliation_discrepancies ALTER COLUMN actual_amount TYPE DECIMAL(11, 3);
+ALTER TABLE plans ALTER COLUMN amount TYPE DECIMAL(11, 3);
+ALTER TABLE subscriptions ALTER COLUMN proration_balance TYPE DECIMAL(11, 3);
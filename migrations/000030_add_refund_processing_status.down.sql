@@ -0,0 +1,11 @@
+-- A processing refund may already have been sent to the acquirer, so it can
+-- be neither pending nor failed; wait until it is finished.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM refunds WHERE status = 'processing') THEN
+        RAISE EXCEPTION 'refunds are still processing';
+    END IF;
+END $$;
+
+ALTER TABLE refunds DROP CONSTRAINT IF EXISTS chk_refund_status;
+ALTER TABLE refunds ADD CONSTRAINT chk_refund_status CHECK (status IN ('pending', 'completed', 'failed', 'canceled'));
@@ -0,0 +1,2 @@
+ALTER TABLE refunds DROP CONSTRAINT IF EXISTS chk_refund_status;
+ALTER TABLE refunds ADD CONSTRAINT chk_refund_status CHECK (status IN ('pending', 'processing', 'completed', 'failed', 'canceled'));
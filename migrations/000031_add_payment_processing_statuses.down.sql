@@ -0,0 +1,12 @@
+-- A processing or voiding payment may already have reached the acquirer, so
+-- it cannot be put back; wait until it is finished.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM payments WHERE status IN ('processing', 'voiding')) THEN
+        RAISE EXCEPTION 'payments are still processing or voiding';
+    END IF;
+END $$;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payment_status;
+ALTER TABLE payments ADD CONSTRAINT chk_payment_status
+    CHECK (status IN ('pending', 'authorized', 'completed', 'failed', 'expired', 'voided'));
@@ -0,0 +1,3 @@
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payment_status;
+ALTER TABLE payments ADD CONSTRAINT chk_payment_status
+    CHECK (status IN ('pending', 'processing', 'authorized', 'voiding', 'completed', 'failed', 'expired', 'voided'));
@@ -0,0 +1,50 @@
+package signing_test
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/payment-gateway/pkg/signing"
+)
+
+func TestSigner_Sign(t *testing.T) {
+	const signingSecret = "ss_0123456789abcdef"
+	signedAt := time.Unix(1760000000, 0)
+
+	signer := signing.NewSigner("key1", signingSecret)
+	signer.Now = func() time.Time { return signedAt }
+
+	body := `{"amount":100,"currency":"USD"}`
+	r, err := http.NewRequest(http.MethodPost, "https://gateway.example/api/v1/payments?expand=transitions", strings.NewReader(body))
+	require.NoError(t, err)
+	require.NoError(t, signer.Sign(r))
+
+	assert.Equal(t, "key1", r.Header.Get(signing.HeaderKeyID))
+	assert.Equal(t, strconv.FormatInt(signedAt.Unix(), 10), r.Header.Get(signing.HeaderTimestamp))
+	assert.Empty(t, r.Header.Get("X-API-Key"), "the key is never sent")
+
+	nonce := r.Header.Get(signing.HeaderNonce)
+	require.NotEmpty(t, nonce)
+
+	sent, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(sent), "the body can still be sent")
+
+	stringToSign := signing.StringToSign("POST", "/api/v1/payments?expand=transitions", signedAt.Unix(), nonce, signing.BodyHash([]byte(body)))
+	signature := r.Header.Get(signing.HeaderSignature)
+	assert.True(t, signing.Verify([]byte(signingSecret), stringToSign, signature))
+	assert.False(t, signing.Verify([]byte("ss_other"), stringToSign, signature))
+
+	tampered := signing.StringToSign("POST", "/api/v1/payments?expand=transitions", signedAt.Unix(), nonce, signing.BodyHash([]byte(`{"amount":1}`)))
+	assert.False(t, signing.Verify([]byte(signingSecret), tampered, signature))
+
+	require.NoError(t, signer.Sign(r))
+	assert.NotEqual(t, nonce, r.Header.Get(signing.HeaderNonce), "every request gets a new nonce")
+}